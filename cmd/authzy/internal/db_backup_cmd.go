@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database/backup"
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+	"github.com/zbiljic/authzy/pkg/di"
+)
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write a backup archive of the database",
+	Long: `Write a backup archive of a consistent snapshot of the configured database.
+
+The archive is a tar file, gzip compressed by default, with a manifest, the
+data of the database, and the checksums of all files. The archive has the
+data of the default tenant, or of the tenant selected with --tenant, without
+the data of the other tenants sharing the database. The database must have the
+current schema version, see migrate-schema.
+
+While the server is running, the archive is created by the server instead,
+with a POST request to /admin/snapshot.`,
+	Example: `  authzy db backup -f authzy.tar.gz`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithDB(cmd, func(ctx context.Context, conf *config.Config) error {
+			return dbBackup(ctx, cmd, conf)
+		})
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a backup archive into the database",
+	Long: `Restore a backup archive into the configured database.
+
+The checksums of the archive are verified before anything is written, and the
+restored data is verified against the archive afterwards. The archive must be
+of the same database type, storage prefix and schema version. The data of the
+default tenant, or of the tenant selected with --tenant, must be empty, unless
+it is replaced with --force.`,
+	Example: `  authzy db restore -f authzy.tar.gz`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithDB(cmd, func(ctx context.Context, conf *config.Config) error {
+			return dbRestore(ctx, cmd, conf)
+		})
+	},
+}
+
+func init() {
+	dbBackupCmd.Flags().StringP("file", "f", stdio, "File to write to")
+	dbBackupCmd.Flags().Bool("compress", true, "Compress the archive with gzip")
+	defineTenantFlag(dbBackupCmd)
+
+	dbRestoreCmd.Flags().StringP("file", "f", "", "File to read from")
+	dbRestoreCmd.Flags().Bool("force", false, "Replace the data in the database")
+	defineTenantFlag(dbRestoreCmd)
+	_ = dbRestoreCmd.MarkFlagRequired("file")
+
+	dbCmd.AddCommand(dbBackupCmd, dbRestoreCmd)
+}
+
+func dbBackup(ctx context.Context, cmd *cobra.Command, conf *config.Config) error {
+	file, _ := cmd.Flags().GetString("file")
+	compress, _ := cmd.Flags().GetBool("compress")
+
+	conf, err := forTenant(cmd, conf)
+	if err != nil {
+		return err
+	}
+
+	db, closeDB, err := openBackupDatabase(conf)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	// as the server, only a database with the current schema is read
+	schemaVersion, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := schemamigrate.CheckVersion(schemaVersion); err != nil {
+		return fmt.Errorf("%w, run: authzy db migrate-schema", err)
+	}
+
+	w := cmd.OutOrStdout()
+	// the report is not mixed with the archive
+	out := cmd.ErrOrStderr()
+
+	if file != stdio {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w = f
+		out = cmd.OutOrStdout()
+	}
+
+	m, err := backup.Backup(ctx, db, w, backup.Options{Compress: compress})
+	if err != nil {
+		if file != stdio {
+			os.Remove(file)
+		}
+		return err
+	}
+
+	return printManifest(out, dbOutput, "Backed up", m)
+}
+
+func dbRestore(ctx context.Context, cmd *cobra.Command, conf *config.Config) error {
+	file, _ := cmd.Flags().GetString("file")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if file == stdio {
+		return errors.New("--file must be a file, the archive is read twice")
+	}
+
+	conf, err := forTenant(cmd, conf)
+	if err != nil {
+		return err
+	}
+
+	db, closeDB, err := openBackupDatabase(conf)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	open := func() (io.ReadCloser, error) {
+		return os.Open(file)
+	}
+
+	m, err := backup.Restore(ctx, db, open, backup.RestoreOptions{Force: force})
+	if err != nil {
+		return err
+	}
+
+	return printManifest(cmd.OutOrStdout(), dbOutput, "Restored", m)
+}
+
+// openBackupDatabase opens the configured database, and returns it with the
+// function closing it.
+func openBackupDatabase(conf *config.Config) (backup.Database, func(), error) {
+	dbConf := conf.Database
+
+	result, err := di.ProvideDatabase(di.DatabaseParams{
+		Type:              dbConf.Type,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeDB := func() {
+		if result.LevelDB != nil {
+			result.LevelDB.Close()
+		}
+		if result.SQLite != nil {
+			result.SQLite.Close()
+		}
+		if result.BBolt != nil {
+			result.BBolt.Close()
+		}
+	}
+
+	db, err := di.ProvideBackupDatabase(di.BackupDatabaseParams{
+		Type:              dbConf.Type,
+		Config:            conf,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     result.JSONLoadSaver,
+		LevelDB:           result.LevelDB,
+		SQLite:            result.SQLite,
+		BBolt:             result.BBolt,
+	})
+	if err != nil {
+		closeDB()
+		return nil, nil, err
+	}
+
+	return db, closeDB, nil
+}
+
+func printManifest(w io.Writer, format string, action string, m *backup.Manifest) error {
+	if format == outputJSON {
+		return printJSON(w, m)
+	}
+
+	_, err := fmt.Fprintf(w, "%s %s database, schema version %d, created at %s\n",
+		action, m.DatabaseType, m.SchemaVersion, m.CreatedAt.Format(time.RFC3339))
+	return err
+}
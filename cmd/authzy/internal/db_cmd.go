@@ -0,0 +1,519 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/authzy/pkg/config"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/database/migrate"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/di"
+	account_di "github.com/zbiljic/authzy/pkg/domain/account/di"
+	auditlog_di "github.com/zbiljic/authzy/pkg/domain/auditlog/di"
+	organization_di "github.com/zbiljic/authzy/pkg/domain/organization/di"
+	policy_di "github.com/zbiljic/authzy/pkg/domain/policy/di"
+	refreshtoken_di "github.com/zbiljic/authzy/pkg/domain/refreshtoken/di"
+	relationtuple_di "github.com/zbiljic/authzy/pkg/domain/relationtuple/di"
+	role_di "github.com/zbiljic/authzy/pkg/domain/role/di"
+	user_di "github.com/zbiljic/authzy/pkg/domain/user/di"
+	webhook_di "github.com/zbiljic/authzy/pkg/domain/webhook/di"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the datastore",
+	Long: `Manage the datastore, without starting the API server.
+
+The datastore should not be used by a running server at the same time.`,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move the data to another database backend",
+	Long: `Move the data to another database backend, both configured in the
+database section of the configuration.
+
+Every entity, including soft deleted users and their history, is copied page
+by page, and the indexes of the target are built as they are saved. The number of entities in both
+databases is compared at the end. The target must be empty when the migration
+starts.
+
+With a checkpoint the progress is saved after every page, and an interrupted
+migration is resumed by running the same command again.
+
+The default tenant is migrated first, followed by every configured tenant.
+The progress of a tenant is kept in the checkpoint file with the ID of the
+tenant appended.`,
+	Example: `  authzy db migrate --from jsonmutexdb --to leveldb --checkpoint migrate.json`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithDB(cmd, func(ctx context.Context, conf *config.Config) error {
+			return dbMigrate(ctx, cmd, conf)
+		})
+	},
+}
+
+var dbOutput string
+
+func init() {
+	dbCmd.PersistentFlags().StringVarP(&dbOutput, "output", "o", outputTable, "Output format (table|json)")
+
+	dbMigrateCmd.Flags().String("from", "", "Type of the database to read from")
+	dbMigrateCmd.Flags().String("to", "", "Type of the database to write to")
+	dbMigrateCmd.Flags().String("checkpoint", "", "File to keep the progress in")
+	dbMigrateCmd.Flags().Int("page-size", migrate.DefaultPageSize, "Number of entities read at once")
+	_ = dbMigrateCmd.MarkFlagRequired("from")
+	_ = dbMigrateCmd.MarkFlagRequired("to")
+
+	dbCmd.AddCommand(dbMigrateCmd)
+
+	rootCmd.AddCommand(dbCmd)
+}
+
+func execWithDB(cmd *cobra.Command, fn func(context.Context, *config.Config) error) error {
+	// the arguments were parsed, errors from here on are not usage errors
+	cmd.SilenceUsage = true
+
+	if dbOutput != outputTable && dbOutput != outputJSON {
+		return fmt.Errorf("invalid output format: %s", dbOutput)
+	}
+
+	return execWithConfig(cmd, func(conf *config.Config) error {
+		return fn(context.Background(), conf)
+	})
+}
+
+func defineTenantFlag(cmd *cobra.Command) {
+	cmd.Flags().String("tenant", "", "ID of the tenant, instead of the default tenant")
+}
+
+// forTenant returns the configuration of the tenant selected with the flag,
+// or the configuration itself for the default tenant.
+func forTenant(cmd *cobra.Command, conf *config.Config) (*config.Config, error) {
+	id, _ := cmd.Flags().GetString("tenant")
+	if id == "" {
+		return conf, nil
+	}
+
+	for _, t := range conf.Tenants {
+		if t.ID == id {
+			return conf.ForTenant(t)
+		}
+	}
+
+	return nil, fmt.Errorf("unknown tenant: %s", id)
+}
+
+// tenantConfig is the configuration of a tenant, with an empty ID for the
+// default tenant.
+type tenantConfig struct {
+	ID     string
+	Config *config.Config
+}
+
+// allTenants returns the configuration of the default tenant, followed by the
+// configurations of the configured tenants.
+func allTenants(conf *config.Config) ([]*tenantConfig, error) {
+	tenants := []*tenantConfig{{Config: conf}}
+
+	for _, t := range conf.Tenants {
+		tenantConf, err := conf.ForTenant(t)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", t.ID, err)
+		}
+
+		tenants = append(tenants, &tenantConfig{ID: t.ID, Config: tenantConf})
+	}
+
+	return tenants, nil
+}
+
+func dbMigrate(ctx context.Context, cmd *cobra.Command, conf *config.Config) error {
+	fromType, _ := cmd.Flags().GetString("from")
+	toType, _ := cmd.Flags().GetString("to")
+	checkpoint, _ := cmd.Flags().GetString("checkpoint")
+	pageSize, _ := cmd.Flags().GetInt("page-size")
+
+	if fromType == toType {
+		return errors.New("--from and --to must be different database types")
+	}
+
+	tenants, err := allTenants(conf)
+	if err != nil {
+		return err
+	}
+
+	fromDB, closeFrom, err := openMigrateDatabase(conf, fromType)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", fromType, err)
+	}
+	defer closeFrom()
+
+	toDB, closeTo, err := openMigrateDatabase(conf, toType)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", toType, err)
+	}
+	defer closeTo()
+
+	var (
+		results    []*migrateResultOutput
+		migrateErr error
+	)
+
+	// the tenants share the database, their repositories are limited to
+	// their storage prefixes
+	for _, t := range tenants {
+		tenantResults, err := dbMigrateTenant(ctx, t, fromType, fromDB, toType, toDB, checkpoint, pageSize)
+		results = append(results, newMigrateResultOutputs(t.ID, tenantResults)...)
+		if err != nil {
+			migrateErr = err
+			if t.ID != "" {
+				migrateErr = fmt.Errorf("tenant %s: %w", t.ID, err)
+			}
+
+			break
+		}
+	}
+
+	if len(results) > 0 {
+		if err := printMigrateResults(cmd.OutOrStdout(), dbOutput, results); err != nil {
+			return err
+		}
+	}
+
+	return migrateErr
+}
+
+func dbMigrateTenant(
+	ctx context.Context,
+	t *tenantConfig,
+	fromType string,
+	fromDB di.DatabaseResult,
+	toType string,
+	toDB di.DatabaseResult,
+	checkpoint string,
+	pageSize int,
+) ([]*migrate.Result, error) {
+	if checkpoint != "" && t.ID != "" {
+		checkpoint += "." + t.ID
+	}
+
+	cp := &migrate.Checkpoint{}
+
+	if checkpoint != "" {
+		var err error
+
+		cp, err = migrate.LoadCheckpoint(checkpoint)
+		if err != nil {
+			return nil, fmt.Errorf("load checkpoint: %w", err)
+		}
+	}
+
+	from, err := newRepositories(fromType, t.Config.Database, fromDB)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", fromType, err)
+	}
+
+	to, err := newRepositories(toType, t.Config.Database, toDB)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", toType, err)
+	}
+
+	migrator := &migrate.Migrator{
+		From:     from,
+		To:       to,
+		PageSize: pageSize,
+	}
+
+	if checkpoint != "" {
+		migrator.SaveCheckpoint = func(cp *migrate.Checkpoint) error {
+			return cp.Save(checkpoint)
+		}
+	}
+
+	return migrator.Migrate(ctx, cp)
+}
+
+// openMigrateDatabase opens the database of the type, as configured, and
+// returns it with the function closing it.
+func openMigrateDatabase(conf *config.Config, dbType string) (di.DatabaseResult, func(), error) {
+	dbConf := conf.Database
+
+	switch dbType {
+	case database_jsonmutexdb.Type:
+		if dbConf.JSONMutexDB == nil || dbConf.JSONMutexDB.DataDir == "" {
+			return di.DatabaseResult{}, nil, errors.New("data dir is not configured")
+		}
+	case database_leveldb.Type:
+		if dbConf.LevelDB == nil || dbConf.LevelDB.DataDir == "" {
+			return di.DatabaseResult{}, nil, errors.New("data dir is not configured")
+		}
+	case database_sqlite.Type:
+		if dbConf.SQLite == nil || dbConf.SQLite.DataDir == "" {
+			return di.DatabaseResult{}, nil, errors.New("data dir is not configured")
+		}
+	case database_bbolt.Type:
+		if dbConf.BBolt == nil || dbConf.BBolt.DataDir == "" {
+			return di.DatabaseResult{}, nil, errors.New("data dir is not configured")
+		}
+	default:
+		return di.DatabaseResult{}, nil, fmt.Errorf("invalid database type: %s", dbType)
+	}
+
+	db, err := di.ProvideDatabase(di.DatabaseParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+	})
+	if err != nil {
+		return di.DatabaseResult{}, nil, err
+	}
+
+	closeDB := func() {
+		if db.LevelDB != nil {
+			db.LevelDB.Close()
+		}
+		if db.SQLite != nil {
+			db.SQLite.Close()
+		}
+		if db.BBolt != nil {
+			db.BBolt.Close()
+		}
+	}
+
+	return db, closeDB, nil
+}
+
+func newRepositories(dbType string, dbConf *config.DatabaseConfig, db di.DatabaseResult) (*migrate.Repositories, error) {
+	users, err := user_di.NewUserRepository(user_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userVersions, err := user_di.NewUserVersionRepository(user_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := account_di.NewAccountRepository(account_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokens, err := refreshtoken_di.NewRefreshTokenRepository(refreshtoken_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := role_di.NewRoleRepository(role_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	organizations, err := organization_di.NewOrganizationRepository(organization_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := organization_di.NewInvitationRepository(organization_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := policy_di.NewPolicyRepository(policy_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	relationTuples, err := relationtuple_di.NewRelationTupleRepository(relationtuple_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog, err := auditlog_di.NewAuditLogRepository(auditlog_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	webhookDeliveries, err := webhook_di.NewDeliveryRepository(webhook_di.RepositoryParams{
+		Type:              dbType,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+		JSONLoadSaver:     db.JSONLoadSaver,
+		LevelDB:           db.LevelDB,
+		SQLite:            db.SQLite,
+		BBolt:             db.BBolt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrate.Repositories{
+		Users:             users,
+		UserVersions:      userVersions,
+		Accounts:          accounts,
+		RefreshTokens:     refreshTokens,
+		Roles:             roles,
+		Organizations:     organizations,
+		Invitations:       invitations,
+		Policies:          policies,
+		RelationTuples:    relationTuples,
+		AuditLog:          auditLog,
+		WebhookDeliveries: webhookDeliveries,
+	}, nil
+}
+
+type migrateResultOutput struct {
+	Tenant      string `json:"tenant,omitempty"`
+	Entity      string `json:"entity"`
+	Copied      int    `json:"copied"`
+	SourceCount int    `json:"source_count"`
+	TargetCount int    `json:"target_count"`
+}
+
+func newMigrateResultOutputs(tenant string, results []*migrate.Result) []*migrateResultOutput {
+	out := make([]*migrateResultOutput, 0, len(results))
+	for _, r := range results {
+		out = append(out, &migrateResultOutput{
+			Tenant:      tenant,
+			Entity:      r.Entity,
+			Copied:      r.Copied,
+			SourceCount: r.SourceCount,
+			TargetCount: r.TargetCount,
+		})
+	}
+
+	return out
+}
+
+func printMigrateResults(w io.Writer, format string, out []*migrateResultOutput) error {
+	if format == outputJSON {
+		return printJSON(w, out)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "TENANT\tENTITY\tCOPIED\tSOURCE\tTARGET")
+	for _, r := range out {
+		tenant := r.Tenant
+		if tenant == "" {
+			tenant = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", tenant, r.Entity, r.Copied, r.SourceCount, r.TargetCount)
+	}
+
+	return tw.Flush()
+}
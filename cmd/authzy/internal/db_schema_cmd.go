@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+	"github.com/zbiljic/authzy/pkg/di"
+)
+
+var dbMigrateSchemaCmd = &cobra.Command{
+	Use:   "migrate-schema",
+	Short: "Migrate the database to the current schema version",
+	Long: `Migrate the stored entities of the configured database to the current schema
+version, and record the version.
+
+The server migrates the database at startup, unless database.migrate_schema
+is disabled. The dry run reports the number of entities every migration
+would change, without writing anything.
+
+The default tenant is migrated first, followed by every configured tenant.`,
+	Example: `  authzy db migrate-schema --dry-run`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithDB(cmd, func(ctx context.Context, conf *config.Config) error {
+			return dbMigrateSchema(ctx, cmd, conf)
+		})
+	},
+}
+
+func init() {
+	dbMigrateSchemaCmd.Flags().Bool("dry-run", false, "Report the changes without writing anything")
+
+	dbCmd.AddCommand(dbMigrateSchemaCmd)
+}
+
+func dbMigrateSchema(ctx context.Context, cmd *cobra.Command, conf *config.Config) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	tenants, err := allTenants(conf)
+	if err != nil {
+		return err
+	}
+
+	dbConf := conf.Database
+
+	db, err := di.ProvideDatabase(di.DatabaseParams{
+		Type:              dbConf.Type,
+		JSONMutexDBConfig: dbConf.JSONMutexDB,
+		LevelDBConfig:     dbConf.LevelDB,
+		SQLiteConfig:      dbConf.SQLite,
+		BBoltConfig:       dbConf.BBolt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if db.LevelDB != nil {
+		defer db.LevelDB.Close()
+	}
+
+	if db.SQLite != nil {
+		defer db.SQLite.Close()
+	}
+
+	if db.BBolt != nil {
+		defer db.BBolt.Close()
+	}
+
+	// the tenants share the database, every one has the schema version of
+	// its own storage prefix
+	for _, t := range tenants {
+		if err := dbMigrateSchemaTenant(ctx, cmd, t, db, dryRun); err != nil {
+			if t.ID != "" {
+				return fmt.Errorf("tenant %s: %w", t.ID, err)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dbMigrateSchemaTenant(ctx context.Context, cmd *cobra.Command, t *tenantConfig, db di.DatabaseResult, dryRun bool) error {
+	store, err := di.NewSchemaStore(t.Config.Database, db)
+	if err != nil {
+		return err
+	}
+
+	m := &schemamigrate.Migrator{
+		Store:  store,
+		DryRun: dryRun,
+	}
+
+	result, err := m.Migrate(ctx)
+
+	if result != nil {
+		if err := printMigrateSchemaResult(cmd.OutOrStdout(), dbOutput, t.ID, result); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+type migrateSchemaOutput struct {
+	Tenant      string                       `json:"tenant,omitempty"`
+	FromVersion int                          `json:"from_version"`
+	ToVersion   int                          `json:"to_version"`
+	DryRun      bool                         `json:"dry_run"`
+	Changes     []*migrateSchemaChangeOutput `json:"changes"`
+}
+
+type migrateSchemaChangeOutput struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Entity      string `json:"entity"`
+	Changed     int    `json:"changed"`
+	Total       int    `json:"total"`
+}
+
+func printMigrateSchemaResult(w io.Writer, format, tenant string, result *schemamigrate.Result) error {
+	out := &migrateSchemaOutput{
+		Tenant:      tenant,
+		FromVersion: result.FromVersion,
+		ToVersion:   result.ToVersion,
+		DryRun:      result.DryRun,
+		Changes:     make([]*migrateSchemaChangeOutput, 0, len(result.Changes)),
+	}
+	for _, c := range result.Changes {
+		out.Changes = append(out.Changes, &migrateSchemaChangeOutput{
+			Version:     c.Version,
+			Description: c.Description,
+			Entity:      c.Entity,
+			Changed:     c.Changed,
+			Total:       c.Total,
+		})
+	}
+
+	if format == outputJSON {
+		return printJSON(w, out)
+	}
+
+	action := "Migrated"
+	if out.DryRun {
+		action = "Dry run, would migrate"
+	}
+
+	var of string
+	if out.Tenant != "" {
+		of = " of tenant " + out.Tenant
+	}
+
+	fmt.Fprintf(w, "%s schema version %d to %d%s\n", action, out.FromVersion, out.ToVersion, of)
+
+	if len(out.Changes) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "VERSION\tENTITY\tCHANGED\tTOTAL\tDESCRIPTION")
+	for _, c := range out.Changes {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\n", c.Version, c.Entity, c.Changed, c.Total, c.Description)
+	}
+
+	return tw.Flush()
+}
@@ -4,7 +4,7 @@ import (
 	"github.com/spf13/viper"
 )
 
-//nolint
+// nolint
 const (
 	// exitCodeOK is a 0 exit code.
 	exitCodeOK = iota
@@ -0,0 +1,456 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/cobra"
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/di"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/logger"
+	"github.com/zbiljic/authzy/pkg/logger/zlogger"
+)
+
+// cliActor is recorded in the user history for changes made by the commands.
+const cliActor = "cli"
+
+// usersPageSize is the number of users requested per page when listing.
+const usersPageSize = 100
+
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage users directly in the datastore",
+	Long: `Manage users directly in the datastore, without starting the API server.
+
+The commands are meant for break-glass situations and scripts. The datastore
+should not be used by a running server at the same time. The webhook events
+are queued, and delivered once the server is started.`,
+}
+
+var usersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUsers(cmd, func(ctx context.Context, deps *usersDeps) error {
+			return usersList(ctx, cmd, deps)
+		})
+	},
+}
+
+var usersGetCmd = &cobra.Command{
+	Use:   "get <id|email|username>",
+	Short: "Show user details",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUsers(cmd, func(ctx context.Context, deps *usersDeps) error {
+			u, err := findUser(ctx, deps, args[0])
+			if err != nil {
+				return err
+			}
+
+			accounts, err := deps.Account.FindAllForUser(ctx, u.ID)
+			if err != nil {
+				return fmt.Errorf("find accounts: %w", err)
+			}
+
+			return printUser(cmd.OutOrStdout(), usersOutput, u, accounts)
+		})
+	},
+}
+
+var usersCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a user with a password",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := readPassword(cmd)
+		if err != nil {
+			return err
+		}
+
+		return execWithUsers(cmd, func(ctx context.Context, deps *usersDeps) error {
+			return usersCreate(ctx, cmd, deps, password)
+		})
+	},
+}
+
+var usersSetPasswordCmd = &cobra.Command{
+	Use:   "set-password <id|email|username>",
+	Short: "Set the password of a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := readPassword(cmd)
+		if err != nil {
+			return err
+		}
+
+		return execWithUser(cmd, args[0], func(ctx context.Context, deps *usersDeps, u *user.User) (*user.User, error) {
+			u, err := deps.API.UpdatePassword(ctx, u, []byte(password))
+			if err != nil {
+				return nil, fmt.Errorf("set password: %w", err)
+			}
+
+			return u, nil
+		})
+	},
+}
+
+var usersConfirmCmd = &cobra.Command{
+	Use:   "confirm <id|email|username>",
+	Short: "Confirm the email of a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUser(cmd, args[0], func(ctx context.Context, deps *usersDeps, u *user.User) (*user.User, error) {
+			u, err := deps.API.ConfirmUser(ctx, u)
+			if err != nil {
+				return nil, fmt.Errorf("confirm user: %w", err)
+			}
+
+			return u, nil
+		})
+	},
+}
+
+var usersBlockCmd = &cobra.Command{
+	Use:   "block <id|email|username>",
+	Short: "Block a user and revoke its refresh tokens",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUser(cmd, args[0], func(ctx context.Context, deps *usersDeps, u *user.User) (*user.User, error) {
+			u, err := deps.API.BlockUser(ctx, u)
+			if err != nil {
+				return nil, apiError("block user", err)
+			}
+
+			return u, nil
+		})
+	},
+}
+
+var usersUnblockCmd = &cobra.Command{
+	Use:   "unblock <id|email|username>",
+	Short: "Allow a blocked user to sign in again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUser(cmd, args[0], func(ctx context.Context, deps *usersDeps, u *user.User) (*user.User, error) {
+			u, err := deps.API.UnblockUser(ctx, u)
+			if err != nil {
+				return nil, apiError("unblock user", err)
+			}
+
+			return u, nil
+		})
+	},
+}
+
+var usersDeleteCmd = &cobra.Command{
+	Use:   "delete <id|email|username>",
+	Short: "Delete a user together with its sessions, roles, memberships and accounts",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUsers(cmd, func(ctx context.Context, deps *usersDeps) error {
+			return usersDelete(ctx, cmd, deps, args[0])
+		})
+	},
+}
+
+var usersLogoutCmd = &cobra.Command{
+	Use:   "logout <id|email|username>",
+	Short: "Revoke all refresh tokens of a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUsers(cmd, func(ctx context.Context, deps *usersDeps) error {
+			u, err := findUser(ctx, deps, args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := deps.API.LogoutUser(ctx, u); err != nil {
+				return apiError("logout", err)
+			}
+
+			return printMessage(cmd.OutOrStdout(), usersOutput, "Logged out user %s", u.ID)
+		})
+	},
+}
+
+var usersOutput string
+
+func init() {
+	usersCmd.PersistentFlags().StringVarP(&usersOutput, "output", "o", outputTable, "Output format (table|json)")
+
+	usersListCmd.Flags().Int("limit", 0, "Maximum number of users to list (0 lists all)")
+	usersListCmd.Flags().String("cursor", "", "List users after the cursor")
+
+	usersCreateCmd.Flags().String("email", "", "Email address of the user")
+	usersCreateCmd.Flags().String("username", "", "Username of the user")
+	usersCreateCmd.Flags().String("name", "", "Full name of the user")
+	usersCreateCmd.Flags().Bool("confirmed", false, "Mark the email address as verified")
+	definePasswordFlags(usersCreateCmd)
+	_ = usersCreateCmd.MarkFlagRequired("email")
+	_ = usersCreateCmd.MarkFlagRequired("username")
+
+	definePasswordFlags(usersSetPasswordCmd)
+
+	usersDeleteCmd.Flags().Bool("purge", false, "Irreversibly delete the user together with its history")
+
+	usersCmd.AddCommand(
+		usersListCmd,
+		usersGetCmd,
+		usersCreateCmd,
+		usersSetPasswordCmd,
+		usersConfirmCmd,
+		usersBlockCmd,
+		usersUnblockCmd,
+		usersDeleteCmd,
+		usersLogoutCmd,
+	)
+
+	rootCmd.AddCommand(usersCmd)
+}
+
+type usersDeps struct {
+	fx.In
+
+	Validator  *validator.Validate
+	Transactor database.Transactor
+
+	// API shares the operations which change the users, so they notify
+	// about the changes in the same way as the admin endpoints
+	API api.Service
+
+	User    user.UserUsecase
+	Account account.AccountUsecase
+
+	LevelDB *leveldb.DB         `optional:"true"`
+	SQLite  *database_sqlite.DB `optional:"true"`
+	BBolt   *database_bbolt.DB  `optional:"true"`
+}
+
+// execWithUsers builds the domain usecases on top of the configured
+// datastore, without starting the servers, and runs the function with them.
+func execWithUsers(cmd *cobra.Command, fn func(context.Context, *usersDeps) error) error {
+	// the arguments were parsed, errors from here on are not usage errors
+	cmd.SilenceUsage = true
+
+	if usersOutput != outputTable && usersOutput != outputJSON {
+		return fmt.Errorf("invalid output format: %s", usersOutput)
+	}
+
+	return execWithConfig(cmd, func(conf *config.Config) error {
+		// only problems are logged, the output is reserved for the results
+		logConfig := *conf.Logger
+		logConfig.Level = logger.WarnLevel
+
+		log, err := zlogger.New(&logConfig)
+		if err != nil {
+			return fmt.Errorf("error creating logger: %w", err)
+		}
+
+		var deps usersDeps
+
+		app := fx.New(
+			fx.Supply(conf),
+			fx.NopLogger,
+			fx.Provide(func() logger.Logger { return log }),
+			di.CommandModule,
+			fx.Populate(&deps),
+		)
+		if err := app.Err(); err != nil {
+			return err
+		}
+
+		if deps.LevelDB != nil {
+			defer deps.LevelDB.Close()
+		}
+
+		if deps.SQLite != nil {
+			defer deps.SQLite.Close()
+		}
+
+		if deps.BBolt != nil {
+			defer deps.BBolt.Close()
+		}
+
+		ctx := user.NewActorContext(context.Background(), cliActor)
+
+		return fn(ctx, &deps)
+	})
+}
+
+// execWithUser runs the function with the user identified by the argument,
+// and prints the user it returns.
+func execWithUser(
+	cmd *cobra.Command,
+	identifier string,
+	fn func(context.Context, *usersDeps, *user.User) (*user.User, error),
+) error {
+	return execWithUsers(cmd, func(ctx context.Context, deps *usersDeps) error {
+		u, err := findUser(ctx, deps, identifier)
+		if err != nil {
+			return err
+		}
+
+		u, err = fn(ctx, deps, u)
+		if err != nil {
+			return err
+		}
+
+		return printUser(cmd.OutOrStdout(), usersOutput, u, nil)
+	})
+}
+
+// findUser finds the user by ID, and then by email or username.
+func findUser(ctx context.Context, deps *usersDeps, identifier string) (*user.User, error) {
+	u, err := deps.User.FindUserByID(ctx, identifier)
+	if err == nil {
+		return u, nil
+	}
+	if !errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("find user: %w", err)
+	}
+
+	u, err = deps.User.FindUserByEmail(ctx, strings.ToLower(identifier))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, fmt.Errorf("user not found: %s", identifier)
+		}
+
+		return nil, fmt.Errorf("find user: %w", err)
+	}
+
+	return u, nil
+}
+
+func usersList(ctx context.Context, cmd *cobra.Command, deps *usersDeps) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit < 0 {
+		return fmt.Errorf("invalid limit: %d", limit)
+	}
+
+	cursor, _ := cmd.Flags().GetString("cursor")
+
+	var users []*user.User
+
+	for {
+		pageSize := usersPageSize
+		if limit > 0 && limit-len(users) < pageSize {
+			pageSize = limit - len(users)
+		}
+
+		page, nextCursor, err := deps.User.FindAllUsers(ctx, cursor, pageSize)
+		if err != nil {
+			return fmt.Errorf("find users: %w", err)
+		}
+
+		users = append(users, page...)
+		cursor = nextCursor
+
+		if cursor == "" || (limit > 0 && len(users) >= limit) {
+			break
+		}
+	}
+
+	return printUsers(cmd.OutOrStdout(), usersOutput, users, cursor)
+}
+
+func usersCreate(ctx context.Context, cmd *cobra.Command, deps *usersDeps, password string) error {
+	email, _ := cmd.Flags().GetString("email")
+	username, _ := cmd.Flags().GetString("username")
+	name, _ := cmd.Flags().GetString("name")
+	confirmed, _ := cmd.Flags().GetBool("confirmed")
+
+	if err := deps.Validator.Var(email, "required,email"); err != nil {
+		return fmt.Errorf("invalid email address: %q", email)
+	}
+
+	// the user is created together with its password account, or not at all
+	u, passwordAccount, err := deps.API.CreateUser(ctx, &user.User{
+		Email:    email,
+		Username: username,
+		Name:     name,
+	}, []byte(password), confirmed)
+	if err != nil {
+		return apiError("create user", err)
+	}
+
+	return printUser(cmd.OutOrStdout(), usersOutput, u, []*account.Account{passwordAccount})
+}
+
+func usersDelete(ctx context.Context, cmd *cobra.Command, deps *usersDeps, identifier string) error {
+	purge, _ := cmd.Flags().GetBool("purge")
+
+	u, err := findUser(ctx, deps, identifier)
+	if err != nil {
+		return err
+	}
+
+	// a soft deleted user keeps its roles, memberships and accounts, so it
+	// can be restored
+	if err := deps.API.DeleteUser(ctx, u, purge); err != nil {
+		return apiError("delete user", err)
+	}
+
+	return printMessage(cmd.OutOrStdout(), usersOutput, "Deleted user %s", u.ID)
+}
+
+// apiError returns the cause of the error of the API operation, instead of
+// its HTTP status.
+func apiError(op string, err error) error {
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.InternalError != nil {
+			err = httpErr.InternalError
+		} else {
+			err = errors.New(httpErr.Message)
+		}
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func definePasswordFlags(cmd *cobra.Command) {
+	cmd.Flags().String("password", "", "Password of the user")
+	cmd.Flags().Bool("password-stdin", false, "Read the password from the first line of stdin")
+}
+
+// readPassword reads the password from the flag, or from stdin, which keeps
+// it out of the shell history.
+func readPassword(cmd *cobra.Command) (string, error) {
+	password, _ := cmd.Flags().GetString("password")
+	fromStdin, _ := cmd.Flags().GetBool("password-stdin")
+
+	if fromStdin {
+		if password != "" {
+			return "", errors.New("--password and --password-stdin are mutually exclusive")
+		}
+
+		line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("read password: %w", err)
+		}
+
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	if password == "" {
+		return "", errors.New("password is required, use --password or --password-stdin")
+	}
+
+	return password, nil
+}
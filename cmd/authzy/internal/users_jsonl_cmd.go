@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/importers"
+	"github.com/zbiljic/authzy/pkg/domain/user/jsonl"
+)
+
+// stdio is the file name of the standard input or output.
+const stdio = "-"
+
+var usersExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export users with their accounts in JSON Lines",
+	Long: `Export users with their accounts in JSON Lines, one user per line.
+
+With a checkpoint the progress is saved after every page, and an interrupted
+export is resumed by running the same command again. Users created since are
+appended when the export is run again after it finished.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUsers(cmd, func(ctx context.Context, deps *usersDeps) error {
+			return usersExport(ctx, cmd, deps)
+		})
+	},
+}
+
+var usersImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import users with their accounts",
+	Long: `Import users with their accounts from JSON Lines, one user per line, as
+written by the export, or from the user export of another identity provider:
+
+  auth0     Auth0 bulk user export, with the password hashes
+  gotrue    rows of the GoTrue (Supabase) auth.users table
+  keycloak  Keycloak realm export
+
+Password hashes are kept, users of other providers without a supported hash
+are reported as skipped or failed. The position of the users which are not
+read from lines is their index in the export.
+
+Users are saved in batches. With a checkpoint the progress is saved after
+every batch, and an interrupted import is resumed by running the same command
+again. The dry run validates all users and resolves the conflicts without
+saving anything. The report lists the skipped and failed users.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execWithUsers(cmd, func(ctx context.Context, deps *usersDeps) error {
+			return usersImport(ctx, cmd, deps)
+		})
+	},
+}
+
+func init() {
+	usersExportCmd.Flags().StringP("file", "f", stdio, "File to write to")
+	usersExportCmd.Flags().String("checkpoint", "", "File to keep the progress in")
+	usersExportCmd.Flags().Int("page-size", jsonl.DefaultPageSize, "Number of users read at once")
+
+	usersImportCmd.Flags().StringP("file", "f", stdio, "File to read from")
+	usersImportCmd.Flags().String("format", importers.FormatJSONL, "Format of the file ("+strings.Join(importers.Formats, "|")+")")
+	usersImportCmd.Flags().String("checkpoint", "", "File to keep the progress in")
+	usersImportCmd.Flags().Int("batch-size", jsonl.DefaultBatchSize, "Number of users saved at once")
+	usersImportCmd.Flags().String("on-conflict", string(user.ConflictFail), "Handling of existing users (skip|overwrite|fail)")
+	usersImportCmd.Flags().Bool("dry-run", false, "Validate without saving anything")
+	usersImportCmd.Flags().Bool("keep-going", false, "Report failed users instead of stopping at the first one")
+
+	usersCmd.AddCommand(usersExportCmd, usersImportCmd)
+}
+
+func usersExport(ctx context.Context, cmd *cobra.Command, deps *usersDeps) error {
+	file, _ := cmd.Flags().GetString("file")
+	checkpoint, _ := cmd.Flags().GetString("checkpoint")
+	pageSize, _ := cmd.Flags().GetInt("page-size")
+
+	if checkpoint != "" && file == stdio {
+		return errors.New("--checkpoint requires --file")
+	}
+
+	cp := &jsonl.Checkpoint{}
+
+	if checkpoint != "" {
+		var err error
+
+		cp, err = jsonl.LoadCheckpoint(checkpoint)
+		if err != nil {
+			return fmt.Errorf("load checkpoint: %w", err)
+		}
+	}
+
+	w := cmd.OutOrStdout()
+
+	if file != stdio {
+		f, err := openExportFile(file, cp.Offset)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	exporter := &jsonl.Exporter{
+		Users:    deps.User,
+		Accounts: deps.Account,
+		PageSize: pageSize,
+	}
+
+	if checkpoint != "" {
+		exporter.SaveCheckpoint = func(cp *jsonl.Checkpoint) error {
+			return cp.Save(checkpoint)
+		}
+	}
+
+	count, err := exporter.Export(ctx, w, cp)
+	if err != nil {
+		return err
+	}
+
+	if file == stdio {
+		return nil
+	}
+
+	return printMessage(cmd.OutOrStdout(), usersOutput, "Exported %d users", count)
+}
+
+// openExportFile opens the file, keeping the part already exported.
+func openExportFile(name string, offset int64) (*os.File, error) {
+	if offset == 0 {
+		return os.Create(name)
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func usersImport(ctx context.Context, cmd *cobra.Command, deps *usersDeps) error {
+	file, _ := cmd.Flags().GetString("file")
+	format, _ := cmd.Flags().GetString("format")
+	checkpoint, _ := cmd.Flags().GetString("checkpoint")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	onConflict, _ := cmd.Flags().GetString("on-conflict")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	keepGoing, _ := cmd.Flags().GetBool("keep-going")
+
+	opts := user.ImportOptions{
+		OnConflict: user.ConflictPolicy(onConflict),
+		DryRun:     dryRun,
+	}
+
+	if err := opts.OnConflict.IsValid(); err != nil {
+		return err
+	}
+
+	cp := &jsonl.Checkpoint{}
+
+	if checkpoint != "" {
+		var err error
+
+		cp, err = jsonl.LoadCheckpoint(checkpoint)
+		if err != nil {
+			return fmt.Errorf("load checkpoint: %w", err)
+		}
+	}
+
+	r := cmd.InOrStdin()
+
+	if file != stdio {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	src, err := importers.NewSource(format, r)
+	if err != nil {
+		return err
+	}
+
+	importer := &jsonl.Importer{
+		Users:      deps.User,
+		Accounts:   deps.Account,
+		Transactor: deps.Transactor,
+		Options:    opts,
+		KeepGoing:  keepGoing,
+		BatchSize:  batchSize,
+	}
+
+	if checkpoint != "" && !dryRun {
+		importer.SaveCheckpoint = func(cp *jsonl.Checkpoint) error {
+			return cp.Save(checkpoint)
+		}
+	}
+
+	stats, err := importer.ImportFrom(ctx, src, cp)
+	if err != nil {
+		return err
+	}
+
+	if err := printImportStats(cmd.OutOrStdout(), usersOutput, stats); err != nil {
+		return err
+	}
+
+	if len(stats.Failed) > 0 {
+		return fmt.Errorf("failed to import %d users", len(stats.Failed))
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/jsonl"
+)
+
+// Output formats of the commands.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+type userOutput struct {
+	ID            string                 `json:"id"`
+	Email         string                 `json:"email"`
+	EmailVerified bool                   `json:"email_verified"`
+	Username      string                 `json:"username,omitempty"`
+	GivenName     string                 `json:"given_name,omitempty"`
+	FamilyName    string                 `json:"family_name,omitempty"`
+	Name          string                 `json:"name,omitempty"`
+	Nickname      string                 `json:"nickname,omitempty"`
+	Picture       string                 `json:"picture,omitempty"`
+	AppMetaData   map[string]interface{} `json:"app_metadata,omitempty"`
+	UserMetaData  map[string]interface{} `json:"user_metadata,omitempty"`
+	Blocked       bool                   `json:"blocked"`
+	LastIP        string                 `json:"last_ip,omitempty"`
+	LastLoginAt   *time.Time             `json:"last_login_at,omitempty"`
+	LoginsCount   int64                  `json:"logins_count"`
+	DeletionAt    *time.Time             `json:"deletion_requested_at,omitempty"`
+	Providers     []providerOutput       `json:"providers,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+type providerOutput struct {
+	Provider    string `json:"provider"`
+	FederatedID string `json:"federated_id"`
+}
+
+type userListOutput struct {
+	Users      []*userOutput `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func newUserOutput(u *user.User, accounts []*account.Account) *userOutput {
+	out := &userOutput{
+		ID:            u.ID,
+		Email:         u.Email,
+		EmailVerified: u.IsConfirmed(),
+		Username:      u.Username,
+		GivenName:     u.GivenName,
+		FamilyName:    u.FamilyName,
+		Name:          u.Name,
+		Nickname:      u.Nickname,
+		Picture:       u.Picture,
+		AppMetaData:   u.AppMetaData,
+		UserMetaData:  u.UserMetaData,
+		Blocked:       u.Blocked,
+		LastIP:        u.LastIP,
+		LastLoginAt:   u.LastLoginAt,
+		LoginsCount:   u.LoginsCount,
+		DeletionAt:    u.DeletionRequestedAt,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+	}
+
+	for _, acc := range accounts {
+		out.Providers = append(out.Providers, providerOutput{
+			Provider:    acc.Provider.String(),
+			FederatedID: acc.FederatedID,
+		})
+	}
+
+	return out
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printUsers(w io.Writer, format string, users []*user.User, nextCursor string) error {
+	if format == outputJSON {
+		out := userListOutput{
+			Users:      make([]*userOutput, 0, len(users)),
+			NextCursor: nextCursor,
+		}
+		for _, u := range users {
+			out.Users = append(out.Users, newUserOutput(u, nil))
+		}
+
+		return printJSON(w, out)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tEMAIL\tUSERNAME\tVERIFIED\tBLOCKED\tCREATED")
+	for _, u := range users {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%t\t%s\n",
+			u.ID,
+			u.Email,
+			u.Username,
+			u.IsConfirmed(),
+			u.Blocked,
+			u.CreatedAt.Format(time.RFC3339),
+		)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if nextCursor != "" {
+		_, err := fmt.Fprintf(w, "\nNext cursor: %s\n", nextCursor)
+		return err
+	}
+
+	return nil
+}
+
+func printUser(w io.Writer, format string, u *user.User, accounts []*account.Account) error {
+	out := newUserOutput(u, accounts)
+
+	if format == outputJSON {
+		return printJSON(w, out)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	field := func(name string, value interface{}) {
+		fmt.Fprintf(tw, "%s:\t%v\n", name, value)
+	}
+	optional := func(name string, value string) {
+		if value != "" {
+			field(name, value)
+		}
+	}
+	optionalTime := func(name string, value *time.Time) {
+		if value != nil {
+			field(name, value.Format(time.RFC3339))
+		}
+	}
+
+	field("ID", out.ID)
+	field("Email", out.Email)
+	field("Email verified", out.EmailVerified)
+	optional("Username", out.Username)
+	optional("Given name", out.GivenName)
+	optional("Family name", out.FamilyName)
+	optional("Name", out.Name)
+	optional("Nickname", out.Nickname)
+	optional("Picture", out.Picture)
+	field("Blocked", out.Blocked)
+	optional("Last IP", out.LastIP)
+	optionalTime("Last login", out.LastLoginAt)
+	field("Logins", out.LoginsCount)
+	optionalTime("Deletion requested", out.DeletionAt)
+	for _, p := range out.Providers {
+		field("Provider", p.Provider+" "+p.FederatedID)
+	}
+	field("Created", out.CreatedAt.Format(time.RFC3339))
+	field("Updated", out.UpdatedAt.Format(time.RFC3339))
+
+	return tw.Flush()
+}
+
+type importStatsOutput struct {
+	Read     int             `json:"read"`
+	Imported int             `json:"imported"`
+	Skipped  []*recordOutput `json:"skipped,omitempty"`
+	Failed   []*recordOutput `json:"failed,omitempty"`
+}
+
+type recordOutput struct {
+	Line   int    `json:"line"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func newRecordOutputs(recErrs []*jsonl.RecordError) []*recordOutput {
+	var out []*recordOutput
+	for _, recErr := range recErrs {
+		out = append(out, &recordOutput{
+			Line:   recErr.Line,
+			ID:     recErr.ID,
+			Reason: recErr.Err.Error(),
+		})
+	}
+	return out
+}
+
+func printImportStats(w io.Writer, format string, stats *jsonl.Stats) error {
+	out := importStatsOutput{
+		Read:     stats.Read,
+		Imported: stats.Imported,
+		Skipped:  newRecordOutputs(stats.Skipped),
+		Failed:   newRecordOutputs(stats.Failed),
+	}
+
+	if format == outputJSON {
+		return printJSON(w, out)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Read:\t%d\n", out.Read)
+	fmt.Fprintf(tw, "Imported:\t%d\n", out.Imported)
+	fmt.Fprintf(tw, "Skipped:\t%d\n", len(out.Skipped))
+	fmt.Fprintf(tw, "Failed:\t%d\n", len(out.Failed))
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(out.Skipped)+len(out.Failed) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "STATUS\tLINE\tID\tREASON")
+	for _, rec := range out.Skipped {
+		fmt.Fprintf(tw, "skipped\t%d\t%s\t%s\n", rec.Line, rec.ID, rec.Reason)
+	}
+	for _, rec := range out.Failed {
+		fmt.Fprintf(tw, "failed\t%d\t%s\t%s\n", rec.Line, rec.ID, rec.Reason)
+	}
+
+	return tw.Flush()
+}
+
+// printMessage prints the confirmation of a command without a result. The
+// JSON output stays empty, the exit code reports the outcome.
+func printMessage(w io.Writer, format string, msg string, args ...interface{}) error {
+	if format == outputJSON {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(w, msg+"\n", args...)
+	return err
+}
@@ -6,6 +6,7 @@ require (
 	github.com/badoux/checkmail v1.2.1
 	github.com/go-playground/validator/v10 v10.7.0
 	github.com/gofrs/uuid v4.0.0+incompatible
+	github.com/google/cel-go v0.12.6
 	github.com/gorilla/csrf v1.7.0
 	github.com/gorilla/handlers v1.5.1
 	github.com/gorilla/mux v1.8.0
@@ -30,7 +31,9 @@ require (
 	github.com/steinfletcher/apitest v1.5.11
 	github.com/stretchr/testify v1.7.0
 	github.com/syndtr/goleveldb v1.0.0
+	go.etcd.io/bbolt v1.3.6
 	go.uber.org/fx v1.13.1
 	go.uber.org/zap v1.18.1
 	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
+	modernc.org/sqlite v1.17.3
 )
@@ -0,0 +1,738 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/hako/durafmt"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/hash"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+const adminUserIDVar = "user_id"
+
+// adminAPIKeyActor is recorded as the actor of the changes made with the
+// admin API key.
+const adminAPIKeyActor = "admin_api_key"
+
+// AdminHandler allows access only to requests authorized with the admin API
+// key, or with an access token having the admin role.
+func (s *server) AdminHandler(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tokenString, err := s.extractBearerToken(r)
+		if err != nil {
+			s.handleError(w, r, err)
+			return
+		}
+
+		apiKey := s.config.API.Admin.APIKey
+		if apiKey != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(tokenString)) == 1 {
+			ctx = s.log.NewContext(ctx, logger.Fields{"admin": "api_key"})
+			ctx = user.NewActorContext(ctx, adminAPIKeyActor)
+
+			next(w, r.WithContext(ctx))
+			return
+		}
+
+		jwtToken, err := s.parseJWT(ctx, tokenString)
+		if err != nil {
+			s.log.WithContext(ctx).Errorf("parse JWT token: %v", err)
+
+			s.handleError(w, r, err)
+			return
+		}
+
+		var customClaims CustomClaims
+		if claim, ok := jwtToken.Get(s.config.API.JWT.ClaimsNamespace); ok {
+			// ignoring error, checked by role
+			mapstructure.Decode(claim, &customClaims) //nolint:errcheck
+		}
+
+		if !hasRole(customClaims.Roles, s.config.API.Admin.Role) {
+			s.log.WithContext(ctx).
+				WithFields(logger.Fields{"user_id": jwtToken.Subject()}).
+				Warn("admin role required")
+
+			s.handleError(w, r, forbiddenError("Admin role required"))
+			return
+		}
+
+		ctx = withToken(ctx, &jwtToken)
+		ctx = s.log.NewContext(ctx, logger.Fields{"admin": jwtToken.Subject()})
+		ctx = user.NewActorContext(ctx, jwtToken.Subject())
+
+		next(w, r.WithContext(ctx))
+	})
+}
+
+// AdminUserListHandler lists users, or finds a user by its identifier.
+func (s *server) AdminUserListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	resp := &AdminUserListResponse{
+		Users: make([]*AdminUserResponse, 0),
+	}
+
+	if identifier := query.Get("identifier"); identifier != "" {
+		user, err := s.userUsecase.FindUserByEmail(ctx, identifier)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				mustSendJSON(w, http.StatusOK, resp)
+				return
+			}
+
+			s.handleError(w, r, internalServerError("Database error finding user").WithInternalError(err))
+			return
+		}
+
+		resp.Users = append(resp.Users, adminUserResponse(user, nil))
+
+		mustSendJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			s.handleError(w, r, badRequestError("Invalid limit: %s", v))
+			return
+		}
+	}
+
+	users, nextCursor, err := s.userUsecase.FindAllUsers(ctx, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding users").WithInternalError(err))
+		return
+	}
+
+	for _, user := range users {
+		resp.Users = append(resp.Users, adminUserResponse(user, nil))
+	}
+	resp.NextCursor = nextCursor
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// AdminUserCreateHandler creates a new user, optionally with a verified email.
+func (s *server) AdminUserCreateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminUserCreateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	if params.Password == "" {
+		s.handleError(w, r, unprocessableEntityError("User creation requires a valid password"))
+		return
+	}
+	if err := s.validateEmail(ctx, params.Email); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	createUserRequest := user.User{
+		Email:        params.Email,
+		Username:     params.Username,
+		GivenName:    params.GivenName,
+		FamilyName:   params.FamilyName,
+		Name:         params.Name,
+		Nickname:     params.Nickname,
+		Picture:      params.Picture,
+		AppMetaData:  params.AppMetaData,
+		UserMetaData: params.UserMetaData,
+	}
+
+	createdUser, passwordAccount, err := s.CreateUser(ctx, &createUserRequest, []byte(params.Password), params.EmailVerified)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	mustSendJSON(w, http.StatusCreated, adminUserResponse(createdUser, []*account.Account{passwordAccount}))
+}
+
+// CreateUser creates the user together with its password account, and
+// notifies about it.
+func (s *server) CreateUser(ctx context.Context, in *user.User, password []byte, emailVerified bool) (*user.User, *account.Account, error) {
+	// hashing is slow, so it is not done while the transaction is open
+	passwordHash, err := s.userUsecase.HashPassword(ctx, password)
+	if err != nil {
+		if errors.Is(err, hash.ErrHasherSaturated) {
+			return nil, nil, serviceUnavailableError("Too many requests in progress, please retry later")
+		}
+
+		return nil, nil, internalServerError("Could not create user").WithInternalError(err)
+	}
+
+	var (
+		createdUser     *user.User
+		passwordAccount *account.Account
+	)
+
+	// the user is only created together with its password account
+	err = s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		u, err := s.userUsecase.CreateUser(ctx, in)
+		if err != nil {
+			return err
+		}
+
+		passwordAccount = &account.Account{
+			UserID:      u.ID,
+			Provider:    account.ProviderTypePassword,
+			FederatedID: u.Email,
+		}
+
+		_, err = s.accountUsecase.CreateAccount(ctx, passwordAccount)
+		if err != nil {
+			return err
+		}
+
+		createdUser, err = s.userUsecase.UpdatePasswordHash(ctx, u.ID, passwordHash)
+		if err != nil {
+			return err
+		}
+
+		if emailVerified {
+			createdUser, err = s.userUsecase.ConfirmUser(ctx, createdUser.ID)
+		}
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrAlreadyExists) {
+			return nil, nil, unprocessableEntityError("Email address or username already registered by another user")
+		}
+
+		return nil, nil, internalServerError("Could not create user").WithInternalError(err)
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": createdUser.ID})
+
+	s.log.WithContext(ctx).Info("user created")
+
+	s.recordAudit(ctx, &auditlog.Event{
+		Action:    auditlog.ActionSignup,
+		Outcome:   auditlog.OutcomeSuccess,
+		SubjectID: createdUser.ID,
+		MetaData:  map[string]interface{}{"admin": true},
+	})
+
+	s.userWebhook(ctx, webhook.EventUserCreated, createdUser, "")
+
+	return createdUser, passwordAccount, nil
+}
+
+// AdminUserGetHandler returns the user details.
+func (s *server) AdminUserGetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	accounts, err := s.accountUsecase.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding accounts").WithInternalError(err))
+		return
+	}
+
+	w.Header().Set(xhttp.ETag, userETag(user))
+
+	mustSendJSON(w, http.StatusOK, adminUserResponse(user, accounts))
+}
+
+// AdminUserUpdateHandler updates the user data.
+func (s *server) AdminUserUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminUserUpdateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	// the updates save the user at the revision checked here, so a user
+	// changed after the check fails them, see userUpdateError
+	if !ifMatch(r, userETag(user)) {
+		s.handleError(w, r, preconditionFailedError("User was changed since it was read"))
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": user.ID})
+
+	if params.Password != "" {
+		user, err = s.UpdatePassword(ctx, user, []byte(params.Password))
+		if err != nil {
+			if errors.Is(err, hash.ErrHasherSaturated) {
+				s.handleError(w, r, serviceUnavailableError("Too many requests in progress, please retry later"))
+				return
+			}
+
+			s.handleError(w, r, userUpdateError(r, "Error during password storage", err))
+			return
+		}
+	}
+
+	if params.EmailVerified != nil && *params.EmailVerified != user.EmailVerified {
+		if *params.EmailVerified {
+			user, err = s.ConfirmUser(ctx, user)
+		} else {
+			user.EmailVerified = false
+			user, err = s.userUsecase.UpdateUser(ctx, user)
+		}
+		if err != nil {
+			s.handleError(w, r, userUpdateError(r, "Error updating user", err))
+			return
+		}
+	}
+
+	changed := false
+	for _, field := range []struct {
+		param string
+		value *string
+	}{
+		{params.GivenName, &user.GivenName},
+		{params.FamilyName, &user.FamilyName},
+		{params.Name, &user.Name},
+		{params.Nickname, &user.Nickname},
+		{params.Picture, &user.Picture},
+	} {
+		if field.param != "" && field.param != *field.value {
+			*field.value = field.param
+			changed = true
+		}
+	}
+
+	if changed {
+		user, err = s.userUsecase.UpdateUser(ctx, user)
+		if err != nil {
+			s.handleError(w, r, userUpdateError(r, "Error updating user", err))
+			return
+		}
+	}
+
+	if params.AppMetaData != nil {
+		user, err = s.userUsecase.UpdateAppMetaData(ctx, user, params.AppMetaData)
+		if err != nil {
+			s.handleError(w, r, userUpdateError(r, "Error updating user", err))
+			return
+		}
+	}
+
+	if params.UserMetaData != nil {
+		user, err = s.userUsecase.UpdateUserMetaData(ctx, user, params.UserMetaData)
+		if err != nil {
+			s.handleError(w, r, userUpdateError(r, "Error updating user", err))
+			return
+		}
+	}
+
+	s.log.WithContext(ctx).Info("user updated by admin")
+
+	accounts, err := s.accountUsecase.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding accounts").WithInternalError(err))
+		return
+	}
+
+	w.Header().Set(xhttp.ETag, userETag(user))
+
+	mustSendJSON(w, http.StatusOK, adminUserResponse(user, accounts))
+}
+
+// UpdatePassword sets the password of the user, at the revision of the
+// user. The errors of the usecase are returned as they are, so they are told
+// apart by the caller.
+func (s *server) UpdatePassword(ctx context.Context, u *user.User, password []byte) (*user.User, error) {
+	u, err := s.userUsecase.UpdatePassword(ctx, u, password)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(ctx, &auditlog.Event{
+		Action:    auditlog.ActionPasswordChange,
+		Outcome:   auditlog.OutcomeSuccess,
+		SubjectID: u.ID,
+		MetaData:  map[string]interface{}{"admin": true},
+	})
+
+	return u, nil
+}
+
+// ConfirmUser verifies the email of the user, and notifies about it. The
+// errors of the usecase are returned as they are, so they are told apart by
+// the caller.
+func (s *server) ConfirmUser(ctx context.Context, u *user.User) (*user.User, error) {
+	u, err := s.userUsecase.ConfirmUser(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.userWebhook(ctx, webhook.EventUserVerified, u, "")
+
+	return u, nil
+}
+
+// AdminUserDeleteHandler soft deletes the user and revokes its refresh
+// tokens. The user can be restored from its history.
+func (s *server) AdminUserDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": user.ID})
+
+	if err := s.DeleteUser(ctx, user, false); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteUser soft deletes the user, or purges it, and notifies about it.
+func (s *server) DeleteUser(ctx context.Context, u *user.User, purge bool) error {
+	deleteFn := s.deleteUser
+	if purge {
+		deleteFn = s.purgeUser
+	}
+
+	if err := deleteFn(ctx, u); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("user deleted")
+
+	s.recordAudit(ctx, &auditlog.Event{
+		Action:    auditlog.ActionAccountDelete,
+		Outcome:   auditlog.OutcomeSuccess,
+		SubjectID: u.ID,
+		MetaData:  map[string]interface{}{"purge": purge},
+	})
+
+	s.userWebhook(ctx, webhook.EventUserDeleted, u, "")
+
+	return nil
+}
+
+// deleteUser soft deletes the user, after revoking its sessions. Its roles,
+// memberships and accounts are kept until the user is purged, so a restored
+// user can sign in again.
+func (s *server) deleteUser(ctx context.Context, user *user.User) error {
+	if err := s.refreshTokenUsecase.Logout(ctx, user); err != nil {
+		return internalServerError("Error logging out user").WithInternalError(err)
+	}
+
+	if err := s.userUsecase.DeleteUser(ctx, user.ID); err != nil {
+		return internalServerError("Error deleting user").WithInternalError(err)
+	}
+
+	return nil
+}
+
+// purgeUser irreversibly deletes the user together with its history, after
+// deleting its sessions, roles, memberships and accounts.
+func (s *server) purgeUser(ctx context.Context, user *user.User) error {
+	// the user is deleted together with all of its data, or not at all
+	err := s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.deleteUserData(ctx, user); err != nil {
+			return err
+		}
+
+		if err := s.userUsecase.PurgeUser(ctx, user.ID); err != nil {
+			return internalServerError("Error deleting user").WithInternalError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if e, ok := err.(*HTTPError); ok {
+			return e
+		}
+
+		return internalServerError("Error deleting user").WithInternalError(err)
+	}
+
+	return nil
+}
+
+func (s *server) deleteUserData(ctx context.Context, user *user.User) error {
+	if err := s.refreshTokenUsecase.Logout(ctx, user); err != nil {
+		return internalServerError("Error logging out user").WithInternalError(err)
+	}
+
+	if err := s.roleUsecase.DeleteAllForUser(ctx, user.ID); err != nil {
+		return internalServerError("Error deleting user roles").WithInternalError(err)
+	}
+
+	if err := s.organizationUsecase.DeleteAllForUser(ctx, user.ID); err != nil {
+		return internalServerError("Error deleting user memberships").WithInternalError(err)
+	}
+
+	if err := s.accountUsecase.DeleteAllForUser(ctx, user.ID); err != nil {
+		return internalServerError("Error deleting user accounts").WithInternalError(err)
+	}
+
+	return nil
+}
+
+// AdminUserBlockHandler blocks the user and revokes all its refresh tokens.
+func (s *server) AdminUserBlockHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": user.ID})
+
+	user, err = s.BlockUser(ctx, user)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminUserResponse(user, nil))
+}
+
+// BlockUser blocks the user, revokes all its refresh tokens, and notifies
+// about it.
+func (s *server) BlockUser(ctx context.Context, u *user.User) (*user.User, error) {
+	u, err := s.userUsecase.BlockUser(ctx, u.ID)
+	if err != nil {
+		return nil, internalServerError("Error blocking user").WithInternalError(err)
+	}
+
+	if err := s.refreshTokenUsecase.Logout(ctx, u); err != nil {
+		return nil, internalServerError("Error logging out user").WithInternalError(err)
+	}
+
+	s.log.WithContext(ctx).Info("user blocked")
+
+	s.recordAudit(ctx, &auditlog.Event{
+		Action:    auditlog.ActionAccountBlock,
+		Outcome:   auditlog.OutcomeSuccess,
+		SubjectID: u.ID,
+	})
+
+	s.userWebhook(ctx, webhook.EventUserBlocked, u, "")
+
+	return u, nil
+}
+
+// AdminUserUnblockHandler allows a blocked user to sign in again.
+func (s *server) AdminUserUnblockHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": user.ID})
+
+	user, err = s.UnblockUser(ctx, user)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminUserResponse(user, nil))
+}
+
+// UnblockUser allows the blocked user to sign in again.
+func (s *server) UnblockUser(ctx context.Context, u *user.User) (*user.User, error) {
+	u, err := s.userUsecase.UnblockUser(ctx, u.ID)
+	if err != nil {
+		return nil, internalServerError("Error unblocking user").WithInternalError(err)
+	}
+
+	s.log.WithContext(ctx).Info("user unblocked")
+
+	s.recordAudit(ctx, &auditlog.Event{
+		Action:    auditlog.ActionAccountUnblock,
+		Outcome:   auditlog.OutcomeSuccess,
+		SubjectID: u.ID,
+	})
+
+	return u, nil
+}
+
+// AdminUserLogoutHandler revokes all refresh tokens of the user.
+func (s *server) AdminUserLogoutHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": user.ID})
+
+	if err := s.LogoutUser(ctx, user); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutUser revokes all refresh tokens of the user.
+func (s *server) LogoutUser(ctx context.Context, u *user.User) error {
+	if err := s.refreshTokenUsecase.Logout(ctx, u); err != nil {
+		return internalServerError("Error logging out user").WithInternalError(err)
+	}
+
+	s.log.WithContext(ctx).Info("user logged out")
+
+	s.recordAudit(ctx, &auditlog.Event{
+		Action:    auditlog.ActionLogout,
+		Outcome:   auditlog.OutcomeSuccess,
+		SubjectID: u.ID,
+		MetaData:  map[string]interface{}{"admin": true},
+	})
+
+	return nil
+}
+
+// AdminUserConfirmationHandler sends the confirmation email again.
+func (s *server) AdminUserConfirmationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": user.ID})
+
+	if user.IsConfirmed() {
+		s.handleError(w, r, unprocessableEntityError("Email already confirmed"))
+		return
+	}
+
+	mailer := s.Mailer(ctx)
+	if err = s.sendConfirmation(ctx, user, mailer, s.config.SMTP.MaxFrequency, ""); err != nil {
+		if errors.Is(err, ErrMaxFrequencyLimit) {
+			maxFrequencyHumanString := durafmt.Parse(s.config.SMTP.MaxFrequency).String()
+			s.handleError(w, r, tooManyRequestsError("For security purposes, you can only request this once every %s", maxFrequencyHumanString))
+			return
+		}
+
+		s.handleError(w, r, internalServerError("Error sending confirmation mail").WithInternalError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).Info("confirmation sent by admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) findAdminUser(r *http.Request) (*user.User, error) {
+	ctx := r.Context()
+
+	id := mux.Vars(r)[adminUserIDVar]
+
+	user, err := s.userUsecase.FindUserByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, notFoundError("User not found")
+		}
+
+		return nil, internalServerError("Database error finding user").WithInternalError(err)
+	}
+
+	return user, nil
+}
+
+func adminUserResponse(user *user.User, accounts []*account.Account) *AdminUserResponse {
+	resp := &AdminUserResponse{
+		UserResponse: UserResponse{
+			UserID:        user.ID,
+			Email:         user.Email,
+			EmailVerified: user.IsConfirmed(),
+			Username:      user.Username,
+			GivenName:     user.GivenName,
+			FamilyName:    user.FamilyName,
+			Name:          user.Name,
+			Nickname:      user.Nickname,
+			Picture:       user.Picture,
+			AppMetaData:   user.AppMetaData,
+			UserMetaData:  user.UserMetaData,
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+		},
+		Blocked:     user.Blocked,
+		LastIP:      user.LastIP,
+		LastLoginAt: user.LastLoginAt,
+		LoginsCount: user.LoginsCount,
+	}
+
+	for _, acc := range accounts {
+		provider := Provider{
+			Provider:    acc.Provider.String(),
+			FederatedID: acc.FederatedID,
+		}
+		resp.Providers = append(resp.Providers, provider)
+	}
+
+	return resp
+}
+
+func hasRole(roles []string, role string) bool {
+	if role == "" {
+		return false
+	}
+
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
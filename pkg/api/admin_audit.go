@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+)
+
+// AdminAuditEventListHandler lists the events of the audit log, newest first.
+func (s *server) AdminAuditEventListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	filter, err := auditFilterFromQuery(query)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	filter.ActorID = query.Get("actor_id")
+	filter.SubjectID = query.Get("subject_id")
+
+	limit, err := queryLimit(query.Get("limit"))
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	events, nextCursor, err := s.auditLogUsecase.FindAllEvents(ctx, filter, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding events").WithInternalError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, auditEventListResponse(events, nextCursor))
+}
@@ -0,0 +1,437 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/jsonmap"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+const (
+	adminOrganizationIDVar = "organization_id"
+	adminInvitationIDVar   = "invitation_id"
+)
+
+// AdminOrganizationListHandler lists organizations.
+func (s *server) AdminOrganizationListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	limit, err := queryLimit(query.Get("limit"))
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	organizations, nextCursor, err := s.organizationUsecase.FindAllOrganizations(ctx, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding organizations").WithInternalError(err))
+		return
+	}
+
+	resp := &AdminOrganizationListResponse{
+		Organizations: make([]*AdminOrganizationResponse, 0, len(organizations)),
+		NextCursor:    nextCursor,
+	}
+
+	for _, o := range organizations {
+		resp.Organizations = append(resp.Organizations, adminOrganizationResponse(o))
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// AdminOrganizationCreateHandler creates a new organization.
+func (s *server) AdminOrganizationCreateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminOrganizationCreateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	createdOrganization, err := s.organizationUsecase.CreateOrganization(ctx, &organization.Organization{
+		Name:        params.Name,
+		DisplayName: params.DisplayName,
+		MetaData:    params.MetaData,
+	})
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"organization_id": createdOrganization.ID}).
+		Info("organization created by admin")
+
+	mustSendJSON(w, http.StatusCreated, adminOrganizationResponse(createdOrganization))
+}
+
+// AdminOrganizationGetHandler returns the organization details.
+func (s *server) AdminOrganizationGetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	o, err := s.organizationUsecase.FindOrganizationByID(ctx, mux.Vars(r)[adminOrganizationIDVar])
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminOrganizationResponse(o))
+}
+
+// AdminOrganizationUpdateHandler updates the organization. Metadata keys
+// having null values are removed.
+func (s *server) AdminOrganizationUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminOrganizationUpdateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	o, err := s.organizationUsecase.FindOrganizationByID(ctx, mux.Vars(r)[adminOrganizationIDVar])
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	if params.Name != nil {
+		o.Name = *params.Name
+	}
+	if params.DisplayName != nil {
+		o.DisplayName = *params.DisplayName
+	}
+	if params.MetaData != nil {
+		if o.MetaData == nil {
+			o.MetaData = make(jsonmap.JSONMap)
+		}
+
+		for key, value := range params.MetaData {
+			if value == nil {
+				delete(o.MetaData, key)
+				continue
+			}
+
+			o.MetaData[key] = value
+		}
+	}
+
+	o, err = s.organizationUsecase.UpdateOrganization(ctx, o)
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"organization_id": o.ID}).
+		Info("organization updated by admin")
+
+	mustSendJSON(w, http.StatusOK, adminOrganizationResponse(o))
+}
+
+// AdminOrganizationDeleteHandler deletes the organization, together with its
+// members and invitations.
+func (s *server) AdminOrganizationDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id := mux.Vars(r)[adminOrganizationIDVar]
+
+	if err := s.organizationUsecase.DeleteOrganization(ctx, id); err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"organization_id": id}).
+		Info("organization deleted by admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminMemberListHandler lists members of the organization.
+func (s *server) AdminMemberListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	limit, err := queryLimit(query.Get("limit"))
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	members, nextCursor, err := s.organizationUsecase.FindAllMembers(ctx, mux.Vars(r)[adminOrganizationIDVar], query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	resp := &AdminMemberListResponse{
+		Members:    make([]*AdminMemberResponse, 0, len(members)),
+		NextCursor: nextCursor,
+	}
+
+	for _, m := range members {
+		resp.Members = append(resp.Members, adminMemberResponse(m))
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// AdminMemberSaveHandler adds the user to the organization, or replaces the
+// member roles.
+func (s *server) AdminMemberSaveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminMemberRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	member, err := s.organizationUsecase.SaveMember(ctx, &organization.Member{
+		OrganizationID: mux.Vars(r)[adminOrganizationIDVar],
+		UserID:         user.ID,
+		Roles:          params.Roles,
+	})
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{
+			"organization_id": member.OrganizationID,
+			"user_id":         member.UserID,
+		}).
+		Info("organization member saved by admin")
+
+	mustSendJSON(w, http.StatusOK, adminMemberResponse(member))
+}
+
+// AdminMemberRemoveHandler removes the user from the organization.
+func (s *server) AdminMemberRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	if err := s.organizationUsecase.RemoveMember(ctx, vars[adminOrganizationIDVar], vars[adminUserIDVar]); err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{
+			"organization_id": vars[adminOrganizationIDVar],
+			"user_id":         vars[adminUserIDVar],
+		}).
+		Info("organization member removed by admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminInvitationListHandler lists pending invitations to the organization.
+func (s *server) AdminInvitationListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	invitations, err := s.organizationUsecase.FindAllInvitations(ctx, mux.Vars(r)[adminOrganizationIDVar])
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	resp := &AdminInvitationListResponse{
+		Invitations: make([]*AdminInvitationResponse, 0, len(invitations)),
+	}
+
+	for _, i := range invitations {
+		resp.Invitations = append(resp.Invitations, adminInvitationResponse(i))
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// AdminInvitationCreateHandler invites the email address to the organization,
+// sending the invitation mail.
+func (s *server) AdminInvitationCreateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminInvitationCreateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	if err := s.validateEmail(ctx, params.Email); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	o, err := s.organizationUsecase.FindOrganizationByID(ctx, mux.Vars(r)[adminOrganizationIDVar])
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	invitation := &organization.Invitation{
+		OrganizationID: o.ID,
+		Email:          params.Email,
+		Roles:          params.Roles,
+	}
+
+	if jwtToken := getToken(ctx); jwtToken != nil {
+		invitation.InviterID = (*jwtToken).Subject()
+	}
+
+	invitation, err = s.organizationUsecase.CreateInvitation(ctx, invitation)
+	if err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{
+		"organization_id": o.ID,
+		"invitation_id":   invitation.ID,
+	})
+
+	referrer := s.getReferrer(r)
+
+	if err := s.Mailer(ctx).InvitationMail(invitation, o, referrer); err != nil {
+		s.log.WithContext(ctx).Errorf("send invitation mail: %v", err)
+
+		// invitation cannot be accepted without the mail
+		_ = s.organizationUsecase.RevokeInvitation(ctx, o.ID, invitation.ID)
+
+		s.handleError(w, r, internalServerError("Error sending invitation email").WithInternalError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).Info("organization invitation created by admin")
+
+	resp := adminInvitationResponse(invitation)
+	resp.Token = invitation.Token
+
+	mustSendJSON(w, http.StatusCreated, resp)
+}
+
+// AdminInvitationRevokeHandler revokes the invitation to the organization.
+func (s *server) AdminInvitationRevokeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	if err := s.organizationUsecase.RevokeInvitation(ctx, vars[adminOrganizationIDVar], vars[adminInvitationIDVar]); err != nil {
+		s.handleError(w, r, adminOrganizationError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{
+			"organization_id": vars[adminOrganizationIDVar],
+			"invitation_id":   vars[adminInvitationIDVar],
+		}).
+		Info("organization invitation revoked by admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func queryLimit(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 0 {
+		return 0, badRequestError("Invalid limit: %s", v)
+	}
+
+	return limit, nil
+}
+
+func adminOrganizationError(err error) *HTTPError {
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return notFoundError("Organization not found")
+	case errors.As(err, &validationErrors):
+		return unprocessableEntityError("Invalid organization: %v", validationErrors[0])
+	default:
+		return internalServerError("Database error").WithInternalError(err)
+	}
+}
+
+func adminOrganizationResponse(in *organization.Organization) *AdminOrganizationResponse {
+	return &AdminOrganizationResponse{
+		OrganizationID: in.ID,
+		Name:           in.Name,
+		DisplayName:    in.DisplayName,
+		MetaData:       in.MetaData,
+		CreatedAt:      in.CreatedAt,
+		UpdatedAt:      in.UpdatedAt,
+	}
+}
+
+func adminMemberResponse(in *organization.Member) *AdminMemberResponse {
+	out := &AdminMemberResponse{
+		OrganizationID: in.OrganizationID,
+		UserID:         in.UserID,
+		Roles:          in.Roles,
+		CreatedAt:      in.CreatedAt,
+		UpdatedAt:      in.UpdatedAt,
+	}
+
+	if out.Roles == nil {
+		out.Roles = make([]string, 0)
+	}
+
+	return out
+}
+
+func adminInvitationResponse(in *organization.Invitation) *AdminInvitationResponse {
+	out := &AdminInvitationResponse{
+		InvitationID:   in.ID,
+		OrganizationID: in.OrganizationID,
+		Email:          in.Email,
+		Roles:          in.Roles,
+		InviterID:      in.InviterID,
+		ExpiresAt:      in.ExpiresAt,
+		CreatedAt:      in.CreatedAt,
+	}
+
+	if out.Roles == nil {
+		out.Roles = make([]string, 0)
+	}
+
+	return out
+}
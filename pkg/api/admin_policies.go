@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+const adminPolicyIDVar = "policy_id"
+
+// AdminPolicyListHandler lists policies.
+func (s *server) AdminPolicyListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			s.handleError(w, r, badRequestError("Invalid limit: %s", v))
+			return
+		}
+	}
+
+	policies, nextCursor, err := s.policyUsecase.FindAllPolicies(ctx, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding policies").WithInternalError(err))
+		return
+	}
+
+	resp := &AdminPolicyListResponse{
+		Policies:   make([]*AdminPolicyResponse, 0, len(policies)),
+		NextCursor: nextCursor,
+	}
+
+	for _, policy := range policies {
+		resp.Policies = append(resp.Policies, adminPolicyResponse(policy))
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// AdminPolicyCreateHandler creates a new policy.
+func (s *server) AdminPolicyCreateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminPolicyCreateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	createdPolicy, err := s.policyUsecase.CreatePolicy(ctx, policyFromRequest(params))
+	if err != nil {
+		s.handleError(w, r, adminPolicyError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"policy_id": createdPolicy.ID}).
+		Info("policy created by admin")
+
+	mustSendJSON(w, http.StatusCreated, adminPolicyResponse(createdPolicy))
+}
+
+// AdminPolicyGetHandler returns the policy details.
+func (s *server) AdminPolicyGetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	policy, err := s.policyUsecase.FindPolicyByID(ctx, mux.Vars(r)[adminPolicyIDVar])
+	if err != nil {
+		s.handleError(w, r, adminPolicyError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminPolicyResponse(policy))
+}
+
+// AdminPolicyUpdateHandler updates the policy.
+func (s *server) AdminPolicyUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminPolicyUpdateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	p, err := s.policyUsecase.FindPolicyByID(ctx, mux.Vars(r)[adminPolicyIDVar])
+	if err != nil {
+		s.handleError(w, r, adminPolicyError(err))
+		return
+	}
+
+	if params.Description != nil {
+		p.Description = *params.Description
+	}
+	if params.Effect != nil {
+		p.Effect = policy.Effect(*params.Effect)
+	}
+	if params.Expression != nil {
+		p.Expression = *params.Expression
+	}
+	if params.Disabled != nil {
+		p.Disabled = *params.Disabled
+	}
+
+	p, err = s.policyUsecase.UpdatePolicy(ctx, p)
+	if err != nil {
+		s.handleError(w, r, adminPolicyError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"policy_id": p.ID}).
+		Info("policy updated by admin")
+
+	mustSendJSON(w, http.StatusOK, adminPolicyResponse(p))
+}
+
+// AdminPolicyDeleteHandler deletes the policy.
+func (s *server) AdminPolicyDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id := mux.Vars(r)[adminPolicyIDVar]
+
+	if err := s.policyUsecase.DeletePolicy(ctx, id); err != nil {
+		s.handleError(w, r, adminPolicyError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"policy_id": id}).
+		Info("policy deleted by admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminPolicyError(err error) *HTTPError {
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return notFoundError("Policy not found")
+	case errors.Is(err, database.ErrAlreadyExists):
+		return unprocessableEntityError("Policy already exists")
+	case errors.Is(err, policy.ErrInvalidExpression):
+		return unprocessableEntityError("%v", err)
+	case errors.As(err, &validationErrors):
+		return unprocessableEntityError("Invalid policy: %v", validationErrors[0])
+	default:
+		return internalServerError("Database error").WithInternalError(err)
+	}
+}
+
+func policyFromRequest(in *AdminPolicyCreateRequest) *policy.Policy {
+	return &policy.Policy{
+		ID:          in.ID,
+		Description: in.Description,
+		Effect:      policy.Effect(in.Effect),
+		Expression:  in.Expression,
+		Disabled:    in.Disabled,
+	}
+}
+
+func adminPolicyResponse(in *policy.Policy) *AdminPolicyResponse {
+	return &AdminPolicyResponse{
+		ID:          in.ID,
+		Description: in.Description,
+		Effect:      string(in.Effect),
+		Expression:  in.Expression,
+		Disabled:    in.Disabled,
+		CreatedAt:   in.CreatedAt,
+		UpdatedAt:   in.UpdatedAt,
+	}
+}
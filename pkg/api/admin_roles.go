@@ -0,0 +1,255 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+const adminRoleIDVar = "role_id"
+
+// AdminRoleListHandler lists roles.
+func (s *server) AdminRoleListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			s.handleError(w, r, badRequestError("Invalid limit: %s", v))
+			return
+		}
+	}
+
+	roles, nextCursor, err := s.roleUsecase.FindAllRoles(ctx, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding roles").WithInternalError(err))
+		return
+	}
+
+	resp := adminRoleListResponse(roles)
+	resp.NextCursor = nextCursor
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// AdminRoleCreateHandler creates a new role.
+func (s *server) AdminRoleCreateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminRoleCreateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	createdRole, err := s.roleUsecase.CreateRole(ctx, &role.Role{
+		ID:          params.ID,
+		Description: params.Description,
+		Permissions: params.Permissions,
+	})
+	if err != nil {
+		s.handleError(w, r, adminRoleError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"role_id": createdRole.ID}).
+		Info("role created by admin")
+
+	mustSendJSON(w, http.StatusCreated, adminRoleResponse(createdRole))
+}
+
+// AdminRoleGetHandler returns the role details.
+func (s *server) AdminRoleGetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	role, err := s.roleUsecase.FindRoleByID(ctx, mux.Vars(r)[adminRoleIDVar])
+	if err != nil {
+		s.handleError(w, r, adminRoleError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminRoleResponse(role))
+}
+
+// AdminRoleUpdateHandler updates the role description and permissions.
+func (s *server) AdminRoleUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &AdminRoleUpdateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	role, err := s.roleUsecase.FindRoleByID(ctx, mux.Vars(r)[adminRoleIDVar])
+	if err != nil {
+		s.handleError(w, r, adminRoleError(err))
+		return
+	}
+
+	if params.Description != nil {
+		role.Description = *params.Description
+	}
+	if params.Permissions != nil {
+		role.Permissions = params.Permissions
+	}
+
+	role, err = s.roleUsecase.UpdateRole(ctx, role)
+	if err != nil {
+		s.handleError(w, r, adminRoleError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"role_id": role.ID}).
+		Info("role updated by admin")
+
+	mustSendJSON(w, http.StatusOK, adminRoleResponse(role))
+}
+
+// AdminRoleDeleteHandler deletes the role, removing it from all users.
+func (s *server) AdminRoleDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id := mux.Vars(r)[adminRoleIDVar]
+
+	if err := s.roleUsecase.DeleteRole(ctx, id); err != nil {
+		s.handleError(w, r, adminRoleError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"role_id": id}).
+		Info("role deleted by admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminUserRoleListHandler lists roles assigned to the user.
+func (s *server) AdminUserRoleListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	roles, err := s.roleUsecase.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding roles").WithInternalError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminRoleListResponse(roles))
+}
+
+// AdminUserRoleAssignHandler assigns the role to the user.
+func (s *server) AdminUserRoleAssignHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	id := mux.Vars(r)[adminRoleIDVar]
+
+	if err := s.roleUsecase.AssignRole(ctx, user.ID, id); err != nil {
+		s.handleError(w, r, adminRoleError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"user_id": user.ID, "role_id": id}).
+		Info("role assigned by admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminUserRoleUnassignHandler removes the role from the user.
+func (s *server) AdminUserRoleUnassignHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.findAdminUser(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	id := mux.Vars(r)[adminRoleIDVar]
+
+	if err := s.roleUsecase.UnassignRole(ctx, user.ID, id); err != nil {
+		s.handleError(w, r, adminRoleError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"user_id": user.ID, "role_id": id}).
+		Info("role unassigned by admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminRoleError(err error) *HTTPError {
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return notFoundError("Role not found")
+	case errors.Is(err, database.ErrAlreadyExists):
+		return unprocessableEntityError("Role already exists")
+	case errors.As(err, &validationErrors):
+		return unprocessableEntityError("Invalid role: %v", validationErrors[0])
+	default:
+		return internalServerError("Database error").WithInternalError(err)
+	}
+}
+
+func adminRoleResponse(role *role.Role) *AdminRoleResponse {
+	resp := &AdminRoleResponse{
+		ID:          role.ID,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+
+	if resp.Permissions == nil {
+		resp.Permissions = make([]string, 0)
+	}
+
+	return resp
+}
+
+func adminRoleListResponse(roles []*role.Role) *AdminRoleListResponse {
+	resp := &AdminRoleListResponse{
+		Roles: make([]*AdminRoleResponse, 0, len(roles)),
+	}
+
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, adminRoleResponse(role))
+	}
+
+	return resp
+}
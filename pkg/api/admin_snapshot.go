@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/database/backup"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// AdminSnapshotHandler streams the backup archive of a consistent snapshot
+// of the database, gzip compressed unless requested otherwise. The archive
+// is restored with the db restore command, while the server is stopped.
+func (s *server) AdminSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	compress := true
+	if v := r.URL.Query().Get("compress"); v != "" {
+		var err error
+
+		compress, err = strconv.ParseBool(v)
+		if err != nil {
+			s.handleError(w, r, badRequestError("Invalid compress: %s", v))
+			return
+		}
+	}
+
+	filename := fmt.Sprintf("authzy-snapshot-%s.tar", time.Now().UTC().Format("20060102T150405Z"))
+	contentType := "application/x-tar"
+
+	if compress {
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+
+	w.Header().Set(xhttp.ContentType, contentType)
+	w.Header().Set(xhttp.ContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	m, err := backup.Backup(ctx, s.backupDatabase, w, backup.Options{Compress: compress})
+	if err != nil {
+		// the response is already started, the archive is left without the
+		// checksums, and fails to verify
+		s.log.WithContext(ctx).Errorf("snapshot: %v", err)
+		return
+	}
+
+	s.log.WithContext(ctx).WithFields(logger.Fields{
+		"database_type":  m.DatabaseType,
+		"schema_version": m.SchemaVersion,
+	}).Info("snapshot created")
+}
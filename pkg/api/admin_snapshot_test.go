@@ -0,0 +1,120 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database/backup"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+func TestAdminSnapshot(t *testing.T) {
+	ls, err := database_jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	users, err := user_jsonmutexdb.NewUserRepository(ls, "")
+	require.NoError(t, err)
+
+	server, _ := newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				Admin: &config.AdminConfig{
+					APIKey: testAdminAPIKey,
+				},
+			},
+		},
+		UserRepository: users,
+		BackupDatabase: backup.NewJSONMutexDB(ls, ""),
+	})
+	defer server.API.Close()
+
+	u, err := server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	snapshot := func(t *testing.T, query, contentType string) []byte {
+		t.Helper()
+
+		result := apitest.New().
+			Handler(server.API).
+			Post(api.AdminSnapshotPath).
+			Query("compress", query).
+			Header(xhttp.Authorization, "Bearer "+testAdminAPIKey).
+			Expect(t).
+			Status(http.StatusOK).
+			Header(xhttp.ContentType, contentType).
+			HeaderPresent(xhttp.ContentDisposition).
+			End()
+
+		data, err := io.ReadAll(result.Response.Body)
+		require.NoError(t, err)
+
+		return data
+	}
+
+	for query, contentType := range map[string]string{
+		"true":  "application/gzip",
+		"false": "application/x-tar",
+	} {
+		query, contentType := query, contentType
+
+		t.Run("compress="+query, func(t *testing.T) {
+			data := snapshot(t, query, contentType)
+
+			m, err := backup.Verify(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, database_jsonmutexdb.Type, m.DatabaseType)
+
+			// the archive restores the user into another database
+			restored, err := database_jsonmutexdb.NewLoadSaver("")
+			require.NoError(t, err)
+
+			_, err = backup.Restore(context.Background(), backup.NewJSONMutexDB(restored, ""), func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(data)), nil
+			}, backup.RestoreOptions{})
+			require.NoError(t, err)
+
+			restoredUsers, err := user_jsonmutexdb.NewUserRepository(restored, "")
+			require.NoError(t, err)
+
+			ru, err := restoredUsers.FindByID(context.Background(), u.ID)
+			require.NoError(t, err)
+			assert.Equal(t, u.Email, ru.Email)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		apitest.New().
+			Handler(server.API).
+			Post(api.AdminSnapshotPath).
+			Query("compress", "maybe").
+			Header(xhttp.Authorization, "Bearer "+testAdminAPIKey).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		apitest.New().
+			Handler(server.API).
+			Post(api.AdminSnapshotPath).
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+}
@@ -0,0 +1,773 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+const testAdminAPIKey = "32-byte-long-admin-api-key------"
+
+type AdminTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+	User   *user.User
+}
+
+//nolint:errcheck
+func (ts *AdminTestSuite) SetupTest() {
+	// truncate
+	ts.Server.AccountRepository.DeleteAll(context.Background())
+	ts.Server.RefreshTokenRepository.DeleteAll(context.Background())
+	ts.Server.RoleRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+	ts.Server.UserVersionRepository.DeleteAll(context.Background())
+
+	// create test user
+	createUserRequest := user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	}
+	user, err := ts.Server.UserUsecase.CreateUser(context.Background(), &createUserRequest)
+	require.NoError(ts.T(), err)
+
+	ts.User, err = ts.Server.UserUsecase.ConfirmUser(context.Background(), user.ID)
+	require.NoError(ts.T(), err)
+}
+
+func TestAdmin(t *testing.T) {
+	ts := &AdminTestSuite{}
+
+	ts.Server, _ = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				Admin: &config.AdminConfig{
+					APIKey: testAdminAPIKey,
+				},
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *AdminTestSuite) adminRequest() *apitest.APITest {
+	return apitest.New().
+		Handler(ts.Server.API).
+		Intercept(func(r *http.Request) {
+			r.Header.Set(xhttp.Authorization, "Bearer "+testAdminAPIKey)
+		})
+}
+
+func (ts *AdminTestSuite) TestUnauthorized() {
+	t := ts.T()
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.AdminUsersPath).
+		Expect(t).
+		Status(http.StatusUnauthorized).
+		End()
+
+	auth := authTokenHelper(t, ts.Server.API, "test@example.com", "password")
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.AdminUsersPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		Expect(t).
+		Status(http.StatusForbidden).
+		End()
+}
+
+func (ts *AdminTestSuite) TestAdminRole() {
+	t := ts.T()
+
+	_, err := ts.Server.RoleUsecase.CreateRole(context.Background(), &role.Role{ID: "admin"})
+	require.NoError(t, err)
+
+	err = ts.Server.RoleUsecase.AssignRole(context.Background(), ts.User.ID, "admin")
+	require.NoError(t, err)
+
+	auth := authTokenHelper(t, ts.Server.API, "test@example.com", "password")
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.AdminUsersPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+}
+
+func (ts *AdminTestSuite) TestList() {
+	t := ts.T()
+
+	for i := 0; i < 2; i++ {
+		_, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+			Email:    fmt.Sprintf("test%d@example.com", i),
+			Username: fmt.Sprintf("test%d", i),
+			Password: "password",
+		})
+		require.NoError(t, err)
+	}
+
+	resp := &api.AdminUserListResponse{}
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath).
+		Query("limit", "2").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Len(t, resp.Users, 2)
+	require.NotEmpty(t, resp.NextCursor)
+
+	next := &api.AdminUserListResponse{}
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath).
+		Query("limit", "2").
+		Query("cursor", resp.NextCursor).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(next)
+
+	assert.Len(t, next.Users, 1)
+	assert.Empty(t, next.NextCursor)
+
+	found := &api.AdminUserListResponse{}
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath).
+		Query("identifier", "test@example.com").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(found)
+
+	require.Len(t, found.Users, 1)
+	assert.Equal(t, ts.User.ID, found.Users[0].UserID)
+}
+
+func (ts *AdminTestSuite) TestCreate() {
+	t := ts.T()
+
+	req := &api.AdminUserCreateRequest{
+		Email:         "new@example.com",
+		EmailVerified: true,
+		Password:      "password",
+		Username:      "new",
+		AppMetaData:   map[string]interface{}{"plan": "pro"},
+	}
+
+	resp := &api.AdminUserResponse{}
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath).
+		JSON(req).
+		Expect(t).
+		Status(http.StatusCreated).
+		End().
+		JSON(resp)
+
+	assert.Equal(t, "new@example.com", resp.Email)
+	assert.True(t, resp.EmailVerified)
+	assert.Equal(t, "pro", resp.AppMetaData["plan"])
+
+	// pre-verified user is able to sign in
+	authTokenHelper(t, ts.Server.API, "new@example.com", "password")
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath).
+		JSON(req).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+}
+
+func (ts *AdminTestSuite) TestGetAndUpdate() {
+	t := ts.T()
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath + "/unknown").
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	req := &api.AdminUserUpdateRequest{
+		Name:        "Test User",
+		AppMetaData: map[string]interface{}{"plan": "pro"},
+	}
+
+	ts.adminRequest().
+		Patch(api.AdminUsersPath + "/" + ts.User.ID).
+		JSON(req).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	resp := &api.AdminUserResponse{}
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath + "/" + ts.User.ID).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Equal(t, "Test User", resp.Name)
+	assert.Equal(t, "pro", resp.AppMetaData["plan"])
+}
+
+func (ts *AdminTestSuite) TestCORS() {
+	t := ts.T()
+
+	// browsers send the revision of the user back, from the other origin
+	apitest.New().
+		Handler(ts.Server.API).
+		Method(http.MethodOptions).
+		URL(api.AdminUsersPath+"/"+ts.User.ID).
+		Header("Origin", "https://example.test").
+		Header("Access-Control-Request-Method", http.MethodPatch).
+		Header("Access-Control-Request-Headers", xhttp.IfMatch).
+		Expect(t).
+		Status(http.StatusOK).
+		Header("Access-Control-Allow-Headers", xhttp.IfMatch).
+		End()
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath+"/"+ts.User.ID).
+		Header("Origin", "https://example.test").
+		Expect(t).
+		Status(http.StatusOK).
+		Header("Access-Control-Expose-Headers", http.CanonicalHeaderKey(xhttp.ETag)).
+		End()
+}
+
+func (ts *AdminTestSuite) TestBlock() {
+	t := ts.T()
+
+	refreshToken, err := ts.Server.RefreshTokenUsecase.GrantAuthenticatedUser(context.Background(), ts.User)
+	require.NoError(t, err)
+
+	resp := &api.AdminUserResponse{}
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath + "/" + ts.User.ID + "/block").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.True(t, resp.Blocked)
+
+	_, err = ts.Server.RefreshTokenUsecase.FindRefreshTokenByID(context.Background(), refreshToken.ID)
+	assert.True(t, errors.Is(err, database.ErrNotFound))
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.TokenPath).
+		FormData("grant_type", "password").
+		FormData("username", "test@example.com").
+		FormData("password", "password").
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath + "/" + ts.User.ID + "/unblock").
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	authTokenHelper(t, ts.Server.API, "test@example.com", "password")
+
+	filter := &auditlog.Filter{
+		Actions:   []auditlog.Action{auditlog.ActionAccountBlock, auditlog.ActionAccountUnblock},
+		SubjectID: ts.User.ID,
+	}
+
+	events, _, err := ts.Server.AuditLogUsecase.FindAllEvents(context.Background(), filter, "", 0)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func (ts *AdminTestSuite) TestBlockOutsideRequest() {
+	t := ts.T()
+
+	// the commands block users without a request, recording their actor
+	ctx := user.NewActorContext(context.Background(), "cli")
+
+	u, err := ts.Server.API.BlockUser(ctx, ts.User)
+	require.NoError(t, err)
+	assert.True(t, u.Blocked)
+
+	filter := &auditlog.Filter{
+		Actions:   []auditlog.Action{auditlog.ActionAccountBlock},
+		SubjectID: ts.User.ID,
+	}
+
+	events, _, err := ts.Server.AuditLogUsecase.FindAllEvents(ctx, filter, "", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "cli", events[0].ActorID)
+}
+
+func (ts *AdminTestSuite) TestCreateOutsideRequest() {
+	t := ts.T()
+
+	ctx := user.NewActorContext(context.Background(), "cli")
+
+	u, passwordAccount, err := ts.Server.API.CreateUser(ctx, &user.User{
+		Email:    "cli@example.com",
+		Username: "cli",
+	}, []byte("password"), true)
+	require.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+	assert.Equal(t, u.ID, passwordAccount.UserID)
+
+	filter := &auditlog.Filter{
+		Actions:   []auditlog.Action{auditlog.ActionSignup},
+		SubjectID: u.ID,
+	}
+
+	events, _, err := ts.Server.AuditLogUsecase.FindAllEvents(ctx, filter, "", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "cli", events[0].ActorID)
+
+	_, _, err = ts.Server.API.CreateUser(ctx, &user.User{
+		Email:    "cli@example.com",
+		Username: "cli",
+	}, []byte("password"), false)
+
+	var httpErr *api.HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, httpErr.Code)
+}
+
+func (ts *AdminTestSuite) TestLogout() {
+	t := ts.T()
+
+	refreshToken, err := ts.Server.RefreshTokenUsecase.GrantAuthenticatedUser(context.Background(), ts.User)
+	require.NoError(t, err)
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath + "/" + ts.User.ID + "/logout").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	_, err = ts.Server.RefreshTokenUsecase.FindRefreshTokenByID(context.Background(), refreshToken.ID)
+	assert.True(t, errors.Is(err, database.ErrNotFound))
+
+	filter := &auditlog.Filter{
+		Actions:   []auditlog.Action{auditlog.ActionLogout},
+		SubjectID: ts.User.ID,
+	}
+
+	events, _, err := ts.Server.AuditLogUsecase.FindAllEvents(context.Background(), filter, "", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, true, events[0].MetaData["admin"])
+}
+
+func (ts *AdminTestSuite) TestDelete() {
+	t := ts.T()
+
+	ts.adminRequest().
+		Delete(api.AdminUsersPath + "/" + ts.User.ID).
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	_, err := ts.Server.UserUsecase.FindUserByID(context.Background(), ts.User.ID)
+	assert.True(t, errors.Is(err, database.ErrNotFound))
+}
+
+func (ts *AdminTestSuite) TestHistory() {
+	t := ts.T()
+
+	ts.adminRequest().
+		Patch(api.AdminUsersPath + "/" + ts.User.ID).
+		JSON(&api.AdminUserUpdateRequest{GivenName: "Test"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	ts.adminRequest().
+		Patch(api.AdminUsersPath + "/" + ts.User.ID).
+		JSON(&api.AdminUserUpdateRequest{GivenName: "Changed"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	resp := &api.AdminUserVersionListResponse{}
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath + "/" + ts.User.ID + "/history").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	// create, confirm and two updates
+	require.Len(t, resp.Versions, 4)
+
+	latest := resp.Versions[0]
+	assert.Equal(t, 4, latest.Version)
+	assert.Equal(t, "update", latest.Action)
+	assert.Equal(t, "admin_api_key", latest.ActorID)
+	require.Len(t, latest.Changes, 1)
+	assert.Equal(t, "given_name", latest.Changes[0].Field)
+	assert.Equal(t, "Test", latest.Changes[0].From)
+	assert.Equal(t, "Changed", latest.Changes[0].To)
+
+	assert.Equal(t, "create", resp.Versions[3].Action)
+	for _, c := range resp.Versions[3].Changes {
+		if c.Field == "password" {
+			assert.Nil(t, c.From)
+			assert.Nil(t, c.To)
+		}
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		restored := &api.AdminUserResponse{}
+
+		ts.adminRequest().
+			Post(api.AdminUsersPath + "/" + ts.User.ID + "/history/3/restore").
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(restored)
+
+		assert.Equal(t, "Test", restored.GivenName)
+
+		v := &api.AdminUserVersionResponse{}
+
+		ts.adminRequest().
+			Get(api.AdminUsersPath + "/" + ts.User.ID + "/history/5").
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(v)
+
+		assert.Equal(t, "restore", v.Action)
+		assert.Equal(t, "Test", v.User.GivenName)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ts.adminRequest().
+			Get(api.AdminUsersPath + "/" + ts.User.ID + "/history/99").
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+	})
+}
+
+func (ts *AdminTestSuite) TestRestoreKeepsPassword() {
+	t := ts.T()
+
+	ts.adminRequest().
+		Patch(api.AdminUsersPath + "/" + ts.User.ID).
+		JSON(&api.AdminUserUpdateRequest{Password: "new_password"}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	// create, confirm and the password change
+	v, err := ts.Server.UserUsecase.FindUserVersion(context.Background(), ts.User.ID, 3)
+	require.NoError(t, err)
+	assert.Empty(t, v.User.PasswordHash)
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath + "/" + ts.User.ID + "/history/2/restore").
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	authTokenHelper(t, ts.Server.API, ts.User.Email, "new_password")
+
+	_, err = ts.Server.UserUsecase.Authenticate(context.Background(), ts.User.Email, []byte("password"))
+	assert.Error(t, err)
+}
+
+func (ts *AdminTestSuite) TestRestoreDeleted() {
+	t := ts.T()
+
+	ctx := context.Background()
+
+	_, err := ts.Server.AccountUsecase.CreateAccount(ctx, &account.Account{
+		UserID:      ts.User.ID,
+		Provider:    account.ProviderTypePassword,
+		FederatedID: ts.User.Email,
+	})
+	require.NoError(t, err)
+
+	_, err = ts.Server.RoleUsecase.CreateRole(ctx, &role.Role{ID: "editor"})
+	require.NoError(t, err)
+
+	err = ts.Server.RoleUsecase.AssignRole(ctx, ts.User.ID, "editor")
+	require.NoError(t, err)
+
+	ts.adminRequest().
+		Delete(api.AdminUsersPath + "/" + ts.User.ID).
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	resp := &api.AdminUserVersionListResponse{}
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath + "/" + ts.User.ID + "/history").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	require.Len(t, resp.Versions, 3)
+	assert.Equal(t, "delete", resp.Versions[0].Action)
+
+	t.Run("identifier taken", func(t *testing.T) {
+		other, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+			Email:    ts.User.Email,
+			Username: "other",
+			Password: "password",
+		})
+		require.NoError(t, err)
+
+		ts.adminRequest().
+			Post(api.AdminUsersPath + "/" + ts.User.ID + "/history/2/restore").
+			Expect(t).
+			Status(http.StatusUnprocessableEntity).
+			End()
+
+		err = ts.Server.UserUsecase.PurgeUser(context.Background(), other.ID)
+		require.NoError(t, err)
+	})
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath + "/" + ts.User.ID + "/history/2/restore").
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	authTokenHelper(t, ts.Server.API, ts.User.Email, "password")
+
+	// kept while the user was deleted
+	accounts, err := ts.Server.AccountUsecase.FindAllForUser(ctx, ts.User.ID)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 1)
+
+	roles, err := ts.Server.RoleUsecase.FindAllForUser(ctx, ts.User.ID)
+	require.NoError(t, err)
+	assert.Len(t, roles, 1)
+}
+
+func (ts *AdminTestSuite) TestRoles() {
+	t := ts.T()
+
+	req := &api.AdminRoleCreateRequest{
+		ID:          "editor",
+		Description: "Edits posts",
+		Permissions: []string{"posts:read", "posts:write"},
+	}
+
+	ts.adminRequest().
+		Post(api.AdminRolesPath).
+		JSON(req).
+		Expect(t).
+		Status(http.StatusCreated).
+		End()
+
+	ts.adminRequest().
+		Post(api.AdminRolesPath).
+		JSON(req).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	ts.adminRequest().
+		Post(api.AdminRolesPath).
+		JSON(&api.AdminRoleCreateRequest{ID: "not valid"}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	resp := &api.AdminRoleResponse{}
+
+	ts.adminRequest().
+		Patch(api.AdminRolesPath + "/editor").
+		JSON(&api.AdminRoleUpdateRequest{Permissions: []string{"posts:read"}}).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Equal(t, "Edits posts", resp.Description)
+	assert.Equal(t, []string{"posts:read"}, resp.Permissions)
+
+	list := &api.AdminRoleListResponse{}
+
+	ts.adminRequest().
+		Get(api.AdminRolesPath).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(list)
+
+	require.Len(t, list.Roles, 1)
+	assert.Equal(t, "editor", list.Roles[0].ID)
+
+	ts.adminRequest().
+		Put(api.AdminUsersPath + "/" + ts.User.ID + "/roles/unknown").
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	ts.adminRequest().
+		Put(api.AdminUsersPath + "/" + ts.User.ID + "/roles/editor").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	userRoles := &api.AdminRoleListResponse{}
+
+	ts.adminRequest().
+		Get(api.AdminUsersPath + "/" + ts.User.ID + "/roles").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(userRoles)
+
+	require.Len(t, userRoles.Roles, 1)
+	assert.Equal(t, "editor", userRoles.Roles[0].ID)
+
+	ts.adminRequest().
+		Delete(api.AdminUsersPath + "/" + ts.User.ID + "/roles/editor").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	roles, err := ts.Server.RoleUsecase.FindAllForUser(context.Background(), ts.User.ID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	ts.adminRequest().
+		Delete(api.AdminRolesPath + "/editor").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	ts.adminRequest().
+		Get(api.AdminRolesPath + "/editor").
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (ts *AdminTestSuite) TestResendConfirmation() {
+	t := ts.T()
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath + "/" + ts.User.ID + "/confirmation").
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	unconfirmed, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:    "unconfirmed@example.com",
+		Username: "unconfirmed",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	ts.adminRequest().
+		Post(api.AdminUsersPath + "/" + unconfirmed.ID + "/confirmation").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	unconfirmed, err = ts.Server.UserUsecase.FindUserByID(context.Background(), unconfirmed.ID)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, unconfirmed.ConfirmationToken)
+}
+
+// failingVersionRepository fails to delete the history of users.
+type failingVersionRepository struct {
+	user.UserVersionRepository
+}
+
+func (failingVersionRepository) DeleteAllForUser(context.Context, string) error {
+	return errors.New("delete failed")
+}
+
+// TestAdminPurgeAtomic checks that a failed purge leaves the user together
+// with its accounts.
+func TestAdminPurgeAtomic(t *testing.T) {
+	for name, setup := range atomicDatabases {
+		setup := setup
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			repos, cleanup := setup(t)
+			defer cleanup()
+
+			server, _ := newTestServer(t, testServerOptions{
+				AccountRepository:     repos.accounts,
+				UserRepository:        repos.users,
+				UserVersionRepository: failingVersionRepository{repos.versions},
+				Transactor:            repos.transactor,
+			})
+			defer server.API.Close()
+
+			u, err := server.UserUsecase.CreateUser(ctx, &user.User{
+				Email:    "atomic@example.com",
+				Username: "atomic",
+			})
+			require.NoError(t, err)
+
+			_, err = server.AccountUsecase.CreateAccount(ctx, &account.Account{
+				UserID:      u.ID,
+				Provider:    account.ProviderTypePassword,
+				FederatedID: u.Email,
+			})
+			require.NoError(t, err)
+
+			err = server.API.DeleteUser(ctx, u, true)
+			require.Error(t, err)
+
+			_, err = server.UserUsecase.FindUserByID(ctx, u.ID)
+			require.NoError(t, err)
+
+			count, err := repos.accounts.Count(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+const adminUserVersionVar = "version"
+
+// AdminUserHistoryListHandler lists the versions of the user, newest first.
+// The history of deleted users is available too.
+func (s *server) AdminUserHistoryListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := mux.Vars(r)[adminUserIDVar]
+	query := r.URL.Query()
+
+	limit, err := queryLimit(query.Get("limit"))
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	versions, nextCursor, err := s.userUsecase.FindAllUserVersions(ctx, userID, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding user history").WithInternalError(err))
+		return
+	}
+
+	if len(versions) == 0 && query.Get("cursor") == "" {
+		s.handleError(w, r, notFoundError("User not found"))
+		return
+	}
+
+	resp := &AdminUserVersionListResponse{
+		Versions:   make([]*AdminUserVersionResponse, 0, len(versions)),
+		NextCursor: nextCursor,
+	}
+
+	for _, v := range versions {
+		resp.Versions = append(resp.Versions, adminUserVersionResponse(v))
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// AdminUserVersionGetHandler returns the version of the user.
+func (s *server) AdminUserVersionGetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, version, err := adminUserVersionVars(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	v, err := s.userUsecase.FindUserVersion(ctx, userID, version)
+	if err != nil {
+		s.handleError(w, r, adminUserVersionError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminUserVersionResponse(v))
+}
+
+// AdminUserVersionRestoreHandler restores the user to the state of the
+// version. Deleted users are undeleted, but their accounts, roles and
+// memberships are not restored.
+func (s *server) AdminUserVersionRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, version, err := adminUserVersionVars(r)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": userID, "version": version})
+
+	restored, err := s.userUsecase.RestoreUserVersion(ctx, userID, version)
+	if err != nil {
+		s.handleError(w, r, adminUserVersionError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).Info("user version restored by admin")
+
+	accounts, err := s.accountUsecase.FindAllForUser(ctx, restored.ID)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding accounts").WithInternalError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminUserResponse(restored, accounts))
+}
+
+func adminUserVersionVars(r *http.Request) (string, int, error) {
+	vars := mux.Vars(r)
+
+	version, err := strconv.Atoi(vars[adminUserVersionVar])
+	if err != nil || version < 1 {
+		return "", 0, badRequestError("Invalid version: %s", vars[adminUserVersionVar])
+	}
+
+	return vars[adminUserIDVar], version, nil
+}
+
+func adminUserVersionError(err error) *HTTPError {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return notFoundError("User version not found")
+	case errors.Is(err, database.ErrAlreadyExists):
+		return unprocessableEntityError("Email address or username already registered by another user")
+	default:
+		return internalServerError("Database error").WithInternalError(err)
+	}
+}
+
+func adminUserVersionResponse(v *user.Version) *AdminUserVersionResponse {
+	resp := &AdminUserVersionResponse{
+		Version:   v.Version,
+		Action:    string(v.Action),
+		ActorID:   v.ActorID,
+		Changes:   make([]*UserChangeResponse, 0, len(v.Changes)),
+		User:      adminUserResponse(v.User, nil),
+		CreatedAt: v.CreatedAt,
+	}
+
+	for _, c := range v.Changes {
+		resp.Changes = append(resp.Changes, &UserChangeResponse{
+			Field: c.Field,
+			From:  c.From,
+			To:    c.To,
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+const adminWebhookDeliveryIDVar = "delivery_id"
+
+// AdminWebhookDeliveryListHandler lists webhook deliveries, oldest first.
+// Dead deliveries are listed with the 'dead' status.
+func (s *server) AdminWebhookDeliveryListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	status := webhook.Status(query.Get("status"))
+	switch status {
+	case "", webhook.StatusPending, webhook.StatusDelivered, webhook.StatusDead:
+	default:
+		s.handleError(w, r, badRequestError("Invalid status: %s", status))
+		return
+	}
+
+	limit, err := queryLimit(query.Get("limit"))
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	deliveries, nextCursor, err := s.webhookUsecase.FindAllDeliveries(ctx, status, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding deliveries").WithInternalError(err))
+		return
+	}
+
+	resp := &AdminWebhookDeliveryListResponse{
+		Deliveries: make([]*AdminWebhookDeliveryResponse, 0, len(deliveries)),
+		NextCursor: nextCursor,
+	}
+
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, adminWebhookDeliveryResponse(d))
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// AdminWebhookDeliveryGetHandler returns the delivery details, including
+// the payload and the outcome of the last attempt.
+func (s *server) AdminWebhookDeliveryGetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id := mux.Vars(r)[adminWebhookDeliveryIDVar]
+
+	delivery, err := s.webhookUsecase.FindDeliveryByID(ctx, id)
+	if err != nil {
+		s.handleError(w, r, adminWebhookDeliveryError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, adminWebhookDeliveryResponse(delivery))
+}
+
+// AdminWebhookDeliveryRedeliverHandler queues the delivery again.
+func (s *server) AdminWebhookDeliveryRedeliverHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id := mux.Vars(r)[adminWebhookDeliveryIDVar]
+
+	delivery, err := s.webhookUsecase.Redeliver(ctx, id)
+	if err != nil {
+		s.handleError(w, r, adminWebhookDeliveryError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"delivery_id": delivery.ID}).
+		Info("webhook redelivered by admin")
+
+	mustSendJSON(w, http.StatusAccepted, adminWebhookDeliveryResponse(delivery))
+}
+
+func adminWebhookDeliveryError(err error) error {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return notFoundError("Delivery not found")
+	case errors.Is(err, webhook.ErrDeliveryPending):
+		return unprocessableEntityError("Delivery is pending")
+	default:
+		return internalServerError("Database error finding delivery").WithInternalError(err)
+	}
+}
+
+func adminWebhookDeliveryResponse(d *webhook.Delivery) *AdminWebhookDeliveryResponse {
+	resp := &AdminWebhookDeliveryResponse{
+		DeliveryID:     d.ID,
+		EventID:        d.EventID,
+		Event:          string(d.Event),
+		URL:            d.URL,
+		Payload:        d.Payload,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+
+	if d.Status == webhook.StatusPending {
+		nextAttemptAt := d.NextAttemptAt
+		resp.NextAttemptAt = &nextAttemptAt
+	}
+	if !d.LastAttemptAt.IsZero() {
+		lastAttemptAt := d.LastAttemptAt
+		resp.LastAttemptAt = &lastAttemptAt
+	}
+
+	return resp
+}
@@ -6,9 +6,18 @@ import (
 	"net/http"
 
 	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/backup"
 	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
 	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/role"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/hook"
 	"github.com/zbiljic/authzy/pkg/jwt"
 	"github.com/zbiljic/authzy/pkg/logger"
 	"github.com/zbiljic/authzy/pkg/mailer"
@@ -21,6 +30,33 @@ const SlashSeparator = "/"
 type Service interface {
 	http.Handler
 	io.Closer
+
+	// PurgeDeletedUsers deletes the users whose deletion grace period has
+	// expired.
+	PurgeDeletedUsers(ctx context.Context) error
+
+	// CreateUser creates the user together with its password account.
+	CreateUser(ctx context.Context, u *user.User, password []byte, emailVerified bool) (*user.User, *account.Account, error)
+
+	// UpdatePassword sets the password of the user, at the revision of the
+	// user.
+	UpdatePassword(ctx context.Context, u *user.User, password []byte) (*user.User, error)
+
+	// ConfirmUser verifies the email of the user.
+	ConfirmUser(ctx context.Context, u *user.User) (*user.User, error)
+
+	// BlockUser blocks the user and revokes all its refresh tokens.
+	BlockUser(ctx context.Context, u *user.User) (*user.User, error)
+
+	// UnblockUser allows the blocked user to sign in again.
+	UnblockUser(ctx context.Context, u *user.User) (*user.User, error)
+
+	// LogoutUser revokes all refresh tokens of the user.
+	LogoutUser(ctx context.Context, u *user.User) error
+
+	// DeleteUser soft deletes the user and revokes its refresh tokens, or
+	// purges it together with its history, roles, memberships and accounts.
+	DeleteUser(ctx context.Context, u *user.User, purge bool) error
 }
 
 type server struct {
@@ -29,10 +65,19 @@ type server struct {
 	log    logger.Logger
 	config *config.Config
 
-	jwtService          jwt.Service
-	accountUsecase      account.AccountUsecase
-	refreshTokenUsecase refreshtoken.RefreshTokenUsecase
-	userUsecase         user.UserUsecase
+	jwtService           jwt.Service
+	hookCaller           hook.Caller
+	accountUsecase       account.AccountUsecase
+	auditLogUsecase      auditlog.AuditLogUsecase
+	organizationUsecase  organization.OrganizationUsecase
+	policyUsecase        policy.PolicyUsecase
+	refreshTokenUsecase  refreshtoken.RefreshTokenUsecase
+	relationTupleUsecase relationtuple.RelationTupleUsecase
+	roleUsecase          role.RoleUsecase
+	userUsecase          user.UserUsecase
+	webhookUsecase       webhook.WebhookUsecase
+	backupDatabase       backup.Database
+	transactor           database.Transactor
 }
 
 // New will create a and initialize a new API service.
@@ -41,16 +86,33 @@ func New(
 	config *config.Config,
 	jwtService jwt.Service,
 	accountUsecase account.AccountUsecase,
+	auditLogUsecase auditlog.AuditLogUsecase,
+	organizationUsecase organization.OrganizationUsecase,
+	policyUsecase policy.PolicyUsecase,
 	refreshTokenUsecase refreshtoken.RefreshTokenUsecase,
+	relationTupleUsecase relationtuple.RelationTupleUsecase,
+	roleUsecase role.RoleUsecase,
 	userUsecase user.UserUsecase,
+	webhookUsecase webhook.WebhookUsecase,
+	backupDatabase backup.Database,
+	transactor database.Transactor,
 ) Service {
 	s := &server{
-		log:                 log,
-		config:              config,
-		jwtService:          jwtService,
-		accountUsecase:      accountUsecase,
-		refreshTokenUsecase: refreshTokenUsecase,
-		userUsecase:         userUsecase,
+		log:                  log,
+		config:               config,
+		jwtService:           jwtService,
+		hookCaller:           hook.NewCaller(log, config.API.Hooks),
+		accountUsecase:       accountUsecase,
+		auditLogUsecase:      auditLogUsecase,
+		organizationUsecase:  organizationUsecase,
+		policyUsecase:        policyUsecase,
+		refreshTokenUsecase:  refreshTokenUsecase,
+		relationTupleUsecase: relationTupleUsecase,
+		roleUsecase:          roleUsecase,
+		userUsecase:          userUsecase,
+		webhookUsecase:       webhookUsecase,
+		backupDatabase:       backupDatabase,
+		transactor:           transactor,
 	}
 
 	s.setupRouting()
@@ -60,6 +122,7 @@ func New(
 
 func (s *server) Close() error {
 	s.log.Info("api shutting down")
+
 	return nil
 }
 
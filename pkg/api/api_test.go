@@ -12,15 +12,36 @@ import (
 
 	"github.com/zbiljic/authzy/pkg/api"
 	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/backup"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
 	"github.com/zbiljic/authzy/pkg/domain/account"
 	account_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/account/storage/jsonmutexdb"
 	accountuc "github.com/zbiljic/authzy/pkg/domain/account/usecases"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	auditlog_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/auditlog/storage/jsonmutexdb"
+	auditloguc "github.com/zbiljic/authzy/pkg/domain/auditlog/usecases"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	organization_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/organization/storage/jsonmutexdb"
+	organizationuc "github.com/zbiljic/authzy/pkg/domain/organization/usecases"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	policy_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/policy/storage/jsonmutexdb"
+	policyuc "github.com/zbiljic/authzy/pkg/domain/policy/usecases"
 	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
 	refreshtoken_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/jsonmutexdb"
 	refreshtokenuc "github.com/zbiljic/authzy/pkg/domain/refreshtoken/usecases"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	relationtuple_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/jsonmutexdb"
+	relationtupleuc "github.com/zbiljic/authzy/pkg/domain/relationtuple/usecases"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	role_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/role/storage/jsonmutexdb"
+	roleuc "github.com/zbiljic/authzy/pkg/domain/role/usecases"
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
 	useruc "github.com/zbiljic/authzy/pkg/domain/user/usecases"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	webhook_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/webhook/storage/jsonmutexdb"
+	webhookuc "github.com/zbiljic/authzy/pkg/domain/webhook/usecases"
 	"github.com/zbiljic/authzy/pkg/hash"
 	mockhasher "github.com/zbiljic/authzy/pkg/hash/mock"
 	"github.com/zbiljic/authzy/pkg/jwt"
@@ -32,23 +53,47 @@ type TestServer struct {
 	Addr string
 	API  api.Service
 
-	Hasher                 hash.Hasher
-	AccountRepository      account.AccountRepository
-	AccountUsecase         account.AccountUsecase
-	RefreshTokenRepository refreshtoken.RefreshTokenRepository
-	RefreshTokenUsecase    refreshtoken.RefreshTokenUsecase
-	UserRepository         user.UserRepository
-	UserUsecase            user.UserUsecase
+	Hasher                  hash.Hasher
+	AccountRepository       account.AccountRepository
+	AccountUsecase          account.AccountUsecase
+	AuditLogRepository      auditlog.AuditLogRepository
+	AuditLogUsecase         auditlog.AuditLogUsecase
+	OrganizationRepository  organization.OrganizationRepository
+	InvitationRepository    organization.InvitationRepository
+	OrganizationUsecase     organization.OrganizationUsecase
+	PolicyRepository        policy.PolicyRepository
+	PolicyUsecase           policy.PolicyUsecase
+	RefreshTokenRepository  refreshtoken.RefreshTokenRepository
+	RefreshTokenUsecase     refreshtoken.RefreshTokenUsecase
+	RelationTupleRepository relationtuple.RelationTupleRepository
+	RelationTupleUsecase    relationtuple.RelationTupleUsecase
+	RoleRepository          role.RoleRepository
+	RoleUsecase             role.RoleUsecase
+	UserRepository          user.UserRepository
+	UserVersionRepository   user.UserVersionRepository
+	UserUsecase             user.UserUsecase
+	DeliveryRepository      webhook.DeliveryRepository
+	WebhookUsecase          webhook.WebhookUsecase
 }
 
 type testServerOptions struct {
-	Log                    logger.Logger
-	Config                 *config.Config
-	Hasher                 hash.Hasher
-	AccountRepository      account.AccountRepository
-	RefreshTokenRepository refreshtoken.RefreshTokenRepository
-	UserRepository         user.UserRepository
-	JwtService             jwt.Service
+	Log                     logger.Logger
+	Config                  *config.Config
+	Hasher                  hash.Hasher
+	AccountRepository       account.AccountRepository
+	AuditLogRepository      auditlog.AuditLogRepository
+	OrganizationRepository  organization.OrganizationRepository
+	InvitationRepository    organization.InvitationRepository
+	PolicyRepository        policy.PolicyRepository
+	RefreshTokenRepository  refreshtoken.RefreshTokenRepository
+	RelationTupleRepository relationtuple.RelationTupleRepository
+	RoleRepository          role.RoleRepository
+	UserRepository          user.UserRepository
+	UserVersionRepository   user.UserVersionRepository
+	DeliveryRepository      webhook.DeliveryRepository
+	JwtService              jwt.Service
+	BackupDatabase          backup.Database
+	Transactor              database.Transactor
 }
 
 func newTestServer(t *testing.T, o testServerOptions) (*TestServer, *config.Config) {
@@ -73,12 +118,43 @@ func newTestServer(t *testing.T, o testServerOptions) (*TestServer, *config.Conf
 	if o.AccountRepository == nil {
 		o.AccountRepository, _ = account_jsonmutexdb.NewAccountRepository(nil, "")
 	}
+	if o.AuditLogRepository == nil {
+		o.AuditLogRepository, _ = auditlog_jsonmutexdb.NewAuditLogRepository(nil, nil, "")
+	}
+	if o.OrganizationRepository == nil {
+		o.OrganizationRepository, _ = organization_jsonmutexdb.NewOrganizationRepository(nil, "")
+	}
+	if o.InvitationRepository == nil {
+		o.InvitationRepository, _ = organization_jsonmutexdb.NewInvitationRepository(nil, "")
+	}
+	if o.PolicyRepository == nil {
+		o.PolicyRepository, _ = policy_jsonmutexdb.NewPolicyRepository(nil, "")
+	}
 	if o.RefreshTokenRepository == nil {
 		o.RefreshTokenRepository, _ = refreshtoken_jsonmutexdb.NewRefreshTokenRepository(nil, "")
 	}
+	if o.RelationTupleRepository == nil {
+		o.RelationTupleRepository, _ = relationtuple_jsonmutexdb.NewRelationTupleRepository(nil, "")
+	}
+	if o.RoleRepository == nil {
+		o.RoleRepository, _ = role_jsonmutexdb.NewRoleRepository(nil, "")
+	}
 	if o.UserRepository == nil {
 		o.UserRepository, _ = user_jsonmutexdb.NewUserRepository(nil, "")
 	}
+	if o.UserVersionRepository == nil {
+		o.UserVersionRepository, _ = user_jsonmutexdb.NewUserVersionRepository(nil, "")
+	}
+	if o.DeliveryRepository == nil {
+		o.DeliveryRepository, _ = webhook_jsonmutexdb.NewDeliveryRepository(nil, "")
+	}
+	if o.BackupDatabase == nil {
+		ls, _ := database_jsonmutexdb.NewLoadSaver("")
+		o.BackupDatabase = backup.NewJSONMutexDB(ls, "")
+	}
+	if o.Transactor == nil {
+		o.Transactor = database.NopTransactor()
+	}
 	if o.JwtService == nil {
 		if o.Config.API.JWT.ClaimsNamespace == "" {
 			o.Config.API.JWT.ClaimsNamespace = "https://example.test/jwt/claims"
@@ -124,31 +200,65 @@ func newTestServer(t *testing.T, o testServerOptions) (*TestServer, *config.Conf
 	}
 
 	accountUsecase := accountuc.NewAccountUsecase(o.AccountRepository)
+	auditLogUsecase := auditloguc.NewAuditLogUsecase(o.AuditLogRepository)
+	organizationUsecase := organizationuc.NewOrganizationUsecase(*o.Config.Organizations, o.OrganizationRepository, o.InvitationRepository)
+	policyUsecase, err := policyuc.NewPolicyUsecase(o.PolicyRepository)
+	if err != nil {
+		t.Fatal(err)
+	}
 	refreshTokenUsecase := refreshtokenuc.NewRefreshTokenUsecase(o.RefreshTokenRepository)
-	userUsecase := useruc.NewUserUsecase(o.Hasher, o.UserRepository)
+	relationTupleUsecase, err := relationtupleuc.NewRelationTupleUsecase(*o.Config.Authz, o.RelationTupleRepository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleUsecase := roleuc.NewRoleUsecase(o.RoleRepository)
+	userUsecase := useruc.NewUserUsecase(o.Log, o.Hasher, o.UserRepository, o.UserVersionRepository)
+	webhookUsecase := webhookuc.NewWebhookUsecase(*o.Config.Webhooks, o.DeliveryRepository)
 
 	s := api.New(
 		o.Log,
 		o.Config,
 		o.JwtService,
 		accountUsecase,
+		auditLogUsecase,
+		organizationUsecase,
+		policyUsecase,
 		refreshTokenUsecase,
+		relationTupleUsecase,
+		roleUsecase,
 		userUsecase,
+		webhookUsecase,
+		o.BackupDatabase,
+		o.Transactor,
 	)
 
 	ts := httptest.NewServer(s)
 	t.Cleanup(ts.Close)
 
 	testServer := &TestServer{
-		Addr:                   ts.Listener.Addr().String(),
-		API:                    s,
-		Hasher:                 o.Hasher,
-		AccountRepository:      o.AccountRepository,
-		AccountUsecase:         accountUsecase,
-		RefreshTokenRepository: o.RefreshTokenRepository,
-		RefreshTokenUsecase:    refreshTokenUsecase,
-		UserRepository:         o.UserRepository,
-		UserUsecase:            userUsecase,
+		Addr:                    ts.Listener.Addr().String(),
+		API:                     s,
+		Hasher:                  o.Hasher,
+		AccountRepository:       o.AccountRepository,
+		AccountUsecase:          accountUsecase,
+		AuditLogRepository:      o.AuditLogRepository,
+		AuditLogUsecase:         auditLogUsecase,
+		OrganizationRepository:  o.OrganizationRepository,
+		InvitationRepository:    o.InvitationRepository,
+		OrganizationUsecase:     organizationUsecase,
+		PolicyRepository:        o.PolicyRepository,
+		PolicyUsecase:           policyUsecase,
+		RefreshTokenRepository:  o.RefreshTokenRepository,
+		RefreshTokenUsecase:     refreshTokenUsecase,
+		RelationTupleRepository: o.RelationTupleRepository,
+		RelationTupleUsecase:    relationTupleUsecase,
+		RoleRepository:          o.RoleRepository,
+		RoleUsecase:             roleUsecase,
+		UserRepository:          o.UserRepository,
+		UserVersionRepository:   o.UserVersionRepository,
+		UserUsecase:             userUsecase,
+		DeliveryRepository:      o.DeliveryRepository,
+		WebhookUsecase:          webhookUsecase,
 	}
 
 	return testServer, o.Config
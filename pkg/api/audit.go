@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// audit records the security event in the audit log, together with the
+// details of the request. The actor defaults to the subject of the access
+// token, if any. Failing to record the event does not fail the request.
+func (s *server) audit(r *http.Request, event *auditlog.Event) {
+	event.UserAgent = r.UserAgent()
+
+	s.recordAudit(r.Context(), event)
+}
+
+// recordAudit records the security event in the audit log, together with the
+// details of the request carried in the context, if any. The actor defaults
+// to the subject of the access token, and then to the actor of the context.
+func (s *server) recordAudit(ctx context.Context, event *auditlog.Event) {
+	if event.ActorID == "" {
+		if token := getToken(ctx); token != nil {
+			event.ActorID = (*token).Subject()
+		} else {
+			event.ActorID = user.ActorFromContext(ctx)
+		}
+	}
+	if userIP := getUserIP(ctx); userIP != nil {
+		event.IP = userIP.String()
+	}
+	event.RequestID = getRequestID(ctx)
+
+	if _, err := s.auditLogUsecase.Record(ctx, event); err != nil {
+		s.log.WithContext(ctx).
+			WithFields(logger.Fields{"action": event.Action, "outcome": event.Outcome}).
+			Errorf("record audit event: %v", err)
+	}
+}
+
+// UserSecurityEventListHandler lists the security events of the user.
+func (s *server) UserSecurityEventListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jwtToken := *getToken(ctx)
+
+	sub := jwtToken.Subject()
+	if sub == "" {
+		s.handleError(w, r, badRequestError("Could not read 'sub' claim"))
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter, err := auditFilterFromQuery(query)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	filter.SubjectID = sub
+
+	limit, err := queryLimit(query.Get("limit"))
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	events, nextCursor, err := s.auditLogUsecase.FindAllEvents(ctx, filter, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding events").WithInternalError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, auditEventListResponse(events, nextCursor))
+}
+
+// auditFilterFromQuery reads the filter shared by the audit log endpoints.
+// Actions may be repeated, or separated by commas.
+func auditFilterFromQuery(query url.Values) (*auditlog.Filter, error) {
+	filter := &auditlog.Filter{}
+
+	for _, v := range query["action"] {
+		for _, action := range strings.Split(v, ",") {
+			if action = strings.TrimSpace(action); action != "" {
+				filter.Actions = append(filter.Actions, auditlog.Action(action))
+			}
+		}
+	}
+
+	switch outcome := auditlog.Outcome(query.Get("outcome")); outcome {
+	case "", auditlog.OutcomeSuccess, auditlog.OutcomeFailure:
+		filter.Outcome = outcome
+	default:
+		return nil, badRequestError("Invalid outcome: %s", outcome)
+	}
+
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, badRequestError("Invalid %s: %s", name, v)
+		}
+
+		*t = parsed
+	}
+
+	return filter, nil
+}
+
+func auditEventResponse(e *auditlog.Event) *AuditEventResponse {
+	return &AuditEventResponse{
+		EventID:   e.ID,
+		Action:    string(e.Action),
+		Outcome:   string(e.Outcome),
+		ActorID:   e.ActorID,
+		SubjectID: e.SubjectID,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		RequestID: e.RequestID,
+		Reason:    e.Reason,
+		MetaData:  e.MetaData,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+func auditEventListResponse(events []*auditlog.Event, nextCursor string) *AuditEventListResponse {
+	resp := &AuditEventListResponse{
+		Events:     make([]*AuditEventResponse, 0, len(events)),
+		NextCursor: nextCursor,
+	}
+
+	for _, e := range events {
+		resp.Events = append(resp.Events, auditEventResponse(e))
+	}
+
+	return resp
+}
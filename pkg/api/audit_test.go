@@ -0,0 +1,272 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+type AuditTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+
+	user *user.User
+}
+
+//nolint:errcheck
+func (ts *AuditTestSuite) SetupTest() {
+	// truncate
+	ts.Server.AuditLogRepository.DeleteAll(context.Background())
+	ts.Server.RefreshTokenRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+
+	// create test user
+	u, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	})
+	require.NoError(ts.T(), err)
+
+	u, err = ts.Server.UserUsecase.ConfirmUser(context.Background(), u.ID)
+	require.NoError(ts.T(), err)
+
+	ts.user = u
+}
+
+func TestAudit(t *testing.T) {
+	ts := &AuditTestSuite{}
+
+	ts.Server, _ = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				Admin: &config.AdminConfig{
+					APIKey: testAdminAPIKey,
+				},
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *AuditTestSuite) adminRequest() *apitest.APITest {
+	return apitest.New().
+		Handler(ts.Server.API).
+		Intercept(func(r *http.Request) {
+			r.Header.Set(xhttp.Authorization, "Bearer "+testAdminAPIKey)
+		})
+}
+
+func (ts *AuditTestSuite) failLogin() {
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.TokenPath).
+		FormData("grant_type", "password").
+		FormData("username", ts.user.Email).
+		FormData("password", "invalid").
+		Expect(ts.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (ts *AuditTestSuite) TestUserSecurityEvents() {
+	t := ts.T()
+
+	// not attributed to the user
+	ts.failLogin()
+
+	token := authTokenHelper(t, ts.Server.API, ts.user.Email, "password")
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.LogoutPath).
+		Header(xhttp.Authorization, "Bearer "+token.Token).
+		Header("User-Agent", "audit-test").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	resp := &api.AuditEventListResponse{}
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.UserSecurityEventsPath).
+		Header(xhttp.Authorization, "Bearer "+token.Token).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	require.Len(t, resp.Events, 2)
+
+	// newest first
+	assert.Equal(t, string(auditlog.ActionLogout), resp.Events[0].Action)
+	assert.Equal(t, string(auditlog.OutcomeSuccess), resp.Events[0].Outcome)
+	assert.Equal(t, ts.user.ID, resp.Events[0].ActorID)
+	assert.Equal(t, "audit-test", resp.Events[0].UserAgent)
+	assert.NotEmpty(t, resp.Events[0].RequestID)
+
+	assert.Equal(t, string(auditlog.ActionLogin), resp.Events[1].Action)
+	assert.Equal(t, string(auditlog.OutcomeSuccess), resp.Events[1].Outcome)
+	assert.Equal(t, ts.user.ID, resp.Events[1].ActorID)
+	assert.Equal(t, ts.user.ID, resp.Events[1].SubjectID)
+
+	t.Run("other user", func(t *testing.T) {
+		u, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+			Email:    "other@example.com",
+			Username: "other",
+			Password: "password",
+		})
+		require.NoError(t, err)
+
+		_, err = ts.Server.UserUsecase.ConfirmUser(context.Background(), u.ID)
+		require.NoError(t, err)
+
+		otherToken := authTokenHelper(t, ts.Server.API, u.Email, "password")
+
+		resp := &api.AuditEventListResponse{}
+
+		apitest.New().
+			Handler(ts.Server.API).
+			Get(api.UserSecurityEventsPath).
+			Header(xhttp.Authorization, "Bearer "+otherToken.Token).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(resp)
+
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, u.ID, resp.Events[0].SubjectID)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		apitest.New().
+			Handler(ts.Server.API).
+			Get(api.UserSecurityEventsPath).
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+}
+
+func (ts *AuditTestSuite) TestAdminAudit() {
+	t := ts.T()
+
+	ts.failLogin()
+	authTokenHelper(t, ts.Server.API, ts.user.Email, "password")
+
+	t.Run("all", func(t *testing.T) {
+		resp := &api.AuditEventListResponse{}
+
+		ts.adminRequest().
+			Get(api.AdminAuditPath).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(resp)
+
+		assert.Len(t, resp.Events, 2)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		resp := &api.AuditEventListResponse{}
+
+		ts.adminRequest().
+			Get(api.AdminAuditPath).
+			Query("action", string(auditlog.ActionLogin)).
+			Query("outcome", string(auditlog.OutcomeFailure)).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(resp)
+
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, string(auditlog.OutcomeFailure), resp.Events[0].Outcome)
+		assert.Equal(t, ts.user.Email, resp.Events[0].MetaData["identifier"])
+	})
+
+	t.Run("subject", func(t *testing.T) {
+		resp := &api.AuditEventListResponse{}
+
+		ts.adminRequest().
+			Get(api.AdminAuditPath).
+			Query("subject_id", ts.user.ID).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(resp)
+
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, string(auditlog.OutcomeSuccess), resp.Events[0].Outcome)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		resp := &api.AuditEventListResponse{}
+
+		ts.adminRequest().
+			Get(api.AdminAuditPath).
+			Query("limit", "1").
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(resp)
+
+		require.Len(t, resp.Events, 1)
+		require.NotEmpty(t, resp.NextCursor)
+
+		next := &api.AuditEventListResponse{}
+
+		ts.adminRequest().
+			Get(api.AdminAuditPath).
+			Query("limit", "1").
+			Query("cursor", resp.NextCursor).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(next)
+
+		require.Len(t, next.Events, 1)
+		assert.NotEqual(t, resp.Events[0].EventID, next.Events[0].EventID)
+	})
+
+	t.Run("invalid outcome", func(t *testing.T) {
+		ts.adminRequest().
+			Get(api.AdminAuditPath).
+			Query("outcome", "unknown").
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("invalid since", func(t *testing.T) {
+		ts.adminRequest().
+			Get(api.AdminAuditPath).
+			Query("since", "yesterday").
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		apitest.New().
+			Handler(ts.Server.API).
+			Get(api.AdminAuditPath).
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/lestrrat-go/jwx/jwt"
 
+	"github.com/zbiljic/authzy/pkg/domain/user"
 	xhttp "github.com/zbiljic/authzy/pkg/http"
 )
 
@@ -35,6 +36,7 @@ func (s *server) AuthHandler(next http.HandlerFunc) http.Handler {
 		}
 
 		ctx = withToken(ctx, &jwtToken)
+		ctx = user.NewActorContext(ctx, jwtToken.Subject())
 
 		next(w, r.WithContext(ctx))
 	})
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// registeredClaims are the registered JWT claims, which mapped claims and
+// hooks cannot override.
+var registeredClaims = map[string]bool{
+	"iss": true,
+	"sub": true,
+	"aud": true,
+	"exp": true,
+	"nbf": true,
+	"iat": true,
+	"jti": true,
+
+	authTimeClaim: true,
+}
+
+// customClaimNames are the names of the CustomClaims, which mapped claims
+// cannot override.
+var customClaimNames = map[string]bool{
+	"username":      true,
+	"email":         true,
+	"roles":         true,
+	"permissions":   true,
+	"org_id":        true,
+	"org_roles":     true,
+	"app_metadata":  true,
+	"user_metadata": true,
+}
+
+// mappedClaims are the claims mapped from the user by the configured claim
+// mappings.
+type mappedClaims struct {
+	Namespaced map[string]interface{}
+	TopLevel   map[string]interface{}
+}
+
+// mapClaims maps the user fields and metadata values into claims. Values
+// which are missing, or exceed the size limits, are left out.
+func (s *server) mapClaims(ctx context.Context, u *user.User) (*mappedClaims, error) {
+	result := &mappedClaims{
+		Namespaced: map[string]interface{}{},
+		TopLevel:   map[string]interface{}{},
+	}
+
+	claimsConfig := s.config.API.JWT.Claims
+	if claimsConfig == nil || len(claimsConfig.Mappings) == 0 {
+		return result, nil
+	}
+
+	userJSON, err := json.Marshal(adminUserResponse(u, nil).UserResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(userJSON, &doc); err != nil {
+		return nil, err
+	}
+
+	var size int
+
+	for _, m := range claimsConfig.Mappings {
+		if m.TopLevel {
+			if registeredClaims[m.Claim] || m.Claim == s.config.API.JWT.ClaimsNamespace {
+				continue
+			}
+		} else if customClaimNames[m.Claim] {
+			continue
+		}
+
+		value, ok := lookupPath(doc, m.Source)
+		if !ok {
+			continue
+		}
+
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(valueJSON) > claimsConfig.MaxValueSize || size+len(valueJSON) > claimsConfig.MaxSize {
+			s.log.WithContext(ctx).
+				WithFields(logger.Fields{"claim": m.Claim, "source": m.Source, "size": len(valueJSON)}).
+				Warn("mapped claim too large")
+
+			continue
+		}
+
+		size += len(valueJSON)
+
+		if m.TopLevel {
+			result.TopLevel[m.Claim] = value
+		} else {
+			result.Namespaced[m.Claim] = value
+		}
+	}
+
+	return result, nil
+}
+
+// namespaceClaims returns the claims set under the claims namespace, which
+// are the custom claims and the namespaced mapped claims.
+func namespaceClaims(customClaims *CustomClaims, mapped *mappedClaims) (interface{}, error) {
+	if len(mapped.Namespaced) == 0 {
+		return customClaims, nil
+	}
+
+	customClaimsJSON, err := json.Marshal(customClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(customClaimsJSON, &claims); err != nil {
+		return nil, err
+	}
+
+	for name, value := range mapped.Namespaced {
+		claims[name] = value
+	}
+
+	return claims, nil
+}
+
+// lookupPath returns the value at the dot separated path in the document.
+func lookupPath(doc map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = doc
+
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		value, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	if value == nil {
+		return nil, false
+	}
+
+	return value, true
+}
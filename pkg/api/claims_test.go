@@ -0,0 +1,171 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+type ClaimsTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+	Config *config.Config
+
+	user *user.User
+}
+
+//nolint:errcheck
+func (ts *ClaimsTestSuite) SetupTest() {
+	// truncate
+	ts.Server.RefreshTokenRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+
+	// create test user
+	u, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:     "test@example.com",
+		Username:  "test",
+		Password:  "password",
+		GivenName: "Test",
+		AppMetaData: map[string]interface{}{
+			"plan": map[string]interface{}{"tier": "gold"},
+			"sub":  "someone-else",
+			"big":  strings.Repeat("x", 100),
+		},
+		UserMetaData: map[string]interface{}{
+			"locale": "en",
+			"email":  "other@example.com",
+		},
+	})
+	require.NoError(ts.T(), err)
+
+	u, err = ts.Server.UserUsecase.ConfirmUser(context.Background(), u.ID)
+	require.NoError(ts.T(), err)
+
+	ts.user = u
+}
+
+func TestClaims(t *testing.T) {
+	ts := &ClaimsTestSuite{}
+
+	ts.Server, ts.Config = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				JWT: &config.JWTConfig{
+					Claims: &config.ClaimsConfig{
+						Mappings: config.ClaimMappings{
+							{Source: "given_name", Claim: "given_name"},
+							{Source: "app_metadata.plan.tier", Claim: "plan_tier", TopLevel: true},
+							{Source: "user_metadata.locale", Claim: "locale"},
+							{Source: "app_metadata.missing", Claim: "missing"},
+							// too large
+							{Source: "app_metadata.big", Claim: "big"},
+							// cannot override registered or custom claims
+							{Source: "app_metadata.sub", Claim: "sub", TopLevel: true},
+							{Source: "user_metadata.email", Claim: "email"},
+						},
+						MaxValueSize: 64,
+					},
+				},
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *ClaimsTestSuite) introspect(t *testing.T, auth *api.AccessTokenResponse, token string) *api.Introspection {
+	t.Helper()
+
+	resp := &api.Introspection{}
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.IntrospectPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		FormData("token", token).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	return resp
+}
+
+func (ts *ClaimsTestSuite) TestAccessToken() {
+	t := ts.T()
+
+	auth := authTokenHelper(t, ts.Server.API, ts.user.Email, "password")
+
+	claims := tokenClaims(t, auth.Token)
+
+	assert.Equal(t, "gold", claims["plan_tier"])
+	assert.Equal(t, ts.user.ID, claims["sub"])
+
+	nsClaims, ok := claims[ts.Config.API.JWT.ClaimsNamespace].(map[string]interface{})
+	require.True(t, ok)
+
+	assert.Equal(t, ts.user.Username, nsClaims["username"])
+	assert.Equal(t, ts.user.Email, nsClaims["email"])
+	assert.Equal(t, "Test", nsClaims["given_name"])
+	assert.Equal(t, "en", nsClaims["locale"])
+	assert.NotContains(t, nsClaims, "missing")
+	assert.NotContains(t, nsClaims, "big")
+
+	t.Run("introspect", func(t *testing.T) {
+		resp := ts.introspect(t, auth, auth.Token)
+
+		require.True(t, resp.Active)
+
+		assert.Equal(t, ts.user.Email, resp.Extra["email"])
+		assert.Equal(t, "Test", resp.Extra["given_name"])
+		assert.Equal(t, "en", resp.Extra["locale"])
+		assert.Equal(t, "gold", resp.Extra["plan_tier"])
+		assert.NotContains(t, resp.Extra, "missing")
+		assert.NotContains(t, resp.Extra, "big")
+		assert.NotContains(t, resp.Extra, "sub")
+	})
+
+	t.Run("introspect refresh token", func(t *testing.T) {
+		resp := ts.introspect(t, auth, auth.RefreshToken)
+
+		require.True(t, resp.Active)
+		require.Equal(t, "refresh_token", resp.TokenUse)
+
+		assert.Equal(t, ts.user.Email, resp.Extra["email"])
+		assert.Equal(t, "Test", resp.Extra["given_name"])
+		assert.Equal(t, "gold", resp.Extra["plan_tier"])
+	})
+}
+
+func (ts *ClaimsTestSuite) TestMaxSize() {
+	t := ts.T()
+
+	ts.Config.API.JWT.Claims.MaxSize = 10
+	defer func() { ts.Config.API.JWT.Claims.MaxSize = 4096 }()
+
+	auth := authTokenHelper(t, ts.Server.API, ts.user.Email, "password")
+
+	claims := tokenClaims(t, auth.Token)
+
+	nsClaims, ok := claims[ts.Config.API.JWT.ClaimsNamespace].(map[string]interface{})
+	require.True(t, ok)
+
+	// mapped in order, until the limit is reached
+	assert.Equal(t, "Test", nsClaims["given_name"])
+	assert.NotContains(t, claims, "plan_tier")
+	assert.Equal(t, "en", nsClaims["locale"])
+}
@@ -63,10 +63,18 @@ func notFoundError(fmtString string, args ...interface{}) *HTTPError {
 	return httpError(http.StatusNotFound, fmtString, args...)
 }
 
+func conflictError(fmtString string, args ...interface{}) *HTTPError {
+	return httpError(http.StatusConflict, fmtString, args...)
+}
+
 func goneError(fmtString string, args ...interface{}) *HTTPError {
 	return httpError(http.StatusGone, fmtString, args...)
 }
 
+func preconditionFailedError(fmtString string, args ...interface{}) *HTTPError {
+	return httpError(http.StatusPreconditionFailed, fmtString, args...)
+}
+
 func unprocessableEntityError(fmtString string, args ...interface{}) *HTTPError {
 	return httpError(http.StatusUnprocessableEntity, fmtString, args...)
 }
@@ -79,6 +87,10 @@ func internalServerError(fmtString string, args ...interface{}) *HTTPError {
 	return httpError(http.StatusInternalServerError, fmtString, args...)
 }
 
+func serviceUnavailableError(fmtString string, args ...interface{}) *HTTPError {
+	return httpError(http.StatusServiceUnavailable, fmtString, args...)
+}
+
 // HTTPError is an error with a message and an HTTP status code.
 type HTTPError struct {
 	Code            int    `json:"code"`
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/hook"
+)
+
+// callHook calls the hook, returning nil if the hook is not configured. Hook
+// errors are returned as HTTP errors.
+func (s *server) callHook(ctx context.Context, req *hook.Request) (*hook.Response, error) {
+	if userIP := getUserIP(ctx); userIP != nil {
+		req.IP = userIP.String()
+	}
+
+	resp, err := s.hookCaller.Call(ctx, req)
+	if err != nil {
+		var rejected *hook.RejectedError
+		if errors.As(err, &rejected) {
+			s.log.WithContext(ctx).Warnf("hook rejected: %v", err)
+
+			message := rejected.Message
+			if message == "" {
+				message = "Request rejected"
+			}
+
+			return nil, forbiddenError("%s", message).WithInternalError(err)
+		}
+
+		return nil, serviceUnavailableError("Service temporarily unavailable, please retry later").WithInternalError(err)
+	}
+
+	return resp, nil
+}
+
+// applyHookMetaData merges the metadata returned by the hook into the user.
+func (s *server) applyHookMetaData(ctx context.Context, u *user.User, resp *hook.Response) (*user.User, error) {
+	if resp == nil {
+		return u, nil
+	}
+
+	var err error
+
+	if len(resp.AppMetaData) > 0 {
+		u, err = s.userUsecase.UpdateAppMetaData(ctx, u, resp.AppMetaData)
+		if err != nil {
+			return nil, internalServerError("Database error updating user").WithInternalError(err)
+		}
+	}
+
+	if len(resp.UserMetaData) > 0 {
+		u, err = s.userUsecase.UpdateUserMetaData(ctx, u, resp.UserMetaData)
+		if err != nil {
+			return nil, internalServerError("Database error updating user").WithInternalError(err)
+		}
+	}
+
+	return u, nil
+}
+
+// afterLoginHook calls the after_login hook for the authenticated user.
+func (s *server) afterLoginHook(ctx context.Context, u *user.User, userAgent string) (*user.User, error) {
+	resp, err := s.callHook(ctx, &hook.Request{
+		Hook:      hook.AfterLogin,
+		User:      adminUserResponse(u, nil),
+		UserAgent: userAgent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.applyHookMetaData(ctx, u, resp)
+}
@@ -0,0 +1,438 @@
+package api_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/hook"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+const (
+	testHookSecret  = "test-hook-secret"
+	testHookTimeout = 200 * time.Millisecond
+)
+
+// hookHandlerFunc handles the request of a single hook.
+type hookHandlerFunc func(req *hook.Request) (int, *hook.Response)
+
+// hookServer dispatches the requests having a valid signature to the handler
+// of the called hook.
+type hookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[hook.Name]hookHandlerFunc
+	requests []*hook.Request
+}
+
+func newHookServer(t *testing.T) *hookServer {
+	t.Helper()
+
+	srv := &hookServer{}
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = webhook.VerifySignature(testHookSecret, r.Header.Get(webhook.SignatureHeader), body, 5*time.Minute, time.Now())
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		req := &hook.Request{}
+		if err := json.Unmarshal(body, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if string(req.Hook) != r.Header.Get(hook.HookHeader) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		srv.mu.Lock()
+		srv.requests = append(srv.requests, req)
+		handler := srv.handlers[req.Hook]
+		srv.mu.Unlock()
+
+		if handler == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		statusCode, resp := handler(req)
+
+		w.Header().Set(xhttp.ContentType, "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(resp) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func (srv *hookServer) reset() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.handlers = make(map[hook.Name]hookHandlerFunc)
+	srv.requests = nil
+}
+
+func (srv *hookServer) handle(name hook.Name, handler hookHandlerFunc) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.handlers[name] = handler
+}
+
+func (srv *hookServer) received(name hook.Name) []*hook.Request {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	var result []*hook.Request
+	for _, req := range srv.requests {
+		if req.Hook == name {
+			result = append(result, req)
+		}
+	}
+
+	return result
+}
+
+// sleep makes the hook time out.
+func sleep(req *hook.Request) (int, *hook.Response) {
+	time.Sleep(2 * testHookTimeout)
+	return http.StatusOK, &hook.Response{}
+}
+
+// tokenClaims returns the claims of the access token, without verifying it.
+func tokenClaims(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+
+	claims := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(payload, &claims))
+
+	return claims
+}
+
+type HooksTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+	Config *config.Config
+
+	hooks *hookServer
+}
+
+//nolint:errcheck
+func (ts *HooksTestSuite) SetupTest() {
+	// truncate
+	ts.Server.AccountRepository.DeleteAll(context.Background())
+	ts.Server.RefreshTokenRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+
+	ts.hooks.reset()
+}
+
+func TestHooks(t *testing.T) {
+	ts := &HooksTestSuite{
+		hooks: newHookServer(t),
+	}
+
+	ts.Server, ts.Config = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				CSRF: &config.CSRFConfig{
+					AuthKey: "test",
+				},
+				JWT: &config.JWTConfig{
+					Claims: &config.ClaimsConfig{
+						Mappings: config.ClaimMappings{
+							{Source: "app_metadata.last_token_hook", Claim: "last_token_hook", TopLevel: true},
+						},
+					},
+				},
+				Mailer: &config.MailerConfig{
+					Autoconfirm: true,
+				},
+				Hooks: &config.HooksConfig{
+					BeforeSignup: &config.HookConfig{
+						URL:     ts.hooks.URL,
+						Secret:  testHookSecret,
+						Timeout: testHookTimeout,
+					},
+					BeforeTokenIssue: &config.HookConfig{
+						URL:     ts.hooks.URL,
+						Secret:  testHookSecret,
+						Timeout: testHookTimeout,
+					},
+					AfterLogin: &config.HookConfig{
+						URL:      ts.hooks.URL,
+						Secret:   testHookSecret,
+						Timeout:  testHookTimeout,
+						FailOpen: true,
+					},
+				},
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *HooksTestSuite) signup(t *testing.T, email string) *apitest.Response {
+	t.Helper()
+
+	csrfToken, cookie := csrfTokenHelper(t, ts.Server.API)
+
+	return apitest.New().
+		Handler(ts.Server.API).
+		Post(api.SignupPath).
+		Header(xhttp.XCSRFToken, csrfToken).
+		Cookie(cookie.Name, cookie.Value).
+		JSON(&api.SignupRequest{
+			Email:        email,
+			Username:     strings.Split(email, "@")[0],
+			Password:     "password",
+			UserMetaData: map[string]interface{}{"plan": "free"},
+		}).
+		Expect(t)
+}
+
+func (ts *HooksTestSuite) createUser(t *testing.T) *user.User {
+	t.Helper()
+
+	u, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	u, err = ts.Server.UserUsecase.ConfirmUser(context.Background(), u.ID)
+	require.NoError(t, err)
+
+	return u
+}
+
+func (ts *HooksTestSuite) TestBeforeSignup() {
+	t := ts.T()
+
+	t.Run("reject", func(t *testing.T) {
+		ts.hooks.handle(hook.BeforeSignup, func(req *hook.Request) (int, *hook.Response) {
+			return http.StatusOK, &hook.Response{Reject: true, Message: "Signups from this domain are not allowed"}
+		})
+
+		ts.signup(t, "rejected@example.com").
+			Status(http.StatusForbidden).
+			Body(`{"code":403,"message":"Signups from this domain are not allowed"}`).
+			End()
+
+		_, err := ts.Server.UserUsecase.FindUserByEmail(context.Background(), "rejected@example.com")
+		assert.Error(t, err)
+
+		received := ts.hooks.received(hook.BeforeSignup)
+		require.Len(t, received, 1)
+
+		signupUser, ok := received[0].User.(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "rejected@example.com", signupUser["email"])
+		assert.NotContains(t, signupUser, "password")
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		ts.hooks.handle(hook.BeforeSignup, func(req *hook.Request) (int, *hook.Response) {
+			return http.StatusOK, &hook.Response{
+				AppMetaData:  map[string]interface{}{"crm_id": "crm-1"},
+				UserMetaData: map[string]interface{}{"plan": "trial"},
+			}
+		})
+
+		ts.signup(t, "patched@example.com").
+			Status(http.StatusCreated).
+			End()
+
+		u, err := ts.Server.UserUsecase.FindUserByEmail(context.Background(), "patched@example.com")
+		require.NoError(t, err)
+
+		assert.Equal(t, "crm-1", u.AppMetaData["crm_id"])
+		assert.Equal(t, "trial", u.UserMetaData["plan"])
+	})
+
+	t.Run("fail closed", func(t *testing.T) {
+		ts.hooks.handle(hook.BeforeSignup, sleep)
+
+		ts.signup(t, "timeout@example.com").
+			Status(http.StatusServiceUnavailable).
+			End()
+
+		ts.hooks.handle(hook.BeforeSignup, func(req *hook.Request) (int, *hook.Response) {
+			return http.StatusInternalServerError, &hook.Response{}
+		})
+
+		ts.signup(t, "error@example.com").
+			Status(http.StatusServiceUnavailable).
+			End()
+	})
+}
+
+func (ts *HooksTestSuite) TestBeforeTokenIssue() {
+	t := ts.T()
+
+	u := ts.createUser(t)
+
+	t.Run("claims", func(t *testing.T) {
+		ts.hooks.handle(hook.BeforeTokenIssue, func(req *hook.Request) (int, *hook.Response) {
+			return http.StatusOK, &hook.Response{
+				Claims: map[string]interface{}{
+					"tier":                            "gold",
+					"sub":                             "someone-else",
+					ts.Config.API.JWT.ClaimsNamespace: "overridden",
+				},
+				AppMetaData: map[string]interface{}{"last_token_hook": "ok"},
+			}
+		})
+
+		token := authTokenHelper(t, ts.Server.API, u.Email, "password")
+
+		claims := tokenClaims(t, token.Token)
+		assert.Equal(t, "gold", claims["tier"])
+		assert.Equal(t, u.ID, claims["sub"])
+		assert.IsType(t, map[string]interface{}{}, claims[ts.Config.API.JWT.ClaimsNamespace])
+		// claims are mapped from the user updated by the hook
+		assert.Equal(t, "ok", claims["last_token_hook"])
+
+		received := ts.hooks.received(hook.BeforeTokenIssue)
+		require.Len(t, received, 1)
+
+		requestClaims, ok := received[0].Claims.(map[string]interface{})
+		require.True(t, ok)
+		assert.Contains(t, requestClaims, ts.Config.API.JWT.ClaimsNamespace)
+
+		updated, err := ts.Server.UserUsecase.FindUserByID(context.Background(), u.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, "ok", updated.AppMetaData["last_token_hook"])
+
+		// refresh
+		ts.hooks.handle(hook.BeforeTokenIssue, func(req *hook.Request) (int, *hook.Response) {
+			return http.StatusOK, &hook.Response{Claims: map[string]interface{}{"tier": "platinum"}}
+		})
+
+		resp := &api.AccessTokenResponse{}
+
+		apitest.New().
+			Handler(ts.Server.API).
+			Post(api.TokenPath).
+			FormData("grant_type", "refresh_token").
+			FormData("refresh_token", token.RefreshToken).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(resp)
+
+		assert.Equal(t, "platinum", tokenClaims(t, resp.Token)["tier"])
+
+		// refreshing the token is not a login
+		assert.Len(t, ts.hooks.received(hook.AfterLogin), 1)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		ts.hooks.handle(hook.BeforeTokenIssue, func(req *hook.Request) (int, *hook.Response) {
+			return http.StatusOK, &hook.Response{Reject: true, Message: "Subscription expired"}
+		})
+
+		apitest.New().
+			Handler(ts.Server.API).
+			Post(api.TokenPath).
+			FormData("grant_type", "password").
+			FormData("username", u.Email).
+			FormData("password", "password").
+			Expect(t).
+			Status(http.StatusForbidden).
+			Body(`{"code":403,"message":"Subscription expired"}`).
+			End()
+	})
+
+	t.Run("fail closed", func(t *testing.T) {
+		ts.hooks.handle(hook.BeforeTokenIssue, sleep)
+
+		apitest.New().
+			Handler(ts.Server.API).
+			Post(api.TokenPath).
+			FormData("grant_type", "password").
+			FormData("username", u.Email).
+			FormData("password", "password").
+			Expect(t).
+			Status(http.StatusServiceUnavailable).
+			End()
+	})
+}
+
+func (ts *HooksTestSuite) TestAfterLogin() {
+	t := ts.T()
+
+	u := ts.createUser(t)
+
+	t.Run("reject", func(t *testing.T) {
+		ts.hooks.handle(hook.AfterLogin, func(req *hook.Request) (int, *hook.Response) {
+			return http.StatusOK, &hook.Response{Reject: true, Message: "Login not allowed"}
+		})
+
+		apitest.New().
+			Handler(ts.Server.API).
+			Post(api.TokenPath).
+			FormData("grant_type", "password").
+			FormData("username", u.Email).
+			FormData("password", "password").
+			Header("User-Agent", "hooks-test").
+			Expect(t).
+			Status(http.StatusForbidden).
+			Body(`{"code":403,"message":"Login not allowed"}`).
+			End()
+
+		received := ts.hooks.received(hook.AfterLogin)
+		require.Len(t, received, 1)
+
+		assert.Equal(t, "hooks-test", received[0].UserAgent)
+
+		// tokens are not issued
+		assert.Empty(t, ts.hooks.received(hook.BeforeTokenIssue))
+	})
+
+	t.Run("fail open", func(t *testing.T) {
+		ts.hooks.handle(hook.AfterLogin, sleep)
+
+		authTokenHelper(t, ts.Server.API, u.Email, "password")
+	})
+}
@@ -106,12 +106,67 @@ func (s *server) handleAccessTokenIntrospect(w http.ResponseWriter, accessToken
 			resp.Username = customClaims.Username
 			resp.Extra = make(map[string]interface{})
 			resp.Extra["email"] = customClaims.Email
+			if len(customClaims.Roles) > 0 {
+				resp.Extra["roles"] = customClaims.Roles
+				resp.Extra["permissions"] = customClaims.Permissions
+			}
+			if customClaims.OrgID != "" {
+				resp.Extra["org_id"] = customClaims.OrgID
+				resp.Extra["org_roles"] = customClaims.OrgRoles
+			}
 		}
 	}
 
+	s.addMappedClaimsExtra(resp, accessToken)
+
 	mustSendJSON(w, http.StatusOK, resp)
 }
 
+// addMappedClaimsExtra adds the configured mapped claims present in the
+// access token to the extra introspection claims.
+func (s *server) addMappedClaimsExtra(resp *Introspection, accessToken jwt.Token) {
+	claimsConfig := s.config.API.JWT.Claims
+	if claimsConfig == nil {
+		return
+	}
+
+	var nsClaims map[string]interface{}
+	if claim, ok := accessToken.Get(s.config.API.JWT.ClaimsNamespace); ok {
+		nsClaims, _ = claim.(map[string]interface{})
+	}
+
+	for _, m := range claimsConfig.Mappings {
+		var (
+			value interface{}
+			ok    bool
+		)
+
+		if m.TopLevel {
+			if registeredClaims[m.Claim] || m.Claim == s.config.API.JWT.ClaimsNamespace {
+				continue
+			}
+
+			value, ok = accessToken.Get(m.Claim)
+		} else {
+			if customClaimNames[m.Claim] {
+				continue
+			}
+
+			value, ok = nsClaims[m.Claim]
+		}
+
+		if !ok {
+			continue
+		}
+
+		if resp.Extra == nil {
+			resp.Extra = make(map[string]interface{})
+		}
+
+		resp.Extra[m.Claim] = value
+	}
+}
+
 func (s *server) handleRefreshTokenIntrospect(w http.ResponseWriter, r *http.Request, refreshToken *refreshtoken.RefreshToken) {
 	ctx := r.Context()
 
@@ -149,5 +204,34 @@ func (s *server) handleRefreshTokenIntrospect(w http.ResponseWriter, r *http.Req
 		},
 	}
 
+	roles, err := s.roleUsecase.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		handleIntrospectError(w)
+		return
+	}
+
+	if len(roles) > 0 {
+		roleIDs := make([]string, 0, len(roles))
+		for _, r := range roles {
+			roleIDs = append(roleIDs, r.ID)
+		}
+
+		resp.Extra["roles"] = roleIDs
+		resp.Extra["permissions"] = s.roleUsecase.Permissions(roles)
+	}
+
+	mapped, err := s.mapClaims(ctx, user)
+	if err != nil {
+		handleIntrospectError(w)
+		return
+	}
+
+	for name, value := range mapped.Namespaced {
+		resp.Extra[name] = value
+	}
+	for name, value := range mapped.TopLevel {
+		resp.Extra[name] = value
+	}
+
 	mustSendJSON(w, http.StatusOK, resp)
 }
@@ -14,6 +14,7 @@ import (
 
 	"github.com/zbiljic/authzy/pkg/api"
 	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/role"
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	xhttp "github.com/zbiljic/authzy/pkg/http"
 )
@@ -30,6 +31,7 @@ func (ts *IntrospectTestSuite) SetupTest() {
 	// truncate
 	ts.Server.AccountRepository.DeleteAll(context.Background())
 	ts.Server.RefreshTokenRepository.DeleteAll(context.Background())
+	ts.Server.RoleRepository.DeleteAll(context.Background())
 	ts.Server.UserRepository.DeleteAll(context.Background())
 
 	// create test user
@@ -125,6 +127,50 @@ func (ts *IntrospectTestSuite) TestAccessToken() {
 	})
 }
 
+func (ts *IntrospectTestSuite) TestRoles() {
+	t := ts.T()
+
+	user, err := ts.Server.UserUsecase.FindUserByEmail(context.Background(), "test@example.com")
+	require.NoError(t, err)
+
+	_, err = ts.Server.RoleUsecase.CreateRole(context.Background(), &role.Role{
+		ID:          "editor",
+		Permissions: []string{"posts:write", "posts:read"},
+	})
+	require.NoError(t, err)
+
+	_, err = ts.Server.RoleUsecase.CreateRole(context.Background(), &role.Role{
+		ID:          "viewer",
+		Permissions: []string{"posts:read"},
+	})
+	require.NoError(t, err)
+
+	for _, id := range []string{"editor", "viewer"} {
+		err = ts.Server.RoleUsecase.AssignRole(context.Background(), user.ID, id)
+		require.NoError(t, err)
+	}
+
+	auth := authTokenHelper(t, ts.Server.API, "test@example.com", "password")
+
+	for _, token := range []string{auth.Token, auth.RefreshToken} {
+		resp := &api.Introspection{}
+
+		apitest.New().
+			Handler(ts.Server.API).
+			Post(api.IntrospectPath).
+			Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+			FormData("token", token).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(resp)
+
+		assert.True(t, resp.Active)
+		assert.ElementsMatch(t, []interface{}{"editor", "viewer"}, resp.Extra["roles"])
+		assert.Equal(t, []interface{}{"posts:read", "posts:write"}, resp.Extra["permissions"])
+	}
+}
+
 func (ts *IntrospectTestSuite) TestRefreshToken() {
 	t := ts.T()
 
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"strings"
+
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
 )
 
 // LogoutHandler is the endpoint for logging out a user and thereby revoking
@@ -20,12 +23,21 @@ func (s *server) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.refreshTokenUsecase.Logout(ctx, user)
+	// every refresh token is revoked, or none
+	err = s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		return s.refreshTokenUsecase.Logout(ctx, user)
+	})
 	if err != nil {
 		s.handleError(w, r, internalServerError("Error logging out user").WithInternalError(err))
 		return
 	}
 
+	s.audit(r, &auditlog.Event{
+		Action:    auditlog.ActionLogout,
+		Outcome:   auditlog.OutcomeSuccess,
+		SubjectID: user.ID,
+	})
+
 	if redirectTo != "" {
 		for _, allowedLogoutURL := range s.config.API.AllowedLogoutURLs {
 			if strings.EqualFold(allowedLogoutURL, redirectTo) {
@@ -107,3 +107,26 @@ func (s *server) sendEmailChange(ctx context.Context, u *user.User, mailer maile
 
 	return nil
 }
+
+func (s *server) sendDeletionRestore(ctx context.Context, u *user.User, mailer mailer.Mailer, gracePeriod time.Duration, referrerURL string) error {
+	now := time.Now()
+
+	oldToken := u.DeletionToken
+
+	u.DeletionToken = ulid.ULID().String()
+
+	if err := mailer.RestorationMail(u, now.Add(gracePeriod), referrerURL); err != nil {
+		u.DeletionToken = oldToken
+
+		return fmt.Errorf("error sending restoration email: %w", err)
+	}
+
+	u.DeletionRequestedAt = &now
+
+	_, err := s.userUsecase.UpdateUser(ctx, u)
+	if err != nil {
+		return fmt.Errorf("database error updating user for deletion: %w", err)
+	}
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package api
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // SignupRequest are the parameters the signup endpoint accepts.
 type SignupRequest struct {
@@ -63,6 +66,360 @@ type Provider struct {
 	FederatedID string `json:"federated_id"`
 }
 
+// AdminUserCreateRequest are the parameters the admin endpoint accepts when
+// creating a user.
+type AdminUserCreateRequest struct {
+	Email         string                 `json:"email"`
+	EmailVerified bool                   `json:"email_verified"`
+	Password      string                 `json:"password"`
+	Username      string                 `json:"username"`
+	GivenName     string                 `json:"given_name"`
+	FamilyName    string                 `json:"family_name"`
+	Name          string                 `json:"name"`
+	Nickname      string                 `json:"nickname"`
+	Picture       string                 `json:"picture"`
+	AppMetaData   map[string]interface{} `json:"app_metadata"`
+	UserMetaData  map[string]interface{} `json:"user_metadata"`
+}
+
+// AdminUserUpdateRequest are the parameters the admin endpoint accepts when
+// updating a user. Empty values are left unchanged.
+type AdminUserUpdateRequest struct {
+	EmailVerified *bool                  `json:"email_verified"`
+	Password      string                 `json:"password"`
+	GivenName     string                 `json:"given_name"`
+	FamilyName    string                 `json:"family_name"`
+	Name          string                 `json:"name"`
+	Nickname      string                 `json:"nickname"`
+	Picture       string                 `json:"picture"`
+	AppMetaData   map[string]interface{} `json:"app_metadata"`
+	UserMetaData  map[string]interface{} `json:"user_metadata"`
+}
+
+type AdminUserResponse struct {
+	UserResponse
+
+	Blocked     bool       `json:"blocked"`
+	LastIP      string     `json:"last_ip,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	LoginsCount int64      `json:"logins_count"`
+}
+
+type AdminUserListResponse struct {
+	Users      []*AdminUserResponse `json:"users"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// AdminUserVersionResponse is a version of the user history.
+type AdminUserVersionResponse struct {
+	Version   int                   `json:"version"`
+	Action    string                `json:"action"`
+	ActorID   string                `json:"actor_id,omitempty"`
+	Changes   []*UserChangeResponse `json:"changes"`
+	User      *AdminUserResponse    `json:"user"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+// UserChangeResponse is the change of a single field of the user.
+type UserChangeResponse struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
+
+type AdminUserVersionListResponse struct {
+	Versions   []*AdminUserVersionResponse `json:"versions"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
+}
+
+// AdminRoleCreateRequest are the parameters the admin endpoint accepts when
+// creating a role.
+type AdminRoleCreateRequest struct {
+	ID          string   `json:"role_id"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// AdminRoleUpdateRequest are the parameters the admin endpoint accepts when
+// updating a role. Nil values are left unchanged.
+type AdminRoleUpdateRequest struct {
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type AdminRoleResponse struct {
+	ID          string    `json:"role_id"`
+	Description string    `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type AdminRoleListResponse struct {
+	Roles      []*AdminRoleResponse `json:"roles"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// AdminPolicyCreateRequest are the parameters the admin endpoint accepts when
+// creating a policy.
+type AdminPolicyCreateRequest struct {
+	ID          string `json:"policy_id"`
+	Description string `json:"description"`
+	Effect      string `json:"effect"`
+	Expression  string `json:"expression"`
+	Disabled    bool   `json:"disabled"`
+}
+
+// AdminPolicyUpdateRequest are the parameters the admin endpoint accepts when
+// updating a policy. Nil values are left unchanged.
+type AdminPolicyUpdateRequest struct {
+	Description *string `json:"description"`
+	Effect      *string `json:"effect"`
+	Expression  *string `json:"expression"`
+	Disabled    *bool   `json:"disabled"`
+}
+
+type AdminPolicyResponse struct {
+	ID          string    `json:"policy_id"`
+	Description string    `json:"description,omitempty"`
+	Effect      string    `json:"effect"`
+	Expression  string    `json:"expression"`
+	Disabled    bool      `json:"disabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type AdminPolicyListResponse struct {
+	Policies   []*AdminPolicyResponse `json:"policies"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// AdminOrganizationCreateRequest are the parameters the admin endpoint
+// accepts when creating an organization.
+type AdminOrganizationCreateRequest struct {
+	Name        string                 `json:"name"`
+	DisplayName string                 `json:"display_name"`
+	MetaData    map[string]interface{} `json:"metadata"`
+}
+
+// AdminOrganizationUpdateRequest are the parameters the admin endpoint
+// accepts when updating an organization. Nil values are left unchanged.
+type AdminOrganizationUpdateRequest struct {
+	Name        *string                `json:"name"`
+	DisplayName *string                `json:"display_name"`
+	MetaData    map[string]interface{} `json:"metadata"`
+}
+
+type AdminOrganizationResponse struct {
+	OrganizationID string                 `json:"organization_id"`
+	Name           string                 `json:"name"`
+	DisplayName    string                 `json:"display_name,omitempty"`
+	MetaData       map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+type AdminOrganizationListResponse struct {
+	Organizations []*AdminOrganizationResponse `json:"organizations"`
+	NextCursor    string                       `json:"next_cursor,omitempty"`
+}
+
+// AdminMemberRequest are the parameters the admin endpoint accepts when
+// adding the user to an organization.
+type AdminMemberRequest struct {
+	Roles []string `json:"roles"`
+}
+
+type AdminMemberResponse struct {
+	OrganizationID string    `json:"organization_id"`
+	UserID         string    `json:"user_id"`
+	Roles          []string  `json:"roles"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type AdminMemberListResponse struct {
+	Members    []*AdminMemberResponse `json:"members"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// AdminInvitationCreateRequest are the parameters the admin endpoint accepts
+// when inviting an email address to an organization.
+type AdminInvitationCreateRequest struct {
+	Email string   `json:"email"`
+	Roles []string `json:"roles"`
+}
+
+// AdminInvitationResponse is the invitation to an organization. Token is
+// returned only when the invitation is created.
+type AdminInvitationResponse struct {
+	InvitationID   string    `json:"invitation_id"`
+	OrganizationID string    `json:"organization_id"`
+	Email          string    `json:"email"`
+	Roles          []string  `json:"roles"`
+	Token          string    `json:"token,omitempty"`
+	InviterID      string    `json:"inviter_id,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type AdminInvitationListResponse struct {
+	Invitations []*AdminInvitationResponse `json:"invitations"`
+}
+
+// UserOrganizationResponse is the organization the user is member of.
+type UserOrganizationResponse struct {
+	OrganizationID string                 `json:"organization_id"`
+	Name           string                 `json:"name"`
+	DisplayName    string                 `json:"display_name,omitempty"`
+	MetaData       map[string]interface{} `json:"metadata,omitempty"`
+	Roles          []string               `json:"roles"`
+}
+
+type UserOrganizationListResponse struct {
+	Organizations []*UserOrganizationResponse `json:"organizations"`
+}
+
+// InvitationAcceptRequest are the parameters the invitation endpoint
+// accepts.
+type InvitationAcceptRequest struct {
+	Token string `json:"token"`
+}
+
+// AuditEventResponse is an event of the audit log.
+type AuditEventResponse struct {
+	EventID   string                 `json:"event_id"`
+	Action    string                 `json:"action"`
+	Outcome   string                 `json:"outcome"`
+	ActorID   string                 `json:"actor_id,omitempty"`
+	SubjectID string                 `json:"subject_id,omitempty"`
+	IP        string                 `json:"ip,omitempty"`
+	UserAgent string                 `json:"user_agent,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Reason    string                 `json:"reason,omitempty"`
+	MetaData  map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type AuditEventListResponse struct {
+	Events     []*AuditEventResponse `json:"events"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// UserSessionResponse is a session of the user, without the refresh token.
+type UserSessionResponse struct {
+	SessionID string    `json:"session_id"`
+	Revoked   bool      `json:"revoked"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserExportResponse is the archive of the data kept about the user.
+type UserExportResponse struct {
+	ExportedAt     time.Time              `json:"exported_at"`
+	User           *AdminUserResponse     `json:"user"`
+	Accounts       []Provider             `json:"accounts"`
+	Sessions       []*UserSessionResponse `json:"sessions"`
+	SecurityEvents []*AuditEventResponse  `json:"security_events"`
+}
+
+// UserDeleteRequest are the parameters the user deletion endpoint accepts.
+// The password is not needed within a few minutes of logging in.
+type UserDeleteRequest struct {
+	Password string `json:"password,omitempty"`
+}
+
+// UserDeleteResponse is returned when the deletion of the user is scheduled.
+type UserDeleteResponse struct {
+	DeletionAt time.Time `json:"deletion_at"`
+}
+
+// AdminWebhookDeliveryResponse is the delivery of the event to the webhook
+// endpoint.
+type AdminWebhookDeliveryResponse struct {
+	DeliveryID     string          `json:"delivery_id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	URL            string          `json:"url"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type AdminWebhookDeliveryListResponse struct {
+	Deliveries []*AdminWebhookDeliveryResponse `json:"deliveries"`
+	NextCursor string                          `json:"next_cursor,omitempty"`
+}
+
+// WebhookUserData is the data of the user events delivered to the webhooks.
+type WebhookUserData struct {
+	User          *AdminUserResponse `json:"user"`
+	PreviousEmail string             `json:"previous_email,omitempty"`
+}
+
+// PolicyEvaluateRequest are the parameters the policy decision endpoint
+// accepts. Subject attributes are loaded for the user, or the subject of the
+// access token, and override the provided subject attributes.
+type PolicyEvaluateRequest struct {
+	UserID   string                 `json:"user_id"`
+	Token    string                 `json:"token"`
+	Subject  map[string]interface{} `json:"subject"`
+	Resource map[string]interface{} `json:"resource"`
+	Action   string                 `json:"action"`
+	Context  map[string]interface{} `json:"context"`
+
+	// DryRun evaluates the policies as if the given policies were stored.
+	DryRun   bool                        `json:"dry_run"`
+	Policies []*AdminPolicyCreateRequest `json:"policies"`
+}
+
+type PolicyEvaluateResponse struct {
+	Allowed   bool              `json:"allowed"`
+	PolicyIDs []string          `json:"policy_ids"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	DryRun    bool              `json:"dry_run,omitempty"`
+}
+
+// RelationTuple is the relation between an object and a subject. Subject is
+// either a subject ID, or a subject set in the "namespace:object#relation"
+// notation.
+type RelationTuple struct {
+	Namespace string `json:"namespace"`
+	Object    string `json:"object"`
+	Relation  string `json:"relation"`
+	Subject   string `json:"subject"`
+}
+
+type RelationTuplesWriteRequest struct {
+	RelationTuples []*RelationTuple `json:"relation_tuples"`
+}
+
+type RelationTupleListResponse struct {
+	RelationTuples []*RelationTuple `json:"relation_tuples"`
+	NextCursor     string           `json:"next_cursor,omitempty"`
+}
+
+type CheckResponse struct {
+	Allowed bool `json:"allowed"`
+}
+
+// ExpandTree is the tree of subjects in the expanded subject set.
+type ExpandTree struct {
+	Type     string        `json:"type"`
+	Subject  string        `json:"subject"`
+	Children []*ExpandTree `json:"children,omitempty"`
+}
+
+type ListObjectsResponse struct {
+	Objects []string `json:"objects"`
+}
+
 // VerifyRequest are the parameters the verify endpoint accepts.
 type VerifyRequest struct {
 	Type       string `json:"type"`
@@ -80,6 +437,10 @@ type RecoverRequest struct {
 type CustomClaims struct {
 	Username     string                 `json:"username,omitempty"`
 	Email        string                 `json:"email,omitempty"`
+	Roles        []string               `json:"roles,omitempty"`
+	Permissions  []string               `json:"permissions,omitempty"`
+	OrgID        string                 `json:"org_id,omitempty" mapstructure:"org_id"`
+	OrgRoles     []string               `json:"org_roles,omitempty" mapstructure:"org_roles"`
 	AppMetaData  map[string]interface{} `json:"app_metadata,omitempty"`
 	UserMetaData map[string]interface{} `json:"user_metadata,omitempty"`
 }
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// UserOrganizationListHandler lists the organizations the user is member of.
+func (s *server) UserOrganizationListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jwtToken := *getToken(ctx)
+
+	sub := jwtToken.Subject()
+	if sub == "" {
+		s.handleError(w, r, badRequestError("Could not read 'sub' claim"))
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": sub})
+
+	members, err := s.organizationUsecase.FindAllForUser(ctx, sub)
+	if err != nil {
+		s.log.WithContext(ctx).Warnf("find memberships: %v", err)
+
+		s.handleError(w, r, internalServerError(err.Error()))
+		return
+	}
+
+	resp := &UserOrganizationListResponse{
+		Organizations: make([]*UserOrganizationResponse, 0, len(members)),
+	}
+
+	for _, member := range members {
+		o, err := s.organizationUsecase.FindOrganizationByID(ctx, member.OrganizationID)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				continue
+			}
+
+			s.log.WithContext(ctx).Warnf("find organization: %v", err)
+
+			s.handleError(w, r, internalServerError(err.Error()))
+			return
+		}
+
+		resp.Organizations = append(resp.Organizations, &UserOrganizationResponse{
+			OrganizationID: o.ID,
+			Name:           o.Name,
+			DisplayName:    o.DisplayName,
+			MetaData:       o.MetaData,
+			Roles:          adminMemberResponse(member).Roles,
+		})
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// InvitationAcceptHandler adds the user to the organization of the invitation.
+// Invitation must be sent to the email address of the user.
+func (s *server) InvitationAcceptHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &InvitationAcceptRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	if params.Token == "" {
+		s.handleError(w, r, unprocessableEntityError("Invitation token required"))
+		return
+	}
+
+	jwtToken := *getToken(ctx)
+
+	sub := jwtToken.Subject()
+	if sub == "" {
+		s.handleError(w, r, badRequestError("Could not read 'sub' claim"))
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": sub})
+
+	user, err := s.userUsecase.FindUserByID(ctx, sub)
+	if err != nil {
+		s.log.WithContext(ctx).Warnf("find user: %v", err)
+
+		if errors.Is(err, database.ErrNotFound) {
+			s.handleError(w, r, notFoundError(err.Error()))
+			return
+		}
+
+		s.handleError(w, r, internalServerError(err.Error()))
+		return
+	}
+
+	member, err := s.organizationUsecase.AcceptInvitation(ctx, params.Token, user.ID, user.Email)
+	if err != nil {
+		s.log.WithContext(ctx).Warnf("accept invitation: %v", err)
+
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			s.handleError(w, r, notFoundError("Invitation not found"))
+		case errors.Is(err, organization.ErrInvitationExpired):
+			s.handleError(w, r, unprocessableEntityError("Invitation expired"))
+		case errors.Is(err, organization.ErrInvitationEmailMismatch):
+			s.handleError(w, r, forbiddenError("Invitation was sent to another email address"))
+		default:
+			s.handleError(w, r, internalServerError("Error accepting invitation").WithInternalError(err))
+		}
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"organization_id": member.OrganizationID}).
+		Info("organization invitation accepted")
+
+	mustSendJSON(w, http.StatusOK, adminMemberResponse(member))
+}
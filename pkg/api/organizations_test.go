@@ -0,0 +1,466 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+type OrganizationsTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+
+	user *user.User
+}
+
+//nolint:errcheck
+func (ts *OrganizationsTestSuite) SetupTest() {
+	// truncate
+	ts.Server.InvitationRepository.DeleteAll(context.Background())
+	ts.Server.OrganizationRepository.DeleteAll(context.Background())
+	ts.Server.RefreshTokenRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+
+	// create test user
+	u, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	})
+	require.NoError(ts.T(), err)
+
+	u, err = ts.Server.UserUsecase.ConfirmUser(context.Background(), u.ID)
+	require.NoError(ts.T(), err)
+
+	ts.user = u
+}
+
+func TestOrganizations(t *testing.T) {
+	ts := &OrganizationsTestSuite{}
+
+	ts.Server, _ = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				Admin: &config.AdminConfig{
+					APIKey: testAdminAPIKey,
+				},
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *OrganizationsTestSuite) request() *apitest.APITest {
+	return apitest.New().
+		Handler(ts.Server.API).
+		Intercept(func(r *http.Request) {
+			r.Header.Set(xhttp.Authorization, "Bearer "+testAdminAPIKey)
+		})
+}
+
+func (ts *OrganizationsTestSuite) createOrganization(name string) *api.AdminOrganizationResponse {
+	resp := &api.AdminOrganizationResponse{}
+
+	ts.request().
+		Post(api.AdminOrganizationsPath).
+		JSON(&api.AdminOrganizationCreateRequest{
+			Name:     name,
+			MetaData: map[string]interface{}{"plan": "free"},
+		}).
+		Expect(ts.T()).
+		Status(http.StatusCreated).
+		End().
+		JSON(resp)
+
+	return resp
+}
+
+func (ts *OrganizationsTestSuite) addMember(organizationID, userID string, roles ...string) {
+	ts.request().
+		Put(fmt.Sprintf("%s/%s/members/%s", api.AdminOrganizationsPath, organizationID, userID)).
+		JSON(&api.AdminMemberRequest{Roles: roles}).
+		Expect(ts.T()).
+		Status(http.StatusOK).
+		End()
+}
+
+func (ts *OrganizationsTestSuite) introspect(token string) *api.Introspection {
+	resp := &api.Introspection{}
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.IntrospectPath).
+		Header(xhttp.Authorization, "Bearer "+token).
+		FormData("token", token).
+		Expect(ts.T()).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	return resp
+}
+
+func (ts *OrganizationsTestSuite) TestUnauthorized() {
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.AdminOrganizationsPath).
+		Expect(ts.T()).
+		Status(http.StatusUnauthorized).
+		End()
+}
+
+func (ts *OrganizationsTestSuite) TestCRUD() {
+	t := ts.T()
+
+	org := ts.createOrganization("Acme")
+
+	assert.NotEmpty(t, org.OrganizationID)
+	assert.Equal(t, "Acme", org.Name)
+	assert.Equal(t, "free", org.MetaData["plan"])
+
+	ts.request().
+		Post(api.AdminOrganizationsPath).
+		JSON(&api.AdminOrganizationCreateRequest{}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	displayName := "Acme Corporation"
+
+	resp := &api.AdminOrganizationResponse{}
+
+	ts.request().
+		Patch(api.AdminOrganizationsPath + "/" + org.OrganizationID).
+		JSON(&api.AdminOrganizationUpdateRequest{
+			DisplayName: &displayName,
+			MetaData:    map[string]interface{}{"plan": nil, "seats": 10},
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Equal(t, "Acme", resp.Name)
+	assert.Equal(t, displayName, resp.DisplayName)
+	assert.Equal(t, map[string]interface{}{"seats": float64(10)}, resp.MetaData)
+
+	listResp := &api.AdminOrganizationListResponse{}
+
+	ts.request().
+		Get(api.AdminOrganizationsPath).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(listResp)
+
+	require.Len(t, listResp.Organizations, 1)
+	assert.Equal(t, displayName, listResp.Organizations[0].DisplayName)
+
+	ts.request().
+		Delete(api.AdminOrganizationsPath + "/" + org.OrganizationID).
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	ts.request().
+		Get(api.AdminOrganizationsPath + "/" + org.OrganizationID).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (ts *OrganizationsTestSuite) TestMembers() {
+	t := ts.T()
+
+	org := ts.createOrganization("Acme")
+	membersPath := api.AdminOrganizationsPath + "/" + org.OrganizationID + "/members"
+
+	ts.request().
+		Put(membersPath + "/unknown").
+		JSON(&api.AdminMemberRequest{}).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	ts.request().
+		Put(api.AdminOrganizationsPath + "/unknown/members/" + ts.user.ID).
+		JSON(&api.AdminMemberRequest{}).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	ts.request().
+		Put(membersPath + "/" + ts.user.ID).
+		JSON(&api.AdminMemberRequest{Roles: []string{"invalid role"}}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	ts.addMember(org.OrganizationID, ts.user.ID, "admin")
+
+	resp := &api.AdminMemberListResponse{}
+
+	ts.request().
+		Get(membersPath).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	require.Len(t, resp.Members, 1)
+	assert.Equal(t, ts.user.ID, resp.Members[0].UserID)
+	assert.Equal(t, []string{"admin"}, resp.Members[0].Roles)
+
+	auth := authTokenHelper(t, ts.Server.API, "test@example.com", "password")
+
+	userResp := &api.UserOrganizationListResponse{}
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.UserOrganizationsPath).
+		Header(xhttp.Authorization, "Bearer "+auth.Token).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(userResp)
+
+	require.Len(t, userResp.Organizations, 1)
+	assert.Equal(t, org.OrganizationID, userResp.Organizations[0].OrganizationID)
+	assert.Equal(t, []string{"admin"}, userResp.Organizations[0].Roles)
+
+	ts.request().
+		Delete(membersPath + "/" + ts.user.ID).
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	ts.request().
+		Get(membersPath).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"members":[]}`).
+		End()
+}
+
+func (ts *OrganizationsTestSuite) TestToken() {
+	t := ts.T()
+
+	org1 := ts.createOrganization("Acme")
+	org2 := ts.createOrganization("Globex")
+
+	ts.addMember(org1.OrganizationID, ts.user.ID, "owner")
+	ts.addMember(org2.OrganizationID, ts.user.ID, "member")
+
+	t.Run("no_org", func(t *testing.T) {
+		auth := authTokenHelper(t, ts.Server.API, "test@example.com", "password")
+
+		resp := ts.introspect(auth.Token)
+
+		assert.NotContains(t, resp.Extra, "org_id")
+	})
+
+	t.Run("password", func(t *testing.T) {
+		auth := &api.AccessTokenResponse{}
+
+		apitest.New().
+			Handler(ts.Server.API).
+			Post(api.TokenPath).
+			FormData("grant_type", "password").
+			FormData("username", "test@example.com").
+			FormData("password", "password").
+			FormData("org_id", org1.OrganizationID).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(auth)
+
+		resp := ts.introspect(auth.Token)
+
+		assert.Equal(t, org1.OrganizationID, resp.Extra["org_id"])
+		assert.Equal(t, []interface{}{"owner"}, resp.Extra["org_roles"])
+
+		// switch organization on refresh
+		apitest.New().
+			Handler(ts.Server.API).
+			Post(api.TokenPath).
+			FormData("grant_type", "refresh_token").
+			FormData("refresh_token", auth.RefreshToken).
+			FormData("org_id", org2.OrganizationID).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(auth)
+
+		resp = ts.introspect(auth.Token)
+
+		assert.Equal(t, org2.OrganizationID, resp.Extra["org_id"])
+		assert.Equal(t, []interface{}{"member"}, resp.Extra["org_roles"])
+	})
+
+	t.Run("not_member", func(t *testing.T) {
+		org3 := ts.createOrganization("Initech")
+
+		apitest.New().
+			Handler(ts.Server.API).
+			Post(api.TokenPath).
+			FormData("grant_type", "password").
+			FormData("username", "test@example.com").
+			FormData("password", "password").
+			FormData("org_id", org3.OrganizationID).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+}
+
+func (ts *OrganizationsTestSuite) TestInvitations() {
+	t := ts.T()
+
+	org := ts.createOrganization("Acme")
+	invitationsPath := api.AdminOrganizationsPath + "/" + org.OrganizationID + "/invitations"
+
+	ts.request().
+		Post(invitationsPath).
+		JSON(&api.AdminInvitationCreateRequest{Email: "invalid"}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	ts.request().
+		Post(api.AdminOrganizationsPath + "/unknown/invitations").
+		JSON(&api.AdminInvitationCreateRequest{Email: "test@example.com"}).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	invitation := &api.AdminInvitationResponse{}
+
+	ts.request().
+		Post(invitationsPath).
+		JSON(&api.AdminInvitationCreateRequest{
+			Email: "TEST@example.com",
+			Roles: []string{"billing"},
+		}).
+		Expect(t).
+		Status(http.StatusCreated).
+		End().
+		JSON(invitation)
+
+	assert.NotEmpty(t, invitation.Token)
+	assert.True(t, invitation.ExpiresAt.After(time.Now()))
+
+	other := &api.AdminInvitationResponse{}
+
+	ts.request().
+		Post(invitationsPath).
+		JSON(&api.AdminInvitationCreateRequest{Email: "other@example.com"}).
+		Expect(t).
+		Status(http.StatusCreated).
+		End().
+		JSON(other)
+
+	listResp := &api.AdminInvitationListResponse{}
+
+	ts.request().
+		Get(invitationsPath).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(listResp)
+
+	require.Len(t, listResp.Invitations, 2)
+	for _, i := range listResp.Invitations {
+		assert.Empty(t, i.Token)
+	}
+
+	auth := authTokenHelper(t, ts.Server.API, "test@example.com", "password")
+
+	accept := func(token string) *apitest.Response {
+		return apitest.New().
+			Handler(ts.Server.API).
+			Post(api.InvitationAcceptPath).
+			Header(xhttp.Authorization, "Bearer "+auth.Token).
+			JSON(&api.InvitationAcceptRequest{Token: token}).
+			Expect(t)
+	}
+
+	t.Run("email_mismatch", func(t *testing.T) {
+		accept(other.Token).Status(http.StatusForbidden).End()
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired, err := ts.Server.InvitationRepository.Save(context.Background(), &organization.Invitation{
+			ID:             "expired",
+			OrganizationID: org.OrganizationID,
+			Email:          "test@example.com",
+			Token:          "expired-token",
+			ExpiresAt:      time.Now().Add(-time.Minute),
+		})
+		require.NoError(t, err)
+
+		accept(expired.Token).Status(http.StatusUnprocessableEntity).End()
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		resp := &api.AdminMemberResponse{}
+
+		accept(invitation.Token).Status(http.StatusOK).End().JSON(resp)
+
+		assert.Equal(t, org.OrganizationID, resp.OrganizationID)
+		assert.Equal(t, ts.user.ID, resp.UserID)
+		assert.Equal(t, []string{"billing"}, resp.Roles)
+
+		// invitation can be accepted only once
+		accept(invitation.Token).Status(http.StatusNotFound).End()
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		ts.request().
+			Delete(invitationsPath + "/" + other.InvitationID).
+			Expect(t).
+			Status(http.StatusNoContent).
+			End()
+
+		ts.request().
+			Delete(invitationsPath + "/" + other.InvitationID).
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+	})
+}
+
+func (ts *OrganizationsTestSuite) TestDeleteUser() {
+	t := ts.T()
+
+	org := ts.createOrganization("Acme")
+
+	ts.addMember(org.OrganizationID, ts.user.ID, "owner")
+
+	ts.request().
+		Delete(api.AdminUsersPath + "/" + ts.user.ID).
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	// kept until the user is purged, so it is restored together with it
+	members, err := ts.Server.OrganizationUsecase.FindAllForUser(context.Background(), ts.user.ID)
+	require.NoError(t, err)
+
+	assert.Len(t, members, 1)
+}
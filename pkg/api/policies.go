@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+)
+
+// PolicyEvaluateHandler evaluates the policies for the subject, resource,
+// action and request context, returning the decision.
+func (s *server) PolicyEvaluateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &PolicyEvaluateRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	if !params.DryRun && len(params.Policies) > 0 {
+		s.handleError(w, r, badRequestError("Policies can be provided only in dry-run mode"))
+		return
+	}
+
+	subject, err := s.policySubject(ctx, params)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	request, err := policyRequest(ctx, params.Context)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	input := &policy.Input{
+		Subject:  subject,
+		Resource: params.Resource,
+		Action:   params.Action,
+		Request:  request,
+	}
+
+	var decision *policy.Decision
+	if params.DryRun {
+		policies := make([]*policy.Policy, 0, len(params.Policies))
+		for _, p := range params.Policies {
+			policies = append(policies, policyFromRequest(p))
+		}
+
+		decision, err = s.policyUsecase.EvaluateDryRun(ctx, input, policies)
+	} else {
+		decision, err = s.policyUsecase.Evaluate(ctx, input)
+	}
+	if err != nil {
+		s.handleError(w, r, internalServerError("Error evaluating policies").WithInternalError(err))
+		return
+	}
+
+	resp := &PolicyEvaluateResponse{
+		Allowed:   decision.Allowed,
+		PolicyIDs: decision.PolicyIDs,
+		Errors:    decision.Errors,
+		DryRun:    params.DryRun,
+	}
+
+	if resp.PolicyIDs == nil {
+		resp.PolicyIDs = make([]string, 0)
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// policySubject returns the subject attributes, loaded for the user and from
+// the access token claims.
+func (s *server) policySubject(ctx context.Context, params *PolicyEvaluateRequest) (map[string]interface{}, error) {
+	subject := make(map[string]interface{}, len(params.Subject))
+	for k, v := range params.Subject {
+		subject[k] = v
+	}
+
+	userID := params.UserID
+
+	if params.Token != "" {
+		jwtToken, err := s.parseJWT(ctx, params.Token)
+		if err != nil {
+			return nil, unprocessableEntityError("Invalid subject token")
+		}
+
+		if userID != "" && userID != jwtToken.Subject() {
+			return nil, unprocessableEntityError("Token subject does not match the user")
+		}
+
+		claims, err := jwtToken.AsMap(ctx)
+		if err != nil {
+			return nil, internalServerError("Error reading token claims").WithInternalError(err)
+		}
+
+		subject["claims"] = claims
+
+		userID = jwtToken.Subject()
+	}
+
+	if userID == "" {
+		return subject, nil
+	}
+
+	user, err := s.userUsecase.FindUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, notFoundError("User not found")
+		}
+
+		return nil, internalServerError("Database error finding user").WithInternalError(err)
+	}
+
+	roles, err := s.roleUsecase.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		return nil, internalServerError("Database error finding roles").WithInternalError(err)
+	}
+
+	roleIDs := make([]string, 0, len(roles))
+	for _, role := range roles {
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	subject["id"] = user.ID
+	subject["email"] = user.Email
+	subject["email_verified"] = user.EmailVerified
+	subject["username"] = user.Username
+	subject["name"] = user.Name
+	subject["blocked"] = user.Blocked
+	subject["app_metadata"] = map[string]interface{}(user.AppMetaData)
+	subject["user_metadata"] = map[string]interface{}(user.UserMetaData)
+	subject["roles"] = roleIDs
+	subject["permissions"] = s.roleUsecase.Permissions(roles)
+	subject["created_at"] = user.CreatedAt
+
+	return subject, nil
+}
+
+// policyRequest returns the request context attributes. Time defaults to now,
+// and IP address to the address of the caller.
+func policyRequest(ctx context.Context, in map[string]interface{}) (map[string]interface{}, error) {
+	request := make(map[string]interface{}, len(in)+2)
+	for k, v := range in {
+		request[k] = v
+	}
+
+	switch v := request["time"].(type) {
+	case nil:
+		request["time"] = time.Now()
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, badRequestError("Invalid time: %s", v)
+		}
+
+		request["time"] = t
+	default:
+		return nil, badRequestError("Invalid time: %v", v)
+	}
+
+	if _, ok := request["ip"]; !ok {
+		if ip := getUserIP(ctx); ip != nil {
+			request["ip"] = ip.String()
+		}
+	}
+
+	return request, nil
+}
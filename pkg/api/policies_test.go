@@ -0,0 +1,390 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+type PoliciesTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+
+	user *user.User
+}
+
+//nolint:errcheck
+func (ts *PoliciesTestSuite) SetupTest() {
+	// truncate
+	ts.Server.PolicyRepository.DeleteAll(context.Background())
+	ts.Server.RoleRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+
+	// create test user
+	u, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	})
+	require.NoError(ts.T(), err)
+
+	ts.user = u
+}
+
+func TestPolicies(t *testing.T) {
+	ts := &PoliciesTestSuite{}
+
+	ts.Server, _ = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				Admin: &config.AdminConfig{
+					APIKey: testAdminAPIKey,
+				},
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *PoliciesTestSuite) request() *apitest.APITest {
+	return apitest.New().
+		Handler(ts.Server.API).
+		Intercept(func(r *http.Request) {
+			r.Header.Set(xhttp.Authorization, "Bearer "+testAdminAPIKey)
+		})
+}
+
+func (ts *PoliciesTestSuite) createPolicy(id, effect, expression string) {
+	ts.request().
+		Post(api.AdminPoliciesPath).
+		JSON(&api.AdminPolicyCreateRequest{
+			ID:         id,
+			Effect:     effect,
+			Expression: expression,
+		}).
+		Expect(ts.T()).
+		Status(http.StatusCreated).
+		End()
+}
+
+func (ts *PoliciesTestSuite) evaluate(req *api.PolicyEvaluateRequest) *api.PolicyEvaluateResponse {
+	resp := &api.PolicyEvaluateResponse{}
+
+	ts.request().
+		Post(api.PolicyEvaluatePath).
+		JSON(req).
+		Expect(ts.T()).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	return resp
+}
+
+func (ts *PoliciesTestSuite) TestUnauthorized() {
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.PolicyEvaluatePath).
+		Expect(ts.T()).
+		Status(http.StatusUnauthorized).
+		End()
+}
+
+func (ts *PoliciesTestSuite) TestCRUD() {
+	t := ts.T()
+
+	ts.createPolicy("owner", "allow", "resource.owner == subject.id")
+
+	ts.request().
+		Post(api.AdminPoliciesPath).
+		JSON(&api.AdminPolicyCreateRequest{
+			ID:         "owner",
+			Effect:     "allow",
+			Expression: "true",
+		}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	ts.request().
+		Post(api.AdminPoliciesPath).
+		JSON(&api.AdminPolicyCreateRequest{
+			ID:         "invalid",
+			Effect:     "allow",
+			Expression: "resource.owner ==",
+		}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	ts.request().
+		Post(api.AdminPoliciesPath).
+		JSON(&api.AdminPolicyCreateRequest{
+			ID:         "not-bool",
+			Effect:     "allow",
+			Expression: "'text'",
+		}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	ts.request().
+		Post(api.AdminPoliciesPath).
+		JSON(&api.AdminPolicyCreateRequest{
+			ID:         "effect",
+			Effect:     "maybe",
+			Expression: "true",
+		}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	resp := &api.AdminPolicyResponse{}
+
+	ts.request().
+		Get(api.AdminPoliciesPath + "/owner").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Equal(t, "owner", resp.ID)
+	assert.Equal(t, "allow", resp.Effect)
+	assert.Equal(t, "resource.owner == subject.id", resp.Expression)
+
+	disabled := true
+
+	ts.request().
+		Patch(api.AdminPoliciesPath + "/owner").
+		JSON(&api.AdminPolicyUpdateRequest{Disabled: &disabled}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	expression := "resource.owner =="
+
+	ts.request().
+		Patch(api.AdminPoliciesPath + "/owner").
+		JSON(&api.AdminPolicyUpdateRequest{Expression: &expression}).
+		Expect(t).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	listResp := &api.AdminPolicyListResponse{}
+
+	ts.request().
+		Get(api.AdminPoliciesPath).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(listResp)
+
+	require.Len(t, listResp.Policies, 1)
+	assert.True(t, listResp.Policies[0].Disabled)
+	assert.Equal(t, "resource.owner == subject.id", listResp.Policies[0].Expression)
+
+	ts.request().
+		Delete(api.AdminPoliciesPath + "/owner").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	ts.request().
+		Get(api.AdminPoliciesPath + "/owner").
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (ts *PoliciesTestSuite) TestEvaluate() {
+	t := ts.T()
+
+	ts.createPolicy("owner", "allow", "resource.owner == subject.id")
+	ts.createPolicy("business-hours", "deny",
+		"action == 'write' && (request.time.getHours() < 9 || request.time.getHours() >= 17)")
+
+	t.Run("default_deny", func(t *testing.T) {
+		resp := ts.evaluate(&api.PolicyEvaluateRequest{
+			UserID:   ts.user.ID,
+			Resource: map[string]interface{}{"owner": "someone-else"},
+			Action:   "read",
+		})
+
+		assert.False(t, resp.Allowed)
+		assert.Empty(t, resp.PolicyIDs)
+	})
+
+	t.Run("owner", func(t *testing.T) {
+		resp := ts.evaluate(&api.PolicyEvaluateRequest{
+			UserID:   ts.user.ID,
+			Resource: map[string]interface{}{"owner": ts.user.ID},
+			Action:   "read",
+		})
+
+		assert.True(t, resp.Allowed)
+		assert.Equal(t, []string{"owner"}, resp.PolicyIDs)
+	})
+
+	t.Run("business_hours", func(t *testing.T) {
+		resp := ts.evaluate(&api.PolicyEvaluateRequest{
+			UserID:   ts.user.ID,
+			Resource: map[string]interface{}{"owner": ts.user.ID},
+			Action:   "write",
+			Context:  map[string]interface{}{"time": "2021-06-01T12:00:00Z"},
+		})
+
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("deny_overrides", func(t *testing.T) {
+		resp := ts.evaluate(&api.PolicyEvaluateRequest{
+			UserID:   ts.user.ID,
+			Resource: map[string]interface{}{"owner": ts.user.ID},
+			Action:   "write",
+			Context:  map[string]interface{}{"time": "2021-06-01T20:00:00Z"},
+		})
+
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, []string{"business-hours"}, resp.PolicyIDs)
+	})
+
+	t.Run("missing_attribute", func(t *testing.T) {
+		resp := ts.evaluate(&api.PolicyEvaluateRequest{
+			UserID: ts.user.ID,
+			Action: "read",
+		})
+
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Errors, "owner")
+	})
+
+	t.Run("failing_deny", func(t *testing.T) {
+		ts.createPolicy("classified", "deny", "resource.classification == 'secret'")
+		defer func() {
+			ts.request().
+				Delete(api.AdminPoliciesPath + "/classified").
+				Expect(t).
+				Status(http.StatusNoContent).
+				End()
+		}()
+
+		// the deny policy fails without the attribute, the allow one matches
+		resp := ts.evaluate(&api.PolicyEvaluateRequest{
+			UserID:   ts.user.ID,
+			Resource: map[string]interface{}{"owner": ts.user.ID},
+			Action:   "read",
+		})
+
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, []string{"classified"}, resp.PolicyIDs)
+		assert.Contains(t, resp.Errors, "classified")
+	})
+
+	t.Run("unknown_user", func(t *testing.T) {
+		ts.request().
+			Post(api.PolicyEvaluatePath).
+			JSON(&api.PolicyEvaluateRequest{UserID: "unknown"}).
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+	})
+
+	t.Run("invalid_time", func(t *testing.T) {
+		ts.request().
+			Post(api.PolicyEvaluatePath).
+			JSON(&api.PolicyEvaluateRequest{
+				Context: map[string]interface{}{"time": "yesterday"},
+			}).
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+}
+
+func (ts *PoliciesTestSuite) TestEvaluateRoles() {
+	t := ts.T()
+
+	ts.createPolicy("admins", "allow", "'admin' in subject.roles")
+
+	resp := ts.evaluate(&api.PolicyEvaluateRequest{
+		UserID: ts.user.ID,
+		Action: "read",
+	})
+
+	assert.False(t, resp.Allowed)
+
+	_, err := ts.Server.RoleUsecase.CreateRole(context.Background(), &role.Role{ID: "admin"})
+	require.NoError(t, err)
+
+	err = ts.Server.RoleUsecase.AssignRole(context.Background(), ts.user.ID, "admin")
+	require.NoError(t, err)
+
+	resp = ts.evaluate(&api.PolicyEvaluateRequest{
+		UserID: ts.user.ID,
+		Action: "read",
+	})
+
+	assert.True(t, resp.Allowed)
+	assert.Equal(t, []string{"admins"}, resp.PolicyIDs)
+}
+
+func (ts *PoliciesTestSuite) TestDryRun() {
+	t := ts.T()
+
+	ts.createPolicy("owner", "allow", "resource.owner == subject.id")
+
+	req := &api.PolicyEvaluateRequest{
+		UserID:   ts.user.ID,
+		Resource: map[string]interface{}{"owner": ts.user.ID},
+		Action:   "delete",
+		DryRun:   true,
+		Policies: []*api.AdminPolicyCreateRequest{
+			{ID: "no-delete", Effect: "deny", Expression: "action == 'delete'"},
+		},
+	}
+
+	resp := ts.evaluate(req)
+
+	assert.False(t, resp.Allowed)
+	assert.True(t, resp.DryRun)
+	assert.Equal(t, []string{"no-delete"}, resp.PolicyIDs)
+
+	// dry-run policies are not stored
+	ts.request().
+		Get(api.AdminPoliciesPath + "/no-delete").
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	resp = ts.evaluate(&api.PolicyEvaluateRequest{
+		UserID:   ts.user.ID,
+		Resource: map[string]interface{}{"owner": ts.user.ID},
+		Action:   "delete",
+	})
+
+	assert.True(t, resp.Allowed)
+
+	// policies are accepted only in dry-run mode
+	req.DryRun = false
+
+	ts.request().
+		Post(api.PolicyEvaluatePath).
+		JSON(req).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+}
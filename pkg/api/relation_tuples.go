@@ -0,0 +1,279 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// RelationTupleListHandler lists relation tuples matching the query.
+func (s *server) RelationTupleListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			s.handleError(w, r, badRequestError("Invalid limit: %s", v))
+			return
+		}
+	}
+
+	relationQuery := &relationtuple.RelationQuery{
+		Namespace: query.Get("namespace"),
+		Object:    query.Get("object"),
+		Relation:  query.Get("relation"),
+	}
+
+	if v := query.Get("subject"); v != "" {
+		subject, err := relationtuple.ParseSubject(v)
+		if err != nil {
+			s.handleError(w, r, relationTupleError(err))
+			return
+		}
+
+		relationQuery.Subject = subject
+	}
+
+	tuples, nextCursor, err := s.relationTupleUsecase.FindRelationTuples(ctx, relationQuery, query.Get("cursor"), limit)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding relation tuples").WithInternalError(err))
+		return
+	}
+
+	resp := &RelationTupleListResponse{
+		RelationTuples: make([]*RelationTuple, 0, len(tuples)),
+		NextCursor:     nextCursor,
+	}
+
+	for _, tuple := range tuples {
+		resp.RelationTuples = append(resp.RelationTuples, relationTupleResponse(tuple))
+	}
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+// RelationTupleWriteHandler stores the relation tuples.
+func (s *server) RelationTupleWriteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &RelationTuplesWriteRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	tuples := make([]*relationtuple.RelationTuple, 0, len(params.RelationTuples))
+	for _, p := range params.RelationTuples {
+		tuple, err := relationTupleFromRequest(p)
+		if err != nil {
+			s.handleError(w, r, relationTupleError(err))
+			return
+		}
+
+		tuples = append(tuples, tuple)
+	}
+
+	if err := s.relationTupleUsecase.WriteRelationTuples(ctx, tuples); err != nil {
+		s.handleError(w, r, relationTupleError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"count": len(tuples)}).
+		Info("relation tuples written")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RelationTupleDeleteHandler deletes the relation tuple identified by the
+// query parameters.
+func (s *server) RelationTupleDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tuple, err := relationTupleFromRequest(relationTupleFromQuery(r.URL.Query()))
+	if err != nil {
+		s.handleError(w, r, relationTupleError(err))
+		return
+	}
+
+	if err := s.relationTupleUsecase.DeleteRelationTuples(ctx, []*relationtuple.RelationTuple{tuple}); err != nil {
+		s.handleError(w, r, relationTupleError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).
+		WithFields(logger.Fields{"relation_tuple": tuple.String()}).
+		Info("relation tuple deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CheckHandler returns whether the subject has the relation on the object.
+func (s *server) CheckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := &RelationTuple{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	err := jsonDecoder.Decode(params)
+	if err != nil {
+		s.handleError(w, r, badRequestError("Could not read input params: %v", err))
+		return
+	}
+
+	tuple, err := relationTupleFromRequest(params)
+	if err != nil {
+		s.handleError(w, r, relationTupleError(err))
+		return
+	}
+
+	allowed, err := s.relationTupleUsecase.Check(ctx, tuple)
+	if err != nil {
+		s.handleError(w, r, relationTupleError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, &CheckResponse{Allowed: allowed})
+}
+
+// ExpandHandler returns the tree of subjects in the subject set.
+func (s *server) ExpandHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	var depth int
+	if v := query.Get("max_depth"); v != "" {
+		var err error
+		depth, err = strconv.Atoi(v)
+		if err != nil || depth < 0 {
+			s.handleError(w, r, badRequestError("Invalid max_depth: %s", v))
+			return
+		}
+	}
+
+	set := &relationtuple.SubjectSet{
+		Namespace: query.Get("namespace"),
+		Object:    query.Get("object"),
+		Relation:  query.Get("relation"),
+	}
+
+	if set.Namespace == "" || set.Object == "" || set.Relation == "" {
+		s.handleError(w, r, badRequestError("Namespace, object and relation are required"))
+		return
+	}
+
+	tree, err := s.relationTupleUsecase.Expand(ctx, set, depth)
+	if err != nil {
+		s.handleError(w, r, relationTupleError(err))
+		return
+	}
+
+	mustSendJSON(w, http.StatusOK, expandTreeResponse(tree))
+}
+
+// ListObjectsHandler returns the objects on which the subject has the
+// relation.
+func (s *server) ListObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	subject, err := relationtuple.ParseSubject(query.Get("subject"))
+	if err != nil {
+		s.handleError(w, r, relationTupleError(err))
+		return
+	}
+
+	objects, err := s.relationTupleUsecase.ListObjects(ctx, query.Get("namespace"), query.Get("relation"), subject)
+	if err != nil {
+		s.handleError(w, r, relationTupleError(err))
+		return
+	}
+
+	resp := &ListObjectsResponse{
+		Objects: make([]string, 0, len(objects)),
+	}
+	resp.Objects = append(resp.Objects, objects...)
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
+
+func relationTupleError(err error) *HTTPError {
+	var validationErrors validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, relationtuple.ErrMalformedInput):
+		return badRequestError("%v", err)
+	case errors.Is(err, relationtuple.ErrUnknownNamespace),
+		errors.Is(err, relationtuple.ErrUnknownRelation):
+		return notFoundError("%v", err)
+	case errors.As(err, &validationErrors):
+		return unprocessableEntityError("Invalid relation tuple: %v", validationErrors[0])
+	default:
+		return internalServerError("Database error").WithInternalError(err)
+	}
+}
+
+func relationTupleFromQuery(query url.Values) *RelationTuple {
+	return &RelationTuple{
+		Namespace: query.Get("namespace"),
+		Object:    query.Get("object"),
+		Relation:  query.Get("relation"),
+		Subject:   query.Get("subject"),
+	}
+}
+
+func relationTupleFromRequest(in *RelationTuple) (*relationtuple.RelationTuple, error) {
+	if in == nil || in.Namespace == "" || in.Object == "" || in.Relation == "" {
+		return nil, relationtuple.ErrMalformedInput
+	}
+
+	subject, err := relationtuple.ParseSubject(in.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &relationtuple.RelationTuple{
+		Namespace: in.Namespace,
+		Object:    in.Object,
+		Relation:  in.Relation,
+		Subject:   subject,
+	}, nil
+}
+
+func relationTupleResponse(in *relationtuple.RelationTuple) *RelationTuple {
+	return &RelationTuple{
+		Namespace: in.Namespace,
+		Object:    in.Object,
+		Relation:  in.Relation,
+		Subject:   in.Subject.String(),
+	}
+}
+
+func expandTreeResponse(in *relationtuple.Tree) *ExpandTree {
+	out := &ExpandTree{
+		Type:    string(in.Type),
+		Subject: in.Subject.String(),
+	}
+
+	for _, child := range in.Children {
+		out.Children = append(out.Children, expandTreeResponse(child))
+	}
+
+	return out
+}
@@ -0,0 +1,357 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+type RelationTuplesTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+}
+
+//nolint:errcheck
+func (ts *RelationTuplesTestSuite) SetupTest() {
+	// truncate
+	ts.Server.RelationTupleRepository.DeleteAll(context.Background())
+
+	ts.writeRelationTuples(
+		"groups:editors#member@alice",
+		"folders:reports#owner@bob",
+		"folders:reports#viewer@groups:editors#member",
+		"documents:q1#parent@folders:reports#...",
+		"documents:q2#owner@carol",
+	)
+}
+
+func TestRelationTuples(t *testing.T) {
+	ts := &RelationTuplesTestSuite{}
+
+	ts.Server, _ = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				Admin: &config.AdminConfig{
+					APIKey: testAdminAPIKey,
+				},
+			},
+			Authz: &relationtuple.Config{
+				Namespaces: testNamespaces(),
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func testNamespaces() []*relationtuple.Namespace {
+	return []*relationtuple.Namespace{
+		{
+			Name: "groups",
+			Relations: []*relationtuple.Relation{
+				{Name: "member"},
+			},
+		},
+		{
+			Name: "folders",
+			Relations: []*relationtuple.Relation{
+				{Name: "..."},
+				{Name: "owner"},
+				{
+					Name: "viewer",
+					Rewrite: &relationtuple.Rewrite{
+						Union: []*relationtuple.Userset{
+							{This: true},
+							{ComputedUserset: &relationtuple.ComputedUserset{Relation: "owner"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "documents",
+			Relations: []*relationtuple.Relation{
+				{Name: "parent"},
+				{Name: "owner"},
+				{
+					Name: "viewer",
+					Rewrite: &relationtuple.Rewrite{
+						Union: []*relationtuple.Userset{
+							{This: true},
+							{ComputedUserset: &relationtuple.ComputedUserset{Relation: "owner"}},
+							{TupleToUserset: &relationtuple.TupleToUserset{
+								Tupleset:        "parent",
+								ComputedUserset: "viewer",
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (ts *RelationTuplesTestSuite) writeRelationTuples(tuples ...string) {
+	var req api.RelationTuplesWriteRequest
+
+	for _, s := range tuples {
+		tuple, err := relationtuple.ParseRelationTuple(s)
+		require.NoError(ts.T(), err)
+
+		req.RelationTuples = append(req.RelationTuples, &api.RelationTuple{
+			Namespace: tuple.Namespace,
+			Object:    tuple.Object,
+			Relation:  tuple.Relation,
+			Subject:   tuple.Subject.String(),
+		})
+	}
+
+	ts.request().
+		Put(api.RelationTuplesPath).
+		JSON(req).
+		Expect(ts.T()).
+		Status(http.StatusNoContent).
+		End()
+}
+
+func (ts *RelationTuplesTestSuite) request() *apitest.APITest {
+	return apitest.New().
+		Handler(ts.Server.API).
+		Intercept(func(r *http.Request) {
+			r.Header.Set(xhttp.Authorization, "Bearer "+testAdminAPIKey)
+		})
+}
+
+func (ts *RelationTuplesTestSuite) check(namespace, object, relation, subject string) bool {
+	resp := &api.CheckResponse{}
+
+	ts.request().
+		Post(api.CheckPath).
+		JSON(&api.RelationTuple{
+			Namespace: namespace,
+			Object:    object,
+			Relation:  relation,
+			Subject:   subject,
+		}).
+		Expect(ts.T()).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	return resp.Allowed
+}
+
+func (ts *RelationTuplesTestSuite) TestUnauthorized() {
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.CheckPath).
+		Expect(ts.T()).
+		Status(http.StatusUnauthorized).
+		End()
+}
+
+func (ts *RelationTuplesTestSuite) TestWrite() {
+	t := ts.T()
+
+	ts.request().
+		Put(api.RelationTuplesPath).
+		JSON(&api.RelationTuplesWriteRequest{
+			RelationTuples: []*api.RelationTuple{
+				{Namespace: "unknown", Object: "o", Relation: "member", Subject: "alice"},
+			},
+		}).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	ts.request().
+		Put(api.RelationTuplesPath).
+		JSON(&api.RelationTuplesWriteRequest{
+			RelationTuples: []*api.RelationTuple{
+				{Namespace: "groups", Object: "o", Relation: "member", Subject: "groups:o"},
+			},
+		}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+
+	resp := &api.RelationTupleListResponse{}
+
+	ts.request().
+		Get(api.RelationTuplesPath).
+		Query("namespace", "folders").
+		Query("object", "reports").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Len(t, resp.RelationTuples, 2)
+
+	ts.request().
+		Delete(api.RelationTuplesPath).
+		Query("namespace", "groups").
+		Query("object", "editors").
+		Query("relation", "member").
+		Query("subject", "alice").
+		Expect(t).
+		Status(http.StatusNoContent).
+		End()
+
+	assert.False(t, ts.check("groups", "editors", "member", "alice"))
+}
+
+func (ts *RelationTuplesTestSuite) TestCheck() {
+	t := ts.T()
+
+	tests := []struct {
+		namespace, object, relation, subject string
+		expected                             bool
+	}{
+		// direct
+		{"groups", "editors", "member", "alice", true},
+		{"groups", "editors", "member", "bob", false},
+		// subject set
+		{"folders", "reports", "viewer", "alice", true},
+		{"folders", "reports", "viewer", "groups:editors#member", true},
+		// computed userset
+		{"folders", "reports", "viewer", "bob", true},
+		{"folders", "reports", "owner", "alice", false},
+		// tuple to userset
+		{"documents", "q1", "viewer", "alice", true},
+		{"documents", "q1", "viewer", "bob", true},
+		{"documents", "q1", "viewer", "carol", false},
+		{"documents", "q2", "viewer", "carol", true},
+		{"documents", "q2", "viewer", "alice", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ts.check(tt.namespace, tt.object, tt.relation, tt.subject),
+			"%s:%s#%s@%s", tt.namespace, tt.object, tt.relation, tt.subject)
+	}
+
+	ts.request().
+		Post(api.CheckPath).
+		JSON(&api.RelationTuple{Namespace: "documents", Object: "q1", Relation: "unknown", Subject: "alice"}).
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (ts *RelationTuplesTestSuite) TestTupleToUsersetUnknownRelation() {
+	t := ts.T()
+
+	// groups have no viewer relation, the parent is not a match
+	ts.writeRelationTuples("documents:q2#parent@groups:editors#member")
+
+	assert.True(t, ts.check("documents", "q2", "viewer", "carol"))
+	assert.False(t, ts.check("documents", "q2", "viewer", "alice"))
+
+	resp := &api.ExpandTree{}
+
+	ts.request().
+		Get(api.ExpandPath).
+		Query("namespace", "documents").
+		Query("object", "q2").
+		Query("relation", "viewer").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	for _, child := range resp.Children {
+		assert.NotEqual(t, "groups:editors#viewer", child.Subject)
+	}
+}
+
+func (ts *RelationTuplesTestSuite) TestExpand() {
+	t := ts.T()
+
+	resp := &api.ExpandTree{}
+
+	ts.request().
+		Get(api.ExpandPath).
+		Query("namespace", "folders").
+		Query("object", "reports").
+		Query("relation", "viewer").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Equal(t, "union", resp.Type)
+	assert.Equal(t, "folders:reports#viewer", resp.Subject)
+	require.Len(t, resp.Children, 2)
+
+	// stored subject set
+	group := resp.Children[0]
+	assert.Equal(t, "groups:editors#member", group.Subject)
+	require.Len(t, group.Children, 1)
+	assert.Equal(t, "leaf", group.Children[0].Type)
+	assert.Equal(t, "alice", group.Children[0].Subject)
+
+	// computed userset
+	owner := resp.Children[1]
+	assert.Equal(t, "folders:reports#owner", owner.Subject)
+	require.Len(t, owner.Children, 1)
+	assert.Equal(t, "bob", owner.Children[0].Subject)
+
+	resp = &api.ExpandTree{}
+
+	ts.request().
+		Get(api.ExpandPath).
+		Query("namespace", "folders").
+		Query("object", "reports").
+		Query("relation", "viewer").
+		Query("max_depth", "1").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	require.Len(t, resp.Children, 2)
+	assert.Equal(t, "leaf", resp.Children[0].Type)
+	assert.Empty(t, resp.Children[0].Children)
+}
+
+func (ts *RelationTuplesTestSuite) TestListObjects() {
+	t := ts.T()
+
+	resp := &api.ListObjectsResponse{}
+
+	ts.request().
+		Get(api.ListObjectsPath).
+		Query("namespace", "documents").
+		Query("relation", "viewer").
+		Query("subject", "alice").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Equal(t, []string{"q1"}, resp.Objects)
+
+	ts.request().
+		Get(api.ListObjectsPath).
+		Query("namespace", "documents").
+		Query("relation", "viewer").
+		Query("subject", "carol").
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	assert.Equal(t, []string{"q2"}, resp.Objects)
+}
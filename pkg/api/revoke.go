@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
 
@@ -40,5 +41,12 @@ func (s *server) RevocationHandler(w http.ResponseWriter, r *http.Request) {
 
 	s.log.WithContext(ctx).Info("token revoked")
 
+	s.audit(r, &auditlog.Event{
+		Action:    auditlog.ActionTokenRevoke,
+		Outcome:   auditlog.OutcomeSuccess,
+		ActorID:   refreshToken.UserID,
+		SubjectID: refreshToken.UserID,
+	})
+
 	w.WriteHeader(http.StatusOK)
 }
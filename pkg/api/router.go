@@ -27,7 +27,26 @@ const (
 	RecoverPath = "/recover"
 	LogoutPath  = "/logout"
 
-	UserPath = "/user"
+	UserPath               = "/user"
+	UserExportPath         = "/user/export"
+	UserOrganizationsPath  = "/user/organizations"
+	UserSecurityEventsPath = "/user/security-events"
+	InvitationAcceptPath   = "/invitations/accept"
+
+	AdminUsersPath         = "/admin/users"
+	AdminRolesPath         = "/admin/roles"
+	AdminPoliciesPath      = "/admin/policies"
+	AdminOrganizationsPath = "/admin/organizations"
+	AdminAuditPath         = "/admin/audit"
+	AdminWebhooksPath      = "/admin/webhooks"
+	AdminSnapshotPath      = "/admin/snapshot"
+
+	PolicyEvaluatePath = "/policies/evaluate"
+
+	RelationTuplesPath = "/relation-tuples"
+	CheckPath          = "/relation-tuples/check"
+	ExpandPath         = "/relation-tuples/expand"
+	ListObjectsPath    = "/relation-tuples/objects"
 )
 
 func (s *server) setupRouting() {
@@ -47,8 +66,9 @@ func (s *server) setupRouting() {
 	var h http.Handler
 	h = router
 	h = handlers.CORS(
-		handlers.AllowedMethods([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}),
-		handlers.AllowedHeaders([]string{xhttp.Accept, xhttp.Authorization, xhttp.ContentType, xhttp.XUseCookie}),
+		handlers.AllowedMethods([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}),
+		handlers.AllowedHeaders([]string{xhttp.Accept, xhttp.Authorization, xhttp.ContentType, xhttp.IfMatch, xhttp.XUseCookie}),
+		handlers.ExposedHeaders([]string{xhttp.ETag}),
 		handlers.AllowCredentials(),
 	)(h)
 	h = handlers.RecoveryHandler(
@@ -124,6 +144,202 @@ func (s *server) registerAPIRouter(router *mux.Router, c *config.APIConfig) {
 		r.Path(UserPath).Methods(http.MethodPost).Handler(
 			s.AuthHandler(s.UserUpdateHandler),
 		)
+		// Deletes the user, or schedules its deletion.
+		r.Path(UserPath).Methods(http.MethodDelete).Handler(
+			s.AuthHandler(s.UserDeleteHandler),
+		)
+		// Exports the data kept about the user.
+		r.Path(UserExportPath).Methods(http.MethodGet).Handler(
+			s.AuthHandler(s.UserExportHandler),
+		)
+		// Lists the organizations of the user.
+		r.Path(UserOrganizationsPath).Methods(http.MethodGet).Handler(
+			s.AuthHandler(s.UserOrganizationListHandler),
+		)
+		// Lists the security events of the user.
+		r.Path(UserSecurityEventsPath).Methods(http.MethodGet).Handler(
+			s.AuthHandler(s.UserSecurityEventListHandler),
+		)
+		// Accepts the invitation to join an organization.
+		r.Path(InvitationAcceptPath).Methods(http.MethodPost).Handler(
+			s.AuthHandler(s.InvitationAcceptHandler),
+		)
+
+		// Manages users.
+		adminUsersRouter := r.PathPrefix(AdminUsersPath).Subrouter()
+		adminUsersRouter.Path("").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminUserListHandler),
+		)
+		adminUsersRouter.Path("").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminUserCreateHandler),
+		)
+		adminUserPath := fmt.Sprintf("/{%s}", adminUserIDVar)
+		adminUsersRouter.Path(adminUserPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminUserGetHandler),
+		)
+		adminUsersRouter.Path(adminUserPath).Methods(http.MethodPatch).Handler(
+			s.AdminHandler(s.AdminUserUpdateHandler),
+		)
+		adminUsersRouter.Path(adminUserPath).Methods(http.MethodDelete).Handler(
+			s.AdminHandler(s.AdminUserDeleteHandler),
+		)
+		adminUsersRouter.Path(adminUserPath + "/block").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminUserBlockHandler),
+		)
+		adminUsersRouter.Path(adminUserPath + "/unblock").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminUserUnblockHandler),
+		)
+		adminUsersRouter.Path(adminUserPath + "/logout").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminUserLogoutHandler),
+		)
+		adminUsersRouter.Path(adminUserPath + "/confirmation").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminUserConfirmationHandler),
+		)
+		adminUsersRouter.Path(adminUserPath + "/history").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminUserHistoryListHandler),
+		)
+		adminUserVersionPath := fmt.Sprintf("%s/history/{%s}", adminUserPath, adminUserVersionVar)
+		adminUsersRouter.Path(adminUserVersionPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminUserVersionGetHandler),
+		)
+		adminUsersRouter.Path(adminUserVersionPath + "/restore").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminUserVersionRestoreHandler),
+		)
+		adminUsersRouter.Path(adminUserPath + "/roles").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminUserRoleListHandler),
+		)
+		adminUserRolePath := fmt.Sprintf("%s/roles/{%s}", adminUserPath, adminRoleIDVar)
+		adminUsersRouter.Path(adminUserRolePath).Methods(http.MethodPut).Handler(
+			s.AdminHandler(s.AdminUserRoleAssignHandler),
+		)
+		adminUsersRouter.Path(adminUserRolePath).Methods(http.MethodDelete).Handler(
+			s.AdminHandler(s.AdminUserRoleUnassignHandler),
+		)
+
+		// Manages roles.
+		adminRolesRouter := r.PathPrefix(AdminRolesPath).Subrouter()
+		adminRolesRouter.Path("").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminRoleListHandler),
+		)
+		adminRolesRouter.Path("").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminRoleCreateHandler),
+		)
+		adminRolePath := fmt.Sprintf("/{%s}", adminRoleIDVar)
+		adminRolesRouter.Path(adminRolePath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminRoleGetHandler),
+		)
+		adminRolesRouter.Path(adminRolePath).Methods(http.MethodPatch).Handler(
+			s.AdminHandler(s.AdminRoleUpdateHandler),
+		)
+		adminRolesRouter.Path(adminRolePath).Methods(http.MethodDelete).Handler(
+			s.AdminHandler(s.AdminRoleDeleteHandler),
+		)
+
+		// Manages policies.
+		adminPoliciesRouter := r.PathPrefix(AdminPoliciesPath).Subrouter()
+		adminPoliciesRouter.Path("").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminPolicyListHandler),
+		)
+		adminPoliciesRouter.Path("").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminPolicyCreateHandler),
+		)
+		adminPolicyPath := fmt.Sprintf("/{%s}", adminPolicyIDVar)
+		adminPoliciesRouter.Path(adminPolicyPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminPolicyGetHandler),
+		)
+		adminPoliciesRouter.Path(adminPolicyPath).Methods(http.MethodPatch).Handler(
+			s.AdminHandler(s.AdminPolicyUpdateHandler),
+		)
+		adminPoliciesRouter.Path(adminPolicyPath).Methods(http.MethodDelete).Handler(
+			s.AdminHandler(s.AdminPolicyDeleteHandler),
+		)
+
+		// Manages organizations, their members and invitations.
+		adminOrganizationsRouter := r.PathPrefix(AdminOrganizationsPath).Subrouter()
+		adminOrganizationsRouter.Path("").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminOrganizationListHandler),
+		)
+		adminOrganizationsRouter.Path("").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminOrganizationCreateHandler),
+		)
+		adminOrganizationPath := fmt.Sprintf("/{%s}", adminOrganizationIDVar)
+		adminOrganizationsRouter.Path(adminOrganizationPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminOrganizationGetHandler),
+		)
+		adminOrganizationsRouter.Path(adminOrganizationPath).Methods(http.MethodPatch).Handler(
+			s.AdminHandler(s.AdminOrganizationUpdateHandler),
+		)
+		adminOrganizationsRouter.Path(adminOrganizationPath).Methods(http.MethodDelete).Handler(
+			s.AdminHandler(s.AdminOrganizationDeleteHandler),
+		)
+		adminOrganizationsRouter.Path(adminOrganizationPath + "/members").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminMemberListHandler),
+		)
+		adminMemberPath := fmt.Sprintf("%s/members/{%s}", adminOrganizationPath, adminUserIDVar)
+		adminOrganizationsRouter.Path(adminMemberPath).Methods(http.MethodPut).Handler(
+			s.AdminHandler(s.AdminMemberSaveHandler),
+		)
+		adminOrganizationsRouter.Path(adminMemberPath).Methods(http.MethodDelete).Handler(
+			s.AdminHandler(s.AdminMemberRemoveHandler),
+		)
+		adminOrganizationsRouter.Path(adminOrganizationPath + "/invitations").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminInvitationListHandler),
+		)
+		adminOrganizationsRouter.Path(adminOrganizationPath + "/invitations").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminInvitationCreateHandler),
+		)
+		adminInvitationPath := fmt.Sprintf("%s/invitations/{%s}", adminOrganizationPath, adminInvitationIDVar)
+		adminOrganizationsRouter.Path(adminInvitationPath).Methods(http.MethodDelete).Handler(
+			s.AdminHandler(s.AdminInvitationRevokeHandler),
+		)
+
+		// Queries the audit log.
+		r.Path(AdminAuditPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminAuditEventListHandler),
+		)
+
+		// Inspects webhook deliveries, and redelivers them.
+		adminWebhooksRouter := r.PathPrefix(AdminWebhooksPath).Subrouter()
+		adminWebhooksRouter.Path("/deliveries").Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminWebhookDeliveryListHandler),
+		)
+		adminWebhookDeliveryPath := fmt.Sprintf("/deliveries/{%s}", adminWebhookDeliveryIDVar)
+		adminWebhooksRouter.Path(adminWebhookDeliveryPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.AdminWebhookDeliveryGetHandler),
+		)
+		adminWebhooksRouter.Path(adminWebhookDeliveryPath + "/redeliver").Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminWebhookDeliveryRedeliverHandler),
+		)
+
+		// Creates the backup archive of the database.
+		r.Path(AdminSnapshotPath).Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.AdminSnapshotHandler),
+		)
+
+		// Policy decisions.
+		r.Path(PolicyEvaluatePath).Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.PolicyEvaluateHandler),
+		)
+
+		// Manages relation tuples, and answers authorization checks.
+		r.Path(RelationTuplesPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.RelationTupleListHandler),
+		)
+		r.Path(RelationTuplesPath).Methods(http.MethodPut).Handler(
+			s.AdminHandler(s.RelationTupleWriteHandler),
+		)
+		r.Path(RelationTuplesPath).Methods(http.MethodDelete).Handler(
+			s.AdminHandler(s.RelationTupleDeleteHandler),
+		)
+		r.Path(CheckPath).Methods(http.MethodPost).Handler(
+			s.AdminHandler(s.CheckHandler),
+		)
+		r.Path(ExpandPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.ExpandHandler),
+		)
+		r.Path(ListObjectsPath).Methods(http.MethodGet).Handler(
+			s.AdminHandler(s.ListObjectsHandler),
+		)
 
 		if c.CSRF.Enabled {
 			csrfRouter.Use(csrfMiddleware)
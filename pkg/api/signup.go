@@ -9,7 +9,11 @@ import (
 	"github.com/hako/durafmt"
 	"github.com/zbiljic/authzy/pkg/database"
 	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/hash"
+	"github.com/zbiljic/authzy/pkg/hook"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
 
@@ -44,6 +48,26 @@ func (s *server) SignupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	signupUser := *params
+	signupUser.Password = ""
+
+	hookResp, err := s.callHook(ctx, &hook.Request{
+		Hook:      hook.BeforeSignup,
+		User:      &signupUser,
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		s.audit(r, &auditlog.Event{
+			Action:   auditlog.ActionSignup,
+			Outcome:  auditlog.OutcomeFailure,
+			Reason:   "before signup hook failed",
+			MetaData: map[string]interface{}{"identifier": params.Email},
+		})
+
+		s.handleError(w, r, err)
+		return
+	}
+
 	createdUser, err := s.userUsecase.FindUserByEmail(ctx, params.Email)
 	if err != nil {
 		if !errors.Is(err, database.ErrNotFound) {
@@ -58,14 +82,31 @@ func (s *server) SignupHandler(w http.ResponseWriter, r *http.Request) {
 		if createdUser.IsConfirmed() {
 			s.log.WithContext(ctx).Warn("already registered")
 
+			s.audit(r, &auditlog.Event{
+				Action:    auditlog.ActionSignup,
+				Outcome:   auditlog.OutcomeFailure,
+				SubjectID: createdUser.ID,
+				Reason:    "already registered",
+			})
+
 			s.handleError(w, r, badRequestError("A user with this email address has already been registered"))
 			return
 		}
 
 		s.log.WithContext(ctx).Warn("user not confirmed")
 
-		if _, err := s.userUsecase.UpdateUserMetaData(ctx, createdUser, params.UserMetaData); err != nil {
-			s.handleError(w, r, internalServerError("Database error updating user").WithInternalError(err))
+		err = s.transactor.WithTx(ctx, func(ctx context.Context) error {
+			u, err := s.userUsecase.UpdateUserMetaData(ctx, createdUser, params.UserMetaData)
+			if err != nil {
+				return internalServerError("Database error updating user").WithInternalError(err)
+			}
+
+			createdUser, err = s.applyHookMetaData(ctx, u, hookResp)
+
+			return err
+		})
+		if err != nil {
+			s.handleError(w, r, err)
 			return
 		}
 
@@ -75,8 +116,15 @@ func (s *server) SignupHandler(w http.ResponseWriter, r *http.Request) {
 			WithFields(logger.Fields{"email": params.Email, "username": params.Username}).
 			Info("creating user")
 
-		createdUser, err = s.signupNewUser(ctx, params)
+		createdUser, err = s.signupNewUser(ctx, params, hookResp)
 		if err != nil {
+			if errors.Is(err, hash.ErrHasherSaturated) {
+				s.log.WithContext(ctx).Warnf("could not create user: %v", err)
+
+				s.handleError(w, r, serviceUnavailableError("Too many requests in progress, please retry later"))
+				return
+			}
+
 			s.log.WithContext(ctx).Errorf("could not create user: %v", err)
 
 			s.handleError(w, r, internalServerError("Could not create user").WithInternalError(err))
@@ -86,16 +134,27 @@ func (s *server) SignupHandler(w http.ResponseWriter, r *http.Request) {
 		s.log.WithContext(ctx).
 			WithFields(logger.Fields{"user_id": createdUser.ID, "email": createdUser.Email, "username": createdUser.Username}).
 			Info("new user created")
+
+		s.userWebhook(ctx, webhook.EventUserCreated, createdUser, "")
 	}
 
+	s.audit(r, &auditlog.Event{
+		Action:    auditlog.ActionSignup,
+		Outcome:   auditlog.OutcomeSuccess,
+		ActorID:   createdUser.ID,
+		SubjectID: createdUser.ID,
+	})
+
 	if s.config.API.Mailer.Autoconfirm {
-		_, err = s.userUsecase.ConfirmUser(ctx, createdUser.ID)
+		confirmedUser, err := s.userUsecase.ConfirmUser(ctx, createdUser.ID)
 		if err != nil {
 			s.log.WithContext(ctx).Errorf("could not confirm user: %v", err)
 
 			s.handleError(w, r, internalServerError("Could not update user").WithInternalError(err))
 			return
 		}
+
+		s.userWebhook(ctx, webhook.EventUserVerified, confirmedUser, "")
 	} else {
 		mailer := s.Mailer(ctx)
 		referrer := s.getReferrer(r)
@@ -126,7 +185,9 @@ func (s *server) SignupHandler(w http.ResponseWriter, r *http.Request) {
 	mustSendJSON(w, http.StatusCreated, out)
 }
 
-func (s *server) signupNewUser(ctx context.Context, in *SignupRequest) (*user.User, error) {
+// signupNewUser creates the user with its password account, and the metadata
+// returned by the before_signup hook.
+func (s *server) signupNewUser(ctx context.Context, in *SignupRequest, hookResp *hook.Response) (*user.User, error) {
 	createUserRequest := user.User{
 		Email:      in.Email,
 		Username:   in.Username,
@@ -137,23 +198,41 @@ func (s *server) signupNewUser(ctx context.Context, in *SignupRequest) (*user.Us
 		Picture:    in.Picture,
 	}
 
-	createdUser, err := s.userUsecase.CreateUser(ctx, &createUserRequest)
+	// hashing is slow, so it is not done while the transaction is open
+	passwordHash, err := s.userUsecase.HashPassword(ctx, []byte(in.Password))
 	if err != nil {
 		return nil, err
 	}
 
-	passwordAccount := &account.Account{
-		UserID:      createdUser.ID,
-		Provider:    account.ProviderTypePassword,
-		FederatedID: createUserRequest.Email,
-	}
+	var createdUser *user.User
 
-	_, err = s.accountUsecase.CreateAccount(ctx, passwordAccount)
-	if err != nil {
-		return nil, err
-	}
+	// the user is only created together with its password account
+	err = s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		u, err := s.userUsecase.CreateUser(ctx, &createUserRequest)
+		if err != nil {
+			return err
+		}
+
+		passwordAccount := &account.Account{
+			UserID:      u.ID,
+			Provider:    account.ProviderTypePassword,
+			FederatedID: createUserRequest.Email,
+		}
+
+		_, err = s.accountUsecase.CreateAccount(ctx, passwordAccount)
+		if err != nil {
+			return err
+		}
+
+		u, err = s.userUsecase.UpdatePasswordHash(ctx, u.ID, passwordHash)
+		if err != nil {
+			return err
+		}
+
+		createdUser, err = s.applyHookMetaData(ctx, u, hookResp)
 
-	createdUser, err = s.userUsecase.UpdatePassword(ctx, createdUser.ID, []byte(in.Password))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
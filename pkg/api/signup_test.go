@@ -2,6 +2,7 @@ package api_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -13,8 +14,22 @@ import (
 
 	"github.com/zbiljic/authzy/pkg/api"
 	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
 	"github.com/zbiljic/authzy/pkg/domain/account"
+	account_bbolt "github.com/zbiljic/authzy/pkg/domain/account/storage/bbolt"
+	account_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/account/storage/jsonmutexdb"
+	account_leveldb "github.com/zbiljic/authzy/pkg/domain/account/storage/leveldb"
+	account_sqlite "github.com/zbiljic/authzy/pkg/domain/account/storage/sqlite"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	user_bbolt "github.com/zbiljic/authzy/pkg/domain/user/storage/bbolt"
+	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	user_leveldb "github.com/zbiljic/authzy/pkg/domain/user/storage/leveldb"
+	user_sqlite "github.com/zbiljic/authzy/pkg/domain/user/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/hash"
 	xhttp "github.com/zbiljic/authzy/pkg/http"
 	"github.com/zbiljic/authzy/pkg/ulid"
 )
@@ -271,3 +286,140 @@ func (ts *SignupTestSuite) TestVerifySignup() {
 	assert.NotNil(t, user.ValidSince)
 	assert.WithinDuration(t, now, *user.ValidSince, 10*time.Second)
 }
+
+// failingHasher fails to hash passwords.
+type failingHasher struct {
+	hash.Hasher
+}
+
+func (failingHasher) Generate(context.Context, []byte) ([]byte, error) {
+	return nil, errors.New("hash failed")
+}
+
+// atomicRepositories are the repositories of a database used by the
+// atomicity tests.
+type atomicRepositories struct {
+	users      user.UserRepository
+	versions   user.UserVersionRepository
+	accounts   account.AccountRepository
+	transactor database.Transactor
+}
+
+// atomicDatabases return the repositories of every database, which support
+// transactions.
+var atomicDatabases = map[string]func(t *testing.T) (*atomicRepositories, func()){
+	"jsonmutexdb": func(t *testing.T) (*atomicRepositories, func()) {
+		ls, err := database_jsonmutexdb.NewLoadSaver("")
+		require.NoError(t, err)
+
+		users, err := user_jsonmutexdb.NewUserRepository(ls, "")
+		require.NoError(t, err)
+
+		versions, err := user_jsonmutexdb.NewUserVersionRepository(ls, "")
+		require.NoError(t, err)
+
+		accounts, err := account_jsonmutexdb.NewAccountRepository(ls, "")
+		require.NoError(t, err)
+
+		return &atomicRepositories{users, versions, accounts, database_jsonmutexdb.NewTransactor(ls)}, func() {}
+	},
+	"leveldb": func(t *testing.T) (*atomicRepositories, func()) {
+		db, cleanup := database_leveldb.Fixture()
+
+		users, err := user_leveldb.NewUserRepository(db, "")
+		require.NoError(t, err)
+
+		versions, err := user_leveldb.NewUserVersionRepository(db, "")
+		require.NoError(t, err)
+
+		accounts, err := account_leveldb.NewAccountRepository(db, "")
+		require.NoError(t, err)
+
+		return &atomicRepositories{users, versions, accounts, database_leveldb.NewTransactor(db)}, cleanup
+	},
+	"sqlite": func(t *testing.T) (*atomicRepositories, func()) {
+		db, cleanup := database_sqlite.Fixture()
+
+		users, err := user_sqlite.NewUserRepository(db, "")
+		require.NoError(t, err)
+
+		versions, err := user_sqlite.NewUserVersionRepository(db, "")
+		require.NoError(t, err)
+
+		accounts, err := account_sqlite.NewAccountRepository(db, "")
+		require.NoError(t, err)
+
+		return &atomicRepositories{users, versions, accounts, database_sqlite.NewTransactor(db)}, cleanup
+	},
+	"bbolt": func(t *testing.T) (*atomicRepositories, func()) {
+		db, cleanup := database_bbolt.Fixture()
+
+		users, err := user_bbolt.NewUserRepository(db, "")
+		require.NoError(t, err)
+
+		versions, err := user_bbolt.NewUserVersionRepository(db, "")
+		require.NoError(t, err)
+
+		accounts, err := account_bbolt.NewAccountRepository(db, "")
+		require.NoError(t, err)
+
+		return &atomicRepositories{users, versions, accounts, db}, cleanup
+	},
+}
+
+// TestSignupAtomic checks that a failed signup leaves neither the user nor its
+// account behind.
+func TestSignupAtomic(t *testing.T) {
+	for name, setup := range atomicDatabases {
+		setup := setup
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			repos, cleanup := setup(t)
+			defer cleanup()
+
+			server, _ := newTestServer(t, testServerOptions{
+				Config: &config.Config{
+					API: &config.APIConfig{
+						CSRF: &config.CSRFConfig{
+							AuthKey: "test",
+						},
+					},
+				},
+				Hasher:                failingHasher{},
+				AccountRepository:     repos.accounts,
+				UserRepository:        repos.users,
+				UserVersionRepository: repos.versions,
+				Transactor:            repos.transactor,
+			})
+			defer server.API.Close()
+
+			csrfToken, cookie := csrfTokenHelper(t, server.API)
+
+			req := &api.SignupRequest{
+				Email:    "atomic@example.com",
+				Username: "atomic",
+				Password: "password",
+			}
+
+			apitest.New().
+				Handler(server.API).
+				Post(api.SignupPath).
+				Header(xhttp.XCSRFToken, csrfToken).
+				Cookie(cookie.Name, cookie.Value).
+				JSON(req).
+				Expect(t).
+				Status(http.StatusInternalServerError).
+				End()
+
+			count, err := repos.users.Count(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, count)
+
+			count, err = repos.accounts.Count(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, count)
+		})
+	}
+}
@@ -5,13 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/hash"
+	"github.com/zbiljic/authzy/pkg/hook"
 	xhttp "github.com/zbiljic/authzy/pkg/http"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
 
+// authTimeClaim is the claim of the access token with the time the user
+// logged in, in seconds since the epoch, as in OpenID Connect.
+const authTimeClaim = "auth_time"
+
 // TokenHandler is the endpoint for OAuth access token requests.
 func (s *server) TokenHandler(w http.ResponseWriter, r *http.Request) {
 	grantType := r.FormValue("grant_type")
@@ -49,10 +58,24 @@ func (s *server) ResourceOwnerPasswordGrant(w http.ResponseWriter, r *http.Reque
 
 	user, err := s.userUsecase.Authenticate(ctx, username, []byte(password))
 	if err != nil {
+		if errors.Is(err, hash.ErrHasherSaturated) {
+			s.log.WithContext(ctx).Warnf("authentication rejected: %v", err)
+
+			s.handleError(w, r, serviceUnavailableError("Too many login attempts in progress, please retry later"))
+			return
+		}
+
 		s.log.WithContext(ctx).
 			WithFields(logger.Fields{"identifier": username}).
 			Warnf("authentication failed: %v", err)
 
+		s.audit(r, &auditlog.Event{
+			Action:   auditlog.ActionLogin,
+			Outcome:  auditlog.OutcomeFailure,
+			Reason:   "invalid credentials",
+			MetaData: map[string]interface{}{"identifier": username},
+		})
+
 		s.handleError(w, r, oauthError("invalid_grant", "No user found with that identifier, or password invalid."))
 		return
 	}
@@ -62,14 +85,55 @@ func (s *server) ResourceOwnerPasswordGrant(w http.ResponseWriter, r *http.Reque
 	if !user.IsConfirmed() {
 		s.log.WithContext(ctx).Warn("email not confirmed")
 
+		s.auditLoginFailure(r, user, "email not confirmed")
+
 		s.handleError(w, r, oauthError("invalid_grant", "Email not confirmed"))
 		return
 	}
 
+	if user.Blocked {
+		s.log.WithContext(ctx).Warn("user blocked")
+
+		s.auditLoginFailure(r, user, "user blocked")
+
+		s.handleError(w, r, oauthError("invalid_grant", "User is blocked"))
+		return
+	}
+
+	if user.IsDeletionScheduled() {
+		s.log.WithContext(ctx).Warn("user scheduled for deletion")
+
+		s.auditLoginFailure(r, user, "user scheduled for deletion")
+
+		s.handleError(w, r, oauthError("invalid_grant", "User is scheduled for deletion"))
+		return
+	}
+
+	loggedInUser, err := s.afterLoginHook(ctx, user, r.UserAgent())
+	if err != nil {
+		s.auditLoginFailure(r, user, "after login hook failed")
+
+		s.handleError(w, r, err)
+		return
+	}
+
+	user = loggedInUser
+
+	member, err := s.findActiveMember(ctx, user, r.FormValue("org_id"))
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
 	var token *AccessTokenResponse
 
-	token, err = s.issueRefreshToken(ctx, user)
+	token, err = s.issueRefreshToken(ctx, user, member)
 	if err != nil {
+		if e, ok := err.(*HTTPError); ok && e.Code != http.StatusInternalServerError {
+			s.handleError(w, r, e)
+			return
+		}
+
 		if e, ok := err.(ErrorCause); ok {
 			s.log.WithContext(ctx).Errorf("issue refresh token: %v", e.Cause())
 		} else {
@@ -82,6 +146,14 @@ func (s *server) ResourceOwnerPasswordGrant(w http.ResponseWriter, r *http.Reque
 
 	s.log.WithContext(ctx).Info("issued refresh token")
 
+	s.audit(r, &auditlog.Event{
+		Action:    auditlog.ActionLogin,
+		Outcome:   auditlog.OutcomeSuccess,
+		ActorID:   user.ID,
+		SubjectID: user.ID,
+		MetaData:  map[string]interface{}{"grant_type": "password"},
+	})
+
 	if cookie != "" && s.config.API.Cookie.DurationSeconds > 0 {
 		err := s.setCookieToken(ctx, w, token.Token, cookie == useSessionCookie)
 		if err != nil {
@@ -146,6 +218,14 @@ func (s *server) RefreshTokenGrant(w http.ResponseWriter, r *http.Request) {
 			WithFields(logger.Fields{"refresh_token": refreshTokenParam}).
 			Error("refresh token revoked")
 
+		s.audit(r, &auditlog.Event{
+			Action:    auditlog.ActionTokenRefresh,
+			Outcome:   auditlog.OutcomeFailure,
+			ActorID:   user.ID,
+			SubjectID: user.ID,
+			Reason:    "refresh token revoked",
+		})
+
 		s.clearCookieToken(ctx, w)
 
 		err := oauthError("invalid_grant", "Invalid Refresh Token").
@@ -154,6 +234,28 @@ func (s *server) RefreshTokenGrant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.Blocked {
+		s.log.WithContext(ctx).Warn("user blocked")
+
+		s.audit(r, &auditlog.Event{
+			Action:    auditlog.ActionTokenRefresh,
+			Outcome:   auditlog.OutcomeFailure,
+			ActorID:   user.ID,
+			SubjectID: user.ID,
+			Reason:    "user blocked",
+		})
+
+		s.clearCookieToken(ctx, w)
+		s.handleError(w, r, oauthError("invalid_grant", "User is blocked"))
+		return
+	}
+
+	member, err := s.findActiveMember(ctx, user, r.FormValue("org_id"))
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
 	newToken, err := s.refreshTokenUsecase.GrantRefreshTokenSwap(ctx, user, token)
 	if err != nil {
 		s.log.WithContext(ctx).Errorf("swap refresh token: %v", err)
@@ -162,8 +264,13 @@ func (s *server) RefreshTokenGrant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenString, err := s.generateAccessToken(ctx, user, s.config.API.JWT.ClaimsNamespace)
+	tokenString, err := s.generateAccessToken(ctx, user, member, newToken.AuthenticatedAt, s.config.API.JWT.ClaimsNamespace)
 	if err != nil {
+		if e, ok := err.(*HTTPError); ok {
+			s.handleError(w, r, e)
+			return
+		}
+
 		s.log.WithContext(ctx).Errorf("generate access token: %v", err)
 
 		s.handleError(w, r, internalServerError("error generating jwt token").WithInternalError(err))
@@ -172,6 +279,13 @@ func (s *server) RefreshTokenGrant(w http.ResponseWriter, r *http.Request) {
 
 	s.log.WithContext(ctx).Info("refreshed token")
 
+	s.audit(r, &auditlog.Event{
+		Action:    auditlog.ActionTokenRefresh,
+		Outcome:   auditlog.OutcomeSuccess,
+		ActorID:   user.ID,
+		SubjectID: user.ID,
+	})
+
 	if cookie != "" && s.config.API.Cookie.DurationSeconds > 0 {
 		err := s.setCookieToken(ctx, w, tokenString, cookie == useSessionCookie)
 		if err != nil {
@@ -192,14 +306,53 @@ func (s *server) RefreshTokenGrant(w http.ResponseWriter, r *http.Request) {
 	mustSendJSON(w, http.StatusOK, resp)
 }
 
-func (s *server) issueRefreshToken(ctx context.Context, user *user.User) (*AccessTokenResponse, error) {
+// auditLoginFailure records the failed login of the user who provided valid
+// credentials.
+func (s *server) auditLoginFailure(r *http.Request, user *user.User, reason string) {
+	s.audit(r, &auditlog.Event{
+		Action:    auditlog.ActionLogin,
+		Outcome:   auditlog.OutcomeFailure,
+		ActorID:   user.ID,
+		SubjectID: user.ID,
+		Reason:    reason,
+	})
+}
+
+// findActiveMember returns the membership of the user in the organization
+// selected as active for the access token, or nil if none is selected.
+func (s *server) findActiveMember(ctx context.Context, user *user.User, orgID string) (*organization.Member, error) {
+	if orgID == "" {
+		return nil, nil
+	}
+
+	member, err := s.organizationUsecase.FindMember(ctx, orgID, user.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			s.log.WithContext(ctx).
+				WithFields(logger.Fields{"org_id": orgID}).
+				Warn("user is not a member of the organization")
+
+			return nil, oauthError("invalid_grant", "User is not a member of the organization")
+		}
+
+		return nil, internalServerError("Database error finding organization member").WithInternalError(err)
+	}
+
+	return member, nil
+}
+
+func (s *server) issueRefreshToken(ctx context.Context, user *user.User, member *organization.Member) (*AccessTokenResponse, error) {
 	refreshToken, err := s.refreshTokenUsecase.GrantAuthenticatedUser(ctx, user)
 	if err != nil {
 		return nil, internalServerError("error granting user").WithInternalError(err)
 	}
 
-	tokenString, err := s.generateAccessToken(ctx, user, s.config.API.JWT.ClaimsNamespace)
+	tokenString, err := s.generateAccessToken(ctx, user, member, refreshToken.AuthenticatedAt, s.config.API.JWT.ClaimsNamespace)
 	if err != nil {
+		if e, ok := err.(*HTTPError); ok {
+			return nil, e
+		}
+
 		return nil, internalServerError("error generating jwt token").WithInternalError(err)
 	}
 
@@ -218,21 +371,67 @@ func (s *server) issueRefreshToken(ctx context.Context, user *user.User) (*Acces
 	}, nil
 }
 
-func (s *server) generateAccessToken(ctx context.Context, user *user.User, claimsNamespace string) (string, error) {
+// generateAccessToken generates the access token for the user, who logged in
+// at the authentication time. Organization claims are added for the
+// membership of the user in the active organization, if any, along with the
+// claims mapped from the user. The metadata returned by the
+// before_token_issue hook is saved first, so the claims are built from the
+// updated user.
+func (s *server) generateAccessToken(ctx context.Context, user *user.User, member *organization.Member, authTime time.Time, claimsNamespace string) (string, error) {
 	token, err := s.jwtService.Generate(user.ID)
 	if err != nil {
 		return "", fmt.Errorf("generate token: %w", err)
 	}
 
-	// add custom claims
-	customClaims := &CustomClaims{
-		Username: user.Username,
-		Email:    user.Email,
+	err = token.Set(authTimeClaim, authTime.Unix())
+	if err != nil {
+		return "", fmt.Errorf("set auth time: %w", err)
 	}
 
-	err = token.Set(claimsNamespace, customClaims)
+	claims, err := s.accessTokenClaims(ctx, user, member, claimsNamespace)
 	if err != nil {
-		return "", fmt.Errorf("set custom claims: %w", err)
+		return "", err
+	}
+
+	hookResp, err := s.callHook(ctx, &hook.Request{
+		Hook:   hook.BeforeTokenIssue,
+		User:   adminUserResponse(user, nil),
+		Claims: claims,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if hookResp != nil && (len(hookResp.AppMetaData) > 0 || len(hookResp.UserMetaData) > 0) {
+		user, err = s.applyHookMetaData(ctx, user, hookResp)
+		if err != nil {
+			return "", err
+		}
+
+		claims, err = s.accessTokenClaims(ctx, user, member, claimsNamespace)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for name, value := range claims {
+		err = token.Set(name, value)
+		if err != nil {
+			return "", fmt.Errorf("set custom claims: %w", err)
+		}
+	}
+
+	if hookResp != nil {
+		for name, value := range hookResp.Claims {
+			if registeredClaims[name] || name == claimsNamespace {
+				continue
+			}
+
+			err = token.Set(name, value)
+			if err != nil {
+				return "", fmt.Errorf("set hook claim: %w", err)
+			}
+		}
 	}
 
 	signed, err := s.jwtService.Sign(token)
@@ -242,3 +441,45 @@ func (s *server) generateAccessToken(ctx context.Context, user *user.User, claim
 
 	return signed, nil
 }
+
+// accessTokenClaims returns the claims of the user added to the access token,
+// the custom claims under the namespace, and the mapped top level claims.
+func (s *server) accessTokenClaims(ctx context.Context, user *user.User, member *organization.Member, claimsNamespace string) (map[string]interface{}, error) {
+	roles, err := s.roleUsecase.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find roles: %w", err)
+	}
+
+	// add custom claims
+	customClaims := &CustomClaims{
+		Username:    user.Username,
+		Email:       user.Email,
+		Permissions: s.roleUsecase.Permissions(roles),
+	}
+
+	for _, r := range roles {
+		customClaims.Roles = append(customClaims.Roles, r.ID)
+	}
+
+	if member != nil {
+		customClaims.OrgID = member.OrganizationID
+		customClaims.OrgRoles = member.Roles
+	}
+
+	mapped, err := s.mapClaims(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("map claims: %w", err)
+	}
+
+	nsClaims, err := namespaceClaims(customClaims, mapped)
+	if err != nil {
+		return nil, fmt.Errorf("namespace claims: %w", err)
+	}
+
+	claims := map[string]interface{}{claimsNamespace: nsClaims}
+	for name, value := range mapped.TopLevel {
+		claims[name] = value
+	}
+
+	return claims, nil
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/zbiljic/authzy/pkg/api"
 	"github.com/zbiljic/authzy/pkg/config"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/hash"
+	mockhasher "github.com/zbiljic/authzy/pkg/hash/mock"
 )
 
 func authTokenHelper(t *testing.T, r http.Handler, username, password string) *api.AccessTokenResponse {
@@ -94,3 +96,88 @@ func (ts *TokenTestSuite) TestHandler() {
 	assert.Equal(t, ts.Config.API.JWT.Exp, resp.ExpiresIn)
 	assert.NotEmpty(t, resp.RefreshToken)
 }
+
+func TestTokenRehash(t *testing.T) {
+	argon2 := hash.NewHasherArgon2(hash.HasherArgon2Config{
+		Memory:      16384,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   16,
+	})
+	bcrypt := hash.NewHasherBcrypt(4)
+
+	server, _ := newTestServer(t, testServerOptions{
+		Hasher: hash.NewRegistry(argon2, bcrypt),
+	})
+	defer server.API.Close()
+
+	ctx := context.Background()
+
+	createUserRequest := user.User{
+		Email:    "test@example.com",
+		Username: "test",
+	}
+	createdUser, err := server.UserUsecase.CreateUser(ctx, &createUserRequest)
+	require.NoError(t, err)
+
+	createdUser, err = server.UserUsecase.ConfirmUser(ctx, createdUser.ID)
+	require.NoError(t, err)
+
+	// imported bcrypt hash
+	bcryptHash, err := bcrypt.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+
+	createdUser.PasswordHash = string(bcryptHash)
+	_, err = server.UserRepository.Save(ctx, createdUser)
+	require.NoError(t, err)
+
+	authTokenHelper(t, server.API, "test@example.com", "password")
+
+	rehashedUser, err := server.UserRepository.FindByID(ctx, createdUser.ID)
+	require.NoError(t, err)
+
+	assert.True(t, argon2.Understands([]byte(rehashedUser.PasswordHash)))
+	assert.NoError(t, argon2.Compare(ctx, []byte("password"), []byte(rehashedUser.PasswordHash)))
+
+	// login with the new hash still works
+	authTokenHelper(t, server.API, "test@example.com", "password")
+}
+
+type saturatedHasher struct {
+	hash.Hasher
+}
+
+func (saturatedHasher) Compare(ctx context.Context, password []byte, encrypted []byte) error {
+	return hash.ErrHasherSaturated
+}
+
+func TestTokenHasherSaturated(t *testing.T) {
+	server, _ := newTestServer(t, testServerOptions{
+		Hasher: saturatedHasher{mockhasher.NewMockHasher()},
+	})
+	defer server.API.Close()
+
+	ctx := context.Background()
+
+	createUserRequest := user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	}
+	createdUser, err := server.UserUsecase.CreateUser(ctx, &createUserRequest)
+	require.NoError(t, err)
+
+	_, err = server.UserUsecase.ConfirmUser(ctx, createdUser.ID)
+	require.NoError(t, err)
+
+	apitest.New().
+		Handler(server.API).
+		Post(api.TokenPath).
+		FormData("grant_type", "password").
+		FormData("username", "test@example.com").
+		FormData("password", "password").
+		Expect(t).
+		Status(http.StatusServiceUnavailable).
+		End()
+}
@@ -1,14 +1,64 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/hash"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
 
+// userETag returns the entity tag of the revision of the user.
+func userETag(u *user.User) string {
+	return fmt.Sprintf(`"%d"`, u.Revision)
+}
+
+// ifMatch reports whether the If-Match header of the request matches the
+// entity tag, which is always the case without the header.
+func ifMatch(r *http.Request, etag string) bool {
+	values := r.Header.Values(xhttp.IfMatch)
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+
+			// weak tags never match, see RFC 7232 section 3.1
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// userUpdateError returns the error of a failed update of the user. A user
+// changed since it was read fails the precondition of a request with the
+// If-Match header, and conflicts otherwise.
+func userUpdateError(r *http.Request, msg string, err error) *HTTPError {
+	if errors.Is(err, database.ErrConflict) {
+		if r.Header.Get(xhttp.IfMatch) != "" {
+			return preconditionFailedError("User was changed since it was read").WithInternalError(err)
+		}
+
+		return conflictError("User was changed concurrently, please retry").WithInternalError(err)
+	}
+
+	return internalServerError(msg).WithInternalError(err)
+}
+
 func (s *server) UserGetHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -68,6 +118,8 @@ func (s *server) UserGetHandler(w http.ResponseWriter, r *http.Request) {
 		resp.Providers = append(resp.Providers, provider)
 	}
 
+	w.Header().Set(xhttp.ETag, userETag(user))
+
 	mustSendJSON(w, http.StatusOK, resp)
 }
 
@@ -106,18 +158,36 @@ func (s *server) UserUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the updates save the user at the revision checked here, so a user
+	// changed after the check fails them, see userUpdateError
+	if !ifMatch(r, userETag(user)) {
+		s.handleError(w, r, preconditionFailedError("User was changed since it was read"))
+		return
+	}
+
 	if params.Password != "" {
-		user, err = s.userUsecase.UpdatePassword(ctx, user.ID, []byte(params.Password))
+		user, err = s.userUsecase.UpdatePassword(ctx, user, []byte(params.Password))
 		if err != nil {
-			s.handleError(w, r, internalServerError("Error during password storage").WithInternalError(err))
+			if errors.Is(err, hash.ErrHasherSaturated) {
+				s.handleError(w, r, serviceUnavailableError("Too many requests in progress, please retry later"))
+				return
+			}
+
+			s.handleError(w, r, userUpdateError(r, "Error during password storage", err))
 			return
 		}
+
+		s.audit(r, &auditlog.Event{
+			Action:    auditlog.ActionPasswordChange,
+			Outcome:   auditlog.OutcomeSuccess,
+			SubjectID: user.ID,
+		})
 	}
 
 	if params.UserMetaData != nil {
 		user, err = s.userUsecase.UpdateUserMetaData(ctx, user, params.UserMetaData)
 		if err != nil {
-			s.handleError(w, r, internalServerError("Error updating user").WithInternalError(err))
+			s.handleError(w, r, userUpdateError(r, "Error updating user", err))
 			return
 		}
 	}
@@ -126,15 +196,37 @@ func (s *server) UserUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		s.log.WithContext(ctx).Debugf("email change token %v", params.EmailChangeToken)
 
 		if params.EmailChangeToken != user.EmailChangeToken {
+			s.audit(r, &auditlog.Event{
+				Action:    auditlog.ActionEmailChange,
+				Outcome:   auditlog.OutcomeFailure,
+				SubjectID: user.ID,
+				Reason:    "email change token invalid",
+			})
+
 			s.handleError(w, r, unauthorizedError("email change token invalid"))
 			return
 		}
 
-		user, err = s.userUsecase.ConfirmEmailChange(ctx, user)
+		oldEmail := user.Email
+
+		// the email is only changed together with its user version
+		err = s.transactor.WithTx(ctx, func(ctx context.Context) error {
+			user, err = s.userUsecase.ConfirmEmailChange(ctx, user)
+			return err
+		})
 		if err != nil {
-			s.handleError(w, r, internalServerError("Error updating user").WithInternalError(err))
+			s.handleError(w, r, userUpdateError(r, "Error updating user", err))
 			return
 		}
+
+		s.audit(r, &auditlog.Event{
+			Action:    auditlog.ActionEmailChange,
+			Outcome:   auditlog.OutcomeSuccess,
+			SubjectID: user.ID,
+			MetaData:  map[string]interface{}{"old_email": oldEmail, "new_email": user.Email},
+		})
+
+		s.userWebhook(ctx, webhook.EventUserEmailChanged, user, oldEmail)
 	} else if params.Email != "" && params.Email != user.Email {
 		if err := s.validateEmail(ctx, params.Email); err != nil {
 			s.handleError(w, r, err)
@@ -202,5 +294,7 @@ func (s *server) UserUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		resp.Providers = append(resp.Providers, provider)
 	}
 
+	w.Header().Set(xhttp.ETag, userETag(user))
+
 	mustSendJSON(w, http.StatusOK, resp)
 }
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/hash"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// recentLoginMaxAge is how long after logging in the user is deleted without
+// entering the password again.
+const recentLoginMaxAge = 5 * time.Minute
+
+// UserDeleteHandler deletes the user after checking the password again, or
+// that the access token is of a recent login, for users without a password,
+// such as federated ones. With a deletion grace period configured, the user is
+// only scheduled for deletion and emailed a link which restores the account
+// until the period expires.
+func (s *server) UserDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jwtToken := *getToken(ctx)
+
+	sub := jwtToken.Subject()
+	if sub == "" {
+		s.handleError(w, r, badRequestError("Could not read 'sub' claim"))
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": sub})
+
+	params := &UserDeleteRequest{}
+
+	jsonDecoder := json.NewDecoder(r.Body)
+	if err := jsonDecoder.Decode(params); err != nil {
+		s.handleError(w, r, badRequestError("Could not read user delete params: %v", err))
+		return
+	}
+
+	user, err := s.userUsecase.FindUserByID(ctx, sub)
+	if err != nil {
+		s.log.WithContext(ctx).Warnf("find user: %v", err)
+
+		if errors.Is(err, database.ErrNotFound) {
+			s.handleError(w, r, notFoundError(err.Error()))
+			return
+		}
+
+		s.handleError(w, r, internalServerError("Database error finding user").WithInternalError(err))
+		return
+	}
+
+	if params.Password == "" {
+		if !isRecentLogin(jwtToken) {
+			s.log.WithContext(ctx).Warn("re-authentication failed: login not recent")
+
+			s.audit(r, &auditlog.Event{
+				Action:    auditlog.ActionAccountDelete,
+				Outcome:   auditlog.OutcomeFailure,
+				SubjectID: user.ID,
+				Reason:    "login not recent",
+			})
+
+			s.handleError(w, r, unauthorizedError("Deleting the user requires the password, or a recent login"))
+			return
+		}
+	} else {
+		authenticated, err := s.userUsecase.Authenticate(ctx, user.Email, []byte(params.Password))
+		if err != nil || authenticated.ID != user.ID {
+			if errors.Is(err, hash.ErrHasherSaturated) {
+				s.log.WithContext(ctx).Warnf("authentication rejected: %v", err)
+
+				s.handleError(w, r, serviceUnavailableError("Too many login attempts in progress, please retry later"))
+				return
+			}
+
+			s.log.WithContext(ctx).Warnf("re-authentication failed: %v", err)
+
+			s.audit(r, &auditlog.Event{
+				Action:    auditlog.ActionAccountDelete,
+				Outcome:   auditlog.OutcomeFailure,
+				SubjectID: user.ID,
+				Reason:    "invalid credentials",
+			})
+
+			s.handleError(w, r, unauthorizedError("Invalid password"))
+			return
+		}
+	}
+
+	gracePeriod := s.config.API.DeletionGracePeriod
+
+	if gracePeriod <= 0 {
+		if err := s.purgeUser(ctx, user); err != nil {
+			s.handleError(w, r, err)
+			return
+		}
+
+		s.log.WithContext(ctx).Info("user deleted")
+
+		s.audit(r, &auditlog.Event{
+			Action:    auditlog.ActionAccountDelete,
+			Outcome:   auditlog.OutcomeSuccess,
+			SubjectID: user.ID,
+		})
+
+		s.userWebhook(ctx, webhook.EventUserDeleted, user, "")
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := s.sendDeletionRestore(ctx, user, s.Mailer(ctx), gracePeriod, s.getReferrer(r)); err != nil {
+		s.handleError(w, r, internalServerError("Error scheduling user deletion").WithInternalError(err))
+		return
+	}
+
+	if err := s.refreshTokenUsecase.Logout(ctx, user); err != nil {
+		s.handleError(w, r, internalServerError("Error logging out user").WithInternalError(err))
+		return
+	}
+
+	deletionAt := user.DeletionRequestedAt.Add(gracePeriod)
+
+	s.log.WithContext(ctx).Info("user deletion scheduled")
+
+	s.audit(r, &auditlog.Event{
+		Action:    auditlog.ActionAccountDelete,
+		Outcome:   auditlog.OutcomeSuccess,
+		SubjectID: user.ID,
+		MetaData:  map[string]interface{}{"deletion_at": deletionAt.UTC().Format(time.RFC3339)},
+	})
+
+	mustSendJSON(w, http.StatusAccepted, &UserDeleteResponse{DeletionAt: deletionAt})
+}
+
+// isRecentLogin returns whether the user logged in recently, starting the
+// session of the access token.
+func isRecentLogin(token jwt.Token) bool {
+	value, ok := token.Get(authTimeClaim)
+	if !ok {
+		return false
+	}
+
+	var authTime int64
+
+	switch v := value.(type) {
+	case float64:
+		authTime = int64(v)
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return false
+		}
+
+		authTime = n
+	default:
+		return false
+	}
+
+	return time.Since(time.Unix(authTime, 0)) <= recentLoginMaxAge
+}
+
+// isDeletionExpired returns whether the deletion grace period of the user has
+// expired.
+func (s *server) isDeletionExpired(u *user.User) bool {
+	return u.IsDeletionScheduled() &&
+		!time.Now().Before(u.DeletionRequestedAt.Add(s.config.API.DeletionGracePeriod))
+}
+
+// purgeDeletedUser deletes the user whose deletion grace period has expired.
+func (s *server) purgeDeletedUser(ctx context.Context, u *user.User) error {
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": u.ID})
+
+	if err := s.purgeUser(ctx, u); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("scheduled user deletion completed")
+
+	s.userWebhook(ctx, webhook.EventUserDeleted, u, "")
+
+	return nil
+}
+
+// PurgeDeletedUsers deletes all users whose deletion grace period has
+// expired.
+func (s *server) PurgeDeletedUsers(ctx context.Context) error {
+	var cursor string
+
+	for {
+		users, nextCursor, err := s.userUsecase.FindAllUsersScheduledForDeletion(ctx, cursor, scanPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range users {
+			if !s.isDeletionExpired(u) {
+				continue
+			}
+
+			if err := s.purgeDeletedUser(ctx, u); err != nil {
+				return err
+			}
+		}
+
+		if nextCursor == "" {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
@@ -0,0 +1,278 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+type UserDeleteTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+	Config *config.Config
+
+	user *user.User
+}
+
+//nolint:errcheck
+func (ts *UserDeleteTestSuite) SetupTest() {
+	// truncate
+	ts.Server.AccountRepository.DeleteAll(context.Background())
+	ts.Server.RefreshTokenRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+
+	// create test user
+	u, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	})
+	require.NoError(ts.T(), err)
+
+	u, err = ts.Server.UserUsecase.ConfirmUser(context.Background(), u.ID)
+	require.NoError(ts.T(), err)
+
+	ts.user = u
+}
+
+func TestUserDelete(t *testing.T) {
+	ts := &UserDeleteTestSuite{}
+
+	ts.Server, ts.Config = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				DeletionGracePeriod: 24 * time.Hour,
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *UserDeleteTestSuite) deleteUser(t *testing.T, password string, status int) apitest.Result {
+	t.Helper()
+
+	auth := authTokenHelper(t, ts.Server.API, ts.user.Email, "password")
+
+	return ts.deleteUserWithToken(t, auth, password, status)
+}
+
+func (ts *UserDeleteTestSuite) deleteUserWithToken(t *testing.T, auth *api.AccessTokenResponse, password string, status int) apitest.Result {
+	t.Helper()
+
+	return apitest.New().
+		Handler(ts.Server.API).
+		Delete(api.UserPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		JSON(&api.UserDeleteRequest{Password: password}).
+		Expect(t).
+		Status(status).
+		End()
+}
+
+func (ts *UserDeleteTestSuite) restore(t *testing.T, token string, status int) {
+	t.Helper()
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.VerifyPath).
+		JSON(&api.VerifyRequest{Type: "restore", Token: token}).
+		Expect(t).
+		Status(status).
+		End()
+}
+
+func (ts *UserDeleteTestSuite) TestWrongPassword() {
+	t := ts.T()
+
+	ts.deleteUser(t, "wrong", http.StatusUnauthorized)
+
+	u, err := ts.Server.UserUsecase.FindUserByID(context.Background(), ts.user.ID)
+	require.NoError(t, err)
+
+	assert.False(t, u.IsDeletionScheduled())
+}
+
+func (ts *UserDeleteTestSuite) TestRecentLogin() {
+	t := ts.T()
+
+	ts.deleteUser(t, "", http.StatusAccepted)
+
+	u, err := ts.Server.UserUsecase.FindUserByID(context.Background(), ts.user.ID)
+	require.NoError(t, err)
+
+	assert.True(t, u.IsDeletionScheduled())
+}
+
+func (ts *UserDeleteTestSuite) TestLoginNotRecent() {
+	t := ts.T()
+
+	ctx := context.Background()
+
+	auth := authTokenHelper(t, ts.Server.API, ts.user.Email, "password")
+
+	token, err := ts.Server.RefreshTokenUsecase.FindRefreshTokenByToken(ctx, auth.RefreshToken)
+	require.NoError(t, err)
+
+	token.AuthenticatedAt = time.Now().Add(-time.Hour)
+
+	_, err = ts.Server.RefreshTokenRepository.Save(ctx, token)
+	require.NoError(t, err)
+
+	// the refreshed access token keeps the time of the login
+	refreshed := &api.AccessTokenResponse{}
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.TokenPath).
+		FormData("grant_type", "refresh_token").
+		FormData("refresh_token", auth.RefreshToken).
+		Expect(t).
+		Status(http.StatusOK).
+		End().
+		JSON(refreshed)
+
+	ts.deleteUserWithToken(t, refreshed, "", http.StatusUnauthorized)
+
+	u, err := ts.Server.UserUsecase.FindUserByID(ctx, ts.user.ID)
+	require.NoError(t, err)
+
+	assert.False(t, u.IsDeletionScheduled())
+
+	// the password is checked instead
+	ts.deleteUserWithToken(t, refreshed, "password", http.StatusAccepted)
+}
+
+func (ts *UserDeleteTestSuite) TestDeleteImmediately() {
+	t := ts.T()
+
+	ts.Config.API.DeletionGracePeriod = 0
+	defer func() { ts.Config.API.DeletionGracePeriod = 24 * time.Hour }()
+
+	ts.deleteUser(t, "password", http.StatusNoContent)
+
+	_, err := ts.Server.UserUsecase.FindUserByID(context.Background(), ts.user.ID)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, database.ErrNotFound))
+
+	tokens, _, err := ts.Server.RefreshTokenUsecase.FindAllRefreshTokensForUser(context.Background(), ts.user.ID, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
+
+func (ts *UserDeleteTestSuite) TestScheduleAndRestore() {
+	t := ts.T()
+
+	resp := &api.UserDeleteResponse{}
+	ts.deleteUser(t, "password", http.StatusAccepted).JSON(resp)
+
+	u, err := ts.Server.UserUsecase.FindUserByID(context.Background(), ts.user.ID)
+	require.NoError(t, err)
+
+	require.True(t, u.IsDeletionScheduled())
+	assert.NotEmpty(t, u.DeletionToken)
+	assert.WithinDuration(t, u.DeletionRequestedAt.Add(24*time.Hour), resp.DeletionAt, time.Second)
+
+	tokens, _, err := ts.Server.RefreshTokenUsecase.FindAllRefreshTokensForUser(context.Background(), ts.user.ID, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	// scheduled users cannot log in
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.TokenPath).
+		FormData("grant_type", "password").
+		FormData("username", ts.user.Email).
+		FormData("password", "password").
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+
+	ts.restore(t, u.DeletionToken, http.StatusOK)
+
+	u, err = ts.Server.UserUsecase.FindUserByID(context.Background(), ts.user.ID)
+	require.NoError(t, err)
+
+	assert.False(t, u.IsDeletionScheduled())
+	assert.Empty(t, u.DeletionToken)
+
+	authTokenHelper(t, ts.Server.API, ts.user.Email, "password")
+}
+
+func (ts *UserDeleteTestSuite) TestRestoreExpired() {
+	t := ts.T()
+
+	ts.deleteUser(t, "password", http.StatusAccepted)
+
+	u, err := ts.Server.UserUsecase.FindUserByID(context.Background(), ts.user.ID)
+	require.NoError(t, err)
+
+	requestedAt := time.Now().Add(-48 * time.Hour)
+	u.DeletionRequestedAt = &requestedAt
+
+	_, err = ts.Server.UserUsecase.UpdateUser(context.Background(), u)
+	require.NoError(t, err)
+
+	ts.restore(t, u.DeletionToken, http.StatusGone)
+
+	_, err = ts.Server.UserUsecase.FindUserByID(context.Background(), ts.user.ID)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, database.ErrNotFound))
+}
+
+func (ts *UserDeleteTestSuite) TestPurge() {
+	t := ts.T()
+
+	ctx := context.Background()
+
+	ts.deleteUser(t, "password", http.StatusAccepted)
+
+	expired, err := ts.Server.UserUsecase.FindUserByID(ctx, ts.user.ID)
+	require.NoError(t, err)
+
+	requestedAt := time.Now().Add(-48 * time.Hour)
+	expired.DeletionRequestedAt = &requestedAt
+
+	_, err = ts.Server.UserUsecase.UpdateUser(ctx, expired)
+	require.NoError(t, err)
+
+	// scheduled within the grace period
+	scheduled, err := ts.Server.UserUsecase.CreateUser(ctx, &user.User{
+		Email:    "scheduled@example.com",
+		Username: "scheduled",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	scheduled.DeletionToken = "scheduled"
+	scheduled.DeletionRequestedAt = &now
+
+	_, err = ts.Server.UserUsecase.UpdateUser(ctx, scheduled)
+	require.NoError(t, err)
+
+	require.NoError(t, ts.Server.API.PurgeDeletedUsers(ctx))
+
+	_, err = ts.Server.UserUsecase.FindUserByID(ctx, expired.ID)
+	assert.True(t, errors.Is(err, database.ErrNotFound))
+
+	u, err := ts.Server.UserUsecase.FindUserByID(ctx, scheduled.ID)
+	require.NoError(t, err)
+	assert.True(t, u.IsDeletionScheduled())
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// scanPageSize is the page size used when reading all pages of a listing.
+const scanPageSize = 100
+
+// UserExportHandler returns the archive of the data kept about the user,
+// which are the user record, linked accounts, sessions and security events.
+func (s *server) UserExportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jwtToken := *getToken(ctx)
+
+	sub := jwtToken.Subject()
+	if sub == "" {
+		s.handleError(w, r, badRequestError("Could not read 'sub' claim"))
+		return
+	}
+
+	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": sub})
+
+	user, err := s.userUsecase.FindUserByID(ctx, sub)
+	if err != nil {
+		s.log.WithContext(ctx).Warnf("find user: %v", err)
+
+		if errors.Is(err, database.ErrNotFound) {
+			s.handleError(w, r, notFoundError(err.Error()))
+			return
+		}
+
+		s.handleError(w, r, internalServerError("Database error finding user").WithInternalError(err))
+		return
+	}
+
+	accounts, err := s.accountUsecase.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		s.handleError(w, r, internalServerError("Database error finding accounts").WithInternalError(err))
+		return
+	}
+
+	resp := &UserExportResponse{
+		ExportedAt:     time.Now().UTC(),
+		User:           adminUserResponse(user, accounts),
+		Accounts:       make([]Provider, 0, len(accounts)),
+		Sessions:       make([]*UserSessionResponse, 0),
+		SecurityEvents: make([]*AuditEventResponse, 0),
+	}
+
+	for _, acc := range accounts {
+		resp.Accounts = append(resp.Accounts, Provider{
+			Provider:    acc.Provider.String(),
+			FederatedID: acc.FederatedID,
+		})
+	}
+
+	var cursor string
+	for {
+		tokens, nextCursor, err := s.refreshTokenUsecase.FindAllRefreshTokensForUser(ctx, user.ID, cursor, scanPageSize)
+		if err != nil {
+			s.handleError(w, r, internalServerError("Database error finding sessions").WithInternalError(err))
+			return
+		}
+
+		for _, t := range tokens {
+			resp.Sessions = append(resp.Sessions, &UserSessionResponse{
+				SessionID: t.ID,
+				Revoked:   t.Revoked,
+				CreatedAt: t.CreatedAt,
+				UpdatedAt: t.UpdatedAt,
+			})
+		}
+
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	cursor = ""
+	for {
+		events, nextCursor, err := s.auditLogUsecase.FindAllEvents(ctx, &auditlog.Filter{SubjectID: user.ID}, cursor, scanPageSize)
+		if err != nil {
+			s.handleError(w, r, internalServerError("Database error finding events").WithInternalError(err))
+			return
+		}
+
+		for _, e := range events {
+			resp.SecurityEvents = append(resp.SecurityEvents, auditEventResponse(e))
+		}
+
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	s.log.WithContext(ctx).Info("user data exported")
+
+	w.Header().Set(xhttp.ContentDisposition, fmt.Sprintf("attachment; filename=%q", "user-"+user.ID+".json"))
+
+	mustSendJSON(w, http.StatusOK, resp)
+}
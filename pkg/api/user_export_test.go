@@ -0,0 +1,100 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+type UserExportTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+
+	user *user.User
+}
+
+//nolint:errcheck
+func (ts *UserExportTestSuite) SetupTest() {
+	// truncate
+	ts.Server.AccountRepository.DeleteAll(context.Background())
+	ts.Server.AuditLogRepository.DeleteAll(context.Background())
+	ts.Server.RefreshTokenRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+
+	// create test user
+	u, err := ts.Server.UserUsecase.CreateUser(context.Background(), &user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	})
+	require.NoError(ts.T(), err)
+
+	u, err = ts.Server.UserUsecase.ConfirmUser(context.Background(), u.ID)
+	require.NoError(ts.T(), err)
+
+	ts.user = u
+}
+
+func TestUserExport(t *testing.T) {
+	ts := &UserExportTestSuite{}
+
+	ts.Server, _ = newTestServer(t, testServerOptions{})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *UserExportTestSuite) TestExport() {
+	t := ts.T()
+
+	auth := authTokenHelper(t, ts.Server.API, ts.user.Email, "password")
+
+	resp := &api.UserExportResponse{}
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.UserExportPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		Expect(t).
+		Status(http.StatusOK).
+		HeaderPresent(xhttp.ContentDisposition).
+		End().
+		JSON(resp)
+
+	assert.False(t, resp.ExportedAt.IsZero())
+
+	require.NotNil(t, resp.User)
+	assert.Equal(t, ts.user.ID, resp.User.UserID)
+	assert.Equal(t, ts.user.Email, resp.User.Email)
+
+	require.Len(t, resp.Sessions, 1)
+	assert.NotEmpty(t, resp.Sessions[0].SessionID)
+	assert.False(t, resp.Sessions[0].Revoked)
+
+	require.NotEmpty(t, resp.SecurityEvents)
+	assert.Equal(t, string(auditlog.ActionLogin), resp.SecurityEvents[0].Action)
+	assert.Equal(t, ts.user.ID, resp.SecurityEvents[0].SubjectID)
+}
+
+func (ts *UserExportTestSuite) TestUnauthorized() {
+	t := ts.T()
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.UserExportPath).
+		Expect(t).
+		Status(http.StatusUnauthorized).
+		End()
+}
@@ -13,6 +13,8 @@ import (
 
 	"github.com/zbiljic/authzy/pkg/api"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/hash"
+	mockhasher "github.com/zbiljic/authzy/pkg/hash/mock"
 	xhttp "github.com/zbiljic/authzy/pkg/http"
 )
 
@@ -82,3 +84,122 @@ func (ts *UserTestSuite) TestUpdatePassword() {
 
 	assert.Equal(t, string(expectedPasswordHash), user.PasswordHash)
 }
+
+func (ts *UserTestSuite) TestUpdateIfMatch() {
+	t := ts.T()
+
+	auth := authTokenHelper(t, ts.Server.API, "test@example.com", "password")
+
+	user, err := ts.Server.UserUsecase.FindUserByEmail(context.Background(), "test@example.com")
+	require.NoError(t, err)
+
+	etag := fmt.Sprintf("%q", fmt.Sprint(user.Revision))
+
+	apitest.New().
+		Handler(ts.Server.API).
+		Get(api.UserPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		Expect(t).
+		Status(http.StatusOK).
+		Header(xhttp.ETag, etag).
+		End()
+
+	req := &api.UserUpdateRequest{
+		UserMetaData: map[string]interface{}{"color": "blue"},
+	}
+
+	// stale
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.UserPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		Header(xhttp.IfMatch, fmt.Sprintf("%q", fmt.Sprint(user.Revision-1))).
+		JSON(req).
+		Expect(t).
+		Status(http.StatusPreconditionFailed).
+		End()
+
+	// current
+	apitest.New().
+		Handler(ts.Server.API).
+		Post(api.UserPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		Header(xhttp.IfMatch, etag).
+		JSON(req).
+		Expect(t).
+		Status(http.StatusOK).
+		Header(xhttp.ETag, fmt.Sprintf("%q", fmt.Sprint(user.Revision+1))).
+		End()
+}
+
+// interleavedHasher runs the change once while the password is hashed, to
+// change the user while it is being updated.
+type interleavedHasher struct {
+	hash.Hasher
+
+	change func()
+}
+
+func (h *interleavedHasher) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	if h.change != nil {
+		change := h.change
+		h.change = nil
+
+		change()
+	}
+
+	return h.Hasher.Generate(ctx, password)
+}
+
+func TestUserUpdateConcurrentIfMatch(t *testing.T) {
+	hasher := &interleavedHasher{Hasher: mockhasher.NewMockHasher()}
+
+	server, _ := newTestServer(t, testServerOptions{
+		Hasher: hasher,
+	})
+	defer server.API.Close()
+
+	ctx := context.Background()
+
+	createUserRequest := user.User{
+		Email:    "test@example.com",
+		Username: "test",
+		Password: "password",
+	}
+	createdUser, err := server.UserUsecase.CreateUser(ctx, &createUserRequest)
+	require.NoError(t, err)
+
+	read, err := server.UserUsecase.ConfirmUser(ctx, createdUser.ID)
+	require.NoError(t, err)
+
+	auth := authTokenHelper(t, server.API, "test@example.com", "password")
+
+	read, err = server.UserUsecase.FindUserByID(ctx, read.ID)
+	require.NoError(t, err)
+
+	// the user is changed after the If-Match header was checked, and before
+	// the password is saved
+	hasher.change = func() {
+		concurrent, err := server.UserUsecase.FindUserByID(ctx, read.ID)
+		require.NoError(t, err)
+
+		_, err = server.UserUsecase.UpdateUserMetaData(ctx, concurrent, map[string]interface{}{"color": "red"})
+		require.NoError(t, err)
+	}
+
+	apitest.New().
+		Handler(server.API).
+		Post(api.UserPath).
+		Header(xhttp.Authorization, fmt.Sprintf("%s %s", auth.TokenType, auth.Token)).
+		Header(xhttp.IfMatch, fmt.Sprintf("%q", fmt.Sprint(read.Revision))).
+		JSON(&api.UserUpdateRequest{Password: "new_password"}).
+		Expect(t).
+		Status(http.StatusPreconditionFailed).
+		End()
+
+	saved, err := server.UserUsecase.FindUserByID(ctx, read.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, read.PasswordHash, saved.PasswordHash)
+	assert.Equal(t, "red", saved.UserMetaData["color"])
+}
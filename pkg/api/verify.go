@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
 	xhttp "github.com/zbiljic/authzy/pkg/http"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
@@ -23,6 +25,7 @@ var (
 const (
 	signupVerification   = "signup"
 	recoveryVerification = "recovery"
+	restoreVerification  = "restore"
 )
 
 // VerifyHandler exchanges a confirmation or recovery token to a refresh token.
@@ -68,6 +71,16 @@ func (s *server) VerifyHandler(w http.ResponseWriter, r *http.Request) {
 		user, err = s.signupVerify(ctx, params)
 	case recoveryVerification:
 		user, err = s.recoverVerify(ctx, params)
+	case restoreVerification:
+		user, err = s.restoreVerify(ctx, params)
+		if err == nil {
+			s.audit(r, &auditlog.Event{
+				Action:    auditlog.ActionAccountRestore,
+				Outcome:   auditlog.OutcomeSuccess,
+				ActorID:   user.ID,
+				SubjectID: user.ID,
+			})
+		}
 	default:
 		s.handleError(w, r, unprocessableEntityError("Verify requires a verification type"))
 		return
@@ -92,8 +105,33 @@ func (s *server) VerifyHandler(w http.ResponseWriter, r *http.Request) {
 
 	ctx = s.log.NewContext(ctx, logger.Fields{"user_id": user.ID})
 
-	token, err = s.issueRefreshToken(ctx, user)
+	if user.Blocked {
+		s.log.WithContext(ctx).Warn("user blocked")
+
+		s.handleError(w, r, forbiddenError("User is blocked"))
+		return
+	}
+
+	if user.IsDeletionScheduled() {
+		s.log.WithContext(ctx).Warn("user scheduled for deletion")
+
+		s.handleError(w, r, forbiddenError("User is scheduled for deletion"))
+		return
+	}
+
+	user, err = s.afterLoginHook(ctx, user, r.UserAgent())
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	token, err = s.issueRefreshToken(ctx, user, nil)
 	if err != nil {
+		if e, ok := err.(*HTTPError); ok && e.Code != http.StatusInternalServerError {
+			s.handleError(w, r, e)
+			return
+		}
+
 		if e, ok := err.(ErrorCause); ok {
 			s.log.WithContext(ctx).Errorf("issue refresh token: %v", e.Cause())
 		} else {
@@ -106,6 +144,14 @@ func (s *server) VerifyHandler(w http.ResponseWriter, r *http.Request) {
 
 	s.log.WithContext(ctx).Info("user verification completed")
 
+	s.audit(r, &auditlog.Event{
+		Action:    auditlog.ActionLogin,
+		Outcome:   auditlog.OutcomeSuccess,
+		ActorID:   user.ID,
+		SubjectID: user.ID,
+		MetaData:  map[string]interface{}{"verify_type": params.Type},
+	})
+
 	if cookie != "" && s.config.API.Cookie.DurationSeconds > 0 {
 		err := s.setCookieToken(ctx, w, token.Token, cookie == useSessionCookie)
 		if err != nil {
@@ -161,6 +207,8 @@ func (s *server) signupVerify(ctx context.Context, params *VerifyRequest) (*user
 		return nil, internalServerError("Error confirming user").WithInternalError(err)
 	}
 
+	s.userWebhook(ctx, webhook.EventUserVerified, user, "")
+
 	return user, nil
 }
 
@@ -189,6 +237,36 @@ func (s *server) recoverVerify(ctx context.Context, params *VerifyRequest) (*use
 	return user, nil
 }
 
+func (s *server) restoreVerify(ctx context.Context, params *VerifyRequest) (*user.User, error) {
+	user, err := s.userUsecase.FindUserByDeletionToken(ctx, params.Token)
+	if err != nil {
+		s.log.WithContext(ctx).Warnf("find user: %v", err)
+
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, notFoundError(err.Error())
+		}
+
+		return nil, internalServerError("Database error finding user").WithInternalError(err)
+	}
+
+	if s.isDeletionExpired(user) {
+		if err := s.purgeDeletedUser(ctx, user); err != nil {
+			return nil, err
+		}
+
+		return nil, goneError("Restoration token expired")
+	}
+
+	user, err = s.userUsecase.CancelDeletion(ctx, user)
+	if err != nil {
+		return nil, internalServerError("Error restoring user").WithInternalError(err)
+	}
+
+	s.log.WithContext(ctx).Info("user deletion canceled")
+
+	return user, nil
+}
+
 func (s *server) prepErrorRedirectURL(err *HTTPError, r *http.Request) string {
 	rURL := s.config.SiteURL
 
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// userWebhook queues the event of the user for delivery to the webhooks.
+// Failing to queue the event does not fail the request.
+func (s *server) userWebhook(ctx context.Context, event webhook.EventType, user *user.User, previousEmail string) {
+	data := &WebhookUserData{
+		User:          adminUserResponse(user, nil),
+		PreviousEmail: previousEmail,
+	}
+
+	if _, err := s.webhookUsecase.Enqueue(ctx, event, data); err != nil {
+		s.log.WithContext(ctx).
+			WithFields(logger.Fields{"event": event}).
+			Errorf("enqueue webhook: %v", err)
+	}
+}
@@ -0,0 +1,437 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/zbiljic/authzy/pkg/api"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+)
+
+const testWebhookSecret = "test-webhook-secret"
+
+// webhookReceiver records the requests having a valid signature.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	statusCode int
+	requests   []*webhookRequest
+
+	// held requests signal their arrival, and wait until released
+	arrived chan struct{}
+	release chan struct{}
+}
+
+type webhookRequest struct {
+	Header  http.Header
+	Body    []byte
+	Payload webhook.Payload
+	Data    api.WebhookUserData
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	t.Helper()
+
+	rcv := &webhookReceiver{statusCode: http.StatusOK}
+
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = webhook.VerifySignature(testWebhookSecret, r.Header.Get(webhook.SignatureHeader), body, 5*time.Minute, time.Now())
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		req := &webhookRequest{Header: r.Header, Body: body}
+		req.Payload.Data = &req.Data
+
+		if err := json.Unmarshal(body, &req.Payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		rcv.mu.Lock()
+		arrived, release := rcv.arrived, rcv.release
+		rcv.mu.Unlock()
+
+		if release != nil {
+			arrived <- struct{}{}
+			<-release
+		}
+
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+
+		rcv.requests = append(rcv.requests, req)
+
+		w.WriteHeader(rcv.statusCode)
+	}))
+	t.Cleanup(rcv.Close)
+
+	return rcv
+}
+
+func (rcv *webhookReceiver) reset(statusCode int) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	rcv.statusCode = statusCode
+	rcv.requests = nil
+	rcv.arrived = nil
+	rcv.release = nil
+}
+
+// hold holds the requests until the returned function is called, and
+// returns the channel signaling their arrival.
+func (rcv *webhookReceiver) hold() (<-chan struct{}, func()) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	arrived, release := make(chan struct{}, 1), make(chan struct{})
+	rcv.arrived, rcv.release = arrived, release
+
+	var once sync.Once
+
+	return arrived, func() { once.Do(func() { close(release) }) }
+}
+
+func (rcv *webhookReceiver) received() []*webhookRequest {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	return append([]*webhookRequest(nil), rcv.requests...)
+}
+
+type WebhooksTestSuite struct {
+	suite.Suite
+
+	Server *TestServer
+
+	// receives all events
+	all *webhookReceiver
+	// receives deleted users only
+	deleted *webhookReceiver
+}
+
+//nolint:errcheck
+func (ts *WebhooksTestSuite) SetupTest() {
+	// truncate
+	ts.Server.DeliveryRepository.DeleteAll(context.Background())
+	ts.Server.AccountRepository.DeleteAll(context.Background())
+	ts.Server.UserRepository.DeleteAll(context.Background())
+
+	ts.all.reset(http.StatusOK)
+	ts.deleted.reset(http.StatusOK)
+}
+
+func TestWebhooks(t *testing.T) {
+	ts := &WebhooksTestSuite{
+		all:     newWebhookReceiver(t),
+		deleted: newWebhookReceiver(t),
+	}
+
+	ts.Server, _ = newTestServer(t, testServerOptions{
+		Config: &config.Config{
+			API: &config.APIConfig{
+				Admin: &config.AdminConfig{
+					APIKey: testAdminAPIKey,
+				},
+			},
+			Webhooks: &webhook.Config{
+				Endpoints: webhook.Endpoints{
+					{
+						URL:    ts.all.URL,
+						Secret: testWebhookSecret,
+					},
+					{
+						URL:    ts.deleted.URL,
+						Secret: testWebhookSecret,
+						Events: []webhook.EventType{webhook.EventUserDeleted},
+					},
+				},
+				MaxAttempts:    2,
+				InitialBackoff: time.Nanosecond,
+				MaxBackoff:     time.Nanosecond,
+			},
+		},
+	})
+	defer ts.Server.API.Close()
+
+	suite.Run(t, ts)
+}
+
+func (ts *WebhooksTestSuite) request() *apitest.APITest {
+	return apitest.New().
+		Handler(ts.Server.API).
+		Intercept(func(r *http.Request) {
+			r.Header.Set(xhttp.Authorization, "Bearer "+testAdminAPIKey)
+		})
+}
+
+func (ts *WebhooksTestSuite) createUser() *api.AdminUserResponse {
+	resp := &api.AdminUserResponse{}
+
+	ts.request().
+		Post(api.AdminUsersPath).
+		JSON(&api.AdminUserCreateRequest{
+			Email:    "test@example.com",
+			Username: "test",
+			Password: "password",
+		}).
+		Expect(ts.T()).
+		Status(http.StatusCreated).
+		End().
+		JSON(resp)
+
+	return resp
+}
+
+func (ts *WebhooksTestSuite) deliverDue() int {
+	count, err := ts.Server.WebhookUsecase.DeliverDue(context.Background())
+	require.NoError(ts.T(), err)
+
+	return count
+}
+
+func (ts *WebhooksTestSuite) listDeliveries(status webhook.Status) *api.AdminWebhookDeliveryListResponse {
+	resp := &api.AdminWebhookDeliveryListResponse{}
+
+	ts.request().
+		Get(api.AdminWebhooksPath+"/deliveries").
+		Query("status", string(status)).
+		Expect(ts.T()).
+		Status(http.StatusOK).
+		End().
+		JSON(resp)
+
+	return resp
+}
+
+func (ts *WebhooksTestSuite) TestDelivery() {
+	t := ts.T()
+
+	u := ts.createUser()
+
+	// only the endpoint subscribed to all events
+	assert.Len(t, ts.listDeliveries(webhook.StatusPending).Deliveries, 1)
+	assert.Empty(t, ts.all.received())
+
+	assert.Equal(t, 1, ts.deliverDue())
+
+	received := ts.all.received()
+	require.Len(t, received, 1)
+
+	req := received[0]
+	assert.Equal(t, "application/json", req.Header.Get(xhttp.ContentType))
+	assert.Equal(t, string(webhook.EventUserCreated), req.Header.Get(webhook.EventHeader))
+	assert.NotEmpty(t, req.Header.Get(webhook.DeliveryHeader))
+	assert.NotEmpty(t, req.Payload.ID)
+	assert.Equal(t, webhook.EventUserCreated, req.Payload.Type)
+	assert.Equal(t, u.UserID, req.Data.User.UserID)
+	assert.Equal(t, u.Email, req.Data.User.Email)
+
+	delivered := ts.listDeliveries(webhook.StatusDelivered).Deliveries
+	require.Len(t, delivered, 1)
+
+	assert.Equal(t, req.Header.Get(webhook.DeliveryHeader), delivered[0].DeliveryID)
+	assert.Equal(t, req.Payload.ID, delivered[0].EventID)
+	assert.Equal(t, 1, delivered[0].Attempts)
+	assert.Equal(t, http.StatusOK, delivered[0].LastStatusCode)
+	assert.JSONEq(t, string(req.Body), string(delivered[0].Payload))
+
+	// nothing left to deliver
+	assert.Equal(t, 0, ts.deliverDue())
+
+	t.Run("subscribed", func(t *testing.T) {
+		ts.request().
+			Delete(fmt.Sprintf("%s/%s", api.AdminUsersPath, u.UserID)).
+			Expect(t).
+			Status(http.StatusNoContent).
+			End()
+
+		assert.Equal(t, 2, ts.deliverDue())
+
+		received := ts.deleted.received()
+		require.Len(t, received, 1)
+
+		assert.Equal(t, webhook.EventUserDeleted, received[0].Payload.Type)
+		assert.Equal(t, u.UserID, received[0].Data.User.UserID)
+
+		// both endpoints receive the same event
+		all := ts.all.received()
+		require.Len(t, all, 2)
+		assert.Equal(t, received[0].Payload.ID, all[1].Payload.ID)
+	})
+}
+
+func (ts *WebhooksTestSuite) TestDeadLetter() {
+	t := ts.T()
+
+	ts.all.reset(http.StatusInternalServerError)
+
+	u := ts.createUser()
+
+	ts.request().
+		Post(fmt.Sprintf("%s/%s/block", api.AdminUsersPath, u.UserID)).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	// retried once
+	assert.Equal(t, 2, ts.deliverDue())
+	assert.Equal(t, 2, ts.deliverDue())
+	assert.Equal(t, 0, ts.deliverDue())
+
+	assert.Len(t, ts.all.received(), 4)
+
+	dead := ts.listDeliveries(webhook.StatusDead).Deliveries
+	require.Len(t, dead, 2)
+
+	blocked := dead[1]
+	assert.Equal(t, string(webhook.EventUserBlocked), blocked.Event)
+	assert.Equal(t, 2, blocked.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, blocked.LastStatusCode)
+	assert.NotEmpty(t, blocked.LastError)
+	assert.Nil(t, blocked.NextAttemptAt)
+
+	deliveryPath := fmt.Sprintf("%s/deliveries/%s", api.AdminWebhooksPath, blocked.DeliveryID)
+
+	t.Run("get", func(t *testing.T) {
+		resp := &api.AdminWebhookDeliveryResponse{}
+
+		ts.request().
+			Get(deliveryPath).
+			Expect(t).
+			Status(http.StatusOK).
+			End().
+			JSON(resp)
+
+		assert.Equal(t, blocked.DeliveryID, resp.DeliveryID)
+		assert.Equal(t, string(webhook.StatusDead), resp.Status)
+	})
+
+	t.Run("redeliver", func(t *testing.T) {
+		ts.all.reset(http.StatusNoContent)
+
+		resp := &api.AdminWebhookDeliveryResponse{}
+
+		ts.request().
+			Post(deliveryPath + "/redeliver").
+			Expect(t).
+			Status(http.StatusAccepted).
+			End().
+			JSON(resp)
+
+		assert.Equal(t, string(webhook.StatusPending), resp.Status)
+		assert.Equal(t, 0, resp.Attempts)
+
+		// still pending
+		ts.request().
+			Post(deliveryPath + "/redeliver").
+			Expect(t).
+			Status(http.StatusUnprocessableEntity).
+			End()
+
+		assert.Equal(t, 1, ts.deliverDue())
+
+		received := ts.all.received()
+		require.Len(t, received, 1)
+
+		assert.Equal(t, blocked.EventID, received[0].Payload.ID)
+		assert.True(t, received[0].Data.User.Blocked)
+
+		assert.Len(t, ts.listDeliveries(webhook.StatusDead).Deliveries, 1)
+		assert.Len(t, ts.listDeliveries(webhook.StatusDelivered).Deliveries, 1)
+	})
+}
+
+func (ts *WebhooksTestSuite) TestDeliveryConcurrent() {
+	t := ts.T()
+
+	ts.createUser()
+
+	arrived, release := ts.all.hold()
+	defer release()
+
+	first := make(chan int, 1)
+	go func() {
+		first <- ts.deliverDue()
+	}()
+
+	<-arrived
+
+	// the delivery being sent is claimed, and does not block other runs
+	second := make(chan int, 1)
+	go func() {
+		second <- ts.deliverDue()
+	}()
+
+	select {
+	case count := <-second:
+		assert.Equal(t, 0, count)
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked by the delivery being sent")
+	}
+
+	release()
+
+	assert.Equal(t, 1, <-first)
+	assert.Len(t, ts.all.received(), 1)
+	assert.Len(t, ts.listDeliveries(webhook.StatusDelivered).Deliveries, 1)
+}
+
+func (ts *WebhooksTestSuite) TestAdmin() {
+	t := ts.T()
+
+	t.Run("invalid status", func(t *testing.T) {
+		ts.request().
+			Get(api.AdminWebhooksPath+"/deliveries").
+			Query("status", "unknown").
+			Expect(t).
+			Status(http.StatusBadRequest).
+			End()
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ts.request().
+			Get(api.AdminWebhooksPath + "/deliveries/unknown").
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+
+		ts.request().
+			Post(api.AdminWebhooksPath + "/deliveries/unknown/redeliver").
+			Expect(t).
+			Status(http.StatusNotFound).
+			End()
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		apitest.New().
+			Handler(ts.Server.API).
+			Get(api.AdminWebhooksPath + "/deliveries").
+			Expect(t).
+			Status(http.StatusUnauthorized).
+			End()
+	})
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"encoding/json"
+)
+
+// ClaimsConfig holds the configuration of the claims mapped from the user
+// into the access token.
+type ClaimsConfig struct {
+	Mappings ClaimMappings `json:"mappings" validate:"dive"`
+	// MaxValueSize is the maximum size of a single mapped claim value, in
+	// bytes of JSON. Larger values are left out of the token.
+	MaxValueSize int `json:"max_value_size" split_words:"true" default:"1024" validate:"gte=1"`
+	// MaxSize is the maximum size of all the mapped claim values, in bytes of
+	// JSON. Claims exceeding it are left out of the token.
+	MaxSize int `json:"max_size" split_words:"true" default:"4096" validate:"gte=1"`
+}
+
+// ClaimMappings holds the claims mapped into the access token. It is decoded
+// from a JSON array.
+type ClaimMappings []*ClaimMapping
+
+// Decode implements envconfig.Decoder.
+func (m *ClaimMappings) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(value), m)
+}
+
+// ClaimMapping maps a user field or a metadata value into a claim. The source
+// is a dot separated path in the user, as returned by the user endpoint, for
+// example `given_name` or `app_metadata.plan.tier`. The claim is added to the
+// claims namespace, unless it is a top-level claim.
+type ClaimMapping struct {
+	Source   string `json:"source" validate:"required"`
+	Claim    string `json:"claim" validate:"required"`
+	TopLevel bool   `json:"top_level"`
+}
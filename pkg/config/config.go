@@ -11,22 +11,31 @@ import (
 	"github.com/kelseyhightower/envconfig"
 
 	"github.com/zbiljic/authzy"
+	"github.com/zbiljic/authzy/pkg/database/bbolt"
 	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
 	"github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
 	"github.com/zbiljic/authzy/pkg/hash"
 	xhttp "github.com/zbiljic/authzy/pkg/http"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
 
 type Config struct {
-	SiteURL  string          `json:"site_url" split_words:"true"`
-	Logger   *logger.Config  `json:"logger"`
-	Debug    *DebugConfig    `json:"debug" validate:"dive"`
-	HTTP     *HTTPConfig     `json:"http" validate:"dive"`
-	Hashers  *HashersConfig  `json:"hashers" validate:"dive"`
-	Database *DatabaseConfig `json:"database" validate:"dive"`
-	API      *APIConfig      `json:"api" validate:"dive"`
-	SMTP     *SMTPConfig     `json:"smtp" validate:"dive"`
+	SiteURL       string                `json:"site_url" split_words:"true"`
+	Logger        *logger.Config        `json:"logger"`
+	Debug         *DebugConfig          `json:"debug" validate:"dive"`
+	HTTP          *HTTPConfig           `json:"http" validate:"dive"`
+	Hashers       *HashersConfig        `json:"hashers" validate:"dive"`
+	Database      *DatabaseConfig       `json:"database" validate:"dive"`
+	API           *APIConfig            `json:"api" validate:"dive"`
+	SMTP          *SMTPConfig           `json:"smtp" validate:"dive"`
+	Authz         *relationtuple.Config `json:"authz"`
+	Organizations *organization.Config  `json:"organizations"`
+	Webhooks      *webhook.Config       `json:"webhooks" validate:"dive"`
+	Tenants       TenantsConfig         `json:"tenants" validate:"dive"`
 }
 
 type DebugConfig struct {
@@ -39,25 +48,36 @@ type HTTPConfig struct {
 }
 
 type HashersConfig struct {
-	Argon2 *hash.HasherArgon2Config `json:"argon2" required:"true"`
+	Argon2         *hash.HasherArgon2Config         `json:"argon2" required:"true"`
+	FirebaseScrypt *hash.HasherFirebaseScryptConfig `json:"firebase_scrypt" split_words:"true"`
+	Scheduler      *hash.HasherSchedulerConfig      `json:"scheduler"`
+	Pepper         *hash.HasherPepperConfig         `json:"pepper"`
 }
 
 type DatabaseConfig struct {
 	Type        string              `json:"type" validate:"required"`
 	JSONMutexDB *jsonmutexdb.Config `json:"jsonmutexdb"`
 	LevelDB     *leveldb.Config     `json:"leveldb"`
+	SQLite      *sqlite.Config      `json:"sqlite"`
+	BBolt       *bbolt.Config       `json:"bbolt"`
+	// MigrateSchema migrates the database to the current schema version at
+	// startup, otherwise an outdated database fails the startup.
+	MigrateSchema bool `json:"migrate_schema" split_words:"true" default:"true"`
 }
 
 type APIConfig struct {
-	Secure            bool          `json:"secure" default:"true"`
-	RequestIDHeader   string        `json:"request_id_header" split_words:"true" validate:"required"`
-	ExternalURL       string        `json:"external_url" split_words:"true"`
-	AllowedLogoutURLs []string      `json:"allowed_logout_urls" split_words:"true"`
-	CSRF              *CSRFConfig   `json:"csrf" validate:"dive"`
-	JWT               *JWTConfig    `json:"jwt" validate:"dive"`
-	Mailer            *MailerConfig `json:"mailer" validate:"dive"`
-	Cookie            *CookieConfig `json:"cookie" validate:"dive"`
-	DisableSignup     bool          `json:"disable_signup" split_words:"true"`
+	Secure              bool          `json:"secure" default:"true"`
+	RequestIDHeader     string        `json:"request_id_header" split_words:"true" validate:"required"`
+	ExternalURL         string        `json:"external_url" split_words:"true"`
+	AllowedLogoutURLs   []string      `json:"allowed_logout_urls" split_words:"true"`
+	CSRF                *CSRFConfig   `json:"csrf" validate:"dive"`
+	JWT                 *JWTConfig    `json:"jwt" validate:"dive"`
+	Mailer              *MailerConfig `json:"mailer" validate:"dive"`
+	Cookie              *CookieConfig `json:"cookie" validate:"dive"`
+	Admin               *AdminConfig  `json:"admin" validate:"dive"`
+	Hooks               *HooksConfig  `json:"hooks" validate:"dive"`
+	DisableSignup       bool          `json:"disable_signup" split_words:"true"`
+	DeletionGracePeriod time.Duration `json:"deletion_grace_period" split_words:"true"`
 }
 
 // CSRFConfig holds all the CSRF related configuration.
@@ -74,6 +94,33 @@ type JWTConfig struct {
 	AcceptableSkew  time.Duration `json:"acceptable_skew" split_words:"true" required:"true" default:"30s"`
 	DefaultKey      string        `json:"default_key" split_words:"true" validate:"required"`
 	KeysJSON        string        `json:"-" envconfig:"keys" validate:"required"`
+	Claims          *ClaimsConfig `json:"claims" validate:"dive"`
+}
+
+// AdminConfig holds the configuration for the admin API. Requests are
+// authorized by an access token having the admin role, or by the API key.
+type AdminConfig struct {
+	Role   string `json:"role" default:"admin"`
+	APIKey string `json:"-" split_words:"true" validate:"omitempty,gte=32"`
+}
+
+// HooksConfig holds the configuration of the synchronous HTTP hooks. Hooks
+// are called while handling the request, and can reject it or amend the user
+// and the access token claims.
+type HooksConfig struct {
+	BeforeSignup     *HookConfig `json:"before_signup" split_words:"true" validate:"dive"`
+	BeforeTokenIssue *HookConfig `json:"before_token_issue" split_words:"true" validate:"dive"`
+	AfterLogin       *HookConfig `json:"after_login" split_words:"true" validate:"dive"`
+}
+
+// HookConfig holds the configuration of a single hook, which is disabled if
+// the URL is empty. Requests fail when the hook cannot be reached, times out
+// or responds with an error, unless the hook fails open.
+type HookConfig struct {
+	URL      string        `json:"url" validate:"omitempty,url"`
+	Secret   string        `json:"-"`
+	Timeout  time.Duration `json:"timeout" default:"5s"`
+	FailOpen bool          `json:"fail_open" split_words:"true"`
 }
 
 type MailerConfig struct {
@@ -90,6 +137,8 @@ type EmailContentConfig struct {
 	Confirmation string `json:"confirmation"`
 	Recovery     string `json:"recovery"`
 	EmailChange  string `json:"email_change" split_words:"true"`
+	Invitation   string `json:"invitation"`
+	Restoration  string `json:"restoration"`
 }
 
 type CookieConfig struct {
@@ -178,6 +227,9 @@ func (config *Config) ApplyDefaults() {
 	if config.API.Mailer.URLPaths.EmailChange == "" {
 		config.API.Mailer.URLPaths.EmailChange = "/verify"
 	}
+	if config.API.Mailer.URLPaths.Restoration == "" {
+		config.API.Mailer.URLPaths.Restoration = "/verify"
+	}
 }
 
 // Validate validates configuration.
@@ -189,5 +241,5 @@ func (config *Config) Validate() error {
 		return validationErrors[0]
 	}
 
-	return nil
+	return config.Tenants.validate()
 }
@@ -3,11 +3,13 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
 	xhttp "github.com/zbiljic/authzy/pkg/http"
 )
 
@@ -38,3 +40,244 @@ func TestDefaults(t *testing.T) {
 	require.NotNil(t, conf)
 	assert.Equal(t, xhttp.XRequestID, conf.API.RequestIDHeader)
 }
+
+func TestTenants(t *testing.T) {
+	os.Setenv("AUTHZY_DATABASE_TYPE", "leveldb")
+	os.Setenv("AUTHZY_DATABASE_LEVELDB_KEY_PREFIX", "authzy/")
+	os.Setenv("AUTHZY_API_CSRF_AUTH_KEY", "32-byte-long-auth-key------------")
+	os.Setenv("AUTHZY_API_JWT_CLAIMS_NAMESPACE", "https://example.test/jwt/claims")
+	os.Setenv("AUTHZY_API_JWT_DEFAULT_KEY", "test")
+	os.Setenv("AUTHZY_API_JWT_KEYS", "{}")
+	os.Setenv("AUTHZY_API_ADMIN_API_KEY", "32-byte-long-admin-api-key------")
+	os.Setenv("AUTHZY_SMTP_HOST", "smtp.example.test")
+	os.Setenv("AUTHZY_TENANTS", `[
+		{
+			"id": "acme",
+			"hosts": ["acme.example.test"],
+			"site_url": "https://acme.example.test",
+			"jwt": {"default_key": "acme", "keys": {"keys": []}},
+			"admin": {"api_key": "32-byte-long-acme-admin-api-key-"},
+			"smtp": {"user": "acme", "pass": "secret"}
+		},
+		{
+			"id": "globex",
+			"path_prefix": "/globex",
+			"storage_prefix": "gx",
+			"jwt": {"default_key": "globex", "keys": {"keys": []}}
+		}
+	]`)
+	defer os.Unsetenv("AUTHZY_TENANTS")
+	defer os.Unsetenv("AUTHZY_API_ADMIN_API_KEY")
+
+	conf, err := config.LoadConfig("")
+	require.NoError(t, err)
+	require.Len(t, conf.Tenants, 2)
+
+	acme, err := conf.ForTenant(conf.Tenants[0])
+	require.NoError(t, err)
+
+	assert.Empty(t, acme.Tenants)
+	assert.Equal(t, "https://acme.example.test", acme.SiteURL)
+	assert.Equal(t, "acme", acme.API.JWT.DefaultKey)
+	assert.JSONEq(t, `{"keys": []}`, acme.API.JWT.KeysJSON)
+	assert.Equal(t, conf.API.JWT.ClaimsNamespace, acme.API.JWT.ClaimsNamespace)
+	assert.Equal(t, "authzy/acme.", acme.Database.LevelDB.KeyPrefix)
+	assert.Equal(t, "smtp.example.test", acme.SMTP.Host)
+	assert.Equal(t, "acme", acme.SMTP.User)
+	assert.Equal(t, "secret", acme.SMTP.Pass)
+	assert.NotEqual(t, conf.API.Cookie.Key, acme.API.Cookie.Key)
+	assert.Equal(t, "32-byte-long-acme-admin-api-key-", acme.API.Admin.APIKey)
+	assert.Equal(t, conf.API.Admin.Role, acme.API.Admin.Role)
+
+	globex, err := conf.ForTenant(conf.Tenants[1])
+	require.NoError(t, err)
+
+	assert.Equal(t, "authzy/gx.", globex.Database.LevelDB.KeyPrefix)
+	assert.Equal(t, conf.SMTP, globex.SMTP)
+	assert.Empty(t, globex.API.Admin.APIKey)
+
+	// the process-wide configuration is left intact
+	assert.Equal(t, "authzy/", conf.Database.LevelDB.KeyPrefix)
+	assert.Equal(t, "test", conf.API.JWT.DefaultKey)
+	assert.Equal(t, "32-byte-long-admin-api-key------", conf.API.Admin.APIKey)
+}
+
+func TestTenantsInvalid(t *testing.T) {
+	os.Setenv("AUTHZY_DATABASE_TYPE", "jsonmutexdb")
+	os.Setenv("AUTHZY_API_CSRF_AUTH_KEY", "32-byte-long-auth-key------------")
+	os.Setenv("AUTHZY_API_JWT_CLAIMS_NAMESPACE", "https://example.test/jwt/claims")
+	os.Setenv("AUTHZY_API_JWT_DEFAULT_KEY", "test")
+	os.Setenv("AUTHZY_API_JWT_KEYS", "{}")
+	defer os.Unsetenv("AUTHZY_TENANTS")
+
+	tests := map[string]string{
+		"missing_route": `[{"id": "acme", "jwt": {"default_key": "acme", "keys": {}}}]`,
+		"missing_jwt":   `[{"id": "acme", "hosts": ["acme.example.test"]}]`,
+		"duplicate_id": `[
+			{"id": "acme", "hosts": ["acme.example.test"], "jwt": {"default_key": "acme", "keys": {}}},
+			{"id": "acme", "path_prefix": "/acme", "jwt": {"default_key": "acme", "keys": {}}}
+		]`,
+		"duplicate_host": `[
+			{"id": "acme", "hosts": ["acme.example.test"], "jwt": {"default_key": "acme", "keys": {}}},
+			{"id": "globex", "hosts": ["ACME.example.test"], "jwt": {"default_key": "globex", "keys": {}}}
+		]`,
+		"duplicate_storage_prefix": `[
+			{"id": "acme", "hosts": ["acme.example.test"], "jwt": {"default_key": "acme", "keys": {}}},
+			{"id": "globex", "path_prefix": "/globex", "storage_prefix": "acme", "jwt": {"default_key": "globex", "keys": {}}}
+		]`,
+		"duplicate_admin_api_key": `[
+			{"id": "acme", "hosts": ["acme.example.test"], "jwt": {"default_key": "acme", "keys": {}}, "admin": {"api_key": "32-byte-long-admin-api-key------"}},
+			{"id": "globex", "path_prefix": "/globex", "jwt": {"default_key": "globex", "keys": {}}, "admin": {"api_key": "32-byte-long-admin-api-key------"}}
+		]`,
+		"default_storage_overlap": `[
+			{"id": "users", "hosts": ["users.example.test"], "jwt": {"default_key": "users", "keys": {}}}
+		]`,
+		"default_storage_prefix_overlap": `[
+			{"id": "acme", "hosts": ["acme.example.test"], "storage_prefix": "accounts2", "jwt": {"default_key": "acme", "keys": {}}}
+		]`,
+		"short_admin_api_key": `[
+			{"id": "acme", "hosts": ["acme.example.test"], "jwt": {"default_key": "acme", "keys": {}}, "admin": {"api_key": "short"}}
+		]`,
+	}
+
+	for name, tenants := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv("AUTHZY_TENANTS", tenants)
+
+			_, err := config.LoadConfig("")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	os.Setenv("AUTHZY_DATABASE_TYPE", "jsonmutexdb")
+	os.Setenv("AUTHZY_API_CSRF_AUTH_KEY", "32-byte-long-auth-key------------")
+	os.Setenv("AUTHZY_API_JWT_CLAIMS_NAMESPACE", "https://example.test/jwt/claims")
+	os.Setenv("AUTHZY_API_JWT_DEFAULT_KEY", "test")
+	os.Setenv("AUTHZY_API_JWT_KEYS", "{}")
+	os.Setenv("AUTHZY_WEBHOOKS_ENDPOINTS", `[
+		{"url": "https://crm.example.test/hooks", "secret": "0123456789abcdef", "events": ["user.created", "user.deleted"]},
+		{"url": "https://audit.example.test/hooks", "secret": "fedcba9876543210"}
+	]`)
+	defer os.Unsetenv("AUTHZY_WEBHOOKS_ENDPOINTS")
+
+	conf, err := config.LoadConfig("")
+	require.NoError(t, err)
+	require.Len(t, conf.Webhooks.Endpoints, 2)
+
+	assert.Equal(t, 8, conf.Webhooks.MaxAttempts)
+
+	crm := conf.Webhooks.Endpoints[0]
+	assert.True(t, crm.IsSubscribed(webhook.EventUserCreated))
+	assert.False(t, crm.IsSubscribed(webhook.EventUserBlocked))
+
+	audit := conf.Webhooks.Endpoints[1]
+	assert.True(t, audit.IsSubscribed(webhook.EventUserBlocked))
+
+	t.Run("invalid", func(t *testing.T) {
+		tests := map[string]string{
+			"missing_url":    `[{"secret": "0123456789abcdef"}]`,
+			"short_secret":   `[{"url": "https://crm.example.test/hooks", "secret": "secret"}]`,
+			"unknown_event":  `[{"url": "https://crm.example.test/hooks", "secret": "0123456789abcdef", "events": ["user.renamed"]}]`,
+			"malformed_json": `{"url": "https://crm.example.test/hooks"}`,
+		}
+
+		for name, endpoints := range tests {
+			t.Run(name, func(t *testing.T) {
+				os.Setenv("AUTHZY_WEBHOOKS_ENDPOINTS", endpoints)
+
+				_, err := config.LoadConfig("")
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("zero durations", func(t *testing.T) {
+		os.Unsetenv("AUTHZY_WEBHOOKS_ENDPOINTS")
+
+		for _, name := range []string{"AUTHZY_WEBHOOKS_TIMEOUT", "AUTHZY_WEBHOOKS_INITIAL_BACKOFF", "AUTHZY_WEBHOOKS_MAX_BACKOFF", "AUTHZY_WEBHOOKS_POLL_INTERVAL"} {
+			t.Run(name, func(t *testing.T) {
+				os.Setenv(name, "0s")
+				defer os.Unsetenv(name)
+
+				_, err := config.LoadConfig("")
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("max backoff below initial backoff", func(t *testing.T) {
+		os.Setenv("AUTHZY_WEBHOOKS_INITIAL_BACKOFF", "1m")
+		os.Setenv("AUTHZY_WEBHOOKS_MAX_BACKOFF", "30s")
+		defer os.Unsetenv("AUTHZY_WEBHOOKS_INITIAL_BACKOFF")
+		defer os.Unsetenv("AUTHZY_WEBHOOKS_MAX_BACKOFF")
+
+		_, err := config.LoadConfig("")
+		assert.Error(t, err)
+	})
+}
+
+func TestHooks(t *testing.T) {
+	os.Setenv("AUTHZY_DATABASE_TYPE", "jsonmutexdb")
+	os.Setenv("AUTHZY_API_CSRF_AUTH_KEY", "32-byte-long-auth-key------------")
+	os.Setenv("AUTHZY_API_JWT_CLAIMS_NAMESPACE", "https://example.test/jwt/claims")
+	os.Setenv("AUTHZY_API_JWT_DEFAULT_KEY", "test")
+	os.Setenv("AUTHZY_API_JWT_KEYS", "{}")
+	os.Setenv("AUTHZY_API_HOOKS_BEFORE_SIGNUP_URL", "https://hooks.example.test/signup")
+	os.Setenv("AUTHZY_API_HOOKS_BEFORE_SIGNUP_TIMEOUT", "2s")
+	os.Setenv("AUTHZY_API_HOOKS_AFTER_LOGIN_FAIL_OPEN", "true")
+	defer os.Unsetenv("AUTHZY_API_HOOKS_BEFORE_SIGNUP_URL")
+	defer os.Unsetenv("AUTHZY_API_HOOKS_BEFORE_SIGNUP_TIMEOUT")
+	defer os.Unsetenv("AUTHZY_API_HOOKS_AFTER_LOGIN_FAIL_OPEN")
+
+	conf, err := config.LoadConfig("")
+	require.NoError(t, err)
+
+	hooks := conf.API.Hooks
+	assert.Equal(t, "https://hooks.example.test/signup", hooks.BeforeSignup.URL)
+	assert.Equal(t, 2*time.Second, hooks.BeforeSignup.Timeout)
+	assert.False(t, hooks.BeforeSignup.FailOpen)
+	assert.Empty(t, hooks.BeforeTokenIssue.URL)
+	assert.Equal(t, 5*time.Second, hooks.BeforeTokenIssue.Timeout)
+	assert.True(t, hooks.AfterLogin.FailOpen)
+
+	t.Run("invalid url", func(t *testing.T) {
+		os.Setenv("AUTHZY_API_HOOKS_BEFORE_SIGNUP_URL", "hooks.example.test")
+
+		_, err := config.LoadConfig("")
+		assert.Error(t, err)
+	})
+}
+
+func TestClaimMappings(t *testing.T) {
+	os.Setenv("AUTHZY_DATABASE_TYPE", "jsonmutexdb")
+	os.Setenv("AUTHZY_API_CSRF_AUTH_KEY", "32-byte-long-auth-key------------")
+	os.Setenv("AUTHZY_API_JWT_CLAIMS_NAMESPACE", "https://example.test/jwt/claims")
+	os.Setenv("AUTHZY_API_JWT_DEFAULT_KEY", "test")
+	os.Setenv("AUTHZY_API_JWT_KEYS", "{}")
+	os.Setenv("AUTHZY_API_JWT_CLAIMS_MAPPINGS", `[
+		{"source": "app_metadata.plan.tier", "claim": "plan_tier", "top_level": true},
+		{"source": "given_name", "claim": "given_name"}
+	]`)
+	defer os.Unsetenv("AUTHZY_API_JWT_CLAIMS_MAPPINGS")
+
+	conf, err := config.LoadConfig("")
+	require.NoError(t, err)
+
+	claims := conf.API.JWT.Claims
+	require.Len(t, claims.Mappings, 2)
+
+	assert.Equal(t, "app_metadata.plan.tier", claims.Mappings[0].Source)
+	assert.True(t, claims.Mappings[0].TopLevel)
+	assert.False(t, claims.Mappings[1].TopLevel)
+	assert.Equal(t, 1024, claims.MaxValueSize)
+	assert.Equal(t, 4096, claims.MaxSize)
+
+	t.Run("invalid", func(t *testing.T) {
+		os.Setenv("AUTHZY_API_JWT_CLAIMS_MAPPINGS", `[{"source": "given_name"}]`)
+
+		_, err := config.LoadConfig("")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/imdario/mergo"
+)
+
+// TenantStoragePrefixSeparator separates the tenant storage prefix from the
+// storage keys and file names.
+const TenantStoragePrefixSeparator = "."
+
+// defaultStorageNames are the names of the keyspaces, files, tables and
+// buckets of the default tenant, which has no storage prefix. The storage of
+// a tenant starting with one of them would be found by scans of the default
+// tenant.
+var defaultStorageNames = []string{
+	"accounts",
+	"audit_events",
+	"deleted_users",
+	"index_audit_events_subject_id",
+	"index_invitations_organization_id",
+	"index_invitations_token",
+	"index_organization_members_user_id",
+	"index_refresh_tokens_token",
+	"index_refresh_tokens_user_id",
+	"index_user_roles_role_id",
+	"index_users_confirmation_token",
+	"index_users_deletion_token",
+	"index_users_identifier",
+	"index_users_recovery_token",
+	"invitation_tokens",
+	"invitations",
+	"meta",
+	"organization_invitations",
+	"organization_members",
+	"organizations",
+	"policies",
+	"refresh_tokens",
+	"relation_tuples",
+	"roles",
+	"subject_audit_events",
+	"user_organizations",
+	"user_roles",
+	"user_versions",
+	"users",
+	"webhook_deliveries",
+	"webhook_delivery_status",
+}
+
+// TenantsConfig holds the configuration of all tenants served by the process.
+// It is decoded from a JSON array.
+type TenantsConfig []*TenantConfig
+
+// Decode implements envconfig.Decoder.
+func (t *TenantsConfig) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(value), t)
+}
+
+// TenantConfig holds the configuration of a single tenant. Requests are
+// routed to the tenant by the host header or by the path prefix. Settings
+// which are not set are inherited from the process-wide configuration, except
+// for the JWT key set which every tenant must have of its own, and the admin
+// API key which is not inherited.
+type TenantConfig struct {
+	ID            string             `json:"id" validate:"required,alphanum"`
+	Hosts         []string           `json:"hosts" validate:"required_without=PathPrefix,dive,hostname_port|hostname"`
+	PathPrefix    string             `json:"path_prefix" validate:"required_without=Hosts,omitempty,startswith=/"`
+	SiteURL       string             `json:"site_url"`
+	ExternalURL   string             `json:"external_url"`
+	StoragePrefix string             `json:"storage_prefix" validate:"omitempty,alphanum"`
+	JWT           *TenantJWTConfig   `json:"jwt" validate:"required"`
+	Admin         *TenantAdminConfig `json:"admin"`
+	Mailer        *MailerConfig      `json:"mailer"`
+	SMTP          *TenantSMTPConfig  `json:"smtp"`
+}
+
+// TenantJWTConfig holds the JWT configuration of a tenant.
+type TenantJWTConfig struct {
+	ClaimsNamespace string          `json:"claims_namespace" validate:"omitempty,gte=3"`
+	Aud             string          `json:"aud"`
+	DefaultKey      string          `json:"default_key" validate:"required"`
+	Keys            json.RawMessage `json:"keys" validate:"required"`
+}
+
+// TenantAdminConfig holds the admin API configuration of a tenant. Unlike the
+// process-wide configuration, the API key is read from JSON.
+type TenantAdminConfig struct {
+	Role   string `json:"role"`
+	APIKey string `json:"api_key" validate:"omitempty,gte=32"`
+}
+
+// TenantSMTPConfig holds the SMTP configuration of a tenant. Unlike the
+// process-wide configuration, the password is read from JSON.
+type TenantSMTPConfig struct {
+	SMTPConfig
+	Pass string `json:"pass"`
+}
+
+// storagePrefix returns the prefix of all storage keys and file names of the
+// tenant.
+func (t *TenantConfig) storagePrefix() string {
+	prefix := t.StoragePrefix
+	if prefix == "" {
+		prefix = t.ID
+	}
+
+	return prefix + TenantStoragePrefixSeparator
+}
+
+// validate checks that tenants can be told apart.
+func (t TenantsConfig) validate() error {
+	ids := make(map[string]bool)
+	hosts := make(map[string]bool)
+	pathPrefixes := make(map[string]bool)
+	storagePrefixes := make(map[string]bool)
+	adminAPIKeys := make(map[string]bool)
+
+	for _, tenant := range t {
+		if ids[tenant.ID] {
+			return fmt.Errorf("duplicate tenant: %s", tenant.ID)
+		}
+		ids[tenant.ID] = true
+
+		for _, host := range tenant.Hosts {
+			host = strings.ToLower(host)
+			if hosts[host] {
+				return fmt.Errorf("duplicate tenant host: %s", host)
+			}
+			hosts[host] = true
+		}
+
+		if tenant.PathPrefix != "" {
+			pathPrefix := strings.TrimSuffix(tenant.PathPrefix, "/")
+			if pathPrefixes[pathPrefix] {
+				return fmt.Errorf("duplicate tenant path prefix: %s", tenant.PathPrefix)
+			}
+			pathPrefixes[pathPrefix] = true
+		}
+
+		storagePrefix := tenant.storagePrefix()
+		if storagePrefixes[storagePrefix] {
+			return fmt.Errorf("duplicate tenant storage prefix: %s", storagePrefix)
+		}
+		storagePrefixes[storagePrefix] = true
+
+		for _, name := range defaultStorageNames {
+			if strings.HasPrefix(storagePrefix, name) {
+				return fmt.Errorf("tenant storage prefix overlaps the default storage %s: %s", name, storagePrefix)
+			}
+		}
+
+		if tenant.Admin != nil && tenant.Admin.APIKey != "" {
+			if adminAPIKeys[tenant.Admin.APIKey] {
+				return fmt.Errorf("duplicate tenant admin API key: %s", tenant.ID)
+			}
+			adminAPIKeys[tenant.Admin.APIKey] = true
+		}
+	}
+
+	return nil
+}
+
+// ForTenant returns the configuration of the tenant, derived from the
+// process-wide configuration.
+func (config *Config) ForTenant(t *TenantConfig) (*Config, error) {
+	c := *config
+	c.Tenants = nil
+
+	if t.SiteURL != "" {
+		c.SiteURL = t.SiteURL
+	}
+
+	apiConfig := *config.API
+	if t.ExternalURL != "" {
+		apiConfig.ExternalURL = t.ExternalURL
+	}
+
+	jwtConfig := *config.API.JWT
+	jwtConfig.DefaultKey = t.JWT.DefaultKey
+	jwtConfig.KeysJSON = string(t.JWT.Keys)
+	if t.JWT.ClaimsNamespace != "" {
+		jwtConfig.ClaimsNamespace = t.JWT.ClaimsNamespace
+	}
+	if t.JWT.Aud != "" {
+		jwtConfig.Aud = t.JWT.Aud
+	}
+	apiConfig.JWT = &jwtConfig
+
+	// the process-wide API key would grant admin access to every tenant
+	adminConfig := AdminConfig{}
+	if config.API.Admin != nil {
+		adminConfig.Role = config.API.Admin.Role
+	}
+	if t.Admin != nil {
+		if t.Admin.Role != "" {
+			adminConfig.Role = t.Admin.Role
+		}
+		adminConfig.APIKey = t.Admin.APIKey
+	}
+	apiConfig.Admin = &adminConfig
+
+	// tenants served by path prefix share the host, and with it the cookies
+	cookieConfig := *config.API.Cookie
+	cookieConfig.Key = cookieConfig.Key + "." + t.ID
+	apiConfig.Cookie = &cookieConfig
+
+	if t.Mailer != nil {
+		mailerConfig := *t.Mailer
+		if err := mergo.Merge(&mailerConfig, *config.API.Mailer); err != nil {
+			return nil, fmt.Errorf("tenant %s mailer: %w", t.ID, err)
+		}
+		apiConfig.Mailer = &mailerConfig
+	}
+
+	c.API = &apiConfig
+
+	if t.SMTP != nil {
+		smtpConfig := t.SMTP.SMTPConfig
+		smtpConfig.Pass = t.SMTP.Pass
+		if err := mergo.Merge(&smtpConfig, *config.SMTP); err != nil {
+			return nil, fmt.Errorf("tenant %s smtp: %w", t.ID, err)
+		}
+		c.SMTP = &smtpConfig
+	}
+
+	databaseConfig := *config.Database
+	if config.Database.JSONMutexDB != nil {
+		jsonMutexDBConfig := *config.Database.JSONMutexDB
+		jsonMutexDBConfig.FilenamePrefix += t.storagePrefix()
+		databaseConfig.JSONMutexDB = &jsonMutexDBConfig
+	}
+	if config.Database.LevelDB != nil {
+		levelDBConfig := *config.Database.LevelDB
+		levelDBConfig.KeyPrefix += t.storagePrefix()
+		databaseConfig.LevelDB = &levelDBConfig
+	}
+	if config.Database.SQLite != nil {
+		sqliteConfig := *config.Database.SQLite
+		sqliteConfig.TablePrefix += t.storagePrefix()
+		databaseConfig.SQLite = &sqliteConfig
+	}
+	if config.Database.BBolt != nil {
+		bboltConfig := *config.Database.BBolt
+		bboltConfig.BucketPrefix += t.storagePrefix()
+		databaseConfig.BBolt = &bboltConfig
+	}
+	c.Database = &databaseConfig
+
+	return &c, nil
+}
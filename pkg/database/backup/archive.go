@@ -0,0 +1,264 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Names of the archive entries besides the data.
+const (
+	manifestName  = "manifest.json"
+	checksumsName = "SHA256SUMS"
+)
+
+// gzipMagic are the first bytes of gzip compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// writer writes the archive, a tar file with the manifest first, the data
+// files, and the checksums of all files last.
+type writer struct {
+	gz        *gzip.Writer
+	tw        *tar.Writer
+	modTime   time.Time
+	checksums map[string]string
+}
+
+func newWriter(w io.Writer, m *Manifest, compress bool) (*writer, error) {
+	aw := &writer{
+		modTime:   m.CreatedAt,
+		checksums: make(map[string]string),
+	}
+
+	if compress {
+		aw.gz = gzip.NewWriter(w)
+		w = aw.gz
+	}
+
+	aw.tw = tar.NewWriter(w)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := aw.WriteFile(manifestName, data); err != nil {
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+// WriteFile writes the file to the archive.
+func (w *writer) WriteFile(name string, data []byte) error {
+	if _, ok := w.checksums[name]; ok {
+		return fmt.Errorf("duplicate file: %s", name)
+	}
+
+	if err := w.writeEntry(name, data); err != nil {
+		return err
+	}
+
+	w.checksums[name] = checksum(data)
+
+	return nil
+}
+
+// Close writes the checksums, and completes the archive.
+func (w *writer) Close() error {
+	if err := w.writeEntry(checksumsName, formatChecksums(w.checksums)); err != nil {
+		return err
+	}
+
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+
+	return nil
+}
+
+func (w *writer) writeEntry(name string, data []byte) error {
+	err := w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0o644,
+		ModTime:  w.modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.tw.Write(data)
+
+	return err
+}
+
+// reader reads the archive, verifying the checksums. The data files are only
+// verified when the end of the archive is reached.
+type reader struct {
+	tr        *tar.Reader
+	manifest  *Manifest
+	checksums map[string]string
+}
+
+func newReader(r io.Reader) (*reader, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	r = br
+
+	if bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		r = gz
+	}
+
+	ar := &reader{
+		tr:        tar.NewReader(r),
+		checksums: make(map[string]string),
+	}
+
+	name, data, err := ar.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if name != manifestName {
+		return nil, fmt.Errorf("%w: missing manifest", ErrInvalidArchive)
+	}
+
+	ar.manifest = &Manifest{}
+	if err := json.Unmarshal(data, ar.manifest); err != nil {
+		return nil, fmt.Errorf("%w: manifest: %v", ErrInvalidArchive, err)
+	}
+
+	return ar, nil
+}
+
+// Next returns the next data file. It returns io.EOF at the end of the
+// archive, once all checksums are verified.
+func (r *reader) Next() (string, []byte, error) {
+	name, data, err := r.next()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if name != checksumsName {
+		return name, data, nil
+	}
+
+	if err := r.verify(data); err != nil {
+		return "", nil, err
+	}
+
+	if _, err := r.tr.Next(); !errors.Is(err, io.EOF) {
+		return "", nil, fmt.Errorf("%w: data after checksums", ErrInvalidArchive)
+	}
+
+	return "", nil, io.EOF
+}
+
+func (r *reader) next() (string, []byte, error) {
+	hdr, err := r.tr.Next()
+	if errors.Is(err, io.EOF) {
+		return "", nil, fmt.Errorf("%w: missing checksums", ErrInvalidArchive)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	if hdr.Typeflag != tar.TypeReg {
+		return "", nil, fmt.Errorf("%w: unexpected entry %s", ErrInvalidArchive, hdr.Name)
+	}
+
+	data, err := io.ReadAll(r.tr)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	if hdr.Name != checksumsName {
+		if _, ok := r.checksums[hdr.Name]; ok {
+			return "", nil, fmt.Errorf("%w: duplicate file %s", ErrInvalidArchive, hdr.Name)
+		}
+
+		r.checksums[hdr.Name] = checksum(data)
+	}
+
+	return hdr.Name, data, nil
+}
+
+func (r *reader) verify(data []byte) error {
+	expected, err := parseChecksums(data)
+	if err != nil {
+		return err
+	}
+
+	if len(expected) != len(r.checksums) {
+		return fmt.Errorf("%w: %d files, %d checksums", ErrChecksumMismatch, len(r.checksums), len(expected))
+	}
+
+	for name, sum := range expected {
+		if r.checksums[name] != sum {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, name)
+		}
+	}
+
+	return nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// formatChecksums formats the checksums as the sha256sum tool does.
+func formatChecksums(checksums map[string]string) []byte {
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", checksums[name], name)
+	}
+
+	return b.Bytes()
+}
+
+func parseChecksums(data []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%w: malformed checksums", ErrInvalidArchive)
+		}
+
+		checksums[parts[1]] = parts[0]
+	}
+
+	return checksums, nil
+}
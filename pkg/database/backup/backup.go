@@ -0,0 +1,279 @@
+// Package backup writes consistent snapshots of the database into portable
+// archives, and restores them.
+//
+// An archive is a tar file, optionally gzip compressed, with the manifest
+// first, the data files of the database, and the SHA-256 checksums of all
+// files last. Archives are restored into a database of the same type and
+// storage prefix.
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+)
+
+// FormatVersion is the version of the archive format.
+const FormatVersion = 1
+
+var (
+	// ErrInvalidArchive is returned for archives which can not be read.
+	ErrInvalidArchive = errors.New("invalid archive")
+
+	// ErrChecksumMismatch is returned when the data does not match the
+	// checksums.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrIncompatible is returned for archives which can not be restored into
+	// the database.
+	ErrIncompatible = errors.New("incompatible archive")
+
+	// ErrNotEmpty is returned when restoring into a database which already has
+	// data.
+	ErrNotEmpty = errors.New("database is not empty")
+)
+
+// Manifest describes the archive.
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	SchemaVersion int       `json:"schema_version"`
+	DatabaseType  string    `json:"database_type"`
+	Prefix        string    `json:"prefix,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Database is a database which can be backed up and restored.
+type Database interface {
+	// Type returns the type of the database.
+	Type() string
+
+	// Prefix returns the storage prefix of the data, see the database config.
+	Prefix() string
+
+	// SchemaVersion returns the schema version recorded in the database.
+	SchemaVersion(ctx context.Context) (int, error)
+
+	// Snapshot passes the data files of a consistent snapshot to the function,
+	// in the same order for the same data.
+	Snapshot(ctx context.Context, fn func(name string, data []byte) error) error
+
+	// Restore writes the data file of a snapshot.
+	Restore(ctx context.Context, name string, data []byte) error
+
+	// IsEmpty returns whether there is no data under the prefix.
+	IsEmpty(ctx context.Context) (bool, error)
+
+	// Clear deletes all data under the prefix.
+	Clear(ctx context.Context) error
+}
+
+// Options are the options of the backup.
+type Options struct {
+	// Compress compresses the archive with gzip.
+	Compress bool
+}
+
+// Backup writes the archive of a consistent snapshot of the database, stamped
+// with the schema version recorded in the database.
+func Backup(ctx context.Context, db Database, w io.Writer, opts Options) (*Manifest, error) {
+	schemaVersion, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("schema version: %w", err)
+	}
+
+	m := &Manifest{
+		FormatVersion: FormatVersion,
+		SchemaVersion: schemaVersion,
+		DatabaseType:  db.Type(),
+		Prefix:        db.Prefix(),
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+
+	aw, err := newWriter(w, m, opts.Compress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Snapshot(ctx, aw.WriteFile); err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// recordedSchema reads the schema version recorded in the database.
+type recordedSchema struct {
+	store schemamigrate.Store
+}
+
+func (s recordedSchema) SchemaVersion(ctx context.Context) (int, error) {
+	version, _, err := s.store.Version(ctx)
+	return version, err
+}
+
+// Verify reads the whole archive, and verifies the checksums.
+func Verify(r io.Reader) (*Manifest, error) {
+	ar, err := newReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		_, _, err := ar.Next()
+		if errors.Is(err, io.EOF) {
+			return ar.manifest, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// CheckCompatible returns an error unless the archive can be restored into
+// the database.
+func (m *Manifest) CheckCompatible(db Database) error {
+	switch {
+	case m.FormatVersion != FormatVersion:
+		return fmt.Errorf("%w: format version %d, supported %d", ErrIncompatible, m.FormatVersion, FormatVersion)
+	case m.SchemaVersion != database.SchemaVersion:
+		return fmt.Errorf("%w: schema version %d, supported %d", ErrIncompatible, m.SchemaVersion, database.SchemaVersion)
+	case m.DatabaseType != db.Type():
+		return fmt.Errorf("%w: database type %s, expected %s", ErrIncompatible, m.DatabaseType, db.Type())
+	case m.Prefix != db.Prefix():
+		return fmt.Errorf("%w: prefix %q, expected %q", ErrIncompatible, m.Prefix, db.Prefix())
+	}
+
+	return nil
+}
+
+// RestoreOptions are the options of the restore.
+type RestoreOptions struct {
+	// Force replaces the data in the database, instead of refusing to
+	// restore into a database which is not empty.
+	Force bool
+}
+
+// Restore verifies the archive, restores it into the database, and verifies
+// the restored data against the archive. The archive is read twice, open
+// returns it from the start.
+func Restore(ctx context.Context, db Database, open func() (io.ReadCloser, error), opts RestoreOptions) (*Manifest, error) {
+	m, err := verifyFile(open)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.CheckCompatible(db); err != nil {
+		return nil, err
+	}
+
+	if opts.Force {
+		if err := db.Clear(ctx); err != nil {
+			return nil, fmt.Errorf("clear: %w", err)
+		}
+	} else {
+		empty, err := db.IsEmpty(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !empty {
+			return nil, ErrNotEmpty
+		}
+	}
+
+	checksums, err := restoreFile(ctx, db, open)
+	if err != nil {
+		return nil, err
+	}
+
+	// the snapshot of the restored data is the same as the one archived
+	restored := make(map[string]string, len(checksums))
+
+	err = db.Snapshot(ctx, func(name string, data []byte) error {
+		restored[name] = checksum(data)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+
+	if len(restored) != len(checksums) {
+		return nil, fmt.Errorf("verify restored: %w: %d files, %d restored", ErrChecksumMismatch, len(checksums), len(restored))
+	}
+
+	for name, sum := range checksums {
+		if restored[name] != sum {
+			return nil, fmt.Errorf("verify restored: %w: %s", ErrChecksumMismatch, name)
+		}
+	}
+
+	return m, nil
+}
+
+func verifyFile(open func() (io.ReadCloser, error)) (*Manifest, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Verify(f)
+}
+
+// restoreFile restores the data files, and returns their checksums.
+func restoreFile(ctx context.Context, db Database, open func() (io.ReadCloser, error)) (map[string]string, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ar, err := newReader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[string]string)
+
+	for {
+		name, data, err := ar.Next()
+		if errors.Is(err, io.EOF) {
+			return checksums, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := db.Restore(ctx, name, data); err != nil {
+			return nil, fmt.Errorf("restore %s: %w", name, err)
+		}
+
+		checksums[name] = checksum(data)
+	}
+}
+
+// hasPrefix returns whether the name has the prefix, and none of the excluded
+// prefixes, of other databases sharing the storage.
+func hasPrefix(name, prefix string, exclude []string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+
+	for _, e := range exclude {
+		if strings.HasPrefix(name, e) {
+			return false
+		}
+	}
+
+	return true
+}
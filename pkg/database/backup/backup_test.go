@@ -0,0 +1,333 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	user_bbolt "github.com/zbiljic/authzy/pkg/domain/user/storage/bbolt"
+	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	user_leveldb "github.com/zbiljic/authzy/pkg/domain/user/storage/leveldb"
+	user_sqlite "github.com/zbiljic/authzy/pkg/domain/user/storage/sqlite"
+)
+
+// Prefixes of a tenant.
+const (
+	testFilenamePrefix = "acme."
+	testKeyPrefix      = "authzy/acme."
+	testTablePrefix    = "acme_"
+	testBucketPrefix   = "acme."
+)
+
+type fixture struct {
+	db Database
+
+	// open opens the repository, which reads the data restored while closed
+	open func() (user.UserRepository, error)
+}
+
+func (f *fixture) users(t *testing.T) user.UserRepository {
+	t.Helper()
+
+	users, err := f.open()
+	require.NoError(t, err)
+
+	return users
+}
+
+func newJSONMutexDB(t *testing.T) *fixture {
+	t.Helper()
+
+	ls, err := database_jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	return &fixture{
+		db: NewJSONMutexDB(ls, testFilenamePrefix),
+		open: func() (user.UserRepository, error) {
+			return user_jsonmutexdb.NewUserRepository(ls, testFilenamePrefix)
+		},
+	}
+}
+
+func newLevelDB(t *testing.T) *fixture {
+	t.Helper()
+
+	db, cleanup := database_leveldb.Fixture()
+	t.Cleanup(func() {
+		db.Close()
+		cleanup()
+	})
+
+	return &fixture{
+		db: NewLevelDB(db, testKeyPrefix),
+		open: func() (user.UserRepository, error) {
+			return user_leveldb.NewUserRepository(db, testKeyPrefix)
+		},
+	}
+}
+
+func newSQLite(t *testing.T) *fixture {
+	t.Helper()
+
+	db, cleanup := database_sqlite.Fixture()
+	t.Cleanup(func() {
+		db.Close()
+		cleanup()
+	})
+
+	return &fixture{
+		db: NewSQLite(db, testTablePrefix),
+		open: func() (user.UserRepository, error) {
+			return user_sqlite.NewUserRepository(db, testTablePrefix)
+		},
+	}
+}
+
+func newBBolt(t *testing.T) *fixture {
+	t.Helper()
+
+	db, cleanup := database_bbolt.Fixture()
+	t.Cleanup(cleanup)
+
+	return &fixture{
+		db: NewBBolt(db, testBucketPrefix),
+		open: func() (user.UserRepository, error) {
+			return user_bbolt.NewUserRepository(db, testBucketPrefix)
+		},
+	}
+}
+
+func seed(t *testing.T, f *fixture, n int) {
+	t.Helper()
+
+	var entities []*user.User
+
+	for i := 0; i < n; i++ {
+		entities = append(entities, &user.User{
+			ID:                 fmt.Sprintf("user%d", i),
+			Email:              fmt.Sprintf("user%d@example.com", i),
+			Username:           fmt.Sprintf("user%d", i),
+			NormalizedUsername: fmt.Sprintf("user%d", i),
+			PasswordHash:       "hash",
+		})
+	}
+
+	// the users replace the ones already stored
+	_, err := f.users(t).SaveAll(context.Background(), entities)
+	require.NoError(t, err)
+}
+
+func opener(data []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	backends := map[string]func(*testing.T) *fixture{
+		database_jsonmutexdb.Type: newJSONMutexDB,
+		database_leveldb.Type:     newLevelDB,
+		database_sqlite.Type:      newSQLite,
+		database_bbolt.Type:       newBBolt,
+	}
+
+	for name, newFixture := range backends {
+		for _, compress := range []bool{false, true} {
+			newFixture := newFixture
+
+			t.Run(fmt.Sprintf("%s/compress=%t", name, compress), func(t *testing.T) {
+				ctx := context.Background()
+
+				src := newFixture(t)
+				seed(t, src, 5)
+
+				var buf bytes.Buffer
+
+				m, err := Backup(ctx, src.db, &buf, Options{Compress: compress})
+				require.NoError(t, err)
+				assert.Equal(t, name, m.DatabaseType)
+				assert.Equal(t, src.db.Prefix(), m.Prefix)
+
+				verified, err := Verify(bytes.NewReader(buf.Bytes()))
+				require.NoError(t, err)
+				assert.Equal(t, m, verified)
+
+				dst := newFixture(t)
+
+				_, err = Restore(ctx, dst.db, opener(buf.Bytes()), RestoreOptions{})
+				require.NoError(t, err)
+
+				// the indexes are restored with the data
+				users := dst.users(t)
+
+				u, err := users.FindByIdentifier(ctx, "user3@example.com")
+				require.NoError(t, err)
+				assert.Equal(t, "user3", u.ID)
+
+				count, err := users.Count(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, 5, count)
+
+				// a database with data is not replaced, unless forced
+				_, err = Restore(ctx, dst.db, opener(buf.Bytes()), RestoreOptions{})
+				assert.True(t, errors.Is(err, ErrNotEmpty))
+
+				seed(t, dst, 8)
+
+				_, err = Restore(ctx, dst.db, opener(buf.Bytes()), RestoreOptions{Force: true})
+				require.NoError(t, err)
+
+				count, err = dst.users(t).Count(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, 5, count)
+			})
+		}
+	}
+}
+
+func TestBackupExcludedTenant(t *testing.T) {
+	// the default tenant has no prefix, and excludes the tenant sharing the
+	// database
+	backends := map[string]func(*testing.T) (Database, *fixture){
+		database_jsonmutexdb.Type: func(t *testing.T) (Database, *fixture) {
+			f := newJSONMutexDB(t)
+			return NewJSONMutexDB(f.db.(*jsonMutexDB).ls, "", testFilenamePrefix), f
+		},
+		database_leveldb.Type: func(t *testing.T) (Database, *fixture) {
+			f := newLevelDB(t)
+			return NewLevelDB(f.db.(*levelDB).db, "authzy/", testKeyPrefix), f
+		},
+		database_bbolt.Type: func(t *testing.T) (Database, *fixture) {
+			f := newBBolt(t)
+			return NewBBolt(f.db.(*bboltDB).db, "", testBucketPrefix), f
+		},
+	}
+
+	for name, newFixtures := range backends {
+		newFixtures := newFixtures
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			db, tenant := newFixtures(t)
+			seed(t, tenant, 3)
+
+			empty, err := db.IsEmpty(ctx)
+			require.NoError(t, err)
+			assert.True(t, empty)
+
+			err = db.Snapshot(ctx, func(name string, data []byte) error {
+				return fmt.Errorf("tenant file in the snapshot: %s", name)
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, db.Clear(ctx))
+
+			count, err := tenant.users(t).Count(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 3, count)
+		})
+	}
+}
+
+func TestBackupSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+
+	ls, err := database_jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	db := NewJSONMutexDB(ls, testFilenamePrefix)
+
+	var buf bytes.Buffer
+
+	m, err := Backup(ctx, db, &buf, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, schemamigrate.InitialVersion, m.SchemaVersion)
+
+	// the archive has the version of the data, not of the binary
+	err = schemamigrate.NewJSONMutexDBStore(ls, testFilenamePrefix).SetVersion(ctx, 2)
+	require.NoError(t, err)
+
+	buf.Reset()
+
+	m, err = Backup(ctx, db, &buf, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.SchemaVersion)
+
+	_, err = Restore(ctx, newJSONMutexDB(t).db, opener(buf.Bytes()), RestoreOptions{})
+	assert.True(t, errors.Is(err, ErrIncompatible), err)
+}
+
+func TestVerifyCorrupted(t *testing.T) {
+	src := newJSONMutexDB(t)
+	seed(t, src, 3)
+
+	var buf bytes.Buffer
+
+	_, err := Backup(context.Background(), src.db, &buf, Options{})
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+
+	corrupted := append([]byte(nil), data...)
+	i := bytes.Index(corrupted, []byte("user1@example.com"))
+	require.True(t, i > 0)
+	corrupted[i] = 'U'
+
+	_, err = Verify(bytes.NewReader(corrupted))
+	assert.True(t, errors.Is(err, ErrChecksumMismatch), err)
+
+	_, err = Verify(bytes.NewReader(data[:len(data)/2]))
+	assert.True(t, errors.Is(err, ErrInvalidArchive), err)
+
+	_, err = Verify(bytes.NewReader([]byte("not an archive")))
+	assert.True(t, errors.Is(err, ErrInvalidArchive), err)
+
+	// nothing is restored from a corrupted archive
+	dst := newJSONMutexDB(t)
+
+	_, err = Restore(context.Background(), dst.db, opener(corrupted), RestoreOptions{})
+	assert.True(t, errors.Is(err, ErrChecksumMismatch), err)
+
+	empty, err := dst.db.IsEmpty(context.Background())
+	require.NoError(t, err)
+	assert.True(t, empty)
+}
+
+func TestRestoreIncompatible(t *testing.T) {
+	db := newJSONMutexDB(t).db
+
+	tests := map[string]*Manifest{
+		"format version": {FormatVersion: FormatVersion + 1, SchemaVersion: 1, DatabaseType: db.Type(), Prefix: db.Prefix()},
+		"schema version": {FormatVersion: FormatVersion, SchemaVersion: 2, DatabaseType: db.Type(), Prefix: db.Prefix()},
+		"database type":  {FormatVersion: FormatVersion, SchemaVersion: 1, DatabaseType: database_leveldb.Type, Prefix: db.Prefix()},
+		"prefix":         {FormatVersion: FormatVersion, SchemaVersion: 1, DatabaseType: db.Type(), Prefix: "other/"},
+	}
+
+	for name, m := range tests {
+		m := m
+
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w, err := newWriter(&buf, m, false)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			_, err = Restore(context.Background(), db, opener(buf.Bytes()), RestoreOptions{})
+			assert.True(t, errors.Is(err, ErrIncompatible), err)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+)
+
+const (
+	bboltDir = "bbolt/"
+
+	// bboltChunkSize is the size from which the records are written into a
+	// new data file.
+	bboltChunkSize = 4 << 20
+)
+
+type bboltDB struct {
+	recordedSchema
+
+	db      *database_bbolt.DB
+	prefix  string
+	exclude []string
+}
+
+// NewBBolt returns the bbolt database with the buckets having the prefix,
+// without the buckets having one of the excluded prefixes, of other databases
+// sharing it.
+func NewBBolt(db *database_bbolt.DB, prefix string, exclude ...string) Database {
+	return &bboltDB{
+		db:             db,
+		prefix:         prefix,
+		exclude:        exclude,
+		recordedSchema: recordedSchema{schemamigrate.NewBBoltStore(db, prefix, schemamigrate.DefaultBatchSize)},
+	}
+}
+
+func (d *bboltDB) Type() string {
+	return database_bbolt.Type
+}
+
+func (d *bboltDB) Prefix() string {
+	return d.prefix
+}
+
+// buckets returns the names of the buckets having the prefix, in order.
+func (d *bboltDB) buckets(tx *bolt.Tx) [][]byte {
+	var names [][]byte
+
+	_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if hasPrefix(string(name), d.prefix, d.exclude) {
+			names = append(names, append([]byte(nil), name...))
+		}
+
+		return nil
+	})
+
+	return names
+}
+
+// Snapshot iterates over the buckets in a read-only transaction, writing the
+// keys of every bucket in chunks of length prefixed keys and values.
+func (d *bboltDB) Snapshot(ctx context.Context, fn func(name string, data []byte) error) error {
+	return d.db.DB.View(func(tx *bolt.Tx) error {
+		for _, bucket := range d.buckets(tx) {
+			dir := bboltDir + strings.TrimPrefix(string(bucket), d.prefix) + "/"
+
+			var (
+				chunk bytes.Buffer
+				n     int
+			)
+
+			flush := func() error {
+				n++
+				name := fmt.Sprintf("%s%06d", dir, n)
+				data := append([]byte(nil), chunk.Bytes()...)
+				chunk.Reset()
+				return fn(name, data)
+			}
+
+			c := tx.Bucket(bucket).Cursor()
+
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				writeRecord(&chunk, k)
+				writeRecord(&chunk, v)
+
+				if chunk.Len() >= bboltChunkSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+
+			if chunk.Len() > 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *bboltDB) Restore(ctx context.Context, name string, data []byte) error {
+	dir := path.Dir(name)
+	if !strings.HasPrefix(name, bboltDir) || dir+"/" == bboltDir {
+		return fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, name)
+	}
+
+	bucket := []byte(d.prefix + strings.TrimPrefix(dir, bboltDir))
+	if !hasPrefix(string(bucket), d.prefix, d.exclude) {
+		return fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, name)
+	}
+
+	return d.db.DB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return err
+		}
+
+		for len(data) > 0 {
+			key, rest, err := readRecord(data)
+			if err != nil {
+				return err
+			}
+
+			value, rest, err := readRecord(rest)
+			if err != nil {
+				return err
+			}
+
+			err = b.Put(key, value)
+			if err != nil {
+				return err
+			}
+
+			data = rest
+		}
+
+		return nil
+	})
+}
+
+func (d *bboltDB) IsEmpty(ctx context.Context) (bool, error) {
+	empty := true
+
+	err := d.db.DB.View(func(tx *bolt.Tx) error {
+		for _, bucket := range d.buckets(tx) {
+			if k, _ := tx.Bucket(bucket).Cursor().First(); k != nil {
+				empty = false
+				return nil
+			}
+		}
+
+		return nil
+	})
+
+	return empty, err
+}
+
+func (d *bboltDB) Clear(ctx context.Context) error {
+	return d.db.DB.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range d.buckets(tx) {
+			if err := database_bbolt.ClearBucket(tx, bucket); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+)
+
+const jsonMutexDBDir = "jsonmutexdb/"
+
+type jsonMutexDB struct {
+	recordedSchema
+
+	ls      database_jsonmutexdb.LoadSaver
+	prefix  string
+	exclude []string
+}
+
+// NewJSONMutexDB returns the JSONMutexDB database with the files having the
+// prefix, without the files having one of the excluded prefixes, of other
+// databases sharing it.
+func NewJSONMutexDB(ls database_jsonmutexdb.LoadSaver, prefix string, exclude ...string) Database {
+	return &jsonMutexDB{
+		ls:             ls,
+		prefix:         prefix,
+		exclude:        exclude,
+		recordedSchema: recordedSchema{schemamigrate.NewJSONMutexDBStore(ls, prefix)},
+	}
+}
+
+func (d *jsonMutexDB) Type() string {
+	return database_jsonmutexdb.Type
+}
+
+func (d *jsonMutexDB) Prefix() string {
+	return d.prefix
+}
+
+// Snapshot reads all files at once, consistent as far as every change is
+// saved into a single file.
+func (d *jsonMutexDB) Snapshot(ctx context.Context, fn func(name string, data []byte) error) error {
+	files, err := d.files()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := fn(jsonMutexDBDir+name, files[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// files returns the files with data, cleared files are left empty.
+func (d *jsonMutexDB) files() (map[string][]byte, error) {
+	files, err := d.ls.Snapshot(d.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, data := range files {
+		if len(data) == 0 || !hasPrefix(name, d.prefix, d.exclude) {
+			delete(files, name)
+		}
+	}
+
+	return files, nil
+}
+
+func (d *jsonMutexDB) Restore(ctx context.Context, name string, data []byte) error {
+	filename := strings.TrimPrefix(name, jsonMutexDBDir)
+
+	if filename == name || strings.Contains(filename, "/") || !hasPrefix(filename, d.prefix, d.exclude) {
+		return fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, name)
+	}
+
+	return d.ls.Save(filename, data)
+}
+
+func (d *jsonMutexDB) IsEmpty(ctx context.Context) (bool, error) {
+	files, err := d.files()
+	if err != nil {
+		return false, err
+	}
+
+	return len(files) == 0, nil
+}
+
+func (d *jsonMutexDB) Clear(ctx context.Context) error {
+	files, err := d.files()
+	if err != nil {
+		return err
+	}
+
+	for name := range files {
+		if err := d.ls.Save(name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
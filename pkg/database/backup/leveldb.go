@@ -0,0 +1,201 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+)
+
+const (
+	levelDBDir = "leveldb/"
+
+	// levelDBChunkSize is the size from which the records are written into a
+	// new data file.
+	levelDBChunkSize = 4 << 20
+
+	levelDBClearBatchSize = 1000
+)
+
+type levelDB struct {
+	recordedSchema
+
+	db      *leveldb.DB
+	prefix  string
+	exclude []string
+}
+
+// NewLevelDB returns the LevelDB database with the keys having the prefix,
+// without the keys having one of the excluded prefixes, of other databases
+// sharing it.
+func NewLevelDB(db *leveldb.DB, prefix string, exclude ...string) Database {
+	return &levelDB{
+		db:             db,
+		prefix:         prefix,
+		exclude:        exclude,
+		recordedSchema: recordedSchema{schemamigrate.NewLevelDBStore(db, prefix, schemamigrate.DefaultBatchSize)},
+	}
+}
+
+func (d *levelDB) Type() string {
+	return database_leveldb.Type
+}
+
+func (d *levelDB) Prefix() string {
+	return d.prefix
+}
+
+// Snapshot iterates over the keys of a LevelDB snapshot, writing them in
+// chunks of length prefixed keys and values.
+func (d *levelDB) Snapshot(ctx context.Context, fn func(name string, data []byte) error) error {
+	snap, err := d.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+
+	iter := snap.NewIterator(util.BytesPrefix([]byte(d.prefix)), nil)
+	defer iter.Release()
+
+	var (
+		chunk bytes.Buffer
+		n     int
+	)
+
+	flush := func() error {
+		n++
+		name := fmt.Sprintf("%s%06d", levelDBDir, n)
+		data := append([]byte(nil), chunk.Bytes()...)
+		chunk.Reset()
+		return fn(name, data)
+	}
+
+	for iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !d.hasKey(iter.Key()) {
+			continue
+		}
+
+		writeRecord(&chunk, iter.Key())
+		writeRecord(&chunk, iter.Value())
+
+		if chunk.Len() >= levelDBChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	if chunk.Len() > 0 {
+		return flush()
+	}
+
+	return nil
+}
+
+func (d *levelDB) Restore(ctx context.Context, name string, data []byte) error {
+	if !strings.HasPrefix(name, levelDBDir) {
+		return fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, name)
+	}
+
+	batch := new(leveldb.Batch)
+
+	for len(data) > 0 {
+		key, rest, err := readRecord(data)
+		if err != nil {
+			return err
+		}
+
+		value, rest, err := readRecord(rest)
+		if err != nil {
+			return err
+		}
+
+		if !d.hasKey(key) {
+			return fmt.Errorf("%w: key outside of the prefix", ErrInvalidArchive)
+		}
+
+		batch.Put(key, value)
+		data = rest
+	}
+
+	return d.db.Write(batch, nil)
+}
+
+func (d *levelDB) IsEmpty(ctx context.Context) (bool, error) {
+	iter := d.db.NewIterator(util.BytesPrefix([]byte(d.prefix)), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if d.hasKey(iter.Key()) {
+			return false, nil
+		}
+	}
+
+	return true, iter.Error()
+}
+
+func (d *levelDB) Clear(ctx context.Context) error {
+	iter := d.db.NewIterator(util.BytesPrefix([]byte(d.prefix)), nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+
+	for iter.Next() {
+		if !d.hasKey(iter.Key()) {
+			continue
+		}
+
+		batch.Delete(append([]byte(nil), iter.Key()...))
+
+		if batch.Len() >= levelDBClearBatchSize {
+			if err := d.db.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	return d.db.Write(batch, nil)
+}
+
+// hasKey returns whether the key is of the database.
+func (d *levelDB) hasKey(key []byte) bool {
+	return hasPrefix(string(key), d.prefix, d.exclude)
+}
+
+func writeRecord(b *bytes.Buffer, data []byte) {
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(data)))
+	b.Write(size[:n])
+	b.Write(data)
+}
+
+func readRecord(data []byte) (record, rest []byte, err error) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < size {
+		return nil, nil, fmt.Errorf("%w: truncated record", ErrInvalidArchive)
+	}
+
+	end := n + int(size)
+
+	return data[n:end], data[end:], nil
+}
@@ -0,0 +1,248 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+)
+
+const (
+	sqliteDir = "sqlite/"
+
+	// sqliteChunkSize is the size from which the rows are written into a new
+	// data file.
+	sqliteChunkSize = 4 << 20
+)
+
+type sqliteDB struct {
+	recordedSchema
+
+	db     *database_sqlite.DB
+	prefix string
+}
+
+// NewSQLite returns the SQLite database with the tables having the prefix.
+func NewSQLite(db *database_sqlite.DB, prefix string) Database {
+	return &sqliteDB{
+		db:             db,
+		prefix:         prefix,
+		recordedSchema: recordedSchema{schemamigrate.NewSQLiteStore(db, prefix, schemamigrate.DefaultBatchSize)},
+	}
+}
+
+func (d *sqliteDB) Type() string {
+	return database_sqlite.Type
+}
+
+func (d *sqliteDB) Prefix() string {
+	return d.prefix
+}
+
+// tables returns the tables of the prefix, which are migrated first. The
+// applied migrations are not part of the data.
+func (d *sqliteDB) tables() ([]string, error) {
+	err := d.db.Migrate(d.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return database_sqlite.Tables()
+}
+
+// Snapshot reads the rows of every table in a transaction, writing them in
+// chunks of JSON arrays of the column values, in the order of insertion.
+func (d *sqliteDB) Snapshot(ctx context.Context, fn func(name string, data []byte) error) error {
+	tables, err := d.tables()
+	if err != nil {
+		return err
+	}
+
+	return d.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range tables {
+			if err := d.snapshotTable(ctx, tx, table, fn); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *sqliteDB) snapshotTable(ctx context.Context, tx *sql.Tx, table string, fn func(name string, data []byte) error) error {
+	rows, err := tx.QueryContext(ctx, `SELECT * FROM `+database_sqlite.Table(d.prefix, table)+` ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var (
+		chunk bytes.Buffer
+		n     int
+	)
+
+	flush := func() error {
+		n++
+		name := fmt.Sprintf("%s%s/%06d", sqliteDir, table, n)
+		data := append([]byte(nil), chunk.Bytes()...)
+		chunk.Reset()
+		return fn(name, data)
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	enc := json.NewEncoder(&chunk)
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		for i, v := range values {
+			// text is scanned as bytes by the driver
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+
+		if err := enc.Encode(values); err != nil {
+			return err
+		}
+
+		if chunk.Len() >= sqliteChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if chunk.Len() > 0 {
+		return flush()
+	}
+
+	return nil
+}
+
+func (d *sqliteDB) Restore(ctx context.Context, name string, data []byte) error {
+	table, err := d.restoreTable(name)
+	if err != nil {
+		return err
+	}
+
+	return d.db.WithTx(ctx, func(tx *sql.Tx) error {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		// integers are restored exactly
+		dec.UseNumber()
+
+		for dec.More() {
+			var values []interface{}
+
+			if err := dec.Decode(&values); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+			}
+
+			for i, v := range values {
+				if n, ok := v.(json.Number); ok {
+					i64, err := n.Int64()
+					if err != nil {
+						return fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+					}
+					values[i] = i64
+				}
+			}
+
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO `+database_sqlite.Table(d.prefix, table)+` VALUES (`+placeholders+`)`,
+				values...,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// restoreTable returns the table of the data file.
+func (d *sqliteDB) restoreTable(name string) (string, error) {
+	tables, err := d.tables()
+	if err != nil {
+		return "", err
+	}
+
+	for _, table := range tables {
+		if strings.HasPrefix(name, sqliteDir+table+"/") {
+			return table, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, name)
+}
+
+func (d *sqliteDB) IsEmpty(ctx context.Context) (bool, error) {
+	tables, err := d.tables()
+	if err != nil {
+		return false, err
+	}
+
+	for _, table := range tables {
+		var has bool
+
+		err := d.db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM `+database_sqlite.Table(d.prefix, table)+`)`,
+		).Scan(&has)
+		if err != nil {
+			return false, err
+		}
+
+		if has {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (d *sqliteDB) Clear(ctx context.Context) error {
+	tables, err := d.tables()
+	if err != nil {
+		return err
+	}
+
+	return d.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range tables {
+			_, err := tx.ExecContext(ctx, `DELETE FROM `+database_sqlite.Table(d.prefix, table))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
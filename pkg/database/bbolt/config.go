@@ -0,0 +1,9 @@
+package bbolt
+
+const Type = "bbolt"
+
+// Config defines database configuration.
+type Config struct {
+	DataDir      string `json:"data_dir" split_words:"true"`
+	BucketPrefix string `json:"bucket_prefix" split_words:"true"`
+}
@@ -0,0 +1,122 @@
+package bbolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+)
+
+// Filename is the name of the database file in the data directory.
+const Filename = "authzy.bolt"
+
+// ErrReadOnlyTx is returned when writing in the read-only transaction
+// carried in the context.
+var ErrReadOnlyTx = errors.New("read-only transaction")
+
+// DB is a bbolt database, having the buckets of every bucket prefix in use.
+type DB struct {
+	*bolt.DB
+}
+
+// New creates a new local bbolt database.
+func New(config Config) (*DB, error) {
+	err := os.MkdirAll(config.DataDir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("open bbolt: %s", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(config.DataDir, Filename), 0o600, &bolt.Options{
+		// the file is locked by a single process
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open bbolt: %s", err)
+	}
+
+	return &DB{DB: db}, nil
+}
+
+// txFromContext returns the transaction of the database carried in the
+// context.
+func (db *DB) txFromContext(ctx context.Context) *bolt.Tx {
+	tx, ok := database.TxFromContext(ctx).(*bolt.Tx)
+	if !ok || tx.DB() != db.DB {
+		return nil
+	}
+
+	return tx
+}
+
+// WithTx runs the function in a read-write transaction carried in the
+// context, see database.Transactor.
+func (db *DB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx := db.txFromContext(ctx); tx != nil {
+		if !tx.Writable() {
+			return ErrReadOnlyTx
+		}
+
+		return fn(ctx)
+	}
+
+	return database.RunInTx(ctx, func() (database.Tx, error) {
+		return db.DB.Begin(true)
+	}, fn)
+}
+
+// View runs the function in the transaction carried in the context, or in a
+// new read-only transaction.
+func (db *DB) View(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if tx := db.txFromContext(ctx); tx != nil {
+		return fn(tx)
+	}
+
+	return db.DB.View(fn)
+}
+
+// Update runs the function in the transaction carried in the context, or in
+// a new read-write transaction. Writes in a transaction carried in the
+// context are committed with it.
+func (db *DB) Update(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if tx := db.txFromContext(ctx); tx != nil {
+		if !tx.Writable() {
+			return ErrReadOnlyTx
+		}
+
+		return fn(tx)
+	}
+
+	return db.DB.Update(fn)
+}
+
+// CreateBuckets creates the buckets which do not exist yet.
+func (db *DB) CreateBuckets(names ...string) error {
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		for _, name := range names {
+			_, err := tx.CreateBucketIfNotExists([]byte(name))
+			if err != nil {
+				return fmt.Errorf("create bucket %q: %w", name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ClearBucket deletes all keys of the bucket.
+func ClearBucket(tx *bolt.Tx, name []byte) error {
+	err := tx.DeleteBucket(name)
+	if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+		return err
+	}
+
+	_, err = tx.CreateBucket(name)
+
+	return err
+}
@@ -8,4 +8,7 @@ var (
 
 	// ErrAlreadyExists is used when an item with same key already exists.
 	ErrAlreadyExists = errors.New("item already exists")
+
+	// ErrConflict is used when an item was changed since it was read.
+	ErrConflict = errors.New("item changed concurrently")
 )
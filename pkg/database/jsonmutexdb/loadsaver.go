@@ -2,6 +2,9 @@ package jsonmutexdb
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/spf13/afero"
@@ -10,6 +13,17 @@ import (
 type LoadSaver interface {
 	Load(filename string) ([]byte, error)
 	Save(filename string, data []byte) error
+
+	// Append appends the data to the file, creating it if it does not exist.
+	Append(filename string, data []byte) error
+
+	// SaveAll saves the files at once, while no other file is loaded or
+	// saved.
+	SaveAll(files map[string][]byte) error
+
+	// Snapshot reads all files having the prefix at once, while no file is
+	// saved. The files are kept in a single directory.
+	Snapshot(prefix string) (map[string][]byte, error)
 }
 
 func NewLoadSaver(dataDir string) (LoadSaver, error) {
@@ -38,6 +52,10 @@ func NewLoadSaver(dataDir string) (LoadSaver, error) {
 type loadSave struct {
 	fs afero.Fs
 	mu sync.Mutex
+
+	// txMu is held by the transaction for its whole duration, and by the
+	// writes of the repositories outside of it.
+	txMu sync.RWMutex
 }
 
 func (ls *loadSave) Load(filename string) ([]byte, error) {
@@ -62,3 +80,67 @@ func (ls *loadSave) Save(filename string, data []byte) error {
 
 	return afero.WriteFile(ls.fs, filename, data, 0644)
 }
+
+func (ls *loadSave) Append(filename string, data []byte) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	f, err := ls.fs.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func (ls *loadSave) SaveAll(files map[string][]byte) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		err := afero.WriteFile(ls.fs, filename, files[filename], 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ls *loadSave) Snapshot(prefix string) (map[string][]byte, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	infos, err := afero.ReadDir(ls.fs, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasPrefix(info.Name(), prefix) {
+			continue
+		}
+
+		data, err := afero.ReadFile(ls.fs, info.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		files[info.Name()] = data
+	}
+
+	return files, nil
+}
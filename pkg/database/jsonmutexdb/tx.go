@@ -0,0 +1,198 @@
+package jsonmutexdb
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/zbiljic/authzy/pkg/database"
+)
+
+// Tx is a transaction of the load saver. The files saved, and the data
+// appended, by the repositories are kept by the transaction, and written at
+// once when it is committed.
+//
+// The repositories keep the entities in memory, so the changes of a
+// transaction are visible outside of it before it is committed. When it is
+// rolled back, the repositories reload the entities from the saved files.
+// The writes outside of the transaction wait for it to end, so they neither
+// save its changes nor are overwritten by them.
+type Tx struct {
+	ls LoadSaver
+
+	mu      sync.Mutex
+	files   map[string][]byte
+	appends map[string][]byte
+	reloads map[string]func() error
+	release func()
+}
+
+func (tx *Tx) save(filename string, data []byte, reload func() error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.files[filename] = data
+	// the saved file replaces the data appended before
+	delete(tx.appends, filename)
+	tx.reloads[filename] = reload
+}
+
+func (tx *Tx) append(filename string, data []byte, reload func() error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.appends[filename] = append(tx.appends[filename], data...)
+	tx.reloads[filename] = reload
+}
+
+// Commit saves the files kept by the transaction, then appends the data to
+// the files. If they fail to be written, the entities of the repositories are
+// reloaded as when the transaction is rolled back, since it is not rolled back
+// after a failed commit.
+func (tx *Tx) Commit() error {
+	defer tx.release()
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if err := tx.write(); err != nil {
+		_ = tx.reload()
+		return err
+	}
+
+	return nil
+}
+
+func (tx *Tx) write() error {
+	if len(tx.files) > 0 {
+		if err := tx.ls.SaveAll(tx.files); err != nil {
+			return err
+		}
+	}
+
+	filenames := make([]string, 0, len(tx.appends))
+	for filename := range tx.appends {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		if err := tx.ls.Append(filename, tx.appends[filename]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rollback discards the files kept by the transaction, and reloads the
+// entities of the repositories which saved them.
+func (tx *Tx) Rollback() error {
+	defer tx.release()
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	return tx.reload()
+}
+
+// reload reloads the entities of the repositories which saved the files.
+func (tx *Tx) reload() error {
+	filenames := make([]string, 0, len(tx.reloads))
+	for filename := range tx.reloads {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var err error
+
+	for _, filename := range filenames {
+		if rerr := tx.reloads[filename](); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+
+	return err
+}
+
+// Save saves the file with the load saver, or keeps it in the transaction of
+// the load saver carried in the context. The reload function replaces the
+// entities kept in memory with the saved ones, if the transaction is rolled
+// back.
+func Save(ctx context.Context, ls LoadSaver, filename string, data []byte, reload func() error) error {
+	if tx, ok := database.TxFromContext(ctx).(*Tx); ok && tx.ls == ls {
+		tx.save(filename, data, reload)
+		return nil
+	}
+
+	return ls.Save(filename, data)
+}
+
+// Append appends the data to the file with the load saver, or keeps it in
+// the transaction of the load saver carried in the context. The reload
+// function is the one of Save.
+func Append(ctx context.Context, ls LoadSaver, filename string, data []byte, reload func() error) error {
+	if tx, ok := database.TxFromContext(ctx).(*Tx); ok && tx.ls == ls {
+		tx.append(filename, data, reload)
+		return nil
+	}
+
+	return ls.Append(filename, data)
+}
+
+// Lock locks the load saver for a write of a repository, which waits for the
+// open transaction to end, unless the context carries it. The repositories
+// lock it before their own mutex, and unlock it with the returned function.
+func Lock(ctx context.Context, ls LoadSaver) (unlock func()) {
+	l, ok := ls.(*loadSave)
+	if !ok {
+		return func() {}
+	}
+
+	if tx, ok := database.TxFromContext(ctx).(*Tx); ok && tx.ls == ls {
+		return func() {}
+	}
+
+	l.txMu.RLock()
+
+	return l.txMu.RUnlock
+}
+
+type transactor struct {
+	ls LoadSaver
+	mu sync.RWMutex
+}
+
+// NewTransactor returns the transactor of the load saver. The transactions
+// run one at a time, and the writes outside of them wait for them to end.
+func NewTransactor(ls LoadSaver) database.Transactor {
+	return &transactor{ls: ls}
+}
+
+// txMu returns the mutex held by the transactions of the load saver.
+func (t *transactor) txMu() *sync.RWMutex {
+	if l, ok := t.ls.(*loadSave); ok {
+		return &l.txMu
+	}
+
+	return &t.mu
+}
+
+func (t *transactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := database.TxFromContext(ctx).(*Tx); ok && tx.ls == t.ls {
+		return fn(ctx)
+	}
+
+	return database.RunInTx(ctx, func() (database.Tx, error) {
+		mu := t.txMu()
+		mu.Lock()
+
+		return &Tx{
+			ls:      t.ls,
+			files:   make(map[string][]byte),
+			appends: make(map[string][]byte),
+			reloads: make(map[string]func() error),
+			release: mu.Unlock,
+		}, nil
+	}, fn)
+}
@@ -0,0 +1,151 @@
+package jsonmutexdb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/ulid"
+)
+
+func newUser(name string) *user.User {
+	return &user.User{
+		ID:                 ulid.ULID().String(),
+		Email:              name + "@example.com",
+		Username:           name,
+		NormalizedUsername: name,
+	}
+}
+
+// saveOutsideTx saves the user without the transaction, and returns the
+// channel receiving the error once it is saved.
+func saveOutsideTx(repo user.UserRepository, entity *user.User) <-chan error {
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := repo.Save(context.Background(), entity)
+		done <- err
+	}()
+
+	return done
+}
+
+func TestTxConcurrentSave(t *testing.T) {
+	ctx := context.Background()
+
+	ls, err := jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	repo, err := user_jsonmutexdb.NewUserRepository(ls, "")
+	require.NoError(t, err)
+
+	transactor := jsonmutexdb.NewTransactor(ls)
+
+	errRollback := errors.New("rollback")
+
+	inTx, outsideTx := newUser("rolledback"), newUser("outside")
+
+	var done <-chan error
+
+	err = transactor.WithTx(ctx, func(ctx context.Context) error {
+		_, err := repo.Save(ctx, inTx)
+		require.NoError(t, err)
+
+		done = saveOutsideTx(repo, outsideTx)
+
+		select {
+		case err := <-done:
+			t.Fatalf("saved while the transaction is open: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+	require.NoError(t, <-done)
+
+	// the saved file has neither lost the write outside of the transaction,
+	// nor kept the rolled back one
+	saved, err := user_jsonmutexdb.NewUserRepository(ls, "")
+	require.NoError(t, err)
+
+	for _, r := range []user.UserRepository{repo, saved} {
+		_, err = r.FindByID(ctx, inTx.ID)
+		assert.ErrorIs(t, err, database.ErrNotFound)
+
+		_, err = r.FindByID(ctx, outsideTx.ID)
+		assert.NoError(t, err)
+	}
+
+	committed, outsideTx := newUser("committed"), newUser("outside2")
+
+	err = transactor.WithTx(ctx, func(ctx context.Context) error {
+		_, err := repo.Save(ctx, committed)
+		require.NoError(t, err)
+
+		done = saveOutsideTx(repo, outsideTx)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	saved, err = user_jsonmutexdb.NewUserRepository(ls, "")
+	require.NoError(t, err)
+
+	for _, id := range []string{committed.ID, outsideTx.ID} {
+		_, err = saved.FindByID(ctx, id)
+		assert.NoError(t, err)
+	}
+}
+
+// failingLoadSaver fails to save the files of the transactions.
+type failingLoadSaver struct {
+	jsonmutexdb.LoadSaver
+}
+
+var errSaveAll = errors.New("save all")
+
+func (failingLoadSaver) SaveAll(files map[string][]byte) error {
+	return errSaveAll
+}
+
+func TestTxCommitFailed(t *testing.T) {
+	ctx := context.Background()
+
+	mem, err := jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	ls := failingLoadSaver{mem}
+
+	repo, err := user_jsonmutexdb.NewUserRepository(ls, "")
+	require.NoError(t, err)
+
+	saved := newUser("saved")
+
+	_, err = repo.Save(ctx, saved)
+	require.NoError(t, err)
+
+	inTx := newUser("uncommitted")
+
+	err = jsonmutexdb.NewTransactor(ls).WithTx(ctx, func(ctx context.Context) error {
+		_, err := repo.Save(ctx, inTx)
+		return err
+	})
+	require.ErrorIs(t, err, errSaveAll)
+
+	// the repository does not keep the changes which were not saved
+	_, err = repo.FindByID(ctx, inTx.ID)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+
+	_, err = repo.FindByID(ctx, saved.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,340 @@
+package leveldb
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+)
+
+// Querier is the database or a transaction in it.
+type Querier interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	Has(key []byte, ro *opt.ReadOptions) (bool, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+	Put(key, value []byte, wo *opt.WriteOptions) error
+	Delete(key []byte, wo *opt.WriteOptions) error
+	Write(batch *leveldb.Batch, wo *opt.WriteOptions) error
+}
+
+// From returns the transaction of the database carried in the context, or
+// the database.
+func From(ctx context.Context, db *leveldb.DB) Querier {
+	if tx, ok := database.TxFromContext(ctx).(*Tx); ok && tx.db == db {
+		return tx
+	}
+
+	return db
+}
+
+// tags of the writes kept by a transaction
+const (
+	tagDelete byte = iota
+	tagPut
+)
+
+// Tx is a transaction of the database. The writes are collected in a single
+// batch, which is written when the transaction is committed, and are read
+// back by the transaction over the keys of the database.
+type Tx struct {
+	db    *leveldb.DB
+	batch *leveldb.Batch
+	// writes has the tagged values of the keys written by the batch
+	writes  *memdb.DB
+	release func()
+}
+
+func (tx *Tx) Get(key []byte, ro *opt.ReadOptions) ([]byte, error) {
+	value, err := tx.writes.Get(key)
+	if err != nil {
+		return tx.db.Get(key, ro)
+	}
+
+	if value[0] == tagDelete {
+		return nil, leveldb.ErrNotFound
+	}
+
+	return append([]byte(nil), value[1:]...), nil
+}
+
+func (tx *Tx) Has(key []byte, ro *opt.ReadOptions) (bool, error) {
+	value, err := tx.writes.Get(key)
+	if err != nil {
+		return tx.db.Has(key, ro)
+	}
+
+	return value[0] == tagPut, nil
+}
+
+func (tx *Tx) NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator {
+	return &txIterator{
+		db:     tx.db.NewIterator(slice, ro),
+		writes: tx.writes.NewIterator(slice),
+	}
+}
+
+func (tx *Tx) Put(key, value []byte, wo *opt.WriteOptions) error {
+	tx.batch.Put(key, value)
+	return tx.writes.Put(key, append([]byte{tagPut}, value...))
+}
+
+func (tx *Tx) Delete(key []byte, wo *opt.WriteOptions) error {
+	tx.batch.Delete(key)
+	return tx.writes.Put(key, []byte{tagDelete})
+}
+
+func (tx *Tx) Write(batch *leveldb.Batch, wo *opt.WriteOptions) error {
+	return batch.Replay(txReplay{tx})
+}
+
+// Commit writes the batch of the transaction.
+func (tx *Tx) Commit() error {
+	defer tx.release()
+
+	if tx.batch.Len() == 0 {
+		return nil
+	}
+
+	return tx.db.Write(tx.batch, nil)
+}
+
+// Rollback discards the batch of the transaction.
+func (tx *Tx) Rollback() error {
+	tx.release()
+	return nil
+}
+
+// txReplay replays the batches written in the transaction.
+type txReplay struct {
+	tx *Tx
+}
+
+func (r txReplay) Put(key, value []byte) {
+	r.tx.Put(key, value, nil) //nolint:errcheck
+}
+
+func (r txReplay) Delete(key []byte) {
+	r.tx.Delete(key, nil) //nolint:errcheck
+}
+
+// directions of the txIterator
+const (
+	dirNone = iota
+	dirForward
+	dirBackward
+)
+
+// txIterator iterates over the keys of the database merged with the writes
+// of a transaction, which take precedence.
+type txIterator struct {
+	util.BasicReleaser
+
+	db     iterator.Iterator
+	writes iterator.Iterator
+
+	// cur is the iterator at the current key, nil when exhausted
+	cur iterator.Iterator
+	dir int
+}
+
+// settle positions the iterator at the nearest key in the direction, which
+// is not deleted by the transaction.
+func (it *txIterator) settle(dir int) bool {
+	it.dir = dir
+
+	for {
+		if !it.writes.Valid() {
+			it.cur = nil
+			if it.db.Valid() {
+				it.cur = it.db
+			}
+
+			return it.cur != nil
+		}
+
+		// the order of the keys in the direction
+		c := -1
+		if it.db.Valid() {
+			c = bytes.Compare(it.writes.Key(), it.db.Key())
+			if dir == dirBackward {
+				c = -c
+			}
+		}
+
+		if c > 0 {
+			it.cur = it.db
+			return true
+		}
+
+		if it.writes.Value()[0] == tagPut {
+			it.cur = it.writes
+			return true
+		}
+
+		it.step(it.writes)
+		if c == 0 {
+			it.step(it.db)
+		}
+	}
+}
+
+func (it *txIterator) step(i iterator.Iterator) {
+	if it.dir == dirBackward {
+		i.Prev()
+	} else {
+		i.Next()
+	}
+}
+
+// advance moves past the current key in the direction.
+func (it *txIterator) advance() bool {
+	if it.cur == it.writes && it.db.Valid() && bytes.Equal(it.db.Key(), it.writes.Key()) {
+		it.step(it.db)
+	}
+
+	it.step(it.cur)
+
+	return it.settle(it.dir)
+}
+
+func (it *txIterator) First() bool {
+	it.db.First()
+	it.writes.First()
+
+	return it.settle(dirForward)
+}
+
+func (it *txIterator) Last() bool {
+	it.db.Last()
+	it.writes.Last()
+
+	return it.settle(dirBackward)
+}
+
+func (it *txIterator) Seek(key []byte) bool {
+	it.db.Seek(key)
+	it.writes.Seek(key)
+
+	return it.settle(dirForward)
+}
+
+// seekBackward positions the iterator at the last key up to the key.
+func (it *txIterator) seekBackward(key []byte) bool {
+	for _, i := range []iterator.Iterator{it.db, it.writes} {
+		if !i.Seek(key) {
+			i.Last()
+		} else if !bytes.Equal(i.Key(), key) {
+			i.Prev()
+		}
+	}
+
+	return it.settle(dirBackward)
+}
+
+func (it *txIterator) Next() bool {
+	switch {
+	case it.dir == dirNone:
+		return it.First()
+	case it.cur == nil:
+		if it.dir == dirBackward {
+			return it.First()
+		}
+
+		return false
+	case it.dir == dirBackward:
+		it.Seek(append([]byte(nil), it.Key()...))
+	}
+
+	return it.advance()
+}
+
+func (it *txIterator) Prev() bool {
+	switch {
+	case it.dir == dirNone:
+		return it.Last()
+	case it.cur == nil:
+		if it.dir == dirForward {
+			return it.Last()
+		}
+
+		return false
+	case it.dir == dirForward:
+		it.seekBackward(append([]byte(nil), it.Key()...))
+	}
+
+	return it.advance()
+}
+
+func (it *txIterator) Valid() bool {
+	return it.cur != nil
+}
+
+func (it *txIterator) Key() []byte {
+	if it.cur == nil {
+		return nil
+	}
+
+	return it.cur.Key()
+}
+
+func (it *txIterator) Value() []byte {
+	switch it.cur {
+	case nil:
+		return nil
+	case it.writes:
+		return it.writes.Value()[1:]
+	default:
+		return it.db.Value()
+	}
+}
+
+func (it *txIterator) Error() error {
+	if err := it.db.Error(); err != nil {
+		return err
+	}
+
+	return it.writes.Error()
+}
+
+func (it *txIterator) Release() {
+	it.db.Release()
+	it.writes.Release()
+	it.cur = nil
+	it.BasicReleaser.Release()
+}
+
+type transactor struct {
+	db *leveldb.DB
+	mu sync.Mutex
+}
+
+// NewTransactor returns the transactor of the database. The transactions
+// run one at a time, while the writes outside of them are not blocked.
+func NewTransactor(db *leveldb.DB) database.Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := database.TxFromContext(ctx).(*Tx); ok && tx.db == t.db {
+		return fn(ctx)
+	}
+
+	return database.RunInTx(ctx, func() (database.Tx, error) {
+		t.mu.Lock()
+
+		return &Tx{
+			db:      t.db,
+			batch:   new(leveldb.Batch),
+			writes:  memdb.New(comparer.DefaultComparer, 0),
+			release: t.mu.Unlock,
+		}, nil
+	}, fn)
+}
@@ -0,0 +1,102 @@
+package leveldb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+)
+
+func keys(iter iterator.Iterator, next func() bool) []string {
+	var result []string
+	for next() {
+		result = append(result, string(iter.Key())+"="+string(iter.Value()))
+	}
+	return result
+}
+
+func TestTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, cleanup := Fixture()
+	defer cleanup()
+
+	for _, k := range []string{"a", "c", "e", "g"} {
+		require.NoError(t, db.Put([]byte(k), []byte("db"), nil))
+	}
+
+	errRollback := errors.New("rollback")
+
+	err := NewTransactor(db).WithTx(ctx, func(ctx context.Context) error {
+		q := From(ctx, db)
+
+		require.NoError(t, q.Put([]byte("b"), []byte("tx"), nil))
+		require.NoError(t, q.Put([]byte("c"), []byte("tx"), nil))
+		require.NoError(t, q.Delete([]byte("e"), nil))
+
+		batch := new(leveldb.Batch)
+		batch.Put([]byte("h"), []byte("tx"))
+		batch.Delete([]byte("a"))
+		require.NoError(t, q.Write(batch, nil))
+
+		value, err := q.Get([]byte("c"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "tx", string(value))
+
+		_, err = q.Get([]byte("e"), nil)
+		assert.ErrorIs(t, err, leveldb.ErrNotFound)
+
+		has, err := q.Has([]byte("a"), nil)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		expected := []string{"b=tx", "c=tx", "g=db", "h=tx"}
+
+		iter := q.NewIterator(nil, nil)
+		assert.Equal(t, expected, keys(iter, iter.Next))
+		assert.Equal(t, []string{"h=tx", "g=db", "c=tx", "b=tx"}, keys(iter, iter.Prev))
+		iter.Release()
+
+		// changing the direction
+		iter = q.NewIterator(&util.Range{Start: []byte("b"), Limit: []byte("h")}, nil)
+		require.True(t, iter.Seek([]byte("d")))
+		assert.Equal(t, "g", string(iter.Key()))
+		require.True(t, iter.Prev())
+		assert.Equal(t, "c", string(iter.Key()))
+		require.True(t, iter.Next())
+		assert.Equal(t, "g", string(iter.Key()))
+		assert.False(t, iter.Next())
+		require.True(t, iter.Last())
+		assert.Equal(t, "g", string(iter.Key()))
+		require.NoError(t, iter.Error())
+		iter.Release()
+
+		// not written before the commit
+		_, err = db.Get([]byte("b"), nil)
+		assert.ErrorIs(t, err, leveldb.ErrNotFound)
+
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	_, err = db.Get([]byte("b"), nil)
+	assert.ErrorIs(t, err, leveldb.ErrNotFound)
+
+	err = NewTransactor(db).WithTx(ctx, func(ctx context.Context) error {
+		assert.NotNil(t, database.TxFromContext(ctx))
+
+		return From(ctx, db).Put([]byte("b"), []byte("tx"), nil)
+	})
+	require.NoError(t, err)
+
+	value, err := db.Get([]byte("b"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "tx", string(value))
+}
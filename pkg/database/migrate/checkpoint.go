@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is the progress of a migration, from which it is resumed.
+type Checkpoint struct {
+	// Completed are the entities copied in full.
+	Completed []string `json:"completed,omitempty"`
+	// Entity is the entity being copied.
+	Entity string `json:"entity,omitempty"`
+	// Cursor is the cursor of the next page of the entity.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// IsEmpty reports whether the migration has not started.
+func (c *Checkpoint) IsEmpty() bool {
+	return len(c.Completed) == 0 && c.Entity == ""
+}
+
+// IsCompleted reports whether the entity is copied in full.
+func (c *Checkpoint) IsCompleted(entity string) bool {
+	for _, e := range c.Completed {
+		if e == entity {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadCheckpoint reads the checkpoint from the file. A missing file is an
+// empty checkpoint.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Checkpoint{}, nil
+		}
+
+		return nil, err
+	}
+
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+// Save replaces the file with the checkpoint.
+func (c *Checkpoint) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
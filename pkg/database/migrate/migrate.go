@@ -0,0 +1,213 @@
+// Package migrate moves the data from one database backend to another, using
+// the repositories of both, so the indexes of the target are built as the
+// entities are saved.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+)
+
+// DefaultPageSize is the number of entities read at once.
+const DefaultPageSize = 100
+
+// Entities in the order they are migrated. Entities referring to others are
+// migrated after them.
+const (
+	EntityUsers               = "users"
+	EntityDeletedUsers        = "deleted_users"
+	EntityUserVersions        = "user_versions"
+	EntityAccounts            = "accounts"
+	EntityRefreshTokens       = "refresh_tokens"
+	EntityRoles               = "roles"
+	EntityUserRoles           = "user_roles"
+	EntityOrganizations       = "organizations"
+	EntityOrganizationMembers = "organization_members"
+	EntityInvitations         = "invitations"
+	EntityPolicies            = "policies"
+	EntityRelationTuples      = "relation_tuples"
+	EntityAuditEvents         = "audit_events"
+	EntityWebhookDeliveries   = "webhook_deliveries"
+)
+
+var (
+	// ErrTargetNotEmpty is returned when a new migration would write into a
+	// database which already has data.
+	ErrTargetNotEmpty = errors.New("target database is not empty")
+
+	// ErrCountMismatch is returned when the target does not have the same
+	// number of entities as the source after the migration.
+	ErrCountMismatch = errors.New("number of entities does not match")
+)
+
+// Repositories are the repositories of a single database. Every repository
+// is migrated, so none of them may be nil.
+type Repositories struct {
+	Users             user.UserRepository
+	UserVersions      user.UserVersionRepository
+	Accounts          account.AccountRepository
+	RefreshTokens     refreshtoken.RefreshTokenRepository
+	Roles             role.RoleRepository
+	Organizations     organization.OrganizationRepository
+	Invitations       organization.InvitationRepository
+	Policies          policy.PolicyRepository
+	RelationTuples    relationtuple.RelationTupleRepository
+	AuditLog          auditlog.AuditLogRepository
+	WebhookDeliveries webhook.DeliveryRepository
+}
+
+// Migrator copies all entities from one database to another.
+type Migrator struct {
+	From *Repositories
+	To   *Repositories
+
+	// PageSize is the number of entities read at once.
+	PageSize int
+
+	// SaveCheckpoint, if set, is called after every page saved.
+	SaveCheckpoint func(*Checkpoint) error
+}
+
+// Result is the outcome of the migration of an entity.
+type Result struct {
+	Entity string
+	// Copied is the number of entities copied by this run.
+	Copied      int
+	SourceCount int
+	TargetCount int
+}
+
+// step copies the entities of a single type.
+type step struct {
+	entity string
+	copy   func(ctx context.Context, cursor string, limit int) (n int, nextCursor string, err error)
+	count  func(ctx context.Context, repos *Repositories) (int, error)
+}
+
+// Migrate copies the entities, resuming after the checkpoint, and verifies
+// the number of entities in the target. The entities copied before an
+// interruption are saved again on resume, which leaves them unchanged.
+func (m *Migrator) Migrate(ctx context.Context, from *Checkpoint) ([]*Result, error) {
+	pageSize := m.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	cp := Checkpoint{}
+	if from != nil {
+		cp = *from
+		cp.Completed = append([]string(nil), from.Completed...)
+	}
+
+	steps := m.steps()
+
+	if cp.IsEmpty() {
+		if err := m.checkEmpty(ctx, steps); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*Result, 0, len(steps))
+
+	for _, s := range steps {
+		result := &Result{Entity: s.entity}
+		results = append(results, result)
+
+		if !cp.IsCompleted(s.entity) {
+			if err := m.copy(ctx, s, &cp, pageSize, result); err != nil {
+				return results, fmt.Errorf("%s: %w", s.entity, err)
+			}
+		}
+
+		if err := m.verify(ctx, s, result); err != nil {
+			return results, fmt.Errorf("%s: %w", s.entity, err)
+		}
+	}
+
+	return results, nil
+}
+
+func (m *Migrator) checkEmpty(ctx context.Context, steps []*step) error {
+	for _, s := range steps {
+		n, err := s.count(ctx, m.To)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.entity, err)
+		}
+
+		if n > 0 {
+			return fmt.Errorf("%w: %d %s", ErrTargetNotEmpty, n, s.entity)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) copy(ctx context.Context, s *step, cp *Checkpoint, pageSize int, result *Result) error {
+	cursor := ""
+	if cp.Entity == s.entity {
+		cursor = cp.Cursor
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, nextCursor, err := s.copy(ctx, cursor, pageSize)
+		result.Copied += n
+		if err != nil {
+			return err
+		}
+
+		cp.Entity = s.entity
+		cp.Cursor = nextCursor
+
+		if nextCursor == "" {
+			cp.Entity = ""
+			cp.Completed = append(cp.Completed, s.entity)
+		}
+
+		if m.SaveCheckpoint != nil {
+			if err := m.SaveCheckpoint(cp); err != nil {
+				return fmt.Errorf("save checkpoint: %w", err)
+			}
+		}
+
+		if nextCursor == "" {
+			return nil
+		}
+
+		cursor = nextCursor
+	}
+}
+
+func (m *Migrator) verify(ctx context.Context, s *step, result *Result) error {
+	var err error
+
+	result.SourceCount, err = s.count(ctx, m.From)
+	if err != nil {
+		return fmt.Errorf("count source: %w", err)
+	}
+
+	result.TargetCount, err = s.count(ctx, m.To)
+	if err != nil {
+		return fmt.Errorf("count target: %w", err)
+	}
+
+	if result.SourceCount != result.TargetCount {
+		return fmt.Errorf("%w: %d in source, %d in target", ErrCountMismatch, result.SourceCount, result.TargetCount)
+	}
+
+	return nil
+}
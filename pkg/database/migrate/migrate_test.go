@@ -0,0 +1,373 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	account_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/account/storage/jsonmutexdb"
+	account_leveldb "github.com/zbiljic/authzy/pkg/domain/account/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	auditlog_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/auditlog/storage/jsonmutexdb"
+	auditlog_leveldb "github.com/zbiljic/authzy/pkg/domain/auditlog/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	organization_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/organization/storage/jsonmutexdb"
+	organization_leveldb "github.com/zbiljic/authzy/pkg/domain/organization/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	policy_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/policy/storage/jsonmutexdb"
+	policy_leveldb "github.com/zbiljic/authzy/pkg/domain/policy/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	refreshtoken_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/jsonmutexdb"
+	refreshtoken_leveldb "github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	relationtuple_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/jsonmutexdb"
+	relationtuple_leveldb "github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	role_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/role/storage/jsonmutexdb"
+	role_leveldb "github.com/zbiljic/authzy/pkg/domain/role/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	user_leveldb "github.com/zbiljic/authzy/pkg/domain/user/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	webhook_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/webhook/storage/jsonmutexdb"
+	webhook_leveldb "github.com/zbiljic/authzy/pkg/domain/webhook/storage/leveldb"
+)
+
+func newJSONMutexDB(t *testing.T) *Repositories {
+	t.Helper()
+
+	var (
+		repos Repositories
+		err   error
+	)
+
+	repos.Users, err = user_jsonmutexdb.NewUserRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.UserVersions, err = user_jsonmutexdb.NewUserVersionRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.Accounts, err = account_jsonmutexdb.NewAccountRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.RefreshTokens, err = refreshtoken_jsonmutexdb.NewRefreshTokenRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.Roles, err = role_jsonmutexdb.NewRoleRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.Organizations, err = organization_jsonmutexdb.NewOrganizationRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.Invitations, err = organization_jsonmutexdb.NewInvitationRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.Policies, err = policy_jsonmutexdb.NewPolicyRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.RelationTuples, err = relationtuple_jsonmutexdb.NewRelationTupleRepository(nil, "")
+	require.NoError(t, err)
+
+	repos.AuditLog, err = auditlog_jsonmutexdb.NewAuditLogRepository(nil, nil, "")
+	require.NoError(t, err)
+
+	repos.WebhookDeliveries, err = webhook_jsonmutexdb.NewDeliveryRepository(nil, "")
+	require.NoError(t, err)
+
+	return &repos
+}
+
+func newLevelDB(t *testing.T) *Repositories {
+	t.Helper()
+
+	db, cleanup := database_leveldb.Fixture()
+	t.Cleanup(func() {
+		db.Close()
+		cleanup()
+	})
+
+	var (
+		repos Repositories
+		err   error
+	)
+
+	repos.Users, err = user_leveldb.NewUserRepository(db, "")
+	require.NoError(t, err)
+
+	repos.UserVersions, err = user_leveldb.NewUserVersionRepository(db, "")
+	require.NoError(t, err)
+
+	repos.Accounts, err = account_leveldb.NewAccountRepository(db, "")
+	require.NoError(t, err)
+
+	repos.RefreshTokens, err = refreshtoken_leveldb.NewRefreshTokenRepository(db, "")
+	require.NoError(t, err)
+
+	repos.Roles, err = role_leveldb.NewRoleRepository(db, "")
+	require.NoError(t, err)
+
+	repos.Organizations, err = organization_leveldb.NewOrganizationRepository(db, "")
+	require.NoError(t, err)
+
+	repos.Invitations, err = organization_leveldb.NewInvitationRepository(db, "")
+	require.NoError(t, err)
+
+	repos.Policies, err = policy_leveldb.NewPolicyRepository(db, "")
+	require.NoError(t, err)
+
+	repos.RelationTuples, err = relationtuple_leveldb.NewRelationTupleRepository(db, "")
+	require.NoError(t, err)
+
+	repos.AuditLog, err = auditlog_leveldb.NewAuditLogRepository(db, "")
+	require.NoError(t, err)
+
+	repos.WebhookDeliveries, err = webhook_leveldb.NewDeliveryRepository(db, "")
+	require.NoError(t, err)
+
+	return &repos
+}
+
+// seed saves n users, with two versions each and one of every entity
+// referring to them, and a soft deleted user.
+func seed(t *testing.T, repos *Repositories, n int) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	_, err := repos.Roles.Save(ctx, &role.Role{ID: "editor"})
+	require.NoError(t, err)
+
+	_, err = repos.Organizations.Save(ctx, &organization.Organization{ID: "org1", Name: "org1"})
+	require.NoError(t, err)
+
+	_, err = repos.Invitations.Save(ctx, &organization.Invitation{
+		ID:             "invitation1",
+		OrganizationID: "org1",
+		Email:          "invited@example.com",
+		Token:          "invitation1",
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = repos.Policies.Save(ctx, &policy.Policy{
+		ID:         "policy1",
+		Effect:     policy.EffectAllow,
+		Expression: "true",
+	})
+	require.NoError(t, err)
+
+	saveUser := func(id string) *user.User {
+		u, err := repos.Users.Save(ctx, &user.User{
+			ID:                 id,
+			Email:              id + "@example.com",
+			Username:           id,
+			NormalizedUsername: id,
+			PasswordHash:       "hash",
+		})
+		require.NoError(t, err)
+
+		_, err = repos.UserVersions.Save(ctx, &user.Version{UserID: u.ID, Action: user.VersionActionCreate, User: u})
+		require.NoError(t, err)
+
+		err = repos.Roles.AssignToUser(ctx, u.ID, "editor")
+		require.NoError(t, err)
+
+		return u
+	}
+
+	for i := 0; i < n; i++ {
+		u := saveUser(fmt.Sprintf("user%d", i))
+
+		_, err = repos.UserVersions.Save(ctx, &user.Version{UserID: u.ID, Action: user.VersionActionUpdate, User: u})
+		require.NoError(t, err)
+
+		_, err = repos.Accounts.Save(ctx, &account.Account{
+			UserID:      u.ID,
+			Provider:    account.ProviderTypePassword,
+			FederatedID: u.Email,
+		})
+		require.NoError(t, err)
+
+		_, err = repos.RefreshTokens.Save(ctx, &refreshtoken.RefreshToken{
+			ID:     fmt.Sprintf("token%d", i),
+			UserID: u.ID,
+			Token:  fmt.Sprintf("secret%d", i),
+		})
+		require.NoError(t, err)
+
+		_, err = repos.Organizations.SaveMember(ctx, &organization.Member{OrganizationID: "org1", UserID: u.ID})
+		require.NoError(t, err)
+
+		_, err = repos.RelationTuples.Save(ctx, &relationtuple.RelationTuple{
+			Namespace: "documents",
+			Object:    fmt.Sprintf("doc%d", i),
+			Relation:  "owner",
+			Subject:   &relationtuple.Subject{ID: u.ID},
+		})
+		require.NoError(t, err)
+
+		_, err = repos.AuditLog.Append(ctx, &auditlog.Event{
+			ID:        fmt.Sprintf("event%d", i),
+			Action:    auditlog.ActionSignup,
+			Outcome:   auditlog.OutcomeSuccess,
+			SubjectID: u.ID,
+		})
+		require.NoError(t, err)
+
+		_, err = repos.WebhookDeliveries.Save(ctx, &webhook.Delivery{
+			ID:      fmt.Sprintf("delivery%d", i),
+			EventID: fmt.Sprintf("event%d", i),
+			Event:   webhook.EventUserCreated,
+			URL:     "http://localhost/hook",
+			Payload: json.RawMessage(`{}`),
+			Status:  webhook.StatusPending,
+		})
+		require.NoError(t, err)
+	}
+
+	deleted := saveUser("deleted")
+
+	err = repos.Users.DeleteByID(ctx, deleted.ID)
+	require.NoError(t, err)
+}
+
+// seeded returns the number of entities saved by seed.
+func seeded(n int) map[string]int {
+	return map[string]int{
+		EntityUsers:               n,
+		EntityDeletedUsers:        1,
+		EntityUserVersions:        2*n + 1,
+		EntityAccounts:            n,
+		EntityRefreshTokens:       n,
+		EntityRoles:               1,
+		EntityUserRoles:           n + 1,
+		EntityOrganizations:       1,
+		EntityOrganizationMembers: n,
+		EntityInvitations:         1,
+		EntityPolicies:            1,
+		EntityRelationTuples:      n,
+		EntityAuditEvents:         n,
+		EntityWebhookDeliveries:   n,
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+
+	from := newJSONMutexDB(t)
+	seed(t, from, 7)
+
+	to := newLevelDB(t)
+
+	results, err := (&Migrator{From: from, To: to, PageSize: 3}).Migrate(ctx, nil)
+	require.NoError(t, err)
+
+	expected := seeded(7)
+	require.Len(t, results, len(expected))
+
+	for _, result := range results {
+		assert.Equal(t, expected[result.Entity], result.Copied, result.Entity)
+		assert.Equal(t, expected[result.Entity], result.TargetCount, result.Entity)
+	}
+
+	// indexes are built on the target
+	u, err := to.Users.FindByIdentifier(ctx, "user3@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "user3", u.ID)
+
+	accounts, err := to.Accounts.FindAllForUser(ctx, "user3")
+	require.NoError(t, err)
+	assert.Len(t, accounts, 1)
+
+	token, err := to.RefreshTokens.FindByToken(ctx, "secret3")
+	require.NoError(t, err)
+	assert.Equal(t, "token3", token.ID)
+
+	// soft deleted users keep their history and roles
+	deleted, err := to.Users.FindDeletedByID(ctx, "deleted")
+	require.NoError(t, err)
+	assert.NotNil(t, deleted.DeletedAt)
+
+	_, err = to.UserVersions.FindByVersion(ctx, "deleted", 1)
+	require.NoError(t, err)
+
+	roles, err := to.Roles.FindAllForUser(ctx, "deleted")
+	require.NoError(t, err)
+	assert.Len(t, roles, 1)
+
+	// versions keep their numbers
+	v, err := to.UserVersions.FindByVersion(ctx, "user3", 2)
+	require.NoError(t, err)
+	assert.Equal(t, user.VersionActionUpdate, v.Action)
+
+	// a new migration into the same target is refused
+	_, err = (&Migrator{From: from, To: to}).Migrate(ctx, nil)
+	assert.True(t, errors.Is(err, ErrTargetNotEmpty))
+}
+
+func TestMigrateResume(t *testing.T) {
+	ctx := context.Background()
+
+	from := newJSONMutexDB(t)
+	seed(t, from, 7)
+
+	to := newLevelDB(t)
+
+	errInterrupted := errors.New("interrupted")
+
+	var saved *Checkpoint
+
+	m := &Migrator{
+		From:     from,
+		To:       to,
+		PageSize: 3,
+		SaveCheckpoint: func(cp *Checkpoint) error {
+			c := *cp
+			saved = &c
+
+			if cp.Entity == EntityUserVersions {
+				return errInterrupted
+			}
+
+			return nil
+		},
+	}
+
+	_, err := m.Migrate(ctx, nil)
+	require.True(t, errors.Is(err, errInterrupted))
+	require.NotNil(t, saved)
+	assert.Equal(t, []string{EntityUsers, EntityDeletedUsers}, saved.Completed)
+	assert.Equal(t, EntityUserVersions, saved.Entity)
+
+	m.SaveCheckpoint = func(cp *Checkpoint) error {
+		saved = cp
+		return nil
+	}
+
+	results, err := m.Migrate(ctx, saved)
+	require.NoError(t, err)
+
+	expected := seeded(7)
+
+	// the versions of the first page of users were copied before the
+	// interruption
+	assert.Equal(t, 0, results[0].Copied)
+	assert.Equal(t, 0, results[1].Copied)
+	assert.Equal(t, expected[EntityUserVersions]-3*2, results[2].Copied)
+
+	completed := make([]string, 0, len(results))
+	for _, result := range results {
+		assert.Equal(t, expected[result.Entity], result.TargetCount, result.Entity)
+		completed = append(completed, result.Entity)
+	}
+
+	assert.Equal(t, completed, saved.Completed)
+}
@@ -0,0 +1,512 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+)
+
+// deletedCursorPrefix marks the cursors of soft deleted users, which are
+// paged after the active ones.
+const deletedCursorPrefix = "deleted:"
+
+func (m *Migrator) steps() []*step {
+	return []*step{
+		{
+			entity: EntityUsers,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				users, nextCursor, err := m.From.Users.FindAll(ctx, cursor, limit)
+				if err != nil || len(users) == 0 {
+					return 0, nextCursor, err
+				}
+
+				_, err = m.To.Users.SaveAll(ctx, users)
+
+				return len(users), nextCursor, err
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.Users.Count(ctx)
+			},
+		},
+		{
+			entity: EntityDeletedUsers,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				users, nextCursor, err := m.From.Users.FindAllDeleted(ctx, cursor, limit)
+				if err != nil || len(users) == 0 {
+					return 0, nextCursor, err
+				}
+
+				_, err = m.To.Users.SaveAll(ctx, users)
+
+				return len(users), nextCursor, err
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return countPages(func(cursor string) (int, string, error) {
+					users, nextCursor, err := repos.Users.FindAllDeleted(ctx, cursor, DefaultPageSize)
+					return len(users), nextCursor, err
+				})
+			},
+		},
+		{
+			entity: EntityUserVersions,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				users, nextCursor, err := findAllUsers(ctx, m.From.Users, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				copied := 0
+
+				for _, u := range users {
+					n, err := m.copyVersions(ctx, u.ID)
+					copied += n
+					if err != nil {
+						return copied, "", err
+					}
+				}
+
+				return copied, nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return countForUsers(ctx, repos, func(userID string) (int, error) {
+					return lastVersion(ctx, repos.UserVersions, userID)
+				})
+			},
+		},
+		{
+			entity: EntityAccounts,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				accounts, nextCursor, err := m.From.Accounts.FindAll(ctx, cursor, limit)
+				if err != nil || len(accounts) == 0 {
+					return 0, nextCursor, err
+				}
+
+				_, err = m.To.Accounts.SaveAll(ctx, accounts)
+
+				return len(accounts), nextCursor, err
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.Accounts.Count(ctx)
+			},
+		},
+		{
+			entity: EntityRefreshTokens,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				tokens, nextCursor, err := m.From.RefreshTokens.FindAll(ctx, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				for i, token := range tokens {
+					if _, err := m.To.RefreshTokens.Save(ctx, token); err != nil {
+						return i, "", err
+					}
+				}
+
+				return len(tokens), nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.RefreshTokens.Count(ctx)
+			},
+		},
+		{
+			entity: EntityRoles,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				roles, nextCursor, err := m.From.Roles.FindAll(ctx, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				for i, r := range roles {
+					if _, err := m.To.Roles.Save(ctx, r); err != nil {
+						return i, "", err
+					}
+				}
+
+				return len(roles), nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.Roles.Count(ctx)
+			},
+		},
+		{
+			entity: EntityUserRoles,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				users, nextCursor, err := findAllUsers(ctx, m.From.Users, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				copied := 0
+
+				for _, u := range users {
+					roles, err := m.From.Roles.FindAllForUser(ctx, u.ID)
+					if err != nil {
+						return copied, "", err
+					}
+
+					for _, r := range roles {
+						if err := m.To.Roles.AssignToUser(ctx, u.ID, r.ID); err != nil {
+							return copied, "", err
+						}
+
+						copied++
+					}
+				}
+
+				return copied, nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return countForUsers(ctx, repos, func(userID string) (int, error) {
+					roles, err := repos.Roles.FindAllForUser(ctx, userID)
+					return len(roles), err
+				})
+			},
+		},
+		{
+			entity: EntityOrganizations,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				orgs, nextCursor, err := m.From.Organizations.FindAll(ctx, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				for i, org := range orgs {
+					if _, err := m.To.Organizations.Save(ctx, org); err != nil {
+						return i, "", err
+					}
+				}
+
+				return len(orgs), nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.Organizations.Count(ctx)
+			},
+		},
+		{
+			entity: EntityOrganizationMembers,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				orgs, nextCursor, err := m.From.Organizations.FindAll(ctx, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				copied := 0
+
+				for _, org := range orgs {
+					err := forEachMember(ctx, m.From.Organizations, org.ID, func(member *organization.Member) error {
+						if _, err := m.To.Organizations.SaveMember(ctx, member); err != nil {
+							return err
+						}
+
+						copied++
+
+						return nil
+					})
+					if err != nil {
+						return copied, "", err
+					}
+				}
+
+				return copied, nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return countForOrganizations(ctx, repos, func(orgID string) (int, error) {
+					n := 0
+					err := forEachMember(ctx, repos.Organizations, orgID, func(*organization.Member) error {
+						n++
+						return nil
+					})
+
+					return n, err
+				})
+			},
+		},
+		{
+			entity: EntityInvitations,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				orgs, nextCursor, err := m.From.Organizations.FindAll(ctx, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				copied := 0
+
+				for _, org := range orgs {
+					invitations, err := m.From.Invitations.FindAllForOrganization(ctx, org.ID)
+					if err != nil {
+						return copied, "", err
+					}
+
+					for _, invitation := range invitations {
+						if _, err := m.To.Invitations.Save(ctx, invitation); err != nil {
+							return copied, "", err
+						}
+
+						copied++
+					}
+				}
+
+				return copied, nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return countForOrganizations(ctx, repos, func(orgID string) (int, error) {
+					invitations, err := repos.Invitations.FindAllForOrganization(ctx, orgID)
+					return len(invitations), err
+				})
+			},
+		},
+		{
+			entity: EntityPolicies,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				policies, nextCursor, err := m.From.Policies.FindAll(ctx, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				for i, p := range policies {
+					if _, err := m.To.Policies.Save(ctx, p); err != nil {
+						return i, "", err
+					}
+				}
+
+				return len(policies), nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.Policies.Count(ctx)
+			},
+		},
+		{
+			entity: EntityRelationTuples,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				tuples, nextCursor, err := m.From.RelationTuples.FindAll(ctx, nil, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				for i, t := range tuples {
+					if _, err := m.To.RelationTuples.Save(ctx, t); err != nil {
+						return i, "", err
+					}
+				}
+
+				return len(tuples), nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.RelationTuples.Count(ctx)
+			},
+		},
+		{
+			entity: EntityAuditEvents,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				events, nextCursor, err := m.From.AuditLog.FindAll(ctx, nil, cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				for i, event := range events {
+					// events appended before an interruption already exist
+					_, err := m.To.AuditLog.Append(ctx, event)
+					if err != nil && !errors.Is(err, database.ErrAlreadyExists) {
+						return i, "", err
+					}
+				}
+
+				return len(events), nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.AuditLog.Count(ctx)
+			},
+		},
+		{
+			entity: EntityWebhookDeliveries,
+			copy: func(ctx context.Context, cursor string, limit int) (int, string, error) {
+				deliveries, nextCursor, err := m.From.WebhookDeliveries.FindAll(ctx, "", cursor, limit)
+				if err != nil {
+					return 0, "", err
+				}
+
+				for i, d := range deliveries {
+					if _, err := m.To.WebhookDeliveries.Save(ctx, d); err != nil {
+						return i, "", err
+					}
+				}
+
+				return len(deliveries), nextCursor, nil
+			},
+			count: func(ctx context.Context, repos *Repositories) (int, error) {
+				return repos.WebhookDeliveries.Count(ctx)
+			},
+		},
+	}
+}
+
+// copyVersions copies the versions of the user missing from the target,
+// oldest first, so that they keep their numbers.
+func (m *Migrator) copyVersions(ctx context.Context, userID string) (int, error) {
+	copied, err := lastVersion(ctx, m.To.UserVersions, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var versions []*user.Version
+
+	cursor := ""
+	for {
+		page, nextCursor, err := m.From.UserVersions.FindAllForUser(ctx, userID, cursor, DefaultPageSize)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, v := range page {
+			if v.Version > copied {
+				versions = append(versions, v)
+			}
+		}
+
+		if nextCursor == "" {
+			break
+		}
+
+		cursor = nextCursor
+	}
+
+	n := 0
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if _, err := m.To.UserVersions.Save(ctx, versions[i]); err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// lastVersion returns the number of the newest version of the user, which is
+// also the number of its versions.
+func lastVersion(ctx context.Context, repo user.UserVersionRepository, userID string) (int, error) {
+	versions, _, err := repo.FindAllForUser(ctx, userID, "", 1)
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+
+	return versions[0].Version, nil
+}
+
+// findAllUsers pages over the active users, followed by the soft deleted
+// ones.
+func findAllUsers(ctx context.Context, repo user.UserRepository, cursor string, limit int) ([]*user.User, string, error) {
+	if strings.HasPrefix(cursor, deletedCursorPrefix) {
+		users, nextCursor, err := repo.FindAllDeleted(ctx, strings.TrimPrefix(cursor, deletedCursorPrefix), limit)
+		if err != nil || nextCursor == "" {
+			return users, "", err
+		}
+
+		return users, deletedCursorPrefix + nextCursor, nil
+	}
+
+	users, nextCursor, err := repo.FindAll(ctx, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if nextCursor == "" {
+		// continues with the soft deleted users
+		nextCursor = deletedCursorPrefix
+	}
+
+	return users, nextCursor, nil
+}
+
+func countForUsers(ctx context.Context, repos *Repositories, count func(userID string) (int, error)) (int, error) {
+	return countPages(func(cursor string) (int, string, error) {
+		users, nextCursor, err := findAllUsers(ctx, repos.Users, cursor, DefaultPageSize)
+		if err != nil {
+			return 0, "", err
+		}
+
+		total := 0
+
+		for _, u := range users {
+			n, err := count(u.ID)
+			if err != nil {
+				return 0, "", err
+			}
+
+			total += n
+		}
+
+		return total, nextCursor, nil
+	})
+}
+
+func countForOrganizations(ctx context.Context, repos *Repositories, count func(orgID string) (int, error)) (int, error) {
+	return countPages(func(cursor string) (int, string, error) {
+		orgs, nextCursor, err := repos.Organizations.FindAll(ctx, cursor, DefaultPageSize)
+		if err != nil {
+			return 0, "", err
+		}
+
+		total := 0
+
+		for _, org := range orgs {
+			n, err := count(org.ID)
+			if err != nil {
+				return 0, "", err
+			}
+
+			total += n
+		}
+
+		return total, nextCursor, nil
+	})
+}
+
+func forEachMember(ctx context.Context, repo organization.OrganizationRepository, orgID string, fn func(*organization.Member) error) error {
+	cursor := ""
+	for {
+		members, nextCursor, err := repo.FindAllMembers(ctx, orgID, cursor, DefaultPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			if err := fn(member); err != nil {
+				return err
+			}
+		}
+
+		if nextCursor == "" {
+			return nil
+		}
+
+		cursor = nextCursor
+	}
+}
+
+// countPages sums the counts of all pages.
+func countPages(page func(cursor string) (int, string, error)) (int, error) {
+	total := 0
+
+	cursor := ""
+	for {
+		n, nextCursor, err := page(cursor)
+		if err != nil {
+			return 0, err
+		}
+
+		total += n
+
+		if nextCursor == "" {
+			return total, nil
+		}
+
+		cursor = nextCursor
+	}
+}
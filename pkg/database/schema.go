@@ -0,0 +1,6 @@
+package database
+
+// SchemaVersion is the version of the storage representation of the
+// entities, recorded in the database and in backups. Databases are migrated
+// to it by the schema migrations.
+const SchemaVersion = 1
@@ -0,0 +1,198 @@
+package schemamigrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+)
+
+const bboltMetaKey = "schema"
+
+// bboltBuckets are the buckets having the documents of the entities.
+var bboltBuckets = map[string][]string{
+	EntityUsers:         {"users", "deleted_users"},
+	EntityUserVersions:  {"user_versions"},
+	EntityAccounts:      {"accounts"},
+	EntityRefreshTokens: {"refresh_tokens"},
+}
+
+type bboltStore struct {
+	db        *database_bbolt.DB
+	prefix    string
+	batchSize int
+}
+
+// NewBBoltStore returns the store of the bbolt database with the buckets
+// having the prefix. Records are written in batches of the size.
+func NewBBoltStore(db *database_bbolt.DB, bucketPrefix string, batchSize int) Store {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &bboltStore{db: db, prefix: bucketPrefix, batchSize: batchSize}
+}
+
+func (s *bboltStore) metaBucket() []byte {
+	return []byte(s.prefix + "meta")
+}
+
+func (s *bboltStore) Version(ctx context.Context) (int, bool, error) {
+	var data []byte
+
+	err := s.db.View(ctx, func(tx *bolt.Tx) error {
+		if b := tx.Bucket(s.metaBucket()); b != nil {
+			// the value is only valid during the transaction
+			data = append([]byte(nil), b.Get([]byte(bboltMetaKey))...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return unmarshalMeta(data)
+}
+
+func (s *bboltStore) SetVersion(ctx context.Context, version int) error {
+	data, err := marshalMeta(version)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(ctx, func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(s.metaBucket())
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(bboltMetaKey), data)
+	})
+}
+
+// bboltEntry is the document of a key.
+type bboltEntry struct {
+	key  []byte
+	data []byte
+}
+
+func (s *bboltStore) Update(ctx context.Context, entity string, fn func(Record) (bool, error), dryRun bool) (int, int, error) {
+	var changed, total int
+
+	for _, name := range bboltBuckets[entity] {
+		bucket := []byte(s.prefix + name)
+
+		var lastKey []byte
+
+		for {
+			entries, err := s.page(ctx, bucket, lastKey)
+			if err != nil {
+				return changed, total, err
+			}
+
+			if len(entries) == 0 {
+				break
+			}
+
+			lastKey = entries[len(entries)-1].key
+
+			var updates []bboltEntry
+
+			for _, e := range entries {
+				total++
+
+				r, err := unmarshalRecord(e.data)
+				if err != nil {
+					return changed, total, fmt.Errorf("%s/%s: %w", name, e.key, err)
+				}
+
+				c, err := fn(r)
+				if err != nil {
+					return changed, total, fmt.Errorf("%s/%s: %w", name, e.key, err)
+				}
+
+				if !c {
+					continue
+				}
+
+				changed++
+
+				if dryRun {
+					continue
+				}
+
+				value, err := marshalRecord(r)
+				if err != nil {
+					return changed, total, fmt.Errorf("%s/%s: %w", name, e.key, err)
+				}
+
+				updates = append(updates, bboltEntry{key: e.key, data: value})
+			}
+
+			if len(updates) == 0 {
+				continue
+			}
+
+			err = s.db.Update(ctx, func(tx *bolt.Tx) error {
+				b := tx.Bucket(bucket)
+
+				for _, u := range updates {
+					err := b.Put(u.key, u.data)
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return changed, total, err
+			}
+		}
+	}
+
+	return changed, total, nil
+}
+
+// page returns the documents of the next batch of keys after the key.
+func (s *bboltStore) page(ctx context.Context, bucket, afterKey []byte) ([]bboltEntry, error) {
+	var result []bboltEntry
+
+	err := s.db.View(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			// not created by the repositories yet
+			return nil
+		}
+
+		c := b.Cursor()
+
+		k, v := c.First()
+		if afterKey != nil {
+			k, v = c.Seek(afterKey)
+			if k != nil && bytes.Equal(k, afterKey) {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < s.batchSize; k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			// keys and values are only valid during the transaction
+			result = append(result, bboltEntry{
+				key:  append([]byte(nil), k...),
+				data: append([]byte(nil), v...),
+			})
+		}
+
+		return nil
+	})
+
+	return result, err
+}
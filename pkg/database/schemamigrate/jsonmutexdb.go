@@ -0,0 +1,156 @@
+package schemamigrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+)
+
+const jsonMutexDBMetaFile = "meta.json"
+
+type jsonMutexDBStore struct {
+	ls     database_jsonmutexdb.LoadSaver
+	prefix string
+}
+
+// NewJSONMutexDBStore returns the store of the JSONMutexDB database with the
+// files having the prefix.
+func NewJSONMutexDBStore(ls database_jsonmutexdb.LoadSaver, filenamePrefix string) Store {
+	return &jsonMutexDBStore{ls: ls, prefix: filenamePrefix}
+}
+
+func (s *jsonMutexDBStore) Version(ctx context.Context) (int, bool, error) {
+	data, err := s.ls.Load(s.prefix + jsonMutexDBMetaFile)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return unmarshalMeta(data)
+}
+
+func (s *jsonMutexDBStore) SetVersion(ctx context.Context, version int) error {
+	data, err := marshalMeta(version)
+	if err != nil {
+		return err
+	}
+
+	return s.ls.Save(s.prefix+jsonMutexDBMetaFile, data)
+}
+
+// jsonMutexDBLists are the entities having a list of records by id in their
+// file.
+var jsonMutexDBLists = map[string]bool{
+	EntityUserVersions: true,
+}
+
+// Update changes the records of the entity file, and saves it once.
+func (s *jsonMutexDBStore) Update(ctx context.Context, entity string, fn func(Record) (bool, error), dryRun bool) (int, int, error) {
+	filename := fmt.Sprintf("%s%s.json", s.prefix, entity)
+
+	data, err := s.ls.Load(filename)
+	if err != nil || len(data) == 0 {
+		return 0, 0, err
+	}
+
+	db := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &db); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	ids := make([]string, 0, len(db))
+	for id := range db {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	update := updateRecord
+	if jsonMutexDBLists[entity] {
+		update = updateList
+	}
+
+	var changed, total int
+
+	for _, id := range ids {
+		value, c, n, err := update(db[id], fn)
+		changed += c
+		total += n
+
+		if err != nil {
+			return changed, total, fmt.Errorf("%s: %s: %w", filename, id, err)
+		}
+
+		if c > 0 {
+			db[id] = value
+		}
+	}
+
+	if dryRun || changed == 0 {
+		return changed, total, nil
+	}
+
+	out, err := json.Marshal(db)
+	if err != nil {
+		return changed, total, err
+	}
+
+	return changed, total, s.ls.Save(filename, out)
+}
+
+// updateRecord passes the record to the function, and returns the record,
+// the number of records changed, and the number of records.
+func updateRecord(data json.RawMessage, fn func(Record) (bool, error)) (json.RawMessage, int, int, error) {
+	r, err := unmarshalRecord(data)
+	if err != nil {
+		return nil, 0, 1, err
+	}
+
+	c, err := fn(r)
+	if err != nil || !c {
+		return nil, 0, 1, err
+	}
+
+	value, err := marshalRecord(r)
+	if err != nil {
+		return nil, 1, 1, err
+	}
+
+	return value, 1, 1, nil
+}
+
+// updateList passes every record of the list to the function, and returns
+// the list, the number of records changed, and the number of records.
+func updateList(data json.RawMessage, fn func(Record) (bool, error)) (json.RawMessage, int, int, error) {
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, 0, 0, err
+	}
+
+	changed := 0
+
+	for i := range list {
+		value, c, _, err := updateRecord(list[i], fn)
+		changed += c
+
+		if err != nil {
+			return nil, changed, len(list), fmt.Errorf("%d: %w", i, err)
+		}
+
+		if c > 0 {
+			list[i] = value
+		}
+	}
+
+	if changed == 0 {
+		return nil, 0, len(list), nil
+	}
+
+	value, err := json.Marshal(list)
+	if err != nil {
+		return nil, changed, len(list), err
+	}
+
+	return value, changed, len(list), nil
+}
@@ -0,0 +1,128 @@
+package schemamigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// DefaultBatchSize is the number of records written at once.
+const DefaultBatchSize = 1000
+
+const levelDBMetaKey = "meta/schema"
+
+// levelDBKeyspaces are the keyspaces having the documents of the entities.
+var levelDBKeyspaces = map[string][]string{
+	EntityUsers:         {"users", "deleted_users"},
+	EntityUserVersions:  {"user_versions"},
+	EntityAccounts:      {"accounts"},
+	EntityRefreshTokens: {"refresh_tokens"},
+}
+
+type levelDBStore struct {
+	db        *leveldb.DB
+	prefix    string
+	batchSize int
+}
+
+// NewLevelDBStore returns the store of the LevelDB database with the keys
+// having the prefix. Records are written in batches of the size.
+func NewLevelDBStore(db *leveldb.DB, keyPrefix string, batchSize int) Store {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &levelDBStore{db: db, prefix: keyPrefix, batchSize: batchSize}
+}
+
+func (s *levelDBStore) Version(ctx context.Context) (int, bool, error) {
+	data, err := s.db.Get([]byte(s.prefix+levelDBMetaKey), nil)
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return 0, false, err
+	}
+
+	return unmarshalMeta(data)
+}
+
+func (s *levelDBStore) SetVersion(ctx context.Context, version int) error {
+	data, err := marshalMeta(version)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Put([]byte(s.prefix+levelDBMetaKey), data, nil)
+}
+
+func (s *levelDBStore) Update(ctx context.Context, entity string, fn func(Record) (bool, error), dryRun bool) (int, int, error) {
+	var changed, total int
+
+	batch := new(leveldb.Batch)
+
+	for _, keyspace := range levelDBKeyspaces[entity] {
+		iter := s.db.NewIterator(util.BytesPrefix([]byte(s.prefix+keyspace+"/")), nil)
+
+		for iter.Next() {
+			if err := ctx.Err(); err != nil {
+				iter.Release()
+				return changed, total, err
+			}
+
+			total++
+
+			r, err := unmarshalRecord(iter.Value())
+			if err != nil {
+				iter.Release()
+				return changed, total, fmt.Errorf("%s: %w", iter.Key(), err)
+			}
+
+			c, err := fn(r)
+			if err != nil {
+				iter.Release()
+				return changed, total, fmt.Errorf("%s: %w", iter.Key(), err)
+			}
+
+			if !c {
+				continue
+			}
+
+			changed++
+
+			if dryRun {
+				continue
+			}
+
+			value, err := marshalRecord(r)
+			if err != nil {
+				iter.Release()
+				return changed, total, fmt.Errorf("%s: %w", iter.Key(), err)
+			}
+
+			batch.Put(append([]byte(nil), iter.Key()...), value)
+
+			if batch.Len() >= s.batchSize {
+				if err := s.db.Write(batch, nil); err != nil {
+					iter.Release()
+					return changed, total, err
+				}
+				batch.Reset()
+			}
+		}
+
+		iter.Release()
+
+		if err := iter.Error(); err != nil {
+			return changed, total, err
+		}
+	}
+
+	if batch.Len() > 0 {
+		if err := s.db.Write(batch, nil); err != nil {
+			return changed, total, err
+		}
+	}
+
+	return changed, total, nil
+}
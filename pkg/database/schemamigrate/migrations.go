@@ -0,0 +1,5 @@
+package schemamigrate
+
+// migrations of the schema, in the order of their versions, starting after
+// InitialVersion. The version of the last one is database.SchemaVersion.
+var migrations = []*Migration{}
@@ -0,0 +1,44 @@
+package schemamigrate
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+func unmarshalRecord(data []byte) (Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	r := Record{}
+	if err := dec.Decode(&r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func marshalRecord(r Record) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// meta is the stored metadata of the database.
+type meta struct {
+	SchemaVersion int `json:"schema_version"`
+}
+
+func unmarshalMeta(data []byte) (int, bool, error) {
+	if len(data) == 0 {
+		return InitialVersion, false, nil
+	}
+
+	m := meta{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return 0, false, err
+	}
+
+	return m.SchemaVersion, true, nil
+}
+
+func marshalMeta(version int) ([]byte, error) {
+	return json.Marshal(&meta{SchemaVersion: version})
+}
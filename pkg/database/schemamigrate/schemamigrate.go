@@ -0,0 +1,254 @@
+// Package schemamigrate migrates the stored entities to the current schema
+// version.
+//
+// The schema version is recorded in every database, under the storage prefix.
+// Migrations change the stored JSON documents of the entities, in the order of
+// their versions, in a single pass over the entities. The indexes are kept by
+// the repositories, and are not changed by the migrations.
+package schemamigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// InitialVersion is the schema version of the data written before the
+// version was recorded.
+const InitialVersion = 1
+
+// Entities having their documents migrated.
+const (
+	EntityUsers         = "users"
+	EntityUserVersions  = "user_versions"
+	EntityAccounts      = "accounts"
+	EntityRefreshTokens = "refresh_tokens"
+)
+
+// Entities in the order they are migrated.
+var Entities = []string{EntityUsers, EntityUserVersions, EntityAccounts, EntityRefreshTokens}
+
+var (
+	// ErrNewerVersion is returned for databases written by a newer version.
+	ErrNewerVersion = errors.New("schema version is newer than supported")
+
+	// ErrOutdated is returned for databases which need to be migrated.
+	ErrOutdated = errors.New("schema version is outdated")
+)
+
+// Record is the stored JSON document of an entity. Numbers are kept as
+// json.Number.
+type Record map[string]interface{}
+
+// Migration migrates the documents to its schema version.
+type Migration struct {
+	Version     int
+	Description string
+
+	// Migrate has the functions changing the records, by entity. They return
+	// whether the record was changed. A migration interrupted before the
+	// version is recorded runs again on the records already changed, so the
+	// functions change a record only once. The versions of the users have
+	// a snapshot of the user, so a migration of the users migrates them
+	// too, with VersionUser.
+	Migrate map[string]func(Record) (bool, error)
+}
+
+// VersionUser returns the function changing the snapshot of the user in the
+// record of a user version with the function changing a user record.
+func VersionUser(fn func(Record) (bool, error)) func(Record) (bool, error) {
+	return func(r Record) (bool, error) {
+		user, ok := r["user"].(map[string]interface{})
+		if !ok {
+			return false, errors.New("version has no user")
+		}
+
+		return fn(Record(user))
+	}
+}
+
+// Store is the storage of a database.
+type Store interface {
+	// Version returns the recorded schema version, and whether it was
+	// recorded.
+	Version(ctx context.Context) (version int, recorded bool, err error)
+
+	// SetVersion records the schema version.
+	SetVersion(ctx context.Context, version int) error
+
+	// Update passes all records of the entity to the function, and writes
+	// the records it changed, unless it is a dry run. It returns the number
+	// of records changed, and the number of all records.
+	Update(ctx context.Context, entity string, fn func(Record) (bool, error), dryRun bool) (changed, total int, err error)
+}
+
+// Migrator migrates the database to the latest schema version.
+type Migrator struct {
+	Store Store
+
+	// Migrations in the order of their versions, the migrations of the
+	// current schema by default.
+	Migrations []*Migration
+
+	// DryRun reports the changes without writing anything.
+	DryRun bool
+}
+
+// Result is the outcome of the migration.
+type Result struct {
+	FromVersion int
+	ToVersion   int
+	DryRun      bool
+	Changes     []*Change
+}
+
+// Change is the number of records changed by a migration.
+type Change struct {
+	Version     int
+	Description string
+	Entity      string
+	Changed     int
+	Total       int
+}
+
+func (m *Migrator) migrations() []*Migration {
+	if m.Migrations != nil {
+		return m.Migrations
+	}
+
+	return migrations
+}
+
+// LatestVersion returns the schema version the database is migrated to.
+func (m *Migrator) LatestVersion() int {
+	return latestVersion(m.migrations())
+}
+
+func latestVersion(migrations []*Migration) int {
+	if len(migrations) == 0 {
+		return InitialVersion
+	}
+
+	return migrations[len(migrations)-1].Version
+}
+
+// Check returns an error unless the database has the latest schema version.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, _, err := m.Store.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkVersion(version, m.LatestVersion())
+}
+
+// CheckVersion returns an error unless the version is the latest schema
+// version of the migrations of the current schema.
+func CheckVersion(version int) error {
+	return checkVersion(version, latestVersion(migrations))
+}
+
+func checkVersion(version, latest int) error {
+	switch {
+	case version > latest:
+		return fmt.Errorf("%w: %d, supported %d", ErrNewerVersion, version, latest)
+	case version < latest:
+		return fmt.Errorf("%w: %d, latest %d", ErrOutdated, version, latest)
+	}
+
+	return nil
+}
+
+// Migrate runs the migrations after the recorded schema version, and records
+// the latest version.
+func (m *Migrator) Migrate(ctx context.Context) (*Result, error) {
+	all := m.migrations()
+
+	version, recorded, err := m.Store.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		FromVersion: version,
+		ToVersion:   latestVersion(all),
+		DryRun:      m.DryRun,
+	}
+
+	if err := checkVersion(version, result.ToVersion); err != nil && !errors.Is(err, ErrOutdated) {
+		return nil, err
+	}
+
+	var pending []*Migration
+	for _, migration := range all {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+
+	for _, entity := range Entities {
+		if err := m.update(ctx, entity, pending, result); err != nil {
+			return result, fmt.Errorf("%s: %w", entity, err)
+		}
+	}
+
+	if m.DryRun || (recorded && version == result.ToVersion) {
+		return result, nil
+	}
+
+	if err := m.Store.SetVersion(ctx, result.ToVersion); err != nil {
+		return result, fmt.Errorf("set version: %w", err)
+	}
+
+	return result, nil
+}
+
+// update runs the migrations of the entity in a single pass over its records.
+func (m *Migrator) update(ctx context.Context, entity string, pending []*Migration, result *Result) error {
+	var changes []*Change
+	var fns []func(Record) (bool, error)
+
+	for _, migration := range pending {
+		fn := migration.Migrate[entity]
+		if fn == nil {
+			continue
+		}
+
+		changes = append(changes, &Change{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Entity:      entity,
+		})
+		fns = append(fns, fn)
+	}
+
+	if len(fns) == 0 {
+		return nil
+	}
+
+	_, total, err := m.Store.Update(ctx, entity, func(r Record) (bool, error) {
+		changed := false
+
+		for i, fn := range fns {
+			c, err := fn(r)
+			if err != nil {
+				return false, fmt.Errorf("migration %d: %w", changes[i].Version, err)
+			}
+
+			if c {
+				changes[i].Changed++
+				changed = true
+			}
+		}
+
+		return changed, nil
+	}, m.DryRun)
+
+	for _, c := range changes {
+		c.Total = total
+	}
+
+	result.Changes = append(result.Changes, changes...)
+
+	return err
+}
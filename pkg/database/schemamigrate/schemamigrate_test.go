@@ -0,0 +1,350 @@
+package schemamigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	account_bbolt "github.com/zbiljic/authzy/pkg/domain/account/storage/bbolt"
+	account_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/account/storage/jsonmutexdb"
+	account_leveldb "github.com/zbiljic/authzy/pkg/domain/account/storage/leveldb"
+	account_sqlite "github.com/zbiljic/authzy/pkg/domain/account/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	user_bbolt "github.com/zbiljic/authzy/pkg/domain/user/storage/bbolt"
+	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	user_leveldb "github.com/zbiljic/authzy/pkg/domain/user/storage/leveldb"
+	user_sqlite "github.com/zbiljic/authzy/pkg/domain/user/storage/sqlite"
+)
+
+const testPrefix = "acme."
+
+type fixture struct {
+	store Store
+
+	// open opens the repositories, which read the data migrated while closed
+	open func() (user.UserRepository, account.AccountRepository, error)
+
+	// versions opens the repository of the user versions
+	versions func() (user.UserVersionRepository, error)
+}
+
+func (f *fixture) repositories(t *testing.T) (user.UserRepository, account.AccountRepository) {
+	t.Helper()
+
+	users, accounts, err := f.open()
+	require.NoError(t, err)
+
+	return users, accounts
+}
+
+func (f *fixture) userVersions(t *testing.T) user.UserVersionRepository {
+	t.Helper()
+
+	versions, err := f.versions()
+	require.NoError(t, err)
+
+	return versions
+}
+
+func newJSONMutexDB(t *testing.T) *fixture {
+	t.Helper()
+
+	ls, err := database_jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	return &fixture{
+		store: NewJSONMutexDBStore(ls, testPrefix),
+		open: func() (user.UserRepository, account.AccountRepository, error) {
+			users, err := user_jsonmutexdb.NewUserRepository(ls, testPrefix)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			accounts, err := account_jsonmutexdb.NewAccountRepository(ls, testPrefix)
+
+			return users, accounts, err
+		},
+		versions: func() (user.UserVersionRepository, error) {
+			return user_jsonmutexdb.NewUserVersionRepository(ls, testPrefix)
+		},
+	}
+}
+
+func newLevelDB(t *testing.T) *fixture {
+	t.Helper()
+
+	db, cleanup := database_leveldb.Fixture()
+	t.Cleanup(func() {
+		db.Close()
+		cleanup()
+	})
+
+	return &fixture{
+		// written in more than one batch
+		store: NewLevelDBStore(db, testPrefix, 2),
+		open: func() (user.UserRepository, account.AccountRepository, error) {
+			users, err := user_leveldb.NewUserRepository(db, testPrefix)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			accounts, err := account_leveldb.NewAccountRepository(db, testPrefix)
+
+			return users, accounts, err
+		},
+		versions: func() (user.UserVersionRepository, error) {
+			return user_leveldb.NewUserVersionRepository(db, testPrefix)
+		},
+	}
+}
+
+func newSQLite(t *testing.T) *fixture {
+	t.Helper()
+
+	db, cleanup := database_sqlite.Fixture()
+	t.Cleanup(cleanup)
+
+	return &fixture{
+		// written in more than one batch
+		store: NewSQLiteStore(db, testPrefix, 2),
+		open: func() (user.UserRepository, account.AccountRepository, error) {
+			users, err := user_sqlite.NewUserRepository(db, testPrefix)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			accounts, err := account_sqlite.NewAccountRepository(db, testPrefix)
+
+			return users, accounts, err
+		},
+		versions: func() (user.UserVersionRepository, error) {
+			return user_sqlite.NewUserVersionRepository(db, testPrefix)
+		},
+	}
+}
+
+func newBBolt(t *testing.T) *fixture {
+	t.Helper()
+
+	db, cleanup := database_bbolt.Fixture()
+	t.Cleanup(cleanup)
+
+	return &fixture{
+		// written in more than one batch
+		store: NewBBoltStore(db, testPrefix, 2),
+		open: func() (user.UserRepository, account.AccountRepository, error) {
+			users, err := user_bbolt.NewUserRepository(db, testPrefix)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			accounts, err := account_bbolt.NewAccountRepository(db, testPrefix)
+
+			return users, accounts, err
+		},
+		versions: func() (user.UserVersionRepository, error) {
+			return user_bbolt.NewUserVersionRepository(db, testPrefix)
+		},
+	}
+}
+
+func seed(t *testing.T, f *fixture, n int) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	users, accounts := f.repositories(t)
+	versions := f.userVersions(t)
+
+	for i := 0; i < n; i++ {
+		u := &user.User{
+			ID:                 fmt.Sprintf("user%d", i),
+			Email:              fmt.Sprintf("user%d@example.com", i),
+			Username:           fmt.Sprintf("user%d", i),
+			NormalizedUsername: fmt.Sprintf("user%d", i),
+			PasswordHash:       "hash",
+		}
+
+		// the migration leaves the users with a nickname unchanged
+		if i == 0 {
+			u.Nickname = "first"
+		}
+
+		_, err := users.Save(ctx, u)
+		require.NoError(t, err)
+
+		_, err = versions.Save(ctx, &user.Version{
+			UserID: u.ID,
+			Action: user.VersionActionCreate,
+			User:   u,
+		})
+		require.NoError(t, err)
+
+		_, err = accounts.Save(ctx, &account.Account{
+			UserID:      u.ID,
+			Provider:    account.ProviderTypePassword,
+			FederatedID: u.Email,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func defaultNickname(r Record) (bool, error) {
+	if r["nickname"] != nil {
+		return false, nil
+	}
+
+	r["nickname"] = r["username"]
+
+	return true, nil
+}
+
+var testMigrations = []*Migration{
+	{
+		Version:     2,
+		Description: "default nickname",
+		Migrate: map[string]func(Record) (bool, error){
+			EntityUsers:        defaultNickname,
+			EntityUserVersions: VersionUser(defaultNickname),
+		},
+	},
+	{
+		Version:     3,
+		Description: "accounts unchanged",
+		Migrate: map[string]func(Record) (bool, error){
+			EntityAccounts: func(r Record) (bool, error) {
+				return false, nil
+			},
+		},
+	},
+}
+
+func TestMigrate(t *testing.T) {
+	backends := map[string]func(*testing.T) *fixture{
+		database_jsonmutexdb.Type: newJSONMutexDB,
+		database_leveldb.Type:     newLevelDB,
+		database_sqlite.Type:      newSQLite,
+		database_bbolt.Type:       newBBolt,
+	}
+
+	for name, newFixture := range backends {
+		newFixture := newFixture
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			f := newFixture(t)
+			seed(t, f, 5)
+
+			version, recorded, err := f.store.Version(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, InitialVersion, version)
+			assert.False(t, recorded)
+
+			m := &Migrator{Store: f.store, Migrations: testMigrations}
+
+			assert.True(t, errors.Is(m.Check(ctx), ErrOutdated))
+
+			// the dry run reports the changes without writing them
+			m.DryRun = true
+
+			result, err := m.Migrate(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, result.FromVersion)
+			assert.Equal(t, 3, result.ToVersion)
+			require.Len(t, result.Changes, 3)
+			assert.Equal(t, &Change{Version: 2, Description: "default nickname", Entity: EntityUsers, Changed: 4, Total: 5}, result.Changes[0])
+			assert.Equal(t, &Change{Version: 2, Description: "default nickname", Entity: EntityUserVersions, Changed: 4, Total: 5}, result.Changes[1])
+			assert.Equal(t, &Change{Version: 3, Description: "accounts unchanged", Entity: EntityAccounts, Changed: 0, Total: 5}, result.Changes[2])
+
+			users, _ := f.repositories(t)
+
+			u, err := users.FindByID(ctx, "user3")
+			require.NoError(t, err)
+			assert.Empty(t, u.Nickname)
+
+			version, _, err = f.store.Version(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, InitialVersion, version)
+
+			// the migration writes the changes, and records the version
+			m.DryRun = false
+
+			_, err = m.Migrate(ctx)
+			require.NoError(t, err)
+			require.NoError(t, m.Check(ctx))
+
+			users, accounts := f.repositories(t)
+
+			u, err = users.FindByID(ctx, "user3")
+			require.NoError(t, err)
+			assert.Equal(t, "user3", u.Nickname)
+			assert.Equal(t, "user3@example.com", u.Email)
+
+			u, err = users.FindByID(ctx, "user0")
+			require.NoError(t, err)
+			assert.Equal(t, "first", u.Nickname)
+
+			// the snapshots of the users in their versions are migrated too
+			v, err := f.userVersions(t).FindByVersion(ctx, "user3", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "user3", v.User.Nickname)
+			assert.Equal(t, "user3@example.com", v.User.Email)
+
+			u, err = users.FindByIdentifier(ctx, "user4@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, "user4", u.ID)
+
+			count, err := accounts.Count(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 5, count)
+
+			// nothing is left to migrate
+			result, err = m.Migrate(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 3, result.FromVersion)
+			assert.Empty(t, result.Changes)
+
+			// databases written by a newer version are refused
+			_, err = (&Migrator{Store: f.store}).Migrate(ctx)
+			assert.True(t, errors.Is(err, ErrNewerVersion))
+		})
+	}
+}
+
+func TestMigrateRecordsVersion(t *testing.T) {
+	ctx := context.Background()
+
+	f := newJSONMutexDB(t)
+
+	_, err := (&Migrator{Store: f.store}).Migrate(ctx)
+	require.NoError(t, err)
+
+	version, recorded, err := f.store.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, database.SchemaVersion, version)
+	assert.True(t, recorded)
+}
+
+func TestMigrations(t *testing.T) {
+	version := InitialVersion
+
+	for _, m := range migrations {
+		version++
+
+		assert.Equal(t, version, m.Version)
+		assert.NotEmpty(t, m.Description)
+	}
+
+	assert.Equal(t, database.SchemaVersion, version)
+}
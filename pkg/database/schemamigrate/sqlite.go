@@ -0,0 +1,187 @@
+package schemamigrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+)
+
+const sqliteMetaKey = "schema"
+
+// sqliteTables are the tables having the documents of the entities.
+var sqliteTables = map[string][]string{
+	EntityUsers:         {"users", "deleted_users"},
+	EntityUserVersions:  {"user_versions"},
+	EntityAccounts:      {"accounts"},
+	EntityRefreshTokens: {"refresh_tokens"},
+}
+
+type sqliteStore struct {
+	db        *database_sqlite.DB
+	prefix    string
+	batchSize int
+}
+
+// NewSQLiteStore returns the store of the SQLite database with the tables
+// having the prefix. Records are written in batches of the size.
+func NewSQLiteStore(db *database_sqlite.DB, tablePrefix string, batchSize int) Store {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &sqliteStore{db: db, prefix: tablePrefix, batchSize: batchSize}
+}
+
+func (s *sqliteStore) metaTable() string {
+	return database_sqlite.Table(s.prefix, "meta")
+}
+
+func (s *sqliteStore) Version(ctx context.Context) (int, bool, error) {
+	var data string
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT value FROM `+s.metaTable()+` WHERE key = ?`,
+		sqliteMetaKey,
+	).Scan(&data)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	return unmarshalMeta([]byte(data))
+}
+
+func (s *sqliteStore) SetVersion(ctx context.Context, version int) error {
+	data, err := marshalMeta(version)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+s.metaTable()+` (key, value) VALUES (?, ?)`,
+		sqliteMetaKey, string(data),
+	)
+
+	return err
+}
+
+// sqliteRow is the document of a row, by its rowid.
+type sqliteRow struct {
+	rowid int64
+	data  []byte
+}
+
+func (s *sqliteStore) Update(ctx context.Context, entity string, fn func(Record) (bool, error), dryRun bool) (int, int, error) {
+	var changed, total int
+
+	for _, name := range sqliteTables[entity] {
+		table := database_sqlite.Table(s.prefix, name)
+
+		var lastRowID int64
+
+		for {
+			rows, err := s.page(ctx, table, lastRowID)
+			if err != nil {
+				return changed, total, err
+			}
+
+			if len(rows) == 0 {
+				break
+			}
+
+			lastRowID = rows[len(rows)-1].rowid
+
+			var updates []sqliteRow
+
+			for _, row := range rows {
+				total++
+
+				r, err := unmarshalRecord(row.data)
+				if err != nil {
+					return changed, total, fmt.Errorf("%s/%d: %w", name, row.rowid, err)
+				}
+
+				c, err := fn(r)
+				if err != nil {
+					return changed, total, fmt.Errorf("%s/%d: %w", name, row.rowid, err)
+				}
+
+				if !c {
+					continue
+				}
+
+				changed++
+
+				if dryRun {
+					continue
+				}
+
+				value, err := marshalRecord(r)
+				if err != nil {
+					return changed, total, fmt.Errorf("%s/%d: %w", name, row.rowid, err)
+				}
+
+				updates = append(updates, sqliteRow{rowid: row.rowid, data: value})
+			}
+
+			if len(updates) == 0 {
+				continue
+			}
+
+			err = s.db.WithTx(ctx, func(tx *sql.Tx) error {
+				for _, u := range updates {
+					_, err := tx.ExecContext(ctx,
+						`UPDATE `+table+` SET data = ? WHERE rowid = ?`,
+						string(u.data), u.rowid,
+					)
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return changed, total, err
+			}
+		}
+	}
+
+	return changed, total, nil
+}
+
+// page returns the documents of the next batch of rows after the rowid.
+func (s *sqliteStore) page(ctx context.Context, table string, afterRowID int64) ([]sqliteRow, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT rowid, data FROM `+table+` WHERE rowid > ? ORDER BY rowid LIMIT ?`,
+		afterRowID, s.batchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []sqliteRow
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var (
+			row  sqliteRow
+			data string
+		)
+
+		if err := rows.Scan(&row.rowid, &data); err != nil {
+			return nil, err
+		}
+
+		row.data = []byte(data)
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
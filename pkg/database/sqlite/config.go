@@ -0,0 +1,9 @@
+package sqlite
+
+const Type = "sqlite"
+
+// Config defines database configuration.
+type Config struct {
+	DataDir     string `json:"data_dir" split_words:"true"`
+	TablePrefix string `json:"table_prefix" split_words:"true"`
+}
@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zbiljic/authzy/pkg/database"
+
+	// registers the pure Go "sqlite" driver
+	_ "modernc.org/sqlite"
+)
+
+// Filename is the name of the database file in the data directory.
+const Filename = "authzy.db"
+
+// DB is a SQLite database, having the tables of every table prefix in use.
+type DB struct {
+	*sql.DB
+
+	mu       sync.Mutex
+	migrated map[string]bool
+}
+
+// New creates a new local SQLite database, and migrates the tables having
+// the table prefix of the configuration.
+func New(config Config) (*DB, error) {
+	err := os.MkdirAll(config.DataDir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %s", err)
+	}
+
+	dsn := url.URL{
+		Scheme: "file",
+		Opaque: filepath.Join(config.DataDir, Filename),
+		RawQuery: url.Values{
+			"_pragma": []string{"busy_timeout(5000)", "journal_mode(WAL)"},
+			"_txlock": []string{"immediate"},
+		}.Encode(),
+	}
+
+	sqlDB, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %s", err)
+	}
+
+	// writes are serialized by SQLite anyway, a single connection avoids
+	// busy errors between the connections of the pool
+	sqlDB.SetMaxOpenConns(1)
+
+	db := &DB{
+		DB:       sqlDB,
+		migrated: make(map[string]bool),
+	}
+
+	err = db.Migrate(config.TablePrefix)
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("open sqlite: %s", err)
+	}
+
+	return db, nil
+}
+
+// Querier is the database or a transaction in it.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// sqliteTx is a transaction of the database.
+type sqliteTx struct {
+	*sql.Tx
+	db *DB
+}
+
+// txFromContext returns the transaction of the database carried in the
+// context.
+func (db *DB) txFromContext(ctx context.Context) *sql.Tx {
+	tx, ok := database.TxFromContext(ctx).(*sqliteTx)
+	if !ok || tx.db != db {
+		return nil
+	}
+
+	return tx.Tx
+}
+
+// querier returns the transaction carried in the context, or the database.
+func (db *DB) querier(ctx context.Context) Querier {
+	if tx := db.txFromContext(ctx); tx != nil {
+		return tx
+	}
+
+	return db.DB
+}
+
+// ExecContext executes the query in the transaction carried in the context,
+// or in the database.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.querier(ctx).ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes the query in the transaction carried in the context,
+// or in the database.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.querier(ctx).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executes the query in the transaction carried in the
+// context, or in the database.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.querier(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// WithTx runs the function in the transaction carried in the context, or in
+// a new transaction, which is committed if the function returns no error.
+// The function must not use the database outside of the transaction, as the
+// database has a single connection.
+func (db *DB) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if tx := db.txFromContext(ctx); tx != nil {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback() //nolint:errcheck
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type transactor struct {
+	db *DB
+}
+
+// NewTransactor returns the transactor of the database. The repositories
+// run their queries in the transaction carried in the context.
+func NewTransactor(db *DB) database.Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.db.txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	return database.RunInTx(ctx, func() (database.Tx, error) {
+		tx, err := t.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return &sqliteTx{Tx: tx, db: t.db}, nil
+	}, fn)
+}
@@ -0,0 +1,31 @@
+package sqlite
+
+import (
+	"os"
+
+	"github.com/zbiljic/authzy/pkg/testutil"
+)
+
+// Fixture returns a temporary test database for testing.
+func Fixture() (*DB, func()) {
+	var cleanup testutil.Cleanup
+	defer cleanup.Recover()
+
+	tmpdir, err := os.MkdirTemp(".", "test-db-")
+	if err != nil {
+		panic(err)
+	}
+	cleanup.Add(func() { os.RemoveAll(tmpdir) })
+
+	db, err := New(Config{DataDir: tmpdir})
+	if err != nil {
+		panic(err)
+	}
+
+	// the database is closed before its files are removed
+	var closeDB testutil.Cleanup
+	closeDB.Add(func() { db.Close() })
+	cleanup.AppendFront(&closeDB)
+
+	return db, cleanup.Run
+}
@@ -0,0 +1,176 @@
+package sqlite
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationsFS has the SQL migrations of the tables, named by the version
+// they migrate to, e.g. "0002_create_users.sql". The table names in them
+// have the table prefix placeholder.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+const tablePrefixPlaceholder = "{{prefix}}"
+
+// Migration is a SQL migration of the tables.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations returns the SQL migrations, ordered by version.
+func Migrations() ([]*Migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]*Migration, 0, len(entries))
+
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+
+		i := strings.Index(name, "_")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid migration name: %s", entry.Name())
+		}
+
+		version, err := strconv.Atoi(name[:i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name: %s", entry.Name())
+		}
+
+		data, err := migrationsFS.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, &Migration{
+			Version: version,
+			Name:    name[i+1:],
+			SQL:     string(data),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("missing migration version: %d", i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+var createTableRe = regexp.MustCompile(`CREATE TABLE "` + regexp.QuoteMeta(tablePrefixPlaceholder) + `(\w+)"`)
+
+// Tables returns the names, without the prefix, of the tables created by the
+// migrations, sorted.
+func Tables() ([]string, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []string
+
+	for _, m := range migrations {
+		for _, match := range createTableRe.FindAllStringSubmatch(m.SQL, -1) {
+			tables = append(tables, match[1])
+		}
+	}
+
+	sort.Strings(tables)
+
+	return tables, nil
+}
+
+// Table returns the quoted name of the table having the prefix.
+func Table(prefix, name string) string {
+	return `"` + prefix + name + `"`
+}
+
+// Migrate applies the migrations, not applied yet, to the tables having the
+// prefix. Applied migrations are recorded in the "schema_migrations" table
+// of the prefix.
+func (db *DB) Migrate(prefix string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.migrated[prefix] {
+		return nil
+	}
+
+	if strings.ContainsAny(prefix, `"`+"\x00") {
+		return fmt.Errorf("invalid table prefix: %q", prefix)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return fmt.Errorf("migrate %q: %w", prefix, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("migrate %q: %w", prefix, err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	migrationsTable := Table(prefix, "schema_migrations")
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("migrate %q: %w", prefix, err)
+	}
+
+	var current int
+
+	err = tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM ` + migrationsTable).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("migrate %q: %w", prefix, err)
+	}
+
+	if current > len(migrations) {
+		return fmt.Errorf("migrate %q: database version %d is newer than %d", prefix, current, len(migrations))
+	}
+
+	for _, m := range migrations[current:] {
+		_, err = tx.Exec(strings.ReplaceAll(m.SQL, tablePrefixPlaceholder, prefix))
+		if err != nil {
+			return fmt.Errorf("migrate %q: %04d_%s: %w", prefix, m.Version, m.Name, err)
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO `+migrationsTable+` (version, name, applied_at) VALUES (?, ?, ?)`,
+			m.Version, m.Name, time.Now().UTC().Format(time.RFC3339),
+		)
+		if err != nil {
+			return fmt.Errorf("migrate %q: %04d_%s: %w", prefix, m.Version, m.Name, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("migrate %q: %w", prefix, err)
+	}
+
+	db.migrated[prefix] = true
+
+	return nil
+}
@@ -0,0 +1,4 @@
+CREATE TABLE "{{prefix}}meta" (
+	key TEXT NOT NULL PRIMARY KEY,
+	value TEXT NOT NULL
+);
@@ -0,0 +1,28 @@
+CREATE TABLE "{{prefix}}users" (
+	id TEXT NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL,
+	normalized_username TEXT NOT NULL,
+	has_password INTEGER NOT NULL,
+	confirmation_token TEXT,
+	recovery_token TEXT,
+	deletion_token TEXT,
+	data TEXT NOT NULL
+);
+
+CREATE INDEX "{{prefix}}users_email" ON "{{prefix}}users" (email);
+CREATE INDEX "{{prefix}}users_normalized_username" ON "{{prefix}}users" (normalized_username);
+CREATE INDEX "{{prefix}}users_confirmation_token" ON "{{prefix}}users" (confirmation_token);
+CREATE INDEX "{{prefix}}users_recovery_token" ON "{{prefix}}users" (recovery_token);
+CREATE INDEX "{{prefix}}users_deletion_token" ON "{{prefix}}users" (deletion_token);
+
+CREATE TABLE "{{prefix}}deleted_users" (
+	id TEXT NOT NULL PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE "{{prefix}}user_versions" (
+	user_id TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	data TEXT NOT NULL,
+	PRIMARY KEY (user_id, version)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE "{{prefix}}accounts" (
+	id TEXT NOT NULL PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE INDEX "{{prefix}}accounts_user_id" ON "{{prefix}}accounts" (user_id);
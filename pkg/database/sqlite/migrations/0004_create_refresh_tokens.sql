@@ -0,0 +1,9 @@
+CREATE TABLE "{{prefix}}refresh_tokens" (
+	id TEXT NOT NULL PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	token TEXT NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE INDEX "{{prefix}}refresh_tokens_user_id" ON "{{prefix}}refresh_tokens" (user_id, id);
+CREATE INDEX "{{prefix}}refresh_tokens_token" ON "{{prefix}}refresh_tokens" (token);
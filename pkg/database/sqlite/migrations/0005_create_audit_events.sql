@@ -0,0 +1,11 @@
+CREATE TABLE "{{prefix}}audit_events" (
+	id TEXT NOT NULL PRIMARY KEY,
+	action TEXT NOT NULL,
+	outcome TEXT NOT NULL,
+	actor_id TEXT NOT NULL,
+	subject_id TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE INDEX "{{prefix}}audit_events_subject_id" ON "{{prefix}}audit_events" (subject_id, id);
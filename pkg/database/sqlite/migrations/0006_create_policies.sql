@@ -0,0 +1,4 @@
+CREATE TABLE "{{prefix}}policies" (
+	id TEXT NOT NULL PRIMARY KEY,
+	data TEXT NOT NULL
+);
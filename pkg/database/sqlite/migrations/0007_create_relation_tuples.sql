@@ -0,0 +1,9 @@
+CREATE TABLE "{{prefix}}relation_tuples" (
+	id TEXT NOT NULL PRIMARY KEY,
+	namespace TEXT NOT NULL,
+	object TEXT NOT NULL,
+	relation TEXT NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE INDEX "{{prefix}}relation_tuples_namespace_object_relation" ON "{{prefix}}relation_tuples" (namespace, object, relation, id);
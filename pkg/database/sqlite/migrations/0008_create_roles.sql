@@ -0,0 +1,12 @@
+CREATE TABLE "{{prefix}}roles" (
+	id TEXT NOT NULL PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE "{{prefix}}user_roles" (
+	user_id TEXT NOT NULL,
+	role_id TEXT NOT NULL,
+	PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX "{{prefix}}user_roles_role_id" ON "{{prefix}}user_roles" (role_id);
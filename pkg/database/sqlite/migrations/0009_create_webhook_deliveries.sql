@@ -0,0 +1,8 @@
+CREATE TABLE "{{prefix}}webhook_deliveries" (
+	id TEXT NOT NULL PRIMARY KEY,
+	status TEXT NOT NULL,
+	next_attempt_at INTEGER NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE INDEX "{{prefix}}webhook_deliveries_status" ON "{{prefix}}webhook_deliveries" (status, id);
@@ -0,0 +1,23 @@
+CREATE TABLE "{{prefix}}organizations" (
+	id TEXT NOT NULL PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE "{{prefix}}organization_members" (
+	organization_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	data TEXT NOT NULL,
+	PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX "{{prefix}}organization_members_user_id" ON "{{prefix}}organization_members" (user_id, organization_id);
+
+CREATE TABLE "{{prefix}}invitations" (
+	id TEXT NOT NULL PRIMARY KEY,
+	organization_id TEXT NOT NULL,
+	token TEXT NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE INDEX "{{prefix}}invitations_organization_id" ON "{{prefix}}invitations" (organization_id, id);
+CREATE INDEX "{{prefix}}invitations_token" ON "{{prefix}}invitations" (token);
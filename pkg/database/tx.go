@@ -0,0 +1,70 @@
+package database
+
+import "context"
+
+// Transactor runs functions in transactions of the database.
+type Transactor interface {
+	// WithTx runs the function in a transaction, which is carried to the
+	// repositories in the context passed to the function. The transaction is
+	// committed if the function returns no error, and rolled back otherwise.
+	// A context already carrying a transaction joins it.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type nopTransactor struct{}
+
+// NopTransactor returns the transactor of the databases without
+// transactions, which runs the functions directly.
+func NopTransactor() Transactor {
+	return nopTransactor{}
+}
+
+func (nopTransactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Tx is a transaction of a database. The repositories of the database read
+// and write through the transaction carried in the context, and ignore the
+// transactions of other databases.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+type txContextKey struct{}
+
+// ContextWithTx returns a copy of the context carrying the transaction.
+func ContextWithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried in the context, or nil.
+func TxFromContext(ctx context.Context) Tx {
+	tx, _ := ctx.Value(txContextKey{}).(Tx)
+	return tx
+}
+
+// RunInTx runs the function in a new transaction started by begin, which is
+// carried in the context passed to the function. The transaction is
+// committed if the function returns no error, and rolled back otherwise.
+func RunInTx(ctx context.Context, begin func() (Tx, error), fn func(ctx context.Context) error) error {
+	tx, err := begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback() //nolint:errcheck
+			panic(p)
+		}
+	}()
+
+	err = fn(ContextWithTx(ctx, tx))
+	if err != nil {
+		tx.Rollback() //nolint:errcheck
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -3,49 +3,90 @@ package di
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"go.uber.org/fx"
 
 	"github.com/zbiljic/authzy/pkg/api"
 	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/backup"
 	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
 	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/role"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
 	"github.com/zbiljic/authzy/pkg/jwt"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
 
-var apifx = fx.Provide(APIHandlerProvider)
+var apifx = fx.Provide(
+	ProvideAPIService,
+	APIHandlerProvider,
+)
 
-type APIHandlerParams struct {
+type APIServiceParams struct {
 	fx.In
 
-	Lifecycle fx.Lifecycle
-
 	Log    logger.Logger
 	Config *config.Config
 
-	JWTService          jwt.Service
-	AccountUsecase      account.AccountUsecase
-	RefreshTokenUsecase refreshtoken.RefreshTokenUsecase
-	UserUsecase         user.UserUsecase
-}
+	JWTService           jwt.Service
+	AccountUsecase       account.AccountUsecase
+	AuditLogUsecase      auditlog.AuditLogUsecase
+	OrganizationUsecase  organization.OrganizationUsecase
+	PolicyUsecase        policy.PolicyUsecase
+	RefreshTokenUsecase  refreshtoken.RefreshTokenUsecase
+	RelationTupleUsecase relationtuple.RelationTupleUsecase
+	RoleUsecase          role.RoleUsecase
+	UserUsecase          user.UserUsecase
+	WebhookUsecase       webhook.WebhookUsecase
 
-type APIHandlerResult struct {
-	fx.Out
-
-	Router http.Handler `name:"api"`
+	BackupDatabase backup.Database
+	Transactor     database.Transactor
 }
 
-func APIHandlerProvider(p APIHandlerParams) (APIHandlerResult, error) {
-	apiService := api.New(
+func ProvideAPIService(p APIServiceParams) api.Service {
+	return api.New(
 		p.Log,
 		p.Config,
 		p.JWTService,
 		p.AccountUsecase,
+		p.AuditLogUsecase,
+		p.OrganizationUsecase,
+		p.PolicyUsecase,
 		p.RefreshTokenUsecase,
+		p.RelationTupleUsecase,
+		p.RoleUsecase,
 		p.UserUsecase,
+		p.WebhookUsecase,
+		p.BackupDatabase,
+		p.Transactor,
 	)
+}
+
+type APIHandlerParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+
+	Log        logger.Logger
+	Config     *config.Config
+	APIService api.Service
+}
+
+type APIHandlerResult struct {
+	fx.Out
+
+	Router http.Handler `name:"default_api"`
+}
+
+func APIHandlerProvider(p APIHandlerParams) (APIHandlerResult, error) {
+	apiService := p.APIService
 
 	p.Lifecycle.Append(fx.Hook{
 		OnStart: func(context.Context) error {
@@ -58,5 +99,59 @@ func APIHandlerProvider(p APIHandlerParams) (APIHandlerResult, error) {
 		},
 	})
 
+	if p.Config.API.DeletionGracePeriod > 0 {
+		registerDeletionPurge(p.Lifecycle, p.Log, apiService)
+	}
+
 	return APIHandlerResult{Router: apiService}, nil
 }
+
+// deletionPurgeInterval is the interval between the scans for the users whose
+// deletion grace period has expired.
+const deletionPurgeInterval = time.Hour
+
+// registerDeletionPurge periodically deletes the users whose deletion grace
+// period has expired, while the application is running.
+func registerDeletionPurge(lc fx.Lifecycle, log logger.Logger, apiService api.Service) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			log.Debug("starting user deletion purge")
+
+			go func() {
+				defer close(done)
+
+				ticker := time.NewTicker(deletionPurgeInterval)
+				defer ticker.Stop()
+
+				for {
+					if err := apiService.PurgeDeletedUsers(ctx); err != nil && ctx.Err() == nil {
+						log.Errorf("purge deleted users: %v", err)
+					}
+
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			log.Debug("stopping user deletion purge")
+
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
@@ -4,8 +4,13 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/zbiljic/authzy/pkg/config"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
 	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
 	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
 	"github.com/zbiljic/authzy/pkg/hash"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
@@ -16,10 +21,16 @@ var configfx = fx.Provide(
 	ProvideHTTPConfig,
 	ProvideHashersConfig,
 	ProvideHasherArgon2Config,
+	ProvideHasherFirebaseScryptConfig,
+	ProvideHasherSchedulerConfig,
+	ProvideHasherPepperConfig,
 	ProvideDatabaseConfig,
 	ProvideDatabaseConfigResult,
 	ProvideAPIConfig,
 	ProvideAPIJWTConfig,
+	ProvideAuthzConfig,
+	ProvideOrganizationsConfig,
+	ProvideWebhooksConfig,
 )
 
 func ProvideLoggerConfig(config *config.Config) *logger.Config {
@@ -42,6 +53,18 @@ func ProvideHasherArgon2Config(config *config.HashersConfig) *hash.HasherArgon2C
 	return config.Argon2
 }
 
+func ProvideHasherFirebaseScryptConfig(config *config.HashersConfig) *hash.HasherFirebaseScryptConfig {
+	return config.FirebaseScrypt
+}
+
+func ProvideHasherSchedulerConfig(config *config.HashersConfig) *hash.HasherSchedulerConfig {
+	return config.Scheduler
+}
+
+func ProvideHasherPepperConfig(config *config.HashersConfig) *hash.HasherPepperConfig {
+	return config.Pepper
+}
+
 func ProvideDatabaseConfig(config *config.Config) *config.DatabaseConfig {
 	return config.Database
 }
@@ -52,6 +75,8 @@ type DatabaseConfigResult struct {
 	Type              string `name:"db_type"`
 	JSONMutexDBConfig *database_jsonmutexdb.Config
 	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
 }
 
 func ProvideDatabaseConfigResult(config *config.DatabaseConfig) DatabaseConfigResult {
@@ -59,6 +84,8 @@ func ProvideDatabaseConfigResult(config *config.DatabaseConfig) DatabaseConfigRe
 		Type:              config.Type,
 		JSONMutexDBConfig: config.JSONMutexDB,
 		LevelDBConfig:     config.LevelDB,
+		SQLiteConfig:      config.SQLite,
+		BBoltConfig:       config.BBolt,
 	}
 }
 
@@ -69,3 +96,15 @@ func ProvideAPIConfig(config *config.Config) *config.APIConfig {
 func ProvideAPIJWTConfig(config *config.APIConfig) *config.JWTConfig {
 	return config.JWT
 }
+
+func ProvideAuthzConfig(config *config.Config) *relationtuple.Config {
+	return config.Authz
+}
+
+func ProvideOrganizationsConfig(config *config.Config) *organization.Config {
+	return config.Organizations
+}
+
+func ProvideWebhooksConfig(config *config.Config) *webhook.Config {
+	return config.Webhooks
+}
@@ -6,14 +6,27 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"go.uber.org/fx"
 
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/backup"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
 	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
 	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
 )
 
 var databasefx = fx.Provide(
 	ProvideDatabase,
 )
 
+var transactorfx = fx.Provide(
+	ProvideTransactor,
+)
+
+var backupfx = fx.Provide(
+	ProvideBackupDatabase,
+)
+
 type DatabaseParams struct {
 	fx.In
 
@@ -21,6 +34,8 @@ type DatabaseParams struct {
 
 	JSONMutexDBConfig *database_jsonmutexdb.Config
 	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
 }
 
 type DatabaseResult struct {
@@ -28,6 +43,8 @@ type DatabaseResult struct {
 
 	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
 	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
 }
 
 func ProvideDatabase(p DatabaseParams) (DatabaseResult, error) {
@@ -48,6 +65,20 @@ func ProvideDatabase(p DatabaseParams) (DatabaseResult, error) {
 		}
 		result.LevelDB = ldb
 		return result, nil
+	case database_sqlite.Type:
+		sdb, err := ProvideSQLiteDatabase(p.SQLiteConfig)
+		if err != nil {
+			return result, err
+		}
+		result.SQLite = sdb
+		return result, nil
+	case database_bbolt.Type:
+		bdb, err := ProvideBBoltDatabase(p.BBoltConfig)
+		if err != nil {
+			return result, err
+		}
+		result.BBolt = bdb
+		return result, nil
 	default:
 		return result, fmt.Errorf("invalid database type: %s", p.Type)
 	}
@@ -65,3 +96,124 @@ func ProvideJSONMutexDBLoadSaver(config *database_jsonmutexdb.Config) (*database
 func ProvideLevelDBDatabase(config *database_leveldb.Config) (*leveldb.DB, error) {
 	return database_leveldb.New(*config)
 }
+
+func ProvideSQLiteDatabase(config *database_sqlite.Config) (*database_sqlite.DB, error) {
+	return database_sqlite.New(*config)
+}
+
+func ProvideBBoltDatabase(config *database_bbolt.Config) (*database_bbolt.DB, error) {
+	return database_bbolt.New(*config)
+}
+
+type TransactorParams struct {
+	fx.In
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+// ProvideTransactor returns the transactor of the database, running functions
+// without a transaction when there is no database.
+func ProvideTransactor(p TransactorParams) database.Transactor {
+	switch {
+	case p.JSONLoadSaver != nil:
+		return database_jsonmutexdb.NewTransactor(*p.JSONLoadSaver)
+	case p.LevelDB != nil:
+		return database_leveldb.NewTransactor(p.LevelDB)
+	case p.SQLite != nil:
+		return database_sqlite.NewTransactor(p.SQLite)
+	case p.BBolt != nil:
+		return p.BBolt
+	}
+
+	return database.NopTransactor()
+}
+
+type BackupDatabaseParams struct {
+	fx.In
+
+	Type string `name:"db_type"`
+
+	// Config has the tenants sharing the database, if any.
+	Config *config.Config `optional:"true"`
+
+	JSONMutexDBConfig *database_jsonmutexdb.Config
+	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+// ProvideBackupDatabase returns the database to back up, limited to the
+// storage prefix of the configuration. The data of the tenants sharing the
+// database is left out, it is backed up by the tenants themselves.
+func ProvideBackupDatabase(p BackupDatabaseParams) (backup.Database, error) {
+	tenants, err := tenantDatabases(p.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var exclude []string
+
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		if p.JSONLoadSaver == nil {
+			return nil, fmt.Errorf("database not provided: %s", p.Type)
+		}
+		for _, t := range tenants {
+			exclude = append(exclude, t.JSONMutexDB.FilenamePrefix)
+		}
+		return backup.NewJSONMutexDB(*p.JSONLoadSaver, p.JSONMutexDBConfig.FilenamePrefix, exclude...), nil
+	case database_leveldb.Type:
+		if p.LevelDB == nil {
+			return nil, fmt.Errorf("database not provided: %s", p.Type)
+		}
+		for _, t := range tenants {
+			exclude = append(exclude, t.LevelDB.KeyPrefix)
+		}
+		return backup.NewLevelDB(p.LevelDB, p.LevelDBConfig.KeyPrefix, exclude...), nil
+	case database_sqlite.Type:
+		if p.SQLite == nil {
+			return nil, fmt.Errorf("database not provided: %s", p.Type)
+		}
+		// only the tables of the prefix are backed up, by their names
+		return backup.NewSQLite(p.SQLite, p.SQLiteConfig.TablePrefix), nil
+	case database_bbolt.Type:
+		if p.BBolt == nil {
+			return nil, fmt.Errorf("database not provided: %s", p.Type)
+		}
+		for _, t := range tenants {
+			exclude = append(exclude, t.BBolt.BucketPrefix)
+		}
+		return backup.NewBBolt(p.BBolt, p.BBoltConfig.BucketPrefix, exclude...), nil
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+// tenantDatabases returns the database configurations of the tenants of the
+// configuration.
+func tenantDatabases(conf *config.Config) ([]*config.DatabaseConfig, error) {
+	if conf == nil {
+		return nil, nil
+	}
+
+	result := make([]*config.DatabaseConfig, 0, len(conf.Tenants))
+
+	for _, t := range conf.Tenants {
+		tenantConf, err := conf.ForTenant(t)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, tenantConf.Database)
+	}
+
+	return result, nil
+}
@@ -4,20 +4,61 @@ import (
 	"go.uber.org/fx"
 
 	account "github.com/zbiljic/authzy/pkg/domain/account/di"
+	auditlog "github.com/zbiljic/authzy/pkg/domain/auditlog/di"
+	organization "github.com/zbiljic/authzy/pkg/domain/organization/di"
+	policy "github.com/zbiljic/authzy/pkg/domain/policy/di"
 	refreshtoken "github.com/zbiljic/authzy/pkg/domain/refreshtoken/di"
+	relationtuple "github.com/zbiljic/authzy/pkg/domain/relationtuple/di"
+	role "github.com/zbiljic/authzy/pkg/domain/role/di"
 	user "github.com/zbiljic/authzy/pkg/domain/user/di"
+	webhook "github.com/zbiljic/authzy/pkg/domain/webhook/di"
 )
 
 var Module = fx.Options(
 	configfx,
 	validatorfx,
 	hasherfx,
+	databasefx,
+	transactorfx,
+	schemafx,
 	debugfx,
 	serverfx,
+	backupfx,
+	domainfx,
+	jwtfx,
+	apifx,
+	tenantfx,
+)
+
+// DomainModule provides the database and the domain usecases without the API
+// and the servers, for commands operating directly on the datastore.
+var DomainModule = fx.Options(
+	configfx,
+	validatorfx,
+	hasherfx,
 	databasefx,
+	transactorfx,
+	schemafx,
+	domainfx,
+)
+
+// CommandModule provides the API service on top of the DomainModule, for
+// commands sharing its operations, without starting the servers.
+var CommandModule = fx.Options(
+	DomainModule,
+	backupfx,
+	jwtfx,
+	fx.Provide(ProvideAPIService),
+)
+
+var domainfx = fx.Options(
 	account.Module,
+	auditlog.Module,
+	organization.Module,
+	policy.Module,
 	refreshtoken.Module,
+	relationtuple.Module,
+	role.Module,
 	user.Module,
-	jwtfx,
-	apifx,
+	webhook.Module,
 )
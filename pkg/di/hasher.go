@@ -1,15 +1,92 @@
 package di
 
 import (
+	"expvar"
+
 	"go.uber.org/fx"
 
 	"github.com/zbiljic/authzy/pkg/hash"
 )
 
+var hasherStats = expvar.NewMap("hasher")
+
 var hasherfx = fx.Provide(
+	ProvideHasherRegistry,
+	ProvideHasherScheduler,
 	ProvideHasher,
 )
 
-func ProvideHasher(config *hash.HasherArgon2Config) hash.Hasher {
-	return hash.NewHasherArgon2(*config)
+type HasherRegistryParams struct {
+	fx.In
+
+	Argon2Config         *hash.HasherArgon2Config
+	FirebaseScryptConfig *hash.HasherFirebaseScryptConfig `optional:"true"`
+	PepperConfig         *hash.HasherPepperConfig         `optional:"true"`
+}
+
+func ProvideHasherRegistry(p HasherRegistryParams) (*hash.Registry, error) {
+	argon2 := hash.NewHasherArgon2(*p.Argon2Config)
+
+	var current hash.Hasher = argon2
+
+	verifiers := []hash.Hasher{
+		hash.NewHasherBcrypt(hash.BcryptDefaultCost),
+		hash.NewHasherScrypt(hash.HasherScryptConfig{
+			Cost:        hash.ScryptDefaultCost,
+			BlockSize:   hash.ScryptDefaultBlockSize,
+			Parallelism: hash.ScryptDefaultParallelism,
+			SaltLength:  hash.ScryptDefaultSaltLength,
+			KeyLength:   hash.ScryptDefaultKeyLength,
+		}),
+		hash.NewHasherPBKDF2(hash.HasherPBKDF2Config{
+			Iterations: hash.PBKDF2DefaultIterations,
+			SaltLength: hash.PBKDF2DefaultSaltLength,
+			KeyLength:  hash.PBKDF2DefaultKeyLength,
+		}),
+	}
+
+	if p.FirebaseScryptConfig != nil {
+		verifiers = append(verifiers, hash.NewHasherFirebaseScrypt(*p.FirebaseScryptConfig))
+	}
+
+	if p.PepperConfig.Enabled() {
+		pepper, err := hash.NewHasherPepper(argon2, *p.PepperConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		// hashes generated before the pepper was enabled are still verified,
+		// and peppered on the next login
+		current = pepper
+		verifiers = append([]hash.Hasher{argon2}, verifiers...)
+	}
+
+	return hash.NewRegistry(current, verifiers...), nil
+}
+
+type HasherSchedulerParams struct {
+	fx.In
+
+	Registry *hash.Registry
+	Config   *hash.HasherSchedulerConfig `optional:"true"`
+}
+
+func ProvideHasherScheduler(p HasherSchedulerParams) *hash.Scheduler {
+	var config hash.HasherSchedulerConfig
+	if p.Config != nil {
+		config = *p.Config
+	}
+
+	scheduler := hash.NewScheduler(p.Registry, config)
+
+	// exposed by the debug server at /debug/vars
+	hasherStats.Set("scheduler", expvar.Func(func() interface{} {
+		return scheduler.Stats()
+	}))
+
+	return scheduler
+}
+
+func ProvideHasher(scheduler *hash.Scheduler) hash.Hasher {
+	return scheduler
 }
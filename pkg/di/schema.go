@@ -0,0 +1,101 @@
+package di
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/config"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/database/schemamigrate"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// schemafx migrates the schema of the database, before the repositories read
+// it. It is invoked before anything else using the database.
+var schemafx = fx.Invoke(MigrateSchema)
+
+type SchemaParams struct {
+	fx.In
+
+	Log    logger.Logger
+	Config *config.DatabaseConfig
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+// MigrateSchema migrates the database to the current schema version, or
+// checks that it has it, as configured.
+func MigrateSchema(p SchemaParams) error {
+	ctx := context.Background()
+
+	store, err := NewSchemaStore(p.Config, DatabaseResult{
+		JSONLoadSaver: p.JSONLoadSaver,
+		LevelDB:       p.LevelDB,
+		SQLite:        p.SQLite,
+		BBolt:         p.BBolt,
+	})
+	if err != nil {
+		return err
+	}
+
+	m := &schemamigrate.Migrator{Store: store}
+
+	if !p.Config.MigrateSchema {
+		if err := m.Check(ctx); err != nil {
+			return fmt.Errorf("%w, run: authzy db migrate-schema", err)
+		}
+		return nil
+	}
+
+	result, err := m.Migrate(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate schema: %w", err)
+	}
+
+	if result.FromVersion != result.ToVersion {
+		p.Log.WithFields(logger.Fields{
+			"from_version": result.FromVersion,
+			"to_version":   result.ToVersion,
+		}).Info("schema migrated")
+	}
+
+	return nil
+}
+
+// NewSchemaStore returns the schema store of the configured database, limited
+// to the storage prefix of the configuration.
+func NewSchemaStore(dbConf *config.DatabaseConfig, db DatabaseResult) (schemamigrate.Store, error) {
+	switch dbConf.Type {
+	case database_jsonmutexdb.Type:
+		if db.JSONLoadSaver == nil {
+			return nil, fmt.Errorf("database not provided: %s", dbConf.Type)
+		}
+		return schemamigrate.NewJSONMutexDBStore(*db.JSONLoadSaver, dbConf.JSONMutexDB.FilenamePrefix), nil
+	case database_leveldb.Type:
+		if db.LevelDB == nil {
+			return nil, fmt.Errorf("database not provided: %s", dbConf.Type)
+		}
+		return schemamigrate.NewLevelDBStore(db.LevelDB, dbConf.LevelDB.KeyPrefix, schemamigrate.DefaultBatchSize), nil
+	case database_sqlite.Type:
+		if db.SQLite == nil {
+			return nil, fmt.Errorf("database not provided: %s", dbConf.Type)
+		}
+		return schemamigrate.NewSQLiteStore(db.SQLite, dbConf.SQLite.TablePrefix, schemamigrate.DefaultBatchSize), nil
+	case database_bbolt.Type:
+		if db.BBolt == nil {
+			return nil, fmt.Errorf("database not provided: %s", dbConf.Type)
+		}
+		return schemamigrate.NewBBoltStore(db.BBolt, dbConf.BBolt.BucketPrefix, schemamigrate.DefaultBatchSize), nil
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", dbConf.Type)
+	}
+}
@@ -0,0 +1,108 @@
+package di
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/config"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/hash"
+	"github.com/zbiljic/authzy/pkg/logger"
+	"github.com/zbiljic/authzy/pkg/tenant"
+)
+
+var tenantfx = fx.Provide(TenantRouterProvider)
+
+type TenantRouterParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+
+	Log    logger.Logger
+	Config *config.Config
+	Hasher hash.Hasher
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+
+	Router http.Handler `name:"default_api"`
+}
+
+type TenantRouterResult struct {
+	fx.Out
+
+	Router http.Handler `name:"api"`
+}
+
+// TenantRouterProvider builds the API of every configured tenant, and routes
+// requests to them. Tenants share the database and the hasher, everything
+// else is built from the tenant configuration.
+func TenantRouterProvider(p TenantRouterParams) (TenantRouterResult, error) {
+	if len(p.Config.Tenants) == 0 {
+		return TenantRouterResult{Router: p.Router}, nil
+	}
+
+	tenants := make([]*tenant.Tenant, 0, len(p.Config.Tenants))
+
+	for _, t := range p.Config.Tenants {
+		log := p.Log.WithFields(logger.Fields{"tenant": t.ID})
+
+		var result struct {
+			fx.In
+
+			Router http.Handler `name:"default_api"`
+		}
+
+		conf, err := p.Config.ForTenant(t)
+		if err != nil {
+			return TenantRouterResult{}, err
+		}
+
+		app := fx.New(
+			fx.Supply(conf),
+			fx.Logger(NewFxLogger(log)),
+			fx.Provide(func() logger.Logger { return log }),
+			fx.Provide(func() hash.Hasher { return p.Hasher }),
+			fx.Provide(func() DatabaseResult {
+				return DatabaseResult{
+					JSONLoadSaver: p.JSONLoadSaver,
+					LevelDB:       p.LevelDB,
+					SQLite:        p.SQLite,
+					BBolt:         p.BBolt,
+				}
+			}),
+			configfx,
+			transactorfx,
+			schemafx,
+			backupfx,
+			domainfx,
+			jwtfx,
+			apifx,
+			fx.Populate(&result),
+		)
+		if err := app.Err(); err != nil {
+			return TenantRouterResult{}, fmt.Errorf("tenant %s: %w", t.ID, err)
+		}
+
+		p.Lifecycle.Append(fx.Hook{
+			OnStart: app.Start,
+			OnStop:  app.Stop,
+		})
+
+		tenants = append(tenants, &tenant.Tenant{
+			ID:         t.ID,
+			Hosts:      t.Hosts,
+			PathPrefix: t.PathPrefix,
+			Handler:    result.Router,
+		})
+	}
+
+	return TenantRouterResult{Router: tenant.NewRouter(p.Router, tenants...)}, nil
+}
@@ -6,11 +6,15 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"go.uber.org/fx"
 
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
 	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
 	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
 	"github.com/zbiljic/authzy/pkg/domain/account"
+	account_bbolt "github.com/zbiljic/authzy/pkg/domain/account/storage/bbolt"
 	account_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/account/storage/jsonmutexdb"
 	account_leveldb "github.com/zbiljic/authzy/pkg/domain/account/storage/leveldb"
+	account_sqlite "github.com/zbiljic/authzy/pkg/domain/account/storage/sqlite"
 )
 
 var repositoresfx = fx.Provide(
@@ -24,9 +28,13 @@ type RepositoryParams struct {
 
 	JSONMutexDBConfig *database_jsonmutexdb.Config
 	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
 
 	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
 	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
 }
 
 func NewAccountRepository(p RepositoryParams) (account.AccountRepository, error) {
@@ -35,6 +43,10 @@ func NewAccountRepository(p RepositoryParams) (account.AccountRepository, error)
 		return NewJSONMutexDBAccountRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
 	case database_leveldb.Type:
 		return NewLevelDBAccountRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteAccountRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltAccountRepository(p.BBoltConfig, p.BBolt)
 	default:
 		return nil, fmt.Errorf("invalid database type: %s", p.Type)
 	}
@@ -59,3 +71,23 @@ func NewLevelDBAccountRepository(
 		config.KeyPrefix,
 	)
 }
+
+func NewSQLiteAccountRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (account.AccountRepository, error) {
+	return account_sqlite.NewAccountRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltAccountRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (account.AccountRepository, error) {
+	return account_bbolt.NewAccountRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
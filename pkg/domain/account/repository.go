@@ -6,6 +6,10 @@ type AccountRepository interface {
 	// Save saves a given entity.
 	Save(ctx context.Context, entity *Account) (*Account, error)
 
+	// SaveAll saves all given entities at once. Nothing is saved unless all
+	// entities are valid.
+	SaveAll(ctx context.Context, entities []*Account) ([]*Account, error)
+
 	// Find retrieves an entity.
 	Find(ctx context.Context, entity *Account) (*Account, error)
 
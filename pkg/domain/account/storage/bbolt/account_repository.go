@@ -0,0 +1,313 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/noop"
+)
+
+const (
+	accountsBucket = "accounts"
+)
+
+// bboltAccountRepository is a repository that uses bbolt database.
+type bboltAccountRepository struct {
+	noop.UnimplementedAccountRepository
+
+	db *database_bbolt.DB
+
+	accountsBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewAccountRepository returns a new bbolt repository.
+func NewAccountRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (account.AccountRepository, error) {
+	r := &bboltAccountRepository{
+		db:             db,
+		accountsBucket: []byte(bucketPrefix + accountsBucket),
+		validate:       validator.New(),
+	}
+
+	err := db.CreateBuckets(string(r.accountsBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns               = "account/storage/bbolt."
+	opSave           = ns + "Save"
+	opSaveAll        = ns + "SaveAll"
+	opFind           = ns + "Find"
+	opExists         = ns + "Exists"
+	opFindAll        = ns + "FindAll"
+	opCount          = ns + "Count"
+	opDelete         = ns + "Delete"
+	opFindAllForUser = ns + "FindAllForUser"
+)
+
+// prepare validates the entity and converts it for storage.
+func (r *bboltAccountRepository) prepare(entity *account.Account) (*schema.Account, error) {
+	inS := schema.AccountToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, err
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, err
+	}
+
+	return inS, nil
+}
+
+// put writes the account to the bucket.
+func (r *bboltAccountRepository) put(b *bolt.Bucket, inS *schema.Account) error {
+	value, err := transformer.MarshalAccount(inS)
+	if err != nil {
+		return err
+	}
+
+	return b.Put([]byte(transformer.MarshalAccountID(inS)), value)
+}
+
+func (r *bboltAccountRepository) Save(ctx context.Context, entity *account.Account) (*account.Account, error) {
+	inS, err := r.prepare(entity)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return r.put(tx.Bucket(r.accountsBucket), inS)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, transformer.MarshalAccountID(inS), err)
+	}
+
+	savedEntity := schema.AccountFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltAccountRepository) SaveAll(ctx context.Context, entities []*account.Account) ([]*account.Account, error) {
+	inSs := make([]*schema.Account, 0, len(entities))
+
+	for _, entity := range entities {
+		inS, err := r.prepare(entity)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		inSs = append(inSs, inS)
+	}
+
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(r.accountsBucket)
+
+		for _, inS := range inSs {
+			err := r.put(b, inS)
+			if err != nil {
+				return fmt.Errorf("%s: %w", transformer.MarshalAccountID(inS), err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+	}
+
+	savedEntities := make([]*account.Account, 0, len(inSs))
+	for _, inS := range inSs {
+		savedEntities = append(savedEntities, schema.AccountFromSchema(inS))
+	}
+
+	return savedEntities, nil
+}
+
+func (r *bboltAccountRepository) Find(ctx context.Context, entity *account.Account) (*account.Account, error) {
+	inS := schema.AccountToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opFind, err)
+	}
+
+	id := transformer.MarshalAccountID(inS)
+
+	var ts *schema.Account
+
+	err = r.db.View(ctx, func(tx *bolt.Tx) error {
+		value := tx.Bucket(r.accountsBucket).Get([]byte(id))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = transformer.UnmarshalAccount(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFind, id, err)
+	}
+
+	dbEntity := schema.AccountFromSchema(ts)
+
+	return dbEntity, nil
+}
+
+func (r *bboltAccountRepository) Exists(ctx context.Context, entity *account.Account) (bool, error) {
+	inS := schema.AccountToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opExists, err)
+	}
+
+	id := transformer.MarshalAccountID(inS)
+
+	var has bool
+
+	err = r.db.View(ctx, func(tx *bolt.Tx) error {
+		has = tx.Bucket(r.accountsBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExists, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *bboltAccountRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*account.Account, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*account.Account
+		nextCursor string
+	)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.accountsBucket).Cursor()
+
+		k, v := c.First()
+		if afterCursor != "" {
+			k, v = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < limit; k, v = c.Next() {
+			ts, err := transformer.UnmarshalAccount(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.AccountFromSchema(ts))
+
+			if len(result) == limit {
+				nextCursor = string(k)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltAccountRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.accountsBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *bboltAccountRepository) Delete(ctx context.Context, entity *account.Account) error {
+	inS := schema.AccountToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDelete, err)
+	}
+
+	id := transformer.MarshalAccountID(inS)
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(r.accountsBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDelete, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltAccountRepository) FindAllForUser(ctx context.Context, userID string) ([]*account.Account, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	var (
+		result []*account.Account
+	)
+
+	prefix := []byte(userID + "/")
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.accountsBucket).Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			ts, err := transformer.UnmarshalAccount(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.AccountFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	return result, nil
+}
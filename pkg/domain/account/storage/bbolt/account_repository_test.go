@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/test"
+)
+
+func TestBBoltAccountRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (account.AccountRepository, func()) {
+		return func(t *testing.T) (account.AccountRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewAccountRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
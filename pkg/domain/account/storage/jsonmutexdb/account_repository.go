@@ -69,6 +69,16 @@ func (r *jsonMutexDBAccountRepository) load() error {
 	return nil
 }
 
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBAccountRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Account)
+
+	return r.load()
+}
+
 func (r *jsonMutexDBAccountRepository) commit(ctx context.Context) error {
 	if r.loadSaver != nil {
 		out, err := json.Marshal(r.db)
@@ -76,7 +86,7 @@ func (r *jsonMutexDBAccountRepository) commit(ctx context.Context) error {
 			return err
 		}
 
-		return r.loadSaver.Save(r.filename, out)
+		return jsonmutexdb.Save(ctx, r.loadSaver, r.filename, out, r.reload)
 	}
 	return nil
 }
@@ -84,6 +94,7 @@ func (r *jsonMutexDBAccountRepository) commit(ctx context.Context) error {
 const (
 	ns               = "account/storage/jsonmutexdb."
 	opSave           = ns + "Save"
+	opSaveAll        = ns + "SaveAll"
 	opFind           = ns + "Find"
 	opExists         = ns + "Exists"
 	opDelete         = ns + "Delete"
@@ -91,6 +102,7 @@ const (
 )
 
 func (r *jsonMutexDBAccountRepository) Save(ctx context.Context, entity *account.Account) (*account.Account, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -121,6 +133,47 @@ func (r *jsonMutexDBAccountRepository) Save(ctx context.Context, entity *account
 	return savedEntity, nil
 }
 
+func (r *jsonMutexDBAccountRepository) SaveAll(ctx context.Context, entities []*account.Account) ([]*account.Account, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inSs := make([]*schema.Account, 0, len(entities))
+
+	// nothing is stored unless all entities are valid
+	for _, entity := range entities {
+		inS := schema.AccountToSchema(entity)
+
+		err := r.validate.Struct(inS)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		// before save
+		err = inS.BeforeSave()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		inSs = append(inSs, inS)
+	}
+
+	savedEntities := make([]*account.Account, 0, len(inSs))
+
+	for _, inS := range inSs {
+		r.db[transformer.MarshalAccountID(inS)] = *inS
+
+		savedEntities = append(savedEntities, schema.AccountFromSchema(inS))
+	}
+
+	err := r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+	}
+
+	return savedEntities, nil
+}
+
 func (r *jsonMutexDBAccountRepository) Find(ctx context.Context, entity *account.Account) (*account.Account, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -227,6 +280,7 @@ func (r *jsonMutexDBAccountRepository) Count(ctx context.Context) (int, error) {
 }
 
 func (r *jsonMutexDBAccountRepository) Delete(ctx context.Context, entity *account.Account) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -241,10 +295,16 @@ func (r *jsonMutexDBAccountRepository) Delete(ctx context.Context, entity *accou
 
 	delete(r.db, id)
 
+	err = r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDelete, err)
+	}
+
 	return nil
 }
 
 func (r *jsonMutexDBAccountRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -11,6 +11,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
 	"github.com/zbiljic/authzy/pkg/domain/account"
 	"github.com/zbiljic/authzy/pkg/domain/account/storage/json/schema"
 	"github.com/zbiljic/authzy/pkg/domain/account/storage/json/transformer"
@@ -50,6 +51,7 @@ func NewAccountRepository(
 const (
 	ns               = "account/storage/leveldb."
 	opSave           = ns + "Save"
+	opSaveAll        = ns + "SaveAll"
 	opFind           = ns + "Find"
 	opExists         = ns + "Exists"
 	opFindAll        = ns + "FindAll"
@@ -83,7 +85,7 @@ func (r *levelDBAccountRepository) Save(ctx context.Context, entity *account.Acc
 		return nil, fmt.Errorf("%s(%s): %w", opSave, id, err)
 	}
 
-	err = r.db.Put([]byte(key), value, nil)
+	err = database_leveldb.From(ctx, r.db).Put([]byte(key), value, nil)
 	if err != nil {
 		return nil, fmt.Errorf("%s(%s): %w", opSave, id, err)
 	}
@@ -93,6 +95,49 @@ func (r *levelDBAccountRepository) Save(ctx context.Context, entity *account.Acc
 	return savedEntity, nil
 }
 
+func (r *levelDBAccountRepository) SaveAll(ctx context.Context, entities []*account.Account) ([]*account.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	savedEntities := make([]*account.Account, 0, len(entities))
+
+	for _, entity := range entities {
+		inS := schema.AccountToSchema(entity)
+
+		err := r.validate.Struct(inS)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		// before save
+		err = inS.BeforeSave()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		id := transformer.MarshalAccountID(inS)
+		key := transformer.MarshalAccountKey(r.accountsKeyspace, id)
+
+		value, err := transformer.MarshalAccount(inS)
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", opSaveAll, id, err)
+		}
+
+		batch.Put([]byte(key), value)
+
+		savedEntities = append(savedEntities, schema.AccountFromSchema(inS))
+	}
+
+	err := database_leveldb.From(ctx, r.db).Write(batch, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+	}
+
+	return savedEntities, nil
+}
+
 func (r *levelDBAccountRepository) Find(ctx context.Context, entity *account.Account) (*account.Account, error) {
 	inS := schema.AccountToSchema(entity)
 
@@ -104,7 +149,7 @@ func (r *levelDBAccountRepository) Find(ctx context.Context, entity *account.Acc
 	id := transformer.MarshalAccountID(inS)
 	key := transformer.MarshalAccountKey(r.accountsKeyspace, id)
 
-	value, err := r.db.Get([]byte(key), nil)
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
 	if err != nil {
 		if errors.Is(err, leveldb.ErrNotFound) {
 			return nil, fmt.Errorf("%s(%s): %w", opFind, id, database.ErrNotFound)
@@ -134,7 +179,7 @@ func (r *levelDBAccountRepository) Exists(ctx context.Context, entity *account.A
 	id := transformer.MarshalAccountID(inS)
 	key := transformer.MarshalAccountKey(r.accountsKeyspace, id)
 
-	has, err := r.db.Has([]byte(key), nil)
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
 	if err != nil {
 		return false, fmt.Errorf("%s(%s): %w", opExists, id, err)
 	}
@@ -154,7 +199,7 @@ func (r *levelDBAccountRepository) FindAll(ctx context.Context, afterCursor stri
 		nextCursor   string
 	)
 
-	iter := r.db.NewIterator(util.BytesPrefix([]byte(r.accountsKeyspace)), nil)
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.accountsKeyspace+"/")), nil)
 	defer iter.Release()
 
 	if afterCursor != "" {
@@ -205,7 +250,7 @@ func (r *levelDBAccountRepository) Count(ctx context.Context) (int, error) {
 		ctxCheckOffset int
 	)
 
-	iter := r.db.NewIterator(util.BytesPrefix([]byte(r.accountsKeyspace)), nil)
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.accountsKeyspace+"/")), nil)
 	defer iter.Release()
 
 	for iter.Next() {
@@ -244,7 +289,7 @@ func (r *levelDBAccountRepository) Delete(ctx context.Context, entity *account.A
 	id := transformer.MarshalAccountID(inS)
 	key := transformer.MarshalAccountKey(r.accountsKeyspace, id)
 
-	has, err := r.db.Has([]byte(key), nil)
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
 	if err != nil {
 		return fmt.Errorf("%s(%s): %w", opDelete, key, err)
 	}
@@ -253,7 +298,7 @@ func (r *levelDBAccountRepository) Delete(ctx context.Context, entity *account.A
 		return nil
 	}
 
-	err = r.db.Delete([]byte(key), nil)
+	err = database_leveldb.From(ctx, r.db).Delete([]byte(key), nil)
 	if err != nil {
 		return fmt.Errorf("%s: %w", opDelete, err)
 	}
@@ -272,7 +317,7 @@ func (r *levelDBAccountRepository) FindAllForUser(ctx context.Context, userID st
 
 	keyPrefix := transformer.MarshalAccountKey(r.accountsKeyspace, userID)
 
-	iter := r.db.NewIterator(util.BytesPrefix([]byte(keyPrefix)), nil)
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(keyPrefix)), nil)
 	defer iter.Release()
 
 	for iter.Next() {
@@ -16,6 +16,10 @@ func (*UnimplementedAccountRepository) Save(ctx context.Context, entity *account
 	panic("Save not implemented")
 }
 
+func (*UnimplementedAccountRepository) SaveAll(ctx context.Context, entities []*account.Account) ([]*account.Account, error) {
+	panic("SaveAll not implemented")
+}
+
 func (*UnimplementedAccountRepository) Find(ctx context.Context, entity *account.Account) (*account.Account, error) {
 	panic("Find not implemented")
 }
@@ -0,0 +1,309 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/noop"
+)
+
+const (
+	accountsTable = "accounts"
+)
+
+// sqliteAccountRepository is a repository that uses SQLite database.
+type sqliteAccountRepository struct {
+	noop.UnimplementedAccountRepository
+
+	db *database_sqlite.DB
+
+	accountsTable string
+
+	validate *validator.Validate
+}
+
+// NewAccountRepository returns a new SQLite repository.
+func NewAccountRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (account.AccountRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sqliteAccountRepository{
+		db:            db,
+		accountsTable: database_sqlite.Table(tablePrefix, accountsTable),
+		validate:      validator.New(),
+	}
+
+	return r, nil
+}
+
+const (
+	ns               = "account/storage/sqlite."
+	opSave           = ns + "Save"
+	opSaveAll        = ns + "SaveAll"
+	opFind           = ns + "Find"
+	opExists         = ns + "Exists"
+	opFindAll        = ns + "FindAll"
+	opCount          = ns + "Count"
+	opDelete         = ns + "Delete"
+	opFindAllForUser = ns + "FindAllForUser"
+)
+
+// prepare validates the entity and converts it for storage.
+func (r *sqliteAccountRepository) prepare(entity *account.Account) (*schema.Account, error) {
+	inS := schema.AccountToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, err
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, err
+	}
+
+	return inS, nil
+}
+
+// put writes the account, with the columns it is looked up by.
+func (r *sqliteAccountRepository) put(ctx context.Context, q database_sqlite.Querier, id string, inS *schema.Account) error {
+	value, err := transformer.MarshalAccount(inS)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.accountsTable+` (id, user_id, data) VALUES (?, ?, ?)`,
+		id, inS.UserID, string(value),
+	)
+
+	return err
+}
+
+func (r *sqliteAccountRepository) Save(ctx context.Context, entity *account.Account) (*account.Account, error) {
+	inS, err := r.prepare(entity)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	id := transformer.MarshalAccountID(inS)
+
+	err = r.put(ctx, r.db, id, inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, id, err)
+	}
+
+	savedEntity := schema.AccountFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *sqliteAccountRepository) SaveAll(ctx context.Context, entities []*account.Account) ([]*account.Account, error) {
+	inSs := make([]*schema.Account, 0, len(entities))
+
+	for _, entity := range entities {
+		inS, err := r.prepare(entity)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		inSs = append(inSs, inS)
+	}
+
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, inS := range inSs {
+			id := transformer.MarshalAccountID(inS)
+
+			err := r.put(ctx, tx, id, inS)
+			if err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+	}
+
+	savedEntities := make([]*account.Account, 0, len(inSs))
+	for _, inS := range inSs {
+		savedEntities = append(savedEntities, schema.AccountFromSchema(inS))
+	}
+
+	return savedEntities, nil
+}
+
+func (r *sqliteAccountRepository) Find(ctx context.Context, entity *account.Account) (*account.Account, error) {
+	inS := schema.AccountToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opFind, err)
+	}
+
+	id := transformer.MarshalAccountID(inS)
+
+	var value string
+
+	err = r.db.QueryRowContext(ctx, `SELECT data FROM `+r.accountsTable+` WHERE id = ?`, id).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s(%s): %w", opFind, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFind, id, err)
+	}
+
+	ts, err := transformer.UnmarshalAccount([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFind, id, err)
+	}
+
+	dbEntity := schema.AccountFromSchema(ts)
+
+	return dbEntity, nil
+}
+
+func (r *sqliteAccountRepository) Exists(ctx context.Context, entity *account.Account) (bool, error) {
+	inS := schema.AccountToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opExists, err)
+	}
+
+	id := transformer.MarshalAccountID(inS)
+
+	var has bool
+
+	err = r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+r.accountsTable+` WHERE id = ?)`,
+		id,
+	).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExists, id, err)
+	}
+
+	return has, nil
+}
+
+// findAll returns the accounts selected by the query, which selects the id
+// and the data.
+func (r *sqliteAccountRepository) findAll(ctx context.Context, query string, args ...interface{}) ([]*account.Account, string, error) {
+	var (
+		result       []*account.Account
+		lastResultID string
+	)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&lastResultID, &value)
+		if err != nil {
+			return nil, "", err
+		}
+
+		ts, err := transformer.UnmarshalAccount([]byte(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", lastResultID, err)
+		}
+
+		result = append(result, schema.AccountFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return result, lastResultID, nil
+}
+
+func (r *sqliteAccountRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*account.Account, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var nextCursor string
+
+	result, lastResultID, err := r.findAll(ctx,
+		`SELECT id, data FROM `+r.accountsTable+` WHERE id > ? ORDER BY id LIMIT ?`,
+		afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = lastResultID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteAccountRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.accountsTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqliteAccountRepository) Delete(ctx context.Context, entity *account.Account) error {
+	inS := schema.AccountToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDelete, err)
+	}
+
+	id := transformer.MarshalAccountID(inS)
+
+	_, err = r.db.ExecContext(ctx, `DELETE FROM `+r.accountsTable+` WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDelete, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteAccountRepository) FindAllForUser(ctx context.Context, userID string) ([]*account.Account, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	result, _, err := r.findAll(ctx,
+		`SELECT id, data FROM `+r.accountsTable+` WHERE user_id = ? ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	return result, nil
+}
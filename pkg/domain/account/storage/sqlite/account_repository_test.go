@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/account/storage/test"
+)
+
+func TestSQLiteAccountRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (account.AccountRepository, func()) {
+		return func(t *testing.T) (account.AccountRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewAccountRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -68,6 +68,12 @@ func Run(t *testing.T, f func() func(t *testing.T) (account.AccountRepository, f
 
 		testAccountRepositorySave(t, repo)
 	})
+	t.Run("SaveAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testAccountRepositorySaveAll(t, repo)
+	})
 	t.Run("Find", func(t *testing.T) {
 		repo, cleanup := f()(t)
 		defer cleanup()
@@ -141,6 +147,49 @@ func testAccountRepositorySave(t *testing.T, repo account.AccountRepository) {
 	})
 }
 
+func testAccountRepositorySaveAll(t *testing.T, repo account.AccountRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("invalid", func(t *testing.T) {
+		entities := []*account.Account{
+			{UserID: "0", Provider: account.ProviderTypePassword, FederatedID: "0"},
+			{},
+		}
+
+		_, err := repo.SaveAll(ctx, entities)
+		assert.Error(t, err)
+
+		count, err := repo.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		var entities []*account.Account
+		for i := 0; i < 3; i++ {
+			entities = append(entities, &account.Account{
+				UserID:      strconv.Itoa(i),
+				Provider:    account.ProviderTypePassword,
+				FederatedID: strconv.Itoa(i),
+			})
+		}
+
+		savedEntities, err := repo.SaveAll(ctx, entities)
+		require.NoError(t, err)
+		require.Len(t, savedEntities, len(entities))
+
+		for i, entity := range entities {
+			assertAccountEqual(t, entity, savedEntities[i])
+
+			dbEntity, err := repo.Find(ctx, entity)
+			require.NoError(t, err)
+			assertAccountEqual(t, entity, dbEntity)
+		}
+	})
+}
+
 func testAccountRepositoryFind(t *testing.T, repo account.AccountRepository) {
 	t.Helper()
 
@@ -9,6 +9,12 @@ type AccountUsecase interface {
 	// UpdateAccount updates existing account.
 	UpdateAccount(context.Context, *Account) (*Account, error)
 
+	// ImportAccounts saves the accounts at once.
+	ImportAccounts(context.Context, []*Account) ([]*Account, error)
+
 	// FindAllForUser retrieves all accounts for specified user ID.
 	FindAllForUser(ctx context.Context, userID string) ([]*Account, error)
+
+	// DeleteAllForUser deletes all accounts for specified user ID.
+	DeleteAllForUser(ctx context.Context, userID string) error
 }
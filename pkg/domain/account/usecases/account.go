@@ -35,6 +35,31 @@ func (uc *accountUsecase) UpdateAccount(ctx context.Context, account *account.Ac
 	return uc.repository.Save(ctx, account)
 }
 
+func (uc *accountUsecase) ImportAccounts(ctx context.Context, accounts []*account.Account) ([]*account.Account, error) {
+	for _, account := range accounts {
+		if err := account.Provider.IsValid(); err != nil {
+			return nil, err
+		}
+	}
+	return uc.repository.SaveAll(ctx, accounts)
+}
+
 func (uc *accountUsecase) FindAllForUser(ctx context.Context, userID string) ([]*account.Account, error) {
 	return uc.repository.FindAllForUser(ctx, userID)
 }
+
+func (uc *accountUsecase) DeleteAllForUser(ctx context.Context, userID string) error {
+	accounts, err := uc.repository.FindAllForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		err = uc.repository.Delete(ctx, account)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -20,6 +20,14 @@ func (*noopAccountUsecase) UpdateAccount(ctx context.Context, account *account.A
 	panic("UpdateAccount not implemented")
 }
 
+func (*noopAccountUsecase) ImportAccounts(ctx context.Context, accounts []*account.Account) ([]*account.Account, error) {
+	panic("ImportAccounts not implemented")
+}
+
 func (*noopAccountUsecase) FindAllForUser(ctx context.Context, userID string) ([]*account.Account, error) {
 	panic("FindAllForUser not implemented")
 }
+
+func (*noopAccountUsecase) DeleteAllForUser(ctx context.Context, userID string) error {
+	panic("DeleteAllForUser not implemented")
+}
@@ -0,0 +1,98 @@
+package di
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	auditlog_bbolt "github.com/zbiljic/authzy/pkg/domain/auditlog/storage/bbolt"
+	auditlog_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/auditlog/storage/jsonmutexdb"
+	auditlog_leveldb "github.com/zbiljic/authzy/pkg/domain/auditlog/storage/leveldb"
+	auditlog_sqlite "github.com/zbiljic/authzy/pkg/domain/auditlog/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+var repositoresfx = fx.Provide(
+	NewAuditLogRepository,
+)
+
+type RepositoryParams struct {
+	fx.In
+
+	Type string `name:"db_type"`
+
+	Log logger.Logger `optional:"true"`
+
+	JSONMutexDBConfig *database_jsonmutexdb.Config
+	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+func NewAuditLogRepository(p RepositoryParams) (auditlog.AuditLogRepository, error) {
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		return NewJSONMutexDBAuditLogRepository(p.Log, p.JSONMutexDBConfig, p.JSONLoadSaver)
+	case database_leveldb.Type:
+		return NewLevelDBAuditLogRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteAuditLogRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltAuditLogRepository(p.BBoltConfig, p.BBolt)
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+func NewJSONMutexDBAuditLogRepository(
+	log logger.Logger,
+	config *database_jsonmutexdb.Config,
+	ls *database_jsonmutexdb.LoadSaver,
+) (auditlog.AuditLogRepository, error) {
+	return auditlog_jsonmutexdb.NewAuditLogRepository(
+		log,
+		*ls,
+		config.FilenamePrefix,
+	)
+}
+
+func NewLevelDBAuditLogRepository(
+	config *database_leveldb.Config,
+	db *leveldb.DB,
+) (auditlog.AuditLogRepository, error) {
+	return auditlog_leveldb.NewAuditLogRepository(
+		db,
+		config.KeyPrefix,
+	)
+}
+
+func NewSQLiteAuditLogRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (auditlog.AuditLogRepository, error) {
+	return auditlog_sqlite.NewAuditLogRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltAuditLogRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (auditlog.AuditLogRepository, error) {
+	return auditlog_bbolt.NewAuditLogRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
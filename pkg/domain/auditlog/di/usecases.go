@@ -0,0 +1,21 @@
+package di
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/usecases"
+)
+
+var usecasesfx = fx.Provide(
+	NewAuditLogUsecase,
+)
+
+func NewAuditLogUsecase(
+	repository auditlog.AuditLogRepository,
+) auditlog.AuditLogUsecase {
+	uc := usecases.NewAuditLogUsecase(
+		repository,
+	)
+	return uc
+}
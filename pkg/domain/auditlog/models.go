@@ -0,0 +1,98 @@
+package auditlog
+
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/jsonmap"
+)
+
+// Action is the security-relevant action recorded by the event.
+type Action string
+
+const (
+	ActionSignup         Action = "signup"
+	ActionLogin          Action = "login"
+	ActionTokenRefresh   Action = "token_refresh"
+	ActionTokenRevoke    Action = "token_revoke"
+	ActionPasswordChange Action = "password_change"
+	ActionEmailChange    Action = "email_change"
+	ActionLogout         Action = "logout"
+	ActionAccountBlock   Action = "account_block"
+	ActionAccountUnblock Action = "account_unblock"
+	ActionAccountDelete  Action = "account_delete"
+	ActionAccountRestore Action = "account_restore"
+)
+
+// Outcome tells whether the action succeeded.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Event is an entry of the audit log. Events are append-only, and their IDs
+// are ordered by the time they were recorded.
+type Event struct {
+	ID      string
+	Action  Action
+	Outcome Outcome
+	// ActorID is the ID of the user who performed the action, if known.
+	ActorID string
+	// SubjectID is the ID of the user the action was performed on, if known.
+	SubjectID string
+	IP        string
+	UserAgent string
+	RequestID string
+	// Reason describes why the action failed.
+	Reason   string
+	MetaData jsonmap.JSONMap
+
+	CreatedAt time.Time
+}
+
+// Filter selects the events to return. Empty fields match all events.
+type Filter struct {
+	ActorID   string
+	SubjectID string
+	Actions   []Action
+	Outcome   Outcome
+	Since     time.Time
+	Until     time.Time
+}
+
+// Match returns whether the event matches the filter.
+func (f *Filter) Match(e *Event) bool {
+	if f == nil {
+		return true
+	}
+	if f.ActorID != "" && f.ActorID != e.ActorID {
+		return false
+	}
+	if f.SubjectID != "" && f.SubjectID != e.SubjectID {
+		return false
+	}
+	if len(f.Actions) > 0 {
+		var found bool
+		for _, a := range f.Actions {
+			if a == e.Action {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Outcome != "" && f.Outcome != e.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && e.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.CreatedAt.Before(f.Until) {
+		return false
+	}
+
+	return true
+}
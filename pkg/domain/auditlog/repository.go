@@ -0,0 +1,20 @@
+package auditlog
+
+import "context"
+
+type AuditLogRepository interface {
+	// Append saves a new entity. Existing entities are never updated.
+	Append(ctx context.Context, entity *Event) (*Event, error)
+
+	// FindByID retrieves an entity by its id.
+	FindByID(ctx context.Context, id string) (*Event, error)
+
+	// FindAll returns the entities matching the filter, newest first.
+	FindAll(ctx context.Context, filter *Filter, afterCursor string, limit int) ([]*Event, string, error)
+
+	// Count returns the number of entities available.
+	Count(ctx context.Context) (int, error)
+
+	// DeleteAll deletes all entities managed by the repository.
+	DeleteAll(ctx context.Context) error
+}
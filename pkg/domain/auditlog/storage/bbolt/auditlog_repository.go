@@ -0,0 +1,301 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/oklog/ulid"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/noop"
+)
+
+const (
+	auditEventsBucket             = "audit_events"
+	auditEventsSubjectIndexBucket = "index_audit_events_subject_id"
+)
+
+// bboltAuditLogRepository is a repository that uses bbolt database.
+type bboltAuditLogRepository struct {
+	noop.UnimplementedAuditLogRepository
+
+	db *database_bbolt.DB
+
+	auditEventsBucket             []byte
+	auditEventsSubjectIndexBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewAuditLogRepository returns a new bbolt repository.
+func NewAuditLogRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (auditlog.AuditLogRepository, error) {
+	r := &bboltAuditLogRepository{
+		db:                            db,
+		auditEventsBucket:             []byte(bucketPrefix + auditEventsBucket),
+		auditEventsSubjectIndexBucket: []byte(bucketPrefix + auditEventsSubjectIndexBucket),
+		validate:                      validator.New(),
+	}
+
+	err := db.CreateBuckets(
+		string(r.auditEventsBucket),
+		string(r.auditEventsSubjectIndexBucket),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns          = "auditlog/storage/bbolt."
+	opAppend    = ns + "Append"
+	opFindByID  = ns + "FindByID"
+	opFindAll   = ns + "FindAll"
+	opCount     = ns + "Count"
+	opDeleteAll = ns + "DeleteAll"
+)
+
+// subjectKeyPrefix returns the prefix of the keys of the events of the
+// subject in the subject index.
+func subjectKeyPrefix(subjectID string) string {
+	return subjectID + "/"
+}
+
+func (r *bboltAuditLogRepository) Append(ctx context.Context, entity *auditlog.Event) (*auditlog.Event, error) {
+	inS := schema.EventToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	value, err := transformer.MarshalEvent(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(r.auditEventsBucket)
+
+		if b.Get([]byte(inS.ID)) != nil {
+			return database.ErrAlreadyExists
+		}
+
+		err := b.Put([]byte(inS.ID), value)
+		if err != nil {
+			return err
+		}
+
+		if inS.SubjectID == "" {
+			return nil
+		}
+
+		subjectKey := subjectKeyPrefix(inS.SubjectID) + inS.ID
+
+		return tx.Bucket(r.auditEventsSubjectIndexBucket).Put([]byte(subjectKey), []byte(inS.ID))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, err)
+	}
+
+	savedEntity := schema.EventFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltAuditLogRepository) FindByID(ctx context.Context, id string) (*auditlog.Event, error) {
+	var es *schema.Event
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		value := tx.Bucket(r.auditEventsBucket).Get([]byte(id))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		es, err = transformer.UnmarshalEvent(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.EventFromSchema(es)
+
+	return entity, nil
+}
+
+// eventIDBound returns the lowest event ID which could have been recorded at
+// the given time.
+func eventIDBound(t time.Time) string {
+	var id ulid.ULID
+
+	_ = id.SetTime(ulid.Timestamp(t))
+
+	return id.String()
+}
+
+// findAllRange returns the bucket and the range of keys holding the events
+// which could match the filter, and were recorded before the cursor. The
+// range starts at the lower key, and ends before the upper key.
+func (r *bboltAuditLogRepository) findAllRange(filter *auditlog.Filter, afterCursor string) (bucket []byte, prefix, lower, upper string) {
+	bucket = r.auditEventsBucket
+	if filter != nil && filter.SubjectID != "" {
+		bucket = r.auditEventsSubjectIndexBucket
+		prefix = subjectKeyPrefix(filter.SubjectID)
+	}
+
+	lower = prefix
+	if filter != nil && !filter.Since.IsZero() {
+		lower = prefix + eventIDBound(filter.Since)
+	}
+
+	bound := afterCursor
+	if filter != nil && !filter.Until.IsZero() {
+		// IDs have millisecond precision
+		until := eventIDBound(filter.Until.Add(time.Millisecond))
+		if bound == "" || until < bound {
+			bound = until
+		}
+	}
+	if bound != "" {
+		upper = prefix + bound
+	}
+
+	return bucket, prefix, lower, upper
+}
+
+func (r *bboltAuditLogRepository) FindAll(ctx context.Context, filter *auditlog.Filter, afterCursor string, limit int) ([]*auditlog.Event, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*auditlog.Event
+		nextCursor string
+	)
+
+	bucket, prefix, lower, upper := r.findAllRange(filter, afterCursor)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		events := tx.Bucket(r.auditEventsBucket)
+
+		c := tx.Bucket(bucket).Cursor()
+
+		// newest first, from the last key before the upper key
+		var k, v []byte
+		switch {
+		case upper != "":
+			k, _ = c.Seek([]byte(upper))
+		case prefix != "":
+			// '0' follows the separator
+			k, _ = c.Seek([]byte(prefix[:len(prefix)-1] + "0"))
+		}
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, []byte(prefix)) && string(k) >= lower; k, v = c.Prev() {
+			value := v
+			if prefix != "" {
+				value = events.Get(v)
+				if value == nil {
+					continue
+				}
+			}
+
+			es, err := transformer.UnmarshalEvent(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			e := schema.EventFromSchema(es)
+
+			if !filter.Match(e) {
+				continue
+			}
+
+			result = append(result, e)
+
+			if len(result) == limit {
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltAuditLogRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.auditEventsBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if count%100 == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *bboltAuditLogRepository) DeleteAll(ctx context.Context) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		err := database_bbolt.ClearBucket(tx, r.auditEventsBucket)
+		if err != nil {
+			return err
+		}
+
+		return database_bbolt.ClearBucket(tx, r.auditEventsSubjectIndexBucket)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/test"
+)
+
+func TestBBoltAuditLogRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (auditlog.AuditLogRepository, func()) {
+		return func(t *testing.T) (auditlog.AuditLogRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewAuditLogRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
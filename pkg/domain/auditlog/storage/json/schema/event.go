@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/jsonmap"
+)
+
+type Event struct {
+	ID        string          `json:"event_id" validate:"required,alphanum"`
+	Action    string          `json:"action" validate:"required,max=64"`
+	Outcome   string          `json:"outcome" validate:"required,oneof=success failure"`
+	ActorID   string          `json:"actor_id,omitempty" validate:"omitempty,printascii,max=64"`
+	SubjectID string          `json:"subject_id,omitempty" validate:"omitempty,alphanum"`
+	IP        string          `json:"ip,omitempty" validate:"omitempty,ip"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	MetaData  jsonmap.JSONMap `json:"metadata,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (e *Event) BeforeSave() error {
+	return nil
+}
+
+func EventToSchema(in *auditlog.Event) *Event {
+	out := &Event{}
+	if in != nil {
+		out.ID = in.ID
+		out.Action = string(in.Action)
+		out.Outcome = string(in.Outcome)
+		out.ActorID = in.ActorID
+		out.SubjectID = in.SubjectID
+		out.IP = in.IP
+		out.UserAgent = in.UserAgent
+		out.RequestID = in.RequestID
+		out.Reason = in.Reason
+		out.MetaData = in.MetaData
+		out.CreatedAt = in.CreatedAt
+	}
+
+	return out
+}
+
+func EventFromSchema(in *Event) *auditlog.Event {
+	out := &auditlog.Event{}
+	out.ID = in.ID
+	out.Action = auditlog.Action(in.Action)
+	out.Outcome = auditlog.Outcome(in.Outcome)
+	out.ActorID = in.ActorID
+	out.SubjectID = in.SubjectID
+	out.IP = in.IP
+	out.UserAgent = in.UserAgent
+	out.RequestID = in.RequestID
+	out.Reason = in.Reason
+	out.MetaData = in.MetaData
+	out.CreatedAt = in.CreatedAt
+
+	return out
+}
@@ -0,0 +1,46 @@
+package transformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/schema"
+)
+
+const keySeparator = "/"
+
+const (
+	ns               = "auditlog/storage/json/transformer."
+	opMarshalEvent   = ns + "MarshalEvent"
+	opUnmarshalEvent = ns + "UnmarshalEvent"
+)
+
+func MarshalEventKey(prefix, id string) string {
+	return strings.Join([]string{prefix, id}, keySeparator)
+}
+
+// MarshalSubjectEventKey returns the key of the event in the index of events
+// of the subject.
+func MarshalSubjectEventKey(prefix, subjectID, id string) string {
+	return strings.Join([]string{prefix, subjectID, id}, keySeparator)
+}
+
+func MarshalEvent(in *schema.Event) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalEvent, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalEvent(in []byte) (*schema.Event, error) {
+	out := &schema.Event{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalEvent, err)
+	}
+
+	return out, nil
+}
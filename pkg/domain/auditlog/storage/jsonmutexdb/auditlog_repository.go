@@ -0,0 +1,264 @@
+package jsonmutexdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/noop"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+const (
+	auditEventsPrefix = "audit_events"
+)
+
+type jsonMutexDBAuditLogRepository struct {
+	noop.UnimplementedAuditLogRepository
+
+	db map[string]schema.Event
+	mu sync.RWMutex
+
+	loadSaver jsonmutexdb.LoadSaver
+	filename  string
+
+	validate *validator.Validate
+
+	log logger.Logger
+}
+
+// NewAuditLogRepository returns a new JSONMutexDB repository. The log may be
+// nil.
+func NewAuditLogRepository(
+	log logger.Logger,
+	loadSaver jsonmutexdb.LoadSaver,
+	filenamePrefix string,
+) (auditlog.AuditLogRepository, error) {
+	r := &jsonMutexDBAuditLogRepository{
+		log:       log,
+		db:        make(map[string]schema.Event),
+		loadSaver: loadSaver,
+		filename:  fmt.Sprintf("%s%s.jsonl", filenamePrefix, auditEventsPrefix),
+		validate:  validator.New(),
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jsonMutexDBAuditLogRepository) load() error {
+	if r.loadSaver != nil {
+		data, err := r.loadSaver.Load(r.filename)
+		if err != nil {
+			return err
+		}
+
+		// the events are appended one per line, a line without the newline
+		// is the partial write of an interrupted append
+		complete := bytes.LastIndexByte(data, '\n') + 1
+
+		if partial := bytes.TrimSpace(data[complete:]); len(partial) > 0 {
+			if err := r.truncate(data[:complete], len(partial)); err != nil {
+				return err
+			}
+
+			data = data[:complete]
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var e schema.Event
+
+			err = dec.Decode(&e)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			r.db[e.ID] = e
+		}
+	}
+	return nil
+}
+
+// truncate saves the complete lines, dropping the partially written event.
+func (r *jsonMutexDBAuditLogRepository) truncate(data []byte, partial int) error {
+	if r.log != nil {
+		r.log.WithFields(logger.Fields{
+			"filename": r.filename,
+			"bytes":    partial,
+		}).Warn("partially written audit event dropped")
+	}
+
+	return r.loadSaver.Save(r.filename, data)
+}
+
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBAuditLogRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Event)
+
+	return r.load()
+}
+
+// commit appends the event to the saved ones, instead of saving them all.
+func (r *jsonMutexDBAuditLogRepository) commit(ctx context.Context, e *schema.Event) error {
+	if r.loadSaver != nil {
+		out, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		return jsonmutexdb.Append(ctx, r.loadSaver, r.filename, append(out, '\n'), r.reload)
+	}
+	return nil
+}
+
+const (
+	ns          = "auditlog/storage/jsonmutexdb."
+	opAppend    = ns + "Append"
+	opFindByID  = ns + "FindByID"
+	opDeleteAll = ns + "DeleteAll"
+)
+
+func (r *jsonMutexDBAuditLogRepository) Append(ctx context.Context, entity *auditlog.Event) (*auditlog.Event, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.EventToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	if _, ok := r.db[inS.ID]; ok {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, database.ErrAlreadyExists)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	err = r.commit(ctx, inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	r.db[inS.ID] = *inS
+
+	savedEntity := schema.EventFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *jsonMutexDBAuditLogRepository) FindByID(ctx context.Context, id string) (*auditlog.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, ok := r.db[id]
+	if !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+	}
+
+	entity := schema.EventFromSchema(&value)
+
+	return entity, nil
+}
+
+func (r *jsonMutexDBAuditLogRepository) FindAll(ctx context.Context, filter *auditlog.Filter, afterCursor string, limit int) ([]*auditlog.Event, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*auditlog.Event
+		nextCursor string
+	)
+
+	keys := []string{}
+	for id := range r.db {
+		if afterCursor != "" && id >= afterCursor {
+			continue
+		}
+
+		keys = append(keys, id)
+	}
+	// newest first
+	sort.Slice(keys, func(i, j int) bool { return keys[i] > keys[j] })
+
+	for _, id := range keys {
+		val := r.db[id]
+
+		e := schema.EventFromSchema(&val)
+
+		if !filter.Match(e) {
+			continue
+		}
+
+		result = append(result, e)
+
+		if len(result) == limit {
+			break
+		}
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *jsonMutexDBAuditLogRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.db), nil
+}
+
+func (r *jsonMutexDBAuditLogRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Event)
+
+	if r.loadSaver != nil {
+		err := jsonmutexdb.Save(ctx, r.loadSaver, r.filename, nil, r.reload)
+		if err != nil {
+			return fmt.Errorf("%s: %w", opDeleteAll, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package jsonmutexdb_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/test"
+	"github.com/zbiljic/authzy/pkg/ulid"
+)
+
+func TestJSONMutexDBAuditLogRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (auditlog.AuditLogRepository, func()) {
+		return func(t *testing.T) (auditlog.AuditLogRepository, func()) {
+			ls, err := database_jsonmutexdb.NewLoadSaver("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repo, err := jsonmutexdb.NewAuditLogRepository(nil, ls, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, func() {}
+		}
+	})
+}
+
+func newEvent() *auditlog.Event {
+	return &auditlog.Event{
+		ID:      ulid.ULID().String(),
+		Action:  auditlog.ActionLogin,
+		Outcome: auditlog.OutcomeSuccess,
+	}
+}
+
+func TestJSONMutexDBAuditLogAppendOnly(t *testing.T) {
+	ctx := context.Background()
+
+	ls, err := database_jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	repo, err := jsonmutexdb.NewAuditLogRepository(nil, ls, test.TestPrefix)
+	require.NoError(t, err)
+
+	first, err := repo.Append(ctx, newEvent())
+	require.NoError(t, err)
+
+	data, err := ls.Load(test.TestPrefix + "audit_events.jsonl")
+	require.NoError(t, err)
+
+	_, err = repo.Append(ctx, newEvent())
+	require.NoError(t, err)
+
+	// the second event is appended to the saved first one
+	appended, err := ls.Load(test.TestPrefix + "audit_events.jsonl")
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(appended, data))
+	assert.Equal(t, 2, bytes.Count(appended, []byte("\n")))
+
+	// the rolled back event is not appended
+	errRollback := errors.New("rollback")
+
+	err = database_jsonmutexdb.NewTransactor(ls).WithTx(ctx, func(ctx context.Context) error {
+		_, err := repo.Append(ctx, newEvent())
+		require.NoError(t, err)
+
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	saved, err := jsonmutexdb.NewAuditLogRepository(nil, ls, test.TestPrefix)
+	require.NoError(t, err)
+
+	for _, r := range []auditlog.AuditLogRepository{repo, saved} {
+		count, err := r.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		_, err = r.FindByID(ctx, first.ID)
+		assert.NoError(t, err)
+	}
+}
+
+func TestJSONMutexDBAuditLogPartialLine(t *testing.T) {
+	ctx := context.Background()
+
+	ls, err := database_jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	repo, err := jsonmutexdb.NewAuditLogRepository(nil, ls, test.TestPrefix)
+	require.NoError(t, err)
+
+	_, err = repo.Append(ctx, newEvent())
+	require.NoError(t, err)
+
+	data, err := ls.Load(test.TestPrefix + "audit_events.jsonl")
+	require.NoError(t, err)
+
+	// the append of the second event was interrupted
+	err = ls.Append(test.TestPrefix+"audit_events.jsonl", []byte(`{"event_id":"01F`))
+	require.NoError(t, err)
+
+	repo, err = jsonmutexdb.NewAuditLogRepository(nil, ls, test.TestPrefix)
+	require.NoError(t, err)
+
+	truncated, err := ls.Load(test.TestPrefix + "audit_events.jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, data, truncated)
+
+	_, err = repo.Append(ctx, newEvent())
+	require.NoError(t, err)
+
+	saved, err := jsonmutexdb.NewAuditLogRepository(nil, ls, test.TestPrefix)
+	require.NoError(t, err)
+
+	count, err := saved.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
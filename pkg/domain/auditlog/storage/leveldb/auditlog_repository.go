@@ -0,0 +1,302 @@
+package leveldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/oklog/ulid"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/noop"
+)
+
+const (
+	auditEventsPrefix        = "audit_events"
+	subjectAuditEventsPrefix = "subject_audit_events"
+)
+
+// levelDBAuditLogRepository is a repository that uses LevelDB database.
+type levelDBAuditLogRepository struct {
+	noop.UnimplementedAuditLogRepository
+
+	db *leveldb.DB
+	mu sync.Mutex
+
+	keyspace        string
+	subjectKeyspace string
+
+	validate *validator.Validate
+}
+
+// NewAuditLogRepository returns a new LevelDB repository.
+func NewAuditLogRepository(
+	db *leveldb.DB,
+	keyPrefix string,
+) (auditlog.AuditLogRepository, error) {
+	r := &levelDBAuditLogRepository{
+		db:              db,
+		keyspace:        keyPrefix + auditEventsPrefix,
+		subjectKeyspace: keyPrefix + subjectAuditEventsPrefix,
+		validate:        validator.New(),
+	}
+
+	return r, nil
+}
+
+const (
+	ns          = "auditlog/storage/leveldb."
+	opAppend    = ns + "Append"
+	opFindByID  = ns + "FindByID"
+	opFindAll   = ns + "FindAll"
+	opCount     = ns + "Count"
+	opDeleteAll = ns + "DeleteAll"
+)
+
+func (r *levelDBAuditLogRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
+}
+
+func (r *levelDBAuditLogRepository) Append(ctx context.Context, entity *auditlog.Event) (*auditlog.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.EventToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	key := transformer.MarshalEventKey(r.keyspace, inS.ID)
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, err)
+	}
+
+	if has {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, database.ErrAlreadyExists)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	value, err := transformer.MarshalEvent(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, err)
+	}
+
+	batch := new(leveldb.Batch)
+
+	batch.Put([]byte(key), value)
+
+	if inS.SubjectID != "" {
+		subjectKey := transformer.MarshalSubjectEventKey(r.subjectKeyspace, inS.SubjectID, inS.ID)
+		batch.Put([]byte(subjectKey), []byte(inS.ID))
+	}
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, err)
+	}
+
+	savedEntity := schema.EventFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *levelDBAuditLogRepository) FindByID(ctx context.Context, id string) (*auditlog.Event, error) {
+	key := transformer.MarshalEventKey(r.keyspace, id)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	es, err := transformer.UnmarshalEvent(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.EventFromSchema(es)
+
+	return entity, nil
+}
+
+// eventIDBound returns the lowest event ID which could have been recorded at
+// the given time.
+func eventIDBound(t time.Time) string {
+	var id ulid.ULID
+
+	_ = id.SetTime(ulid.Timestamp(t))
+
+	return id.String()
+}
+
+// findAllRange returns the range of keys holding the events which could
+// match the filter, and were recorded before the cursor.
+func (r *levelDBAuditLogRepository) findAllRange(filter *auditlog.Filter, afterCursor string) *util.Range {
+	prefix := r.keyspace + "/"
+	if filter != nil && filter.SubjectID != "" {
+		prefix = transformer.MarshalSubjectEventKey(r.subjectKeyspace, filter.SubjectID, "")
+	}
+
+	rng := util.BytesPrefix([]byte(prefix))
+
+	if filter != nil && !filter.Since.IsZero() {
+		rng.Start = []byte(prefix + eventIDBound(filter.Since))
+	}
+
+	upper := afterCursor
+	if filter != nil && !filter.Until.IsZero() {
+		// IDs have millisecond precision
+		until := eventIDBound(filter.Until.Add(time.Millisecond))
+		if upper == "" || until < upper {
+			upper = until
+		}
+	}
+	if upper != "" {
+		rng.Limit = []byte(prefix + upper)
+	}
+
+	return rng
+}
+
+func (r *levelDBAuditLogRepository) FindAll(ctx context.Context, filter *auditlog.Filter, afterCursor string, limit int) ([]*auditlog.Event, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*auditlog.Event
+		nextCursor string
+	)
+
+	bySubject := filter != nil && filter.SubjectID != ""
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(r.findAllRange(filter, afterCursor), nil)
+	defer iter.Release()
+
+	// newest first
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		var (
+			e   *auditlog.Event
+			err error
+		)
+
+		if bySubject {
+			e, err = r.FindByID(ctx, string(iter.Value()))
+			if err != nil {
+				return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, string(iter.Key()), err)
+			}
+		} else {
+			es, err := transformer.UnmarshalEvent(iter.Value())
+			if err != nil {
+				return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, string(iter.Key()), err)
+			}
+
+			e = schema.EventFromSchema(es)
+		}
+
+		if !filter.Match(e) {
+			continue
+		}
+
+		result = append(result, e)
+
+		if len(result) == limit {
+			break // stops iterator
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *levelDBAuditLogRepository) Count(ctx context.Context) (int, error) {
+	var (
+		count          int
+		ctxCheckOffset int
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.keyspace+"/")), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if count == ctxCheckOffset {
+			select {
+			case <-ctx.Done():
+				return count, fmt.Errorf("%s: %w", opCount, ctx.Err())
+			default:
+			}
+
+			ctxCheckOffset += 100
+		}
+
+		count++
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *levelDBAuditLogRepository) DeleteAll(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	for _, keyspace := range []string{r.keyspace, r.subjectKeyspace} {
+		iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(keyspace+"/")), nil)
+
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+
+		iter.Release()
+
+		if err := iter.Error(); err != nil {
+			return fmt.Errorf("%s: %w", opDeleteAll, err)
+		}
+	}
+
+	err := r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
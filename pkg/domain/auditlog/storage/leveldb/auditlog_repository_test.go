@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/test"
+)
+
+func TestLevelDBAuditLogRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (auditlog.AuditLogRepository, func()) {
+		return func(t *testing.T) (auditlog.AuditLogRepository, func()) {
+			db, cleanup := database_leveldb.Fixture()
+
+			repo, err := leveldb.NewAuditLogRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
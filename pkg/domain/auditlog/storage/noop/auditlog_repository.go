@@ -0,0 +1,33 @@
+package noop
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+)
+
+// Compile-time proof of interface implementation.
+var _ auditlog.AuditLogRepository = (*UnimplementedAuditLogRepository)(nil)
+
+// UnimplementedAuditLogRepository can be embedded to have forward compatible implementations.
+type UnimplementedAuditLogRepository struct{}
+
+func (*UnimplementedAuditLogRepository) Append(ctx context.Context, entity *auditlog.Event) (*auditlog.Event, error) {
+	panic("Append not implemented")
+}
+
+func (*UnimplementedAuditLogRepository) FindByID(ctx context.Context, id string) (*auditlog.Event, error) {
+	panic("FindByID not implemented")
+}
+
+func (*UnimplementedAuditLogRepository) FindAll(ctx context.Context, filter *auditlog.Filter, afterCursor string, limit int) ([]*auditlog.Event, string, error) {
+	panic("FindAll not implemented")
+}
+
+func (*UnimplementedAuditLogRepository) Count(ctx context.Context) (int, error) {
+	panic("Count not implemented")
+}
+
+func (*UnimplementedAuditLogRepository) DeleteAll(ctx context.Context) error {
+	panic("DeleteAll not implemented")
+}
@@ -0,0 +1,270 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/noop"
+)
+
+const (
+	auditEventsTable = "audit_events"
+)
+
+// sqliteAuditLogRepository is a repository that uses SQLite database.
+type sqliteAuditLogRepository struct {
+	noop.UnimplementedAuditLogRepository
+
+	db *database_sqlite.DB
+
+	auditEventsTable string
+
+	validate *validator.Validate
+}
+
+// NewAuditLogRepository returns a new SQLite repository.
+func NewAuditLogRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (auditlog.AuditLogRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sqliteAuditLogRepository{
+		db:               db,
+		auditEventsTable: database_sqlite.Table(tablePrefix, auditEventsTable),
+		validate:         validator.New(),
+	}
+
+	return r, nil
+}
+
+const (
+	ns          = "auditlog/storage/sqlite."
+	opAppend    = ns + "Append"
+	opFindByID  = ns + "FindByID"
+	opFindAll   = ns + "FindAll"
+	opCount     = ns + "Count"
+	opDeleteAll = ns + "DeleteAll"
+)
+
+func (r *sqliteAuditLogRepository) Append(ctx context.Context, entity *auditlog.Event) (*auditlog.Event, error) {
+	inS := schema.EventToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opAppend, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	value, err := transformer.MarshalEvent(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, err)
+	}
+
+	err = r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		var has bool
+
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM `+r.auditEventsTable+` WHERE id = ?)`,
+			inS.ID,
+		).Scan(&has)
+		if err != nil {
+			return err
+		}
+
+		if has {
+			return database.ErrAlreadyExists
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO `+r.auditEventsTable+` (
+				id, action, outcome, actor_id, subject_id, created_at, data
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			inS.ID,
+			inS.Action,
+			inS.Outcome,
+			inS.ActorID,
+			inS.SubjectID,
+			inS.CreatedAt.UnixNano(),
+			string(value),
+		)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opAppend, inS.ID, err)
+	}
+
+	savedEntity := schema.EventFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *sqliteAuditLogRepository) FindByID(ctx context.Context, id string) (*auditlog.Event, error) {
+	var value string
+
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM `+r.auditEventsTable+` WHERE id = ?`, id).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	es, err := transformer.UnmarshalEvent([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.EventFromSchema(es)
+
+	return entity, nil
+}
+
+// findAllWhere returns the conditions, and their arguments, selecting the
+// events which match the filter, and were recorded before the cursor.
+func findAllWhere(filter *auditlog.Filter, afterCursor string) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	if afterCursor != "" {
+		conds = append(conds, "id < ?")
+		args = append(args, afterCursor)
+	}
+
+	if filter != nil {
+		if filter.ActorID != "" {
+			conds = append(conds, "actor_id = ?")
+			args = append(args, filter.ActorID)
+		}
+
+		if filter.SubjectID != "" {
+			conds = append(conds, "subject_id = ?")
+			args = append(args, filter.SubjectID)
+		}
+
+		if len(filter.Actions) > 0 {
+			conds = append(conds, "action IN (?"+strings.Repeat(", ?", len(filter.Actions)-1)+")")
+			for _, a := range filter.Actions {
+				args = append(args, string(a))
+			}
+		}
+
+		if filter.Outcome != "" {
+			conds = append(conds, "outcome = ?")
+			args = append(args, string(filter.Outcome))
+		}
+
+		if !filter.Since.IsZero() {
+			conds = append(conds, "created_at >= ?")
+			args = append(args, filter.Since.UnixNano())
+		}
+
+		if !filter.Until.IsZero() {
+			conds = append(conds, "created_at < ?")
+			args = append(args, filter.Until.UnixNano())
+		}
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (r *sqliteAuditLogRepository) FindAll(ctx context.Context, filter *auditlog.Filter, afterCursor string, limit int) ([]*auditlog.Event, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*auditlog.Event
+		nextCursor string
+	)
+
+	where, args := findAllWhere(filter, afterCursor)
+
+	// newest first
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT data FROM `+r.auditEventsTable+where+` ORDER BY id DESC LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+		}
+
+		es, err := transformer.UnmarshalEvent([]byte(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+		}
+
+		result = append(result, schema.EventFromSchema(es))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteAuditLogRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.auditEventsTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqliteAuditLogRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.auditEventsTable)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/test"
+)
+
+func TestSQLiteAuditLogRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (auditlog.AuditLogRepository, func()) {
+		return func(t *testing.T) (auditlog.AuditLogRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewAuditLogRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -0,0 +1,352 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/domain/auditlog/storage/json/schema"
+)
+
+const (
+	TestPrefix = "test-"
+)
+
+var testTime = time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// createEvents creates events one minute apart, alternating between two
+// subjects and between successful and failed logins.
+func createEvents(t *testing.T, repo auditlog.AuditLogRepository, count int) []*auditlog.Event {
+	t.Helper()
+
+	var result []*auditlog.Event
+
+	ctx := context.Background()
+
+	subjects := []string{"user1", "user2"}
+	outcomes := []auditlog.Outcome{auditlog.OutcomeSuccess, auditlog.OutcomeFailure}
+
+	for i := 0; i < count; i++ {
+		createdAt := testTime.Add(time.Duration(i) * time.Minute)
+
+		entity := &auditlog.Event{
+			ID:        ulid.MustNew(ulid.Timestamp(createdAt), rand.Reader).String(),
+			Action:    auditlog.ActionLogin,
+			Outcome:   outcomes[i%2],
+			ActorID:   subjects[i%2],
+			SubjectID: subjects[i%2],
+			IP:        "127.0.0.1",
+			UserAgent: "test",
+			RequestID: "request",
+			MetaData:  map[string]interface{}{"index": float64(i)},
+			CreatedAt: createdAt,
+		}
+
+		savedEntity, err := repo.Append(ctx, entity)
+		require.NoError(t, err)
+
+		result = append(result, savedEntity)
+	}
+
+	return result
+}
+
+func eventIDs(events []*auditlog.Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func Run(t *testing.T, f func() func(t *testing.T) (auditlog.AuditLogRepository, func())) {
+	t.Helper()
+
+	t.Run("init", func(t *testing.T) {
+		_, cleanup := f()(t)
+		defer cleanup()
+	})
+	t.Run("Append", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testAuditLogRepositoryAppend(t, repo)
+	})
+	t.Run("FindByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testAuditLogRepositoryFindByID(t, repo)
+	})
+	t.Run("FindAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testAuditLogRepositoryFindAll(t, repo)
+	})
+	t.Run("Count", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testAuditLogRepositoryCount(t, repo)
+	})
+	t.Run("DeleteAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testAuditLogRepositoryDeleteAll(t, repo)
+	})
+}
+
+func testAuditLogRepositoryAppend(t *testing.T, repo auditlog.AuditLogRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	validate := validator.New()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := repo.Append(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		entity := &auditlog.Event{}
+
+		_, err := repo.Append(ctx, entity)
+		assert.Error(t, err)
+
+		inS := schema.EventToSchema(entity)
+		validateErr := validate.Struct(inS)
+
+		assert.Contains(t, err.Error(), validateErr.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := repo.Append(ctx, &auditlog.Event{ID: "event", Action: auditlog.ActionLogin, Outcome: "maybe"})
+		assert.Error(t, err)
+
+		_, err = repo.Append(ctx, &auditlog.Event{ID: "event", Action: auditlog.ActionLogin, Outcome: auditlog.OutcomeSuccess, IP: "localhost"})
+		assert.Error(t, err)
+	})
+
+	t.Run("simple", func(t *testing.T) {
+		entity := &auditlog.Event{
+			ID:      "event",
+			Action:  auditlog.ActionSignup,
+			Outcome: auditlog.OutcomeSuccess,
+		}
+
+		savedEntity, err := repo.Append(ctx, entity)
+		require.NoError(t, err)
+
+		assert.False(t, savedEntity.CreatedAt.IsZero())
+	})
+
+	t.Run("append only", func(t *testing.T) {
+		entity := &auditlog.Event{
+			ID:      "event",
+			Action:  auditlog.ActionLogout,
+			Outcome: auditlog.OutcomeSuccess,
+		}
+
+		_, err := repo.Append(ctx, entity)
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrAlreadyExists))
+
+		savedEntity, err := repo.FindByID(ctx, "event")
+		require.NoError(t, err)
+
+		assert.Equal(t, auditlog.ActionSignup, savedEntity.Action)
+	})
+}
+
+func testAuditLogRepositoryFindByID(t *testing.T, repo auditlog.AuditLogRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	events := createEvents(t, repo, 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, "non_existent_id")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		entity, err := repo.FindByID(ctx, events[0].ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, events[0].ID, entity.ID)
+		assert.Equal(t, events[0].Action, entity.Action)
+		assert.Equal(t, events[0].Outcome, entity.Outcome)
+		assert.Equal(t, events[0].ActorID, entity.ActorID)
+		assert.Equal(t, events[0].SubjectID, entity.SubjectID)
+		assert.Equal(t, events[0].IP, entity.IP)
+		assert.Equal(t, events[0].UserAgent, entity.UserAgent)
+		assert.Equal(t, events[0].RequestID, entity.RequestID)
+		assert.Equal(t, events[0].MetaData, entity.MetaData)
+		assert.True(t, events[0].CreatedAt.Equal(entity.CreatedAt))
+	})
+}
+
+func testAuditLogRepositoryFindAll(t *testing.T, repo auditlog.AuditLogRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("empty", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAll(ctx, nil, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	createCount := 7
+
+	events := createEvents(t, repo, createCount)
+
+	t.Run("ok", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAll(ctx, nil, "", 0)
+		assert.NoError(t, err)
+
+		require.Equal(t, createCount, len(results))
+		assert.Equal(t, "", nextCursor)
+
+		// newest first
+		assert.Equal(t, events[createCount-1].ID, results[0].ID)
+		assert.Equal(t, events[0].ID, results[createCount-1].ID)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		limit := 5
+
+		results, nextCursor, err := repo.FindAll(ctx, nil, "", limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, limit, len(results))
+		assert.Equal(t, events[createCount-limit].ID, nextCursor)
+
+		// next page
+		results, nextCursor, err = repo.FindAll(ctx, nil, nextCursor, limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{events[1].ID, events[0].ID}, eventIDs(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	t.Run("subject", func(t *testing.T) {
+		results, _, err := repo.FindAll(ctx, &auditlog.Filter{SubjectID: "user2"}, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{events[5].ID, events[3].ID, events[1].ID}, eventIDs(results))
+
+		results, nextCursor, err := repo.FindAll(ctx, &auditlog.Filter{SubjectID: "user2"}, "", 2)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{events[5].ID, events[3].ID}, eventIDs(results))
+
+		results, _, err = repo.FindAll(ctx, &auditlog.Filter{SubjectID: "user2"}, nextCursor, 2)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{events[1].ID}, eventIDs(results))
+	})
+
+	t.Run("actor", func(t *testing.T) {
+		results, _, err := repo.FindAll(ctx, &auditlog.Filter{ActorID: "user1"}, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{events[6].ID, events[4].ID, events[2].ID, events[0].ID}, eventIDs(results))
+	})
+
+	t.Run("outcome", func(t *testing.T) {
+		results, _, err := repo.FindAll(ctx, &auditlog.Filter{Outcome: auditlog.OutcomeFailure}, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{events[5].ID, events[3].ID, events[1].ID}, eventIDs(results))
+	})
+
+	t.Run("actions", func(t *testing.T) {
+		results, _, err := repo.FindAll(ctx, &auditlog.Filter{Actions: []auditlog.Action{auditlog.ActionLogout}}, "", 0)
+		assert.NoError(t, err)
+
+		assert.Empty(t, results)
+
+		results, _, err = repo.FindAll(ctx, &auditlog.Filter{Actions: []auditlog.Action{auditlog.ActionLogout, auditlog.ActionLogin}}, "", 0)
+		assert.NoError(t, err)
+
+		assert.Len(t, results, createCount)
+	})
+
+	t.Run("time", func(t *testing.T) {
+		filter := &auditlog.Filter{
+			Since: events[2].CreatedAt,
+			Until: events[5].CreatedAt,
+		}
+
+		results, _, err := repo.FindAll(ctx, filter, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{events[4].ID, events[3].ID, events[2].ID}, eventIDs(results))
+
+		filter.SubjectID = "user1"
+
+		results, _, err = repo.FindAll(ctx, filter, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{events[4].ID, events[2].ID}, eventIDs(results))
+	})
+}
+
+func testAuditLogRepositoryCount(t *testing.T, repo auditlog.AuditLogRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, count)
+
+	createCount := 3
+
+	createEvents(t, repo, createCount)
+
+	count, err = repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, createCount, count)
+}
+
+func testAuditLogRepositoryDeleteAll(t *testing.T, repo auditlog.AuditLogRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	createEvents(t, repo, 3)
+
+	err := repo.DeleteAll(ctx)
+	require.NoError(t, err)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, count)
+
+	results, _, err := repo.FindAll(ctx, &auditlog.Filter{SubjectID: "user1"}, "", 0)
+	require.NoError(t, err)
+
+	assert.Empty(t, results)
+}
@@ -0,0 +1,15 @@
+package auditlog
+
+import "context"
+
+type AuditLogUsecase interface {
+	// Record appends the event to the audit log.
+	Record(context.Context, *Event) (*Event, error)
+
+	// FindEventByID retrieves an event by ID.
+	FindEventByID(context.Context, string) (*Event, error)
+
+	// FindAllEvents retrieves events matching the filter in pages, newest
+	// first, starting after the cursor.
+	FindAllEvents(ctx context.Context, filter *Filter, afterCursor string, limit int) ([]*Event, string, error)
+}
@@ -0,0 +1,47 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	oklogulid "github.com/oklog/ulid"
+
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+	"github.com/zbiljic/authzy/pkg/ulid"
+)
+
+type auditLogUsecase struct {
+	noopAuditLogUsecase
+
+	newID func(time.Time) oklogulid.ULID
+
+	repository auditlog.AuditLogRepository
+}
+
+func NewAuditLogUsecase(
+	repository auditlog.AuditLogRepository,
+) auditlog.AuditLogUsecase {
+	uc := &auditLogUsecase{
+		newID:      ulid.Monotonic(),
+		repository: repository,
+	}
+	return uc
+}
+
+func (uc *auditLogUsecase) Record(ctx context.Context, entity *auditlog.Event) (*auditlog.Event, error) {
+	now := time.Now()
+
+	event := *entity
+	event.ID = uc.newID(now).String()
+	event.CreatedAt = now
+
+	return uc.repository.Append(ctx, &event)
+}
+
+func (uc *auditLogUsecase) FindEventByID(ctx context.Context, id string) (*auditlog.Event, error) {
+	return uc.repository.FindByID(ctx, id)
+}
+
+func (uc *auditLogUsecase) FindAllEvents(ctx context.Context, filter *auditlog.Filter, afterCursor string, limit int) ([]*auditlog.Event, string, error) {
+	return uc.repository.FindAll(ctx, filter, afterCursor, limit)
+}
@@ -0,0 +1,25 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/auditlog"
+)
+
+// Compile-time proof of interface implementation.
+var _ auditlog.AuditLogUsecase = (*noopAuditLogUsecase)(nil)
+
+// noopAuditLogUsecase can be embedded to have forward compatible implementations.
+type noopAuditLogUsecase struct{}
+
+func (*noopAuditLogUsecase) Record(ctx context.Context, event *auditlog.Event) (*auditlog.Event, error) {
+	panic("Record not implemented")
+}
+
+func (*noopAuditLogUsecase) FindEventByID(ctx context.Context, id string) (*auditlog.Event, error) {
+	panic("FindEventByID not implemented")
+}
+
+func (*noopAuditLogUsecase) FindAllEvents(ctx context.Context, filter *auditlog.Filter, afterCursor string, limit int) ([]*auditlog.Event, string, error) {
+	panic("FindAllEvents not implemented")
+}
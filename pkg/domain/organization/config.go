@@ -0,0 +1,8 @@
+package organization
+
+import "time"
+
+// Config holds the organization configuration.
+type Config struct {
+	InvitationExpiry time.Duration `json:"invitation_expiry" split_words:"true" default:"168h"`
+}
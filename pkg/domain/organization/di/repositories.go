@@ -0,0 +1,149 @@
+package di
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	organization_bbolt "github.com/zbiljic/authzy/pkg/domain/organization/storage/bbolt"
+	organization_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/organization/storage/jsonmutexdb"
+	organization_leveldb "github.com/zbiljic/authzy/pkg/domain/organization/storage/leveldb"
+	organization_sqlite "github.com/zbiljic/authzy/pkg/domain/organization/storage/sqlite"
+)
+
+var repositoresfx = fx.Provide(
+	NewOrganizationRepository,
+	NewInvitationRepository,
+)
+
+type RepositoryParams struct {
+	fx.In
+
+	Type string `name:"db_type"`
+
+	JSONMutexDBConfig *database_jsonmutexdb.Config
+	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+func NewOrganizationRepository(p RepositoryParams) (organization.OrganizationRepository, error) {
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		return NewJSONMutexDBOrganizationRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
+	case database_leveldb.Type:
+		return NewLevelDBOrganizationRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteOrganizationRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltOrganizationRepository(p.BBoltConfig, p.BBolt)
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+func NewJSONMutexDBOrganizationRepository(
+	config *database_jsonmutexdb.Config,
+	ls *database_jsonmutexdb.LoadSaver,
+) (organization.OrganizationRepository, error) {
+	return organization_jsonmutexdb.NewOrganizationRepository(
+		*ls,
+		config.FilenamePrefix,
+	)
+}
+
+func NewLevelDBOrganizationRepository(
+	config *database_leveldb.Config,
+	db *leveldb.DB,
+) (organization.OrganizationRepository, error) {
+	return organization_leveldb.NewOrganizationRepository(
+		db,
+		config.KeyPrefix,
+	)
+}
+
+func NewSQLiteOrganizationRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (organization.OrganizationRepository, error) {
+	return organization_sqlite.NewOrganizationRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltOrganizationRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (organization.OrganizationRepository, error) {
+	return organization_bbolt.NewOrganizationRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
+
+func NewInvitationRepository(p RepositoryParams) (organization.InvitationRepository, error) {
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		return NewJSONMutexDBInvitationRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
+	case database_leveldb.Type:
+		return NewLevelDBInvitationRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteInvitationRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltInvitationRepository(p.BBoltConfig, p.BBolt)
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+func NewJSONMutexDBInvitationRepository(
+	config *database_jsonmutexdb.Config,
+	ls *database_jsonmutexdb.LoadSaver,
+) (organization.InvitationRepository, error) {
+	return organization_jsonmutexdb.NewInvitationRepository(
+		*ls,
+		config.FilenamePrefix,
+	)
+}
+
+func NewLevelDBInvitationRepository(
+	config *database_leveldb.Config,
+	db *leveldb.DB,
+) (organization.InvitationRepository, error) {
+	return organization_leveldb.NewInvitationRepository(
+		db,
+		config.KeyPrefix,
+	)
+}
+
+func NewSQLiteInvitationRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (organization.InvitationRepository, error) {
+	return organization_sqlite.NewInvitationRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltInvitationRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (organization.InvitationRepository, error) {
+	return organization_bbolt.NewInvitationRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
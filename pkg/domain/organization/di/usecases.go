@@ -0,0 +1,25 @@
+package di
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/usecases"
+)
+
+var usecasesfx = fx.Provide(
+	NewOrganizationUsecase,
+)
+
+func NewOrganizationUsecase(
+	config *organization.Config,
+	repository organization.OrganizationRepository,
+	invitationRepository organization.InvitationRepository,
+) organization.OrganizationUsecase {
+	uc := usecases.NewOrganizationUsecase(
+		*config,
+		repository,
+		invitationRepository,
+	)
+	return uc
+}
@@ -0,0 +1,64 @@
+package organization
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/jsonmap"
+)
+
+var (
+	// ErrInvitationExpired is returned when accepting expired invitation.
+	ErrInvitationExpired = errors.New("invitation expired")
+
+	// ErrInvitationEmailMismatch is returned when the invitation is accepted
+	// by the user having different email address than the invited one.
+	ErrInvitationEmailMismatch = errors.New("invitation email mismatch")
+)
+
+// Organization represents a company, or any other group of users.
+type Organization struct {
+	ID          string
+	Name        string
+	DisplayName string
+	MetaData    jsonmap.JSONMap
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Member represents the membership of the user in an organization. Roles are
+// scoped to the organization.
+type Member struct {
+	OrganizationID string
+	UserID         string
+	Roles          []string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Invitation represents a pending invitation for the email address to join
+// an organization.
+type Invitation struct {
+	ID             string
+	OrganizationID string
+	Email          string
+	Roles          []string
+	Token          string
+	InviterID      string
+
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// IsExpired checks if the invitation can no longer be accepted.
+func (i *Invitation) IsExpired() bool {
+	return !i.ExpiresAt.After(time.Now())
+}
+
+// IsFor checks if the invitation was sent to the email address.
+func (i *Invitation) IsFor(email string) bool {
+	return strings.EqualFold(i.Email, email)
+}
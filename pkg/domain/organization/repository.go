@@ -0,0 +1,69 @@
+package organization
+
+import "context"
+
+type OrganizationRepository interface {
+	// Save saves a given entity.
+	Save(ctx context.Context, entity *Organization) (*Organization, error)
+
+	// FindByID retrieves an entity by its id.
+	FindByID(ctx context.Context, id string) (*Organization, error)
+
+	// ExistsByID returns whether an entity with the given id exists.
+	ExistsByID(ctx context.Context, id string) (bool, error)
+
+	// FindAll returns all instances of the type.
+	FindAll(ctx context.Context, afterCursor string, limit int) ([]*Organization, string, error)
+
+	// Count returns the number of entities available.
+	Count(ctx context.Context) (int, error)
+
+	// DeleteByID deletes the entity with the given id, together with all
+	// its members.
+	DeleteByID(ctx context.Context, id string) error
+
+	// DeleteAll deletes all entities managed by the repository.
+	DeleteAll(ctx context.Context) error
+
+	// SaveMember saves the membership of the user in the organization.
+	SaveMember(ctx context.Context, member *Member) (*Member, error)
+
+	// FindMember retrieves the membership of the user in the organization.
+	FindMember(ctx context.Context, id, userID string) (*Member, error)
+
+	// FindAllMembers returns members of the organization with the given id.
+	FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*Member, string, error)
+
+	// DeleteMember removes the user from the organization.
+	DeleteMember(ctx context.Context, id, userID string) error
+
+	// FindAllForUser returns all memberships of specified user ID.
+	FindAllForUser(ctx context.Context, userID string) ([]*Member, error)
+
+	// DeleteAllForUser removes specified user ID from all organizations.
+	DeleteAllForUser(ctx context.Context, userID string) error
+}
+
+type InvitationRepository interface {
+	// Save saves a given entity.
+	Save(ctx context.Context, entity *Invitation) (*Invitation, error)
+
+	// FindByID retrieves an entity by its id.
+	FindByID(ctx context.Context, id string) (*Invitation, error)
+
+	// FindByToken retrieves an entity by its token.
+	FindByToken(ctx context.Context, token string) (*Invitation, error)
+
+	// FindAllForOrganization returns all invitations to specified organization ID.
+	FindAllForOrganization(ctx context.Context, organizationID string) ([]*Invitation, error)
+
+	// DeleteByID deletes the entity with the given id.
+	DeleteByID(ctx context.Context, id string) error
+
+	// DeleteAllForOrganization deletes all invitations to specified
+	// organization ID.
+	DeleteAllForOrganization(ctx context.Context, organizationID string) error
+
+	// DeleteAll deletes all entities managed by the repository.
+	DeleteAll(ctx context.Context) error
+}
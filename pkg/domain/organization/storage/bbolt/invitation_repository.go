@@ -0,0 +1,324 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/noop"
+)
+
+const (
+	invitationsBucket                  = "invitations"
+	invitationsTokenIndexBucket        = "index_invitations_token"
+	invitationsOrganizationIndexBucket = "index_invitations_organization_id"
+)
+
+// bboltInvitationRepository is a repository that uses bbolt database.
+type bboltInvitationRepository struct {
+	noop.UnimplementedInvitationRepository
+
+	db *database_bbolt.DB
+
+	invitationsBucket                  []byte
+	invitationsTokenIndexBucket        []byte
+	invitationsOrganizationIndexBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewInvitationRepository returns a new bbolt repository.
+func NewInvitationRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (organization.InvitationRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &bboltInvitationRepository{
+		db:                                 db,
+		invitationsBucket:                  []byte(bucketPrefix + invitationsBucket),
+		invitationsTokenIndexBucket:        []byte(bucketPrefix + invitationsTokenIndexBucket),
+		invitationsOrganizationIndexBucket: []byte(bucketPrefix + invitationsOrganizationIndexBucket),
+		validate:                           validate,
+	}
+
+	err := db.CreateBuckets(
+		string(r.invitationsBucket),
+		string(r.invitationsTokenIndexBucket),
+		string(r.invitationsOrganizationIndexBucket),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	opInvitationSave                     = ns + "Invitation.Save"
+	opInvitationFindByID                 = ns + "Invitation.FindByID"
+	opInvitationFindByToken              = ns + "Invitation.FindByToken"
+	opInvitationFindAllForOrganization   = ns + "Invitation.FindAllForOrganization"
+	opInvitationDeleteByID               = ns + "Invitation.DeleteByID"
+	opInvitationDeleteAllForOrganization = ns + "Invitation.DeleteAllForOrganization"
+	opInvitationDeleteAll                = ns + "Invitation.DeleteAll"
+)
+
+// find returns the stored invitation with the ID, or nil if there is none.
+func (r *bboltInvitationRepository) find(tx *bolt.Tx, id []byte) (*schema.Invitation, error) {
+	value := tx.Bucket(r.invitationsBucket).Get(id)
+	if value == nil {
+		return nil, nil
+	}
+
+	return transformer.UnmarshalInvitation(value)
+}
+
+// delete deletes the stored invitation, together with its index entries.
+func (r *bboltInvitationRepository) delete(tx *bolt.Tx, ts *schema.Invitation) error {
+	if ts.Token != "" {
+		b := tx.Bucket(r.invitationsTokenIndexBucket)
+
+		// the token may have been taken over by another invitation
+		if string(b.Get([]byte(ts.Token))) == ts.ID {
+			err := b.Delete([]byte(ts.Token))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err := tx.Bucket(r.invitationsOrganizationIndexBucket).Delete(pairKey(ts.OrganizationID, ts.ID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(r.invitationsBucket).Delete([]byte(ts.ID))
+}
+
+func (r *bboltInvitationRepository) Save(ctx context.Context, entity *organization.Invitation) (*organization.Invitation, error) {
+	inS := schema.InvitationToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	value, err := transformer.MarshalInvitation(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationSave, inS.ID, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		previous, err := r.find(tx, []byte(inS.ID))
+		if err != nil {
+			return err
+		}
+
+		if previous != nil {
+			err = r.delete(tx, previous)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Bucket(r.invitationsBucket).Put([]byte(inS.ID), value)
+		if err != nil {
+			return err
+		}
+
+		if inS.Token != "" {
+			err = tx.Bucket(r.invitationsTokenIndexBucket).Put([]byte(inS.Token), []byte(inS.ID))
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket(r.invitationsOrganizationIndexBucket).Put(pairKey(inS.OrganizationID, inS.ID), []byte(inS.ID))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationSave, inS.ID, err)
+	}
+
+	savedEntity := schema.InvitationFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltInvitationRepository) FindByID(ctx context.Context, id string) (*organization.Invitation, error) {
+	var ts *schema.Invitation
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		ts, err = r.find(tx, []byte(id))
+		if err == nil && ts == nil {
+			err = database.ErrNotFound
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationFindByID, id, err)
+	}
+
+	return schema.InvitationFromSchema(ts), nil
+}
+
+func (r *bboltInvitationRepository) FindByToken(ctx context.Context, token string) (*organization.Invitation, error) {
+	if token == "" {
+		return nil, fmt.Errorf("%s: token cannot be empty", opInvitationFindByToken)
+	}
+
+	var ts *schema.Invitation
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		id := tx.Bucket(r.invitationsTokenIndexBucket).Get([]byte(token))
+		if id == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = r.find(tx, id)
+		if err == nil && ts == nil {
+			err = database.ErrNotFound
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationFindByToken, err)
+	}
+
+	return schema.InvitationFromSchema(ts), nil
+}
+
+// findAllForOrganization returns the stored invitations of the organization.
+func (r *bboltInvitationRepository) findAllForOrganization(tx *bolt.Tx, organizationID string) ([]*schema.Invitation, error) {
+	var result []*schema.Invitation
+
+	prefix := pairKey(organizationID, "")
+
+	c := tx.Bucket(r.invitationsOrganizationIndexBucket).Cursor()
+
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		ts, err := r.find(tx, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", string(v), err)
+		}
+
+		if ts != nil {
+			result = append(result, ts)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *bboltInvitationRepository) FindAllForOrganization(ctx context.Context, organizationID string) ([]*organization.Invitation, error) {
+	if organizationID == "" {
+		return nil, fmt.Errorf("%s: organizationID cannot be empty", opInvitationFindAllForOrganization)
+	}
+
+	var result []*organization.Invitation
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		invitations, err := r.findAllForOrganization(tx, organizationID)
+		if err != nil {
+			return err
+		}
+
+		for _, ts := range invitations {
+			result = append(result, schema.InvitationFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationFindAllForOrganization, organizationID, err)
+	}
+
+	return result, nil
+}
+
+func (r *bboltInvitationRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		ts, err := r.find(tx, []byte(id))
+		if err != nil || ts == nil {
+			return err
+		}
+
+		return r.delete(tx, ts)
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opInvitationDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltInvitationRepository) DeleteAllForOrganization(ctx context.Context, organizationID string) error {
+	if organizationID == "" {
+		return fmt.Errorf("%s: organizationID cannot be empty", opInvitationDeleteAllForOrganization)
+	}
+
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		invitations, err := r.findAllForOrganization(tx, organizationID)
+		if err != nil {
+			return err
+		}
+
+		for _, ts := range invitations {
+			err = r.delete(tx, ts)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opInvitationDeleteAllForOrganization, organizationID, err)
+	}
+
+	return nil
+}
+
+func (r *bboltInvitationRepository) DeleteAll(ctx context.Context) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{
+			r.invitationsBucket,
+			r.invitationsTokenIndexBucket,
+			r.invitationsOrganizationIndexBucket,
+		} {
+			err := database_bbolt.ClearBucket(tx, bucket)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", opInvitationDeleteAll, err)
+	}
+
+	return nil
+}
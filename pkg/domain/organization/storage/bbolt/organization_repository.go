@@ -0,0 +1,489 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/noop"
+)
+
+const (
+	organizationsBucket    = "organizations"
+	membersBucket          = "organization_members"
+	membersUserIndexBucket = "index_organization_members_user_id"
+)
+
+// bboltOrganizationRepository is a repository that uses bbolt database.
+type bboltOrganizationRepository struct {
+	noop.UnimplementedOrganizationRepository
+
+	db *database_bbolt.DB
+
+	organizationsBucket    []byte
+	membersBucket          []byte
+	membersUserIndexBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewOrganizationRepository returns a new bbolt repository.
+func NewOrganizationRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (organization.OrganizationRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &bboltOrganizationRepository{
+		db:                     db,
+		organizationsBucket:    []byte(bucketPrefix + organizationsBucket),
+		membersBucket:          []byte(bucketPrefix + membersBucket),
+		membersUserIndexBucket: []byte(bucketPrefix + membersUserIndexBucket),
+		validate:               validate,
+	}
+
+	err := db.CreateBuckets(
+		string(r.organizationsBucket),
+		string(r.membersBucket),
+		string(r.membersUserIndexBucket),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns                 = "organization/storage/bbolt."
+	opSave             = ns + "Save"
+	opFindByID         = ns + "FindByID"
+	opExistsByID       = ns + "ExistsByID"
+	opFindAll          = ns + "FindAll"
+	opCount            = ns + "Count"
+	opDeleteByID       = ns + "DeleteByID"
+	opDeleteAll        = ns + "DeleteAll"
+	opSaveMember       = ns + "SaveMember"
+	opFindMember       = ns + "FindMember"
+	opFindAllMembers   = ns + "FindAllMembers"
+	opDeleteMember     = ns + "DeleteMember"
+	opFindAllForUser   = ns + "FindAllForUser"
+	opDeleteAllForUser = ns + "DeleteAllForUser"
+)
+
+// pairKey returns the key of the pair of IDs, sorting the pairs by the first
+// ID.
+func pairKey(first, second string) []byte {
+	return []byte(first + "/" + second)
+}
+
+func (r *bboltOrganizationRepository) Save(ctx context.Context, entity *organization.Organization) (*organization.Organization, error) {
+	inS := schema.OrganizationToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalOrganization(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(r.organizationsBucket).Put([]byte(inS.ID), value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.OrganizationFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltOrganizationRepository) FindByID(ctx context.Context, id string) (*organization.Organization, error) {
+	var ts *schema.Organization
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		value := tx.Bucket(r.organizationsBucket).Get([]byte(id))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = transformer.UnmarshalOrganization(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.OrganizationFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *bboltOrganizationRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		has = tx.Bucket(r.organizationsBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *bboltOrganizationRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*organization.Organization, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*organization.Organization
+		nextCursor string
+	)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.organizationsBucket).Cursor()
+
+		k, v := c.First()
+		if afterCursor != "" {
+			k, v = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < limit; k, v = c.Next() {
+			ts, err := transformer.UnmarshalOrganization(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.OrganizationFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltOrganizationRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.organizationsBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+// deletePairs deletes the pairs of IDs starting with the first ID from the
+// bucket, together with the reversed pairs from the other bucket.
+func deletePairs(tx *bolt.Tx, bucket, reversedBucket []byte, first string) error {
+	prefix := pairKey(first, "")
+
+	c := tx.Bucket(bucket).Cursor()
+	reversed := tx.Bucket(reversedBucket)
+
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+		second := string(k[len(prefix):])
+
+		err := reversed.Delete(pairKey(second, first))
+		if err != nil {
+			return err
+		}
+
+		err = c.Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *bboltOrganizationRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		err := tx.Bucket(r.organizationsBucket).Delete([]byte(id))
+		if err != nil {
+			return err
+		}
+
+		// remove all members
+		return deletePairs(tx, r.membersBucket, r.membersUserIndexBucket, id)
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltOrganizationRepository) DeleteAll(ctx context.Context) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{r.organizationsBucket, r.membersBucket, r.membersUserIndexBucket} {
+			err := database_bbolt.ClearBucket(tx, bucket)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
+
+func (r *bboltOrganizationRepository) SaveMember(ctx context.Context, member *organization.Member) (*organization.Member, error) {
+	inS := schema.MemberToSchema(member)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	memberID := transformer.MarshalMemberID(inS)
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		if tx.Bucket(r.organizationsBucket).Get([]byte(inS.OrganizationID)) == nil {
+			return database.ErrNotFound
+		}
+
+		members := tx.Bucket(r.membersBucket)
+
+		key := pairKey(inS.OrganizationID, inS.UserID)
+
+		if inS.CreatedAt.IsZero() {
+			if existing := members.Get(key); existing != nil {
+				ts, err := transformer.UnmarshalMember(existing)
+				if err != nil {
+					return err
+				}
+
+				inS.CreatedAt = ts.CreatedAt
+			} else {
+				inS.CreatedAt = time.Now()
+			}
+		}
+		inS.UpdatedAt = time.Now()
+
+		value, err := transformer.MarshalMember(inS)
+		if err != nil {
+			return err
+		}
+
+		err = members.Put(key, value)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(r.membersUserIndexBucket).Put(pairKey(inS.UserID, inS.OrganizationID), []byte{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSaveMember, memberID, err)
+	}
+
+	savedMember := schema.MemberFromSchema(inS)
+
+	return savedMember, nil
+}
+
+func (r *bboltOrganizationRepository) FindMember(ctx context.Context, id, userID string) (*organization.Member, error) {
+	memberID := transformer.MarshalMemberID(&schema.Member{
+		OrganizationID: id,
+		UserID:         userID,
+	})
+
+	var ts *schema.Member
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		value := tx.Bucket(r.membersBucket).Get(pairKey(id, userID))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = transformer.UnmarshalMember(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindMember, memberID, err)
+	}
+
+	member := schema.MemberFromSchema(ts)
+
+	return member, nil
+}
+
+func (r *bboltOrganizationRepository) FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*organization.Member, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*organization.Member
+		nextCursor string
+	)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		prefix := pairKey(id, "")
+
+		c := tx.Bucket(r.membersBucket).Cursor()
+
+		k, v := c.Seek(prefix)
+		if afterCursor != "" {
+			start := pairKey(id, afterCursor)
+
+			k, v = c.Seek(start)
+			if k != nil && bytes.Equal(k, start) {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, prefix) && len(result) < limit; k, v = c.Next() {
+			ts, err := transformer.UnmarshalMember(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.MemberFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAllMembers, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].UserID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltOrganizationRepository) DeleteMember(ctx context.Context, id, userID string) error {
+	memberID := transformer.MarshalMemberID(&schema.Member{
+		OrganizationID: id,
+		UserID:         userID,
+	})
+
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		err := tx.Bucket(r.membersBucket).Delete(pairKey(id, userID))
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(r.membersUserIndexBucket).Delete(pairKey(userID, id))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteMember, memberID, err)
+	}
+
+	return nil
+}
+
+func (r *bboltOrganizationRepository) FindAllForUser(ctx context.Context, userID string) ([]*organization.Member, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	var result []*organization.Member
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		prefix := pairKey(userID, "")
+
+		members := tx.Bucket(r.membersBucket)
+
+		c := tx.Bucket(r.membersUserIndexBucket).Cursor()
+
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			value := members.Get(pairKey(string(k[len(prefix):]), userID))
+			if value == nil {
+				continue
+			}
+
+			ts, err := transformer.UnmarshalMember(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.MemberFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	return result, nil
+}
+
+func (r *bboltOrganizationRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("%s: userID cannot be empty", opDeleteAllForUser)
+	}
+
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return deletePairs(tx, r.membersUserIndexBucket, r.membersBucket, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, userID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/test"
+)
+
+func TestBBoltOrganizationRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (organization.OrganizationRepository, func()) {
+		return func(t *testing.T) (organization.OrganizationRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewOrganizationRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+)
+
+type Invitation struct {
+	ID             string   `json:"invitation_id" validate:"required,alphanum"`
+	OrganizationID string   `json:"organization_id" validate:"required,alphanum"`
+	Email          string   `json:"email" validate:"required,email"`
+	Roles          []string `json:"roles,omitempty" validate:"dive,memberrole"`
+	Token          string   `json:"token" validate:"required"`
+	InviterID      string   `json:"inviter_id,omitempty"`
+
+	ExpiresAt time.Time `json:"expires_at" validate:"required"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (i *Invitation) BeforeSave() error {
+	return nil
+}
+
+func InvitationToSchema(in *organization.Invitation) *Invitation {
+	out := &Invitation{}
+	if in != nil {
+		out.ID = in.ID
+		out.OrganizationID = in.OrganizationID
+		out.Email = in.Email
+		out.Roles = in.Roles
+		out.Token = in.Token
+		out.InviterID = in.InviterID
+		out.ExpiresAt = in.ExpiresAt
+		out.CreatedAt = in.CreatedAt
+	}
+
+	return out
+}
+
+func InvitationFromSchema(in *Invitation) *organization.Invitation {
+	out := &organization.Invitation{}
+	out.ID = in.ID
+	out.OrganizationID = in.OrganizationID
+	out.Email = in.Email
+	out.Roles = in.Roles
+	out.Token = in.Token
+	out.InviterID = in.InviterID
+	out.ExpiresAt = in.ExpiresAt
+	out.CreatedAt = in.CreatedAt
+
+	return out
+}
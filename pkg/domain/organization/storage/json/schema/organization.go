@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/jsonmap"
+)
+
+type Organization struct {
+	ID          string          `json:"organization_id" validate:"required,alphanum"`
+	Name        string          `json:"name" validate:"required,max=256"`
+	DisplayName string          `json:"display_name,omitempty" validate:"max=256"`
+	MetaData    jsonmap.JSONMap `json:"metadata,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (o *Organization) BeforeSave() error {
+	return nil
+}
+
+// Member is the membership of the user in an organization.
+type Member struct {
+	OrganizationID string   `json:"organization_id" validate:"required,alphanum"`
+	UserID         string   `json:"user_id" validate:"required,alphanum"`
+	Roles          []string `json:"roles,omitempty" validate:"dive,memberrole"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (m *Member) BeforeSave() error {
+	return nil
+}
+
+func OrganizationToSchema(in *organization.Organization) *Organization {
+	out := &Organization{}
+	if in != nil {
+		out.ID = in.ID
+		out.Name = in.Name
+		out.DisplayName = in.DisplayName
+		out.MetaData = in.MetaData
+		out.CreatedAt = in.CreatedAt
+		out.UpdatedAt = in.UpdatedAt
+	}
+
+	return out
+}
+
+func OrganizationFromSchema(in *Organization) *organization.Organization {
+	out := &organization.Organization{}
+	out.ID = in.ID
+	out.Name = in.Name
+	out.DisplayName = in.DisplayName
+	out.MetaData = in.MetaData
+	out.CreatedAt = in.CreatedAt
+	out.UpdatedAt = in.UpdatedAt
+
+	return out
+}
+
+func MemberToSchema(in *organization.Member) *Member {
+	out := &Member{}
+	if in != nil {
+		out.OrganizationID = in.OrganizationID
+		out.UserID = in.UserID
+		out.Roles = in.Roles
+		out.CreatedAt = in.CreatedAt
+		out.UpdatedAt = in.UpdatedAt
+	}
+
+	return out
+}
+
+func MemberFromSchema(in *Member) *organization.Member {
+	out := &organization.Member{}
+	out.OrganizationID = in.OrganizationID
+	out.UserID = in.UserID
+	out.Roles = in.Roles
+	out.CreatedAt = in.CreatedAt
+	out.UpdatedAt = in.UpdatedAt
+
+	return out
+}
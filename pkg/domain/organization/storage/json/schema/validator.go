@@ -0,0 +1,29 @@
+package schema
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	memberRoleRegexString = "^[a-zA-Z0-9][a-zA-Z0-9_.:-]{0,63}$"
+)
+
+var (
+	memberRoleRegex = regexp.MustCompile(memberRoleRegexString)
+
+	validators = map[string]validator.Func{
+		"memberrole": isMemberRole,
+	}
+)
+
+func RegisterValidators(v *validator.Validate) {
+	for k, val := range validators {
+		_ = v.RegisterValidation(k, val)
+	}
+}
+
+func isMemberRole(fl validator.FieldLevel) bool {
+	return memberRoleRegex.MatchString(fl.Field().String())
+}
@@ -0,0 +1,90 @@
+package transformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+)
+
+const keySeparator = "/"
+
+const (
+	ns                      = "organization/storage/json/transformer."
+	opMarshalOrganization   = ns + "MarshalOrganization"
+	opUnmarshalOrganization = ns + "UnmarshalOrganization"
+	opMarshalMember         = ns + "MarshalMember"
+	opUnmarshalMember       = ns + "UnmarshalMember"
+	opMarshalInvitation     = ns + "MarshalInvitation"
+	opUnmarshalInvitation   = ns + "UnmarshalInvitation"
+)
+
+func MarshalKey(prefix, id string) string {
+	return strings.Join([]string{prefix, id}, keySeparator)
+}
+
+func MarshalMemberID(member *schema.Member) string {
+	return strings.Join([]string{member.OrganizationID, member.UserID}, keySeparator)
+}
+
+func MarshalUserMemberID(member *schema.Member) string {
+	return strings.Join([]string{member.UserID, member.OrganizationID}, keySeparator)
+}
+
+func MarshalOrganization(in *schema.Organization) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalOrganization, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalOrganization(in []byte) (*schema.Organization, error) {
+	out := &schema.Organization{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalOrganization, err)
+	}
+
+	return out, nil
+}
+
+func MarshalMember(in *schema.Member) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalMember, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalMember(in []byte) (*schema.Member, error) {
+	out := &schema.Member{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalMember, err)
+	}
+
+	return out, nil
+}
+
+func MarshalInvitation(in *schema.Invitation) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalInvitation, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalInvitation(in []byte) (*schema.Invitation, error) {
+	out := &schema.Invitation{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalInvitation, err)
+	}
+
+	return out, nil
+}
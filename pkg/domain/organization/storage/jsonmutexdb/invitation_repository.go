@@ -0,0 +1,259 @@
+package jsonmutexdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/noop"
+)
+
+const (
+	invitationsPrefix = "organization_invitations"
+)
+
+type jsonMutexDBInvitationRepository struct {
+	noop.UnimplementedInvitationRepository
+
+	db map[string]schema.Invitation
+	mu sync.RWMutex
+
+	loadSaver jsonmutexdb.LoadSaver
+	filename  string
+
+	validate *validator.Validate
+}
+
+// NewInvitationRepository returns a new JSONMutexDB repository.
+func NewInvitationRepository(
+	loadSaver jsonmutexdb.LoadSaver,
+	filenamePrefix string,
+) (organization.InvitationRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &jsonMutexDBInvitationRepository{
+		db:        make(map[string]schema.Invitation),
+		loadSaver: loadSaver,
+		filename:  fmt.Sprintf("%s%s.json", filenamePrefix, invitationsPrefix),
+		validate:  validate,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jsonMutexDBInvitationRepository) load() error {
+	if r.loadSaver != nil {
+		data, err := r.loadSaver.Load(r.filename)
+		if err != nil {
+			return err
+		}
+
+		if len(data) > 0 {
+			err = json.Unmarshal(data, &r.db)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBInvitationRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Invitation)
+
+	return r.load()
+}
+
+func (r *jsonMutexDBInvitationRepository) commit(ctx context.Context) error {
+	if r.loadSaver != nil {
+		out, err := json.Marshal(r.db)
+		if err != nil {
+			return err
+		}
+
+		err = jsonmutexdb.Save(ctx, r.loadSaver, r.filename, out, r.reload)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	opInvitationSave                     = ns + "Invitation.Save"
+	opInvitationFindByID                 = ns + "Invitation.FindByID"
+	opInvitationFindByToken              = ns + "Invitation.FindByToken"
+	opInvitationFindAllForOrganization   = ns + "Invitation.FindAllForOrganization"
+	opInvitationDeleteByID               = ns + "Invitation.DeleteByID"
+	opInvitationDeleteAllForOrganization = ns + "Invitation.DeleteAllForOrganization"
+	opInvitationDeleteAll                = ns + "Invitation.DeleteAll"
+)
+
+func (r *jsonMutexDBInvitationRepository) Save(ctx context.Context, entity *organization.Invitation) (*organization.Invitation, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.InvitationToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	r.db[inS.ID] = *inS
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	savedEntity := schema.InvitationFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *jsonMutexDBInvitationRepository) FindByID(ctx context.Context, id string) (*organization.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, ok := r.db[id]
+	if !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationFindByID, id, database.ErrNotFound)
+	}
+
+	entity := schema.InvitationFromSchema(&value)
+
+	return entity, nil
+}
+
+func (r *jsonMutexDBInvitationRepository) FindByToken(ctx context.Context, token string) (*organization.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if token == "" {
+		return nil, fmt.Errorf("%s: token cannot be empty", opInvitationFindByToken)
+	}
+
+	for _, value := range r.db {
+		if value.Token == token {
+			entity := schema.InvitationFromSchema(&value)
+
+			return entity, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", opInvitationFindByToken, database.ErrNotFound)
+}
+
+func (r *jsonMutexDBInvitationRepository) FindAllForOrganization(ctx context.Context, organizationID string) ([]*organization.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if organizationID == "" {
+		return nil, fmt.Errorf("%s: organizationID cannot be empty", opInvitationFindAllForOrganization)
+	}
+
+	var (
+		result []*organization.Invitation
+	)
+
+	for _, val := range r.db {
+		if val.OrganizationID != organizationID {
+			continue
+		}
+
+		t := schema.InvitationFromSchema(&val)
+
+		result = append(result, t)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result, nil
+}
+
+func (r *jsonMutexDBInvitationRepository) DeleteByID(ctx context.Context, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[id]; !ok {
+		return nil
+	}
+
+	delete(r.db, id)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opInvitationDeleteByID, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBInvitationRepository) DeleteAllForOrganization(ctx context.Context, organizationID string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if organizationID == "" {
+		return fmt.Errorf("%s: organizationID cannot be empty", opInvitationDeleteAllForOrganization)
+	}
+
+	for key, val := range r.db {
+		if val.OrganizationID == organizationID {
+			delete(r.db, key)
+		}
+	}
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opInvitationDeleteAllForOrganization, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBInvitationRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Invitation)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opInvitationDeleteAll, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package jsonmutexdb_test
+
+import (
+	"testing"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/test"
+)
+
+func TestJSONMutexDBInvitationRepository(t *testing.T) {
+	test.RunInvitation(t, func() func(t *testing.T) (organization.InvitationRepository, func()) {
+		return func(t *testing.T) (organization.InvitationRepository, func()) {
+			repo, err := jsonmutexdb.NewInvitationRepository(nil, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, func() {}
+		}
+	})
+}
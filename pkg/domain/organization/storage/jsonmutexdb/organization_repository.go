@@ -0,0 +1,485 @@
+package jsonmutexdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/noop"
+)
+
+const (
+	organizationsPrefix = "organizations"
+	membersPrefix       = "organization_members"
+)
+
+type jsonMutexDBOrganizationRepository struct {
+	noop.UnimplementedOrganizationRepository
+
+	db        map[string]schema.Organization
+	dbMembers map[string]schema.Member
+	mu        sync.RWMutex
+
+	loadSaver       jsonmutexdb.LoadSaver
+	filename        string
+	membersFilename string
+
+	validate *validator.Validate
+}
+
+// NewOrganizationRepository returns a new JSONMutexDB repository.
+func NewOrganizationRepository(
+	loadSaver jsonmutexdb.LoadSaver,
+	filenamePrefix string,
+) (organization.OrganizationRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &jsonMutexDBOrganizationRepository{
+		db:              make(map[string]schema.Organization),
+		dbMembers:       make(map[string]schema.Member),
+		loadSaver:       loadSaver,
+		filename:        fmt.Sprintf("%s%s.json", filenamePrefix, organizationsPrefix),
+		membersFilename: fmt.Sprintf("%s%s.json", filenamePrefix, membersPrefix),
+		validate:        validate,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) load() error {
+	if r.loadSaver != nil {
+		for filename, v := range map[string]interface{}{
+			r.filename:        &r.db,
+			r.membersFilename: &r.dbMembers,
+		} {
+			data, err := r.loadSaver.Load(filename)
+			if err != nil {
+				return err
+			}
+
+			if len(data) > 0 {
+				err = json.Unmarshal(data, v)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBOrganizationRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Organization)
+	r.dbMembers = make(map[string]schema.Member)
+
+	return r.load()
+}
+
+func (r *jsonMutexDBOrganizationRepository) commit(ctx context.Context) error {
+	if r.loadSaver != nil {
+		for filename, v := range map[string]interface{}{
+			r.filename:        r.db,
+			r.membersFilename: r.dbMembers,
+		} {
+			out, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			err = jsonmutexdb.Save(ctx, r.loadSaver, filename, out, r.reload)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+const (
+	ns                 = "organization/storage/jsonmutexdb."
+	opSave             = ns + "Save"
+	opFindByID         = ns + "FindByID"
+	opDeleteByID       = ns + "DeleteByID"
+	opDeleteAll        = ns + "DeleteAll"
+	opSaveMember       = ns + "SaveMember"
+	opFindMember       = ns + "FindMember"
+	opDeleteMember     = ns + "DeleteMember"
+	opFindAllForUser   = ns + "FindAllForUser"
+	opDeleteAllForUser = ns + "DeleteAllForUser"
+)
+
+func (r *jsonMutexDBOrganizationRepository) Save(ctx context.Context, entity *organization.Organization) (*organization.Organization, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.OrganizationToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	r.db[inS.ID] = *inS
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	savedEntity := schema.OrganizationFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) FindByID(ctx context.Context, id string) (*organization.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, ok := r.db[id]
+	if !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+	}
+
+	entity := schema.OrganizationFromSchema(&value)
+
+	return entity, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, has := r.db[id]
+
+	return has, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*organization.Organization, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*organization.Organization
+		nextCursor string
+	)
+
+	keys := []string{}
+	for id := range r.db {
+		keys = append(keys, id)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var afterCursorKey string
+	if afterCursor != "" {
+		afterCursorKey = afterCursor
+	}
+
+	for _, id := range keys {
+		if afterCursorKey != "" {
+			if afterCursorKey == id {
+				afterCursorKey = ""
+			}
+
+			continue
+		}
+
+		offset++
+
+		val := r.db[id]
+
+		t := schema.OrganizationFromSchema(&val)
+
+		result = append(result, t)
+
+		if limit == offset {
+			break // stops iterator
+		}
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.db), nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) DeleteByID(ctx context.Context, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[id]; !ok {
+		return nil
+	}
+
+	delete(r.db, id)
+
+	for key, val := range r.dbMembers {
+		if val.OrganizationID == id {
+			delete(r.dbMembers, key)
+		}
+	}
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteByID, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Organization)
+	r.dbMembers = make(map[string]schema.Member)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) SaveMember(ctx context.Context, member *organization.Member) (*organization.Member, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.MemberToSchema(member)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	if _, ok := r.db[inS.OrganizationID]; !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opSaveMember, inS.OrganizationID, database.ErrNotFound)
+	}
+
+	key := transformer.MarshalMemberID(inS)
+
+	if inS.CreatedAt.IsZero() {
+		if value, ok := r.dbMembers[key]; ok {
+			inS.CreatedAt = value.CreatedAt
+		} else {
+			inS.CreatedAt = time.Now()
+		}
+	}
+	inS.UpdatedAt = time.Now()
+
+	r.dbMembers[key] = *inS
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	savedMember := schema.MemberFromSchema(inS)
+
+	return savedMember, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) FindMember(ctx context.Context, id, userID string) (*organization.Member, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key := transformer.MarshalMemberID(&schema.Member{
+		OrganizationID: id,
+		UserID:         userID,
+	})
+
+	value, ok := r.dbMembers[key]
+	if !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opFindMember, key, database.ErrNotFound)
+	}
+
+	member := schema.MemberFromSchema(&value)
+
+	return member, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*organization.Member, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*organization.Member
+		nextCursor string
+	)
+
+	keys := []string{}
+	for key, val := range r.dbMembers {
+		if val.OrganizationID == id {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var afterCursorKey string
+	if afterCursor != "" {
+		afterCursorKey = transformer.MarshalMemberID(&schema.Member{
+			OrganizationID: id,
+			UserID:         afterCursor,
+		})
+	}
+
+	for _, key := range keys {
+		if afterCursorKey != "" {
+			if afterCursorKey == key {
+				afterCursorKey = ""
+			}
+
+			continue
+		}
+
+		offset++
+
+		val := r.dbMembers[key]
+
+		t := schema.MemberFromSchema(&val)
+
+		result = append(result, t)
+
+		if limit == offset {
+			break // stops iterator
+		}
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].UserID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) DeleteMember(ctx context.Context, id, userID string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := transformer.MarshalMemberID(&schema.Member{
+		OrganizationID: id,
+		UserID:         userID,
+	})
+
+	if _, ok := r.dbMembers[key]; !ok {
+		return nil
+	}
+
+	delete(r.dbMembers, key)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteMember, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) FindAllForUser(ctx context.Context, userID string) ([]*organization.Member, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	var (
+		result []*organization.Member
+	)
+
+	for _, val := range r.dbMembers {
+		if val.UserID != userID {
+			continue
+		}
+
+		t := schema.MemberFromSchema(&val)
+
+		result = append(result, t)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].OrganizationID < result[j].OrganizationID })
+
+	return result, nil
+}
+
+func (r *jsonMutexDBOrganizationRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if userID == "" {
+		return fmt.Errorf("%s: userID cannot be empty", opDeleteAllForUser)
+	}
+
+	for key, val := range r.dbMembers {
+		if val.UserID == userID {
+			delete(r.dbMembers, key)
+		}
+	}
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAllForUser, err)
+	}
+
+	return nil
+}
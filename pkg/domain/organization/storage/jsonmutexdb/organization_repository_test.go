@@ -0,0 +1,22 @@
+package jsonmutexdb_test
+
+import (
+	"testing"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/test"
+)
+
+func TestJSONMutexDBOrganizationRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (organization.OrganizationRepository, func()) {
+		return func(t *testing.T) (organization.OrganizationRepository, func()) {
+			repo, err := jsonmutexdb.NewOrganizationRepository(nil, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, func() {}
+		}
+	})
+}
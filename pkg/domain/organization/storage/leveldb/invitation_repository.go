@@ -0,0 +1,335 @@
+package leveldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/noop"
+)
+
+const (
+	invitationsPrefix             = "invitations"
+	invitationTokensPrefix        = "invitation_tokens"
+	organizationInvitationsPrefix = "organization_invitations"
+)
+
+// levelDBInvitationRepository is a repository that uses LevelDB database.
+type levelDBInvitationRepository struct {
+	noop.UnimplementedInvitationRepository
+
+	db *leveldb.DB
+	mu sync.Mutex
+
+	invitationsKeyspace             string
+	invitationTokensKeyspace        string
+	organizationInvitationsKeyspace string
+
+	validate *validator.Validate
+}
+
+// NewInvitationRepository returns a new LevelDB repository.
+func NewInvitationRepository(
+	db *leveldb.DB,
+	keyPrefix string,
+) (organization.InvitationRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &levelDBInvitationRepository{
+		db:                              db,
+		invitationsKeyspace:             keyPrefix + invitationsPrefix,
+		invitationTokensKeyspace:        keyPrefix + invitationTokensPrefix,
+		organizationInvitationsKeyspace: keyPrefix + organizationInvitationsPrefix,
+		validate:                        validate,
+	}
+
+	return r, nil
+}
+
+const (
+	opInvitationSave                     = ns + "Invitation.Save"
+	opInvitationFindByID                 = ns + "Invitation.FindByID"
+	opInvitationFindByToken              = ns + "Invitation.FindByToken"
+	opInvitationFindAllForOrganization   = ns + "Invitation.FindAllForOrganization"
+	opInvitationDeleteByID               = ns + "Invitation.DeleteByID"
+	opInvitationDeleteAllForOrganization = ns + "Invitation.DeleteAllForOrganization"
+	opInvitationDeleteAll                = ns + "Invitation.DeleteAll"
+)
+
+func (r *levelDBInvitationRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
+}
+
+// organizationInvitationsKeyPrefix returns the prefix of all invitations to
+// the organization.
+func (r *levelDBInvitationRepository) organizationInvitationsKeyPrefix(organizationID string) string {
+	return transformer.MarshalKey(r.organizationInvitationsKeyspace, organizationID) + "/"
+}
+
+func (r *levelDBInvitationRepository) invitationKeys(in *schema.Invitation) (string, string, string) {
+	return transformer.MarshalKey(r.invitationsKeyspace, in.ID),
+		transformer.MarshalKey(r.invitationTokensKeyspace, in.Token),
+		r.organizationInvitationsKeyPrefix(in.OrganizationID) + in.ID
+}
+
+func (r *levelDBInvitationRepository) Save(ctx context.Context, entity *organization.Invitation) (*organization.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.InvitationToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	batch := new(leveldb.Batch)
+
+	// remove indexes of the previous version
+	prev, err := r.find(ctx, inS.ID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationSave, inS.ID, err)
+	}
+
+	if prev != nil {
+		_, tokenKey, organizationKey := r.invitationKeys(prev)
+
+		batch.Delete([]byte(tokenKey))
+		batch.Delete([]byte(organizationKey))
+	}
+
+	key, tokenKey, organizationKey := r.invitationKeys(inS)
+
+	value, err := transformer.MarshalInvitation(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationSave, inS.ID, err)
+	}
+
+	batch.Put([]byte(key), value)
+	batch.Put([]byte(tokenKey), []byte(inS.ID))
+	batch.Put([]byte(organizationKey), []byte(inS.ID))
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationSave, inS.ID, err)
+	}
+
+	savedEntity := schema.InvitationFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *levelDBInvitationRepository) find(ctx context.Context, id string) (*schema.Invitation, error) {
+	key := transformer.MarshalKey(r.invitationsKeyspace, id)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, database.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return transformer.UnmarshalInvitation(value)
+}
+
+func (r *levelDBInvitationRepository) FindByID(ctx context.Context, id string) (*organization.Invitation, error) {
+	ts, err := r.find(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationFindByID, id, err)
+	}
+
+	entity := schema.InvitationFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *levelDBInvitationRepository) FindByToken(ctx context.Context, token string) (*organization.Invitation, error) {
+	if token == "" {
+		return nil, fmt.Errorf("%s: token cannot be empty", opInvitationFindByToken)
+	}
+
+	key := transformer.MarshalKey(r.invitationTokensKeyspace, token)
+
+	id, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s: %w", opInvitationFindByToken, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: %w", opInvitationFindByToken, err)
+	}
+
+	ts, err := r.find(ctx, string(id))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationFindByToken, err)
+	}
+
+	entity := schema.InvitationFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *levelDBInvitationRepository) FindAllForOrganization(ctx context.Context, organizationID string) ([]*organization.Invitation, error) {
+	if organizationID == "" {
+		return nil, fmt.Errorf("%s: organizationID cannot be empty", opInvitationFindAllForOrganization)
+	}
+
+	var (
+		result []*organization.Invitation
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.organizationInvitationsKeyPrefix(organizationID))), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		ts, err := r.find(ctx, string(iter.Value()))
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				continue
+			}
+
+			return nil, fmt.Errorf("%s(%s): %w", opInvitationFindAllForOrganization, organizationID, err)
+		}
+
+		t := schema.InvitationFromSchema(ts)
+
+		result = append(result, t)
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationFindAllForOrganization, organizationID, err)
+	}
+
+	return result, nil
+}
+
+func (r *levelDBInvitationRepository) DeleteByID(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ts, err := r.find(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("%s(%s): %w", opInvitationDeleteByID, id, err)
+	}
+
+	batch := new(leveldb.Batch)
+
+	key, tokenKey, organizationKey := r.invitationKeys(ts)
+
+	batch.Delete([]byte(key))
+	batch.Delete([]byte(tokenKey))
+	batch.Delete([]byte(organizationKey))
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opInvitationDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBInvitationRepository) DeleteAllForOrganization(ctx context.Context, organizationID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if organizationID == "" {
+		return fmt.Errorf("%s: organizationID cannot be empty", opInvitationDeleteAllForOrganization)
+	}
+
+	batch := new(leveldb.Batch)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.organizationInvitationsKeyPrefix(organizationID))), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+
+		ts, err := r.find(ctx, string(iter.Value()))
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				continue
+			}
+
+			return fmt.Errorf("%s(%s): %w", opInvitationDeleteAllForOrganization, organizationID, err)
+		}
+
+		key, tokenKey, _ := r.invitationKeys(ts)
+
+		batch.Delete([]byte(key))
+		batch.Delete([]byte(tokenKey))
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opInvitationDeleteAllForOrganization, organizationID, err)
+	}
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opInvitationDeleteAllForOrganization, organizationID, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBInvitationRepository) DeleteAll(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	for _, keyspace := range []string{
+		r.invitationsKeyspace,
+		r.invitationTokensKeyspace,
+		r.organizationInvitationsKeyspace,
+	} {
+		iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(keyspace+"/")), nil)
+
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+
+		iter.Release()
+
+		err := iter.Error()
+		if err != nil {
+			return fmt.Errorf("%s: %w", opInvitationDeleteAll, err)
+		}
+	}
+
+	err := r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opInvitationDeleteAll, err)
+	}
+
+	return nil
+}
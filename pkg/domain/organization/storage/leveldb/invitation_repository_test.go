@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/test"
+)
+
+func TestLevelDBInvitationRepository(t *testing.T) {
+	test.RunInvitation(t, func() func(t *testing.T) (organization.InvitationRepository, func()) {
+		return func(t *testing.T) (organization.InvitationRepository, func()) {
+			db, cleanup := database_leveldb.Fixture()
+
+			repo, err := leveldb.NewInvitationRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
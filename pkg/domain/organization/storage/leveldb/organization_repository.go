@@ -0,0 +1,570 @@
+package leveldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/noop"
+)
+
+const (
+	organizationsPrefix     = "organizations"
+	membersPrefix           = "organization_members"
+	userOrganizationsPrefix = "user_organizations"
+)
+
+// levelDBOrganizationRepository is a repository that uses LevelDB database.
+type levelDBOrganizationRepository struct {
+	noop.UnimplementedOrganizationRepository
+
+	db *leveldb.DB
+	mu sync.Mutex
+
+	organizationsKeyspace     string
+	membersKeyspace           string
+	userOrganizationsKeyspace string
+
+	validate *validator.Validate
+}
+
+// NewOrganizationRepository returns a new LevelDB repository.
+func NewOrganizationRepository(
+	db *leveldb.DB,
+	keyPrefix string,
+) (organization.OrganizationRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &levelDBOrganizationRepository{
+		db:                        db,
+		organizationsKeyspace:     keyPrefix + organizationsPrefix,
+		membersKeyspace:           keyPrefix + membersPrefix,
+		userOrganizationsKeyspace: keyPrefix + userOrganizationsPrefix,
+		validate:                  validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns                 = "organization/storage/leveldb."
+	opSave             = ns + "Save"
+	opFindByID         = ns + "FindByID"
+	opExistsByID       = ns + "ExistsByID"
+	opFindAll          = ns + "FindAll"
+	opCount            = ns + "Count"
+	opDeleteByID       = ns + "DeleteByID"
+	opDeleteAll        = ns + "DeleteAll"
+	opSaveMember       = ns + "SaveMember"
+	opFindMember       = ns + "FindMember"
+	opFindAllMembers   = ns + "FindAllMembers"
+	opDeleteMember     = ns + "DeleteMember"
+	opFindAllForUser   = ns + "FindAllForUser"
+	opDeleteAllForUser = ns + "DeleteAllForUser"
+)
+
+func (r *levelDBOrganizationRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
+}
+
+// membersKeyPrefix returns the prefix of all members of the organization.
+func (r *levelDBOrganizationRepository) membersKeyPrefix(id string) string {
+	return transformer.MarshalKey(r.membersKeyspace, id) + "/"
+}
+
+// userOrganizationsKeyPrefix returns the prefix of all memberships of the
+// user.
+func (r *levelDBOrganizationRepository) userOrganizationsKeyPrefix(userID string) string {
+	return transformer.MarshalKey(r.userOrganizationsKeyspace, userID) + "/"
+}
+
+func (r *levelDBOrganizationRepository) memberKeys(member *schema.Member) (string, string) {
+	return transformer.MarshalKey(r.membersKeyspace, transformer.MarshalMemberID(member)),
+		transformer.MarshalKey(r.userOrganizationsKeyspace, transformer.MarshalUserMemberID(member))
+}
+
+func (r *levelDBOrganizationRepository) Save(ctx context.Context, entity *organization.Organization) (*organization.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.OrganizationToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	key := transformer.MarshalKey(r.organizationsKeyspace, inS.ID)
+
+	value, err := transformer.MarshalOrganization(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = database_leveldb.From(ctx, r.db).Put([]byte(key), value, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.OrganizationFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *levelDBOrganizationRepository) FindByID(ctx context.Context, id string) (*organization.Organization, error) {
+	key := transformer.MarshalKey(r.organizationsKeyspace, id)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	ts, err := transformer.UnmarshalOrganization(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.OrganizationFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *levelDBOrganizationRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	key := transformer.MarshalKey(r.organizationsKeyspace, id)
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *levelDBOrganizationRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*organization.Organization, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*organization.Organization
+		nextCursor string
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.organizationsKeyspace+"/")), nil)
+	defer iter.Release()
+
+	if afterCursor != "" {
+		key := transformer.MarshalKey(r.organizationsKeyspace, afterCursor)
+
+		if ok := iter.Seek([]byte(key)); !ok {
+			err := iter.Error()
+			if err != nil {
+				return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+			}
+		}
+	}
+
+	for iter.Next() {
+		offset++
+
+		ts, err := transformer.UnmarshalOrganization(iter.Value())
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, string(iter.Key()), err)
+		}
+
+		t := schema.OrganizationFromSchema(ts)
+
+		result = append(result, t)
+
+		if limit == offset {
+			break // stops iterator
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *levelDBOrganizationRepository) Count(ctx context.Context) (int, error) {
+	var (
+		count          int
+		ctxCheckOffset int
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.organizationsKeyspace+"/")), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if count == ctxCheckOffset {
+			select {
+			case <-ctx.Done():
+				return count, fmt.Errorf("%s: %w", opCount, ctx.Err())
+			default:
+			}
+
+			ctxCheckOffset += 100
+		}
+
+		count++
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *levelDBOrganizationRepository) DeleteByID(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := transformer.MarshalKey(r.organizationsKeyspace, id)
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	if !has {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+
+	batch.Delete([]byte(key))
+
+	// remove all members
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.membersKeyPrefix(id))), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		ts, err := transformer.UnmarshalMember(iter.Value())
+		if err != nil {
+			return fmt.Errorf("%s(%s): %w", opDeleteByID, string(iter.Key()), err)
+		}
+
+		memberKey, userKey := r.memberKeys(ts)
+
+		batch.Delete([]byte(memberKey))
+		batch.Delete([]byte(userKey))
+	}
+
+	err = iter.Error()
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBOrganizationRepository) DeleteAll(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	for _, keyspace := range []string{
+		r.organizationsKeyspace,
+		r.membersKeyspace,
+		r.userOrganizationsKeyspace,
+	} {
+		iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(keyspace+"/")), nil)
+
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+
+		iter.Release()
+
+		err := iter.Error()
+		if err != nil {
+			return fmt.Errorf("%s: %w", opDeleteAll, err)
+		}
+	}
+
+	err := r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBOrganizationRepository) SaveMember(ctx context.Context, member *organization.Member) (*organization.Member, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.MemberToSchema(member)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(transformer.MarshalKey(r.organizationsKeyspace, inS.OrganizationID)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSaveMember, inS.OrganizationID, err)
+	}
+
+	if !has {
+		return nil, fmt.Errorf("%s(%s): %w", opSaveMember, inS.OrganizationID, database.ErrNotFound)
+	}
+
+	memberKey, userKey := r.memberKeys(inS)
+
+	if inS.CreatedAt.IsZero() {
+		value, err := database_leveldb.From(ctx, r.db).Get([]byte(memberKey), nil)
+		switch {
+		case err == nil:
+			ts, err := transformer.UnmarshalMember(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s(%s): %w", opSaveMember, memberKey, err)
+			}
+
+			inS.CreatedAt = ts.CreatedAt
+		case errors.Is(err, leveldb.ErrNotFound):
+			inS.CreatedAt = time.Now()
+		default:
+			return nil, fmt.Errorf("%s(%s): %w", opSaveMember, memberKey, err)
+		}
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalMember(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSaveMember, memberKey, err)
+	}
+
+	batch := new(leveldb.Batch)
+
+	batch.Put([]byte(memberKey), value)
+	batch.Put([]byte(userKey), value)
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSaveMember, memberKey, err)
+	}
+
+	savedMember := schema.MemberFromSchema(inS)
+
+	return savedMember, nil
+}
+
+func (r *levelDBOrganizationRepository) FindMember(ctx context.Context, id, userID string) (*organization.Member, error) {
+	memberKey, _ := r.memberKeys(&schema.Member{
+		OrganizationID: id,
+		UserID:         userID,
+	})
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(memberKey), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindMember, memberKey, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindMember, memberKey, err)
+	}
+
+	ts, err := transformer.UnmarshalMember(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindMember, memberKey, err)
+	}
+
+	member := schema.MemberFromSchema(ts)
+
+	return member, nil
+}
+
+func (r *levelDBOrganizationRepository) FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*organization.Member, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*organization.Member
+		nextCursor string
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.membersKeyPrefix(id))), nil)
+	defer iter.Release()
+
+	if afterCursor != "" {
+		key := r.membersKeyPrefix(id) + afterCursor
+
+		if ok := iter.Seek([]byte(key)); !ok {
+			err := iter.Error()
+			if err != nil {
+				return nil, "", fmt.Errorf("%s(%s): %w", opFindAllMembers, afterCursor, err)
+			}
+		}
+	}
+
+	for iter.Next() {
+		offset++
+
+		ts, err := transformer.UnmarshalMember(iter.Value())
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opFindAllMembers, string(iter.Key()), err)
+		}
+
+		t := schema.MemberFromSchema(ts)
+
+		result = append(result, t)
+
+		if limit == offset {
+			break // stops iterator
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAllMembers, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].UserID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *levelDBOrganizationRepository) DeleteMember(ctx context.Context, id, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	memberKey, userKey := r.memberKeys(&schema.Member{
+		OrganizationID: id,
+		UserID:         userID,
+	})
+
+	batch := new(leveldb.Batch)
+
+	batch.Delete([]byte(memberKey))
+	batch.Delete([]byte(userKey))
+
+	err := r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteMember, memberKey, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBOrganizationRepository) FindAllForUser(ctx context.Context, userID string) ([]*organization.Member, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	var (
+		result []*organization.Member
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.userOrganizationsKeyPrefix(userID))), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		ts, err := transformer.UnmarshalMember(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, string(iter.Key()), err)
+		}
+
+		t := schema.MemberFromSchema(ts)
+
+		result = append(result, t)
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	return result, nil
+}
+
+func (r *levelDBOrganizationRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if userID == "" {
+		return fmt.Errorf("%s: userID cannot be empty", opDeleteAllForUser)
+	}
+
+	batch := new(leveldb.Batch)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.userOrganizationsKeyPrefix(userID))), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		ts, err := transformer.UnmarshalMember(iter.Value())
+		if err != nil {
+			return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, string(iter.Key()), err)
+		}
+
+		memberKey, userKey := r.memberKeys(ts)
+
+		batch.Delete([]byte(memberKey))
+		batch.Delete([]byte(userKey))
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, userID, err)
+	}
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, userID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/test"
+)
+
+func TestLevelDBOrganizationRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (organization.OrganizationRepository, func()) {
+		return func(t *testing.T) (organization.OrganizationRepository, func()) {
+			db, cleanup := database_leveldb.Fixture()
+
+			repo, err := leveldb.NewOrganizationRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package noop
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+)
+
+// Compile-time proof of interface implementation.
+var _ organization.InvitationRepository = (*UnimplementedInvitationRepository)(nil)
+
+// UnimplementedInvitationRepository can be embedded to have forward compatible implementations.
+type UnimplementedInvitationRepository struct{}
+
+func (*UnimplementedInvitationRepository) Save(ctx context.Context, entity *organization.Invitation) (*organization.Invitation, error) {
+	panic("Save not implemented")
+}
+
+func (*UnimplementedInvitationRepository) FindByID(ctx context.Context, id string) (*organization.Invitation, error) {
+	panic("FindByID not implemented")
+}
+
+func (*UnimplementedInvitationRepository) FindByToken(ctx context.Context, token string) (*organization.Invitation, error) {
+	panic("FindByToken not implemented")
+}
+
+func (*UnimplementedInvitationRepository) FindAllForOrganization(ctx context.Context, organizationID string) ([]*organization.Invitation, error) {
+	panic("FindAllForOrganization not implemented")
+}
+
+func (*UnimplementedInvitationRepository) DeleteByID(ctx context.Context, id string) error {
+	panic("DeleteByID not implemented")
+}
+
+func (*UnimplementedInvitationRepository) DeleteAllForOrganization(ctx context.Context, organizationID string) error {
+	panic("DeleteAllForOrganization not implemented")
+}
+
+func (*UnimplementedInvitationRepository) DeleteAll(ctx context.Context) error {
+	panic("DeleteAll not implemented")
+}
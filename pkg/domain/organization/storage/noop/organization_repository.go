@@ -0,0 +1,65 @@
+package noop
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+)
+
+// Compile-time proof of interface implementation.
+var _ organization.OrganizationRepository = (*UnimplementedOrganizationRepository)(nil)
+
+// UnimplementedOrganizationRepository can be embedded to have forward compatible implementations.
+type UnimplementedOrganizationRepository struct{}
+
+func (*UnimplementedOrganizationRepository) Save(ctx context.Context, entity *organization.Organization) (*organization.Organization, error) {
+	panic("Save not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) FindByID(ctx context.Context, id string) (*organization.Organization, error) {
+	panic("FindByID not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	panic("ExistsByID not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*organization.Organization, string, error) {
+	panic("FindAll not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) Count(ctx context.Context) (int, error) {
+	panic("Count not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) DeleteByID(ctx context.Context, id string) error {
+	panic("DeleteByID not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) DeleteAll(ctx context.Context) error {
+	panic("DeleteAll not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) SaveMember(ctx context.Context, member *organization.Member) (*organization.Member, error) {
+	panic("SaveMember not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) FindMember(ctx context.Context, id, userID string) (*organization.Member, error) {
+	panic("FindMember not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*organization.Member, string, error) {
+	panic("FindAllMembers not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) DeleteMember(ctx context.Context, id, userID string) error {
+	panic("DeleteMember not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) FindAllForUser(ctx context.Context, userID string) ([]*organization.Member, error) {
+	panic("FindAllForUser not implemented")
+}
+
+func (*UnimplementedOrganizationRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	panic("DeleteAllForUser not implemented")
+}
@@ -0,0 +1,217 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/noop"
+)
+
+const (
+	invitationsTable = "invitations"
+)
+
+// sqliteInvitationRepository is a repository that uses SQLite database.
+type sqliteInvitationRepository struct {
+	noop.UnimplementedInvitationRepository
+
+	db *database_sqlite.DB
+
+	invitationsTable string
+
+	validate *validator.Validate
+}
+
+// NewInvitationRepository returns a new SQLite repository.
+func NewInvitationRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (organization.InvitationRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &sqliteInvitationRepository{
+		db:               db,
+		invitationsTable: database_sqlite.Table(tablePrefix, invitationsTable),
+		validate:         validate,
+	}
+
+	return r, nil
+}
+
+const (
+	opInvitationSave                     = ns + "Invitation.Save"
+	opInvitationFindByID                 = ns + "Invitation.FindByID"
+	opInvitationFindByToken              = ns + "Invitation.FindByToken"
+	opInvitationFindAllForOrganization   = ns + "Invitation.FindAllForOrganization"
+	opInvitationDeleteByID               = ns + "Invitation.DeleteByID"
+	opInvitationDeleteAllForOrganization = ns + "Invitation.DeleteAllForOrganization"
+	opInvitationDeleteAll                = ns + "Invitation.DeleteAll"
+)
+
+func (r *sqliteInvitationRepository) Save(ctx context.Context, entity *organization.Invitation) (*organization.Invitation, error) {
+	inS := schema.InvitationToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	value, err := transformer.MarshalInvitation(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationSave, inS.ID, err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.invitationsTable+` (id, organization_id, token, data) VALUES (?, ?, ?, ?)`,
+		inS.ID, inS.OrganizationID, inS.Token, string(value),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationSave, inS.ID, err)
+	}
+
+	savedEntity := schema.InvitationFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+// findOne returns the invitation found by the query, which selects the data.
+func (r *sqliteInvitationRepository) findOne(ctx context.Context, query string, args ...interface{}) (*organization.Invitation, error) {
+	var value string
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	ts, err := transformer.UnmarshalInvitation([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.InvitationFromSchema(ts), nil
+}
+
+func (r *sqliteInvitationRepository) FindByID(ctx context.Context, id string) (*organization.Invitation, error) {
+	entity, err := r.findOne(ctx, `SELECT data FROM `+r.invitationsTable+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationFindByID, id, err)
+	}
+
+	return entity, nil
+}
+
+func (r *sqliteInvitationRepository) FindByToken(ctx context.Context, token string) (*organization.Invitation, error) {
+	if token == "" {
+		return nil, fmt.Errorf("%s: token cannot be empty", opInvitationFindByToken)
+	}
+
+	entity, err := r.findOne(ctx, `SELECT data FROM `+r.invitationsTable+` WHERE token = ? LIMIT 1`, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opInvitationFindByToken, err)
+	}
+
+	return entity, nil
+}
+
+func (r *sqliteInvitationRepository) FindAllForOrganization(ctx context.Context, organizationID string) ([]*organization.Invitation, error) {
+	if organizationID == "" {
+		return nil, fmt.Errorf("%s: organizationID cannot be empty", opInvitationFindAllForOrganization)
+	}
+
+	var (
+		result []*organization.Invitation
+	)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT data FROM `+r.invitationsTable+` WHERE organization_id = ? ORDER BY id`,
+		organizationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationFindAllForOrganization, organizationID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", opInvitationFindAllForOrganization, organizationID, err)
+		}
+
+		ts, err := transformer.UnmarshalInvitation([]byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", opInvitationFindAllForOrganization, organizationID, err)
+		}
+
+		result = append(result, schema.InvitationFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opInvitationFindAllForOrganization, organizationID, err)
+	}
+
+	return result, nil
+}
+
+func (r *sqliteInvitationRepository) DeleteByID(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.invitationsTable+` WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opInvitationDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteInvitationRepository) DeleteAllForOrganization(ctx context.Context, organizationID string) error {
+	if organizationID == "" {
+		return fmt.Errorf("%s: organizationID cannot be empty", opInvitationDeleteAllForOrganization)
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.invitationsTable+` WHERE organization_id = ?`, organizationID)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opInvitationDeleteAllForOrganization, organizationID, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteInvitationRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.invitationsTable)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opInvitationDeleteAll, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,453 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/noop"
+)
+
+const (
+	organizationsTable = "organizations"
+	membersTable       = "organization_members"
+)
+
+// sqliteOrganizationRepository is a repository that uses SQLite database.
+type sqliteOrganizationRepository struct {
+	noop.UnimplementedOrganizationRepository
+
+	db *database_sqlite.DB
+
+	organizationsTable string
+	membersTable       string
+
+	validate *validator.Validate
+}
+
+// NewOrganizationRepository returns a new SQLite repository.
+func NewOrganizationRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (organization.OrganizationRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &sqliteOrganizationRepository{
+		db:                 db,
+		organizationsTable: database_sqlite.Table(tablePrefix, organizationsTable),
+		membersTable:       database_sqlite.Table(tablePrefix, membersTable),
+		validate:           validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns                 = "organization/storage/sqlite."
+	opSave             = ns + "Save"
+	opFindByID         = ns + "FindByID"
+	opExistsByID       = ns + "ExistsByID"
+	opFindAll          = ns + "FindAll"
+	opCount            = ns + "Count"
+	opDeleteByID       = ns + "DeleteByID"
+	opDeleteAll        = ns + "DeleteAll"
+	opSaveMember       = ns + "SaveMember"
+	opFindMember       = ns + "FindMember"
+	opFindAllMembers   = ns + "FindAllMembers"
+	opDeleteMember     = ns + "DeleteMember"
+	opFindAllForUser   = ns + "FindAllForUser"
+	opDeleteAllForUser = ns + "DeleteAllForUser"
+)
+
+func (r *sqliteOrganizationRepository) Save(ctx context.Context, entity *organization.Organization) (*organization.Organization, error) {
+	inS := schema.OrganizationToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalOrganization(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.organizationsTable+` (id, data) VALUES (?, ?)`,
+		inS.ID, string(value),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.OrganizationFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *sqliteOrganizationRepository) FindByID(ctx context.Context, id string) (*organization.Organization, error) {
+	var value string
+
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM `+r.organizationsTable+` WHERE id = ?`, id).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	ts, err := transformer.UnmarshalOrganization([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.OrganizationFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *sqliteOrganizationRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+r.organizationsTable+` WHERE id = ?)`,
+		id,
+	).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *sqliteOrganizationRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*organization.Organization, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*organization.Organization
+		nextCursor string
+	)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT data FROM `+r.organizationsTable+` WHERE id > ? ORDER BY id LIMIT ?`,
+		afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+		}
+
+		ts, err := transformer.UnmarshalOrganization([]byte(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+		}
+
+		result = append(result, schema.OrganizationFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteOrganizationRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.organizationsTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqliteOrganizationRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM `+r.organizationsTable+` WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+
+		// remove all members
+		_, err = tx.ExecContext(ctx, `DELETE FROM `+r.membersTable+` WHERE organization_id = ?`, id)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteOrganizationRepository) DeleteAll(ctx context.Context) error {
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{r.organizationsTable, r.membersTable} {
+			_, err := tx.ExecContext(ctx, `DELETE FROM `+table)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteOrganizationRepository) SaveMember(ctx context.Context, member *organization.Member) (*organization.Member, error) {
+	inS := schema.MemberToSchema(member)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveMember, err)
+	}
+
+	memberID := transformer.MarshalMemberID(inS)
+
+	err = r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		var has bool
+
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM `+r.organizationsTable+` WHERE id = ?)`,
+			inS.OrganizationID,
+		).Scan(&has)
+		if err != nil {
+			return err
+		}
+
+		if !has {
+			return database.ErrNotFound
+		}
+
+		if inS.CreatedAt.IsZero() {
+			var value string
+
+			err := tx.QueryRowContext(ctx,
+				`SELECT data FROM `+r.membersTable+` WHERE organization_id = ? AND user_id = ?`,
+				inS.OrganizationID, inS.UserID,
+			).Scan(&value)
+			switch {
+			case err == nil:
+				ts, err := transformer.UnmarshalMember([]byte(value))
+				if err != nil {
+					return err
+				}
+
+				inS.CreatedAt = ts.CreatedAt
+			case errors.Is(err, sql.ErrNoRows):
+				inS.CreatedAt = time.Now()
+			default:
+				return err
+			}
+		}
+		inS.UpdatedAt = time.Now()
+
+		value, err := transformer.MarshalMember(inS)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO `+r.membersTable+` (organization_id, user_id, data) VALUES (?, ?, ?)`,
+			inS.OrganizationID, inS.UserID, string(value),
+		)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSaveMember, memberID, err)
+	}
+
+	savedMember := schema.MemberFromSchema(inS)
+
+	return savedMember, nil
+}
+
+func (r *sqliteOrganizationRepository) FindMember(ctx context.Context, id, userID string) (*organization.Member, error) {
+	memberID := transformer.MarshalMemberID(&schema.Member{
+		OrganizationID: id,
+		UserID:         userID,
+	})
+
+	var value string
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT data FROM `+r.membersTable+` WHERE organization_id = ? AND user_id = ?`,
+		id, userID,
+	).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindMember, memberID, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindMember, memberID, err)
+	}
+
+	ts, err := transformer.UnmarshalMember([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindMember, memberID, err)
+	}
+
+	member := schema.MemberFromSchema(ts)
+
+	return member, nil
+}
+
+// findAllMembers returns the members selected by the query, which selects
+// the data.
+func (r *sqliteOrganizationRepository) findAllMembers(ctx context.Context, query string, args ...interface{}) ([]*organization.Member, error) {
+	var result []*organization.Member
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		ts, err := transformer.UnmarshalMember([]byte(value))
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, schema.MemberFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *sqliteOrganizationRepository) FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*organization.Member, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var nextCursor string
+
+	result, err := r.findAllMembers(ctx,
+		`SELECT data FROM `+r.membersTable+`
+		WHERE organization_id = ? AND user_id > ?
+		ORDER BY user_id LIMIT ?`,
+		id, afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAllMembers, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].UserID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteOrganizationRepository) DeleteMember(ctx context.Context, id, userID string) error {
+	memberID := transformer.MarshalMemberID(&schema.Member{
+		OrganizationID: id,
+		UserID:         userID,
+	})
+
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM `+r.membersTable+` WHERE organization_id = ? AND user_id = ?`,
+		id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteMember, memberID, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteOrganizationRepository) FindAllForUser(ctx context.Context, userID string) ([]*organization.Member, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	result, err := r.findAllMembers(ctx,
+		`SELECT data FROM `+r.membersTable+` WHERE user_id = ? ORDER BY organization_id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	return result, nil
+}
+
+func (r *sqliteOrganizationRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("%s: userID cannot be empty", opDeleteAllForUser)
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.membersTable+` WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, userID, err)
+	}
+
+	return nil
+}
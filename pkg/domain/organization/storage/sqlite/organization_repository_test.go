@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/test"
+)
+
+func TestSQLiteOrganizationRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (organization.OrganizationRepository, func()) {
+		return func(t *testing.T) (organization.OrganizationRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewOrganizationRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
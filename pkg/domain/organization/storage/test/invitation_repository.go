@@ -0,0 +1,286 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/ulid"
+)
+
+func createInvitations(t *testing.T, repo organization.InvitationRepository, organizationID string, count int) []*organization.Invitation {
+	t.Helper()
+
+	var result []*organization.Invitation
+
+	ctx := context.Background()
+
+	for i := 0; i < count; i++ {
+		entity := &organization.Invitation{
+			ID:             ulid.ULID().String(),
+			OrganizationID: organizationID,
+			Email:          fmt.Sprintf("user%d@example.com", i),
+			Roles:          []string{"member"},
+			Token:          ulid.ULID().String(),
+			ExpiresAt:      time.Now().Add(time.Hour),
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		result = append(result, savedEntity)
+	}
+
+	return result
+}
+
+func assertInvitationEqual(t *testing.T, expected, actual *organization.Invitation) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.OrganizationID, actual.OrganizationID)
+	assert.Equal(t, expected.Email, actual.Email)
+	assert.Equal(t, expected.Roles, actual.Roles)
+	assert.Equal(t, expected.Token, actual.Token)
+	assert.Equal(t, expected.ExpiresAt.Unix(), actual.ExpiresAt.Unix())
+}
+
+func RunInvitation(t *testing.T, f func() func(t *testing.T) (organization.InvitationRepository, func())) {
+	t.Helper()
+
+	t.Run("init", func(t *testing.T) {
+		_, cleanup := f()(t)
+		defer cleanup()
+	})
+	t.Run("Save", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testInvitationRepositorySave(t, repo)
+	})
+	t.Run("FindByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testInvitationRepositoryFindByID(t, repo)
+	})
+	t.Run("FindByToken", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testInvitationRepositoryFindByToken(t, repo)
+	})
+	t.Run("FindAllForOrganization", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testInvitationRepositoryFindAllForOrganization(t, repo)
+	})
+	t.Run("DeleteByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testInvitationRepositoryDeleteByID(t, repo)
+	})
+	t.Run("DeleteAllForOrganization", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testInvitationRepositoryDeleteAllForOrganization(t, repo)
+	})
+}
+
+func testInvitationRepositorySave(t *testing.T, repo organization.InvitationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := repo.Save(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := repo.Save(ctx, &organization.Invitation{
+			ID:             "invitation",
+			OrganizationID: "org",
+			Email:          "invalid",
+			Token:          "token",
+			ExpiresAt:      time.Now(),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("simple", func(t *testing.T) {
+		invitations := createInvitations(t, repo, "org", 1)
+
+		assert.False(t, invitations[0].CreatedAt.IsZero())
+	})
+
+	t.Run("token change", func(t *testing.T) {
+		invitations := createInvitations(t, repo, "org", 1)
+
+		oldToken := invitations[0].Token
+		invitations[0].Token = ulid.ULID().String()
+
+		_, err := repo.Save(ctx, invitations[0])
+		require.NoError(t, err)
+
+		_, err = repo.FindByToken(ctx, oldToken)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		entity, err := repo.FindByToken(ctx, invitations[0].Token)
+		require.NoError(t, err)
+
+		assertInvitationEqual(t, invitations[0], entity)
+	})
+}
+
+func testInvitationRepositoryFindByID(t *testing.T, repo organization.InvitationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	invitations := createInvitations(t, repo, "org", 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, "non_existent_id")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		entity, err := repo.FindByID(ctx, invitations[0].ID)
+		require.NoError(t, err)
+
+		assertInvitationEqual(t, invitations[0], entity)
+	})
+}
+
+func testInvitationRepositoryFindByToken(t *testing.T, repo organization.InvitationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	invitations := createInvitations(t, repo, "org", 2)
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := repo.FindByToken(ctx, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByToken(ctx, "non_existent_token")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		entity, err := repo.FindByToken(ctx, invitations[1].Token)
+		require.NoError(t, err)
+
+		assertInvitationEqual(t, invitations[1], entity)
+	})
+}
+
+func testInvitationRepositoryFindAllForOrganization(t *testing.T, repo organization.InvitationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	invitations := createInvitations(t, repo, "org1", 3)
+	createInvitations(t, repo, "org2", 2)
+
+	t.Run("empty", func(t *testing.T) {
+		results, err := repo.FindAllForOrganization(ctx, "")
+		assert.Error(t, err)
+
+		assert.Equal(t, 0, len(results))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		results, err := repo.FindAllForOrganization(ctx, "org1")
+		require.NoError(t, err)
+
+		require.Equal(t, len(invitations), len(results))
+
+		var expectedIDs, actualIDs []string
+		for i := range invitations {
+			expectedIDs = append(expectedIDs, invitations[i].ID)
+			actualIDs = append(actualIDs, results[i].ID)
+		}
+
+		assert.ElementsMatch(t, expectedIDs, actualIDs)
+	})
+}
+
+func testInvitationRepositoryDeleteByID(t *testing.T, repo organization.InvitationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	invitations := createInvitations(t, repo, "org", 2)
+
+	t.Run("non existent", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, invitations[0].ID)
+		require.NoError(t, err)
+
+		_, err = repo.FindByID(ctx, invitations[0].ID)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		_, err = repo.FindByToken(ctx, invitations[0].Token)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		results, err := repo.FindAllForOrganization(ctx, "org")
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(results))
+		assertInvitationEqual(t, invitations[1], results[0])
+	})
+}
+
+func testInvitationRepositoryDeleteAllForOrganization(t *testing.T, repo organization.InvitationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	invitations := createInvitations(t, repo, "org1", 2)
+	createInvitations(t, repo, "org2", 1)
+
+	t.Run("empty", func(t *testing.T) {
+		err := repo.DeleteAllForOrganization(ctx, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.DeleteAllForOrganization(ctx, "org1")
+		require.NoError(t, err)
+
+		results1, err := repo.FindAllForOrganization(ctx, "org1")
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, len(results1))
+
+		_, err = repo.FindByToken(ctx, invitations[0].Token)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		results2, err := repo.FindAllForOrganization(ctx, "org2")
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, len(results2))
+	})
+}
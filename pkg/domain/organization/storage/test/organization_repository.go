@@ -0,0 +1,534 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/organization/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/jsonmap"
+)
+
+const (
+	TestPrefix = "test-"
+)
+
+func testValidator() *validator.Validate {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+	return validate
+}
+
+func createOrganizations(t *testing.T, repo organization.OrganizationRepository, count int) []*organization.Organization {
+	t.Helper()
+
+	var result []*organization.Organization
+
+	ctx := context.Background()
+
+	for i := 0; i < count; i++ {
+		entity := &organization.Organization{
+			ID:          fmt.Sprintf("org%d", i),
+			Name:        fmt.Sprintf("org-%d", i),
+			DisplayName: fmt.Sprintf("Organization %d", i),
+			MetaData:    jsonmap.JSONMap{"plan": "free"},
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		result = append(result, savedEntity)
+	}
+
+	return result
+}
+
+func assertOrganizationEqual(t *testing.T, expected, actual *organization.Organization) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Name, actual.Name)
+	assert.Equal(t, expected.DisplayName, actual.DisplayName)
+	assert.Equal(t, expected.MetaData, actual.MetaData)
+}
+
+func Run(t *testing.T, f func() func(t *testing.T) (organization.OrganizationRepository, func())) {
+	t.Helper()
+
+	t.Run("init", func(t *testing.T) {
+		_, cleanup := f()(t)
+		defer cleanup()
+	})
+	t.Run("Save", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositorySave(t, repo)
+	})
+	t.Run("FindByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositoryFindByID(t, repo)
+	})
+	t.Run("ExistsByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositoryExistsByID(t, repo)
+	})
+	t.Run("FindAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositoryFindAll(t, repo)
+	})
+	t.Run("Count", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositoryCount(t, repo)
+	})
+	t.Run("DeleteByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositoryDeleteByID(t, repo)
+	})
+	t.Run("SaveMember", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositorySaveMember(t, repo)
+	})
+	t.Run("FindAllMembers", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositoryFindAllMembers(t, repo)
+	})
+	t.Run("DeleteAllForUser", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositoryDeleteAllForUser(t, repo)
+	})
+	t.Run("DeleteAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testOrganizationRepositoryDeleteAll(t, repo)
+	})
+}
+
+func testOrganizationRepositorySave(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	validate := testValidator()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := repo.Save(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		entity := &organization.Organization{}
+
+		_, err := repo.Save(ctx, entity)
+		assert.Error(t, err)
+
+		inS := schema.OrganizationToSchema(entity)
+		validateErr := validate.Struct(inS)
+
+		assert.Contains(t, err.Error(), validateErr.Error())
+	})
+
+	t.Run("simple", func(t *testing.T) {
+		entity := &organization.Organization{
+			ID:   "acme",
+			Name: "Acme",
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		assert.False(t, savedEntity.CreatedAt.IsZero())
+		assert.False(t, savedEntity.UpdatedAt.IsZero())
+	})
+}
+
+func testOrganizationRepositoryFindByID(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	organizations := createOrganizations(t, repo, 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, "non_existent_id")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		entity, err := repo.FindByID(ctx, organizations[0].ID)
+		require.NoError(t, err)
+
+		assertOrganizationEqual(t, organizations[0], entity)
+	})
+}
+
+func testOrganizationRepositoryExistsByID(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	organizations := createOrganizations(t, repo, 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		exists, err := repo.ExistsByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+
+		assert.False(t, exists)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		exists, err := repo.ExistsByID(ctx, organizations[0].ID)
+		require.NoError(t, err)
+
+		assert.True(t, exists)
+	})
+}
+
+func testOrganizationRepositoryFindAll(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("empty", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAll(ctx, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	createCount := 7
+
+	organizations := createOrganizations(t, repo, createCount)
+
+	// members are not listed as organizations
+	_, err := repo.SaveMember(ctx, &organization.Member{OrganizationID: organizations[0].ID, UserID: "user"})
+	require.NoError(t, err)
+
+	t.Run("ok", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAll(ctx, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, createCount, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		limit := 5
+
+		results, nextCursor, err := repo.FindAll(ctx, "", limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, limit, len(results))
+		assert.Equal(t, organizations[limit-1].ID, nextCursor)
+
+		// next page
+		results, nextCursor, err = repo.FindAll(ctx, nextCursor, limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, createCount-limit, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+}
+
+func testOrganizationRepositoryCount(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, count)
+
+	createCount := 3
+
+	createOrganizations(t, repo, createCount)
+
+	count, err = repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, createCount, count)
+}
+
+func testOrganizationRepositoryDeleteByID(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	userID := "test"
+
+	organizations := createOrganizations(t, repo, 2)
+
+	for _, o := range organizations {
+		_, err := repo.SaveMember(ctx, &organization.Member{OrganizationID: o.ID, UserID: userID})
+		require.NoError(t, err)
+	}
+
+	t.Run("non existent", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, organizations[0].ID)
+		require.NoError(t, err)
+
+		exists, err := repo.ExistsByID(ctx, organizations[0].ID)
+		require.NoError(t, err)
+
+		assert.False(t, exists)
+
+		results, err := repo.FindAllForUser(ctx, userID)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(results))
+		assert.Equal(t, organizations[1].ID, results[0].OrganizationID)
+
+		// members are not restored with the organization
+		_, err = repo.Save(ctx, organizations[0])
+		require.NoError(t, err)
+
+		_, err = repo.FindMember(ctx, organizations[0].ID, userID)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+}
+
+func testOrganizationRepositorySaveMember(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	user1ID := "test1"
+	user2ID := "test2"
+
+	organizations := createOrganizations(t, repo, 2)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.SaveMember(ctx, &organization.Member{OrganizationID: "non_existent_id", UserID: user1ID})
+		require.Error(t, err)
+
+		_, err = repo.SaveMember(ctx, &organization.Member{OrganizationID: "nonexistent", UserID: user1ID})
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := repo.SaveMember(ctx, &organization.Member{
+			OrganizationID: organizations[0].ID,
+			UserID:         user1ID,
+			Roles:          []string{"invalid role"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		results, err := repo.FindAllForUser(ctx, "")
+		assert.Error(t, err)
+
+		assert.Equal(t, 0, len(results))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		saved, err := repo.SaveMember(ctx, &organization.Member{
+			OrganizationID: organizations[0].ID,
+			UserID:         user1ID,
+			Roles:          []string{"owner"},
+		})
+		require.NoError(t, err)
+
+		assert.False(t, saved.CreatedAt.IsZero())
+
+		_, err = repo.SaveMember(ctx, &organization.Member{OrganizationID: organizations[1].ID, UserID: user1ID})
+		require.NoError(t, err)
+
+		_, err = repo.SaveMember(ctx, &organization.Member{OrganizationID: organizations[1].ID, UserID: user2ID})
+		require.NoError(t, err)
+
+		// saving again updates the roles
+		updated, err := repo.SaveMember(ctx, &organization.Member{
+			OrganizationID: organizations[0].ID,
+			UserID:         user1ID,
+			Roles:          []string{"admin", "billing"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, saved.CreatedAt.Unix(), updated.CreatedAt.Unix())
+
+		member, err := repo.FindMember(ctx, organizations[0].ID, user1ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"admin", "billing"}, member.Roles)
+
+		results1, err := repo.FindAllForUser(ctx, user1ID)
+		require.NoError(t, err)
+
+		require.Equal(t, 2, len(results1))
+		assert.Equal(t, organizations[0].ID, results1[0].OrganizationID)
+		assert.Equal(t, []string{"admin", "billing"}, results1[0].Roles)
+		assert.Equal(t, organizations[1].ID, results1[1].OrganizationID)
+
+		results2, err := repo.FindAllForUser(ctx, user2ID)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(results2))
+		assert.Equal(t, organizations[1].ID, results2[0].OrganizationID)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, repo.DeleteMember(ctx, organizations[0].ID, user1ID))
+		require.NoError(t, repo.DeleteMember(ctx, organizations[0].ID, "non_existent_id"))
+
+		_, err := repo.FindMember(ctx, organizations[0].ID, user1ID)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		results, err := repo.FindAllForUser(ctx, user1ID)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(results))
+		assert.Equal(t, organizations[1].ID, results[0].OrganizationID)
+	})
+}
+
+func testOrganizationRepositoryFindAllMembers(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	organizations := createOrganizations(t, repo, 2)
+
+	t.Run("empty", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAllMembers(ctx, organizations[0].ID, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	createCount := 7
+
+	for i := 0; i < createCount; i++ {
+		_, err := repo.SaveMember(ctx, &organization.Member{
+			OrganizationID: organizations[0].ID,
+			UserID:         fmt.Sprintf("user%d", i),
+		})
+		require.NoError(t, err)
+	}
+
+	// members of other organizations are not listed
+	_, err := repo.SaveMember(ctx, &organization.Member{OrganizationID: organizations[1].ID, UserID: "user0"})
+	require.NoError(t, err)
+
+	t.Run("ok", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAllMembers(ctx, organizations[0].ID, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, createCount, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		limit := 5
+
+		results, nextCursor, err := repo.FindAllMembers(ctx, organizations[0].ID, "", limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, limit, len(results))
+		assert.Equal(t, fmt.Sprintf("user%d", limit-1), nextCursor)
+
+		// next page
+		results, nextCursor, err = repo.FindAllMembers(ctx, organizations[0].ID, nextCursor, limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, createCount-limit, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+}
+
+func testOrganizationRepositoryDeleteAllForUser(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	user1ID := "test1"
+	user2ID := "test2"
+
+	organizations := createOrganizations(t, repo, 2)
+
+	for _, m := range []*organization.Member{
+		{OrganizationID: organizations[0].ID, UserID: user1ID},
+		{OrganizationID: organizations[1].ID, UserID: user1ID},
+		{OrganizationID: organizations[0].ID, UserID: user2ID},
+	} {
+		_, err := repo.SaveMember(ctx, m)
+		require.NoError(t, err)
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		err := repo.DeleteAllForUser(ctx, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.DeleteAllForUser(ctx, user1ID)
+		require.NoError(t, err)
+
+		results1, err := repo.FindAllForUser(ctx, user1ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, len(results1))
+
+		members, _, err := repo.FindAllMembers(ctx, organizations[0].ID, "", 0)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(members))
+		assert.Equal(t, user2ID, members[0].UserID)
+	})
+}
+
+func testOrganizationRepositoryDeleteAll(t *testing.T, repo organization.OrganizationRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	organizations := createOrganizations(t, repo, 3)
+
+	_, err := repo.SaveMember(ctx, &organization.Member{OrganizationID: organizations[0].ID, UserID: "test"})
+	require.NoError(t, err)
+
+	err = repo.DeleteAll(ctx)
+	require.NoError(t, err)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, count)
+
+	results, err := repo.FindAllForUser(ctx, "test")
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, len(results))
+}
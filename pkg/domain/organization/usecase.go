@@ -0,0 +1,56 @@
+package organization
+
+import "context"
+
+type OrganizationUsecase interface {
+	// CreateOrganization creates new organization in the system.
+	CreateOrganization(context.Context, *Organization) (*Organization, error)
+
+	// UpdateOrganization updates existing organization.
+	UpdateOrganization(context.Context, *Organization) (*Organization, error)
+
+	// DeleteOrganization deletes existing organization, together with its
+	// members and invitations.
+	DeleteOrganization(ctx context.Context, id string) error
+
+	// FindOrganizationByID retrieves an organization by ID.
+	FindOrganizationByID(context.Context, string) (*Organization, error)
+
+	// FindAllOrganizations retrieves organizations in pages, starting after
+	// the cursor.
+	FindAllOrganizations(ctx context.Context, afterCursor string, limit int) ([]*Organization, string, error)
+
+	// SaveMember adds the user to existing organization, or updates the
+	// member roles.
+	SaveMember(context.Context, *Member) (*Member, error)
+
+	// FindMember retrieves the membership of the user in the organization.
+	FindMember(ctx context.Context, id, userID string) (*Member, error)
+
+	// FindAllMembers retrieves organization members in pages, starting after
+	// the cursor.
+	FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*Member, string, error)
+
+	// RemoveMember removes the user from the organization.
+	RemoveMember(ctx context.Context, id, userID string) error
+
+	// FindAllForUser retrieves all memberships of specified user ID.
+	FindAllForUser(ctx context.Context, userID string) ([]*Member, error)
+
+	// DeleteAllForUser removes specified user ID from all organizations.
+	DeleteAllForUser(ctx context.Context, userID string) error
+
+	// CreateInvitation creates new invitation to existing organization,
+	// generating its token and expiry.
+	CreateInvitation(context.Context, *Invitation) (*Invitation, error)
+
+	// FindAllInvitations retrieves all invitations to the organization.
+	FindAllInvitations(ctx context.Context, id string) ([]*Invitation, error)
+
+	// RevokeInvitation deletes the invitation to the organization.
+	RevokeInvitation(ctx context.Context, id, invitationID string) error
+
+	// AcceptInvitation adds the user to the organization of the invitation
+	// with the given token, and deletes the invitation.
+	AcceptInvitation(ctx context.Context, token, userID, email string) (*Member, error)
+}
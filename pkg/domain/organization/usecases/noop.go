@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+)
+
+// Compile-time proof of interface implementation.
+var _ organization.OrganizationUsecase = (*noopOrganizationUsecase)(nil)
+
+// noopOrganizationUsecase can be embedded to have forward compatible implementations.
+type noopOrganizationUsecase struct{}
+
+func (*noopOrganizationUsecase) CreateOrganization(ctx context.Context, organization *organization.Organization) (*organization.Organization, error) {
+	panic("CreateOrganization not implemented")
+}
+
+func (*noopOrganizationUsecase) UpdateOrganization(ctx context.Context, organization *organization.Organization) (*organization.Organization, error) {
+	panic("UpdateOrganization not implemented")
+}
+
+func (*noopOrganizationUsecase) DeleteOrganization(ctx context.Context, id string) error {
+	panic("DeleteOrganization not implemented")
+}
+
+func (*noopOrganizationUsecase) FindOrganizationByID(ctx context.Context, id string) (*organization.Organization, error) {
+	panic("FindOrganizationByID not implemented")
+}
+
+func (*noopOrganizationUsecase) FindAllOrganizations(ctx context.Context, afterCursor string, limit int) ([]*organization.Organization, string, error) {
+	panic("FindAllOrganizations not implemented")
+}
+
+func (*noopOrganizationUsecase) SaveMember(ctx context.Context, member *organization.Member) (*organization.Member, error) {
+	panic("SaveMember not implemented")
+}
+
+func (*noopOrganizationUsecase) FindMember(ctx context.Context, id, userID string) (*organization.Member, error) {
+	panic("FindMember not implemented")
+}
+
+func (*noopOrganizationUsecase) FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*organization.Member, string, error) {
+	panic("FindAllMembers not implemented")
+}
+
+func (*noopOrganizationUsecase) RemoveMember(ctx context.Context, id, userID string) error {
+	panic("RemoveMember not implemented")
+}
+
+func (*noopOrganizationUsecase) FindAllForUser(ctx context.Context, userID string) ([]*organization.Member, error) {
+	panic("FindAllForUser not implemented")
+}
+
+func (*noopOrganizationUsecase) DeleteAllForUser(ctx context.Context, userID string) error {
+	panic("DeleteAllForUser not implemented")
+}
+
+func (*noopOrganizationUsecase) CreateInvitation(ctx context.Context, invitation *organization.Invitation) (*organization.Invitation, error) {
+	panic("CreateInvitation not implemented")
+}
+
+func (*noopOrganizationUsecase) FindAllInvitations(ctx context.Context, id string) ([]*organization.Invitation, error) {
+	panic("FindAllInvitations not implemented")
+}
+
+func (*noopOrganizationUsecase) RevokeInvitation(ctx context.Context, id, invitationID string) error {
+	panic("RevokeInvitation not implemented")
+}
+
+func (*noopOrganizationUsecase) AcceptInvitation(ctx context.Context, token, userID, email string) (*organization.Member, error) {
+	panic("AcceptInvitation not implemented")
+}
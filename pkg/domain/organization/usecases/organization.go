@@ -0,0 +1,211 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/ulid"
+)
+
+type organizationUsecase struct {
+	noopOrganizationUsecase
+
+	config               organization.Config
+	repository           organization.OrganizationRepository
+	invitationRepository organization.InvitationRepository
+}
+
+func NewOrganizationUsecase(
+	config organization.Config,
+	repository organization.OrganizationRepository,
+	invitationRepository organization.InvitationRepository,
+) organization.OrganizationUsecase {
+	uc := &organizationUsecase{
+		config:               config,
+		repository:           repository,
+		invitationRepository: invitationRepository,
+	}
+	return uc
+}
+
+func (uc *organizationUsecase) CreateOrganization(ctx context.Context, entity *organization.Organization) (*organization.Organization, error) {
+	entity.ID = ulid.ULID().String()
+
+	return uc.repository.Save(ctx, entity)
+}
+
+func (uc *organizationUsecase) UpdateOrganization(ctx context.Context, entity *organization.Organization) (*organization.Organization, error) {
+	exists, err := uc.repository.ExistsByID(ctx, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, database.ErrNotFound
+	}
+
+	return uc.repository.Save(ctx, entity)
+}
+
+func (uc *organizationUsecase) DeleteOrganization(ctx context.Context, id string) error {
+	exists, err := uc.repository.ExistsByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return database.ErrNotFound
+	}
+
+	if err := uc.invitationRepository.DeleteAllForOrganization(ctx, id); err != nil {
+		return err
+	}
+
+	return uc.repository.DeleteByID(ctx, id)
+}
+
+func (uc *organizationUsecase) FindOrganizationByID(ctx context.Context, id string) (*organization.Organization, error) {
+	return uc.repository.FindByID(ctx, id)
+}
+
+func (uc *organizationUsecase) FindAllOrganizations(ctx context.Context, afterCursor string, limit int) ([]*organization.Organization, string, error) {
+	return uc.repository.FindAll(ctx, afterCursor, limit)
+}
+
+func (uc *organizationUsecase) SaveMember(ctx context.Context, member *organization.Member) (*organization.Member, error) {
+	return uc.repository.SaveMember(ctx, member)
+}
+
+func (uc *organizationUsecase) FindMember(ctx context.Context, id, userID string) (*organization.Member, error) {
+	return uc.repository.FindMember(ctx, id, userID)
+}
+
+func (uc *organizationUsecase) FindAllMembers(ctx context.Context, id, afterCursor string, limit int) ([]*organization.Member, string, error) {
+	exists, err := uc.repository.ExistsByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !exists {
+		return nil, "", database.ErrNotFound
+	}
+
+	return uc.repository.FindAllMembers(ctx, id, afterCursor, limit)
+}
+
+func (uc *organizationUsecase) RemoveMember(ctx context.Context, id, userID string) error {
+	return uc.repository.DeleteMember(ctx, id, userID)
+}
+
+func (uc *organizationUsecase) FindAllForUser(ctx context.Context, userID string) ([]*organization.Member, error) {
+	return uc.repository.FindAllForUser(ctx, userID)
+}
+
+func (uc *organizationUsecase) DeleteAllForUser(ctx context.Context, userID string) error {
+	return uc.repository.DeleteAllForUser(ctx, userID)
+}
+
+func (uc *organizationUsecase) CreateInvitation(ctx context.Context, invitation *organization.Invitation) (*organization.Invitation, error) {
+	exists, err := uc.repository.ExistsByID(ctx, invitation.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, database.ErrNotFound
+	}
+
+	invitation.ID = ulid.ULID().String()
+	invitation.Token = ulid.ULID().String()
+	invitation.ExpiresAt = time.Now().Add(uc.config.InvitationExpiry)
+
+	return uc.invitationRepository.Save(ctx, invitation)
+}
+
+func (uc *organizationUsecase) FindAllInvitations(ctx context.Context, id string) ([]*organization.Invitation, error) {
+	exists, err := uc.repository.ExistsByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, database.ErrNotFound
+	}
+
+	return uc.invitationRepository.FindAllForOrganization(ctx, id)
+}
+
+func (uc *organizationUsecase) RevokeInvitation(ctx context.Context, id, invitationID string) error {
+	invitation, err := uc.invitationRepository.FindByID(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+
+	if invitation.OrganizationID != id {
+		return database.ErrNotFound
+	}
+
+	return uc.invitationRepository.DeleteByID(ctx, invitationID)
+}
+
+func (uc *organizationUsecase) AcceptInvitation(ctx context.Context, token, userID, email string) (*organization.Member, error) {
+	invitation, err := uc.invitationRepository.FindByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.IsExpired() {
+		return nil, organization.ErrInvitationExpired
+	}
+
+	if !invitation.IsFor(email) {
+		return nil, organization.ErrInvitationEmailMismatch
+	}
+
+	member, err := uc.repository.FindMember(ctx, invitation.OrganizationID, userID)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			return nil, err
+		}
+
+		member = &organization.Member{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         userID,
+		}
+	}
+
+	member.Roles = mergeRoles(member.Roles, invitation.Roles)
+
+	member, err = uc.repository.SaveMember(ctx, member)
+	if err != nil {
+		return nil, fmt.Errorf("save member: %w", err)
+	}
+
+	if err := uc.invitationRepository.DeleteByID(ctx, invitation.ID); err != nil {
+		return nil, fmt.Errorf("delete invitation: %w", err)
+	}
+
+	return member, nil
+}
+
+// mergeRoles returns the roles, followed by the additional roles not already
+// present.
+func mergeRoles(roles, additional []string) []string {
+	set := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		set[role] = struct{}{}
+	}
+
+	for _, role := range additional {
+		if _, ok := set[role]; !ok {
+			set[role] = struct{}{}
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
@@ -0,0 +1,93 @@
+package di
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	policy_bbolt "github.com/zbiljic/authzy/pkg/domain/policy/storage/bbolt"
+	policy_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/policy/storage/jsonmutexdb"
+	policy_leveldb "github.com/zbiljic/authzy/pkg/domain/policy/storage/leveldb"
+	policy_sqlite "github.com/zbiljic/authzy/pkg/domain/policy/storage/sqlite"
+)
+
+var repositoresfx = fx.Provide(
+	NewPolicyRepository,
+)
+
+type RepositoryParams struct {
+	fx.In
+
+	Type string `name:"db_type"`
+
+	JSONMutexDBConfig *database_jsonmutexdb.Config
+	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+func NewPolicyRepository(p RepositoryParams) (policy.PolicyRepository, error) {
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		return NewJSONMutexDBPolicyRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
+	case database_leveldb.Type:
+		return NewLevelDBPolicyRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLitePolicyRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltPolicyRepository(p.BBoltConfig, p.BBolt)
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+func NewJSONMutexDBPolicyRepository(
+	config *database_jsonmutexdb.Config,
+	ls *database_jsonmutexdb.LoadSaver,
+) (policy.PolicyRepository, error) {
+	return policy_jsonmutexdb.NewPolicyRepository(
+		*ls,
+		config.FilenamePrefix,
+	)
+}
+
+func NewLevelDBPolicyRepository(
+	config *database_leveldb.Config,
+	db *leveldb.DB,
+) (policy.PolicyRepository, error) {
+	return policy_leveldb.NewPolicyRepository(
+		db,
+		config.KeyPrefix,
+	)
+}
+
+func NewSQLitePolicyRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (policy.PolicyRepository, error) {
+	return policy_sqlite.NewPolicyRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltPolicyRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (policy.PolicyRepository, error) {
+	return policy_bbolt.NewPolicyRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
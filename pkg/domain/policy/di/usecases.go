@@ -0,0 +1,20 @@
+package di
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/usecases"
+)
+
+var usecasesfx = fx.Provide(
+	NewPolicyUsecase,
+)
+
+func NewPolicyUsecase(
+	repository policy.PolicyRepository,
+) (policy.PolicyUsecase, error) {
+	return usecases.NewPolicyUsecase(
+		repository,
+	)
+}
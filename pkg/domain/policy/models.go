@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidExpression is returned for policy expressions which do not
+// compile, or do not evaluate to a boolean.
+var ErrInvalidExpression = errors.New("invalid policy expression")
+
+// Effect is the result of the policy when its expression matches.
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+// Policy allows or denies the request when its expression evaluates to true.
+// Expressions are written in CEL, over the "subject", "resource", "action"
+// and "request" variables.
+type Policy struct {
+	ID          string
+	Description string
+	Effect      Effect
+	Expression  string
+	Disabled    bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Input holds the attributes the policies are evaluated against.
+type Input struct {
+	// Subject attributes, such as the user profile, metadata and token
+	// claims.
+	Subject map[string]interface{}
+	// Resource attributes, as provided by the caller.
+	Resource map[string]interface{}
+	// Action performed on the resource.
+	Action string
+	// Request context, such as time and IP address.
+	Request map[string]interface{}
+}
+
+// Decision is the result of the evaluation. Any matching deny policy denies
+// the request; otherwise the request is allowed if any allow policy matches.
+type Decision struct {
+	Allowed bool
+	// PolicyIDs are the IDs of the policies which determined the decision.
+	PolicyIDs []string
+	// Errors holds evaluation errors by policy ID. Allow policies which fail
+	// to evaluate do not match, while deny policies do, so a missing
+	// attribute does not disable them.
+	Errors map[string]string
+}
@@ -0,0 +1,26 @@
+package policy
+
+import "context"
+
+type PolicyRepository interface {
+	// Save saves a given entity.
+	Save(ctx context.Context, entity *Policy) (*Policy, error)
+
+	// FindByID retrieves an entity by its id.
+	FindByID(ctx context.Context, id string) (*Policy, error)
+
+	// ExistsByID returns whether an entity with the given id exists.
+	ExistsByID(ctx context.Context, id string) (bool, error)
+
+	// FindAll returns all instances of the type.
+	FindAll(ctx context.Context, afterCursor string, limit int) ([]*Policy, string, error)
+
+	// Count returns the number of entities available.
+	Count(ctx context.Context) (int, error)
+
+	// DeleteByID deletes the entity with the given id.
+	DeleteByID(ctx context.Context, id string) error
+
+	// DeleteAll deletes all entities managed by the repository.
+	DeleteAll(ctx context.Context) error
+}
@@ -0,0 +1,209 @@
+package bbolt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/noop"
+)
+
+const (
+	policiesBucket = "policies"
+)
+
+// bboltPolicyRepository is a repository that uses bbolt database.
+type bboltPolicyRepository struct {
+	noop.UnimplementedPolicyRepository
+
+	db *database_bbolt.DB
+
+	policiesBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewPolicyRepository returns a new bbolt repository.
+func NewPolicyRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (policy.PolicyRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &bboltPolicyRepository{
+		db:             db,
+		policiesBucket: []byte(bucketPrefix + policiesBucket),
+		validate:       validate,
+	}
+
+	err := db.CreateBuckets(string(r.policiesBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns           = "policy/storage/bbolt."
+	opSave       = ns + "Save"
+	opFindByID   = ns + "FindByID"
+	opExistsByID = ns + "ExistsByID"
+	opFindAll    = ns + "FindAll"
+	opCount      = ns + "Count"
+	opDeleteByID = ns + "DeleteByID"
+)
+
+func (r *bboltPolicyRepository) Save(ctx context.Context, entity *policy.Policy) (*policy.Policy, error) {
+	inS := schema.PolicyToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalPolicy(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(r.policiesBucket).Put([]byte(inS.ID), value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.PolicyFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltPolicyRepository) FindByID(ctx context.Context, id string) (*policy.Policy, error) {
+	var ts *schema.Policy
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		value := tx.Bucket(r.policiesBucket).Get([]byte(id))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = transformer.UnmarshalPolicy(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.PolicyFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *bboltPolicyRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		has = tx.Bucket(r.policiesBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *bboltPolicyRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*policy.Policy, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*policy.Policy
+		nextCursor string
+	)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.policiesBucket).Cursor()
+
+		k, v := c.First()
+		if afterCursor != "" {
+			k, v = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < limit; k, v = c.Next() {
+			ts, err := transformer.UnmarshalPolicy(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.PolicyFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltPolicyRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.policiesBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *bboltPolicyRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(r.policiesBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
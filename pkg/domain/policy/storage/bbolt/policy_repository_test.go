@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/test"
+)
+
+func TestBBoltPolicyRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (policy.PolicyRepository, func()) {
+		return func(t *testing.T) (policy.PolicyRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewPolicyRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
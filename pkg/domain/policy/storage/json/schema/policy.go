@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+)
+
+type Policy struct {
+	ID          string `json:"policy_id" validate:"required,policyid"`
+	Description string `json:"description,omitempty"`
+	Effect      string `json:"effect" validate:"required,oneof=allow deny"`
+	Expression  string `json:"expression" validate:"required,max=4096"`
+	Disabled    bool   `json:"disabled,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (p *Policy) BeforeSave() error {
+	return nil
+}
+
+func PolicyToSchema(in *policy.Policy) *Policy {
+	out := &Policy{}
+	if in != nil {
+		out.ID = in.ID
+		out.Description = in.Description
+		out.Effect = string(in.Effect)
+		out.Expression = in.Expression
+		out.Disabled = in.Disabled
+		out.CreatedAt = in.CreatedAt
+		out.UpdatedAt = in.UpdatedAt
+	}
+
+	return out
+}
+
+func PolicyFromSchema(in *Policy) *policy.Policy {
+	out := &policy.Policy{}
+	out.ID = in.ID
+	out.Description = in.Description
+	out.Effect = policy.Effect(in.Effect)
+	out.Expression = in.Expression
+	out.Disabled = in.Disabled
+	out.CreatedAt = in.CreatedAt
+	out.UpdatedAt = in.UpdatedAt
+
+	return out
+}
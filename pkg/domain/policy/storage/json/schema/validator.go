@@ -0,0 +1,29 @@
+package schema
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	policyIDRegexString = "^[a-zA-Z0-9][a-zA-Z0-9_.:-]{0,63}$"
+)
+
+var (
+	policyIDRegex = regexp.MustCompile(policyIDRegexString)
+
+	validators = map[string]validator.Func{
+		"policyid": isPolicyID,
+	}
+)
+
+func RegisterValidators(v *validator.Validate) {
+	for k, val := range validators {
+		_ = v.RegisterValidation(k, val)
+	}
+}
+
+func isPolicyID(fl validator.FieldLevel) bool {
+	return policyIDRegex.MatchString(fl.Field().String())
+}
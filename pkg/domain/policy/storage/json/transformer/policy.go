@@ -0,0 +1,40 @@
+package transformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/schema"
+)
+
+const keySeparator = "/"
+
+const (
+	ns                = "policy/storage/json/transformer."
+	opMarshalPolicy   = ns + "MarshalPolicy"
+	opUnmarshalPolicy = ns + "UnmarshalPolicy"
+)
+
+func MarshalPolicyKey(prefix, id string) string {
+	return strings.Join([]string{prefix, id}, keySeparator)
+}
+
+func MarshalPolicy(in *schema.Policy) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalPolicy, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalPolicy(in []byte) (*schema.Policy, error) {
+	out := &schema.Policy{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalPolicy, err)
+	}
+
+	return out, nil
+}
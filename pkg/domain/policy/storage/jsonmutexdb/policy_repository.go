@@ -0,0 +1,256 @@
+package jsonmutexdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/noop"
+)
+
+const (
+	policiesPrefix = "policies"
+)
+
+type jsonMutexDBPolicyRepository struct {
+	noop.UnimplementedPolicyRepository
+
+	db map[string]schema.Policy
+	mu sync.RWMutex
+
+	loadSaver jsonmutexdb.LoadSaver
+	filename  string
+
+	validate *validator.Validate
+}
+
+// NewPolicyRepository returns a new JSONMutexDB repository.
+func NewPolicyRepository(
+	loadSaver jsonmutexdb.LoadSaver,
+	filenamePrefix string,
+) (policy.PolicyRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &jsonMutexDBPolicyRepository{
+		db:        make(map[string]schema.Policy),
+		loadSaver: loadSaver,
+		filename:  fmt.Sprintf("%s%s.json", filenamePrefix, policiesPrefix),
+		validate:  validate,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jsonMutexDBPolicyRepository) load() error {
+	if r.loadSaver != nil {
+		data, err := r.loadSaver.Load(r.filename)
+		if err != nil {
+			return err
+		}
+
+		if len(data) > 0 {
+			err = json.Unmarshal(data, &r.db)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBPolicyRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Policy)
+
+	return r.load()
+}
+
+func (r *jsonMutexDBPolicyRepository) commit(ctx context.Context) error {
+	if r.loadSaver != nil {
+		out, err := json.Marshal(r.db)
+		if err != nil {
+			return err
+		}
+
+		return jsonmutexdb.Save(ctx, r.loadSaver, r.filename, out, r.reload)
+	}
+	return nil
+}
+
+const (
+	ns           = "policy/storage/jsonmutexdb."
+	opSave       = ns + "Save"
+	opFindByID   = ns + "FindByID"
+	opDeleteByID = ns + "DeleteByID"
+	opDeleteAll  = ns + "DeleteAll"
+)
+
+func (r *jsonMutexDBPolicyRepository) Save(ctx context.Context, entity *policy.Policy) (*policy.Policy, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.PolicyToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	r.db[inS.ID] = *inS
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	savedEntity := schema.PolicyFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *jsonMutexDBPolicyRepository) FindByID(ctx context.Context, id string) (*policy.Policy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, ok := r.db[id]
+	if !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+	}
+
+	entity := schema.PolicyFromSchema(&value)
+
+	return entity, nil
+}
+
+func (r *jsonMutexDBPolicyRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, has := r.db[id]
+
+	return has, nil
+}
+
+func (r *jsonMutexDBPolicyRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*policy.Policy, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*policy.Policy
+		nextCursor string
+	)
+
+	keys := []string{}
+	for id := range r.db {
+		keys = append(keys, id)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var afterCursorKey string
+	if afterCursor != "" {
+		afterCursorKey = afterCursor
+	}
+
+	for _, id := range keys {
+		if afterCursorKey != "" {
+			if afterCursorKey == id {
+				afterCursorKey = ""
+			}
+
+			continue
+		}
+
+		offset++
+
+		val := r.db[id]
+
+		t := schema.PolicyFromSchema(&val)
+
+		result = append(result, t)
+
+		if limit == offset {
+			break // stops iterator
+		}
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *jsonMutexDBPolicyRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.db), nil
+}
+
+func (r *jsonMutexDBPolicyRepository) DeleteByID(ctx context.Context, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[id]; !ok {
+		return nil
+	}
+
+	delete(r.db, id)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteByID, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBPolicyRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Policy)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
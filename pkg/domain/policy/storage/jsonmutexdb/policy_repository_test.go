@@ -0,0 +1,22 @@
+package jsonmutexdb_test
+
+import (
+	"testing"
+
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/test"
+)
+
+func TestJSONMutexDBPolicyRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (policy.PolicyRepository, func()) {
+		return func(t *testing.T) (policy.PolicyRepository, func()) {
+			repo, err := jsonmutexdb.NewPolicyRepository(nil, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, func() {}
+		}
+	})
+}
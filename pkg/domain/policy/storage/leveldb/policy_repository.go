@@ -0,0 +1,251 @@
+package leveldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/noop"
+)
+
+const (
+	policiesPrefix = "policies"
+)
+
+// levelDBPolicyRepository is a repository that uses LevelDB database.
+type levelDBPolicyRepository struct {
+	noop.UnimplementedPolicyRepository
+
+	db *leveldb.DB
+	mu sync.Mutex
+
+	keyspace string
+
+	validate *validator.Validate
+}
+
+// NewPolicyRepository returns a new LevelDB repository.
+func NewPolicyRepository(
+	db *leveldb.DB,
+	keyPrefix string,
+) (policy.PolicyRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &levelDBPolicyRepository{
+		db:       db,
+		keyspace: keyPrefix + policiesPrefix,
+		validate: validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns           = "policy/storage/leveldb."
+	opSave       = ns + "Save"
+	opFindByID   = ns + "FindByID"
+	opExistsByID = ns + "ExistsByID"
+	opFindAll    = ns + "FindAll"
+	opCount      = ns + "Count"
+	opDeleteByID = ns + "DeleteByID"
+)
+
+func (r *levelDBPolicyRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
+}
+
+func (r *levelDBPolicyRepository) Save(ctx context.Context, entity *policy.Policy) (*policy.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.PolicyToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	key := transformer.MarshalPolicyKey(r.keyspace, inS.ID)
+
+	value, err := transformer.MarshalPolicy(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = database_leveldb.From(ctx, r.db).Put([]byte(key), value, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.PolicyFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *levelDBPolicyRepository) FindByID(ctx context.Context, id string) (*policy.Policy, error) {
+	key := transformer.MarshalPolicyKey(r.keyspace, id)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	ts, err := transformer.UnmarshalPolicy(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.PolicyFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *levelDBPolicyRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	key := transformer.MarshalPolicyKey(r.keyspace, id)
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *levelDBPolicyRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*policy.Policy, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*policy.Policy
+		nextCursor string
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.keyspace+"/")), nil)
+	defer iter.Release()
+
+	if afterCursor != "" {
+		key := transformer.MarshalPolicyKey(r.keyspace, afterCursor)
+
+		if ok := iter.Seek([]byte(key)); !ok {
+			err := iter.Error()
+			if err != nil {
+				return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+			}
+		}
+	}
+
+	for iter.Next() {
+		offset++
+
+		ts, err := transformer.UnmarshalPolicy(iter.Value())
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, string(iter.Key()), err)
+		}
+
+		t := schema.PolicyFromSchema(ts)
+
+		result = append(result, t)
+
+		if limit == offset {
+			break // stops iterator
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *levelDBPolicyRepository) Count(ctx context.Context) (int, error) {
+	var (
+		count          int
+		ctxCheckOffset int
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.keyspace+"/")), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if count == ctxCheckOffset {
+			select {
+			case <-ctx.Done():
+				return count, fmt.Errorf("%s: %w", opCount, ctx.Err())
+			default:
+			}
+
+			ctxCheckOffset += 100
+		}
+
+		count++
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *levelDBPolicyRepository) DeleteByID(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := transformer.MarshalPolicyKey(r.keyspace, id)
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	if !has {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+
+	batch.Delete([]byte(key))
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
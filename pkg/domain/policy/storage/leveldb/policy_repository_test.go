@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/test"
+)
+
+func TestLevelDBPolicyRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (policy.PolicyRepository, func()) {
+		return func(t *testing.T) (policy.PolicyRepository, func()) {
+			db, cleanup := database_leveldb.Fixture()
+
+			repo, err := leveldb.NewPolicyRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
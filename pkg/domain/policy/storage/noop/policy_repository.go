@@ -0,0 +1,41 @@
+package noop
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+)
+
+// Compile-time proof of interface implementation.
+var _ policy.PolicyRepository = (*UnimplementedPolicyRepository)(nil)
+
+// UnimplementedPolicyRepository can be embedded to have forward compatible implementations.
+type UnimplementedPolicyRepository struct{}
+
+func (*UnimplementedPolicyRepository) Save(ctx context.Context, entity *policy.Policy) (*policy.Policy, error) {
+	panic("Save not implemented")
+}
+
+func (*UnimplementedPolicyRepository) FindByID(ctx context.Context, id string) (*policy.Policy, error) {
+	panic("FindByID not implemented")
+}
+
+func (*UnimplementedPolicyRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	panic("ExistsByID not implemented")
+}
+
+func (*UnimplementedPolicyRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*policy.Policy, string, error) {
+	panic("FindAll not implemented")
+}
+
+func (*UnimplementedPolicyRepository) Count(ctx context.Context) (int, error) {
+	panic("Count not implemented")
+}
+
+func (*UnimplementedPolicyRepository) DeleteByID(ctx context.Context, id string) error {
+	panic("DeleteByID not implemented")
+}
+
+func (*UnimplementedPolicyRepository) DeleteAll(ctx context.Context) error {
+	panic("DeleteAll not implemented")
+}
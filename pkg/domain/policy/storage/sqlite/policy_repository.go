@@ -0,0 +1,205 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/noop"
+)
+
+const (
+	policiesTable = "policies"
+)
+
+// sqlitePolicyRepository is a repository that uses SQLite database.
+type sqlitePolicyRepository struct {
+	noop.UnimplementedPolicyRepository
+
+	db *database_sqlite.DB
+
+	policiesTable string
+
+	validate *validator.Validate
+}
+
+// NewPolicyRepository returns a new SQLite repository.
+func NewPolicyRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (policy.PolicyRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &sqlitePolicyRepository{
+		db:            db,
+		policiesTable: database_sqlite.Table(tablePrefix, policiesTable),
+		validate:      validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns           = "policy/storage/sqlite."
+	opSave       = ns + "Save"
+	opFindByID   = ns + "FindByID"
+	opExistsByID = ns + "ExistsByID"
+	opFindAll    = ns + "FindAll"
+	opCount      = ns + "Count"
+	opDeleteByID = ns + "DeleteByID"
+)
+
+func (r *sqlitePolicyRepository) Save(ctx context.Context, entity *policy.Policy) (*policy.Policy, error) {
+	inS := schema.PolicyToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalPolicy(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.policiesTable+` (id, data) VALUES (?, ?)`,
+		inS.ID, string(value),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.PolicyFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *sqlitePolicyRepository) FindByID(ctx context.Context, id string) (*policy.Policy, error) {
+	var value string
+
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM `+r.policiesTable+` WHERE id = ?`, id).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	ts, err := transformer.UnmarshalPolicy([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.PolicyFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *sqlitePolicyRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+r.policiesTable+` WHERE id = ?)`,
+		id,
+	).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *sqlitePolicyRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*policy.Policy, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*policy.Policy
+		nextCursor string
+	)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT data FROM `+r.policiesTable+` WHERE id > ? ORDER BY id LIMIT ?`,
+		afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+		}
+
+		ts, err := transformer.UnmarshalPolicy([]byte(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+		}
+
+		result = append(result, schema.PolicyFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqlitePolicyRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.policiesTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqlitePolicyRepository) DeleteByID(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.policiesTable+` WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
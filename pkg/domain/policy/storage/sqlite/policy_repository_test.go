@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/test"
+)
+
+func TestSQLitePolicyRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (policy.PolicyRepository, func()) {
+		return func(t *testing.T) (policy.PolicyRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewPolicyRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
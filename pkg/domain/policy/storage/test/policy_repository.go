@@ -0,0 +1,286 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+	"github.com/zbiljic/authzy/pkg/domain/policy/storage/json/schema"
+)
+
+const (
+	TestPrefix = "test-"
+)
+
+func testValidator() *validator.Validate {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+	return validate
+}
+
+func createPolicies(t *testing.T, repo policy.PolicyRepository, count int) []*policy.Policy {
+	t.Helper()
+
+	var result []*policy.Policy
+
+	ctx := context.Background()
+
+	for i := 0; i < count; i++ {
+		entity := &policy.Policy{
+			ID:          fmt.Sprintf("policy-%d", i),
+			Description: fmt.Sprintf("Policy %d", i),
+			Effect:      policy.EffectAllow,
+			Expression:  fmt.Sprintf("resource.id == 'resource-%d'", i),
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		result = append(result, savedEntity)
+	}
+
+	return result
+}
+
+func assertPolicyEqual(t *testing.T, expected, actual *policy.Policy) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.Equal(t, expected.Effect, actual.Effect)
+	assert.Equal(t, expected.Expression, actual.Expression)
+	assert.Equal(t, expected.Disabled, actual.Disabled)
+}
+
+func Run(t *testing.T, f func() func(t *testing.T) (policy.PolicyRepository, func())) {
+	t.Helper()
+
+	t.Run("init", func(t *testing.T) {
+		_, cleanup := f()(t)
+		defer cleanup()
+	})
+	t.Run("Save", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testPolicyRepositorySave(t, repo)
+	})
+	t.Run("FindByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testPolicyRepositoryFindByID(t, repo)
+	})
+	t.Run("ExistsByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testPolicyRepositoryExistsByID(t, repo)
+	})
+	t.Run("FindAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testPolicyRepositoryFindAll(t, repo)
+	})
+	t.Run("Count", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testPolicyRepositoryCount(t, repo)
+	})
+	t.Run("DeleteByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testPolicyRepositoryDeleteByID(t, repo)
+	})
+}
+
+func testPolicyRepositorySave(t *testing.T, repo policy.PolicyRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	validate := testValidator()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := repo.Save(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		entity := &policy.Policy{}
+
+		_, err := repo.Save(ctx, entity)
+		assert.Error(t, err)
+
+		inS := schema.PolicyToSchema(entity)
+		validateErr := validate.Struct(inS)
+
+		assert.Contains(t, err.Error(), validateErr.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := repo.Save(ctx, &policy.Policy{ID: "invalid/policy", Effect: policy.EffectAllow, Expression: "true"})
+		assert.Error(t, err)
+
+		_, err = repo.Save(ctx, &policy.Policy{ID: "policy", Effect: "maybe", Expression: "true"})
+		assert.Error(t, err)
+	})
+
+	t.Run("simple", func(t *testing.T) {
+		entity := &policy.Policy{
+			ID:         "owner",
+			Effect:     policy.EffectAllow,
+			Expression: "resource.owner == subject.id",
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		assert.False(t, savedEntity.CreatedAt.IsZero())
+		assert.False(t, savedEntity.UpdatedAt.IsZero())
+	})
+}
+
+func testPolicyRepositoryFindByID(t *testing.T, repo policy.PolicyRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	policies := createPolicies(t, repo, 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, "non_existent_id")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		entity, err := repo.FindByID(ctx, policies[0].ID)
+		require.NoError(t, err)
+
+		assertPolicyEqual(t, policies[0], entity)
+	})
+}
+
+func testPolicyRepositoryExistsByID(t *testing.T, repo policy.PolicyRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	policies := createPolicies(t, repo, 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		exists, err := repo.ExistsByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+
+		assert.False(t, exists)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		exists, err := repo.ExistsByID(ctx, policies[0].ID)
+		require.NoError(t, err)
+
+		assert.True(t, exists)
+	})
+}
+
+func testPolicyRepositoryFindAll(t *testing.T, repo policy.PolicyRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("empty", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAll(ctx, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	createCount := 7
+
+	policies := createPolicies(t, repo, createCount)
+
+	t.Run("ok", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAll(ctx, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, createCount, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		limit := 5
+
+		results, nextCursor, err := repo.FindAll(ctx, "", limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, limit, len(results))
+		assert.Equal(t, policies[limit-1].ID, nextCursor)
+
+		// next page
+		results, nextCursor, err = repo.FindAll(ctx, nextCursor, limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, createCount-limit, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+}
+
+func testPolicyRepositoryCount(t *testing.T, repo policy.PolicyRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, count)
+
+	createCount := 3
+
+	createPolicies(t, repo, createCount)
+
+	count, err = repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, createCount, count)
+}
+
+func testPolicyRepositoryDeleteByID(t *testing.T, repo policy.PolicyRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	policies := createPolicies(t, repo, 2)
+
+	t.Run("non existent", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, policies[0].ID)
+		require.NoError(t, err)
+
+		exists, err := repo.ExistsByID(ctx, policies[0].ID)
+		require.NoError(t, err)
+
+		assert.False(t, exists)
+
+		count, err := repo.Count(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, count)
+	})
+}
@@ -0,0 +1,28 @@
+package policy
+
+import "context"
+
+type PolicyUsecase interface {
+	// CreatePolicy creates new policy in the system.
+	CreatePolicy(context.Context, *Policy) (*Policy, error)
+
+	// UpdatePolicy updates existing policy.
+	UpdatePolicy(context.Context, *Policy) (*Policy, error)
+
+	// DeletePolicy deletes existing policy.
+	DeletePolicy(ctx context.Context, id string) error
+
+	// FindPolicyByID retrieves a policy by ID.
+	FindPolicyByID(context.Context, string) (*Policy, error)
+
+	// FindAllPolicies retrieves policies in pages, starting after the cursor.
+	FindAllPolicies(ctx context.Context, afterCursor string, limit int) ([]*Policy, string, error)
+
+	// Evaluate evaluates all enabled policies against the input.
+	Evaluate(context.Context, *Input) (*Decision, error)
+
+	// EvaluateDryRun evaluates the policies against the input as if the
+	// given policies were stored, replacing stored policies with the same
+	// ID. Nothing is persisted.
+	EvaluateDryRun(ctx context.Context, input *Input, policies []*Policy) (*Decision, error)
+}
@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+)
+
+// Compile-time proof of interface implementation.
+var _ policy.PolicyUsecase = (*noopPolicyUsecase)(nil)
+
+// noopPolicyUsecase can be embedded to have forward compatible implementations.
+type noopPolicyUsecase struct{}
+
+func (*noopPolicyUsecase) CreatePolicy(ctx context.Context, policy *policy.Policy) (*policy.Policy, error) {
+	panic("CreatePolicy not implemented")
+}
+
+func (*noopPolicyUsecase) UpdatePolicy(ctx context.Context, policy *policy.Policy) (*policy.Policy, error) {
+	panic("UpdatePolicy not implemented")
+}
+
+func (*noopPolicyUsecase) DeletePolicy(ctx context.Context, id string) error {
+	panic("DeletePolicy not implemented")
+}
+
+func (*noopPolicyUsecase) FindPolicyByID(ctx context.Context, id string) (*policy.Policy, error) {
+	panic("FindPolicyByID not implemented")
+}
+
+func (*noopPolicyUsecase) FindAllPolicies(ctx context.Context, afterCursor string, limit int) ([]*policy.Policy, string, error) {
+	panic("FindAllPolicies not implemented")
+}
+
+func (*noopPolicyUsecase) Evaluate(ctx context.Context, input *policy.Input) (*policy.Decision, error) {
+	panic("Evaluate not implemented")
+}
+
+func (*noopPolicyUsecase) EvaluateDryRun(ctx context.Context, input *policy.Input, policies []*policy.Policy) (*policy.Decision, error) {
+	panic("EvaluateDryRun not implemented")
+}
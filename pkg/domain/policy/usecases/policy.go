@@ -0,0 +1,360 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/policy"
+)
+
+const (
+	// pageSize is the number of policies read at once for evaluation.
+	pageSize = 100
+
+	// costLimit bounds the work done by a single expression.
+	costLimit = 100000
+)
+
+// cachedProgram is the compiled expression of a policy.
+type cachedProgram struct {
+	expression string
+	program    cel.Program
+}
+
+type policyUsecase struct {
+	noopPolicyUsecase
+
+	env *cel.Env
+
+	// programs caches compiled expressions of stored policies, by policy ID.
+	programs   map[string]*cachedProgram
+	programsMu sync.RWMutex
+
+	repository policy.PolicyRepository
+}
+
+func NewPolicyUsecase(
+	repository policy.PolicyRepository,
+) (policy.PolicyUsecase, error) {
+	attributes := cel.MapType(cel.StringType, cel.DynType)
+
+	env, err := cel.NewEnv(
+		cel.Variable("subject", attributes),
+		cel.Variable("resource", attributes),
+		cel.Variable("action", cel.StringType),
+		cel.Variable("request", attributes),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	uc := &policyUsecase{
+		env:        env,
+		programs:   make(map[string]*cachedProgram),
+		repository: repository,
+	}
+	return uc, nil
+}
+
+func (uc *policyUsecase) CreatePolicy(ctx context.Context, entity *policy.Policy) (*policy.Policy, error) {
+	if _, err := uc.compile(entity.Expression); err != nil {
+		return nil, err
+	}
+
+	exists, err := uc.repository.ExistsByID(ctx, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, database.ErrAlreadyExists
+	}
+
+	return uc.repository.Save(ctx, entity)
+}
+
+func (uc *policyUsecase) UpdatePolicy(ctx context.Context, entity *policy.Policy) (*policy.Policy, error) {
+	prg, err := uc.compile(entity.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := uc.repository.ExistsByID(ctx, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, database.ErrNotFound
+	}
+
+	saved, err := uc.repository.Save(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.cacheProgram(saved.ID, saved.Expression, prg)
+
+	return saved, nil
+}
+
+func (uc *policyUsecase) DeletePolicy(ctx context.Context, id string) error {
+	exists, err := uc.repository.ExistsByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return database.ErrNotFound
+	}
+
+	if err := uc.repository.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+
+	uc.programsMu.Lock()
+	delete(uc.programs, id)
+	uc.programsMu.Unlock()
+
+	return nil
+}
+
+func (uc *policyUsecase) FindPolicyByID(ctx context.Context, id string) (*policy.Policy, error) {
+	return uc.repository.FindByID(ctx, id)
+}
+
+func (uc *policyUsecase) FindAllPolicies(ctx context.Context, afterCursor string, limit int) ([]*policy.Policy, string, error) {
+	return uc.repository.FindAll(ctx, afterCursor, limit)
+}
+
+func (uc *policyUsecase) Evaluate(ctx context.Context, input *policy.Input) (*policy.Decision, error) {
+	policies, err := uc.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.pruneCache(policies)
+
+	return uc.evaluate(ctx, input, policies, uc.cachedProgram)
+}
+
+func (uc *policyUsecase) EvaluateDryRun(ctx context.Context, input *policy.Input, overrides []*policy.Policy) (*policy.Decision, error) {
+	policies, err := uc.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*policy.Policy, len(policies)+len(overrides))
+	for _, p := range policies {
+		byID[p.ID] = p
+	}
+	for _, p := range overrides {
+		byID[p.ID] = p
+	}
+
+	policies = make([]*policy.Policy, 0, len(byID))
+	for _, p := range byID {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].ID < policies[j].ID })
+
+	// policies under test are not cached
+	return uc.evaluate(ctx, input, policies, func(p *policy.Policy) (cel.Program, error) {
+		return uc.compile(p.Expression)
+	})
+}
+
+func (uc *policyUsecase) evaluate(
+	ctx context.Context,
+	input *policy.Input,
+	policies []*policy.Policy,
+	program func(*policy.Policy) (cel.Program, error),
+) (*policy.Decision, error) {
+	vars := map[string]interface{}{
+		"subject":  attributes(input.Subject),
+		"resource": attributes(input.Resource),
+		"action":   input.Action,
+		"request":  attributes(input.Request),
+	}
+
+	var allowIDs, denyIDs []string
+
+	errs := make(map[string]string)
+
+	for _, p := range policies {
+		if p.Disabled {
+			continue
+		}
+
+		matched, err := match(ctx, p, program, vars)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			errs[p.ID] = err.Error()
+
+			// deny policies which fail to evaluate match, so the request is
+			// not allowed without the attributes they check
+			matched = p.Effect == policy.EffectDeny
+		}
+
+		if !matched {
+			continue
+		}
+
+		switch p.Effect {
+		case policy.EffectDeny:
+			denyIDs = append(denyIDs, p.ID)
+		case policy.EffectAllow:
+			allowIDs = append(allowIDs, p.ID)
+		}
+	}
+
+	decision := &policy.Decision{}
+
+	switch {
+	case len(denyIDs) > 0:
+		decision.PolicyIDs = denyIDs
+	case len(allowIDs) > 0:
+		decision.Allowed = true
+		decision.PolicyIDs = allowIDs
+	}
+
+	if len(errs) > 0 {
+		decision.Errors = errs
+	}
+
+	return decision, nil
+}
+
+// match returns whether the policy matches the variables.
+func match(
+	ctx context.Context,
+	p *policy.Policy,
+	program func(*policy.Policy) (cel.Program, error),
+	vars map[string]interface{},
+) (bool, error) {
+	prg, err := program(p)
+	if err != nil {
+		return false, err
+	}
+
+	out, _, err := prg.ContextEval(ctx, vars)
+	if err != nil {
+		return false, err
+	}
+
+	return out == types.True, nil
+}
+
+func (uc *policyUsecase) findAll(ctx context.Context) ([]*policy.Policy, error) {
+	var (
+		result []*policy.Policy
+		cursor string
+	)
+
+	for {
+		policies, nextCursor, err := uc.repository.FindAll(ctx, cursor, pageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, policies...)
+
+		if nextCursor == "" {
+			return result, nil
+		}
+
+		cursor = nextCursor
+	}
+}
+
+// compile compiles the expression, which must evaluate to a boolean.
+func (uc *policyUsecase) compile(expression string) (cel.Program, error) {
+	ast, iss := uc.env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("%w: %v", policy.ErrInvalidExpression, iss.Err())
+	}
+
+	if t := ast.OutputType(); !cel.BoolType.IsAssignableType(t) && t.String() != cel.DynType.String() {
+		return nil, fmt.Errorf("%w: expected bool result, got %s", policy.ErrInvalidExpression, t)
+	}
+
+	prg, err := uc.env.Program(ast,
+		cel.EvalOptions(cel.OptOptimize),
+		cel.CostLimit(costLimit),
+		cel.InterruptCheckFrequency(100),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", policy.ErrInvalidExpression, err)
+	}
+
+	return prg, nil
+}
+
+// cachedProgram returns the compiled expression of the stored policy, which
+// is compiled again once the expression is changed.
+func (uc *policyUsecase) cachedProgram(p *policy.Policy) (cel.Program, error) {
+	uc.programsMu.RLock()
+	cached, ok := uc.programs[p.ID]
+	uc.programsMu.RUnlock()
+
+	if ok && cached.expression == p.Expression {
+		return cached.program, nil
+	}
+
+	prg, err := uc.compile(p.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.cacheProgram(p.ID, p.Expression, prg)
+
+	return prg, nil
+}
+
+func (uc *policyUsecase) cacheProgram(id, expression string, prg cel.Program) {
+	uc.programsMu.Lock()
+	uc.programs[id] = &cachedProgram{expression: expression, program: prg}
+	uc.programsMu.Unlock()
+}
+
+// pruneCache removes the programs of the policies which are not stored
+// anymore, such as the ones deleted by other instances.
+func (uc *policyUsecase) pruneCache(policies []*policy.Policy) {
+	uc.programsMu.RLock()
+	stale := len(uc.programs) > len(policies)
+	uc.programsMu.RUnlock()
+
+	if !stale {
+		return
+	}
+
+	stored := make(map[string]bool, len(policies))
+	for _, p := range policies {
+		stored[p.ID] = true
+	}
+
+	uc.programsMu.Lock()
+	for id := range uc.programs {
+		if !stored[id] {
+			delete(uc.programs, id)
+		}
+	}
+	uc.programsMu.Unlock()
+}
+
+func attributes(in map[string]interface{}) map[string]interface{} {
+	if in == nil {
+		return map[string]interface{}{}
+	}
+
+	return in
+}
@@ -6,11 +6,15 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"go.uber.org/fx"
 
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
 	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
 	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
 	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	refreshtoken_bbolt "github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/bbolt"
 	refreshtoken_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/jsonmutexdb"
 	refreshtoken_leveldb "github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/leveldb"
+	refreshtoken_sqlite "github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/sqlite"
 )
 
 var repositoresfx = fx.Provide(
@@ -24,9 +28,13 @@ type RepositoryParams struct {
 
 	JSONMutexDBConfig *database_jsonmutexdb.Config
 	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
 
 	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
 	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
 }
 
 func NewRefreshTokenRepository(p RepositoryParams) (refreshtoken.RefreshTokenRepository, error) {
@@ -35,6 +43,10 @@ func NewRefreshTokenRepository(p RepositoryParams) (refreshtoken.RefreshTokenRep
 		return NewJSONMutexDBRefreshTokenRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
 	case database_leveldb.Type:
 		return NewLevelDBRefreshTokenRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteRefreshTokenRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltRefreshTokenRepository(p.BBoltConfig, p.BBolt)
 	default:
 		return nil, fmt.Errorf("invalid database type: %s", p.Type)
 	}
@@ -59,3 +71,23 @@ func NewLevelDBRefreshTokenRepository(
 		config.KeyPrefix,
 	)
 }
+
+func NewSQLiteRefreshTokenRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (refreshtoken.RefreshTokenRepository, error) {
+	return refreshtoken_sqlite.NewRefreshTokenRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltRefreshTokenRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (refreshtoken.RefreshTokenRepository, error) {
+	return refreshtoken_bbolt.NewRefreshTokenRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
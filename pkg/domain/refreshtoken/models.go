@@ -9,6 +9,10 @@ type RefreshToken struct {
 	Token   string
 	Revoked bool
 
+	// AuthenticatedAt is when the user logged in, starting the session of
+	// the token. Swapped tokens keep it.
+	AuthenticatedAt time.Time
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
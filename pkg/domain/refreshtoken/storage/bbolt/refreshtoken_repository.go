@@ -0,0 +1,362 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/noop"
+)
+
+const (
+	refreshTokensBucket            = "refresh_tokens"
+	refreshTokensUserIDIndexBucket = "index_refresh_tokens_user_id"
+	refreshTokensTokenIndexBucket  = "index_refresh_tokens_token"
+)
+
+// bboltRefreshTokenRepository is a repository that uses bbolt database.
+type bboltRefreshTokenRepository struct {
+	noop.UnimplementedRefreshTokenRepository
+
+	db *database_bbolt.DB
+
+	refreshTokensBucket            []byte
+	refreshTokensUserIDIndexBucket []byte
+	refreshTokensTokenIndexBucket  []byte
+
+	validate *validator.Validate
+}
+
+// NewRefreshTokenRepository returns a new bbolt repository.
+func NewRefreshTokenRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (refreshtoken.RefreshTokenRepository, error) {
+	r := &bboltRefreshTokenRepository{
+		db:                             db,
+		refreshTokensBucket:            []byte(bucketPrefix + refreshTokensBucket),
+		refreshTokensUserIDIndexBucket: []byte(bucketPrefix + refreshTokensUserIDIndexBucket),
+		refreshTokensTokenIndexBucket:  []byte(bucketPrefix + refreshTokensTokenIndexBucket),
+		validate:                       validator.New(),
+	}
+
+	err := db.CreateBuckets(
+		string(r.refreshTokensBucket),
+		string(r.refreshTokensUserIDIndexBucket),
+		string(r.refreshTokensTokenIndexBucket),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns               = "refreshtoken/storage/bbolt."
+	opSave           = ns + "Save"
+	opFindByID       = ns + "FindByID"
+	opExistsByID     = ns + "ExistsByID"
+	opFindAll        = ns + "FindAll"
+	opCount          = ns + "Count"
+	opDeleteByID     = ns + "DeleteByID"
+	opDelete         = ns + "Delete"
+	opFindByToken    = ns + "FindByToken"
+	opFindAllForUser = ns + "FindAllForUser"
+)
+
+// userIDIndexKey returns the key of the refresh token in the user ID index.
+func userIDIndexKey(userID, id string) []byte {
+	return []byte(userID + "/" + id)
+}
+
+func (r *bboltRefreshTokenRepository) Save(ctx context.Context, entity *refreshtoken.RefreshToken) (*refreshtoken.RefreshToken, error) {
+	inS := schema.RefreshTokenToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalRefreshToken(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(r.refreshTokensBucket)
+
+		// the indexes of the previous token are replaced
+		if prevValue := b.Get([]byte(inS.ID)); prevValue != nil {
+			prev, err := transformer.UnmarshalRefreshToken(prevValue)
+			if err != nil {
+				return err
+			}
+
+			err = r.deleteIndexes(tx, prev)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := b.Put([]byte(inS.ID), value)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(r.refreshTokensUserIDIndexBucket).Put(userIDIndexKey(inS.UserID, inS.ID), []byte{})
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(r.refreshTokensTokenIndexBucket).Put([]byte(inS.Token), []byte(inS.ID))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.RefreshTokenFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+// deleteIndexes deletes the index entries of the refresh token.
+func (r *bboltRefreshTokenRepository) deleteIndexes(tx *bolt.Tx, ts *schema.RefreshToken) error {
+	err := tx.Bucket(r.refreshTokensUserIDIndexBucket).Delete(userIDIndexKey(ts.UserID, ts.ID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(r.refreshTokensTokenIndexBucket).Delete([]byte(ts.Token))
+}
+
+// find returns the stored refresh token, or nil if it does not exist.
+func (r *bboltRefreshTokenRepository) find(tx *bolt.Tx, id string) (*schema.RefreshToken, error) {
+	value := tx.Bucket(r.refreshTokensBucket).Get([]byte(id))
+	if value == nil {
+		return nil, nil
+	}
+
+	return transformer.UnmarshalRefreshToken(value)
+}
+
+func (r *bboltRefreshTokenRepository) FindByID(ctx context.Context, id string) (*refreshtoken.RefreshToken, error) {
+	var ts *schema.RefreshToken
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		ts, err = r.find(tx, id)
+		if err == nil && ts == nil {
+			err = database.ErrNotFound
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.RefreshTokenFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *bboltRefreshTokenRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		has = tx.Bucket(r.refreshTokensBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *bboltRefreshTokenRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*refreshtoken.RefreshToken, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*refreshtoken.RefreshToken
+		nextCursor string
+	)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.refreshTokensBucket).Cursor()
+
+		k, v := c.First()
+		if afterCursor != "" {
+			k, v = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < limit; k, v = c.Next() {
+			ts, err := transformer.UnmarshalRefreshToken(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.RefreshTokenFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltRefreshTokenRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.refreshTokensBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *bboltRefreshTokenRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		ts, err := r.find(tx, id)
+		if err != nil || ts == nil {
+			return err
+		}
+
+		err = r.deleteIndexes(tx, ts)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(r.refreshTokensBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltRefreshTokenRepository) Delete(ctx context.Context, entity *refreshtoken.RefreshToken) error {
+	inS := schema.RefreshTokenToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDelete, err)
+	}
+
+	return r.DeleteByID(ctx, inS.ID)
+}
+
+func (r *bboltRefreshTokenRepository) FindByToken(ctx context.Context, token string) (*refreshtoken.RefreshToken, error) {
+	var ts *schema.RefreshToken
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		id := tx.Bucket(r.refreshTokensTokenIndexBucket).Get([]byte(token))
+		if id == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = r.find(tx, string(id))
+		if err == nil && ts == nil {
+			err = database.ErrNotFound
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByToken, token, err)
+	}
+
+	return schema.RefreshTokenFromSchema(ts), nil
+}
+
+func (r *bboltRefreshTokenRepository) FindAllForUser(ctx context.Context, userID, afterCursor string, limit int) ([]*refreshtoken.RefreshToken, string, error) {
+	if userID == "" {
+		return nil, "", fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*refreshtoken.RefreshToken
+		nextCursor string
+	)
+
+	prefix := userIDIndexKey(userID, "")
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.refreshTokensUserIDIndexBucket).Cursor()
+
+		k, _ := c.Seek(prefix)
+		if afterCursor != "" {
+			key := userIDIndexKey(userID, afterCursor)
+
+			k, _ = c.Seek(key)
+			if k != nil && bytes.Equal(k, key) {
+				k, _ = c.Next()
+			}
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, prefix) && len(result) < limit; k, _ = c.Next() {
+			ts, err := r.find(tx, string(k[len(prefix):]))
+			if err != nil {
+				return err
+			}
+
+			if ts == nil {
+				continue
+			}
+
+			result = append(result, schema.RefreshTokenFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/test"
+)
+
+func TestBBoltRefreshTokenRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (refreshtoken.RefreshTokenRepository, func()) {
+		return func(t *testing.T) (refreshtoken.RefreshTokenRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewRefreshTokenRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -12,6 +12,8 @@ type RefreshToken struct {
 	Token   string `json:"token" validate:"required,alphanum"`
 	Revoked bool   `json:"revoked,omitempty"`
 
+	AuthenticatedAt time.Time `json:"authenticated_at"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -23,6 +25,7 @@ func RefreshTokenToSchema(in *refreshtoken.RefreshToken) *RefreshToken {
 		out.UserID = in.UserID
 		out.Token = in.Token
 		out.Revoked = in.Revoked
+		out.AuthenticatedAt = in.AuthenticatedAt
 		out.CreatedAt = in.CreatedAt
 		out.UpdatedAt = in.UpdatedAt
 	}
@@ -36,6 +39,7 @@ func RefreshTokenFromSchema(in *RefreshToken) *refreshtoken.RefreshToken {
 	out.UserID = in.UserID
 	out.Token = in.Token
 	out.Revoked = in.Revoked
+	out.AuthenticatedAt = in.AuthenticatedAt
 	out.CreatedAt = in.CreatedAt
 	out.UpdatedAt = in.UpdatedAt
 
@@ -78,6 +78,17 @@ func (r *jsonMutexDBRefreshTokenRepository) load() error {
 	return nil
 }
 
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBRefreshTokenRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.RefreshToken)
+	r.dbIndexToken = make(map[string]*schema.RefreshToken)
+
+	return r.load()
+}
+
 func (r *jsonMutexDBRefreshTokenRepository) commit(ctx context.Context) error {
 	if r.loadSaver != nil {
 		out, err := json.Marshal(r.db)
@@ -85,7 +96,7 @@ func (r *jsonMutexDBRefreshTokenRepository) commit(ctx context.Context) error {
 			return err
 		}
 
-		return r.loadSaver.Save(r.filename, out)
+		return jsonmutexdb.Save(ctx, r.loadSaver, r.filename, out, r.reload)
 	}
 
 	return nil
@@ -101,6 +112,7 @@ const (
 )
 
 func (r *jsonMutexDBRefreshTokenRepository) Save(ctx context.Context, entity *refreshtoken.RefreshToken) (*refreshtoken.RefreshToken, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -216,6 +228,7 @@ func (r *jsonMutexDBRefreshTokenRepository) Count(ctx context.Context) (int, err
 }
 
 func (r *jsonMutexDBRefreshTokenRepository) DeleteByID(ctx context.Context, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -245,6 +258,7 @@ func (r *jsonMutexDBRefreshTokenRepository) Delete(ctx context.Context, entity *
 }
 
 func (r *jsonMutexDBRefreshTokenRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
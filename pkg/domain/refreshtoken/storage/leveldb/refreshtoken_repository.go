@@ -12,6 +12,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
 	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
 	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/json/schema"
 	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/json/transformer"
@@ -68,7 +69,7 @@ const (
 )
 
 func (r *levelDBRefreshTokenRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
-	return r.db.Write(batch, nil)
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
 }
 
 func (r *levelDBRefreshTokenRepository) Save(ctx context.Context, entity *refreshtoken.RefreshToken) (*refreshtoken.RefreshToken, error) {
@@ -128,7 +129,7 @@ func (r *levelDBRefreshTokenRepository) Save(ctx context.Context, entity *refres
 func (r *levelDBRefreshTokenRepository) FindByID(ctx context.Context, id string) (*refreshtoken.RefreshToken, error) {
 	key := transformer.MarshalRefreshTokenKey(r.refreshTokensKeyspace, id)
 
-	value, err := r.db.Get([]byte(key), nil)
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
 	if err != nil {
 		if errors.Is(err, leveldb.ErrNotFound) {
 			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
@@ -150,7 +151,7 @@ func (r *levelDBRefreshTokenRepository) FindByID(ctx context.Context, id string)
 func (r *levelDBRefreshTokenRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
 	key := transformer.MarshalRefreshTokenKey(r.refreshTokensKeyspace, id)
 
-	has, err := r.db.Has([]byte(key), nil)
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
 	if err != nil {
 		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
 	}
@@ -169,7 +170,7 @@ func (r *levelDBRefreshTokenRepository) FindAll(ctx context.Context, afterCursor
 		nextCursor string
 	)
 
-	iter := r.db.NewIterator(util.BytesPrefix([]byte(r.refreshTokensKeyspace)), nil)
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.refreshTokensKeyspace+"/")), nil)
 	defer iter.Release()
 
 	if afterCursor != "" {
@@ -218,7 +219,7 @@ func (r *levelDBRefreshTokenRepository) Count(ctx context.Context) (int, error)
 		ctxCheckOffset int
 	)
 
-	iter := r.db.NewIterator(util.BytesPrefix([]byte(r.refreshTokensKeyspace)), nil)
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.refreshTokensKeyspace+"/")), nil)
 	defer iter.Release()
 
 	for iter.Next() {
@@ -249,7 +250,7 @@ func (r *levelDBRefreshTokenRepository) DeleteByID(ctx context.Context, id strin
 
 	key := transformer.MarshalRefreshTokenKey(r.refreshTokensKeyspace, id)
 
-	value, err := r.db.Get([]byte(key), nil)
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
 	if err != nil {
 		if errors.Is(err, leveldb.ErrNotFound) {
 			return nil
@@ -299,7 +300,7 @@ func (r *levelDBRefreshTokenRepository) Delete(ctx context.Context, entity *refr
 func (r *levelDBRefreshTokenRepository) FindByToken(ctx context.Context, token string) (*refreshtoken.RefreshToken, error) {
 	tKey := transformer.MarshalRefreshTokenKey(r.refreshTokensTokenIndexKeyspace, token)
 
-	tValue, err := r.db.Get([]byte(tKey), nil)
+	tValue, err := database_leveldb.From(ctx, r.db).Get([]byte(tKey), nil)
 	if err != nil {
 		if errors.Is(err, leveldb.ErrNotFound) {
 			return nil, fmt.Errorf("%s(%s): %w", opFindByToken, token, database.ErrNotFound)
@@ -333,7 +334,7 @@ func (r *levelDBRefreshTokenRepository) FindAllForUser(ctx context.Context, user
 
 	keyPrefix := transformer.MarshalRefreshTokenUserIDKey(r.refreshTokensUserIDIndexKeyspace, userID, "")
 
-	iter := r.db.NewIterator(util.BytesPrefix([]byte(keyPrefix)), nil)
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(keyPrefix)), nil)
 	defer iter.Release()
 
 	if afterCursor != "" {
@@ -0,0 +1,265 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/noop"
+)
+
+const (
+	refreshTokensTable = "refresh_tokens"
+)
+
+// sqliteRefreshTokenRepository is a repository that uses SQLite database.
+type sqliteRefreshTokenRepository struct {
+	noop.UnimplementedRefreshTokenRepository
+
+	db *database_sqlite.DB
+
+	refreshTokensTable string
+
+	validate *validator.Validate
+}
+
+// NewRefreshTokenRepository returns a new SQLite repository.
+func NewRefreshTokenRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (refreshtoken.RefreshTokenRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sqliteRefreshTokenRepository{
+		db:                 db,
+		refreshTokensTable: database_sqlite.Table(tablePrefix, refreshTokensTable),
+		validate:           validator.New(),
+	}
+
+	return r, nil
+}
+
+const (
+	ns               = "refreshtoken/storage/sqlite."
+	opSave           = ns + "Save"
+	opFindByID       = ns + "FindByID"
+	opExistsByID     = ns + "ExistsByID"
+	opFindAll        = ns + "FindAll"
+	opCount          = ns + "Count"
+	opDeleteByID     = ns + "DeleteByID"
+	opDelete         = ns + "Delete"
+	opFindByToken    = ns + "FindByToken"
+	opFindAllForUser = ns + "FindAllForUser"
+)
+
+func (r *sqliteRefreshTokenRepository) Save(ctx context.Context, entity *refreshtoken.RefreshToken) (*refreshtoken.RefreshToken, error) {
+	inS := schema.RefreshTokenToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalRefreshToken(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.refreshTokensTable+` (id, user_id, token, data) VALUES (?, ?, ?, ?)`,
+		inS.ID, inS.UserID, inS.Token, string(value),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.RefreshTokenFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+// findOne returns the refresh token found by the query, which selects the
+// data.
+func (r *sqliteRefreshTokenRepository) findOne(ctx context.Context, query string, args ...interface{}) (*refreshtoken.RefreshToken, error) {
+	var value string
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	ts, err := transformer.UnmarshalRefreshToken([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.RefreshTokenFromSchema(ts), nil
+}
+
+// findAll returns the refresh tokens selected by the query, which selects
+// the data.
+func (r *sqliteRefreshTokenRepository) findAll(ctx context.Context, query string, args ...interface{}) ([]*refreshtoken.RefreshToken, error) {
+	var result []*refreshtoken.RefreshToken
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		ts, err := transformer.UnmarshalRefreshToken([]byte(value))
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, schema.RefreshTokenFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *sqliteRefreshTokenRepository) FindByID(ctx context.Context, id string) (*refreshtoken.RefreshToken, error) {
+	entity, err := r.findOne(ctx, `SELECT data FROM `+r.refreshTokensTable+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	return entity, nil
+}
+
+func (r *sqliteRefreshTokenRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+r.refreshTokensTable+` WHERE id = ?)`,
+		id,
+	).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *sqliteRefreshTokenRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*refreshtoken.RefreshToken, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var nextCursor string
+
+	result, err := r.findAll(ctx,
+		`SELECT data FROM `+r.refreshTokensTable+` WHERE id > ? ORDER BY id LIMIT ?`,
+		afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteRefreshTokenRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.refreshTokensTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqliteRefreshTokenRepository) DeleteByID(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.refreshTokensTable+` WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteRefreshTokenRepository) Delete(ctx context.Context, entity *refreshtoken.RefreshToken) error {
+	inS := schema.RefreshTokenToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDelete, err)
+	}
+
+	return r.DeleteByID(ctx, inS.ID)
+}
+
+func (r *sqliteRefreshTokenRepository) FindByToken(ctx context.Context, token string) (*refreshtoken.RefreshToken, error) {
+	entity, err := r.findOne(ctx, `SELECT data FROM `+r.refreshTokensTable+` WHERE token = ? LIMIT 1`, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByToken, token, err)
+	}
+
+	return entity, nil
+}
+
+func (r *sqliteRefreshTokenRepository) FindAllForUser(ctx context.Context, userID, afterCursor string, limit int) ([]*refreshtoken.RefreshToken, string, error) {
+	if userID == "" {
+		return nil, "", fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var nextCursor string
+
+	result, err := r.findAll(ctx,
+		`SELECT data FROM `+r.refreshTokensTable+` WHERE user_id = ? AND id > ? ORDER BY id LIMIT ?`,
+		userID, afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/refreshtoken/storage/test"
+)
+
+func TestSQLiteRefreshTokenRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (refreshtoken.RefreshTokenRepository, func()) {
+		return func(t *testing.T) (refreshtoken.RefreshTokenRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewRefreshTokenRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
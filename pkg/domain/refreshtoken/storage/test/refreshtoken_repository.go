@@ -5,6 +5,7 @@ import (
 	"errors"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
@@ -36,6 +37,8 @@ func createRefreshTokens(t *testing.T, repo refreshtoken.RefreshTokenRepository,
 			ID:     ulid.ULID().String(),
 			UserID: userID,
 			Token:  ulid.ULID().String(),
+
+			AuthenticatedAt: time.Now(),
 		}
 
 		savedEntity, err := repo.Save(ctx, entity)
@@ -56,6 +59,7 @@ func assertRefreshTokenEqual(t *testing.T, expected, actual *refreshtoken.Refres
 	assert.Equal(t, expected.UserID, actual.UserID)
 	assert.Equal(t, expected.Token, actual.Token)
 	assert.Equal(t, expected.Revoked, actual.Revoked)
+	assert.Equal(t, expected.AuthenticatedAt.Unix(), actual.AuthenticatedAt.Unix())
 	assert.Equal(t, expected.CreatedAt.Unix(), actual.CreatedAt.Unix())
 	assert.Equal(t, expected.UpdatedAt.Unix(), actual.UpdatedAt.Unix())
 }
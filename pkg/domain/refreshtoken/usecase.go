@@ -7,11 +7,12 @@ import (
 )
 
 type RefreshTokenUsecase interface {
-	// GrantAuthenticatedUser creates a refresh token for the provided user.
+	// GrantAuthenticatedUser creates a refresh token for the provided user,
+	// who has just logged in.
 	GrantAuthenticatedUser(context.Context, *user.User) (*RefreshToken, error)
 
-	// GrantRefreshTokenSwap swaps a refresh token for a new one, revoking
-	// the provided token.
+	// GrantRefreshTokenSwap swaps a refresh token for a new one of the same
+	// session, revoking the provided token.
 	GrantRefreshTokenSwap(context.Context, *user.User, *RefreshToken) (*RefreshToken, error)
 
 	// FindRefreshTokenByID retrieves an refresh token by ID.
@@ -20,6 +21,10 @@ type RefreshTokenUsecase interface {
 	// FindRefreshTokenByToken retrieves an refresh token by token value.
 	FindRefreshTokenByToken(context.Context, string) (*RefreshToken, error)
 
+	// FindAllRefreshTokensForUser retrieves the refresh tokens of the user in
+	// pages, starting after the cursor.
+	FindAllRefreshTokensForUser(ctx context.Context, userID, afterCursor string, limit int) ([]*RefreshToken, string, error)
+
 	// Revoke revokes the provided token.
 	Revoke(context.Context, *RefreshToken) error
 
@@ -29,6 +29,10 @@ func (*noopRefreshTokenUsecase) FindRefreshTokenByToken(ctx context.Context, tok
 	panic("FindRefreshTokenByToken not implemented")
 }
 
+func (*noopRefreshTokenUsecase) FindAllRefreshTokensForUser(ctx context.Context, userID, afterCursor string, limit int) ([]*refreshtoken.RefreshToken, string, error) {
+	panic("FindAllRefreshTokensForUser not implemented")
+}
+
 func (*noopRefreshTokenUsecase) Revoke(ctx context.Context, token *refreshtoken.RefreshToken) error {
 	panic("Revoke not implemented")
 }
@@ -3,6 +3,7 @@ package usecases
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/zbiljic/authzy/pkg/database"
 	"github.com/zbiljic/authzy/pkg/domain/refreshtoken"
@@ -26,13 +27,7 @@ func NewRefreshTokenUsecase(
 }
 
 func (uc *refreshTokenUsecase) GrantAuthenticatedUser(ctx context.Context, user *user.User) (*refreshtoken.RefreshToken, error) {
-	token := &refreshtoken.RefreshToken{
-		ID:     ulid.QuickULID().String(),
-		UserID: user.ID,
-		Token:  ulid.ULID().String(),
-	}
-
-	return uc.repository.Save(ctx, token)
+	return uc.grant(ctx, user, time.Now())
 }
 
 func (uc *refreshTokenUsecase) GrantRefreshTokenSwap(ctx context.Context, user *user.User, token *refreshtoken.RefreshToken) (*refreshtoken.RefreshToken, error) {
@@ -43,7 +38,25 @@ func (uc *refreshTokenUsecase) GrantRefreshTokenSwap(ctx context.Context, user *
 		return nil, err
 	}
 
-	return uc.GrantAuthenticatedUser(ctx, user)
+	// tokens saved before the login time was recorded
+	authenticatedAt := token.AuthenticatedAt
+	if authenticatedAt.IsZero() {
+		authenticatedAt = token.CreatedAt
+	}
+
+	return uc.grant(ctx, user, authenticatedAt)
+}
+
+// grant saves a new token of the session started by the login at the time.
+func (uc *refreshTokenUsecase) grant(ctx context.Context, user *user.User, authenticatedAt time.Time) (*refreshtoken.RefreshToken, error) {
+	token := &refreshtoken.RefreshToken{
+		ID:              ulid.QuickULID().String(),
+		UserID:          user.ID,
+		Token:           ulid.ULID().String(),
+		AuthenticatedAt: authenticatedAt,
+	}
+
+	return uc.repository.Save(ctx, token)
 }
 
 func (uc *refreshTokenUsecase) FindRefreshTokenByID(ctx context.Context, id string) (*refreshtoken.RefreshToken, error) {
@@ -54,6 +67,10 @@ func (uc *refreshTokenUsecase) FindRefreshTokenByToken(ctx context.Context, toke
 	return uc.repository.FindByToken(ctx, token)
 }
 
+func (uc *refreshTokenUsecase) FindAllRefreshTokensForUser(ctx context.Context, userID, afterCursor string, limit int) ([]*refreshtoken.RefreshToken, string, error) {
+	return uc.repository.FindAllForUser(ctx, userID, afterCursor, limit)
+}
+
 func (uc *refreshTokenUsecase) Revoke(ctx context.Context, token *refreshtoken.RefreshToken) error {
 	token.Revoked = true
 
@@ -0,0 +1,93 @@
+package di
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	relationtuple_bbolt "github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/bbolt"
+	relationtuple_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/jsonmutexdb"
+	relationtuple_leveldb "github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/leveldb"
+	relationtuple_sqlite "github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/sqlite"
+)
+
+var repositoresfx = fx.Provide(
+	NewRelationTupleRepository,
+)
+
+type RepositoryParams struct {
+	fx.In
+
+	Type string `name:"db_type"`
+
+	JSONMutexDBConfig *database_jsonmutexdb.Config
+	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+func NewRelationTupleRepository(p RepositoryParams) (relationtuple.RelationTupleRepository, error) {
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		return NewJSONMutexDBRelationTupleRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
+	case database_leveldb.Type:
+		return NewLevelDBRelationTupleRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteRelationTupleRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltRelationTupleRepository(p.BBoltConfig, p.BBolt)
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+func NewJSONMutexDBRelationTupleRepository(
+	config *database_jsonmutexdb.Config,
+	ls *database_jsonmutexdb.LoadSaver,
+) (relationtuple.RelationTupleRepository, error) {
+	return relationtuple_jsonmutexdb.NewRelationTupleRepository(
+		*ls,
+		config.FilenamePrefix,
+	)
+}
+
+func NewLevelDBRelationTupleRepository(
+	config *database_leveldb.Config,
+	db *leveldb.DB,
+) (relationtuple.RelationTupleRepository, error) {
+	return relationtuple_leveldb.NewRelationTupleRepository(
+		db,
+		config.KeyPrefix,
+	)
+}
+
+func NewSQLiteRelationTupleRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (relationtuple.RelationTupleRepository, error) {
+	return relationtuple_sqlite.NewRelationTupleRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltRelationTupleRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (relationtuple.RelationTupleRepository, error) {
+	return relationtuple_bbolt.NewRelationTupleRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
@@ -0,0 +1,22 @@
+package di
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/usecases"
+)
+
+var usecasesfx = fx.Provide(
+	NewRelationTupleUsecase,
+)
+
+func NewRelationTupleUsecase(
+	config *relationtuple.Config,
+	repository relationtuple.RelationTupleRepository,
+) (relationtuple.RelationTupleUsecase, error) {
+	return usecases.NewRelationTupleUsecase(
+		*config,
+		repository,
+	)
+}
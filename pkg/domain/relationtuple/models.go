@@ -0,0 +1,171 @@
+package relationtuple
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrMalformedInput is returned when a relation tuple or subject cannot be
+// parsed.
+var ErrMalformedInput = errors.New("malformed relation tuple")
+
+// RelationTuple represents the relation between an object and a subject, in
+// the "namespace:object#relation@subject" notation.
+type RelationTuple struct {
+	Namespace string
+	Object    string
+	Relation  string
+	Subject   *Subject
+
+	CreatedAt time.Time
+}
+
+// Subject of the relation tuple is either a subject ID, or a set of subjects
+// having a relation on an object (userset).
+type Subject struct {
+	ID  string
+	Set *SubjectSet
+}
+
+// SubjectSet references all subjects having the relation on the object.
+type SubjectSet struct {
+	Namespace string
+	Object    string
+	Relation  string
+}
+
+// RelationQuery filters relation tuples. Empty fields match any value.
+type RelationQuery struct {
+	Namespace string
+	Object    string
+	Relation  string
+	Subject   *Subject
+}
+
+// TreeNodeType is the type of the expand tree node.
+type TreeNodeType string
+
+const (
+	TreeNodeUnion TreeNodeType = "union"
+	TreeNodeLeaf  TreeNodeType = "leaf"
+)
+
+// Tree is the result of expanding a subject set. Union nodes contain the
+// subject sets whose members are part of the expanded set, leaves contain
+// subjects.
+type Tree struct {
+	Type     TreeNodeType
+	Subject  *Subject
+	Children []*Tree
+}
+
+func (t *RelationTuple) String() string {
+	return fmt.Sprintf("%s:%s#%s@%s", t.Namespace, t.Object, t.Relation, t.Subject)
+}
+
+func (s *Subject) String() string {
+	if s == nil {
+		return ""
+	}
+	if s.Set != nil {
+		return s.Set.String()
+	}
+
+	return s.ID
+}
+
+// Equal returns whether both subjects are the same.
+func (s *Subject) Equal(other *Subject) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+
+	return s.String() == other.String()
+}
+
+func (s *SubjectSet) String() string {
+	return fmt.Sprintf("%s:%s#%s", s.Namespace, s.Object, s.Relation)
+}
+
+// Matches returns whether the relation tuple satisfies the query.
+func (q *RelationQuery) Matches(t *RelationTuple) bool {
+	if q == nil {
+		return true
+	}
+
+	return (q.Namespace == "" || q.Namespace == t.Namespace) &&
+		(q.Object == "" || q.Object == t.Object) &&
+		(q.Relation == "" || q.Relation == t.Relation) &&
+		(q.Subject == nil || q.Subject.Equal(t.Subject))
+}
+
+// ParseRelationTuple parses the relation tuple from the
+// "namespace:object#relation@subject" notation.
+func ParseRelationTuple(s string) (*RelationTuple, error) {
+	parts := strings.SplitN(s, "@", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: expected subject: %q", ErrMalformedInput, s)
+	}
+
+	set, err := parseSubjectSet(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := ParseSubject(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &RelationTuple{
+		Namespace: set.Namespace,
+		Object:    set.Object,
+		Relation:  set.Relation,
+		Subject:   subject,
+	}, nil
+}
+
+// ParseSubject parses the subject, which is either a subject ID, or a subject
+// set in the "namespace:object#relation" notation.
+func ParseSubject(s string) (*Subject, error) {
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty subject", ErrMalformedInput)
+	}
+
+	if !strings.ContainsAny(s, ":#") {
+		return &Subject{ID: s}, nil
+	}
+
+	set, err := parseSubjectSet(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Subject{Set: set}, nil
+}
+
+func parseSubjectSet(s string) (*SubjectSet, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: expected namespace: %q", ErrMalformedInput, s)
+	}
+
+	objectRelation := strings.SplitN(parts[1], "#", 2)
+	if len(objectRelation) != 2 {
+		return nil, fmt.Errorf("%w: expected relation: %q", ErrMalformedInput, s)
+	}
+
+	set := &SubjectSet{
+		Namespace: parts[0],
+		Object:    objectRelation[0],
+		Relation:  objectRelation[1],
+	}
+
+	if set.Namespace == "" || set.Object == "" || set.Relation == "" {
+		return nil, fmt.Errorf("%w: %q", ErrMalformedInput, s)
+	}
+
+	return set, nil
+}
@@ -0,0 +1,179 @@
+package relationtuple
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrUnknownNamespace is returned for namespaces missing from the
+	// configuration.
+	ErrUnknownNamespace = errors.New("unknown namespace")
+
+	// ErrUnknownRelation is returned for relations not defined in the
+	// namespace.
+	ErrUnknownRelation = errors.New("unknown relation")
+)
+
+// Config holds the namespace configuration. Namespaces are loaded from a JSON
+// file containing an array of namespaces, or can be set directly.
+type Config struct {
+	NamespacesFile string       `json:"namespaces_file" split_words:"true"`
+	Namespaces     []*Namespace `json:"namespaces" ignored:"true"`
+	MaxDepth       int          `json:"max_depth" split_words:"true" default:"8"`
+}
+
+// Namespace defines the relations which objects in the namespace can have.
+type Namespace struct {
+	Name      string      `json:"name"`
+	Relations []*Relation `json:"relations"`
+}
+
+// Relation defines how subjects having the relation are computed. Relations
+// without the rewrite contain only subjects from the stored relation tuples.
+type Relation struct {
+	Name    string   `json:"name"`
+	Rewrite *Rewrite `json:"rewrite,omitempty"`
+}
+
+// Rewrite is the union of the subjects computed by each of the child usersets.
+type Rewrite struct {
+	Union []*Userset `json:"union"`
+}
+
+// Userset is a single rewrite rule. Exactly one of the fields is set.
+type Userset struct {
+	// This includes subjects from the stored relation tuples.
+	This bool `json:"this,omitempty"`
+
+	// ComputedUserset includes subjects having another relation on the
+	// same object.
+	ComputedUserset *ComputedUserset `json:"computed_userset,omitempty"`
+
+	// TupleToUserset includes subjects having the computed relation on
+	// the objects referenced by the tupleset relation.
+	TupleToUserset *TupleToUserset `json:"tuple_to_userset,omitempty"`
+}
+
+type ComputedUserset struct {
+	Relation string `json:"relation"`
+}
+
+type TupleToUserset struct {
+	Tupleset        string `json:"tupleset"`
+	ComputedUserset string `json:"computed_userset"`
+}
+
+// thisRewrite is used for relations without the rewrite.
+var thisRewrite = &Rewrite{Union: []*Userset{{This: true}}}
+
+// Namespaces is the validated namespace configuration.
+type Namespaces map[string]*Namespace
+
+// NewNamespaces loads and validates the namespace configuration.
+func NewNamespaces(c Config) (Namespaces, error) {
+	namespaces := append([]*Namespace(nil), c.Namespaces...)
+
+	if c.NamespacesFile != "" {
+		data, err := os.ReadFile(c.NamespacesFile)
+		if err != nil {
+			return nil, fmt.Errorf("read namespaces file: %w", err)
+		}
+
+		var fromFile []*Namespace
+		if err := json.Unmarshal(data, &fromFile); err != nil {
+			return nil, fmt.Errorf("parse namespaces file: %w", err)
+		}
+
+		namespaces = append(namespaces, fromFile...)
+	}
+
+	out := make(Namespaces, len(namespaces))
+	for _, n := range namespaces {
+		if n == nil || n.Name == "" {
+			return nil, errors.New("namespace name is required")
+		}
+		if _, ok := out[n.Name]; ok {
+			return nil, fmt.Errorf("duplicate namespace: %s", n.Name)
+		}
+
+		out[n.Name] = n
+	}
+
+	for _, n := range out {
+		if err := out.validate(n); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func (ns Namespaces) validate(n *Namespace) error {
+	relations := make(map[string]struct{}, len(n.Relations))
+	for _, r := range n.Relations {
+		if r == nil || r.Name == "" {
+			return fmt.Errorf("namespace %s: relation name is required", n.Name)
+		}
+		if _, ok := relations[r.Name]; ok {
+			return fmt.Errorf("namespace %s: duplicate relation: %s", n.Name, r.Name)
+		}
+
+		relations[r.Name] = struct{}{}
+	}
+
+	for _, r := range n.Relations {
+		if r.Rewrite == nil {
+			continue
+		}
+
+		for _, u := range r.Rewrite.Union {
+			switch {
+			case u == nil:
+				return fmt.Errorf("namespace %s: relation %s: empty userset", n.Name, r.Name)
+			case u.ComputedUserset != nil:
+				if _, ok := relations[u.ComputedUserset.Relation]; !ok {
+					return fmt.Errorf("namespace %s: relation %s: %w: %s",
+						n.Name, r.Name, ErrUnknownRelation, u.ComputedUserset.Relation)
+				}
+			case u.TupleToUserset != nil:
+				if _, ok := relations[u.TupleToUserset.Tupleset]; !ok {
+					return fmt.Errorf("namespace %s: relation %s: %w: %s",
+						n.Name, r.Name, ErrUnknownRelation, u.TupleToUserset.Tupleset)
+				}
+				if u.TupleToUserset.ComputedUserset == "" {
+					return fmt.Errorf("namespace %s: relation %s: computed userset is required",
+						n.Name, r.Name)
+				}
+			case !u.This:
+				return fmt.Errorf("namespace %s: relation %s: empty userset", n.Name, r.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Rewrite returns the rewrite rules of the relation in the namespace.
+func (ns Namespaces) Rewrite(namespace, relation string) (*Rewrite, error) {
+	n, ok := ns[namespace]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNamespace, namespace)
+	}
+
+	for _, r := range n.Relations {
+		if r.Name != relation {
+			continue
+		}
+
+		if r.Rewrite == nil {
+			return thisRewrite, nil
+		}
+
+		return r.Rewrite, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s#%s", ErrUnknownRelation, namespace, relation)
+}
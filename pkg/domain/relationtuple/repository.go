@@ -0,0 +1,24 @@
+package relationtuple
+
+import "context"
+
+type RelationTupleRepository interface {
+	// Save saves a given entity.
+	Save(ctx context.Context, entity *RelationTuple) (*RelationTuple, error)
+
+	// Exists returns whether the given entity exists.
+	Exists(ctx context.Context, entity *RelationTuple) (bool, error)
+
+	// FindAll returns all instances matching the query, ordered by their
+	// string representation.
+	FindAll(ctx context.Context, query *RelationQuery, afterCursor string, limit int) ([]*RelationTuple, string, error)
+
+	// Count returns the number of entities available.
+	Count(ctx context.Context) (int, error)
+
+	// Delete deletes a given entity.
+	Delete(ctx context.Context, entity *RelationTuple) error
+
+	// DeleteAll deletes all entities managed by the repository.
+	DeleteAll(ctx context.Context) error
+}
@@ -0,0 +1,236 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/noop"
+)
+
+const (
+	relationTuplesBucket = "relation_tuples"
+)
+
+// bboltRelationTupleRepository is a repository that uses bbolt database.
+type bboltRelationTupleRepository struct {
+	noop.UnimplementedRelationTupleRepository
+
+	db *database_bbolt.DB
+
+	relationTuplesBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewRelationTupleRepository returns a new bbolt repository.
+func NewRelationTupleRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (relationtuple.RelationTupleRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &bboltRelationTupleRepository{
+		db:                   db,
+		relationTuplesBucket: []byte(bucketPrefix + relationTuplesBucket),
+		validate:             validate,
+	}
+
+	err := db.CreateBuckets(string(r.relationTuplesBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns          = "relationtuple/storage/bbolt."
+	opSave      = ns + "Save"
+	opExists    = ns + "Exists"
+	opFindAll   = ns + "FindAll"
+	opCount     = ns + "Count"
+	opDelete    = ns + "Delete"
+	opDeleteAll = ns + "DeleteAll"
+)
+
+func (r *bboltRelationTupleRepository) Save(ctx context.Context, entity *relationtuple.RelationTuple) (*relationtuple.RelationTuple, error) {
+	inS := schema.RelationTupleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	id := transformer.MarshalRelationTupleID(inS)
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(r.relationTuplesBucket)
+
+		if existing := b.Get([]byte(id)); existing != nil {
+			if ts, err := transformer.UnmarshalRelationTuple(existing); err == nil {
+				inS.CreatedAt = ts.CreatedAt
+			}
+		}
+		if inS.CreatedAt.IsZero() {
+			inS.CreatedAt = time.Now()
+		}
+
+		value, err := transformer.MarshalRelationTuple(inS)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(id), value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, id, err)
+	}
+
+	savedEntity := schema.RelationTupleFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltRelationTupleRepository) Exists(ctx context.Context, entity *relationtuple.RelationTuple) (bool, error) {
+	inS := schema.RelationTupleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opExists, err)
+	}
+
+	id := transformer.MarshalRelationTupleID(inS)
+
+	var has bool
+
+	err = r.db.View(ctx, func(tx *bolt.Tx) error {
+		has = tx.Bucket(r.relationTuplesBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExists, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *bboltRelationTupleRepository) FindAll(ctx context.Context, query *relationtuple.RelationQuery, afterCursor string, limit int) ([]*relationtuple.RelationTuple, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*relationtuple.RelationTuple
+		nextCursor string
+	)
+
+	var prefix []byte
+	if query != nil {
+		prefix = []byte(transformer.MarshalRelationTupleIDPrefix(query.Namespace, query.Object, query.Relation))
+	}
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.relationTuplesBucket).Cursor()
+
+		k, v := c.Seek(prefix)
+		if afterCursor != "" && afterCursor >= string(prefix) {
+			// starts right after the cursor
+			k, v = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			ts, err := transformer.UnmarshalRelationTuple(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			t := schema.RelationTupleFromSchema(ts)
+
+			if !query.Matches(t) {
+				continue
+			}
+
+			result = append(result, t)
+
+			if len(result) == limit {
+				nextCursor = string(k)
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltRelationTupleRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.relationTuplesBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if count%100 == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *bboltRelationTupleRepository) Delete(ctx context.Context, entity *relationtuple.RelationTuple) error {
+	id := transformer.MarshalRelationTupleID(schema.RelationTupleToSchema(entity))
+
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(r.relationTuplesBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDelete, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltRelationTupleRepository) DeleteAll(ctx context.Context) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return database_bbolt.ClearBucket(tx, r.relationTuplesBucket)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/test"
+)
+
+func TestBBoltRelationTupleRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (relationtuple.RelationTupleRepository, func()) {
+		return func(t *testing.T) (relationtuple.RelationTupleRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewRelationTupleRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+)
+
+type RelationTuple struct {
+	Namespace string `json:"namespace" validate:"required,relationname"`
+	Object    string `json:"object" validate:"required,objectid"`
+	Relation  string `json:"relation" validate:"required,relationname"`
+
+	SubjectID        string `json:"subject_id,omitempty" validate:"required_without=SubjectNamespace,excluded_with=SubjectNamespace,omitempty,subjectid"`
+	SubjectNamespace string `json:"subject_namespace,omitempty" validate:"omitempty,relationname"`
+	SubjectObject    string `json:"subject_object,omitempty" validate:"required_with=SubjectNamespace,omitempty,objectid"`
+	SubjectRelation  string `json:"subject_relation,omitempty" validate:"required_with=SubjectNamespace,omitempty,relationname"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (t *RelationTuple) BeforeSave() error {
+	return nil
+}
+
+func RelationTupleToSchema(in *relationtuple.RelationTuple) *RelationTuple {
+	out := &RelationTuple{}
+	if in != nil {
+		out.Namespace = in.Namespace
+		out.Object = in.Object
+		out.Relation = in.Relation
+		if in.Subject != nil {
+			out.SubjectID = in.Subject.ID
+			if in.Subject.Set != nil {
+				out.SubjectNamespace = in.Subject.Set.Namespace
+				out.SubjectObject = in.Subject.Set.Object
+				out.SubjectRelation = in.Subject.Set.Relation
+			}
+		}
+		out.CreatedAt = in.CreatedAt
+	}
+
+	return out
+}
+
+func RelationTupleFromSchema(in *RelationTuple) *relationtuple.RelationTuple {
+	out := &relationtuple.RelationTuple{}
+	out.Namespace = in.Namespace
+	out.Object = in.Object
+	out.Relation = in.Relation
+	out.Subject = &relationtuple.Subject{ID: in.SubjectID}
+	if in.SubjectNamespace != "" {
+		out.Subject = &relationtuple.Subject{
+			Set: &relationtuple.SubjectSet{
+				Namespace: in.SubjectNamespace,
+				Object:    in.SubjectObject,
+				Relation:  in.SubjectRelation,
+			},
+		}
+	}
+	out.CreatedAt = in.CreatedAt
+
+	return out
+}
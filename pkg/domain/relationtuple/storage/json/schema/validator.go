@@ -0,0 +1,43 @@
+package schema
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	relationNameRegexString = "^[a-zA-Z0-9_.-]{1,64}$"
+	objectIDRegexString     = "^[^\\s/#@]{1,256}$"
+	subjectIDRegexString    = "^[^\\s/#@:]{1,256}$"
+)
+
+var (
+	relationNameRegex = regexp.MustCompile(relationNameRegexString)
+	objectIDRegex     = regexp.MustCompile(objectIDRegexString)
+	subjectIDRegex    = regexp.MustCompile(subjectIDRegexString)
+
+	validators = map[string]validator.Func{
+		"relationname": isRelationName,
+		"objectid":     isObjectID,
+		"subjectid":    isSubjectID,
+	}
+)
+
+func RegisterValidators(v *validator.Validate) {
+	for k, val := range validators {
+		_ = v.RegisterValidation(k, val)
+	}
+}
+
+func isRelationName(fl validator.FieldLevel) bool {
+	return relationNameRegex.MatchString(fl.Field().String())
+}
+
+func isObjectID(fl validator.FieldLevel) bool {
+	return objectIDRegex.MatchString(fl.Field().String())
+}
+
+func isSubjectID(fl validator.FieldLevel) bool {
+	return subjectIDRegex.MatchString(fl.Field().String())
+}
@@ -0,0 +1,71 @@
+package transformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/schema"
+)
+
+const keySeparator = "/"
+
+const (
+	ns                       = "relationtuple/storage/json/transformer."
+	opMarshalRelationTuple   = ns + "MarshalRelationTuple"
+	opUnmarshalRelationTuple = ns + "UnmarshalRelationTuple"
+)
+
+func MarshalRelationTupleKey(prefix, id string) string {
+	return strings.Join([]string{prefix, id}, keySeparator)
+}
+
+// MarshalRelationTupleID returns the ID of the relation tuple, composed so
+// that tuples sharing the namespace, object and relation are adjacent.
+func MarshalRelationTupleID(in *schema.RelationTuple) string {
+	subject := in.SubjectID
+	if in.SubjectNamespace != "" {
+		subject = fmt.Sprintf("%s:%s#%s", in.SubjectNamespace, in.SubjectObject, in.SubjectRelation)
+	}
+
+	return strings.Join([]string{in.Namespace, in.Object, in.Relation, subject}, keySeparator)
+}
+
+// MarshalRelationTupleIDPrefix returns the longest ID prefix shared by all
+// tuples with the given namespace, object and relation. Empty values are
+// treated as unknown.
+func MarshalRelationTupleIDPrefix(namespace, object, relation string) string {
+	var parts []string
+	for _, part := range []string{namespace, object, relation} {
+		if part == "" {
+			break
+		}
+
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return strings.Join(parts, keySeparator) + keySeparator
+}
+
+func MarshalRelationTuple(in *schema.RelationTuple) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalRelationTuple, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalRelationTuple(in []byte) (*schema.RelationTuple, error) {
+	out := &schema.RelationTuple{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalRelationTuple, err)
+	}
+
+	return out, nil
+}
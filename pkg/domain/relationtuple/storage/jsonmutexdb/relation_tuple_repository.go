@@ -0,0 +1,251 @@
+package jsonmutexdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/noop"
+)
+
+const (
+	relationTuplesPrefix = "relation_tuples"
+)
+
+type jsonMutexDBRelationTupleRepository struct {
+	noop.UnimplementedRelationTupleRepository
+
+	db map[string]schema.RelationTuple
+	mu sync.RWMutex
+
+	loadSaver jsonmutexdb.LoadSaver
+	filename  string
+
+	validate *validator.Validate
+}
+
+// NewRelationTupleRepository returns a new JSONMutexDB repository.
+func NewRelationTupleRepository(
+	loadSaver jsonmutexdb.LoadSaver,
+	filenamePrefix string,
+) (relationtuple.RelationTupleRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &jsonMutexDBRelationTupleRepository{
+		db:        make(map[string]schema.RelationTuple),
+		loadSaver: loadSaver,
+		filename:  fmt.Sprintf("%s%s.json", filenamePrefix, relationTuplesPrefix),
+		validate:  validate,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jsonMutexDBRelationTupleRepository) load() error {
+	if r.loadSaver != nil {
+		data, err := r.loadSaver.Load(r.filename)
+		if err != nil {
+			return err
+		}
+
+		if len(data) > 0 {
+			err = json.Unmarshal(data, &r.db)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBRelationTupleRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.RelationTuple)
+
+	return r.load()
+}
+
+func (r *jsonMutexDBRelationTupleRepository) commit(ctx context.Context) error {
+	if r.loadSaver != nil {
+		out, err := json.Marshal(r.db)
+		if err != nil {
+			return err
+		}
+
+		return jsonmutexdb.Save(ctx, r.loadSaver, r.filename, out, r.reload)
+	}
+	return nil
+}
+
+const (
+	ns          = "relationtuple/storage/jsonmutexdb."
+	opSave      = ns + "Save"
+	opExists    = ns + "Exists"
+	opDelete    = ns + "Delete"
+	opDeleteAll = ns + "DeleteAll"
+)
+
+func (r *jsonMutexDBRelationTupleRepository) Save(ctx context.Context, entity *relationtuple.RelationTuple) (*relationtuple.RelationTuple, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.RelationTupleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	id := transformer.MarshalRelationTupleID(inS)
+
+	if existing, ok := r.db[id]; ok {
+		inS.CreatedAt = existing.CreatedAt
+	}
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	r.db[id] = *inS
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	savedEntity := schema.RelationTupleFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *jsonMutexDBRelationTupleRepository) Exists(ctx context.Context, entity *relationtuple.RelationTuple) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	inS := schema.RelationTupleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opExists, err)
+	}
+
+	_, has := r.db[transformer.MarshalRelationTupleID(inS)]
+
+	return has, nil
+}
+
+func (r *jsonMutexDBRelationTupleRepository) FindAll(ctx context.Context, query *relationtuple.RelationQuery, afterCursor string, limit int) ([]*relationtuple.RelationTuple, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*relationtuple.RelationTuple
+		nextCursor string
+	)
+
+	var prefix string
+	if query != nil {
+		prefix = transformer.MarshalRelationTupleIDPrefix(query.Namespace, query.Object, query.Relation)
+	}
+
+	keys := []string{}
+	for id := range r.db {
+		if strings.HasPrefix(id, prefix) && id > afterCursor {
+			keys = append(keys, id)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, id := range keys {
+		val := r.db[id]
+
+		t := schema.RelationTupleFromSchema(&val)
+
+		if !query.Matches(t) {
+			continue
+		}
+
+		offset++
+
+		result = append(result, t)
+
+		if limit == offset {
+			nextCursor = id
+			break // stops iterator
+		}
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *jsonMutexDBRelationTupleRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.db), nil
+}
+
+func (r *jsonMutexDBRelationTupleRepository) Delete(ctx context.Context, entity *relationtuple.RelationTuple) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := transformer.MarshalRelationTupleID(schema.RelationTupleToSchema(entity))
+
+	if _, ok := r.db[id]; !ok {
+		return nil
+	}
+
+	delete(r.db, id)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDelete, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBRelationTupleRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.RelationTuple)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
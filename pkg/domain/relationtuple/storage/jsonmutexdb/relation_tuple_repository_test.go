@@ -0,0 +1,22 @@
+package jsonmutexdb_test
+
+import (
+	"testing"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/test"
+)
+
+func TestJSONMutexDBRelationTupleRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (relationtuple.RelationTupleRepository, func()) {
+		return func(t *testing.T) (relationtuple.RelationTupleRepository, func()) {
+			repo, err := jsonmutexdb.NewRelationTupleRepository(nil, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, func() {}
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package leveldb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/noop"
+)
+
+const (
+	relationTuplesPrefix = "relation_tuples"
+)
+
+// levelDBRelationTupleRepository is a repository that uses LevelDB database.
+type levelDBRelationTupleRepository struct {
+	noop.UnimplementedRelationTupleRepository
+
+	db *leveldb.DB
+	mu sync.Mutex
+
+	keyspace string
+
+	validate *validator.Validate
+}
+
+// NewRelationTupleRepository returns a new LevelDB repository.
+func NewRelationTupleRepository(
+	db *leveldb.DB,
+	keyPrefix string,
+) (relationtuple.RelationTupleRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &levelDBRelationTupleRepository{
+		db:       db,
+		keyspace: keyPrefix + relationTuplesPrefix,
+		validate: validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns          = "relationtuple/storage/leveldb."
+	opSave      = ns + "Save"
+	opExists    = ns + "Exists"
+	opFindAll   = ns + "FindAll"
+	opCount     = ns + "Count"
+	opDelete    = ns + "Delete"
+	opDeleteAll = ns + "DeleteAll"
+)
+
+func (r *levelDBRelationTupleRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
+}
+
+func (r *levelDBRelationTupleRepository) Save(ctx context.Context, entity *relationtuple.RelationTuple) (*relationtuple.RelationTuple, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.RelationTupleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	id := transformer.MarshalRelationTupleID(inS)
+	key := transformer.MarshalRelationTupleKey(r.keyspace, id)
+
+	existing, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err == nil {
+		if ts, err := transformer.UnmarshalRelationTuple(existing); err == nil {
+			inS.CreatedAt = ts.CreatedAt
+		}
+	}
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	value, err := transformer.MarshalRelationTuple(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, id, err)
+	}
+
+	err = database_leveldb.From(ctx, r.db).Put([]byte(key), value, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, id, err)
+	}
+
+	savedEntity := schema.RelationTupleFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *levelDBRelationTupleRepository) Exists(ctx context.Context, entity *relationtuple.RelationTuple) (bool, error) {
+	inS := schema.RelationTupleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opExists, err)
+	}
+
+	id := transformer.MarshalRelationTupleID(inS)
+	key := transformer.MarshalRelationTupleKey(r.keyspace, id)
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExists, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *levelDBRelationTupleRepository) FindAll(ctx context.Context, query *relationtuple.RelationQuery, afterCursor string, limit int) ([]*relationtuple.RelationTuple, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*relationtuple.RelationTuple
+		nextCursor string
+	)
+
+	var prefix string
+	if query != nil {
+		prefix = transformer.MarshalRelationTupleIDPrefix(query.Namespace, query.Object, query.Relation)
+	}
+
+	keyRange := util.BytesPrefix([]byte(transformer.MarshalRelationTupleKey(r.keyspace, prefix)))
+
+	if afterCursor != "" {
+		// starts right after the cursor
+		start := []byte(transformer.MarshalRelationTupleKey(r.keyspace, afterCursor) + "\x00")
+		if bytes.Compare(start, keyRange.Start) > 0 {
+			keyRange.Start = start
+		}
+	}
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(keyRange, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		ts, err := transformer.UnmarshalRelationTuple(iter.Value())
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, string(iter.Key()), err)
+		}
+
+		t := schema.RelationTupleFromSchema(ts)
+
+		if !query.Matches(t) {
+			continue
+		}
+
+		offset++
+
+		result = append(result, t)
+
+		if limit == offset {
+			nextCursor = transformer.MarshalRelationTupleID(ts)
+			break // stops iterator
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *levelDBRelationTupleRepository) Count(ctx context.Context) (int, error) {
+	var (
+		count          int
+		ctxCheckOffset int
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.keyspace+"/")), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if count == ctxCheckOffset {
+			select {
+			case <-ctx.Done():
+				return count, fmt.Errorf("%s: %w", opCount, ctx.Err())
+			default:
+			}
+
+			ctxCheckOffset += 100
+		}
+
+		count++
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *levelDBRelationTupleRepository) Delete(ctx context.Context, entity *relationtuple.RelationTuple) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := transformer.MarshalRelationTupleID(schema.RelationTupleToSchema(entity))
+	key := transformer.MarshalRelationTupleKey(r.keyspace, id)
+
+	err := database_leveldb.From(ctx, r.db).Delete([]byte(key), nil)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDelete, id, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBRelationTupleRepository) DeleteAll(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.keyspace+"/")), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
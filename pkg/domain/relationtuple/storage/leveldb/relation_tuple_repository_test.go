@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/test"
+)
+
+func TestLevelDBRelationTupleRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (relationtuple.RelationTupleRepository, func()) {
+		return func(t *testing.T) (relationtuple.RelationTupleRepository, func()) {
+			db, cleanup := database_leveldb.Fixture()
+
+			repo, err := leveldb.NewRelationTupleRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
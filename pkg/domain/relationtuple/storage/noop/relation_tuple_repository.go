@@ -0,0 +1,37 @@
+package noop
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+)
+
+// Compile-time proof of interface implementation.
+var _ relationtuple.RelationTupleRepository = (*UnimplementedRelationTupleRepository)(nil)
+
+// UnimplementedRelationTupleRepository can be embedded to have forward compatible implementations.
+type UnimplementedRelationTupleRepository struct{}
+
+func (*UnimplementedRelationTupleRepository) Save(ctx context.Context, entity *relationtuple.RelationTuple) (*relationtuple.RelationTuple, error) {
+	panic("Save not implemented")
+}
+
+func (*UnimplementedRelationTupleRepository) Exists(ctx context.Context, entity *relationtuple.RelationTuple) (bool, error) {
+	panic("Exists not implemented")
+}
+
+func (*UnimplementedRelationTupleRepository) FindAll(ctx context.Context, query *relationtuple.RelationQuery, afterCursor string, limit int) ([]*relationtuple.RelationTuple, string, error) {
+	panic("FindAll not implemented")
+}
+
+func (*UnimplementedRelationTupleRepository) Count(ctx context.Context) (int, error) {
+	panic("Count not implemented")
+}
+
+func (*UnimplementedRelationTupleRepository) Delete(ctx context.Context, entity *relationtuple.RelationTuple) error {
+	panic("Delete not implemented")
+}
+
+func (*UnimplementedRelationTupleRepository) DeleteAll(ctx context.Context) error {
+	panic("DeleteAll not implemented")
+}
@@ -0,0 +1,246 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/noop"
+)
+
+const (
+	relationTuplesTable = "relation_tuples"
+)
+
+// sqliteRelationTupleRepository is a repository that uses SQLite database.
+type sqliteRelationTupleRepository struct {
+	noop.UnimplementedRelationTupleRepository
+
+	db *database_sqlite.DB
+
+	relationTuplesTable string
+
+	validate *validator.Validate
+}
+
+// NewRelationTupleRepository returns a new SQLite repository.
+func NewRelationTupleRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (relationtuple.RelationTupleRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &sqliteRelationTupleRepository{
+		db:                  db,
+		relationTuplesTable: database_sqlite.Table(tablePrefix, relationTuplesTable),
+		validate:            validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns          = "relationtuple/storage/sqlite."
+	opSave      = ns + "Save"
+	opExists    = ns + "Exists"
+	opFindAll   = ns + "FindAll"
+	opCount     = ns + "Count"
+	opDelete    = ns + "Delete"
+	opDeleteAll = ns + "DeleteAll"
+)
+
+func (r *sqliteRelationTupleRepository) Save(ctx context.Context, entity *relationtuple.RelationTuple) (*relationtuple.RelationTuple, error) {
+	inS := schema.RelationTupleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	id := transformer.MarshalRelationTupleID(inS)
+
+	err = r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		var existing string
+
+		err := tx.QueryRowContext(ctx, `SELECT data FROM `+r.relationTuplesTable+` WHERE id = ?`, id).Scan(&existing)
+		if err == nil {
+			if ts, err := transformer.UnmarshalRelationTuple([]byte(existing)); err == nil {
+				inS.CreatedAt = ts.CreatedAt
+			}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if inS.CreatedAt.IsZero() {
+			inS.CreatedAt = time.Now()
+		}
+
+		value, err := transformer.MarshalRelationTuple(inS)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO `+r.relationTuplesTable+` (
+				id, namespace, object, relation, data
+			) VALUES (?, ?, ?, ?, ?)`,
+			id, inS.Namespace, inS.Object, inS.Relation, string(value),
+		)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, id, err)
+	}
+
+	savedEntity := schema.RelationTupleFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *sqliteRelationTupleRepository) Exists(ctx context.Context, entity *relationtuple.RelationTuple) (bool, error) {
+	inS := schema.RelationTupleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opExists, err)
+	}
+
+	id := transformer.MarshalRelationTupleID(inS)
+
+	var has bool
+
+	err = r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+r.relationTuplesTable+` WHERE id = ?)`,
+		id,
+	).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExists, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *sqliteRelationTupleRepository) FindAll(ctx context.Context, query *relationtuple.RelationQuery, afterCursor string, limit int) ([]*relationtuple.RelationTuple, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*relationtuple.RelationTuple
+		nextCursor string
+	)
+
+	conds := []string{"id > ?"}
+	args := []interface{}{afterCursor}
+
+	if query != nil {
+		for _, c := range []struct{ column, value string }{
+			{"namespace", query.Namespace},
+			{"object", query.Object},
+			{"relation", query.Relation},
+		} {
+			if c.value != "" {
+				conds = append(conds, c.column+" = ?")
+				args = append(args, c.value)
+			}
+		}
+	}
+
+	// the subject is matched while reading the rows
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, data FROM `+r.relationTuplesTable+`
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY id`,
+		args...,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, value string
+
+		err := rows.Scan(&id, &value)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+		}
+
+		ts, err := transformer.UnmarshalRelationTuple([]byte(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, id, err)
+		}
+
+		t := schema.RelationTupleFromSchema(ts)
+
+		if !query.Matches(t) {
+			continue
+		}
+
+		result = append(result, t)
+
+		if len(result) == limit {
+			nextCursor = id
+			break
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteRelationTupleRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.relationTuplesTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqliteRelationTupleRepository) Delete(ctx context.Context, entity *relationtuple.RelationTuple) error {
+	id := transformer.MarshalRelationTupleID(schema.RelationTupleToSchema(entity))
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.relationTuplesTable+` WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDelete, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteRelationTupleRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.relationTuplesTable)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/test"
+)
+
+func TestSQLiteRelationTupleRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (relationtuple.RelationTupleRepository, func()) {
+		return func(t *testing.T) (relationtuple.RelationTupleRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewRelationTupleRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -0,0 +1,317 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple/storage/json/schema"
+)
+
+const (
+	TestPrefix = "test-"
+)
+
+func testValidator() *validator.Validate {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+	return validate
+}
+
+func mustParse(t *testing.T, s string) *relationtuple.RelationTuple {
+	t.Helper()
+
+	tuple, err := relationtuple.ParseRelationTuple(s)
+	require.NoError(t, err)
+
+	return tuple
+}
+
+func createRelationTuples(t *testing.T, repo relationtuple.RelationTupleRepository, count int) []*relationtuple.RelationTuple {
+	t.Helper()
+
+	var result []*relationtuple.RelationTuple
+
+	ctx := context.Background()
+
+	for i := 0; i < count; i++ {
+		entity := mustParse(t, fmt.Sprintf("documents:doc-%d#viewer@user-%d", i%2, i))
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		result = append(result, savedEntity)
+	}
+
+	return result
+}
+
+func Run(t *testing.T, f func() func(t *testing.T) (relationtuple.RelationTupleRepository, func())) {
+	t.Helper()
+
+	t.Run("init", func(t *testing.T) {
+		_, cleanup := f()(t)
+		defer cleanup()
+	})
+	t.Run("Save", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRelationTupleRepositorySave(t, repo)
+	})
+	t.Run("Exists", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRelationTupleRepositoryExists(t, repo)
+	})
+	t.Run("FindAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRelationTupleRepositoryFindAll(t, repo)
+	})
+	t.Run("Count", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRelationTupleRepositoryCount(t, repo)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRelationTupleRepositoryDelete(t, repo)
+	})
+	t.Run("DeleteAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRelationTupleRepositoryDeleteAll(t, repo)
+	})
+}
+
+func testRelationTupleRepositorySave(t *testing.T, repo relationtuple.RelationTupleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	validate := testValidator()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := repo.Save(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		entity := &relationtuple.RelationTuple{}
+
+		_, err := repo.Save(ctx, entity)
+		assert.Error(t, err)
+
+		inS := schema.RelationTupleToSchema(entity)
+		validateErr := validate.Struct(inS)
+
+		assert.Contains(t, err.Error(), validateErr.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, entity := range []*relationtuple.RelationTuple{
+			{
+				Namespace: "documents",
+				Object:    "doc/1",
+				Relation:  "viewer",
+				Subject:   &relationtuple.Subject{ID: "user"},
+			},
+			{
+				Namespace: "documents",
+				Object:    "doc",
+				Relation:  "viewer",
+				Subject:   &relationtuple.Subject{ID: "groups:admins"},
+			},
+			{
+				Namespace: "documents",
+				Object:    "doc",
+				Relation:  "viewer",
+				Subject: &relationtuple.Subject{
+					Set: &relationtuple.SubjectSet{Namespace: "groups", Object: "admins"},
+				},
+			},
+		} {
+			_, err := repo.Save(ctx, entity)
+			assert.Error(t, err, entity.String())
+		}
+	})
+
+	t.Run("subject", func(t *testing.T) {
+		entity := mustParse(t, "documents:doc#viewer@user")
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		assert.Equal(t, entity.String(), savedEntity.String())
+		assert.False(t, savedEntity.CreatedAt.IsZero())
+
+		// idempotent
+		resavedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		assert.Equal(t, savedEntity.CreatedAt.Unix(), resavedEntity.CreatedAt.Unix())
+	})
+
+	t.Run("subject_set", func(t *testing.T) {
+		entity := mustParse(t, "documents:doc#viewer@groups:admins#member")
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		assert.Equal(t, entity.String(), savedEntity.String())
+		require.NotNil(t, savedEntity.Subject.Set)
+		assert.Equal(t, "member", savedEntity.Subject.Set.Relation)
+	})
+}
+
+func testRelationTupleRepositoryExists(t *testing.T, repo relationtuple.RelationTupleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	entities := createRelationTuples(t, repo, 2)
+
+	for _, entity := range entities {
+		exists, err := repo.Exists(ctx, entity)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	}
+
+	exists, err := repo.Exists(ctx, mustParse(t, "documents:doc-0#owner@user-0"))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testRelationTupleRepositoryFindAll(t *testing.T, repo relationtuple.RelationTupleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	createRelationTuples(t, repo, 10)
+
+	_, err := repo.Save(ctx, mustParse(t, "documents:doc-0#owner@user-0"))
+	require.NoError(t, err)
+	_, err = repo.Save(ctx, mustParse(t, "folders:doc-0#viewer@user-0"))
+	require.NoError(t, err)
+
+	t.Run("all", func(t *testing.T) {
+		result, _, err := repo.FindAll(ctx, nil, "", 100)
+		require.NoError(t, err)
+		assert.Len(t, result, 12)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		tests := []struct {
+			query    *relationtuple.RelationQuery
+			expected int
+		}{
+			{&relationtuple.RelationQuery{Namespace: "documents"}, 11},
+			{&relationtuple.RelationQuery{Namespace: "documents", Object: "doc-0"}, 6},
+			{&relationtuple.RelationQuery{Namespace: "documents", Object: "doc-0", Relation: "viewer"}, 5},
+			{&relationtuple.RelationQuery{Relation: "viewer"}, 11},
+			{&relationtuple.RelationQuery{Subject: &relationtuple.Subject{ID: "user-0"}}, 3},
+			{&relationtuple.RelationQuery{Namespace: "unknown"}, 0},
+		}
+
+		for _, tt := range tests {
+			result, _, err := repo.FindAll(ctx, tt.query, "", 100)
+			require.NoError(t, err)
+			assert.Len(t, result, tt.expected, "%+v", tt.query)
+
+			for _, entity := range result {
+				assert.True(t, tt.query.Matches(entity))
+			}
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		query := &relationtuple.RelationQuery{Namespace: "documents", Relation: "viewer"}
+
+		var (
+			seen   = make(map[string]struct{})
+			cursor string
+		)
+
+		for i := 0; i < 10; i++ {
+			result, nextCursor, err := repo.FindAll(ctx, query, cursor, 3)
+			require.NoError(t, err)
+
+			for _, entity := range result {
+				seen[entity.String()] = struct{}{}
+			}
+
+			if nextCursor == "" {
+				break
+			}
+
+			cursor = nextCursor
+		}
+
+		assert.Len(t, seen, 10)
+	})
+}
+
+func testRelationTupleRepositoryCount(t *testing.T, repo relationtuple.RelationTupleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	createRelationTuples(t, repo, 5)
+
+	count, err = repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func testRelationTupleRepositoryDelete(t *testing.T, repo relationtuple.RelationTupleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	entities := createRelationTuples(t, repo, 3)
+
+	err := repo.Delete(ctx, entities[0])
+	require.NoError(t, err)
+
+	exists, err := repo.Exists(ctx, entities[0])
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// not existing
+	err = repo.Delete(ctx, entities[0])
+	require.NoError(t, err)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func testRelationTupleRepositoryDeleteAll(t *testing.T, repo relationtuple.RelationTupleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	createRelationTuples(t, repo, 3)
+
+	err := repo.DeleteAll(ctx)
+	require.NoError(t, err)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,28 @@
+package relationtuple
+
+import "context"
+
+type RelationTupleUsecase interface {
+	// WriteRelationTuples stores the relation tuples, after validating them
+	// against the namespace configuration.
+	WriteRelationTuples(context.Context, []*RelationTuple) error
+
+	// DeleteRelationTuples deletes the relation tuples.
+	DeleteRelationTuples(context.Context, []*RelationTuple) error
+
+	// FindRelationTuples retrieves relation tuples matching the query in
+	// pages, starting after the cursor.
+	FindRelationTuples(ctx context.Context, query *RelationQuery, afterCursor string, limit int) ([]*RelationTuple, string, error)
+
+	// Check returns whether the subject has the relation on the object,
+	// either directly or through the namespace rewrite rules.
+	Check(context.Context, *RelationTuple) (bool, error)
+
+	// Expand returns the tree of all subjects in the subject set, up to the
+	// given depth. Zero depth uses the configured maximum.
+	Expand(ctx context.Context, set *SubjectSet, depth int) (*Tree, error)
+
+	// ListObjects returns the objects in the namespace on which the subject
+	// has the relation.
+	ListObjects(ctx context.Context, namespace, relation string, subject *Subject) ([]string, error)
+}
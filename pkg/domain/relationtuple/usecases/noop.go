@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+)
+
+// Compile-time proof of interface implementation.
+var _ relationtuple.RelationTupleUsecase = (*noopRelationTupleUsecase)(nil)
+
+// noopRelationTupleUsecase can be embedded to have forward compatible implementations.
+type noopRelationTupleUsecase struct{}
+
+func (*noopRelationTupleUsecase) WriteRelationTuples(ctx context.Context, tuples []*relationtuple.RelationTuple) error {
+	panic("WriteRelationTuples not implemented")
+}
+
+func (*noopRelationTupleUsecase) DeleteRelationTuples(ctx context.Context, tuples []*relationtuple.RelationTuple) error {
+	panic("DeleteRelationTuples not implemented")
+}
+
+func (*noopRelationTupleUsecase) FindRelationTuples(ctx context.Context, query *relationtuple.RelationQuery, afterCursor string, limit int) ([]*relationtuple.RelationTuple, string, error) {
+	panic("FindRelationTuples not implemented")
+}
+
+func (*noopRelationTupleUsecase) Check(ctx context.Context, tuple *relationtuple.RelationTuple) (bool, error) {
+	panic("Check not implemented")
+}
+
+func (*noopRelationTupleUsecase) Expand(ctx context.Context, set *relationtuple.SubjectSet, depth int) (*relationtuple.Tree, error) {
+	panic("Expand not implemented")
+}
+
+func (*noopRelationTupleUsecase) ListObjects(ctx context.Context, namespace, relation string, subject *relationtuple.Subject) ([]string, error) {
+	panic("ListObjects not implemented")
+}
@@ -0,0 +1,337 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zbiljic/authzy/pkg/domain/relationtuple"
+)
+
+// pageSize is the number of relation tuples read at once while traversing.
+const pageSize = 100
+
+type relationTupleUsecase struct {
+	noopRelationTupleUsecase
+
+	namespaces relationtuple.Namespaces
+	maxDepth   int
+
+	repository relationtuple.RelationTupleRepository
+}
+
+func NewRelationTupleUsecase(
+	config relationtuple.Config,
+	repository relationtuple.RelationTupleRepository,
+) (relationtuple.RelationTupleUsecase, error) {
+	namespaces, err := relationtuple.NewNamespaces(config)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDepth := config.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 8
+	}
+
+	uc := &relationTupleUsecase{
+		namespaces: namespaces,
+		maxDepth:   maxDepth,
+		repository: repository,
+	}
+	return uc, nil
+}
+
+func (uc *relationTupleUsecase) WriteRelationTuples(ctx context.Context, tuples []*relationtuple.RelationTuple) error {
+	for _, tuple := range tuples {
+		if err := uc.validate(tuple); err != nil {
+			return err
+		}
+	}
+
+	for _, tuple := range tuples {
+		if _, err := uc.repository.Save(ctx, tuple); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *relationTupleUsecase) DeleteRelationTuples(ctx context.Context, tuples []*relationtuple.RelationTuple) error {
+	for _, tuple := range tuples {
+		if err := uc.repository.Delete(ctx, tuple); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *relationTupleUsecase) FindRelationTuples(ctx context.Context, query *relationtuple.RelationQuery, afterCursor string, limit int) ([]*relationtuple.RelationTuple, string, error) {
+	return uc.repository.FindAll(ctx, query, afterCursor, limit)
+}
+
+func (uc *relationTupleUsecase) Check(ctx context.Context, tuple *relationtuple.RelationTuple) (bool, error) {
+	if tuple.Subject == nil {
+		return false, fmt.Errorf("%w: empty subject", relationtuple.ErrMalformedInput)
+	}
+
+	set := &relationtuple.SubjectSet{
+		Namespace: tuple.Namespace,
+		Object:    tuple.Object,
+		Relation:  tuple.Relation,
+	}
+
+	return uc.check(ctx, set, tuple.Subject, uc.maxDepth)
+}
+
+func (uc *relationTupleUsecase) check(ctx context.Context, set *relationtuple.SubjectSet, subject *relationtuple.Subject, depth int) (bool, error) {
+	if subject.Set != nil && *subject.Set == *set {
+		return true, nil
+	}
+
+	if depth <= 0 {
+		return false, nil
+	}
+
+	rewrite, err := uc.namespaces.Rewrite(set.Namespace, set.Relation)
+	if err != nil {
+		return false, err
+	}
+
+	for _, userset := range rewrite.Union {
+		var allowed bool
+
+		switch {
+		case userset.This:
+			err = uc.forEachTuple(ctx, set.Namespace, set.Object, set.Relation, func(t *relationtuple.RelationTuple) (bool, error) {
+				if t.Subject.Equal(subject) {
+					allowed = true
+					return false, nil
+				}
+
+				if t.Subject.Set != nil {
+					allowed, err = uc.check(ctx, t.Subject.Set, subject, depth-1)
+					return !allowed && err == nil, err
+				}
+
+				return true, nil
+			})
+		case userset.ComputedUserset != nil:
+			allowed, err = uc.check(ctx, &relationtuple.SubjectSet{
+				Namespace: set.Namespace,
+				Object:    set.Object,
+				Relation:  userset.ComputedUserset.Relation,
+			}, subject, depth-1)
+		case userset.TupleToUserset != nil:
+			ttu := userset.TupleToUserset
+			err = uc.forEachTuple(ctx, set.Namespace, set.Object, ttu.Tupleset, func(t *relationtuple.RelationTuple) (bool, error) {
+				if t.Subject.Set == nil || !uc.hasRelation(t.Subject.Set.Namespace, ttu.ComputedUserset) {
+					return true, nil
+				}
+
+				allowed, err = uc.check(ctx, &relationtuple.SubjectSet{
+					Namespace: t.Subject.Set.Namespace,
+					Object:    t.Subject.Set.Object,
+					Relation:  ttu.ComputedUserset,
+				}, subject, depth-1)
+				return !allowed && err == nil, err
+			})
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if allowed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// hasRelation reports whether the relation is defined in the namespace. The
+// objects a tuple to userset rewrite points to may be of any namespace, and
+// the ones without the computed relation are not a match.
+func (uc *relationTupleUsecase) hasRelation(namespace, relation string) bool {
+	_, err := uc.namespaces.Rewrite(namespace, relation)
+	return !errors.Is(err, relationtuple.ErrUnknownRelation)
+}
+
+func (uc *relationTupleUsecase) Expand(ctx context.Context, set *relationtuple.SubjectSet, depth int) (*relationtuple.Tree, error) {
+	if depth <= 0 || depth > uc.maxDepth {
+		depth = uc.maxDepth
+	}
+
+	return uc.expand(ctx, set, depth)
+}
+
+func (uc *relationTupleUsecase) expand(ctx context.Context, set *relationtuple.SubjectSet, depth int) (*relationtuple.Tree, error) {
+	tree := &relationtuple.Tree{
+		Type:    relationtuple.TreeNodeUnion,
+		Subject: &relationtuple.Subject{Set: set},
+	}
+
+	if depth <= 0 {
+		tree.Type = relationtuple.TreeNodeLeaf
+		return tree, nil
+	}
+
+	rewrite, err := uc.namespaces.Rewrite(set.Namespace, set.Relation)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userset := range rewrite.Union {
+		switch {
+		case userset.This:
+			err = uc.forEachTuple(ctx, set.Namespace, set.Object, set.Relation, func(t *relationtuple.RelationTuple) (bool, error) {
+				if t.Subject.Set == nil {
+					tree.Children = append(tree.Children, &relationtuple.Tree{
+						Type:    relationtuple.TreeNodeLeaf,
+						Subject: t.Subject,
+					})
+					return true, nil
+				}
+
+				child, err := uc.expand(ctx, t.Subject.Set, depth-1)
+				if err != nil {
+					return false, err
+				}
+
+				tree.Children = append(tree.Children, child)
+				return true, nil
+			})
+		case userset.ComputedUserset != nil:
+			var child *relationtuple.Tree
+			child, err = uc.expand(ctx, &relationtuple.SubjectSet{
+				Namespace: set.Namespace,
+				Object:    set.Object,
+				Relation:  userset.ComputedUserset.Relation,
+			}, depth-1)
+			if err == nil {
+				tree.Children = append(tree.Children, child)
+			}
+		case userset.TupleToUserset != nil:
+			ttu := userset.TupleToUserset
+			err = uc.forEachTuple(ctx, set.Namespace, set.Object, ttu.Tupleset, func(t *relationtuple.RelationTuple) (bool, error) {
+				if t.Subject.Set == nil || !uc.hasRelation(t.Subject.Set.Namespace, ttu.ComputedUserset) {
+					return true, nil
+				}
+
+				child, err := uc.expand(ctx, &relationtuple.SubjectSet{
+					Namespace: t.Subject.Set.Namespace,
+					Object:    t.Subject.Set.Object,
+					Relation:  ttu.ComputedUserset,
+				}, depth-1)
+				if err != nil {
+					return false, err
+				}
+
+				tree.Children = append(tree.Children, child)
+				return true, nil
+			})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tree, nil
+}
+
+func (uc *relationTupleUsecase) ListObjects(ctx context.Context, namespace, relation string, subject *relationtuple.Subject) ([]string, error) {
+	if _, err := uc.namespaces.Rewrite(namespace, relation); err != nil {
+		return nil, err
+	}
+
+	var (
+		objects []string
+		seen    = make(map[string]struct{})
+	)
+
+	// candidates are all objects having any relation tuple in the namespace
+	err := uc.forEachTuple(ctx, namespace, "", "", func(t *relationtuple.RelationTuple) (bool, error) {
+		if _, ok := seen[t.Object]; ok {
+			return true, nil
+		}
+		seen[t.Object] = struct{}{}
+
+		allowed, err := uc.check(ctx, &relationtuple.SubjectSet{
+			Namespace: namespace,
+			Object:    t.Object,
+			Relation:  relation,
+		}, subject, uc.maxDepth)
+		if err != nil {
+			return false, err
+		}
+
+		if allowed {
+			objects = append(objects, t.Object)
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// validate checks the relation tuple against the namespace configuration.
+func (uc *relationTupleUsecase) validate(tuple *relationtuple.RelationTuple) error {
+	if tuple.Subject == nil {
+		return fmt.Errorf("%w: empty subject", relationtuple.ErrMalformedInput)
+	}
+
+	if _, err := uc.namespaces.Rewrite(tuple.Namespace, tuple.Relation); err != nil {
+		return err
+	}
+
+	if set := tuple.Subject.Set; set != nil {
+		if _, err := uc.namespaces.Rewrite(set.Namespace, set.Relation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forEachTuple calls the function for every relation tuple matching the
+// namespace, object and relation, until it returns false or an error.
+func (uc *relationTupleUsecase) forEachTuple(
+	ctx context.Context,
+	namespace, object, relation string,
+	fn func(*relationtuple.RelationTuple) (bool, error),
+) error {
+	query := &relationtuple.RelationQuery{
+		Namespace: namespace,
+		Object:    object,
+		Relation:  relation,
+	}
+
+	var cursor string
+	for {
+		tuples, nextCursor, err := uc.repository.FindAll(ctx, query, cursor, pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tuples {
+			next, err := fn(t)
+			if err != nil || !next {
+				return err
+			}
+		}
+
+		if nextCursor == "" {
+			return nil
+		}
+
+		cursor = nextCursor
+	}
+}
@@ -0,0 +1,93 @@
+package di
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	role_bbolt "github.com/zbiljic/authzy/pkg/domain/role/storage/bbolt"
+	role_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/role/storage/jsonmutexdb"
+	role_leveldb "github.com/zbiljic/authzy/pkg/domain/role/storage/leveldb"
+	role_sqlite "github.com/zbiljic/authzy/pkg/domain/role/storage/sqlite"
+)
+
+var repositoresfx = fx.Provide(
+	NewRoleRepository,
+)
+
+type RepositoryParams struct {
+	fx.In
+
+	Type string `name:"db_type"`
+
+	JSONMutexDBConfig *database_jsonmutexdb.Config
+	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+func NewRoleRepository(p RepositoryParams) (role.RoleRepository, error) {
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		return NewJSONMutexDBRoleRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
+	case database_leveldb.Type:
+		return NewLevelDBRoleRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteRoleRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltRoleRepository(p.BBoltConfig, p.BBolt)
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+func NewJSONMutexDBRoleRepository(
+	config *database_jsonmutexdb.Config,
+	ls *database_jsonmutexdb.LoadSaver,
+) (role.RoleRepository, error) {
+	return role_jsonmutexdb.NewRoleRepository(
+		*ls,
+		config.FilenamePrefix,
+	)
+}
+
+func NewLevelDBRoleRepository(
+	config *database_leveldb.Config,
+	db *leveldb.DB,
+) (role.RoleRepository, error) {
+	return role_leveldb.NewRoleRepository(
+		db,
+		config.KeyPrefix,
+	)
+}
+
+func NewSQLiteRoleRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (role.RoleRepository, error) {
+	return role_sqlite.NewRoleRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltRoleRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (role.RoleRepository, error) {
+	return role_bbolt.NewRoleRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
@@ -0,0 +1,21 @@
+package di
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/usecases"
+)
+
+var usecasesfx = fx.Provide(
+	NewRoleUsecase,
+)
+
+func NewRoleUsecase(
+	repository role.RoleRepository,
+) role.RoleUsecase {
+	uc := usecases.NewRoleUsecase(
+		repository,
+	)
+	return uc
+}
@@ -0,0 +1,14 @@
+package role
+
+import "time"
+
+// Role represents a named set of permissions which can be assigned to users.
+type Role struct {
+	// ID is the unique role name, as it appears in access tokens.
+	ID          string
+	Description string
+	Permissions []string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,39 @@
+package role
+
+import "context"
+
+type RoleRepository interface {
+	// Save saves a given entity.
+	Save(ctx context.Context, entity *Role) (*Role, error)
+
+	// FindByID retrieves an entity by its id.
+	FindByID(ctx context.Context, id string) (*Role, error)
+
+	// ExistsByID returns whether an entity with the given id exists.
+	ExistsByID(ctx context.Context, id string) (bool, error)
+
+	// FindAll returns all instances of the type.
+	FindAll(ctx context.Context, afterCursor string, limit int) ([]*Role, string, error)
+
+	// Count returns the number of entities available.
+	Count(ctx context.Context) (int, error)
+
+	// DeleteByID deletes the entity with the given id, together with all
+	// its user assignments.
+	DeleteByID(ctx context.Context, id string) error
+
+	// DeleteAll deletes all entities managed by the repository.
+	DeleteAll(ctx context.Context) error
+
+	// AssignToUser assigns the role with the given id to the user.
+	AssignToUser(ctx context.Context, userID, id string) error
+
+	// UnassignFromUser removes the role with the given id from the user.
+	UnassignFromUser(ctx context.Context, userID, id string) error
+
+	// FindAllForUser returns all roles assigned to specified user ID.
+	FindAllForUser(ctx context.Context, userID string) ([]*Role, error)
+
+	// DeleteAllForUser removes all roles assigned to specified user ID.
+	DeleteAllForUser(ctx context.Context, userID string) error
+}
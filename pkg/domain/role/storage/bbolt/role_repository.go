@@ -0,0 +1,357 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/noop"
+)
+
+const (
+	rolesBucket              = "roles"
+	userRolesBucket          = "user_roles"
+	userRolesRoleIndexBucket = "index_user_roles_role_id"
+)
+
+// bboltRoleRepository is a repository that uses bbolt database.
+type bboltRoleRepository struct {
+	noop.UnimplementedRoleRepository
+
+	db *database_bbolt.DB
+
+	rolesBucket              []byte
+	userRolesBucket          []byte
+	userRolesRoleIndexBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewRoleRepository returns a new bbolt repository.
+func NewRoleRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (role.RoleRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &bboltRoleRepository{
+		db:                       db,
+		rolesBucket:              []byte(bucketPrefix + rolesBucket),
+		userRolesBucket:          []byte(bucketPrefix + userRolesBucket),
+		userRolesRoleIndexBucket: []byte(bucketPrefix + userRolesRoleIndexBucket),
+		validate:                 validate,
+	}
+
+	err := db.CreateBuckets(
+		string(r.rolesBucket),
+		string(r.userRolesBucket),
+		string(r.userRolesRoleIndexBucket),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns                 = "role/storage/bbolt."
+	opSave             = ns + "Save"
+	opFindByID         = ns + "FindByID"
+	opExistsByID       = ns + "ExistsByID"
+	opFindAll          = ns + "FindAll"
+	opCount            = ns + "Count"
+	opDeleteByID       = ns + "DeleteByID"
+	opAssignToUser     = ns + "AssignToUser"
+	opUnassignFromUser = ns + "UnassignFromUser"
+	opFindAllForUser   = ns + "FindAllForUser"
+	opDeleteAllForUser = ns + "DeleteAllForUser"
+)
+
+// pairKey returns the key of the pair of IDs, sorting the pairs by the first
+// ID.
+func pairKey(first, second string) []byte {
+	return []byte(first + "/" + second)
+}
+
+func (r *bboltRoleRepository) Save(ctx context.Context, entity *role.Role) (*role.Role, error) {
+	inS := schema.RoleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalRole(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(r.rolesBucket).Put([]byte(inS.ID), value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.RoleFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltRoleRepository) FindByID(ctx context.Context, id string) (*role.Role, error) {
+	var ts *schema.Role
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		value := tx.Bucket(r.rolesBucket).Get([]byte(id))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = transformer.UnmarshalRole(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.RoleFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *bboltRoleRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		has = tx.Bucket(r.rolesBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *bboltRoleRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*role.Role, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*role.Role
+		nextCursor string
+	)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.rolesBucket).Cursor()
+
+		k, v := c.First()
+		if afterCursor != "" {
+			k, v = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < limit; k, v = c.Next() {
+			ts, err := transformer.UnmarshalRole(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.RoleFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltRoleRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.rolesBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+// deletePairs deletes the pairs of IDs starting with the first ID from the
+// bucket, together with the reversed pairs from the other bucket.
+func deletePairs(tx *bolt.Tx, bucket, reversedBucket []byte, first string) error {
+	prefix := pairKey(first, "")
+
+	c := tx.Bucket(bucket).Cursor()
+	reversed := tx.Bucket(reversedBucket)
+
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Seek(prefix) {
+		err := reversed.Delete(pairKey(string(v), first))
+		if err != nil {
+			return err
+		}
+
+		err = c.Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *bboltRoleRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		err := tx.Bucket(r.rolesBucket).Delete([]byte(id))
+		if err != nil {
+			return err
+		}
+
+		// remove role from all users
+		return deletePairs(tx, r.userRolesRoleIndexBucket, r.userRolesBucket, id)
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltRoleRepository) AssignToUser(ctx context.Context, userID, id string) error {
+	inS := &schema.UserRole{
+		UserID: userID,
+		RoleID: id,
+	}
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opAssignToUser, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		if tx.Bucket(r.rolesBucket).Get([]byte(inS.RoleID)) == nil {
+			return database.ErrNotFound
+		}
+
+		err := tx.Bucket(r.userRolesBucket).Put(pairKey(inS.UserID, inS.RoleID), []byte(inS.RoleID))
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(r.userRolesRoleIndexBucket).Put(pairKey(inS.RoleID, inS.UserID), []byte(inS.UserID))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opAssignToUser, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltRoleRepository) UnassignFromUser(ctx context.Context, userID, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		err := tx.Bucket(r.userRolesBucket).Delete(pairKey(userID, id))
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(r.userRolesRoleIndexBucket).Delete(pairKey(id, userID))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opUnassignFromUser, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltRoleRepository) FindAllForUser(ctx context.Context, userID string) ([]*role.Role, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	var result []*role.Role
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		prefix := pairKey(userID, "")
+
+		roles := tx.Bucket(r.rolesBucket)
+
+		c := tx.Bucket(r.userRolesBucket).Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			value := roles.Get(v)
+			if value == nil {
+				continue
+			}
+
+			ts, err := transformer.UnmarshalRole(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(v), err)
+			}
+
+			result = append(result, schema.RoleFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	return result, nil
+}
+
+func (r *bboltRoleRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("%s: userID cannot be empty", opDeleteAllForUser)
+	}
+
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return deletePairs(tx, r.userRolesBucket, r.userRolesRoleIndexBucket, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, userID, err)
+	}
+
+	return nil
+}
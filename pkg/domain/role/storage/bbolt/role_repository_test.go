@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/test"
+)
+
+func TestBBoltRoleRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (role.RoleRepository, func()) {
+		return func(t *testing.T) (role.RoleRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewRoleRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
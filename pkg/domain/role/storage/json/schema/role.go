@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/role"
+)
+
+type Role struct {
+	ID          string   `json:"role_id" validate:"required,rolename"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty" validate:"dive,permission"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (r *Role) BeforeSave() error {
+	return nil
+}
+
+// UserRole is the assignment of a role to the user.
+type UserRole struct {
+	UserID string `json:"user_id" validate:"required,alphanum"`
+	RoleID string `json:"role_id" validate:"required,rolename"`
+}
+
+func RoleToSchema(in *role.Role) *Role {
+	out := &Role{}
+	if in != nil {
+		out.ID = in.ID
+		out.Description = in.Description
+		out.Permissions = in.Permissions
+		out.CreatedAt = in.CreatedAt
+		out.UpdatedAt = in.UpdatedAt
+	}
+
+	return out
+}
+
+func RoleFromSchema(in *Role) *role.Role {
+	out := &role.Role{}
+	out.ID = in.ID
+	out.Description = in.Description
+	out.Permissions = in.Permissions
+	out.CreatedAt = in.CreatedAt
+	out.UpdatedAt = in.UpdatedAt
+
+	return out
+}
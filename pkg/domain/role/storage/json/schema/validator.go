@@ -0,0 +1,36 @@
+package schema
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	roleNameRegexString   = "^[a-zA-Z0-9][a-zA-Z0-9_.:-]{0,63}$"
+	permissionRegexString = "^[a-zA-Z0-9*][a-zA-Z0-9_.:/*-]{0,127}$"
+)
+
+var (
+	roleNameRegex   = regexp.MustCompile(roleNameRegexString)
+	permissionRegex = regexp.MustCompile(permissionRegexString)
+
+	validators = map[string]validator.Func{
+		"rolename":   isRoleName,
+		"permission": isPermission,
+	}
+)
+
+func RegisterValidators(v *validator.Validate) {
+	for k, val := range validators {
+		_ = v.RegisterValidation(k, val)
+	}
+}
+
+func isRoleName(fl validator.FieldLevel) bool {
+	return roleNameRegex.MatchString(fl.Field().String())
+}
+
+func isPermission(fl validator.FieldLevel) bool {
+	return permissionRegex.MatchString(fl.Field().String())
+}
@@ -0,0 +1,65 @@
+package transformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/schema"
+)
+
+const keySeparator = "/"
+
+const (
+	ns                  = "role/storage/json/transformer."
+	opMarshalRole       = ns + "MarshalRole"
+	opUnmarshalRole     = ns + "UnmarshalRole"
+	opMarshalUserRole   = ns + "MarshalUserRole"
+	opUnmarshalUserRole = ns + "UnmarshalUserRole"
+)
+
+func MarshalRoleKey(prefix, id string) string {
+	return strings.Join([]string{prefix, id}, keySeparator)
+}
+
+func MarshalUserRoleID(userRole *schema.UserRole) string {
+	return strings.Join([]string{userRole.UserID, userRole.RoleID}, keySeparator)
+}
+
+func MarshalRole(in *schema.Role) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalRole, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalRole(in []byte) (*schema.Role, error) {
+	out := &schema.Role{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalRole, err)
+	}
+
+	return out, nil
+}
+
+func MarshalUserRole(in *schema.UserRole) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalUserRole, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalUserRole(in []byte) (*schema.UserRole, error) {
+	out := &schema.UserRole{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalUserRole, err)
+	}
+
+	return out, nil
+}
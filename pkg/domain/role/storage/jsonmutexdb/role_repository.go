@@ -0,0 +1,395 @@
+package jsonmutexdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/noop"
+)
+
+const (
+	rolesPrefix     = "roles"
+	userRolesPrefix = "user_roles"
+)
+
+type jsonMutexDBRoleRepository struct {
+	noop.UnimplementedRoleRepository
+
+	db          map[string]schema.Role
+	dbUserRoles map[string]schema.UserRole
+	mu          sync.RWMutex
+
+	loadSaver         jsonmutexdb.LoadSaver
+	filename          string
+	userRolesFilename string
+
+	validate *validator.Validate
+}
+
+// NewRoleRepository returns a new JSONMutexDB repository.
+func NewRoleRepository(
+	loadSaver jsonmutexdb.LoadSaver,
+	filenamePrefix string,
+) (role.RoleRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &jsonMutexDBRoleRepository{
+		db:                make(map[string]schema.Role),
+		dbUserRoles:       make(map[string]schema.UserRole),
+		loadSaver:         loadSaver,
+		filename:          fmt.Sprintf("%s%s.json", filenamePrefix, rolesPrefix),
+		userRolesFilename: fmt.Sprintf("%s%s.json", filenamePrefix, userRolesPrefix),
+		validate:          validate,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jsonMutexDBRoleRepository) load() error {
+	if r.loadSaver != nil {
+		for filename, v := range map[string]interface{}{
+			r.filename:          &r.db,
+			r.userRolesFilename: &r.dbUserRoles,
+		} {
+			data, err := r.loadSaver.Load(filename)
+			if err != nil {
+				return err
+			}
+
+			if len(data) > 0 {
+				err = json.Unmarshal(data, v)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBRoleRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Role)
+	r.dbUserRoles = make(map[string]schema.UserRole)
+
+	return r.load()
+}
+
+func (r *jsonMutexDBRoleRepository) commit(ctx context.Context) error {
+	if r.loadSaver != nil {
+		for filename, v := range map[string]interface{}{
+			r.filename:          r.db,
+			r.userRolesFilename: r.dbUserRoles,
+		} {
+			out, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			err = jsonmutexdb.Save(ctx, r.loadSaver, filename, out, r.reload)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+const (
+	ns                 = "role/storage/jsonmutexdb."
+	opSave             = ns + "Save"
+	opFindByID         = ns + "FindByID"
+	opDeleteByID       = ns + "DeleteByID"
+	opDeleteAll        = ns + "DeleteAll"
+	opAssignToUser     = ns + "AssignToUser"
+	opUnassignFromUser = ns + "UnassignFromUser"
+	opFindAllForUser   = ns + "FindAllForUser"
+	opDeleteAllForUser = ns + "DeleteAllForUser"
+)
+
+func (r *jsonMutexDBRoleRepository) Save(ctx context.Context, entity *role.Role) (*role.Role, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.RoleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	r.db[inS.ID] = *inS
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	savedEntity := schema.RoleFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *jsonMutexDBRoleRepository) FindByID(ctx context.Context, id string) (*role.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, ok := r.db[id]
+	if !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+	}
+
+	entity := schema.RoleFromSchema(&value)
+
+	return entity, nil
+}
+
+func (r *jsonMutexDBRoleRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, has := r.db[id]
+
+	return has, nil
+}
+
+func (r *jsonMutexDBRoleRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*role.Role, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*role.Role
+		nextCursor string
+	)
+
+	keys := []string{}
+	for id := range r.db {
+		keys = append(keys, id)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var afterCursorKey string
+	if afterCursor != "" {
+		afterCursorKey = afterCursor
+	}
+
+	for _, id := range keys {
+		if afterCursorKey != "" {
+			if afterCursorKey == id {
+				afterCursorKey = ""
+			}
+
+			continue
+		}
+
+		offset++
+
+		val := r.db[id]
+
+		t := schema.RoleFromSchema(&val)
+
+		result = append(result, t)
+
+		if limit == offset {
+			break // stops iterator
+		}
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *jsonMutexDBRoleRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.db), nil
+}
+
+func (r *jsonMutexDBRoleRepository) DeleteByID(ctx context.Context, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[id]; !ok {
+		return nil
+	}
+
+	delete(r.db, id)
+
+	for key, val := range r.dbUserRoles {
+		if val.RoleID == id {
+			delete(r.dbUserRoles, key)
+		}
+	}
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteByID, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBRoleRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Role)
+	r.dbUserRoles = make(map[string]schema.UserRole)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBRoleRepository) AssignToUser(ctx context.Context, userID, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := &schema.UserRole{
+		UserID: userID,
+		RoleID: id,
+	}
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opAssignToUser, err)
+	}
+
+	if _, ok := r.db[id]; !ok {
+		return fmt.Errorf("%s(%s): %w", opAssignToUser, id, database.ErrNotFound)
+	}
+
+	r.dbUserRoles[transformer.MarshalUserRoleID(inS)] = *inS
+
+	err = r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opAssignToUser, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBRoleRepository) UnassignFromUser(ctx context.Context, userID, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := transformer.MarshalUserRoleID(&schema.UserRole{
+		UserID: userID,
+		RoleID: id,
+	})
+
+	if _, ok := r.dbUserRoles[key]; !ok {
+		return nil
+	}
+
+	delete(r.dbUserRoles, key)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opUnassignFromUser, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBRoleRepository) FindAllForUser(ctx context.Context, userID string) ([]*role.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	var (
+		result []*role.Role
+	)
+
+	for _, val := range r.dbUserRoles {
+		if val.UserID != userID {
+			continue
+		}
+
+		value, ok := r.db[val.RoleID]
+		if !ok {
+			continue
+		}
+
+		t := schema.RoleFromSchema(&value)
+
+		result = append(result, t)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result, nil
+}
+
+func (r *jsonMutexDBRoleRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if userID == "" {
+		return fmt.Errorf("%s: userID cannot be empty", opDeleteAllForUser)
+	}
+
+	for key, val := range r.dbUserRoles {
+		if val.UserID == userID {
+			delete(r.dbUserRoles, key)
+		}
+	}
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAllForUser, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package jsonmutexdb_test
+
+import (
+	"testing"
+
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/test"
+)
+
+func TestJSONMutexDBRoleRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (role.RoleRepository, func()) {
+		return func(t *testing.T) (role.RoleRepository, func()) {
+			repo, err := jsonmutexdb.NewRoleRepository(nil, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, func() {}
+		}
+	})
+}
@@ -0,0 +1,406 @@
+package leveldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/noop"
+)
+
+const (
+	rolesPrefix     = "roles"
+	userRolesPrefix = "user_roles"
+)
+
+// levelDBRoleRepository is a repository that uses LevelDB database.
+type levelDBRoleRepository struct {
+	noop.UnimplementedRoleRepository
+
+	db *leveldb.DB
+	mu sync.Mutex
+
+	rolesKeyspace     string
+	userRolesKeyspace string
+
+	validate *validator.Validate
+}
+
+// NewRoleRepository returns a new LevelDB repository.
+func NewRoleRepository(
+	db *leveldb.DB,
+	keyPrefix string,
+) (role.RoleRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &levelDBRoleRepository{
+		db:                db,
+		rolesKeyspace:     keyPrefix + rolesPrefix,
+		userRolesKeyspace: keyPrefix + userRolesPrefix,
+		validate:          validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns                 = "role/storage/leveldb."
+	opSave             = ns + "Save"
+	opFindByID         = ns + "FindByID"
+	opExistsByID       = ns + "ExistsByID"
+	opFindAll          = ns + "FindAll"
+	opCount            = ns + "Count"
+	opDeleteByID       = ns + "DeleteByID"
+	opAssignToUser     = ns + "AssignToUser"
+	opUnassignFromUser = ns + "UnassignFromUser"
+	opFindAllForUser   = ns + "FindAllForUser"
+	opDeleteAllForUser = ns + "DeleteAllForUser"
+)
+
+func (r *levelDBRoleRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
+}
+
+// userRolesKeyPrefix returns the prefix of all role assignments of the user.
+func (r *levelDBRoleRepository) userRolesKeyPrefix(userID string) string {
+	return transformer.MarshalRoleKey(r.userRolesKeyspace, userID) + "/"
+}
+
+func (r *levelDBRoleRepository) Save(ctx context.Context, entity *role.Role) (*role.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.RoleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	key := transformer.MarshalRoleKey(r.rolesKeyspace, inS.ID)
+
+	value, err := transformer.MarshalRole(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = database_leveldb.From(ctx, r.db).Put([]byte(key), value, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.RoleFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *levelDBRoleRepository) FindByID(ctx context.Context, id string) (*role.Role, error) {
+	key := transformer.MarshalRoleKey(r.rolesKeyspace, id)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	ts, err := transformer.UnmarshalRole(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.RoleFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *levelDBRoleRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	key := transformer.MarshalRoleKey(r.rolesKeyspace, id)
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *levelDBRoleRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*role.Role, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		offset     int
+		result     []*role.Role
+		nextCursor string
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.rolesKeyspace+"/")), nil)
+	defer iter.Release()
+
+	if afterCursor != "" {
+		key := transformer.MarshalRoleKey(r.rolesKeyspace, afterCursor)
+
+		if ok := iter.Seek([]byte(key)); !ok {
+			err := iter.Error()
+			if err != nil {
+				return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+			}
+		}
+	}
+
+	for iter.Next() {
+		offset++
+
+		ts, err := transformer.UnmarshalRole(iter.Value())
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, string(iter.Key()), err)
+		}
+
+		t := schema.RoleFromSchema(ts)
+
+		result = append(result, t)
+
+		if limit == offset {
+			break // stops iterator
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *levelDBRoleRepository) Count(ctx context.Context) (int, error) {
+	var (
+		count          int
+		ctxCheckOffset int
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.rolesKeyspace+"/")), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if count == ctxCheckOffset {
+			select {
+			case <-ctx.Done():
+				return count, fmt.Errorf("%s: %w", opCount, ctx.Err())
+			default:
+			}
+
+			ctxCheckOffset += 100
+		}
+
+		count++
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *levelDBRoleRepository) DeleteByID(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := transformer.MarshalRoleKey(r.rolesKeyspace, id)
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	if !has {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+
+	batch.Delete([]byte(key))
+
+	// remove role from all users
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.userRolesKeyspace+"/")), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		ts, err := transformer.UnmarshalUserRole(iter.Value())
+		if err != nil {
+			return fmt.Errorf("%s(%s): %w", opDeleteByID, string(iter.Key()), err)
+		}
+
+		if ts.RoleID == id {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+	}
+
+	err = iter.Error()
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBRoleRepository) AssignToUser(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := &schema.UserRole{
+		UserID: userID,
+		RoleID: id,
+	}
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opAssignToUser, err)
+	}
+
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(transformer.MarshalRoleKey(r.rolesKeyspace, id)), nil)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opAssignToUser, id, err)
+	}
+
+	if !has {
+		return fmt.Errorf("%s(%s): %w", opAssignToUser, id, database.ErrNotFound)
+	}
+
+	key := transformer.MarshalRoleKey(r.userRolesKeyspace, transformer.MarshalUserRoleID(inS))
+
+	value, err := transformer.MarshalUserRole(inS)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opAssignToUser, id, err)
+	}
+
+	err = database_leveldb.From(ctx, r.db).Put([]byte(key), value, nil)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opAssignToUser, id, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBRoleRepository) UnassignFromUser(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := transformer.MarshalRoleKey(r.userRolesKeyspace, transformer.MarshalUserRoleID(&schema.UserRole{
+		UserID: userID,
+		RoleID: id,
+	}))
+
+	err := database_leveldb.From(ctx, r.db).Delete([]byte(key), nil)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opUnassignFromUser, id, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBRoleRepository) FindAllForUser(ctx context.Context, userID string) ([]*role.Role, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	var (
+		result []*role.Role
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.userRolesKeyPrefix(userID))), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		ts, err := transformer.UnmarshalUserRole(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, string(iter.Key()), err)
+		}
+
+		t, err := r.FindByID(ctx, ts.RoleID)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				continue
+			}
+
+			return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+		}
+
+		result = append(result, t)
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	return result, nil
+}
+
+func (r *levelDBRoleRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if userID == "" {
+		return fmt.Errorf("%s: userID cannot be empty", opDeleteAllForUser)
+	}
+
+	batch := new(leveldb.Batch)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.userRolesKeyPrefix(userID))), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, userID, err)
+	}
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, userID, err)
+	}
+
+	return nil
+}
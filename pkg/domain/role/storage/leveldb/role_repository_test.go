@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/test"
+)
+
+func TestLevelDBRoleRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (role.RoleRepository, func()) {
+		return func(t *testing.T) (role.RoleRepository, func()) {
+			db, cleanup := database_leveldb.Fixture()
+
+			repo, err := leveldb.NewRoleRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
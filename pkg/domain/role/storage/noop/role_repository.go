@@ -0,0 +1,57 @@
+package noop
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/role"
+)
+
+// Compile-time proof of interface implementation.
+var _ role.RoleRepository = (*UnimplementedRoleRepository)(nil)
+
+// UnimplementedRoleRepository can be embedded to have forward compatible implementations.
+type UnimplementedRoleRepository struct{}
+
+func (*UnimplementedRoleRepository) Save(ctx context.Context, entity *role.Role) (*role.Role, error) {
+	panic("Save not implemented")
+}
+
+func (*UnimplementedRoleRepository) FindByID(ctx context.Context, id string) (*role.Role, error) {
+	panic("FindByID not implemented")
+}
+
+func (*UnimplementedRoleRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	panic("ExistsByID not implemented")
+}
+
+func (*UnimplementedRoleRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*role.Role, string, error) {
+	panic("FindAll not implemented")
+}
+
+func (*UnimplementedRoleRepository) Count(ctx context.Context) (int, error) {
+	panic("Count not implemented")
+}
+
+func (*UnimplementedRoleRepository) DeleteByID(ctx context.Context, id string) error {
+	panic("DeleteByID not implemented")
+}
+
+func (*UnimplementedRoleRepository) DeleteAll(ctx context.Context) error {
+	panic("DeleteAll not implemented")
+}
+
+func (*UnimplementedRoleRepository) AssignToUser(ctx context.Context, userID, id string) error {
+	panic("AssignToUser not implemented")
+}
+
+func (*UnimplementedRoleRepository) UnassignFromUser(ctx context.Context, userID, id string) error {
+	panic("UnassignFromUser not implemented")
+}
+
+func (*UnimplementedRoleRepository) FindAllForUser(ctx context.Context, userID string) ([]*role.Role, error) {
+	panic("FindAllForUser not implemented")
+}
+
+func (*UnimplementedRoleRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	panic("DeleteAllForUser not implemented")
+}
@@ -0,0 +1,315 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/noop"
+)
+
+const (
+	rolesTable     = "roles"
+	userRolesTable = "user_roles"
+)
+
+// sqliteRoleRepository is a repository that uses SQLite database.
+type sqliteRoleRepository struct {
+	noop.UnimplementedRoleRepository
+
+	db *database_sqlite.DB
+
+	rolesTable     string
+	userRolesTable string
+
+	validate *validator.Validate
+}
+
+// NewRoleRepository returns a new SQLite repository.
+func NewRoleRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (role.RoleRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &sqliteRoleRepository{
+		db:             db,
+		rolesTable:     database_sqlite.Table(tablePrefix, rolesTable),
+		userRolesTable: database_sqlite.Table(tablePrefix, userRolesTable),
+		validate:       validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns                 = "role/storage/sqlite."
+	opSave             = ns + "Save"
+	opFindByID         = ns + "FindByID"
+	opExistsByID       = ns + "ExistsByID"
+	opFindAll          = ns + "FindAll"
+	opCount            = ns + "Count"
+	opDeleteByID       = ns + "DeleteByID"
+	opAssignToUser     = ns + "AssignToUser"
+	opUnassignFromUser = ns + "UnassignFromUser"
+	opFindAllForUser   = ns + "FindAllForUser"
+	opDeleteAllForUser = ns + "DeleteAllForUser"
+)
+
+func (r *sqliteRoleRepository) Save(ctx context.Context, entity *role.Role) (*role.Role, error) {
+	inS := schema.RoleToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalRole(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.rolesTable+` (id, data) VALUES (?, ?)`,
+		inS.ID, string(value),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.RoleFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *sqliteRoleRepository) FindByID(ctx context.Context, id string) (*role.Role, error) {
+	var value string
+
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM `+r.rolesTable+` WHERE id = ?`, id).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	ts, err := transformer.UnmarshalRole([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.RoleFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *sqliteRoleRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+r.rolesTable+` WHERE id = ?)`,
+		id,
+	).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+// findAll returns the roles selected by the query, which selects the data.
+func (r *sqliteRoleRepository) findAll(ctx context.Context, query string, args ...interface{}) ([]*role.Role, error) {
+	var result []*role.Role
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		ts, err := transformer.UnmarshalRole([]byte(value))
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, schema.RoleFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *sqliteRoleRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*role.Role, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var nextCursor string
+
+	result, err := r.findAll(ctx,
+		`SELECT data FROM `+r.rolesTable+` WHERE id > ? ORDER BY id LIMIT ?`,
+		afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteRoleRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.rolesTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqliteRoleRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM `+r.rolesTable+` WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+
+		// remove role from all users
+		_, err = tx.ExecContext(ctx, `DELETE FROM `+r.userRolesTable+` WHERE role_id = ?`, id)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteRoleRepository) AssignToUser(ctx context.Context, userID, id string) error {
+	inS := &schema.UserRole{
+		UserID: userID,
+		RoleID: id,
+	}
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opAssignToUser, err)
+	}
+
+	err = r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		var has bool
+
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM `+r.rolesTable+` WHERE id = ?)`,
+			id,
+		).Scan(&has)
+		if err != nil {
+			return err
+		}
+
+		if !has {
+			return database.ErrNotFound
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO `+r.userRolesTable+` (user_id, role_id) VALUES (?, ?)`,
+			inS.UserID, inS.RoleID,
+		)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opAssignToUser, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteRoleRepository) UnassignFromUser(ctx context.Context, userID, id string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM `+r.userRolesTable+` WHERE user_id = ? AND role_id = ?`,
+		userID, id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opUnassignFromUser, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteRoleRepository) FindAllForUser(ctx context.Context, userID string) ([]*role.Role, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%s: userID cannot be empty", opFindAllForUser)
+	}
+
+	result, err := r.findAll(ctx,
+		`SELECT r.data FROM `+r.userRolesTable+` ur
+		JOIN `+r.rolesTable+` r ON r.id = ur.role_id
+		WHERE ur.user_id = ?
+		ORDER BY ur.role_id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindAllForUser, userID, err)
+	}
+
+	return result, nil
+}
+
+func (r *sqliteRoleRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("%s: userID cannot be empty", opDeleteAllForUser)
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.userRolesTable+` WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteAllForUser, userID, err)
+	}
+
+	return nil
+}
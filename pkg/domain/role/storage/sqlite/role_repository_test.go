@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/test"
+)
+
+func TestSQLiteRoleRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (role.RoleRepository, func()) {
+		return func(t *testing.T) (role.RoleRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewRoleRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
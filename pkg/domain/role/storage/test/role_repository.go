@@ -0,0 +1,402 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+	"github.com/zbiljic/authzy/pkg/domain/role/storage/json/schema"
+)
+
+const (
+	TestPrefix = "test-"
+)
+
+func testValidator() *validator.Validate {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+	return validate
+}
+
+func createRoles(t *testing.T, repo role.RoleRepository, count int) []*role.Role {
+	t.Helper()
+
+	var result []*role.Role
+
+	ctx := context.Background()
+
+	for i := 0; i < count; i++ {
+		entity := &role.Role{
+			ID:          fmt.Sprintf("role-%d", i),
+			Description: fmt.Sprintf("Role %d", i),
+			Permissions: []string{fmt.Sprintf("resource:%d:read", i)},
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		result = append(result, savedEntity)
+	}
+
+	return result
+}
+
+func assertRoleEqual(t *testing.T, expected, actual *role.Role) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.Equal(t, expected.Permissions, actual.Permissions)
+}
+
+func Run(t *testing.T, f func() func(t *testing.T) (role.RoleRepository, func())) {
+	t.Helper()
+
+	t.Run("init", func(t *testing.T) {
+		_, cleanup := f()(t)
+		defer cleanup()
+	})
+	t.Run("Save", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRoleRepositorySave(t, repo)
+	})
+	t.Run("FindByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRoleRepositoryFindByID(t, repo)
+	})
+	t.Run("ExistsByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRoleRepositoryExistsByID(t, repo)
+	})
+	t.Run("FindAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRoleRepositoryFindAll(t, repo)
+	})
+	t.Run("Count", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRoleRepositoryCount(t, repo)
+	})
+	t.Run("DeleteByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRoleRepositoryDeleteByID(t, repo)
+	})
+	t.Run("AssignToUser", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRoleRepositoryAssignToUser(t, repo)
+	})
+	t.Run("DeleteAllForUser", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testRoleRepositoryDeleteAllForUser(t, repo)
+	})
+}
+
+func testRoleRepositorySave(t *testing.T, repo role.RoleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	validate := testValidator()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := repo.Save(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		entity := &role.Role{}
+
+		_, err := repo.Save(ctx, entity)
+		assert.Error(t, err)
+
+		inS := schema.RoleToSchema(entity)
+		validateErr := validate.Struct(inS)
+
+		assert.Contains(t, err.Error(), validateErr.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := repo.Save(ctx, &role.Role{ID: "invalid/role"})
+		assert.Error(t, err)
+
+		_, err = repo.Save(ctx, &role.Role{ID: "role", Permissions: []string{"invalid permission"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("simple", func(t *testing.T) {
+		entity := &role.Role{
+			ID:          "admin",
+			Permissions: []string{"users:*"},
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		assert.False(t, savedEntity.CreatedAt.IsZero())
+		assert.False(t, savedEntity.UpdatedAt.IsZero())
+	})
+}
+
+func testRoleRepositoryFindByID(t *testing.T, repo role.RoleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	roles := createRoles(t, repo, 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, "non_existent_id")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		entity, err := repo.FindByID(ctx, roles[0].ID)
+		require.NoError(t, err)
+
+		assertRoleEqual(t, roles[0], entity)
+	})
+}
+
+func testRoleRepositoryExistsByID(t *testing.T, repo role.RoleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	roles := createRoles(t, repo, 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		exists, err := repo.ExistsByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+
+		assert.False(t, exists)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		exists, err := repo.ExistsByID(ctx, roles[0].ID)
+		require.NoError(t, err)
+
+		assert.True(t, exists)
+	})
+}
+
+func testRoleRepositoryFindAll(t *testing.T, repo role.RoleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("empty", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAll(ctx, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	createCount := 7
+
+	roles := createRoles(t, repo, createCount)
+
+	// assignments are not listed as roles
+	require.NoError(t, repo.AssignToUser(ctx, "user", roles[0].ID))
+
+	t.Run("ok", func(t *testing.T) {
+		results, nextCursor, err := repo.FindAll(ctx, "", 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, createCount, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		limit := 5
+
+		results, nextCursor, err := repo.FindAll(ctx, "", limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, limit, len(results))
+		assert.Equal(t, roles[limit-1].ID, nextCursor)
+
+		// next page
+		results, nextCursor, err = repo.FindAll(ctx, nextCursor, limit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, createCount-limit, len(results))
+		assert.Equal(t, "", nextCursor)
+	})
+}
+
+func testRoleRepositoryCount(t *testing.T, repo role.RoleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, count)
+
+	createCount := 3
+
+	createRoles(t, repo, createCount)
+
+	count, err = repo.Count(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, createCount, count)
+}
+
+func testRoleRepositoryDeleteByID(t *testing.T, repo role.RoleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	userID := "test"
+
+	roles := createRoles(t, repo, 2)
+
+	require.NoError(t, repo.AssignToUser(ctx, userID, roles[0].ID))
+	require.NoError(t, repo.AssignToUser(ctx, userID, roles[1].ID))
+
+	t.Run("non existent", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, roles[0].ID)
+		require.NoError(t, err)
+
+		exists, err := repo.ExistsByID(ctx, roles[0].ID)
+		require.NoError(t, err)
+
+		assert.False(t, exists)
+
+		results, err := repo.FindAllForUser(ctx, userID)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(results))
+		assertRoleEqual(t, roles[1], results[0])
+
+		// assignments are not restored with the role
+		_, err = repo.Save(ctx, roles[0])
+		require.NoError(t, err)
+
+		results, err = repo.FindAllForUser(ctx, userID)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, len(results))
+	})
+}
+
+func testRoleRepositoryAssignToUser(t *testing.T, repo role.RoleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	user1ID := "test1"
+	user2ID := "test2"
+
+	roles := createRoles(t, repo, 3)
+
+	t.Run("non existent", func(t *testing.T) {
+		err := repo.AssignToUser(ctx, user1ID, "non_existent_id")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		results, err := repo.FindAllForUser(ctx, "")
+		assert.Error(t, err)
+
+		assert.Equal(t, 0, len(results))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		require.NoError(t, repo.AssignToUser(ctx, user1ID, roles[0].ID))
+		require.NoError(t, repo.AssignToUser(ctx, user1ID, roles[1].ID))
+		require.NoError(t, repo.AssignToUser(ctx, user2ID, roles[2].ID))
+
+		// assigning twice is allowed
+		require.NoError(t, repo.AssignToUser(ctx, user1ID, roles[1].ID))
+
+		results1, err := repo.FindAllForUser(ctx, user1ID)
+		require.NoError(t, err)
+
+		require.Equal(t, 2, len(results1))
+		assertRoleEqual(t, roles[0], results1[0])
+		assertRoleEqual(t, roles[1], results1[1])
+
+		results2, err := repo.FindAllForUser(ctx, user2ID)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(results2))
+		assertRoleEqual(t, roles[2], results2[0])
+	})
+
+	t.Run("unassign", func(t *testing.T) {
+		require.NoError(t, repo.UnassignFromUser(ctx, user1ID, roles[0].ID))
+		require.NoError(t, repo.UnassignFromUser(ctx, user1ID, "non_existent_id"))
+
+		results, err := repo.FindAllForUser(ctx, user1ID)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(results))
+		assertRoleEqual(t, roles[1], results[0])
+	})
+}
+
+func testRoleRepositoryDeleteAllForUser(t *testing.T, repo role.RoleRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	user1ID := "test1"
+	user2ID := "test2"
+
+	roles := createRoles(t, repo, 2)
+
+	require.NoError(t, repo.AssignToUser(ctx, user1ID, roles[0].ID))
+	require.NoError(t, repo.AssignToUser(ctx, user1ID, roles[1].ID))
+	require.NoError(t, repo.AssignToUser(ctx, user2ID, roles[0].ID))
+
+	t.Run("empty", func(t *testing.T) {
+		err := repo.DeleteAllForUser(ctx, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.DeleteAllForUser(ctx, user1ID)
+		require.NoError(t, err)
+
+		results1, err := repo.FindAllForUser(ctx, user1ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, len(results1))
+
+		results2, err := repo.FindAllForUser(ctx, user2ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, len(results2))
+	})
+}
@@ -0,0 +1,35 @@
+package role
+
+import "context"
+
+type RoleUsecase interface {
+	// CreateRole creates new role in the system.
+	CreateRole(context.Context, *Role) (*Role, error)
+
+	// UpdateRole updates existing role.
+	UpdateRole(context.Context, *Role) (*Role, error)
+
+	// DeleteRole deletes existing role, removing it from all users.
+	DeleteRole(ctx context.Context, id string) error
+
+	// FindRoleByID retrieves a role by ID.
+	FindRoleByID(context.Context, string) (*Role, error)
+
+	// FindAllRoles retrieves roles in pages, starting after the cursor.
+	FindAllRoles(ctx context.Context, afterCursor string, limit int) ([]*Role, string, error)
+
+	// AssignRole assigns existing role to the user.
+	AssignRole(ctx context.Context, userID, id string) error
+
+	// UnassignRole removes the role from the user.
+	UnassignRole(ctx context.Context, userID, id string) error
+
+	// FindAllForUser retrieves all roles assigned to specified user ID.
+	FindAllForUser(ctx context.Context, userID string) ([]*Role, error)
+
+	// DeleteAllForUser removes all roles assigned to specified user ID.
+	DeleteAllForUser(ctx context.Context, userID string) error
+
+	// Permissions returns the sorted permissions granted by the roles.
+	Permissions(roles []*Role) []string
+}
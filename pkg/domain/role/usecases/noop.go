@@ -0,0 +1,53 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/role"
+)
+
+// Compile-time proof of interface implementation.
+var _ role.RoleUsecase = (*noopRoleUsecase)(nil)
+
+// noopRoleUsecase can be embedded to have forward compatible implementations.
+type noopRoleUsecase struct{}
+
+func (*noopRoleUsecase) CreateRole(ctx context.Context, role *role.Role) (*role.Role, error) {
+	panic("CreateRole not implemented")
+}
+
+func (*noopRoleUsecase) UpdateRole(ctx context.Context, role *role.Role) (*role.Role, error) {
+	panic("UpdateRole not implemented")
+}
+
+func (*noopRoleUsecase) DeleteRole(ctx context.Context, id string) error {
+	panic("DeleteRole not implemented")
+}
+
+func (*noopRoleUsecase) FindRoleByID(ctx context.Context, id string) (*role.Role, error) {
+	panic("FindRoleByID not implemented")
+}
+
+func (*noopRoleUsecase) FindAllRoles(ctx context.Context, afterCursor string, limit int) ([]*role.Role, string, error) {
+	panic("FindAllRoles not implemented")
+}
+
+func (*noopRoleUsecase) AssignRole(ctx context.Context, userID, id string) error {
+	panic("AssignRole not implemented")
+}
+
+func (*noopRoleUsecase) UnassignRole(ctx context.Context, userID, id string) error {
+	panic("UnassignRole not implemented")
+}
+
+func (*noopRoleUsecase) FindAllForUser(ctx context.Context, userID string) ([]*role.Role, error) {
+	panic("FindAllForUser not implemented")
+}
+
+func (*noopRoleUsecase) DeleteAllForUser(ctx context.Context, userID string) error {
+	panic("DeleteAllForUser not implemented")
+}
+
+func (*noopRoleUsecase) Permissions(roles []*role.Role) []string {
+	panic("Permissions not implemented")
+}
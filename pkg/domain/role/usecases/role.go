@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"context"
+	"sort"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/role"
+)
+
+type roleUsecase struct {
+	noopRoleUsecase
+
+	repository role.RoleRepository
+}
+
+func NewRoleUsecase(
+	repository role.RoleRepository,
+) role.RoleUsecase {
+	uc := &roleUsecase{
+		repository: repository,
+	}
+	return uc
+}
+
+func (uc *roleUsecase) CreateRole(ctx context.Context, entity *role.Role) (*role.Role, error) {
+	exists, err := uc.repository.ExistsByID(ctx, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, database.ErrAlreadyExists
+	}
+
+	return uc.repository.Save(ctx, entity)
+}
+
+func (uc *roleUsecase) UpdateRole(ctx context.Context, entity *role.Role) (*role.Role, error) {
+	exists, err := uc.repository.ExistsByID(ctx, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, database.ErrNotFound
+	}
+
+	return uc.repository.Save(ctx, entity)
+}
+
+func (uc *roleUsecase) DeleteRole(ctx context.Context, id string) error {
+	exists, err := uc.repository.ExistsByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return database.ErrNotFound
+	}
+
+	return uc.repository.DeleteByID(ctx, id)
+}
+
+func (uc *roleUsecase) FindRoleByID(ctx context.Context, id string) (*role.Role, error) {
+	return uc.repository.FindByID(ctx, id)
+}
+
+func (uc *roleUsecase) FindAllRoles(ctx context.Context, afterCursor string, limit int) ([]*role.Role, string, error) {
+	return uc.repository.FindAll(ctx, afterCursor, limit)
+}
+
+func (uc *roleUsecase) AssignRole(ctx context.Context, userID, id string) error {
+	return uc.repository.AssignToUser(ctx, userID, id)
+}
+
+func (uc *roleUsecase) UnassignRole(ctx context.Context, userID, id string) error {
+	return uc.repository.UnassignFromUser(ctx, userID, id)
+}
+
+func (uc *roleUsecase) FindAllForUser(ctx context.Context, userID string) ([]*role.Role, error) {
+	return uc.repository.FindAllForUser(ctx, userID)
+}
+
+func (uc *roleUsecase) DeleteAllForUser(ctx context.Context, userID string) error {
+	return uc.repository.DeleteAllForUser(ctx, userID)
+}
+
+func (uc *roleUsecase) Permissions(roles []*role.Role) []string {
+	set := make(map[string]struct{})
+	for _, r := range roles {
+		for _, permission := range r.Permissions {
+			set[permission] = struct{}{}
+		}
+	}
+
+	permissions := make([]string, 0, len(set))
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+
+	return permissions
+}
@@ -0,0 +1,18 @@
+package user
+
+import "context"
+
+type actorContextKey struct{}
+
+// NewActorContext returns a context holding the ID of who is making changes
+// to users, which is recorded in the user history.
+func NewActorContext(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actorID)
+}
+
+// ActorFromContext returns the ID of who is making changes to users, if
+// known.
+func ActorFromContext(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorContextKey{}).(string)
+	return actorID
+}
@@ -6,15 +6,20 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"go.uber.org/fx"
 
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
 	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
 	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
 	"github.com/zbiljic/authzy/pkg/domain/user"
+	user_bbolt "github.com/zbiljic/authzy/pkg/domain/user/storage/bbolt"
 	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
 	user_leveldb "github.com/zbiljic/authzy/pkg/domain/user/storage/leveldb"
+	user_sqlite "github.com/zbiljic/authzy/pkg/domain/user/storage/sqlite"
 )
 
 var repositoresfx = fx.Provide(
 	NewUserRepository,
+	NewUserVersionRepository,
 )
 
 type RepositoryParams struct {
@@ -24,9 +29,13 @@ type RepositoryParams struct {
 
 	JSONMutexDBConfig *database_jsonmutexdb.Config
 	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
 
 	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
 	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
 }
 
 func NewUserRepository(p RepositoryParams) (user.UserRepository, error) {
@@ -35,6 +44,10 @@ func NewUserRepository(p RepositoryParams) (user.UserRepository, error) {
 		return NewJSONMutexDBUserRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
 	case database_leveldb.Type:
 		return NewLevelDBUserRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteUserRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltUserRepository(p.BBoltConfig, p.BBolt)
 	default:
 		return nil, fmt.Errorf("invalid database type: %s", p.Type)
 	}
@@ -59,3 +72,78 @@ func NewLevelDBUserRepository(
 		config.KeyPrefix,
 	)
 }
+
+func NewSQLiteUserRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (user.UserRepository, error) {
+	return user_sqlite.NewUserRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltUserRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (user.UserRepository, error) {
+	return user_bbolt.NewUserRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
+
+func NewUserVersionRepository(p RepositoryParams) (user.UserVersionRepository, error) {
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		return NewJSONMutexDBUserVersionRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
+	case database_leveldb.Type:
+		return NewLevelDBUserVersionRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteUserVersionRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltUserVersionRepository(p.BBoltConfig, p.BBolt)
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+func NewJSONMutexDBUserVersionRepository(
+	config *database_jsonmutexdb.Config,
+	ls *database_jsonmutexdb.LoadSaver,
+) (user.UserVersionRepository, error) {
+	return user_jsonmutexdb.NewUserVersionRepository(
+		*ls,
+		config.FilenamePrefix,
+	)
+}
+
+func NewLevelDBUserVersionRepository(
+	config *database_leveldb.Config,
+	db *leveldb.DB,
+) (user.UserVersionRepository, error) {
+	return user_leveldb.NewUserVersionRepository(
+		db,
+		config.KeyPrefix,
+	)
+}
+
+func NewSQLiteUserVersionRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (user.UserVersionRepository, error) {
+	return user_sqlite.NewUserVersionRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltUserVersionRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (user.UserVersionRepository, error) {
+	return user_bbolt.NewUserVersionRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
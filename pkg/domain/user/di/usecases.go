@@ -6,6 +6,7 @@ import (
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	"github.com/zbiljic/authzy/pkg/domain/user/usecases"
 	"github.com/zbiljic/authzy/pkg/hash"
+	"github.com/zbiljic/authzy/pkg/logger"
 )
 
 var usecasesfx = fx.Provide(
@@ -13,12 +14,16 @@ var usecasesfx = fx.Provide(
 )
 
 func NewUserUsecase(
+	log logger.Logger,
 	hasher hash.Hasher,
 	repository user.UserRepository,
+	versionRepository user.UserVersionRepository,
 ) user.UserUsecase {
 	uc := usecases.NewUserUsecase(
+		log,
 		hasher,
 		repository,
+		versionRepository,
 	)
 	return uc
 }
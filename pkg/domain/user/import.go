@@ -0,0 +1,32 @@
+package user
+
+import "fmt"
+
+// ConflictPolicy decides how an imported user which already exists is
+// handled.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing user.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the existing user with the imported one.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictFail aborts the import.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+func (p ConflictPolicy) IsValid() error {
+	switch p {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+		return nil
+	}
+	return fmt.Errorf("invalid conflict policy: %s", p)
+}
+
+// ImportOptions controls how users are imported.
+type ImportOptions struct {
+	// OnConflict resolves users which already exist.
+	OnConflict ConflictPolicy
+	// DryRun resolves the conflicts without saving anything.
+	DryRun bool
+}
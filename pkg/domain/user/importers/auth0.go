@@ -0,0 +1,115 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/user/jsonl"
+	"github.com/zbiljic/authzy/pkg/hash"
+)
+
+// auth0User is a user of the Auth0 bulk user export, with the password hash
+// of the export requested from the support.
+type auth0User struct {
+	UserID        string                 `json:"user_id"`
+	Email         string                 `json:"email"`
+	EmailVerified bool                   `json:"email_verified"`
+	Username      string                 `json:"username"`
+	GivenName     string                 `json:"given_name"`
+	FamilyName    string                 `json:"family_name"`
+	Name          string                 `json:"name"`
+	Nickname      string                 `json:"nickname"`
+	Picture       string                 `json:"picture"`
+	CreatedAt     *time.Time             `json:"created_at"`
+	UpdatedAt     *time.Time             `json:"updated_at"`
+	LastLogin     *time.Time             `json:"last_login"`
+	LastIP        string                 `json:"last_ip"`
+	LoginsCount   int64                  `json:"logins_count"`
+	Blocked       bool                   `json:"blocked"`
+	AppMetadata   map[string]interface{} `json:"app_metadata"`
+	UserMetadata  map[string]interface{} `json:"user_metadata"`
+
+	PasswordHash       string `json:"passwordHash"`
+	CustomPasswordHash *struct {
+		Algorithm string `json:"algorithm"`
+		Hash      struct {
+			Value string `json:"value"`
+		} `json:"hash"`
+	} `json:"custom_password_hash"`
+}
+
+// NewAuth0Source returns the source reading the Auth0 bulk user export, in
+// JSON Lines. Users without a bcrypt password hash, such as the users of
+// social connections, are skipped.
+func NewAuth0Source(r io.Reader) jsonl.Source {
+	names := newUsernames()
+
+	return &source{
+		raw: newLinesReader(r),
+		convert: func(data []byte) (*jsonl.Record, string, error) {
+			u := &auth0User{}
+			if err := json.Unmarshal(data, u); err != nil {
+				return nil, "", err
+			}
+
+			rec, err := u.record(names)
+
+			return rec, u.UserID, err
+		},
+	}
+}
+
+func (u *auth0User) passwordHash() (string, error) {
+	value := u.PasswordHash
+
+	if u.CustomPasswordHash != nil {
+		if u.CustomPasswordHash.Algorithm != "bcrypt" {
+			return "", fmt.Errorf("%w: %s", hash.ErrUnknownHashAlgorithm, u.CustomPasswordHash.Algorithm)
+		}
+
+		value = u.CustomPasswordHash.Hash.Value
+	}
+
+	if value != "" && !hash.NewHasherBcrypt(0).Understands([]byte(value)) {
+		return "", hash.ErrUnknownHashAlgorithm
+	}
+
+	return value, nil
+}
+
+func (u *auth0User) record(names *usernames) (*jsonl.Record, error) {
+	passwordHash, err := u.passwordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := newRecord(FormatAuth0, u.UserID, u.Email, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.EmailVerified = u.EmailVerified
+	rec.Username = names.pick(u.Username, localPart(u.Email), "user"+rec.ID)
+	rec.GivenName = u.GivenName
+	rec.FamilyName = u.FamilyName
+	rec.Name = u.Name
+	rec.Nickname = u.Nickname
+	rec.Picture = u.Picture
+	rec.AppMetaData = mergeMetadata(rec.AppMetaData, u.AppMetadata)
+	rec.UserMetaData = mergeMetadata(nil, u.UserMetadata)
+	rec.LastIP = u.LastIP
+	rec.LastLoginAt = u.LastLogin
+	rec.LoginsCount = u.LoginsCount
+	rec.Blocked = u.Blocked
+
+	if u.CreatedAt != nil {
+		rec.CreatedAt = *u.CreatedAt
+	}
+	if u.UpdatedAt != nil {
+		rec.UpdatedAt = *u.UpdatedAt
+	}
+
+	return rec, nil
+}
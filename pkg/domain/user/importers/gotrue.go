@@ -0,0 +1,137 @@
+package importers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/user/jsonl"
+	"github.com/zbiljic/authzy/pkg/hash"
+)
+
+// goTrueUser is a row of the GoTrue (Supabase) auth.users table.
+type goTrueUser struct {
+	ID                string     `json:"id"`
+	Email             string     `json:"email"`
+	EncryptedPassword string     `json:"encrypted_password"`
+	EmailConfirmedAt  *time.Time `json:"email_confirmed_at"`
+	ConfirmedAt       *time.Time `json:"confirmed_at"`
+	LastSignInAt      *time.Time `json:"last_sign_in_at"`
+	RawAppMetaData    jsonObject `json:"raw_app_meta_data"`
+	RawUserMetaData   jsonObject `json:"raw_user_meta_data"`
+	BannedUntil       *time.Time `json:"banned_until"`
+	IsAnonymous       bool       `json:"is_anonymous"`
+	CreatedAt         *time.Time `json:"created_at"`
+	UpdatedAt         *time.Time `json:"updated_at"`
+	DeletedAt         *time.Time `json:"deleted_at"`
+}
+
+// jsonObject is a JSON column, dumped either as an object or as a string.
+type jsonObject map[string]interface{}
+
+func (o *jsonObject) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s == "" {
+			return nil
+		}
+		data = []byte(s)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	*o = m
+
+	return nil
+}
+
+func (o jsonObject) string(keys ...string) string {
+	for _, key := range keys {
+		if s, ok := o[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// NewGoTrueSource returns the source reading the rows of the GoTrue auth.users
+// table, in JSON Lines or as a JSON array. Deleted and anonymous users, and
+// users without a password, are skipped.
+func NewGoTrueSource(r io.Reader) (jsonl.Source, error) {
+	br := bufio.NewReader(r)
+
+	b, err := peek(br)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	raw := newLinesReader(br)
+
+	if b == '[' {
+		raw, err = newArrayReader(br)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	names := newUsernames()
+	now := time.Now()
+
+	return &source{
+		raw: raw,
+		convert: func(data []byte) (*jsonl.Record, string, error) {
+			u := &goTrueUser{}
+			if err := json.Unmarshal(data, u); err != nil {
+				return nil, "", err
+			}
+
+			rec, err := u.record(names, now)
+
+			return rec, u.ID, err
+		},
+	}, nil
+}
+
+func (u *goTrueUser) record(names *usernames, now time.Time) (*jsonl.Record, error) {
+	if u.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: deleted", jsonl.ErrSkipped)
+	}
+
+	if u.IsAnonymous {
+		return nil, fmt.Errorf("%w: anonymous", jsonl.ErrSkipped)
+	}
+
+	if u.EncryptedPassword != "" && !hash.NewHasherBcrypt(0).Understands([]byte(u.EncryptedPassword)) {
+		return nil, hash.ErrUnknownHashAlgorithm
+	}
+
+	rec, err := newRecord(FormatGoTrue, u.ID, u.Email, u.EncryptedPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := u.RawUserMetaData
+
+	rec.EmailVerified = u.EmailConfirmedAt != nil || u.ConfirmedAt != nil
+	rec.Username = names.pick(meta.string("username", "user_name", "preferred_username"), localPart(u.Email), "user"+rec.ID)
+	rec.Name = meta.string("full_name", "name")
+	rec.Picture = meta.string("avatar_url", "picture")
+	rec.AppMetaData = mergeMetadata(rec.AppMetaData, u.RawAppMetaData)
+	rec.UserMetaData = mergeMetadata(nil, meta)
+	rec.LastLoginAt = u.LastSignInAt
+	rec.Blocked = u.BannedUntil != nil && u.BannedUntil.After(now)
+
+	if u.CreatedAt != nil {
+		rec.CreatedAt = *u.CreatedAt
+	}
+	if u.UpdatedAt != nil {
+		rec.UpdatedAt = *u.UpdatedAt
+	}
+
+	return rec, nil
+}
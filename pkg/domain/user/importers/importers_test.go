@@ -0,0 +1,266 @@
+package importers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+
+	account_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/account/storage/jsonmutexdb"
+	account_usecases "github.com/zbiljic/authzy/pkg/domain/account/usecases"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/jsonl"
+	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	user_usecases "github.com/zbiljic/authzy/pkg/domain/user/usecases"
+	"github.com/zbiljic/authzy/pkg/hash"
+)
+
+type result struct {
+	records []*jsonl.Record
+	errs    []*jsonl.RecordError
+}
+
+func readAll(t *testing.T, src jsonl.Source) *result {
+	t.Helper()
+
+	res := &result{}
+
+	for {
+		rec, err := src.Read()
+		if errors.Is(err, io.EOF) {
+			return res
+		}
+
+		var recErr *jsonl.RecordError
+		if err != nil {
+			require.True(t, errors.As(err, &recErr), err)
+			res.errs = append(res.errs, recErr)
+			continue
+		}
+
+		res.records = append(res.records, rec)
+	}
+}
+
+func bcryptHash(t *testing.T, password string) string {
+	t.Helper()
+
+	h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	return string(h)
+}
+
+func TestAuth0(t *testing.T) {
+	input := strings.Join([]string{
+		fmt.Sprintf(`{"user_id":"auth0|5f7c8ec7c33c6c004bbafe82","email":"Jane.Doe+test@Example.com","email_verified":true,`+
+			`"given_name":"Jane","family_name":"Doe","name":"Jane Doe","created_at":"2020-10-06T15:37:11.577Z",`+
+			`"last_login":"2021-01-02T03:04:05.000Z","logins_count":3,"blocked":true,`+
+			`"app_metadata":{"plan":"pro"},"user_metadata":{"lang":"en"},"passwordHash":%q}`, bcryptHash(t, "secret")),
+		`{"user_id":"google-oauth2|1234","email":"social@example.com"}`,
+		`{"user_id":"auth0|md5","email":"md5@example.com","custom_password_hash":{"algorithm":"md5","hash":{"value":"x"}}}`,
+		`not json`,
+	}, "\n")
+
+	res := readAll(t, NewAuth0Source(strings.NewReader(input)))
+
+	require.Len(t, res.records, 1)
+
+	rec := res.records[0]
+	assert.Equal(t, "auth05f7c8ec7c33c6c004bbafe82", rec.ID)
+	assert.Equal(t, "auth0|5f7c8ec7c33c6c004bbafe82", rec.SourceID)
+	assert.Equal(t, "jane.doe+test@example.com", rec.Email)
+	assert.Equal(t, "Jane_Doe_test", rec.Username)
+	assert.True(t, rec.EmailVerified)
+	assert.True(t, rec.Blocked)
+	assert.Equal(t, int64(3), rec.LoginsCount)
+	assert.Equal(t, 2020, rec.CreatedAt.Year())
+	assert.Equal(t, "pro", rec.AppMetaData["plan"])
+	assert.Equal(t, map[string]interface{}{"provider": "auth0", "user_id": "auth0|5f7c8ec7c33c6c004bbafe82"}, rec.AppMetaData[ImportedFromKey])
+	assert.Equal(t, "en", rec.UserMetaData["lang"])
+	require.Len(t, rec.Accounts, 1)
+	assert.Equal(t, "jane.doe+test@example.com", rec.Accounts[0].FederatedID)
+	assert.NoError(t, hash.NewHasherBcrypt(0).Compare(context.Background(), []byte("secret"), []byte(rec.PasswordHash)))
+
+	require.Len(t, res.errs, 3)
+	assert.Equal(t, 2, res.errs[0].Line)
+	assert.Equal(t, "google-oauth2|1234", res.errs[0].ID)
+	assert.True(t, errors.Is(res.errs[0], jsonl.ErrSkipped))
+	assert.Equal(t, 3, res.errs[1].Line)
+	assert.True(t, errors.Is(res.errs[1], hash.ErrUnknownHashAlgorithm))
+	assert.Equal(t, 4, res.errs[2].Line)
+	assert.False(t, errors.Is(res.errs[2], jsonl.ErrSkipped))
+}
+
+func TestGoTrue(t *testing.T) {
+	input := fmt.Sprintf(`[
+  {"id":"6b9a5b3e-9c43-4d6c-9d0f-0d9b1c3e4f5a","email":"john@example.com","encrypted_password":%q,
+   "email_confirmed_at":"2022-01-01T10:00:00.123456+00:00","banned_until":"2999-01-01T00:00:00+00:00",
+   "raw_app_meta_data":"{\"provider\":\"email\"}","raw_user_meta_data":{"full_name":"John Smith","user_name":"johnny"},
+   "created_at":"2021-12-31T10:00:00+00:00","deleted_at":null},
+  {"id":"a","email":"deleted@example.com","encrypted_password":"","deleted_at":"2022-01-01T00:00:00+00:00"},
+  {"id":"b","email":"","is_anonymous":true},
+  {"id":"c","email":"magic@example.com","encrypted_password":""}
+]`, bcryptHash(t, "secret"))
+
+	src, err := NewGoTrueSource(strings.NewReader(input))
+	require.NoError(t, err)
+
+	res := readAll(t, src)
+
+	require.Len(t, res.records, 1)
+
+	rec := res.records[0]
+	assert.Equal(t, "6b9a5b3e9c434d6c9d0f0d9b1c3e4f5a", rec.ID)
+	assert.Equal(t, "johnny", rec.Username)
+	assert.Equal(t, "John Smith", rec.Name)
+	assert.True(t, rec.EmailVerified)
+	assert.True(t, rec.Blocked)
+	assert.Equal(t, "email", rec.AppMetaData["provider"])
+
+	require.Len(t, res.errs, 3)
+	for i, recErr := range res.errs {
+		assert.Equal(t, i+2, recErr.Line)
+		assert.True(t, errors.Is(recErr, jsonl.ErrSkipped), recErr)
+	}
+
+	// rows in JSON Lines
+	lines := `{"id":"d","email":"d@example.com","encrypted_password":"plain"}` + "\n"
+
+	src, err = NewGoTrueSource(strings.NewReader(lines))
+	require.NoError(t, err)
+
+	res = readAll(t, src)
+	require.Len(t, res.errs, 1)
+	assert.True(t, errors.Is(res.errs[0], hash.ErrUnknownHashAlgorithm))
+}
+
+func keycloakCredentialJSON(algorithm string, iterations int, params, salt, value []byte) string {
+	secret := fmt.Sprintf(`{"value":%q,"salt":%q,"additionalParameters":{}}`,
+		base64.StdEncoding.EncodeToString(value), base64.StdEncoding.EncodeToString(salt))
+	data := fmt.Sprintf(`{"hashIterations":%d,"algorithm":%q,"additionalParameters":%s}`, iterations, algorithm, params)
+
+	return fmt.Sprintf(`{"type":"password","secretData":%q,"credentialData":%q}`, secret, data)
+}
+
+func TestKeycloak(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+
+	pbkdf2Hash := pbkdf2.Key([]byte("secret"), salt, 1000, 64, sha256.New)
+	argon2Hash := argon2.IDKey([]byte("secret"), salt, 3, 1024, 1, 32)
+
+	input := fmt.Sprintf(`{
+  "realm": "demo",
+  "clients": [{"clientId": "app"}],
+  "users": [
+    {"id":"0c1e7d3a-6d2f-4a8e-9a55-3c1e4b2f9d10","createdTimestamp":1600000000000,"username":"jane","enabled":false,
+     "emailVerified":true,"firstName":"Jane","lastName":"Doe","email":"jane@example.com",
+     "attributes":{"locale":["en"]},"credentials":[%s]},
+    {"id":"u2","username":"argon","email":"argon@example.com","credentials":[%s]},
+    {"id":"u3","username":"legacy","email":"legacy@example.com",
+     "credentials":[{"type":"password","hashedSaltedValue":"aGFzaA==","salt":"c2FsdA==","hashIterations":20000,"algorithm":"pbkdf2"}]},
+    {"id":"u4","username":"service-account-app","serviceAccountClientId":"app"},
+    {"id":"u5","username":"ldap","email":"ldap@example.com","federationLink":"ldap"}
+  ]
+}`,
+		keycloakCredentialJSON("pbkdf2-sha256", 1000, []byte(`{}`), salt, pbkdf2Hash),
+		keycloakCredentialJSON("argon2", 3, []byte(`{"memory":["1024"],"parallelism":["1"],"type":["id"],"version":["1.3"]}`), salt, argon2Hash),
+	)
+
+	src, err := NewKeycloakSource(strings.NewReader(input))
+	require.NoError(t, err)
+
+	res := readAll(t, src)
+
+	require.Len(t, res.records, 2)
+
+	rec := res.records[0]
+	assert.Equal(t, "0c1e7d3a6d2f4a8e9a553c1e4b2f9d10", rec.ID)
+	assert.Equal(t, "jane", rec.Username)
+	assert.Equal(t, "Jane Doe", rec.Name)
+	assert.True(t, rec.Blocked)
+	assert.Equal(t, int64(1600000000), rec.CreatedAt.Unix())
+	assert.Equal(t, []string{"en"}, rec.UserMetaData["locale"])
+	assert.NoError(t, hash.NewHasherPBKDF2(hash.HasherPBKDF2Config{}).Compare(context.Background(), []byte("secret"), []byte(rec.PasswordHash)))
+
+	assert.NoError(t, hash.NewHasherArgon2(hash.HasherArgon2Config{}).Compare(context.Background(), []byte("secret"), []byte(res.records[1].PasswordHash)))
+
+	require.Len(t, res.errs, 3)
+	assert.Equal(t, "u3", res.errs[0].ID)
+	assert.True(t, errors.Is(res.errs[0], hash.ErrUnknownHashAlgorithm))
+	assert.Equal(t, "u4", res.errs[1].ID)
+	assert.True(t, errors.Is(res.errs[1], jsonl.ErrSkipped))
+	assert.Equal(t, "u5", res.errs[2].ID)
+	assert.True(t, errors.Is(res.errs[2], jsonl.ErrSkipped))
+}
+
+func TestUsernames(t *testing.T) {
+	names := newUsernames()
+
+	assert.Equal(t, "jane", names.pick("jane"))
+	assert.Equal(t, "Jane_2", names.pick("Jane"))
+	assert.Equal(t, "jane_3", names.pick("", "jane"))
+	assert.Equal(t, "j_doe", names.pick("j", ".j.doe"))
+	assert.Equal(t, "user1", names.pick("", "user1"))
+	assert.Len(t, names.pick(strings.Repeat("a", 40)), maxUsernameLength)
+	assert.Equal(t, strings.Repeat("a", 30)+"_2", names.pick(strings.Repeat("a", 40)))
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+
+	userRepository, err := user_jsonmutexdb.NewUserRepository(nil, "")
+	require.NoError(t, err)
+
+	versionRepository, err := user_jsonmutexdb.NewUserVersionRepository(nil, "")
+	require.NoError(t, err)
+
+	accountRepository, err := account_jsonmutexdb.NewAccountRepository(nil, "")
+	require.NoError(t, err)
+
+	users := user_usecases.NewUserUsecase(nil, nil, userRepository, versionRepository)
+	accounts := account_usecases.NewAccountUsecase(accountRepository)
+
+	input := strings.Join([]string{
+		fmt.Sprintf(`{"user_id":"auth0|1","email":"one@example.com","passwordHash":%q}`, bcryptHash(t, "secret")),
+		`{"user_id":"auth0|2","email":"two@example.com","passwordHash":"plain"}`,
+		`{"user_id":"github|3","email":"three@example.com"}`,
+		fmt.Sprintf(`{"user_id":"auth0|4","email":"four@example.com","passwordHash":%q}`, bcryptHash(t, "secret")),
+	}, "\n")
+
+	im := &jsonl.Importer{
+		Users:     users,
+		Accounts:  accounts,
+		Options:   user.ImportOptions{OnConflict: user.ConflictFail},
+		KeepGoing: true,
+	}
+
+	stats, err := im.ImportFrom(ctx, NewAuth0Source(strings.NewReader(input)), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, stats.Read)
+	assert.Equal(t, 2, stats.Imported)
+	require.Len(t, stats.Skipped, 1)
+	assert.Equal(t, "github|3", stats.Skipped[0].ID)
+	require.Len(t, stats.Failed, 1)
+	assert.Equal(t, "auth0|2", stats.Failed[0].ID)
+
+	u, err := users.FindUserByEmail(ctx, "four@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "auth04", u.ID)
+	assert.Equal(t, "four", u.Username)
+
+	found, err := accounts.FindAllForUser(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+}
@@ -0,0 +1,201 @@
+package importers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/user/jsonl"
+	"github.com/zbiljic/authzy/pkg/hash"
+)
+
+// keycloakUser is a user of the Keycloak realm export.
+type keycloakUser struct {
+	ID                     string                `json:"id"`
+	CreatedTimestamp       int64                 `json:"createdTimestamp"`
+	Username               string                `json:"username"`
+	Enabled                *bool                 `json:"enabled"`
+	EmailVerified          bool                  `json:"emailVerified"`
+	FirstName              string                `json:"firstName"`
+	LastName               string                `json:"lastName"`
+	Email                  string                `json:"email"`
+	Attributes             map[string][]string   `json:"attributes"`
+	ServiceAccountClientID string                `json:"serviceAccountClientId"`
+	Credentials            []*keycloakCredential `json:"credentials"`
+}
+
+// keycloakCredential is a credential of the user. Since Keycloak 7 the hash
+// is kept in the secret and credential data, encoded as JSON, before in the
+// legacy fields.
+type keycloakCredential struct {
+	Type           string `json:"type"`
+	SecretData     string `json:"secretData"`
+	CredentialData string `json:"credentialData"`
+
+	HashedSaltedValue string `json:"hashedSaltedValue"`
+	Salt              string `json:"salt"`
+	HashIterations    int    `json:"hashIterations"`
+	Algorithm         string `json:"algorithm"`
+}
+
+type keycloakSecretData struct {
+	Value string `json:"value"`
+	Salt  string `json:"salt"`
+}
+
+type keycloakCredentialData struct {
+	HashIterations       int                 `json:"hashIterations"`
+	Algorithm            string              `json:"algorithm"`
+	AdditionalParameters map[string][]string `json:"additionalParameters"`
+}
+
+// NewKeycloakSource returns the source reading the users of the Keycloak
+// realm export, or of the users file of the export. Service accounts and
+// users without a password, such as the federated users, are skipped.
+func NewKeycloakSource(r io.Reader) (jsonl.Source, error) {
+	raw, err := newArrayReader(r, "users")
+	if err != nil {
+		return nil, err
+	}
+
+	names := newUsernames()
+
+	return &source{
+		raw: raw,
+		convert: func(data []byte) (*jsonl.Record, string, error) {
+			u := &keycloakUser{}
+			if err := json.Unmarshal(data, u); err != nil {
+				return nil, "", err
+			}
+
+			rec, err := u.record(names)
+
+			return rec, u.ID, err
+		},
+	}, nil
+}
+
+func (u *keycloakUser) record(names *usernames) (*jsonl.Record, error) {
+	if u.ServiceAccountClientID != "" {
+		return nil, fmt.Errorf("%w: service account", jsonl.ErrSkipped)
+	}
+
+	var passwordHash string
+
+	for _, c := range u.Credentials {
+		if c.Type != "password" {
+			continue
+		}
+
+		var err error
+
+		passwordHash, err = c.passwordHash()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rec, err := newRecord(FormatKeycloak, u.ID, u.Email, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.EmailVerified = u.EmailVerified
+	rec.Username = names.pick(u.Username, localPart(u.Email), "user"+rec.ID)
+	rec.GivenName = u.FirstName
+	rec.FamilyName = u.LastName
+	rec.Blocked = u.Enabled != nil && !*u.Enabled
+
+	if u.FirstName != "" && u.LastName != "" {
+		rec.Name = u.FirstName + " " + u.LastName
+	}
+
+	if len(u.Attributes) > 0 {
+		attributes := make(map[string]interface{}, len(u.Attributes))
+		for k, v := range u.Attributes {
+			attributes[k] = v
+		}
+		rec.UserMetaData = mergeMetadata(nil, attributes)
+	}
+
+	if u.CreatedTimestamp > 0 {
+		rec.CreatedAt = time.Unix(0, u.CreatedTimestamp*int64(time.Millisecond)).UTC()
+	}
+
+	return rec, nil
+}
+
+// passwordHash returns the hash in the format of the hasher verifying it.
+func (c *keycloakCredential) passwordHash() (string, error) {
+	secret := keycloakSecretData{Value: c.HashedSaltedValue, Salt: c.Salt}
+	data := keycloakCredentialData{HashIterations: c.HashIterations, Algorithm: c.Algorithm}
+
+	if c.SecretData != "" {
+		if err := json.Unmarshal([]byte(c.SecretData), &secret); err != nil {
+			return "", fmt.Errorf("secret data: %w", err)
+		}
+	}
+
+	if c.CredentialData != "" {
+		if err := json.Unmarshal([]byte(c.CredentialData), &data); err != nil {
+			return "", fmt.Errorf("credential data: %w", err)
+		}
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(secret.Salt)
+	if err != nil {
+		return "", fmt.Errorf("salt: %w", err)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(secret.Value)
+	if err != nil {
+		return "", fmt.Errorf("hash: %w", err)
+	}
+
+	switch data.Algorithm {
+	case "pbkdf2-sha256", "pbkdf2-sha512":
+		return fmt.Sprintf("$%s$i=%d$%s$%s",
+			data.Algorithm, data.HashIterations,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(value),
+		), nil
+	case "argon2":
+		return keycloakArgon2Hash(data, salt, value)
+	}
+
+	return "", fmt.Errorf("%w: %s", hash.ErrUnknownHashAlgorithm, data.Algorithm)
+}
+
+// keycloakArgon2Hash returns the argon2id hash. Other argon2 types and
+// versions are not supported.
+func keycloakArgon2Hash(data keycloakCredentialData, salt, value []byte) (string, error) {
+	param := func(name, def string) string {
+		if v := data.AdditionalParameters[name]; len(v) > 0 {
+			return v[0]
+		}
+		return def
+	}
+
+	if param("type", "id") != "id" || param("version", "1.3") != "1.3" {
+		return "", fmt.Errorf("%w: argon2%s v%s", hash.ErrUnknownHashAlgorithm, param("type", "id"), param("version", "1.3"))
+	}
+
+	memory, err := strconv.ParseUint(param("memory", "7168"), 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("memory: %w", err)
+	}
+
+	parallelism, err := strconv.ParseUint(param("parallelism", "1"), 10, 8)
+	if err != nil {
+		return "", fmt.Errorf("parallelism: %w", err)
+	}
+
+	return fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$%s$%s",
+		memory, data.HashIterations, parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(value),
+	), nil
+}
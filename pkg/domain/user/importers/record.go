@@ -0,0 +1,150 @@
+package importers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/user/jsonl"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/jsonmap"
+)
+
+// ImportedFromKey is the key of the app metadata recording where the user was
+// imported from.
+const ImportedFromKey = "imported_from"
+
+const maxUsernameLength = 32
+
+var (
+	errMissingEmail    = errors.New("missing email")
+	errMissingPassword = fmt.Errorf("%w: no password", jsonl.ErrSkipped)
+)
+
+// newRecord returns the record of the user imported from the provider, with
+// the password account when the user has a password hash.
+func newRecord(provider, id, email, passwordHash string) (*jsonl.Record, error) {
+	if email == "" {
+		return nil, errMissingEmail
+	}
+
+	if passwordHash == "" {
+		return nil, errMissingPassword
+	}
+
+	rec := &jsonl.Record{}
+
+	rec.ID = sanitizeID(id)
+	rec.Email = strings.ToLower(email)
+	rec.PasswordHash = passwordHash
+	rec.AppMetaData = jsonmap.JSONMap{
+		ImportedFromKey: map[string]interface{}{
+			"provider": provider,
+			"user_id":  id,
+		},
+	}
+	rec.Accounts = []*jsonl.Account{
+		{
+			Provider:    string(account.ProviderTypePassword),
+			FederatedID: rec.Email,
+		},
+	}
+
+	return rec, nil
+}
+
+// sanitizeID returns the ID without the characters IDs can not contain.
+func sanitizeID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, id)
+}
+
+// mergeMetadata adds the values to the metadata, keeping the existing ones.
+func mergeMetadata(m jsonmap.JSONMap, values map[string]interface{}) jsonmap.JSONMap {
+	if len(values) == 0 {
+		return m
+	}
+
+	if m == nil {
+		m = jsonmap.JSONMap{}
+	}
+
+	for k, v := range values {
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+
+	return m
+}
+
+// usernames picks unique usernames within an import.
+type usernames struct {
+	taken map[string]bool
+}
+
+func newUsernames() *usernames {
+	return &usernames{taken: make(map[string]bool)}
+}
+
+// pick returns the first candidate which can be made into a valid username,
+// with a suffix when it is already taken. The candidates are usually the
+// username in the export, the local part of the email and the fallback.
+func (u *usernames) pick(candidates ...string) string {
+	for _, candidate := range candidates {
+		base := sanitizeUsername(candidate)
+		if !schema.IsUsername(base) {
+			continue
+		}
+
+		username := base
+		for i := 2; u.taken[strings.ToLower(username)]; i++ {
+			suffix := fmt.Sprintf("_%d", i)
+			if len(base)+len(suffix) > maxUsernameLength {
+				base = base[:maxUsernameLength-len(suffix)]
+			}
+			username = base + suffix
+		}
+
+		u.taken[strings.ToLower(username)] = true
+
+		return username
+	}
+
+	return ""
+}
+
+// sanitizeUsername replaces the characters usernames can not contain.
+func sanitizeUsername(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			return r
+		case r == '_' || r == '-' || r == '.' || r == '+' || r == ' ':
+			return '_'
+		}
+		return -1
+	}, s)
+
+	s = strings.TrimLeft(s, "_-")
+
+	if len(s) > maxUsernameLength {
+		s = s[:maxUsernameLength]
+	}
+
+	return s
+}
+
+// localPart returns the part of the email before the @.
+func localPart(email string) string {
+	if i := strings.LastIndexByte(email, '@'); i >= 0 {
+		return email[:i]
+	}
+	return email
+}
@@ -0,0 +1,203 @@
+// Package importers reads the user exports of other identity providers as
+// records to import, keeping their password hashes where they are supported.
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/zbiljic/authzy/pkg/domain/user/jsonl"
+)
+
+// Formats of the user exports.
+const (
+	FormatJSONL    = "jsonl"
+	FormatAuth0    = "auth0"
+	FormatGoTrue   = "gotrue"
+	FormatKeycloak = "keycloak"
+)
+
+// Formats are all the formats supported.
+var Formats = []string{FormatJSONL, FormatAuth0, FormatGoTrue, FormatKeycloak}
+
+// NewSource returns the source of records reading the export in the format.
+func NewSource(format string, r io.Reader) (jsonl.Source, error) {
+	switch format {
+	case FormatJSONL:
+		return jsonl.NewReader(r), nil
+	case FormatAuth0:
+		return NewAuth0Source(r), nil
+	case FormatGoTrue:
+		return NewGoTrueSource(r)
+	case FormatKeycloak:
+		return NewKeycloakSource(r)
+	}
+
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+// convertFunc converts a single exported user into a record. It returns the
+// ID of the user in the export, to report the record by.
+type convertFunc func(data []byte) (rec *jsonl.Record, id string, err error)
+
+// source converts the exported users read one by one.
+type source struct {
+	raw     *rawReader
+	convert convertFunc
+}
+
+func (s *source) Read() (*jsonl.Record, error) {
+	data, err := s.raw.next()
+	if err != nil {
+		return nil, err
+	}
+
+	rec, id, err := s.convert(data)
+	if err != nil {
+		return nil, &jsonl.RecordError{Line: s.raw.pos, ID: id, Err: err}
+	}
+
+	rec.SourceID = id
+
+	return rec, nil
+}
+
+func (s *source) Line() int {
+	return s.raw.pos
+}
+
+// rawReader reads JSON objects either one per line, or as elements of an
+// array. The position is the line, or the index of the element, counted
+// from one.
+type rawReader struct {
+	lines *bufio.Reader
+	dec   *json.Decoder
+	pos   int
+}
+
+func newLinesReader(r io.Reader) *rawReader {
+	return &rawReader{lines: bufio.NewReader(r)}
+}
+
+// newArrayReader returns the reader of the array elements. The path are the
+// keys of the objects leading to the array, none if the array is the top
+// level value.
+func newArrayReader(r io.Reader, path ...string) (*rawReader, error) {
+	dec := json.NewDecoder(r)
+
+	for _, key := range path {
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, err
+		}
+
+		if err := skipToKey(dec, key); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, err
+	}
+
+	return &rawReader{dec: dec}, nil
+}
+
+// next returns the next object. It returns io.EOF when there are no more
+// objects, and a *jsonl.RecordError for a malformed line.
+func (r *rawReader) next() ([]byte, error) {
+	if r.dec != nil {
+		if !r.dec.More() {
+			return nil, io.EOF
+		}
+
+		// a malformed element breaks the array, the error is not recoverable
+		var raw json.RawMessage
+		if err := r.dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("element %d: %w", r.pos+1, err)
+		}
+
+		r.pos++
+
+		return raw, nil
+	}
+
+	for {
+		data, err := r.lines.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		if len(data) == 0 && errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		r.pos++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		if !json.Valid(data) {
+			return nil, &jsonl.RecordError{Line: r.pos, Err: errors.New("invalid JSON")}
+		}
+
+		return data, nil
+	}
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok != delim {
+		return fmt.Errorf("expected %s, found %v", delim, tok)
+	}
+
+	return nil
+}
+
+// skipToKey skips the values of the object up to the key.
+func skipToKey(dec *json.Decoder, key string) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if tok == key {
+			return nil
+		}
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("key not found: %s", key)
+}
+
+// peek returns the first byte of the input which is not a space, leaving the
+// input unchanged.
+func peek(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b, r.UnreadByte()
+	}
+}
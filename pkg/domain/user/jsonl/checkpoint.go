@@ -0,0 +1,64 @@
+package jsonl
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is the progress of an import or an export, from which it is
+// resumed.
+type Checkpoint struct {
+	// Line is the last imported line.
+	Line int `json:"line,omitempty"`
+	// Cursor is the last exported user.
+	Cursor string `json:"cursor,omitempty"`
+	// Offset is the size of the export up to the cursor.
+	Offset int64 `json:"offset,omitempty"`
+}
+
+// LoadCheckpoint reads the checkpoint from the file. A missing file is an
+// empty checkpoint.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Checkpoint{}, nil
+		}
+
+		return nil, err
+	}
+
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+// Save replaces the file with the checkpoint.
+func (c *Checkpoint) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,96 @@
+package jsonl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+)
+
+// DefaultPageSize is the number of users exported at once.
+const DefaultPageSize = 100
+
+// Exporter writes all users with their accounts.
+type Exporter struct {
+	Users    user.UserUsecase
+	Accounts account.AccountUsecase
+
+	// PageSize is the number of users read at once.
+	PageSize int
+
+	// SaveCheckpoint, if set, is called after every page written.
+	SaveCheckpoint func(*Checkpoint) error
+}
+
+// Export writes the users after the checkpoint, and returns their number.
+// The output must contain exactly the checkpoint offset bytes of the
+// previous export.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, from *Checkpoint) (int, error) {
+	pageSize := e.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	cp := Checkpoint{}
+	if from != nil {
+		cp = *from
+	}
+
+	cw := &countingWriter{w: w, n: cp.Offset}
+	enc := json.NewEncoder(cw)
+
+	var count int
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+
+		users, nextCursor, err := e.Users.FindAllUsers(ctx, cp.Cursor, pageSize)
+		if err != nil {
+			return count, fmt.Errorf("find users: %w", err)
+		}
+
+		for _, u := range users {
+			accounts, err := e.Accounts.FindAllForUser(ctx, u.ID)
+			if err != nil {
+				return count, fmt.Errorf("find accounts of user %s: %w", u.ID, err)
+			}
+
+			if err := enc.Encode(NewRecord(u, accounts)); err != nil {
+				return count, err
+			}
+
+			count++
+		}
+
+		if len(users) > 0 {
+			cp.Cursor = users[len(users)-1].ID
+			cp.Offset = cw.n
+
+			if e.SaveCheckpoint != nil {
+				if err := e.SaveCheckpoint(&cp); err != nil {
+					return count, fmt.Errorf("save checkpoint: %w", err)
+				}
+			}
+		}
+
+		if nextCursor == "" {
+			return count, nil
+		}
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,328 @@
+package jsonl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/ulid"
+)
+
+// DefaultBatchSize is the number of users imported at once.
+const DefaultBatchSize = 500
+
+// Importer reads users with their accounts, and saves them in batches.
+type Importer struct {
+	Users    user.UserUsecase
+	Accounts account.AccountUsecase
+
+	// Transactor saves every batch in a transaction, so that users are not
+	// imported without their accounts. Batches are saved directly if nil.
+	Transactor database.Transactor
+
+	Options user.ImportOptions
+
+	// KeepGoing collects the failed records in the stats, instead of stopping
+	// at the first one.
+	KeepGoing bool
+
+	// BatchSize is the number of users saved at once.
+	BatchSize int
+
+	// SaveCheckpoint, if set, is called after every batch saved.
+	SaveCheckpoint func(*Checkpoint) error
+}
+
+// Stats are the results of an import, the reconciliation report.
+type Stats struct {
+	Read     int
+	Imported int
+
+	// Skipped are the records left out on purpose, by the source or by the
+	// conflict policy.
+	Skipped []*RecordError
+
+	// Failed are the invalid or conflicting records, collected in dry run or
+	// when the import keeps going.
+	Failed []*RecordError
+}
+
+type pending struct {
+	line   int
+	record *Record
+}
+
+// Import reads the records of JSON Lines after the checkpoint line and imports
+// them, see ImportFrom.
+func (im *Importer) Import(ctx context.Context, r io.Reader, from *Checkpoint) (*Stats, error) {
+	return im.ImportFrom(ctx, NewReader(r), from)
+}
+
+// ImportFrom reads the records after the checkpoint line and imports them. The
+// first failed record stops the import, while in dry run, or when the import
+// keeps going, all records are processed, and the failed ones are reported in
+// the stats.
+func (im *Importer) ImportFrom(ctx context.Context, src Source, from *Checkpoint) (*Stats, error) {
+	if err := im.Options.OnConflict.IsValid(); err != nil {
+		return nil, err
+	}
+
+	batchSize := im.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	cp := Checkpoint{}
+	if from != nil {
+		cp = *from
+	}
+
+	stats := &Stats{}
+	validate := newRecordValidator()
+
+	var batch []*pending
+
+	for {
+		rec, err := src.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var recErr *RecordError
+		if err != nil && !errors.As(err, &recErr) {
+			return stats, err
+		}
+
+		if src.Line() <= cp.Line {
+			continue
+		}
+
+		stats.Read++
+
+		if err == nil {
+			err = validate.check(src.Line(), rec)
+		}
+
+		if err != nil {
+			if !errors.As(err, &recErr) {
+				return stats, err
+			}
+
+			if errors.Is(err, ErrSkipped) {
+				stats.Skipped = append(stats.Skipped, recErr)
+				continue
+			}
+
+			if im.collectFailed() {
+				stats.Failed = append(stats.Failed, recErr)
+				continue
+			}
+
+			return stats, err
+		}
+
+		batch = append(batch, &pending{line: src.Line(), record: rec})
+
+		if len(batch) >= batchSize {
+			if err := im.flush(ctx, stats, &cp, batch); err != nil {
+				return stats, err
+			}
+
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := im.flush(ctx, stats, &cp, batch); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+// collectFailed reports whether failed records are collected instead of
+// stopping the import.
+func (im *Importer) collectFailed() bool {
+	return im.Options.DryRun || im.KeepGoing
+}
+
+func (im *Importer) flush(ctx context.Context, stats *Stats, cp *Checkpoint, batch []*pending) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !im.Options.DryRun {
+		err := im.save(ctx, stats, batch)
+		if err == nil {
+			return im.checkpoint(cp, batch)
+		}
+
+		if !im.KeepGoing || !errors.Is(err, database.ErrAlreadyExists) {
+			return err
+		}
+	}
+
+	// conflicts are resolved one by one, to be reported by line
+	for _, p := range batch {
+		err := im.save(ctx, stats, []*pending{p})
+		if errors.Is(err, database.ErrAlreadyExists) {
+			stats.Failed = append(stats.Failed, &RecordError{
+				Line: p.line,
+				ID:   p.record.sourceID(),
+				Err:  database.ErrAlreadyExists,
+			})
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if im.Options.DryRun {
+		return nil
+	}
+
+	return im.checkpoint(cp, batch)
+}
+
+func (im *Importer) checkpoint(cp *Checkpoint, batch []*pending) error {
+	cp.Line = batch[len(batch)-1].line
+
+	if im.SaveCheckpoint != nil {
+		if err := im.SaveCheckpoint(cp); err != nil {
+			return fmt.Errorf("save checkpoint: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// save imports the users of the batch with their accounts, in a single
+// transaction. The stats are updated once it is committed.
+func (im *Importer) save(ctx context.Context, stats *Stats, batch []*pending) error {
+	transactor := im.Transactor
+	if transactor == nil {
+		transactor = database.NopTransactor()
+	}
+
+	saved := &Stats{}
+
+	err := transactor.WithTx(ctx, func(ctx context.Context) error {
+		return im.saveBatch(ctx, saved, batch)
+	})
+	if err != nil {
+		return err
+	}
+
+	stats.Imported += saved.Imported
+	stats.Skipped = append(stats.Skipped, saved.Skipped...)
+
+	return nil
+}
+
+func (im *Importer) saveBatch(ctx context.Context, stats *Stats, batch []*pending) error {
+	users := make([]*user.User, 0, len(batch))
+	for _, p := range batch {
+		users = append(users, p.record.ToUser())
+	}
+
+	imported, err := im.Users.ImportUsers(ctx, users, im.Options)
+	if err != nil {
+		return fmt.Errorf("lines %d-%d: %w", batch[0].line, batch[len(batch)-1].line, err)
+	}
+
+	var accounts []*account.Account
+
+	for i, u := range imported {
+		if u == nil {
+			stats.Skipped = append(stats.Skipped, &RecordError{
+				Line: batch[i].line,
+				ID:   batch[i].record.sourceID(),
+				Err:  fmt.Errorf("%w: %v", ErrSkipped, database.ErrAlreadyExists),
+			})
+			continue
+		}
+
+		stats.Imported++
+
+		if im.Options.DryRun {
+			continue
+		}
+
+		if im.Options.OnConflict == user.ConflictOverwrite {
+			// accounts of the replaced user are replaced as well
+			if err := im.Accounts.DeleteAllForUser(ctx, u.ID); err != nil {
+				return fmt.Errorf("line %d: %w", batch[i].line, err)
+			}
+		}
+
+		accounts = append(accounts, batch[i].record.ToAccounts(u.ID)...)
+	}
+
+	if len(accounts) > 0 {
+		if _, err := im.Accounts.ImportAccounts(ctx, accounts); err != nil {
+			return fmt.Errorf("lines %d-%d: %w", batch[0].line, batch[len(batch)-1].line, err)
+		}
+	}
+
+	return nil
+}
+
+// recordValidator validates records as they would be stored, and detects
+// users repeated in the input.
+type recordValidator struct {
+	validate *validator.Validate
+	seen     map[string]int
+}
+
+func newRecordValidator() *recordValidator {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	return &recordValidator{
+		validate: validate,
+		seen:     make(map[string]int),
+	}
+}
+
+func (v *recordValidator) check(line int, rec *Record) error {
+	// normalize, as users are when saved
+	rec.Email = strings.ToLower(rec.Email)
+	rec.NormalizedUsername = strings.ToLower(rec.Username)
+
+	if rec.ID == "" {
+		rec.ID = ulid.ULID().String()
+	}
+
+	if err := v.validate.Struct(rec); err != nil {
+		return &RecordError{Line: line, ID: rec.sourceID(), Err: err}
+	}
+
+	for _, acc := range rec.Accounts {
+		if err := account.ProviderType(acc.Provider).IsValid(); err != nil {
+			return &RecordError{Line: line, ID: rec.sourceID(), Err: fmt.Errorf("%w: %s", err, acc.Provider)}
+		}
+	}
+
+	for _, key := range []string{"id:" + rec.ID, "identifier:" + rec.Email, "identifier:" + rec.NormalizedUsername} {
+		if prev, ok := v.seen[key]; ok {
+			return &RecordError{Line: line, ID: rec.sourceID(), Err: fmt.Errorf("user repeats line %d", prev)}
+		}
+	}
+
+	v.seen["id:"+rec.ID] = line
+	v.seen["identifier:"+rec.Email] = line
+	v.seen["identifier:"+rec.NormalizedUsername] = line
+
+	return nil
+}
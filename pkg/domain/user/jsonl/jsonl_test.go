@@ -0,0 +1,373 @@
+package jsonl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	account_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/account/storage/jsonmutexdb"
+	account_usecases "github.com/zbiljic/authzy/pkg/domain/account/usecases"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	user_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	user_usecases "github.com/zbiljic/authzy/pkg/domain/user/usecases"
+)
+
+type store struct {
+	users      user.UserUsecase
+	accounts   account.AccountUsecase
+	transactor database.Transactor
+}
+
+func newStore(t *testing.T) *store {
+	t.Helper()
+
+	ls, err := jsonmutexdb.NewLoadSaver("")
+	require.NoError(t, err)
+
+	userRepository, err := user_jsonmutexdb.NewUserRepository(ls, "")
+	require.NoError(t, err)
+
+	versionRepository, err := user_jsonmutexdb.NewUserVersionRepository(ls, "")
+	require.NoError(t, err)
+
+	accountRepository, err := account_jsonmutexdb.NewAccountRepository(ls, "")
+	require.NoError(t, err)
+
+	return &store{
+		users:      user_usecases.NewUserUsecase(nil, nil, userRepository, versionRepository),
+		accounts:   account_usecases.NewAccountUsecase(accountRepository),
+		transactor: jsonmutexdb.NewTransactor(ls),
+	}
+}
+
+func (s *store) importer(opts user.ImportOptions) *Importer {
+	return &Importer{
+		Users:      s.users,
+		Accounts:   s.accounts,
+		Transactor: s.transactor,
+		Options:    opts,
+		BatchSize:  2,
+	}
+}
+
+func record(i int) string {
+	return fmt.Sprintf(
+		`{"email":"User_%[1]d@Example.com","username":"user_%[1]d","password_hash":"hash_%[1]d","email_verified":true,`+
+			`"app_metadata":{"plan":"pro"},"accounts":[{"provider":"password","federated_id":"user_%[1]d@example.com"}]}`,
+		i,
+	)
+}
+
+func records(from, to int) string {
+	var lines []string
+	for i := from; i < to; i++ {
+		lines = append(lines, record(i))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestImportExport(t *testing.T) {
+	ctx := context.Background()
+
+	source := newStore(t)
+
+	stats, err := source.importer(user.ImportOptions{OnConflict: user.ConflictFail}).
+		Import(ctx, strings.NewReader(records(0, 5)), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Read)
+	assert.Equal(t, 5, stats.Imported)
+
+	u, err := source.users.FindUserByEmail(ctx, "user_3@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "user_3", u.Username)
+	assert.Equal(t, "hash_3", u.PasswordHash)
+	assert.True(t, u.EmailVerified)
+	assert.Equal(t, "pro", u.AppMetaData["plan"])
+
+	accounts, err := source.accounts.FindAllForUser(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, "user_3@example.com", accounts[0].FederatedID)
+
+	var out bytes.Buffer
+
+	count, err := (&Exporter{Users: source.users, Accounts: source.accounts, PageSize: 2}).Export(ctx, &out, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, 5, strings.Count(out.String(), "\n"))
+
+	target := newStore(t)
+
+	stats, err = target.importer(user.ImportOptions{OnConflict: user.ConflictFail}).Import(ctx, &out, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Imported)
+
+	imported, err := target.users.FindUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.Email, imported.Email)
+	assert.Equal(t, u.PasswordHash, imported.PasswordHash)
+	assert.Equal(t, u.CreatedAt.Unix(), imported.CreatedAt.Unix())
+
+	accounts, err = target.accounts.FindAllForUser(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 1)
+}
+
+func TestImportConflicts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fail", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.importer(user.ImportOptions{OnConflict: user.ConflictFail}).
+			Import(ctx, strings.NewReader(records(0, 2)), nil)
+		require.NoError(t, err)
+
+		_, err = s.importer(user.ImportOptions{OnConflict: user.ConflictFail}).
+			Import(ctx, strings.NewReader(records(1, 3)), nil)
+		assert.True(t, errors.Is(err, database.ErrAlreadyExists))
+
+		_, err = s.users.FindUserByEmail(ctx, "user_2@example.com")
+		assert.True(t, errors.Is(err, database.ErrNotFound), "nothing is saved from the failed batch")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.importer(user.ImportOptions{OnConflict: user.ConflictFail}).
+			Import(ctx, strings.NewReader(records(0, 2)), nil)
+		require.NoError(t, err)
+
+		stats, err := s.importer(user.ImportOptions{OnConflict: user.ConflictSkip}).
+			Import(ctx, strings.NewReader(records(1, 3)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.Imported)
+		assert.Len(t, stats.Skipped, 1)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.importer(user.ImportOptions{OnConflict: user.ConflictFail}).
+			Import(ctx, strings.NewReader(records(0, 1)), nil)
+		require.NoError(t, err)
+
+		existing, err := s.users.FindUserByEmail(ctx, "user_0@example.com")
+		require.NoError(t, err)
+
+		line := `{"email":"user_0@example.com","username":"user_0","password_hash":"new","accounts":[{"provider":"password","federated_id":"new"}]}`
+
+		stats, err := s.importer(user.ImportOptions{OnConflict: user.ConflictOverwrite}).
+			Import(ctx, strings.NewReader(line), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.Imported)
+
+		u, err := s.users.FindUserByID(ctx, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "new", u.PasswordHash)
+
+		accounts, err := s.accounts.FindAllForUser(ctx, existing.ID)
+		require.NoError(t, err)
+		require.Len(t, accounts, 1)
+		assert.Equal(t, "new", accounts[0].FederatedID)
+	})
+}
+
+// failingAccounts fails to import accounts.
+type failingAccounts struct {
+	account.AccountUsecase
+}
+
+var errImportAccounts = errors.New("import accounts")
+
+func (failingAccounts) ImportAccounts(context.Context, []*account.Account) ([]*account.Account, error) {
+	return nil, errImportAccounts
+}
+
+func TestImportAccountsFailed(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStore(t)
+
+	im := s.importer(user.ImportOptions{OnConflict: user.ConflictFail})
+	im.Accounts = failingAccounts{s.accounts}
+
+	stats, err := im.Import(ctx, strings.NewReader(records(0, 2)), nil)
+	assert.True(t, errors.Is(err, errImportAccounts))
+	assert.Equal(t, 0, stats.Imported)
+
+	_, err = s.users.FindUserByEmail(ctx, "user_0@example.com")
+	assert.True(t, errors.Is(err, database.ErrNotFound), "users are not saved without their accounts")
+}
+
+func TestImportDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStore(t)
+
+	_, err := s.importer(user.ImportOptions{OnConflict: user.ConflictFail}).
+		Import(ctx, strings.NewReader(records(0, 1)), nil)
+	require.NoError(t, err)
+
+	input := strings.Join([]string{
+		record(0), // conflicts with the existing user
+		record(1),
+		`{"email":"invalid","username":"x"}`,
+		`not json`,
+		record(1), // repeated
+		"",
+		record(2),
+	}, "\n")
+
+	stats, err := s.importer(user.ImportOptions{OnConflict: user.ConflictFail, DryRun: true}).
+		Import(ctx, strings.NewReader(input), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, stats.Read)
+	assert.Equal(t, 2, stats.Imported)
+
+	var lines []int
+	for _, recErr := range stats.Failed {
+		lines = append(lines, recErr.Line)
+	}
+	assert.ElementsMatch(t, []int{1, 3, 4, 5}, lines)
+
+	_, err = s.users.FindUserByEmail(ctx, "user_1@example.com")
+	assert.True(t, errors.Is(err, database.ErrNotFound), "nothing is saved in dry run")
+
+	// without dry run the first invalid record stops the import
+	_, err = s.importer(user.ImportOptions{OnConflict: user.ConflictSkip}).
+		Import(ctx, strings.NewReader(input), nil)
+
+	var recErr *RecordError
+	require.True(t, errors.As(err, &recErr))
+	assert.Equal(t, 3, recErr.Line)
+}
+
+func TestImportKeepGoing(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStore(t)
+
+	_, err := s.importer(user.ImportOptions{OnConflict: user.ConflictFail}).
+		Import(ctx, strings.NewReader(records(0, 1)), nil)
+	require.NoError(t, err)
+
+	input := strings.Join([]string{
+		record(1),
+		record(0), // conflicts with the existing user
+		`{"email":"invalid","username":"x"}`,
+		record(2),
+	}, "\n")
+
+	im := s.importer(user.ImportOptions{OnConflict: user.ConflictFail})
+	im.KeepGoing = true
+
+	stats, err := im.Import(ctx, strings.NewReader(input), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, stats.Read)
+	assert.Equal(t, 2, stats.Imported)
+
+	var lines []int
+	for _, recErr := range stats.Failed {
+		lines = append(lines, recErr.Line)
+	}
+	assert.ElementsMatch(t, []int{2, 3}, lines)
+
+	for _, email := range []string{"user_1@example.com", "user_2@example.com"} {
+		_, err = s.users.FindUserByEmail(ctx, email)
+		assert.NoError(t, err, email)
+	}
+}
+
+func TestImportCheckpoint(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStore(t)
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	cp, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cp.Line)
+
+	im := s.importer(user.ImportOptions{OnConflict: user.ConflictFail})
+	im.SaveCheckpoint = func(cp *Checkpoint) error {
+		return cp.Save(path)
+	}
+
+	// the third batch is invalid, the first two are saved
+	input := records(0, 4) + `{"email":"invalid"}` + "\n" + records(5, 6)
+
+	_, err = im.Import(ctx, strings.NewReader(input), cp)
+	require.Error(t, err)
+
+	cp, err = LoadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, cp.Line)
+
+	// resumed after the line is fixed
+	input = records(0, 6)
+
+	stats, err := im.Import(ctx, strings.NewReader(input), cp)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Read)
+	assert.Equal(t, 2, stats.Imported)
+
+	cp, err = LoadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, 6, cp.Line)
+}
+
+func TestExportCheckpoint(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStore(t)
+
+	_, err := s.importer(user.ImportOptions{OnConflict: user.ConflictFail}).
+		Import(ctx, strings.NewReader(records(0, 5)), nil)
+	require.NoError(t, err)
+
+	var full bytes.Buffer
+
+	_, err = (&Exporter{Users: s.users, Accounts: s.accounts}).Export(ctx, &full, nil)
+	require.NoError(t, err)
+
+	var checkpoints []Checkpoint
+
+	var out bytes.Buffer
+
+	ex := &Exporter{
+		Users:    s.users,
+		Accounts: s.accounts,
+		PageSize: 2,
+		SaveCheckpoint: func(cp *Checkpoint) error {
+			checkpoints = append(checkpoints, *cp)
+			return nil
+		},
+	}
+
+	_, err = ex.Export(ctx, &out, nil)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 3)
+
+	// resumed from the first page
+	cp := checkpoints[0]
+	out.Truncate(int(cp.Offset))
+
+	count, err := ex.Export(ctx, &out, &cp)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, full.String(), out.String())
+}
@@ -0,0 +1,67 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Source provides the records to import.
+type Source interface {
+	// Read reads the next record. It returns io.EOF when there are no more
+	// records, and a *RecordError for a record which can not be imported.
+	Read() (*Record, error)
+
+	// Line returns the line of the last record read, see RecordError.
+	Line() int
+}
+
+// Reader reads records line by line.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a new reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads the next record, skipping blank lines. It returns io.EOF when
+// there are no more records, and a *RecordError for a malformed line.
+func (r *Reader) Read() (*Record, error) {
+	for {
+		data, err := r.r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		if len(data) == 0 && errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		r.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+
+		rec := &Record{}
+		if err := dec.Decode(rec); err != nil {
+			return nil, &RecordError{Line: r.line, Err: err}
+		}
+
+		return rec, nil
+	}
+}
+
+// Line returns the line number of the last record read.
+func (r *Reader) Line() int {
+	return r.line
+}
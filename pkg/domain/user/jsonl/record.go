@@ -0,0 +1,106 @@
+// Package jsonl reads and writes users in JSON Lines, one user together with
+// its accounts per line, using the storage representation of the user.
+package jsonl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zbiljic/authzy/pkg/domain/account"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+)
+
+// Record is a single line, the user with its accounts.
+type Record struct {
+	schema.User
+
+	Accounts []*Account `json:"accounts,omitempty" validate:"dive"`
+
+	// SourceID is the ID of the user in the export it was converted from.
+	SourceID string `json:"-"`
+}
+
+// Account is an account linked to the user of the record.
+type Account struct {
+	Provider    string `json:"provider" validate:"required"`
+	FederatedID string `json:"federated_id" validate:"required"`
+}
+
+// NewRecord returns the record of the user and its accounts. Pending tokens
+// are left out, they are only valid where they were issued.
+func NewRecord(u *user.User, accounts []*account.Account) *Record {
+	r := &Record{
+		User: *schema.UserToSchema(u),
+	}
+
+	r.ConfirmationToken = ""
+	r.ConfirmationSentAt = nil
+	r.RecoveryToken = ""
+	r.RecoverySentAt = nil
+	r.EmailChangeToken = ""
+	r.EmailChange = ""
+	r.EmailChangeSentAt = nil
+	r.DeletionToken = ""
+
+	for _, acc := range accounts {
+		r.Accounts = append(r.Accounts, &Account{
+			Provider:    acc.Provider.String(),
+			FederatedID: acc.FederatedID,
+		})
+	}
+
+	return r
+}
+
+// ToUser returns the user of the record.
+func (r *Record) ToUser() *user.User {
+	return schema.UserFromSchema(&r.User)
+}
+
+// ToAccounts returns the accounts of the record, linked to the user ID.
+func (r *Record) ToAccounts(userID string) []*account.Account {
+	result := make([]*account.Account, 0, len(r.Accounts))
+
+	for _, acc := range r.Accounts {
+		result = append(result, &account.Account{
+			UserID:      userID,
+			Provider:    account.ProviderType(acc.Provider),
+			FederatedID: acc.FederatedID,
+		})
+	}
+
+	return result
+}
+
+// ErrSkipped is the reason of records left out of the import on purpose.
+var ErrSkipped = errors.New("skipped")
+
+// sourceID returns the ID of the record to report.
+func (r *Record) sourceID() string {
+	if r.SourceID != "" {
+		return r.SourceID
+	}
+	return r.ID
+}
+
+// RecordError is the error of a single record.
+type RecordError struct {
+	// Line is the line of the record, or its position in sources which are
+	// not line based.
+	Line int
+	// ID is the ID of the record in the source, if known.
+	ID  string
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	if e.ID != "" {
+		return fmt.Sprintf("line %d (%s): %v", e.Line, e.ID, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
@@ -35,6 +35,9 @@ type User struct {
 	EmailChange       string
 	EmailChangeSentAt *time.Time
 
+	DeletionToken       string
+	DeletionRequestedAt *time.Time
+
 	AppMetaData  jsonmap.JSONMap
 	UserMetaData jsonmap.JSONMap
 
@@ -46,9 +49,25 @@ type User struct {
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt *time.Time
+
+	// Revision is incremented by every save of the user. Saving a user read
+	// at an older revision fails with database.ErrConflict.
+	Revision int64
 }
 
 // IsConfirmed checks if a user is already registered and confirmed.
 func (u *User) IsConfirmed() bool {
 	return u.EmailVerified
 }
+
+// IsDeletionScheduled checks if the user requested the deletion of the
+// account, which can still be restored.
+func (u *User) IsDeletionScheduled() bool {
+	return u.DeletionRequestedAt != nil
+}
+
+// IsDeleted checks if the user was soft deleted.
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
@@ -6,6 +6,10 @@ type UserRepository interface {
 	// Save saves a given entity.
 	Save(ctx context.Context, entity *User) (*User, error)
 
+	// SaveAll saves all given entities at once. Nothing is saved unless all
+	// entities are valid. Deleted entities are saved as tombstones.
+	SaveAll(ctx context.Context, entities []*User) ([]*User, error)
+
 	// FindByID retrieves an entity by its id.
 	FindByID(ctx context.Context, id string) (*User, error)
 
@@ -18,12 +22,26 @@ type UserRepository interface {
 	// Count returns the number of entities available.
 	Count(ctx context.Context) (int, error)
 
-	// DeleteByID deletes the entity with the given id.
+	// DeleteByID soft deletes the entity with the given id. Deleted entities
+	// are kept as tombstones, excluded from all lookups and indexes, until
+	// they are saved again.
 	DeleteByID(ctx context.Context, id string) error
 
-	// Delete deletes a given entity.
+	// Delete soft deletes a given entity.
 	Delete(ctx context.Context, entity *User) error
 
+	// PurgeByID irreversibly deletes the entity with the given id, including
+	// its tombstone.
+	PurgeByID(ctx context.Context, id string) error
+
+	// FindDeletedByID retrieves the tombstone of the soft deleted entity by
+	// its id.
+	FindDeletedByID(ctx context.Context, id string) (*User, error)
+
+	// FindAllDeleted returns the tombstones of the soft deleted entities in
+	// pages, starting after the cursor.
+	FindAllDeleted(ctx context.Context, afterCursor string, limit int) ([]*User, string, error)
+
 	// DeleteAll deletes all entities managed by the repository.
 	DeleteAll(ctx context.Context) error
 
@@ -39,4 +57,29 @@ type UserRepository interface {
 
 	// FindByRecoveryToken finds user with the matching recovery token.
 	FindByRecoveryToken(ctx context.Context, token string) (*User, error)
+
+	// FindByDeletionToken finds user with the matching deletion token.
+	FindByDeletionToken(ctx context.Context, token string) (*User, error)
+
+	// FindAllScheduledForDeletion returns the users having a deletion token
+	// in pages, ordered by the token, starting after the cursor.
+	FindAllScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*User, string, error)
+}
+
+type UserVersionRepository interface {
+	// Save saves the version as the next version of the user.
+	Save(ctx context.Context, entity *Version) (*Version, error)
+
+	// FindByVersion retrieves the version of the user.
+	FindByVersion(ctx context.Context, userID string, version int) (*Version, error)
+
+	// FindAllForUser retrieves the versions of the user in pages, newest
+	// first, starting after the cursor.
+	FindAllForUser(ctx context.Context, userID string, afterCursor string, limit int) ([]*Version, string, error)
+
+	// DeleteAllForUser deletes all versions of the user.
+	DeleteAllForUser(ctx context.Context, userID string) error
+
+	// DeleteAll deletes all entities managed by the repository.
+	DeleteAll(ctx context.Context) error
 }
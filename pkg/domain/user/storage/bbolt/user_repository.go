@@ -0,0 +1,639 @@
+package bbolt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/noop"
+)
+
+const (
+	usersBucket                       = "users"
+	deletedUsersBucket                = "deleted_users"
+	usersIdentifierIndexBucket        = "index_users_identifier"
+	usersConfirmationTokenIndexBucket = "index_users_confirmation_token"
+	usersRecoveryTokenIndexBucket     = "index_users_recovery_token"
+	usersDeletionTokenIndexBucket     = "index_users_deletion_token"
+)
+
+// bboltUserRepository is a repository that uses bbolt database.
+type bboltUserRepository struct {
+	noop.UnimplementedUserRepository
+
+	db *database_bbolt.DB
+
+	usersBucket                       []byte
+	deletedUsersBucket                []byte
+	usersIdentifierIndexBucket        []byte
+	usersConfirmationTokenIndexBucket []byte
+	usersRecoveryTokenIndexBucket     []byte
+	usersDeletionTokenIndexBucket     []byte
+
+	validate *validator.Validate
+}
+
+// NewUserRepository returns a new bbolt repository.
+func NewUserRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (user.UserRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &bboltUserRepository{
+		db:                                db,
+		usersBucket:                       []byte(bucketPrefix + usersBucket),
+		deletedUsersBucket:                []byte(bucketPrefix + deletedUsersBucket),
+		usersIdentifierIndexBucket:        []byte(bucketPrefix + usersIdentifierIndexBucket),
+		usersConfirmationTokenIndexBucket: []byte(bucketPrefix + usersConfirmationTokenIndexBucket),
+		usersRecoveryTokenIndexBucket:     []byte(bucketPrefix + usersRecoveryTokenIndexBucket),
+		usersDeletionTokenIndexBucket:     []byte(bucketPrefix + usersDeletionTokenIndexBucket),
+		validate:                          validate,
+	}
+
+	err := db.CreateBuckets(
+		string(r.usersBucket),
+		string(r.deletedUsersBucket),
+		string(r.usersIdentifierIndexBucket),
+		string(r.usersConfirmationTokenIndexBucket),
+		string(r.usersRecoveryTokenIndexBucket),
+		string(r.usersDeletionTokenIndexBucket),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns                            = "user/storage/bbolt."
+	opSave                        = ns + "Save"
+	opSaveAll                     = ns + "SaveAll"
+	opFindByID                    = ns + "FindByID"
+	opExistsByID                  = ns + "ExistsByID"
+	opFindAll                     = ns + "FindAll"
+	opFindAllDeleted              = ns + "FindAllDeleted"
+	opCount                       = ns + "Count"
+	opDeleteByID                  = ns + "DeleteByID"
+	opPurgeByID                   = ns + "PurgeByID"
+	opFindDeletedByID             = ns + "FindDeletedByID"
+	opExistsByIdentifier          = ns + "ExistsByIdentifier"
+	opFindByIdentifier            = ns + "FindByIdentifier"
+	opFindByConfirmationToken     = ns + "FindByConfirmationToken"
+	opFindByRecoveryToken         = ns + "FindByRecoveryToken"
+	opFindByDeletionToken         = ns + "FindByDeletionToken"
+	opFindAllScheduledForDeletion = ns + "FindAllScheduledForDeletion"
+)
+
+func (r *bboltUserRepository) Save(ctx context.Context, entity *user.User) (*user.User, error) {
+	inS, err := r.prepare(entity)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		stored, err := r.find(tx, inS.ID)
+		if err != nil {
+			return err
+		}
+
+		err = inS.Revise(stored)
+		if err != nil {
+			return err
+		}
+
+		return r.put(tx, inS)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.UserFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltUserRepository) SaveAll(ctx context.Context, entities []*user.User) ([]*user.User, error) {
+	inSs := make([]*schema.User, 0, len(entities))
+
+	for _, entity := range entities {
+		inS, err := r.prepare(entity)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		inSs = append(inSs, inS)
+	}
+
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		for _, inS := range inSs {
+			stored, err := r.find(tx, inS.ID)
+			if err != nil {
+				return fmt.Errorf("%s: %w", inS.ID, err)
+			}
+
+			// imported users replace the stored ones, whatever their
+			// revision
+			inS.Replace(stored)
+
+			if inS.DeletedAt != nil {
+				err = r.putDeleted(tx, stored, inS)
+			} else {
+				err = r.put(tx, inS)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", inS.ID, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+	}
+
+	savedEntities := make([]*user.User, 0, len(inSs))
+	for _, inS := range inSs {
+		savedEntities = append(savedEntities, schema.UserFromSchema(inS))
+	}
+
+	return savedEntities, nil
+}
+
+// prepare validates the entity and converts it for storage.
+func (r *bboltUserRepository) prepare(entity *user.User) (*schema.User, error) {
+	inS := schema.UserToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, err
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, err
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	return inS, nil
+}
+
+// put writes the user and its indexes, replacing the indexes of the
+// previous version of the user.
+func (r *bboltUserRepository) put(tx *bolt.Tx, inS *schema.User) error {
+	prev, err := r.find(tx, inS.ID)
+	if err != nil {
+		return err
+	}
+
+	if prev != nil {
+		err = r.deleteIndexes(tx, prev)
+		if err != nil {
+			return err
+		}
+	}
+
+	value, err := transformer.MarshalUser(inS)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Bucket(r.usersBucket).Put([]byte(inS.ID), value)
+	if err != nil {
+		return err
+	}
+
+	// saving a deleted user restores it
+	err = tx.Bucket(r.deletedUsersBucket).Delete([]byte(inS.ID))
+	if err != nil {
+		return err
+	}
+
+	return r.putIndexes(tx, inS)
+}
+
+// indexes returns the index buckets with the keys of the user in them.
+func (r *bboltUserRepository) indexes(ts *schema.User) map[string][]string {
+	indexes := map[string][]string{
+		string(r.usersConfirmationTokenIndexBucket): {ts.ConfirmationToken},
+		string(r.usersRecoveryTokenIndexBucket):     {ts.RecoveryToken},
+		string(r.usersDeletionTokenIndexBucket):     {ts.DeletionToken},
+	}
+
+	// only users with a password are found by their identifier
+	if ts.PasswordHash != "" {
+		indexes[string(r.usersIdentifierIndexBucket)] = []string{ts.NormalizedUsername, ts.Email}
+	}
+
+	return indexes
+}
+
+func (r *bboltUserRepository) putIndexes(tx *bolt.Tx, ts *schema.User) error {
+	for bucket, keys := range r.indexes(ts) {
+		b := tx.Bucket([]byte(bucket))
+
+		for _, key := range keys {
+			if key == "" {
+				continue
+			}
+
+			err := b.Put([]byte(key), []byte(ts.ID))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteIndexes deletes the index entries pointing to the user.
+func (r *bboltUserRepository) deleteIndexes(tx *bolt.Tx, ts *schema.User) error {
+	for bucket, keys := range r.indexes(ts) {
+		b := tx.Bucket([]byte(bucket))
+
+		for _, key := range keys {
+			if key == "" || string(b.Get([]byte(key))) != ts.ID {
+				continue
+			}
+
+			err := b.Delete([]byte(key))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// find returns the stored user, which is not deleted, or nil if it does not
+// exist.
+// putDeleted writes the tombstone of the user, and deletes the stored user,
+// if any.
+func (r *bboltUserRepository) putDeleted(tx *bolt.Tx, stored, ts *schema.User) error {
+	if stored != nil {
+		err := r.deleteIndexes(tx, stored)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(r.usersBucket).Delete([]byte(stored.ID))
+		if err != nil {
+			return err
+		}
+	}
+
+	value, err := transformer.MarshalUser(ts)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(r.deletedUsersBucket).Put([]byte(ts.ID), value)
+}
+
+func (r *bboltUserRepository) find(tx *bolt.Tx, id string) (*schema.User, error) {
+	value := tx.Bucket(r.usersBucket).Get([]byte(id))
+	if value == nil {
+		return nil, nil
+	}
+
+	return transformer.UnmarshalUser(value)
+}
+
+// findByIndex returns the user the key of the index points to.
+func (r *bboltUserRepository) findByIndex(ctx context.Context, bucket []byte, key string) (*user.User, error) {
+	var ts *schema.User
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		id := tx.Bucket(bucket).Get([]byte(key))
+		if id == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = r.find(tx, string(id))
+		if err == nil && ts == nil {
+			err = database.ErrNotFound
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.UserFromSchema(ts), nil
+}
+
+func (r *bboltUserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
+	var ts *schema.User
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		ts, err = r.find(tx, id)
+		if err == nil && ts == nil {
+			err = database.ErrNotFound
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.UserFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *bboltUserRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		has = tx.Bucket(r.usersBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *bboltUserRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return r.findAll(ctx, opFindAll, r.usersBucket, afterCursor, limit)
+}
+
+func (r *bboltUserRepository) FindAllDeleted(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return r.findAll(ctx, opFindAllDeleted, r.deletedUsersBucket, afterCursor, limit)
+}
+
+// findAll returns the users of the bucket in pages, starting after the
+// cursor.
+func (r *bboltUserRepository) findAll(ctx context.Context, op string, bucket []byte, afterCursor string, limit int) ([]*user.User, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.User
+		nextCursor string
+	)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+
+		k, v := c.First()
+		if afterCursor != "" {
+			k, v = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < limit; k, v = c.Next() {
+			ts, err := transformer.UnmarshalUser(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.UserFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", op, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltUserRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.usersBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if count%100 == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *bboltUserRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		ts, err := r.find(tx, id)
+		if err != nil || ts == nil {
+			return err
+		}
+
+		err = r.deleteIndexes(tx, ts)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(r.usersBucket).Delete([]byte(id))
+		if err != nil {
+			return err
+		}
+
+		// keep tombstone
+		now := time.Now()
+		ts.DeletedAt = &now
+
+		value, err := transformer.MarshalUser(ts)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(r.deletedUsersBucket).Put([]byte(id), value)
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltUserRepository) Delete(ctx context.Context, entity *user.User) error {
+	return r.DeleteByID(ctx, entity.ID)
+}
+
+func (r *bboltUserRepository) PurgeByID(ctx context.Context, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		ts, err := r.find(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if ts != nil {
+			err = r.deleteIndexes(tx, ts)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Bucket(r.usersBucket).Delete([]byte(id))
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket(r.deletedUsersBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opPurgeByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltUserRepository) FindDeletedByID(ctx context.Context, id string) (*user.User, error) {
+	var ts *schema.User
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		value := tx.Bucket(r.deletedUsersBucket).Get([]byte(id))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = transformer.UnmarshalUser(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindDeletedByID, id, err)
+	}
+
+	entity := schema.UserFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *bboltUserRepository) ExistsByIdentifier(ctx context.Context, identifier string) (bool, error) {
+	var has bool
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		has = tx.Bucket(r.usersIdentifierIndexBucket).Get([]byte(identifier)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByIdentifier, identifier, err)
+	}
+
+	return has, nil
+}
+
+func (r *bboltUserRepository) FindByIdentifier(ctx context.Context, identifier string) (*user.User, error) {
+	entity, err := r.findByIndex(ctx, r.usersIdentifierIndexBucket, identifier)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByIdentifier, identifier, err)
+	}
+
+	return entity, nil
+}
+
+func (r *bboltUserRepository) FindByConfirmationToken(ctx context.Context, token string) (*user.User, error) {
+	entity, err := r.findByIndex(ctx, r.usersConfirmationTokenIndexBucket, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByConfirmationToken, token, err)
+	}
+
+	return entity, nil
+}
+
+func (r *bboltUserRepository) FindByRecoveryToken(ctx context.Context, token string) (*user.User, error) {
+	entity, err := r.findByIndex(ctx, r.usersRecoveryTokenIndexBucket, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByRecoveryToken, token, err)
+	}
+
+	return entity, nil
+}
+
+func (r *bboltUserRepository) FindByDeletionToken(ctx context.Context, token string) (*user.User, error) {
+	entity, err := r.findByIndex(ctx, r.usersDeletionTokenIndexBucket, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByDeletionToken, token, err)
+	}
+
+	return entity, nil
+}
+
+func (r *bboltUserRepository) FindAllScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.User
+		nextCursor string
+	)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.usersDeletionTokenIndexBucket).Cursor()
+
+		k, id := c.First()
+		if afterCursor != "" {
+			k, id = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, id = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < limit; k, id = c.Next() {
+			ts, err := r.find(tx, string(id))
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			if ts == nil {
+				continue
+			}
+
+			result = append(result, schema.UserFromSchema(ts))
+
+			if len(result) == limit {
+				nextCursor = string(k)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAllScheduledForDeletion, afterCursor, err)
+	}
+
+	return result, nextCursor, nil
+}
@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/test"
+)
+
+func TestBBoltUserRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (user.UserRepository, func()) {
+		return func(t *testing.T) (user.UserRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewUserRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/noop"
+)
+
+const (
+	userVersionsBucket = "user_versions"
+)
+
+// bboltUserVersionRepository is a repository that uses bbolt database.
+type bboltUserVersionRepository struct {
+	noop.UnimplementedUserVersionRepository
+
+	db *database_bbolt.DB
+
+	userVersionsBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewUserVersionRepository returns a new bbolt repository.
+func NewUserVersionRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (user.UserVersionRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &bboltUserVersionRepository{
+		db:                 db,
+		userVersionsBucket: []byte(bucketPrefix + userVersionsBucket),
+		validate:           validate,
+	}
+
+	err := db.CreateBuckets(string(r.userVersionsBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	opVersionSave             = ns + "Version.Save"
+	opVersionFindByVersion    = ns + "Version.FindByVersion"
+	opVersionFindAllForUser   = ns + "Version.FindAllForUser"
+	opVersionDeleteAllForUser = ns + "Version.DeleteAllForUser"
+	opVersionDeleteAll        = ns + "Version.DeleteAll"
+)
+
+// versionKeyPrefix returns the prefix of the keys of all versions of the
+// user.
+func versionKeyPrefix(userID string) []byte {
+	return []byte(userID + "/")
+}
+
+// versionKey returns the key of the version of the user, which sorts the
+// versions of the user in order.
+func versionKey(userID string, version int) []byte {
+	return []byte(fmt.Sprintf("%s/%020d", userID, version))
+}
+
+// lastVersion positions the cursor at the latest version of the user, and
+// returns its key and value.
+func lastVersion(c *bolt.Cursor, userID string) ([]byte, []byte) {
+	prefix := versionKeyPrefix(userID)
+
+	// the first key after all versions of the user, as '0' follows '/'
+	k, v := c.Seek([]byte(userID + "0"))
+	if k == nil {
+		k, v = c.Last()
+	} else {
+		k, v = c.Prev()
+	}
+
+	if k == nil || !bytes.HasPrefix(k, prefix) {
+		return nil, nil
+	}
+
+	return k, v
+}
+
+func (r *bboltUserVersionRepository) Save(ctx context.Context, entity *user.Version) (*user.Version, error) {
+	inS := schema.VersionToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(r.userVersionsBucket)
+
+		// the next version follows the latest one
+		inS.Version = 1
+		if _, v := lastVersion(b.Cursor(), inS.UserID); v != nil {
+			latest, err := transformer.UnmarshalVersion(v)
+			if err != nil {
+				return err
+			}
+
+			inS.Version = latest.Version + 1
+		}
+
+		value, err := transformer.MarshalVersion(inS)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(versionKey(inS.UserID, inS.Version), value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opVersionSave, inS.UserID, err)
+	}
+
+	return schema.VersionFromSchema(inS), nil
+}
+
+func (r *bboltUserVersionRepository) FindByVersion(ctx context.Context, userID string, version int) (*user.Version, error) {
+	var ts *schema.Version
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		value := tx.Bucket(r.userVersionsBucket).Get(versionKey(userID, version))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		ts, err = transformer.UnmarshalVersion(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s, %d): %w", opVersionFindByVersion, userID, version, err)
+	}
+
+	return schema.VersionFromSchema(ts), nil
+}
+
+func (r *bboltUserVersionRepository) FindAllForUser(ctx context.Context, userID string, afterCursor string, limit int) ([]*user.Version, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.Version
+		nextCursor string
+	)
+
+	cursor := -1
+	if afterCursor != "" {
+		var err error
+
+		cursor, err = strconv.Atoi(afterCursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): invalid cursor: %w", opVersionFindAllForUser, afterCursor, err)
+		}
+	}
+
+	prefix := versionKeyPrefix(userID)
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.userVersionsBucket).Cursor()
+
+		// newest first
+		k, v := lastVersion(c, userID)
+		if cursor >= 0 {
+			// the previous entry is the first one older than the cursor
+			k, v = c.Seek(versionKey(userID, cursor))
+			if k == nil {
+				k, v = c.Last()
+			} else {
+				k, v = c.Prev()
+			}
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, prefix) && len(result) < limit; k, v = c.Prev() {
+			ts, err := transformer.UnmarshalVersion(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			result = append(result, schema.VersionFromSchema(ts))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opVersionFindAllForUser, userID, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = strconv.Itoa(result[len(result)-1].Version)
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltUserVersionRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		prefix := versionKeyPrefix(userID)
+
+		c := tx.Bucket(r.userVersionsBucket).Cursor()
+
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+			err := c.Delete()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opVersionDeleteAllForUser, userID, err)
+	}
+
+	return nil
+}
+
+func (r *bboltUserVersionRepository) DeleteAll(ctx context.Context) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return database_bbolt.ClearBucket(tx, r.userVersionsBucket)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", opVersionDeleteAll, err)
+	}
+
+	return nil
+}
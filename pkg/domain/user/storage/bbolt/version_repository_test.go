@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/test"
+)
+
+func TestBBoltUserVersionRepository(t *testing.T) {
+	test.RunVersion(t, func() func(t *testing.T) (user.UserVersionRepository, func()) {
+		return func(t *testing.T) (user.UserVersionRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewUserVersionRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -3,6 +3,7 @@ package schema
 import (
 	"time"
 
+	"github.com/zbiljic/authzy/pkg/database"
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	"github.com/zbiljic/authzy/pkg/jsonmap"
 )
@@ -34,6 +35,9 @@ type User struct {
 	EmailChange       string     `json:"new_email,omitempty"`
 	EmailChangeSentAt *time.Time `json:"email_change_sent_at,omitempty"`
 
+	DeletionToken       string     `json:"deletion_token,omitempty"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+
 	AppMetaData  jsonmap.JSONMap `json:"app_metadata,omitempty"`
 	UserMetaData jsonmap.JSONMap `json:"user_metadata,omitempty"`
 
@@ -43,8 +47,11 @@ type User struct {
 
 	Blocked bool `json:"blocked,omitempty"`
 
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Revision int64 `json:"revision,omitempty"`
 }
 
 func (u *User) BeforeSave() error {
@@ -63,13 +70,43 @@ func (u *User) BeforeSave() error {
 	if u.EmailChangeSentAt != nil && u.EmailChangeSentAt.IsZero() {
 		u.EmailChangeSentAt = nil
 	}
+	if u.DeletionRequestedAt != nil && u.DeletionRequestedAt.IsZero() {
+		u.DeletionRequestedAt = nil
+	}
 	if u.LastLoginAt != nil && u.LastLoginAt.IsZero() {
 		u.LastLoginAt = nil
 	}
+	if u.DeletedAt != nil && u.DeletedAt.IsZero() {
+		u.DeletedAt = nil
+	}
+
+	return nil
+}
+
+// Revise increments the revision of the user saved over the stored user,
+// which is nil if there is none. The user must have the revision of the
+// stored user, or database.ErrConflict is returned.
+func (u *User) Revise(stored *User) error {
+	if stored != nil && stored.Revision != u.Revision {
+		return database.ErrConflict
+	}
+
+	u.Revision++
 
 	return nil
 }
 
+// Replace increments the revision of the stored user, which is nil if there
+// is none, for the user replacing it regardless of its revision.
+func (u *User) Replace(stored *User) {
+	u.Revision = 0
+	if stored != nil {
+		u.Revision = stored.Revision
+	}
+
+	u.Revision++
+}
+
 func UserToSchema(in *user.User) *User {
 	out := &User{}
 	if in != nil {
@@ -81,6 +118,10 @@ func UserToSchema(in *user.User) *User {
 		out.PasswordUpdatedAt = in.PasswordUpdatedAt
 		out.Username = in.Username
 		out.NormalizedUsername = in.NormalizedUsername
+		out.GivenName = in.GivenName
+		out.FamilyName = in.FamilyName
+		out.Name = in.Name
+		out.Nickname = in.Nickname
 		out.Picture = in.Picture
 		out.ConfirmationToken = in.ConfirmationToken
 		out.ConfirmationSentAt = in.ConfirmationSentAt
@@ -89,14 +130,18 @@ func UserToSchema(in *user.User) *User {
 		out.EmailChangeToken = in.EmailChangeToken
 		out.EmailChange = in.EmailChange
 		out.EmailChangeSentAt = in.EmailChangeSentAt
-		out.AppMetaData = in.AppMetaData
-		out.UserMetaData = in.UserMetaData
+		out.DeletionToken = in.DeletionToken
+		out.DeletionRequestedAt = in.DeletionRequestedAt
+		out.AppMetaData = in.AppMetaData.Clone()
+		out.UserMetaData = in.UserMetaData.Clone()
 		out.LastIP = in.LastIP
 		out.LastLoginAt = in.LastLoginAt
 		out.LoginsCount = in.LoginsCount
 		out.Blocked = in.Blocked
 		out.CreatedAt = in.CreatedAt
 		out.UpdatedAt = in.UpdatedAt
+		out.DeletedAt = in.DeletedAt
+		out.Revision = in.Revision
 	}
 
 	return out
@@ -112,6 +157,10 @@ func UserFromSchema(in *User) *user.User {
 	out.PasswordUpdatedAt = in.PasswordUpdatedAt
 	out.Username = in.Username
 	out.NormalizedUsername = in.NormalizedUsername
+	out.GivenName = in.GivenName
+	out.FamilyName = in.FamilyName
+	out.Name = in.Name
+	out.Nickname = in.Nickname
 	out.Picture = in.Picture
 	out.ConfirmationToken = in.ConfirmationToken
 	out.ConfirmationSentAt = in.ConfirmationSentAt
@@ -120,14 +169,18 @@ func UserFromSchema(in *User) *user.User {
 	out.EmailChangeToken = in.EmailChangeToken
 	out.EmailChange = in.EmailChange
 	out.EmailChangeSentAt = in.EmailChangeSentAt
-	out.AppMetaData = in.AppMetaData
-	out.UserMetaData = in.UserMetaData
+	out.DeletionToken = in.DeletionToken
+	out.DeletionRequestedAt = in.DeletionRequestedAt
+	out.AppMetaData = in.AppMetaData.Clone()
+	out.UserMetaData = in.UserMetaData.Clone()
 	out.LastIP = in.LastIP
 	out.LastLoginAt = in.LastLoginAt
 	out.LoginsCount = in.LoginsCount
 	out.Blocked = in.Blocked
 	out.CreatedAt = in.CreatedAt
 	out.UpdatedAt = in.UpdatedAt
+	out.DeletedAt = in.DeletedAt
+	out.Revision = in.Revision
 
 	return out
 }
@@ -24,6 +24,11 @@ func RegisterValidators(v *validator.Validate) {
 	}
 }
 
+// IsUsername reports whether the username is valid.
+func IsUsername(username string) bool {
+	return usernameRegex.MatchString(username)
+}
+
 func isUsername(fl validator.FieldLevel) bool {
-	return usernameRegex.MatchString(fl.Field().String())
+	return IsUsername(fl.Field().String())
 }
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/user"
+)
+
+type Version struct {
+	UserID  string   `json:"user_id" validate:"required,alphanum"`
+	Version int      `json:"version"`
+	Action  string   `json:"action" validate:"required,oneof=create update delete restore"`
+	ActorID string   `json:"actor_id,omitempty"`
+	Changes []Change `json:"changes,omitempty"`
+	User    User     `json:"user"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Change struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
+
+func (v *Version) BeforeSave() error {
+	return v.User.BeforeSave()
+}
+
+func VersionToSchema(in *user.Version) *Version {
+	out := &Version{}
+	if in != nil {
+		out.UserID = in.UserID
+		out.Version = in.Version
+		out.Action = string(in.Action)
+		out.ActorID = in.ActorID
+		for _, c := range in.Changes {
+			out.Changes = append(out.Changes, Change{
+				Field: c.Field,
+				From:  c.From,
+				To:    c.To,
+			})
+		}
+		if in.User != nil {
+			out.User = *UserToSchema(in.User)
+		}
+		out.CreatedAt = in.CreatedAt
+	}
+
+	return out
+}
+
+func VersionFromSchema(in *Version) *user.Version {
+	out := &user.Version{}
+	out.UserID = in.UserID
+	out.Version = in.Version
+	out.Action = user.VersionAction(in.Action)
+	out.ActorID = in.ActorID
+	for _, c := range in.Changes {
+		out.Changes = append(out.Changes, user.Change{
+			Field: c.Field,
+			From:  c.From,
+			To:    c.To,
+		})
+	}
+	out.User = UserFromSchema(&in.User)
+	out.CreatedAt = in.CreatedAt
+
+	return out
+}
@@ -11,9 +11,11 @@ import (
 const keySeparator = "/"
 
 const (
-	ns              = "user/storage/json/transformer."
-	opMarshalUser   = ns + "MarshalUser"
-	opUnmarshalUser = ns + "UnmarshalUser"
+	ns                 = "user/storage/json/transformer."
+	opMarshalUser      = ns + "MarshalUser"
+	opUnmarshalUser    = ns + "UnmarshalUser"
+	opMarshalVersion   = ns + "MarshalVersion"
+	opUnmarshalVersion = ns + "UnmarshalVersion"
 )
 
 func MarshalUserKey(prefix, id string) string {
@@ -38,3 +40,26 @@ func UnmarshalUser(in []byte) (*schema.User, error) {
 
 	return out, nil
 }
+
+func MarshalVersionKey(prefix, userID string, version int) string {
+	return strings.Join([]string{prefix, userID, fmt.Sprintf("%020d", version)}, keySeparator)
+}
+
+func MarshalVersion(in *schema.Version) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalVersion, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalVersion(in []byte) (*schema.Version, error) {
+	out := &schema.Version{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalVersion, err)
+	}
+
+	return out, nil
+}
@@ -28,6 +28,7 @@ type jsonMutexDBUserRepository struct {
 	dbIndexUsersIdentifier        map[string]*schema.User
 	dbIndexUsersConfirmationToken map[string]*schema.User
 	dbIndexUsersRecoveryToken     map[string]*schema.User
+	dbIndexUsersDeletionToken     map[string]*schema.User
 	mu                            sync.RWMutex
 
 	loadSaver jsonmutexdb.LoadSaver
@@ -49,6 +50,7 @@ func NewUserRepository(
 		dbIndexUsersIdentifier:        make(map[string]*schema.User),
 		dbIndexUsersConfirmationToken: make(map[string]*schema.User),
 		dbIndexUsersRecoveryToken:     make(map[string]*schema.User),
+		dbIndexUsersDeletionToken:     make(map[string]*schema.User),
 		loadSaver:                     loadSaver,
 		filename:                      fmt.Sprintf("%s%s.json", filenamePrefix, usersPrefix),
 		validate:                      validate,
@@ -77,6 +79,9 @@ func (r *jsonMutexDBUserRepository) load() error {
 			// read indexes
 			for _, v := range r.db {
 				v := v
+				if v.DeletedAt != nil {
+					continue
+				}
 				r.dbIndexUsersIdentifier[v.NormalizedUsername] = &v
 				r.dbIndexUsersIdentifier[v.Email] = &v
 				if v.ConfirmationToken != "" {
@@ -85,12 +90,29 @@ func (r *jsonMutexDBUserRepository) load() error {
 				if v.RecoveryToken != "" {
 					r.dbIndexUsersRecoveryToken[v.RecoveryToken] = &v
 				}
+				if v.DeletionToken != "" {
+					r.dbIndexUsersDeletionToken[v.DeletionToken] = &v
+				}
 			}
 		}
 	}
 	return nil
 }
 
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBUserRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.User)
+	r.dbIndexUsersIdentifier = make(map[string]*schema.User)
+	r.dbIndexUsersConfirmationToken = make(map[string]*schema.User)
+	r.dbIndexUsersRecoveryToken = make(map[string]*schema.User)
+	r.dbIndexUsersDeletionToken = make(map[string]*schema.User)
+
+	return r.load()
+}
+
 func (r *jsonMutexDBUserRepository) commit(ctx context.Context) error {
 	if r.loadSaver != nil {
 		out, err := json.Marshal(r.db)
@@ -98,36 +120,113 @@ func (r *jsonMutexDBUserRepository) commit(ctx context.Context) error {
 			return err
 		}
 
-		return r.loadSaver.Save(r.filename, out)
+		return jsonmutexdb.Save(ctx, r.loadSaver, r.filename, out, r.reload)
 	}
 	return nil
 }
 
 const (
-	ns                        = "user/storage/jsonmutexdb."
-	opSave                    = ns + "Save"
-	opFindByID                = ns + "FindByID"
-	opDeleteByID              = ns + "DeleteByID"
-	opFindByIdentifier        = ns + "FindByIdentifier"
-	opFindByConfirmationToken = ns + "FindByConfirmationToken"
-	opFindByRecoveryToken     = ns + "FindByRecoveryToken"
+	ns                            = "user/storage/jsonmutexdb."
+	opSave                        = ns + "Save"
+	opSaveAll                     = ns + "SaveAll"
+	opFindByID                    = ns + "FindByID"
+	opDeleteByID                  = ns + "DeleteByID"
+	opPurgeByID                   = ns + "PurgeByID"
+	opFindDeletedByID             = ns + "FindDeletedByID"
+	opFindByIdentifier            = ns + "FindByIdentifier"
+	opFindByConfirmationToken     = ns + "FindByConfirmationToken"
+	opFindByRecoveryToken         = ns + "FindByRecoveryToken"
+	opFindByDeletionToken         = ns + "FindByDeletionToken"
+	opFindAllScheduledForDeletion = ns + "FindAllScheduledForDeletion"
 )
 
 func (r *jsonMutexDBUserRepository) Save(ctx context.Context, entity *user.User) (*user.User, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	inS, err := r.prepare(entity)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	err = inS.Revise(r.findActive(inS.ID))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	r.put(inS)
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	savedEntity := schema.UserFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *jsonMutexDBUserRepository) SaveAll(ctx context.Context, entities []*user.User) ([]*user.User, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inSs := make([]*schema.User, 0, len(entities))
+
+	// nothing is stored unless all entities are valid
+	for _, entity := range entities {
+		inS, err := r.prepare(entity)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		inSs = append(inSs, inS)
+	}
+
+	savedEntities := make([]*user.User, 0, len(inSs))
+
+	for _, inS := range inSs {
+		stored := r.findActive(inS.ID)
+
+		// imported users replace the stored ones, whatever their revision
+		inS.Replace(stored)
+
+		if inS.DeletedAt != nil {
+			// keep tombstone
+			if stored != nil {
+				r.deleteIndexes(stored)
+			}
+
+			r.db[inS.ID] = *inS
+		} else {
+			r.put(inS)
+		}
+
+		savedEntities = append(savedEntities, schema.UserFromSchema(inS))
+	}
+
+	err := r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+	}
+
+	return savedEntities, nil
+}
+
+// prepare validates the entity and converts it for storage.
+func (r *jsonMutexDBUserRepository) prepare(entity *user.User) (*schema.User, error) {
 	inS := schema.UserToSchema(entity)
 
 	err := r.validate.Struct(inS)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", opSave, err)
+		return nil, err
 	}
 
 	// before save
 	err = inS.BeforeSave()
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", opSave, err)
+		return nil, err
 	}
 
 	if inS.CreatedAt.IsZero() {
@@ -135,6 +234,26 @@ func (r *jsonMutexDBUserRepository) Save(ctx context.Context, entity *user.User)
 	}
 	inS.UpdatedAt = time.Now()
 
+	return inS, nil
+}
+
+// findActive returns the stored user, which is not deleted, or nil if there
+// is none.
+func (r *jsonMutexDBUserRepository) findActive(id string) *schema.User {
+	value, ok := r.db[id]
+	if !ok || value.DeletedAt != nil {
+		return nil
+	}
+
+	return &value
+}
+
+// put stores the user and updates its indexes.
+func (r *jsonMutexDBUserRepository) put(inS *schema.User) {
+	if prev, ok := r.db[inS.ID]; ok && prev.DeletionToken != "" && prev.DeletionToken != inS.DeletionToken {
+		delete(r.dbIndexUsersDeletionToken, prev.DeletionToken)
+	}
+
 	r.db[inS.ID] = *inS
 
 	if inS.PasswordHash != "" {
@@ -162,14 +281,9 @@ func (r *jsonMutexDBUserRepository) Save(ctx context.Context, entity *user.User)
 		}
 	}
 
-	err = r.commit(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", opSave, err)
+	if inS.DeletionToken != "" {
+		r.dbIndexUsersDeletionToken[inS.DeletionToken] = inS
 	}
-
-	savedEntity := schema.UserFromSchema(inS)
-
-	return savedEntity, nil
 }
 
 func (r *jsonMutexDBUserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
@@ -177,7 +291,7 @@ func (r *jsonMutexDBUserRepository) FindByID(ctx context.Context, id string) (*u
 	defer r.mu.RUnlock()
 
 	value, ok := r.db[id]
-	if !ok {
+	if !ok || value.DeletedAt != nil {
 		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
 	}
 
@@ -190,12 +304,22 @@ func (r *jsonMutexDBUserRepository) ExistsByID(ctx context.Context, id string) (
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	_, has := r.db[id]
+	value, has := r.db[id]
 
-	return has, nil
+	return has && value.DeletedAt == nil, nil
 }
 
 func (r *jsonMutexDBUserRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return r.findAll(afterCursor, limit, false)
+}
+
+func (r *jsonMutexDBUserRepository) FindAllDeleted(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return r.findAll(afterCursor, limit, true)
+}
+
+// findAll returns either the users or the tombstones in pages, starting
+// after the cursor.
+func (r *jsonMutexDBUserRepository) findAll(afterCursor string, limit int, deleted bool) ([]*user.User, string, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -229,9 +353,12 @@ func (r *jsonMutexDBUserRepository) FindAll(ctx context.Context, afterCursor str
 			continue
 		}
 
-		offset++
-
 		val := r.db[id]
+		if (val.DeletedAt != nil) != deleted {
+			continue
+		}
+
+		offset++
 
 		t := schema.UserFromSchema(&val)
 
@@ -253,48 +380,104 @@ func (r *jsonMutexDBUserRepository) Count(ctx context.Context) (int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	return len(r.db), nil
+	var count int
+	for _, v := range r.db {
+		if v.DeletedAt == nil {
+			count++
+		}
+	}
+
+	return count, nil
 }
 
 func (r *jsonMutexDBUserRepository) DeleteByID(ctx context.Context, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	value, ok := r.db[id]
-	if !ok {
+	if !ok || value.DeletedAt != nil {
 		return nil
 	}
 
-	entity := schema.UserFromSchema(&value)
+	r.deleteIndexes(&value)
 
-	// delete main value
-	delete(r.db, id)
+	// keep tombstone
+	now := time.Now()
+	value.DeletedAt = &now
 
-	// delete from index
-	delete(r.dbIndexUsersIdentifier, entity.NormalizedUsername)
-	delete(r.dbIndexUsersIdentifier, entity.Email)
+	r.db[id] = value
 
-	if entity.ConfirmationToken != "" {
-		delete(r.dbIndexUsersConfirmationToken, entity.ConfirmationToken)
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteByID, err)
 	}
 
-	if entity.RecoveryToken != "" {
-		delete(r.dbIndexUsersRecoveryToken, entity.RecoveryToken)
+	return nil
+}
+
+func (r *jsonMutexDBUserRepository) PurgeByID(ctx context.Context, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value, ok := r.db[id]
+	if !ok {
+		return nil
+	}
+
+	if value.DeletedAt == nil {
+		r.deleteIndexes(&value)
 	}
 
+	// delete main value
+	delete(r.db, id)
+
 	err := r.commit(ctx)
 	if err != nil {
-		return fmt.Errorf("%s: %w", opDeleteByID, err)
+		return fmt.Errorf("%s: %w", opPurgeByID, err)
 	}
 
 	return nil
 }
 
+func (r *jsonMutexDBUserRepository) FindDeletedByID(ctx context.Context, id string) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, ok := r.db[id]
+	if !ok || value.DeletedAt == nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindDeletedByID, id, database.ErrNotFound)
+	}
+
+	entity := schema.UserFromSchema(&value)
+
+	return entity, nil
+}
+
+func (r *jsonMutexDBUserRepository) deleteIndexes(value *schema.User) {
+	delete(r.dbIndexUsersIdentifier, value.NormalizedUsername)
+	delete(r.dbIndexUsersIdentifier, value.Email)
+
+	if value.ConfirmationToken != "" {
+		delete(r.dbIndexUsersConfirmationToken, value.ConfirmationToken)
+	}
+
+	if value.RecoveryToken != "" {
+		delete(r.dbIndexUsersRecoveryToken, value.RecoveryToken)
+	}
+
+	if value.DeletionToken != "" {
+		delete(r.dbIndexUsersDeletionToken, value.DeletionToken)
+	}
+}
+
 func (r *jsonMutexDBUserRepository) Delete(ctx context.Context, entity *user.User) error {
 	return r.DeleteByID(ctx, entity.ID)
 }
 
 func (r *jsonMutexDBUserRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -302,6 +485,7 @@ func (r *jsonMutexDBUserRepository) DeleteAll(ctx context.Context) error {
 	r.dbIndexUsersIdentifier = make(map[string]*schema.User)
 	r.dbIndexUsersConfirmationToken = make(map[string]*schema.User)
 	r.dbIndexUsersRecoveryToken = make(map[string]*schema.User)
+	r.dbIndexUsersDeletionToken = make(map[string]*schema.User)
 
 	return nil
 }
@@ -351,3 +535,53 @@ func (r *jsonMutexDBUserRepository) FindByRecoveryToken(ctx context.Context, tok
 
 	return r.FindByID(ctx, rtValue.ID)
 }
+
+func (r *jsonMutexDBUserRepository) FindByDeletionToken(ctx context.Context, token string) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dtValue, ok := r.dbIndexUsersDeletionToken[token]
+	if !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByDeletionToken, token, database.ErrNotFound)
+	}
+
+	return r.FindByID(ctx, dtValue.ID)
+}
+
+func (r *jsonMutexDBUserRepository) FindAllScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.User
+		nextCursor string
+	)
+
+	tokens := []string{}
+	for token := range r.dbIndexUsersDeletionToken {
+		if token > afterCursor {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Strings(tokens)
+
+	for _, token := range tokens {
+		val, ok := r.db[r.dbIndexUsersDeletionToken[token].ID]
+		if !ok {
+			continue
+		}
+
+		result = append(result, schema.UserFromSchema(&val))
+
+		if len(result) == limit {
+			nextCursor = token
+			break
+		}
+	}
+
+	return result, nextCursor, nil
+}
@@ -0,0 +1,219 @@
+package jsonmutexdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/noop"
+)
+
+const (
+	userVersionsPrefix = "user_versions"
+)
+
+type jsonMutexDBUserVersionRepository struct {
+	noop.UnimplementedUserVersionRepository
+
+	// db holds the versions of every user, oldest first
+	db map[string][]schema.Version
+	mu sync.RWMutex
+
+	loadSaver jsonmutexdb.LoadSaver
+	filename  string
+
+	validate *validator.Validate
+}
+
+// NewUserVersionRepository returns a new JSONMutexDB repository.
+func NewUserVersionRepository(
+	loadSaver jsonmutexdb.LoadSaver,
+	filenamePrefix string,
+) (user.UserVersionRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &jsonMutexDBUserVersionRepository{
+		db:        make(map[string][]schema.Version),
+		loadSaver: loadSaver,
+		filename:  fmt.Sprintf("%s%s.json", filenamePrefix, userVersionsPrefix),
+		validate:  validate,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jsonMutexDBUserVersionRepository) load() error {
+	if r.loadSaver != nil {
+		data, err := r.loadSaver.Load(r.filename)
+		if err != nil {
+			return err
+		}
+
+		if len(data) > 0 {
+			err = json.Unmarshal(data, &r.db)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBUserVersionRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string][]schema.Version)
+
+	return r.load()
+}
+
+func (r *jsonMutexDBUserVersionRepository) commit(ctx context.Context) error {
+	if r.loadSaver != nil {
+		out, err := json.Marshal(r.db)
+		if err != nil {
+			return err
+		}
+
+		return jsonmutexdb.Save(ctx, r.loadSaver, r.filename, out, r.reload)
+	}
+	return nil
+}
+
+const (
+	opVersionSave             = ns + "Version.Save"
+	opVersionFindByVersion    = ns + "Version.FindByVersion"
+	opVersionFindAllForUser   = ns + "Version.FindAllForUser"
+	opVersionDeleteAllForUser = ns + "Version.DeleteAllForUser"
+)
+
+func (r *jsonMutexDBUserVersionRepository) Save(ctx context.Context, entity *user.Version) (*user.Version, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.VersionToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	versions := r.db[inS.UserID]
+
+	inS.Version = len(versions) + 1
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	r.db[inS.UserID] = append(versions, *inS)
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	return schema.VersionFromSchema(inS), nil
+}
+
+func (r *jsonMutexDBUserVersionRepository) FindByVersion(ctx context.Context, userID string, version int) (*user.Version, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.db[userID]
+
+	if version < 1 || version > len(versions) {
+		return nil, fmt.Errorf("%s(%s, %d): %w", opVersionFindByVersion, userID, version, database.ErrNotFound)
+	}
+
+	return schema.VersionFromSchema(&versions[version-1]), nil
+}
+
+func (r *jsonMutexDBUserVersionRepository) FindAllForUser(ctx context.Context, userID string, afterCursor string, limit int) ([]*user.Version, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.Version
+		nextCursor string
+	)
+
+	versions := r.db[userID]
+
+	// newest first
+	start := len(versions)
+	if afterCursor != "" {
+		cursor, err := strconv.Atoi(afterCursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): invalid cursor: %w", opVersionFindAllForUser, afterCursor, err)
+		}
+
+		if cursor-1 < start {
+			start = cursor - 1
+		}
+	}
+
+	for i := start - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, schema.VersionFromSchema(&versions[i]))
+	}
+
+	if len(result) == limit {
+		nextCursor = strconv.Itoa(result[len(result)-1].Version)
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *jsonMutexDBUserVersionRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[userID]; !ok {
+		return nil
+	}
+
+	delete(r.db, userID)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opVersionDeleteAllForUser, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBUserVersionRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string][]schema.Version)
+
+	return nil
+}
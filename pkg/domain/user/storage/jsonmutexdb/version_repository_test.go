@@ -0,0 +1,22 @@
+package jsonmutexdb_test
+
+import (
+	"testing"
+
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/test"
+)
+
+func TestJSONMutexDBUserVersionRepository(t *testing.T) {
+	test.RunVersion(t, func() func(t *testing.T) (user.UserVersionRepository, func()) {
+		return func(t *testing.T) (user.UserVersionRepository, func()) {
+			repo, err := jsonmutexdb.NewUserVersionRepository(nil, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, func() {}
+		}
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
 	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/transformer"
@@ -20,9 +21,11 @@ import (
 
 const (
 	usersPrefix                       = "users"
+	deletedUsersPrefix                = "deleted_users"
 	usersIdentifierIndexPrefix        = "index_users_identifier"
 	usersConfirmationTokenIndexPrefix = "index_users_confirmation_token"
 	usersRecoveryTokenIndexPrefix     = "index_users_recovery_token"
+	usersDeletionTokenIndexPrefix     = "index_users_deletion_token"
 )
 
 // levelDBUserRepository is a repository that uses LevelDB database.
@@ -33,9 +36,11 @@ type levelDBUserRepository struct {
 	mu sync.Mutex
 
 	usersKeyspace                       string
+	deletedUsersKeyspace                string
 	usersIdentifierIndexKeyspace        string
 	usersConfirmationTokenIndexKeyspace string
 	usersRecoveryTokenIndexKeyspace     string
+	usersDeletionTokenIndexKeyspace     string
 
 	validate *validator.Validate
 }
@@ -51,9 +56,11 @@ func NewUserRepository(
 	r := &levelDBUserRepository{
 		db:                                  db,
 		usersKeyspace:                       keyPrefix + usersPrefix,
+		deletedUsersKeyspace:                keyPrefix + deletedUsersPrefix,
 		usersIdentifierIndexKeyspace:        keyPrefix + usersIdentifierIndexPrefix,
 		usersConfirmationTokenIndexKeyspace: keyPrefix + usersConfirmationTokenIndexPrefix,
 		usersRecoveryTokenIndexKeyspace:     keyPrefix + usersRecoveryTokenIndexPrefix,
+		usersDeletionTokenIndexKeyspace:     keyPrefix + usersDeletionTokenIndexPrefix,
 		validate:                            validate,
 	}
 
@@ -61,38 +68,125 @@ func NewUserRepository(
 }
 
 const (
-	ns                        = "user/storage/leveldb."
-	opSave                    = ns + "Save"
-	opFindByID                = ns + "FindByID"
-	opExistsByID              = ns + "ExistsByID"
-	opFindAll                 = ns + "FindAll"
-	opCount                   = ns + "Count"
-	opDeleteByID              = ns + "DeleteByID"
-	opExistsByIdentifier      = ns + "ExistsByIdentifier"
-	opFindByIdentifier        = ns + "FindByIdentifier"
-	opFindByConfirmationToken = ns + "FindByConfirmationToken"
-	opFindByRecoveryToken     = ns + "FindByRecoveryToken"
+	ns                            = "user/storage/leveldb."
+	opSave                        = ns + "Save"
+	opSaveAll                     = ns + "SaveAll"
+	opFindByID                    = ns + "FindByID"
+	opExistsByID                  = ns + "ExistsByID"
+	opFindAll                     = ns + "FindAll"
+	opFindAllDeleted              = ns + "FindAllDeleted"
+	opCount                       = ns + "Count"
+	opDeleteByID                  = ns + "DeleteByID"
+	opPurgeByID                   = ns + "PurgeByID"
+	opFindDeletedByID             = ns + "FindDeletedByID"
+	opExistsByIdentifier          = ns + "ExistsByIdentifier"
+	opFindByIdentifier            = ns + "FindByIdentifier"
+	opFindByConfirmationToken     = ns + "FindByConfirmationToken"
+	opFindByRecoveryToken         = ns + "FindByRecoveryToken"
+	opFindByDeletionToken         = ns + "FindByDeletionToken"
+	opFindAllScheduledForDeletion = ns + "FindAllScheduledForDeletion"
 )
 
 func (r *levelDBUserRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
-	return r.db.Write(batch, nil)
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
 }
 
 func (r *levelDBUserRepository) Save(ctx context.Context, entity *user.User) (*user.User, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	inS, err := r.prepare(entity)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	stored, err := r.findActive(ctx, inS.ID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = inS.Revise(stored)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	batch := new(leveldb.Batch)
+
+	err = r.put(ctx, batch, inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	savedEntity := schema.UserFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *levelDBUserRepository) SaveAll(ctx context.Context, entities []*user.User) ([]*user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	inSs := make([]*schema.User, 0, len(entities))
+
+	for _, entity := range entities {
+		inS, err := r.prepare(entity)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		stored, err := r.findActive(ctx, inS.ID)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s): %w", opSaveAll, inS.ID, err)
+		}
+
+		// imported users replace the stored ones, whatever their revision
+		inS.Replace(stored)
+
+		if inS.DeletedAt != nil {
+			err = r.putDeleted(batch, stored, inS)
+		} else {
+			err = r.put(ctx, batch, inS)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", opSaveAll, inS.ID, err)
+		}
+
+		inSs = append(inSs, inS)
+	}
+
+	err := r.commit(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+	}
+
+	savedEntities := make([]*user.User, 0, len(inSs))
+	for _, inS := range inSs {
+		savedEntities = append(savedEntities, schema.UserFromSchema(inS))
+	}
+
+	return savedEntities, nil
+}
+
+// prepare validates the entity and converts it for storage.
+func (r *levelDBUserRepository) prepare(entity *user.User) (*schema.User, error) {
 	inS := schema.UserToSchema(entity)
 
 	err := r.validate.Struct(inS)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", opSave, err)
+		return nil, err
 	}
 
 	// before save
 	err = inS.BeforeSave()
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", opSave, err)
+		return nil, err
 	}
 
 	if inS.CreatedAt.IsZero() {
@@ -100,17 +194,40 @@ func (r *levelDBUserRepository) Save(ctx context.Context, entity *user.User) (*u
 	}
 	inS.UpdatedAt = time.Now()
 
+	return inS, nil
+}
+
+// putDeleted writes the tombstone of the user to the batch, and deletes the
+// stored user, if any.
+func (r *levelDBUserRepository) putDeleted(batch *leveldb.Batch, stored, ts *schema.User) error {
+	if stored != nil {
+		r.deleteActive(batch, stored)
+	}
+
+	value, err := transformer.MarshalUser(ts)
+	if err != nil {
+		return err
+	}
+
+	batch.Put([]byte(transformer.MarshalUserKey(r.deletedUsersKeyspace, ts.ID)), value)
+
+	return nil
+}
+
+// put writes the user and its indexes to the batch.
+func (r *levelDBUserRepository) put(ctx context.Context, batch *leveldb.Batch, inS *schema.User) error {
 	key := transformer.MarshalUserKey(r.usersKeyspace, inS.ID)
 
 	value, err := transformer.MarshalUser(inS)
 	if err != nil {
-		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+		return err
 	}
 
-	batch := new(leveldb.Batch)
-
 	batch.Put([]byte(key), value)
 
+	// saving a deleted user restores it
+	batch.Delete([]byte(transformer.MarshalUserKey(r.deletedUsersKeyspace, inS.ID)))
+
 	if inS.PasswordHash != "" {
 		// put base fields only in index
 		usernameKey := transformer.MarshalUserKey(r.usersIdentifierIndexKeyspace, inS.NormalizedUsername)
@@ -123,7 +240,7 @@ func (r *levelDBUserRepository) Save(ctx context.Context, entity *user.User) (*u
 
 		partialValue, err := transformer.MarshalUser(&partialUser)
 		if err != nil {
-			return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+			return err
 		}
 
 		batch.Put([]byte(usernameKey), partialValue)
@@ -140,14 +257,14 @@ func (r *levelDBUserRepository) Save(ctx context.Context, entity *user.User) (*u
 
 		partialValue, err := transformer.MarshalUser(&partialUser)
 		if err != nil {
-			return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+			return err
 		}
 
 		batch.Put([]byte(ctKey), partialValue)
 	} else {
-		has, err := r.db.Has([]byte(ctKey), nil)
+		has, err := database_leveldb.From(ctx, r.db).Has([]byte(ctKey), nil)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %w", opSave, err)
+			return err
 		}
 
 		if has {
@@ -165,14 +282,14 @@ func (r *levelDBUserRepository) Save(ctx context.Context, entity *user.User) (*u
 
 		partialValue, err := transformer.MarshalUser(&partialUser)
 		if err != nil {
-			return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+			return err
 		}
 
 		batch.Put([]byte(rtKey), partialValue)
 	} else {
-		has, err := r.db.Has([]byte(rtKey), nil)
+		has, err := database_leveldb.From(ctx, r.db).Has([]byte(rtKey), nil)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %w", opSave, err)
+			return err
 		}
 
 		if has {
@@ -180,20 +297,45 @@ func (r *levelDBUserRepository) Save(ctx context.Context, entity *user.User) (*u
 		}
 	}
 
-	err = r.commit(ctx, batch)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", opSave, err)
+	prevValue, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return err
 	}
 
-	savedEntity := schema.UserFromSchema(inS)
+	if err == nil {
+		prev, err := transformer.UnmarshalUser(prevValue)
+		if err != nil {
+			return err
+		}
 
-	return savedEntity, nil
+		if prev.DeletionToken != "" && prev.DeletionToken != inS.DeletionToken {
+			batch.Delete([]byte(transformer.MarshalUserKey(r.usersDeletionTokenIndexKeyspace, prev.DeletionToken)))
+		}
+	}
+
+	if inS.DeletionToken != "" {
+		partialUser := schema.User{
+			ID:                  inS.ID,
+			DeletionToken:       inS.DeletionToken,
+			DeletionRequestedAt: inS.DeletionRequestedAt,
+		}
+
+		partialValue, err := transformer.MarshalUser(&partialUser)
+		if err != nil {
+			return err
+		}
+
+		dtKey := transformer.MarshalUserKey(r.usersDeletionTokenIndexKeyspace, inS.DeletionToken)
+		batch.Put([]byte(dtKey), partialValue)
+	}
+
+	return nil
 }
 
 func (r *levelDBUserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
 	key := transformer.MarshalUserKey(r.usersKeyspace, id)
 
-	value, err := r.db.Get([]byte(key), nil)
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
 	if err != nil {
 		if errors.Is(err, leveldb.ErrNotFound) {
 			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
@@ -215,7 +357,7 @@ func (r *levelDBUserRepository) FindByID(ctx context.Context, id string) (*user.
 func (r *levelDBUserRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
 	key := transformer.MarshalUserKey(r.usersKeyspace, id)
 
-	has, err := r.db.Has([]byte(key), nil)
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(key), nil)
 	if err != nil {
 		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
 	}
@@ -224,6 +366,16 @@ func (r *levelDBUserRepository) ExistsByID(ctx context.Context, id string) (bool
 }
 
 func (r *levelDBUserRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return r.findAll(ctx, opFindAll, r.usersKeyspace, afterCursor, limit)
+}
+
+func (r *levelDBUserRepository) FindAllDeleted(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return r.findAll(ctx, opFindAllDeleted, r.deletedUsersKeyspace, afterCursor, limit)
+}
+
+// findAll returns the users of the keyspace in pages, starting after the
+// cursor.
+func (r *levelDBUserRepository) findAll(ctx context.Context, op, keyspace, afterCursor string, limit int) ([]*user.User, string, error) {
 	if limit <= 0 {
 		limit = 25
 	}
@@ -234,16 +386,16 @@ func (r *levelDBUserRepository) FindAll(ctx context.Context, afterCursor string,
 		nextCursor string
 	)
 
-	iter := r.db.NewIterator(util.BytesPrefix([]byte(r.usersKeyspace)), nil)
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(keyspace+"/")), nil)
 	defer iter.Release()
 
 	if afterCursor != "" {
-		key := transformer.MarshalUserKey(r.usersKeyspace, afterCursor)
+		key := transformer.MarshalUserKey(keyspace, afterCursor)
 
 		if ok := iter.Seek([]byte(key)); !ok {
 			err := iter.Error()
 			if err != nil {
-				return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+				return nil, "", fmt.Errorf("%s(%s): %w", op, afterCursor, err)
 			}
 		}
 	}
@@ -253,7 +405,7 @@ func (r *levelDBUserRepository) FindAll(ctx context.Context, afterCursor string,
 
 		ts, err := transformer.UnmarshalUser(iter.Value())
 		if err != nil {
-			return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, string(iter.Key()), err)
+			return nil, "", fmt.Errorf("%s(%s): %w", op, string(iter.Key()), err)
 		}
 
 		t := schema.UserFromSchema(ts)
@@ -267,7 +419,7 @@ func (r *levelDBUserRepository) FindAll(ctx context.Context, afterCursor string,
 
 	err := iter.Error()
 	if err != nil {
-		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+		return nil, "", fmt.Errorf("%s: %w", op, err)
 	}
 
 	if len(result) == limit {
@@ -283,7 +435,7 @@ func (r *levelDBUserRepository) Count(ctx context.Context) (int, error) {
 		ctxCheckOffset int
 	)
 
-	iter := r.db.NewIterator(util.BytesPrefix([]byte(r.usersKeyspace)), nil)
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.usersKeyspace+"/")), nil)
 	defer iter.Release()
 
 	for iter.Next() {
@@ -312,58 +464,137 @@ func (r *levelDBUserRepository) DeleteByID(ctx context.Context, id string) error
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	key := transformer.MarshalUserKey(r.usersKeyspace, id)
-
-	value, err := r.db.Get([]byte(key), nil)
+	ts, err := r.findActive(ctx, id)
 	if err != nil {
-		if errors.Is(err, leveldb.ErrNotFound) {
+		if errors.Is(err, database.ErrNotFound) {
 			return nil
 		}
 
 		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
 	}
 
-	ts, err := transformer.UnmarshalUser(value)
+	batch := new(leveldb.Batch)
+
+	r.deleteActive(batch, ts)
+
+	// keep tombstone
+	now := time.Now()
+	ts.DeletedAt = &now
+
+	value, err := transformer.MarshalUser(ts)
 	if err != nil {
 		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
 	}
 
-	entity := schema.UserFromSchema(ts)
+	batch.Put([]byte(transformer.MarshalUserKey(r.deletedUsersKeyspace, id)), value)
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteByID, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBUserRepository) Delete(ctx context.Context, entity *user.User) error {
+	return r.DeleteByID(ctx, entity.ID)
+}
+
+func (r *levelDBUserRepository) PurgeByID(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	batch := new(leveldb.Batch)
 
+	ts, err := r.findActive(ctx, id)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return fmt.Errorf("%s(%s): %w", opPurgeByID, id, err)
+	}
+
+	if ts != nil {
+		r.deleteActive(batch, ts)
+	}
+
+	batch.Delete([]byte(transformer.MarshalUserKey(r.deletedUsersKeyspace, id)))
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opPurgeByID, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBUserRepository) FindDeletedByID(ctx context.Context, id string) (*user.User, error) {
+	key := transformer.MarshalUserKey(r.deletedUsersKeyspace, id)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindDeletedByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindDeletedByID, id, err)
+	}
+
+	ts, err := transformer.UnmarshalUser(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindDeletedByID, id, err)
+	}
+
+	entity := schema.UserFromSchema(ts)
+
+	return entity, nil
+}
+
+// findActive returns the stored user, which is not deleted.
+func (r *levelDBUserRepository) findActive(ctx context.Context, id string) (*schema.User, error) {
+	key := transformer.MarshalUserKey(r.usersKeyspace, id)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, database.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return transformer.UnmarshalUser(value)
+}
+
+// deleteActive deletes the user and its indexes.
+func (r *levelDBUserRepository) deleteActive(batch *leveldb.Batch, ts *schema.User) {
 	// delete main value
-	batch.Delete([]byte(key))
+	batch.Delete([]byte(transformer.MarshalUserKey(r.usersKeyspace, ts.ID)))
 
 	// delete from index
-	usernameKey := transformer.MarshalUserKey(r.usersIdentifierIndexKeyspace, entity.NormalizedUsername)
-	emailKey := transformer.MarshalUserKey(r.usersIdentifierIndexKeyspace, entity.Email)
+	usernameKey := transformer.MarshalUserKey(r.usersIdentifierIndexKeyspace, ts.NormalizedUsername)
+	emailKey := transformer.MarshalUserKey(r.usersIdentifierIndexKeyspace, ts.Email)
 
 	batch.Delete([]byte(usernameKey))
 	batch.Delete([]byte(emailKey))
 
-	if entity.ConfirmationToken != "" {
-		ctKey := transformer.MarshalUserKey(r.usersConfirmationTokenIndexKeyspace, entity.ConfirmationToken)
+	if ts.ConfirmationToken != "" {
+		ctKey := transformer.MarshalUserKey(r.usersConfirmationTokenIndexKeyspace, ts.ConfirmationToken)
 		batch.Delete([]byte(ctKey))
 	}
 
-	if entity.RecoveryToken != "" {
-		rtKey := transformer.MarshalUserKey(r.usersRecoveryTokenIndexKeyspace, entity.RecoveryToken)
+	if ts.RecoveryToken != "" {
+		rtKey := transformer.MarshalUserKey(r.usersRecoveryTokenIndexKeyspace, ts.RecoveryToken)
 		batch.Delete([]byte(rtKey))
 	}
 
-	err = r.commit(ctx, batch)
-	if err != nil {
-		return fmt.Errorf("%s: %w", opDeleteByID, err)
+	if ts.DeletionToken != "" {
+		dtKey := transformer.MarshalUserKey(r.usersDeletionTokenIndexKeyspace, ts.DeletionToken)
+		batch.Delete([]byte(dtKey))
 	}
-
-	return nil
 }
 
 func (r *levelDBUserRepository) ExistsByIdentifier(ctx context.Context, identifier string) (bool, error) {
 	identifierKey := transformer.MarshalUserKey(r.usersIdentifierIndexKeyspace, identifier)
 
-	has, err := r.db.Has([]byte(identifierKey), nil)
+	has, err := database_leveldb.From(ctx, r.db).Has([]byte(identifierKey), nil)
 	if err != nil {
 		return false, fmt.Errorf("%s(%s): %w", opExistsByIdentifier, identifier, err)
 	}
@@ -375,7 +606,7 @@ func (r *levelDBUserRepository) FindByIdentifier(ctx context.Context, identifier
 	// find using index first
 	identifierKey := transformer.MarshalUserKey(r.usersIdentifierIndexKeyspace, identifier)
 
-	identifierValue, err := r.db.Get([]byte(identifierKey), nil)
+	identifierValue, err := database_leveldb.From(ctx, r.db).Get([]byte(identifierKey), nil)
 	if err != nil {
 		if errors.Is(err, leveldb.ErrNotFound) {
 			return nil, fmt.Errorf("%s(%s): %w", opFindByIdentifier, identifier, database.ErrNotFound)
@@ -395,7 +626,7 @@ func (r *levelDBUserRepository) FindByIdentifier(ctx context.Context, identifier
 func (r *levelDBUserRepository) FindByConfirmationToken(ctx context.Context, token string) (*user.User, error) {
 	ctKey := transformer.MarshalUserKey(r.usersConfirmationTokenIndexKeyspace, token)
 
-	ctValue, err := r.db.Get([]byte(ctKey), nil)
+	ctValue, err := database_leveldb.From(ctx, r.db).Get([]byte(ctKey), nil)
 	if err != nil {
 		if errors.Is(err, leveldb.ErrNotFound) {
 			return nil, fmt.Errorf("%s(%s): %w", opFindByConfirmationToken, token, database.ErrNotFound)
@@ -415,7 +646,7 @@ func (r *levelDBUserRepository) FindByConfirmationToken(ctx context.Context, tok
 func (r *levelDBUserRepository) FindByRecoveryToken(ctx context.Context, token string) (*user.User, error) {
 	rtKey := transformer.MarshalUserKey(r.usersRecoveryTokenIndexKeyspace, token)
 
-	rtValue, err := r.db.Get([]byte(rtKey), nil)
+	rtValue, err := database_leveldb.From(ctx, r.db).Get([]byte(rtKey), nil)
 	if err != nil {
 		if errors.Is(err, leveldb.ErrNotFound) {
 			return nil, fmt.Errorf("%s(%s): %w", opFindByRecoveryToken, token, database.ErrNotFound)
@@ -431,3 +662,70 @@ func (r *levelDBUserRepository) FindByRecoveryToken(ctx context.Context, token s
 
 	return r.FindByID(ctx, rtTs.ID)
 }
+
+func (r *levelDBUserRepository) FindByDeletionToken(ctx context.Context, token string) (*user.User, error) {
+	dtKey := transformer.MarshalUserKey(r.usersDeletionTokenIndexKeyspace, token)
+
+	dtValue, err := database_leveldb.From(ctx, r.db).Get([]byte(dtKey), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByDeletionToken, token, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByDeletionToken, token, err)
+	}
+
+	dtTs, err := transformer.UnmarshalUser(dtValue)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByDeletionToken, token, err)
+	}
+
+	return r.FindByID(ctx, dtTs.ID)
+}
+
+func (r *levelDBUserRepository) FindAllScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.User
+		nextCursor string
+	)
+
+	prefix := transformer.MarshalUserKey(r.usersDeletionTokenIndexKeyspace, "")
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	defer iter.Release()
+
+	ok := iter.First()
+	if afterCursor != "" {
+		// the first key after the cursor
+		ok = iter.Seek([]byte(prefix + afterCursor + "\x00"))
+	}
+
+	for ; ok && len(result) < limit; ok = iter.Next() {
+		dtTs, err := transformer.UnmarshalUser(iter.Value())
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opFindAllScheduledForDeletion, string(iter.Key()), err)
+		}
+
+		entity, err := r.FindByID(ctx, dtTs.ID)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAllScheduledForDeletion, err)
+		}
+
+		result = append(result, entity)
+
+		if len(result) == limit {
+			nextCursor = dtTs.DeletionToken
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAllScheduledForDeletion, err)
+	}
+
+	return result, nextCursor, nil
+}
@@ -1,8 +1,13 @@
 package leveldb_test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/config"
 	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	"github.com/zbiljic/authzy/pkg/domain/user/storage/leveldb"
@@ -23,3 +28,37 @@ func TestLevelDBUserRepository(t *testing.T) {
 		}
 	})
 }
+
+func TestLevelDBUserRepositoryTenant(t *testing.T) {
+	ctx := context.Background()
+
+	db, cleanup := database_leveldb.Fixture()
+	defer cleanup()
+
+	repo, err := leveldb.NewUserRepository(db, test.TestPrefix)
+	require.NoError(t, err)
+
+	// the keys of the tenant named after the keyspace start with it
+	tenantRepo, err := leveldb.NewUserRepository(db, test.TestPrefix+"users"+config.TenantStoragePrefixSeparator)
+	require.NoError(t, err)
+
+	_, err = tenantRepo.Save(ctx, &user.User{
+		ID:                 "1",
+		Email:              "user@test.com",
+		Username:           "username",
+		NormalizedUsername: "username",
+	})
+	require.NoError(t, err)
+
+	users, _, err := repo.FindAll(ctx, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	users, _, err = tenantRepo.FindAll(ctx, "", 0)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+}
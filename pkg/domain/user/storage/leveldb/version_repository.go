@@ -0,0 +1,234 @@
+package leveldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/noop"
+)
+
+const (
+	userVersionsPrefix = "user_versions"
+)
+
+// levelDBUserVersionRepository is a repository that uses LevelDB database.
+type levelDBUserVersionRepository struct {
+	noop.UnimplementedUserVersionRepository
+
+	db *leveldb.DB
+	mu sync.Mutex
+
+	userVersionsKeyspace string
+
+	validate *validator.Validate
+}
+
+// NewUserVersionRepository returns a new LevelDB repository.
+func NewUserVersionRepository(
+	db *leveldb.DB,
+	keyPrefix string,
+) (user.UserVersionRepository, error) {
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &levelDBUserVersionRepository{
+		db:                   db,
+		userVersionsKeyspace: keyPrefix + userVersionsPrefix,
+		validate:             validate,
+	}
+
+	return r, nil
+}
+
+const (
+	opVersionSave             = ns + "Version.Save"
+	opVersionFindByVersion    = ns + "Version.FindByVersion"
+	opVersionFindAllForUser   = ns + "Version.FindAllForUser"
+	opVersionDeleteAllForUser = ns + "Version.DeleteAllForUser"
+	opVersionDeleteAll        = ns + "Version.DeleteAll"
+)
+
+func (r *levelDBUserVersionRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
+}
+
+// userVersionsKeyPrefix returns the prefix of all versions of the user.
+func (r *levelDBUserVersionRepository) userVersionsKeyPrefix(userID string) string {
+	return transformer.MarshalUserKey(r.userVersionsKeyspace, userID) + "/"
+}
+
+func (r *levelDBUserVersionRepository) Save(ctx context.Context, entity *user.Version) (*user.Version, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.VersionToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	// the next version follows the latest one
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.userVersionsKeyPrefix(inS.UserID))), nil)
+
+	inS.Version = 1
+	if iter.Last() {
+		latest, err := transformer.UnmarshalVersion(iter.Value())
+		if err != nil {
+			iter.Release()
+			return nil, fmt.Errorf("%s(%s): %w", opVersionSave, inS.UserID, err)
+		}
+
+		inS.Version = latest.Version + 1
+	}
+
+	iter.Release()
+
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opVersionSave, inS.UserID, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	value, err := transformer.MarshalVersion(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opVersionSave, inS.UserID, err)
+	}
+
+	batch := new(leveldb.Batch)
+
+	batch.Put([]byte(transformer.MarshalVersionKey(r.userVersionsKeyspace, inS.UserID, inS.Version)), value)
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opVersionSave, inS.UserID, err)
+	}
+
+	return schema.VersionFromSchema(inS), nil
+}
+
+func (r *levelDBUserVersionRepository) FindByVersion(ctx context.Context, userID string, version int) (*user.Version, error) {
+	key := transformer.MarshalVersionKey(r.userVersionsKeyspace, userID, version)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, fmt.Errorf("%s(%s, %d): %w", opVersionFindByVersion, userID, version, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s, %d): %w", opVersionFindByVersion, userID, version, err)
+	}
+
+	ts, err := transformer.UnmarshalVersion(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s, %d): %w", opVersionFindByVersion, userID, version, err)
+	}
+
+	return schema.VersionFromSchema(ts), nil
+}
+
+func (r *levelDBUserVersionRepository) FindAllForUser(ctx context.Context, userID string, afterCursor string, limit int) ([]*user.Version, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.Version
+		nextCursor string
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.userVersionsKeyPrefix(userID))), nil)
+	defer iter.Release()
+
+	// newest first
+	ok := iter.Last()
+	if afterCursor != "" {
+		cursor, err := strconv.Atoi(afterCursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): invalid cursor: %w", opVersionFindAllForUser, afterCursor, err)
+		}
+
+		key := transformer.MarshalVersionKey(r.userVersionsKeyspace, userID, cursor)
+
+		// positions at the cursor, or after it, so the previous entry is
+		// the first one older than the cursor
+		if iter.Seek([]byte(key)) {
+			ok = iter.Prev()
+		} else {
+			ok = iter.Last()
+		}
+	}
+
+	for ; ok && len(result) < limit; ok = iter.Prev() {
+		ts, err := transformer.UnmarshalVersion(iter.Value())
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opVersionFindAllForUser, string(iter.Key()), err)
+		}
+
+		result = append(result, schema.VersionFromSchema(ts))
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opVersionFindAllForUser, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = strconv.Itoa(result[len(result)-1].Version)
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *levelDBUserVersionRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	return r.deletePrefix(ctx, r.userVersionsKeyPrefix(userID), opVersionDeleteAllForUser)
+}
+
+func (r *levelDBUserVersionRepository) DeleteAll(ctx context.Context) error {
+	return r.deletePrefix(ctx, r.userVersionsKeyspace+"/", opVersionDeleteAll)
+}
+
+func (r *levelDBUserVersionRepository) deletePrefix(ctx context.Context, prefix, op string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	for iter.Next() {
+		batch.Delete(append([]byte{}, iter.Key()...))
+	}
+	iter.Release()
+
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err := r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/test"
+)
+
+func TestLevelDBUserVersionRepository(t *testing.T) {
+	test.RunVersion(t, func() func(t *testing.T) (user.UserVersionRepository, func()) {
+		return func(t *testing.T) (user.UserVersionRepository, func()) {
+			db, cleanup := database_leveldb.Fixture()
+
+			repo, err := leveldb.NewUserVersionRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -16,6 +16,10 @@ func (*UnimplementedUserRepository) Save(ctx context.Context, entity *user.User)
 	panic("Save not implemented")
 }
 
+func (*UnimplementedUserRepository) SaveAll(ctx context.Context, entities []*user.User) ([]*user.User, error) {
+	panic("SaveAll not implemented")
+}
+
 func (*UnimplementedUserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
 	panic("FindByID not implemented")
 }
@@ -40,6 +44,18 @@ func (*UnimplementedUserRepository) Delete(ctx context.Context, entity *user.Use
 	panic("Delete not implemented")
 }
 
+func (*UnimplementedUserRepository) PurgeByID(ctx context.Context, id string) error {
+	panic("PurgeByID not implemented")
+}
+
+func (*UnimplementedUserRepository) FindDeletedByID(ctx context.Context, id string) (*user.User, error) {
+	panic("FindDeletedByID not implemented")
+}
+
+func (*UnimplementedUserRepository) FindAllDeleted(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	panic("FindAllDeleted not implemented")
+}
+
 func (*UnimplementedUserRepository) DeleteAll(ctx context.Context) error {
 	panic("DeleteAll not implemented")
 }
@@ -59,3 +75,11 @@ func (*UnimplementedUserRepository) FindByConfirmationToken(ctx context.Context,
 func (*UnimplementedUserRepository) FindByRecoveryToken(ctx context.Context, token string) (*user.User, error) {
 	panic("FindByRecoveryToken not implemented")
 }
+
+func (*UnimplementedUserRepository) FindByDeletionToken(ctx context.Context, token string) (*user.User, error) {
+	panic("FindByDeletionToken not implemented")
+}
+
+func (*UnimplementedUserRepository) FindAllScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	panic("FindAllScheduledForDeletion not implemented")
+}
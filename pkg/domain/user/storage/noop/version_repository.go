@@ -0,0 +1,33 @@
+package noop
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/user"
+)
+
+// Compile-time proof of interface implementation.
+var _ user.UserVersionRepository = (*UnimplementedUserVersionRepository)(nil)
+
+// UnimplementedUserVersionRepository can be embedded to have forward compatible implementations.
+type UnimplementedUserVersionRepository struct{}
+
+func (*UnimplementedUserVersionRepository) Save(ctx context.Context, entity *user.Version) (*user.Version, error) {
+	panic("Save not implemented")
+}
+
+func (*UnimplementedUserVersionRepository) FindByVersion(ctx context.Context, userID string, version int) (*user.Version, error) {
+	panic("FindByVersion not implemented")
+}
+
+func (*UnimplementedUserVersionRepository) FindAllForUser(ctx context.Context, userID string, afterCursor string, limit int) ([]*user.Version, string, error) {
+	panic("FindAllForUser not implemented")
+}
+
+func (*UnimplementedUserVersionRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	panic("DeleteAllForUser not implemented")
+}
+
+func (*UnimplementedUserVersionRepository) DeleteAll(ctx context.Context) error {
+	panic("DeleteAll not implemented")
+}
@@ -0,0 +1,534 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/noop"
+)
+
+const (
+	usersTable        = "users"
+	deletedUsersTable = "deleted_users"
+)
+
+// sqliteUserRepository is a repository that uses SQLite database.
+type sqliteUserRepository struct {
+	noop.UnimplementedUserRepository
+
+	db *database_sqlite.DB
+
+	usersTable        string
+	deletedUsersTable string
+
+	validate *validator.Validate
+}
+
+// NewUserRepository returns a new SQLite repository.
+func NewUserRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (user.UserRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &sqliteUserRepository{
+		db:                db,
+		usersTable:        database_sqlite.Table(tablePrefix, usersTable),
+		deletedUsersTable: database_sqlite.Table(tablePrefix, deletedUsersTable),
+		validate:          validate,
+	}
+
+	return r, nil
+}
+
+const (
+	ns                            = "user/storage/sqlite."
+	opSave                        = ns + "Save"
+	opSaveAll                     = ns + "SaveAll"
+	opFindByID                    = ns + "FindByID"
+	opExistsByID                  = ns + "ExistsByID"
+	opFindAll                     = ns + "FindAll"
+	opFindAllDeleted              = ns + "FindAllDeleted"
+	opCount                       = ns + "Count"
+	opDeleteByID                  = ns + "DeleteByID"
+	opPurgeByID                   = ns + "PurgeByID"
+	opFindDeletedByID             = ns + "FindDeletedByID"
+	opExistsByIdentifier          = ns + "ExistsByIdentifier"
+	opFindByIdentifier            = ns + "FindByIdentifier"
+	opFindByConfirmationToken     = ns + "FindByConfirmationToken"
+	opFindByRecoveryToken         = ns + "FindByRecoveryToken"
+	opFindByDeletionToken         = ns + "FindByDeletionToken"
+	opFindAllScheduledForDeletion = ns + "FindAllScheduledForDeletion"
+)
+
+func (r *sqliteUserRepository) Save(ctx context.Context, entity *user.User) (*user.User, error) {
+	inS, err := r.prepare(entity)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	err = r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		stored, err := r.findActive(ctx, tx, inS.ID)
+		if err != nil {
+			return err
+		}
+
+		err = inS.Revise(stored)
+		if err != nil {
+			return err
+		}
+
+		return r.put(ctx, tx, inS)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.UserFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *sqliteUserRepository) SaveAll(ctx context.Context, entities []*user.User) ([]*user.User, error) {
+	inSs := make([]*schema.User, 0, len(entities))
+
+	for _, entity := range entities {
+		inS, err := r.prepare(entity)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+		}
+
+		inSs = append(inSs, inS)
+	}
+
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, inS := range inSs {
+			stored, err := r.findActive(ctx, tx, inS.ID)
+			if err != nil {
+				return fmt.Errorf("%s: %w", inS.ID, err)
+			}
+
+			// imported users replace the stored ones, whatever their
+			// revision
+			inS.Replace(stored)
+
+			if inS.DeletedAt != nil {
+				err = r.putDeleted(ctx, tx, inS)
+			} else {
+				err = r.put(ctx, tx, inS)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", inS.ID, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSaveAll, err)
+	}
+
+	savedEntities := make([]*user.User, 0, len(inSs))
+	for _, inS := range inSs {
+		savedEntities = append(savedEntities, schema.UserFromSchema(inS))
+	}
+
+	return savedEntities, nil
+}
+
+// prepare validates the entity and converts it for storage.
+func (r *sqliteUserRepository) prepare(entity *user.User) (*schema.User, error) {
+	inS := schema.UserToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, err
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, err
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	return inS, nil
+}
+
+// put writes the user, with the columns it is looked up by.
+func (r *sqliteUserRepository) put(ctx context.Context, q database_sqlite.Querier, inS *schema.User) error {
+	value, err := transformer.MarshalUser(inS)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.usersTable+` (
+			id, email, normalized_username, has_password,
+			confirmation_token, recovery_token, deletion_token, data
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		inS.ID,
+		inS.Email,
+		inS.NormalizedUsername,
+		inS.PasswordHash != "",
+		nullString(inS.ConfirmationToken),
+		nullString(inS.RecoveryToken),
+		nullString(inS.DeletionToken),
+		string(value),
+	)
+	if err != nil {
+		return err
+	}
+
+	// saving a deleted user restores it
+	_, err = q.ExecContext(ctx, `DELETE FROM `+r.deletedUsersTable+` WHERE id = ?`, inS.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// putDeleted writes the tombstone of the user, and deletes the stored user,
+// if any.
+func (r *sqliteUserRepository) putDeleted(ctx context.Context, q database_sqlite.Querier, ts *schema.User) error {
+	_, err := q.ExecContext(ctx, `DELETE FROM `+r.usersTable+` WHERE id = ?`, ts.ID)
+	if err != nil {
+		return err
+	}
+
+	value, err := transformer.MarshalUser(ts)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.deletedUsersTable+` (id, data) VALUES (?, ?)`,
+		ts.ID, string(value),
+	)
+
+	return err
+}
+
+// nullString returns NULL for the empty string, so it is not found by the
+// lookups.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// findActive returns the stored user, which is not deleted, or nil if there
+// is none.
+func (r *sqliteUserRepository) findActive(ctx context.Context, q database_sqlite.Querier, id string) (*schema.User, error) {
+	ts, err := r.findOne(ctx, q, `SELECT data FROM `+r.usersTable+` WHERE id = ?`, id)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+
+	return ts, err
+}
+
+// findOne returns the user found by the query, which selects the data.
+func (r *sqliteUserRepository) findOne(ctx context.Context, q database_sqlite.Querier, query string, args ...interface{}) (*schema.User, error) {
+	var value string
+
+	err := q.QueryRowContext(ctx, query, args...).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return transformer.UnmarshalUser([]byte(value))
+}
+
+func (r *sqliteUserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
+	ts, err := r.findOne(ctx, r.db, `SELECT data FROM `+r.usersTable+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.UserFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *sqliteUserRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	var has bool
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+r.usersTable+` WHERE id = ?)`,
+		id,
+	).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByID, id, err)
+	}
+
+	return has, nil
+}
+
+func (r *sqliteUserRepository) FindAll(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return r.findAll(ctx, opFindAll, r.usersTable, afterCursor, limit)
+}
+
+func (r *sqliteUserRepository) FindAllDeleted(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return r.findAll(ctx, opFindAllDeleted, r.deletedUsersTable, afterCursor, limit)
+}
+
+// findAll returns the users of the table in pages, starting after the
+// cursor.
+func (r *sqliteUserRepository) findAll(ctx context.Context, op, table, afterCursor string, limit int) ([]*user.User, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.User
+		nextCursor string
+	)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT data FROM `+table+` WHERE id > ? ORDER BY id LIMIT ?`,
+		afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", op, afterCursor, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		ts, err := transformer.UnmarshalUser([]byte(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		result = append(result, schema.UserFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteUserRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.usersTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqliteUserRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		ts, err := r.findOne(ctx, tx, `SELECT data FROM `+r.usersTable+` WHERE id = ?`, id)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM `+r.usersTable+` WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+
+		// keep tombstone
+		now := time.Now()
+		ts.DeletedAt = &now
+
+		value, err := transformer.MarshalUser(ts)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO `+r.deletedUsersTable+` (id, data) VALUES (?, ?)`,
+			id, string(value),
+		)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteUserRepository) Delete(ctx context.Context, entity *user.User) error {
+	return r.DeleteByID(ctx, entity.ID)
+}
+
+func (r *sqliteUserRepository) PurgeByID(ctx context.Context, id string) error {
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM `+r.usersTable+` WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM `+r.deletedUsersTable+` WHERE id = ?`, id)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opPurgeByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteUserRepository) FindDeletedByID(ctx context.Context, id string) (*user.User, error) {
+	ts, err := r.findOne(ctx, r.db, `SELECT data FROM `+r.deletedUsersTable+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindDeletedByID, id, err)
+	}
+
+	entity := schema.UserFromSchema(ts)
+
+	return entity, nil
+}
+
+func (r *sqliteUserRepository) ExistsByIdentifier(ctx context.Context, identifier string) (bool, error) {
+	var has bool
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM `+r.usersTable+`
+			WHERE has_password AND (email = ? OR normalized_username = ?)
+		)`,
+		identifier, identifier,
+	).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("%s(%s): %w", opExistsByIdentifier, identifier, err)
+	}
+
+	return has, nil
+}
+
+func (r *sqliteUserRepository) FindByIdentifier(ctx context.Context, identifier string) (*user.User, error) {
+	ts, err := r.findOne(ctx, r.db,
+		`SELECT data FROM `+r.usersTable+`
+		WHERE has_password AND (email = ? OR normalized_username = ?)
+		LIMIT 1`,
+		identifier, identifier,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByIdentifier, identifier, err)
+	}
+
+	return schema.UserFromSchema(ts), nil
+}
+
+func (r *sqliteUserRepository) FindByConfirmationToken(ctx context.Context, token string) (*user.User, error) {
+	ts, err := r.findOne(ctx, r.db, `SELECT data FROM `+r.usersTable+` WHERE confirmation_token = ? LIMIT 1`, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByConfirmationToken, token, err)
+	}
+
+	return schema.UserFromSchema(ts), nil
+}
+
+func (r *sqliteUserRepository) FindByRecoveryToken(ctx context.Context, token string) (*user.User, error) {
+	ts, err := r.findOne(ctx, r.db, `SELECT data FROM `+r.usersTable+` WHERE recovery_token = ? LIMIT 1`, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByRecoveryToken, token, err)
+	}
+
+	return schema.UserFromSchema(ts), nil
+}
+
+func (r *sqliteUserRepository) FindByDeletionToken(ctx context.Context, token string) (*user.User, error) {
+	ts, err := r.findOne(ctx, r.db, `SELECT data FROM `+r.usersTable+` WHERE deletion_token = ? LIMIT 1`, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByDeletionToken, token, err)
+	}
+
+	return schema.UserFromSchema(ts), nil
+}
+
+func (r *sqliteUserRepository) FindAllScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.User
+		nextCursor string
+	)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT data FROM `+r.usersTable+` WHERE deletion_token > ? ORDER BY deletion_token LIMIT ?`,
+		afterCursor, limit,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAllScheduledForDeletion, afterCursor, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAllScheduledForDeletion, err)
+		}
+
+		ts, err := transformer.UnmarshalUser([]byte(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", opFindAllScheduledForDeletion, err)
+		}
+
+		result = append(result, schema.UserFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAllScheduledForDeletion, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].DeletionToken
+	}
+
+	return result, nextCursor, nil
+}
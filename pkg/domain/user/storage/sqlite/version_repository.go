@@ -0,0 +1,213 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/noop"
+)
+
+const (
+	userVersionsTable = "user_versions"
+)
+
+// sqliteUserVersionRepository is a repository that uses SQLite database.
+type sqliteUserVersionRepository struct {
+	noop.UnimplementedUserVersionRepository
+
+	db *database_sqlite.DB
+
+	userVersionsTable string
+
+	validate *validator.Validate
+}
+
+// NewUserVersionRepository returns a new SQLite repository.
+func NewUserVersionRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (user.UserVersionRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+	schema.RegisterValidators(validate)
+
+	r := &sqliteUserVersionRepository{
+		db:                db,
+		userVersionsTable: database_sqlite.Table(tablePrefix, userVersionsTable),
+		validate:          validate,
+	}
+
+	return r, nil
+}
+
+const (
+	opVersionSave             = ns + "Version.Save"
+	opVersionFindByVersion    = ns + "Version.FindByVersion"
+	opVersionFindAllForUser   = ns + "Version.FindAllForUser"
+	opVersionDeleteAllForUser = ns + "Version.DeleteAllForUser"
+	opVersionDeleteAll        = ns + "Version.DeleteAll"
+)
+
+func (r *sqliteUserVersionRepository) Save(ctx context.Context, entity *user.Version) (*user.Version, error) {
+	inS := schema.VersionToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opVersionSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+
+	err = r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		// the next version follows the latest one
+		err := tx.QueryRowContext(ctx,
+			`SELECT COALESCE(MAX(version), 0) + 1 FROM `+r.userVersionsTable+` WHERE user_id = ?`,
+			inS.UserID,
+		).Scan(&inS.Version)
+		if err != nil {
+			return err
+		}
+
+		value, err := transformer.MarshalVersion(inS)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO `+r.userVersionsTable+` (user_id, version, data) VALUES (?, ?, ?)`,
+			inS.UserID, inS.Version, string(value),
+		)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opVersionSave, inS.UserID, err)
+	}
+
+	return schema.VersionFromSchema(inS), nil
+}
+
+func (r *sqliteUserVersionRepository) FindByVersion(ctx context.Context, userID string, version int) (*user.Version, error) {
+	var value string
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT data FROM `+r.userVersionsTable+` WHERE user_id = ? AND version = ?`,
+		userID, version,
+	).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s(%s, %d): %w", opVersionFindByVersion, userID, version, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s, %d): %w", opVersionFindByVersion, userID, version, err)
+	}
+
+	ts, err := transformer.UnmarshalVersion([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s, %d): %w", opVersionFindByVersion, userID, version, err)
+	}
+
+	return schema.VersionFromSchema(ts), nil
+}
+
+func (r *sqliteUserVersionRepository) FindAllForUser(ctx context.Context, userID string, afterCursor string, limit int) ([]*user.Version, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*user.Version
+		nextCursor string
+	)
+
+	query := `SELECT data FROM ` + r.userVersionsTable + ` WHERE user_id = ?`
+	args := []interface{}{userID}
+
+	if afterCursor != "" {
+		cursor, err := strconv.Atoi(afterCursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): invalid cursor: %w", opVersionFindAllForUser, afterCursor, err)
+		}
+
+		query += ` AND version < ?`
+		args = append(args, cursor)
+	}
+
+	// newest first
+	query += ` ORDER BY version DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opVersionFindAllForUser, userID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opVersionFindAllForUser, userID, err)
+		}
+
+		ts, err := transformer.UnmarshalVersion([]byte(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opVersionFindAllForUser, userID, err)
+		}
+
+		result = append(result, schema.VersionFromSchema(ts))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opVersionFindAllForUser, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = strconv.Itoa(result[len(result)-1].Version)
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteUserVersionRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.userVersionsTable+` WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opVersionDeleteAllForUser, userID, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteUserVersionRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.userVersionsTable)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opVersionDeleteAll, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/user/storage/test"
+)
+
+func TestSQLiteUserVersionRepository(t *testing.T) {
+	test.RunVersion(t, func() func(t *testing.T) (user.UserVersionRepository, func()) {
+		return func(t *testing.T) (user.UserVersionRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewUserVersionRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
@@ -77,6 +78,12 @@ func Run(t *testing.T, f func() func(t *testing.T) (user.UserRepository, func())
 
 		testUserRepositorySave(t, repo)
 	})
+	t.Run("SaveAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserRepositorySaveAll(t, repo)
+	})
 	t.Run("FindByID", func(t *testing.T) {
 		repo, cleanup := f()(t)
 		defer cleanup()
@@ -107,6 +114,30 @@ func Run(t *testing.T, f func() func(t *testing.T) (user.UserRepository, func())
 
 		testUserRepositoryDeleteByID(t, repo)
 	})
+	t.Run("PurgeByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserRepositoryPurgeByID(t, repo)
+	})
+	t.Run("FindByDeletionToken", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserRepositoryFindByDeletionToken(t, repo)
+	})
+	t.Run("FindAllScheduledForDeletion", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserRepositoryFindAllScheduledForDeletion(t, repo)
+	})
+	t.Run("Revision", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserRepositoryRevision(t, repo)
+	})
 }
 
 func testUserRepositorySave(t *testing.T, repo user.UserRepository) {
@@ -146,6 +177,90 @@ func testUserRepositorySave(t *testing.T, repo user.UserRepository) {
 	})
 }
 
+func testUserRepositorySaveAll(t *testing.T, repo user.UserRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("invalid", func(t *testing.T) {
+		entities := []*user.User{
+			{
+				ID:                 "0",
+				Email:              "user_0@test.com",
+				PasswordHash:       "password_0",
+				NormalizedUsername: "username_0",
+				Username:           "username_0",
+			},
+			{},
+		}
+
+		_, err := repo.SaveAll(ctx, entities)
+		assert.Error(t, err)
+
+		has, err := repo.ExistsByID(ctx, "0")
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		var entities []*user.User
+		for i := 0; i < 3; i++ {
+			entities = append(entities, &user.User{
+				ID:                 strconv.Itoa(i),
+				Email:              fmt.Sprintf("user_%d@test.com", i),
+				PasswordHash:       fmt.Sprintf("password_%d", i),
+				Username:           fmt.Sprintf("username_%d", i),
+				NormalizedUsername: fmt.Sprintf("username_%d", i),
+			})
+		}
+
+		savedEntities, err := repo.SaveAll(ctx, entities)
+		require.NoError(t, err)
+		require.Len(t, savedEntities, len(entities))
+
+		for _, entity := range savedEntities {
+			dbEntity, err := repo.FindByID(ctx, entity.ID)
+			require.NoError(t, err)
+			assertUserEqual(t, entity, dbEntity)
+
+			dbEntity, err = repo.FindByIdentifier(ctx, entity.Email)
+			require.NoError(t, err)
+			assert.Equal(t, entity.ID, dbEntity.ID)
+		}
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		deletedAt := time.Now().Add(-time.Hour)
+
+		// replaces the stored user with its tombstone
+		entities := []*user.User{
+			{
+				ID:                 "0",
+				Email:              "user_0@test.com",
+				PasswordHash:       "password_0",
+				Username:           "username_0",
+				NormalizedUsername: "username_0",
+				DeletedAt:          &deletedAt,
+			},
+		}
+
+		_, err := repo.SaveAll(ctx, entities)
+		require.NoError(t, err)
+
+		_, err = repo.FindByID(ctx, "0")
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		_, err = repo.FindByIdentifier(ctx, "user_0@test.com")
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		found, err := repo.FindDeletedByID(ctx, "0")
+		require.NoError(t, err)
+		require.NotNil(t, found.DeletedAt)
+		assert.Equal(t, deletedAt.Unix(), found.DeletedAt.Unix())
+		assert.Equal(t, "password_0", found.PasswordHash)
+	})
+}
+
 func testUserRepositoryFindByID(t *testing.T, repo user.UserRepository) {
 	t.Helper()
 
@@ -285,4 +400,275 @@ func testUserRepositoryDeleteByID(t *testing.T, repo user.UserRepository) {
 
 		assert.False(t, exists)
 	})
+
+	t.Run("excluded from lookups", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, users[0].ID)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		_, err = repo.FindByIdentifier(ctx, users[0].Email)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		exists, err := repo.ExistsByIdentifier(ctx, users[0].NormalizedUsername)
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		all, _, err := repo.FindAll(ctx, "", 0)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+
+		count, err := repo.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("tombstone", func(t *testing.T) {
+		_, err := repo.FindDeletedByID(ctx, "non_existent_id")
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		found, err := repo.FindDeletedByID(ctx, users[0].ID)
+		require.NoError(t, err)
+
+		assertUserEqual(t, users[0], found)
+		assert.True(t, found.IsDeleted())
+
+		all, nextCursor, err := repo.FindAllDeleted(ctx, "", 0)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, users[0].ID, all[0].ID)
+		assert.Empty(t, nextCursor)
+	})
+
+	t.Run("restored by save", func(t *testing.T) {
+		_, err := repo.Save(ctx, users[0])
+		require.NoError(t, err)
+
+		found, err := repo.FindByIdentifier(ctx, users[0].Email)
+		require.NoError(t, err)
+
+		assert.Equal(t, users[0].ID, found.ID)
+		assert.False(t, found.IsDeleted())
+
+		_, err = repo.FindDeletedByID(ctx, users[0].ID)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		all, _, err := repo.FindAllDeleted(ctx, "", 0)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+}
+
+func testUserRepositoryPurgeByID(t *testing.T, repo user.UserRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	users := createUsers(t, repo, 2)
+
+	t.Run("non existent", func(t *testing.T) {
+		err := repo.PurgeByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.PurgeByID(ctx, users[0].ID)
+		require.NoError(t, err)
+
+		_, err = repo.FindByID(ctx, users[0].ID)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		_, err = repo.FindByIdentifier(ctx, users[0].Email)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, users[1].ID)
+		require.NoError(t, err)
+
+		err = repo.PurgeByID(ctx, users[1].ID)
+		require.NoError(t, err)
+
+		count, err := repo.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
+
+func testUserRepositoryFindAllScheduledForDeletion(t *testing.T, repo user.UserRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	users := createUsers(t, repo, 5)
+
+	t.Run("none", func(t *testing.T) {
+		all, nextCursor, err := repo.FindAllScheduledForDeletion(ctx, "", 0)
+		require.NoError(t, err)
+
+		assert.Empty(t, all)
+		assert.Empty(t, nextCursor)
+	})
+
+	now := time.Now()
+
+	// scheduled in the reverse order of their IDs
+	for i, entity := range users[1:4] {
+		entity.DeletionToken = fmt.Sprintf("token_%d", 3-i)
+		entity.DeletionRequestedAt = &now
+
+		_, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+	}
+
+	// deleted users are not scheduled anymore
+	err := repo.DeleteByID(ctx, users[3].ID)
+	require.NoError(t, err)
+
+	t.Run("pages", func(t *testing.T) {
+		page, nextCursor, err := repo.FindAllScheduledForDeletion(ctx, "", 1)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+
+		assert.Equal(t, users[2].ID, page[0].ID)
+		assert.Equal(t, "token_2", page[0].DeletionToken)
+		assert.Equal(t, "token_2", nextCursor)
+
+		page, _, err = repo.FindAllScheduledForDeletion(ctx, nextCursor, 1)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+
+		assert.Equal(t, users[1].ID, page[0].ID)
+		assert.Equal(t, "token_3", page[0].DeletionToken)
+	})
+
+	t.Run("after purged cursor", func(t *testing.T) {
+		err := repo.PurgeByID(ctx, users[2].ID)
+		require.NoError(t, err)
+
+		page, _, err := repo.FindAllScheduledForDeletion(ctx, "token_2", 0)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+
+		assert.Equal(t, users[1].ID, page[0].ID)
+	})
+}
+
+func testUserRepositoryFindByDeletionToken(t *testing.T, repo user.UserRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	users := createUsers(t, repo, 2)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByDeletionToken(ctx, "non_existent_token")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	now := time.Now()
+
+	entity := users[0]
+	entity.DeletionToken = "token_1"
+	entity.DeletionRequestedAt = &now
+
+	entity, err := repo.Save(ctx, entity)
+	require.NoError(t, err)
+
+	t.Run("ok", func(t *testing.T) {
+		found, err := repo.FindByDeletionToken(ctx, "token_1")
+		require.NoError(t, err)
+
+		assert.Equal(t, entity.ID, found.ID)
+		assert.Equal(t, "token_1", found.DeletionToken)
+		require.NotNil(t, found.DeletionRequestedAt)
+		assert.Equal(t, now.Unix(), found.DeletionRequestedAt.Unix())
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		entity.DeletionToken = "token_2"
+
+		var err error
+		entity, err = repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		_, err = repo.FindByDeletionToken(ctx, "token_1")
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		found, err := repo.FindByDeletionToken(ctx, "token_2")
+		require.NoError(t, err)
+
+		assert.Equal(t, entity.ID, found.ID)
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		entity.DeletionToken = ""
+		entity.DeletionRequestedAt = nil
+
+		_, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		_, err = repo.FindByDeletionToken(ctx, "token_2")
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		other := users[1]
+		other.DeletionToken = "token_3"
+
+		_, err := repo.Save(ctx, other)
+		require.NoError(t, err)
+
+		err = repo.DeleteByID(ctx, other.ID)
+		require.NoError(t, err)
+
+		_, err = repo.FindByDeletionToken(ctx, "token_3")
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+}
+
+func testUserRepositoryRevision(t *testing.T, repo user.UserRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	users := createUsers(t, repo, 1)
+
+	entity := users[0]
+	assert.Equal(t, int64(1), entity.Revision)
+
+	stale := *entity
+
+	entity.Name = "first"
+
+	saved, err := repo.Save(ctx, entity)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), saved.Revision)
+
+	t.Run("conflict", func(t *testing.T) {
+		stale.Name = "second"
+
+		_, err := repo.Save(ctx, &stale)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, database.ErrConflict))
+
+		found, err := repo.FindByID(ctx, entity.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", found.Name)
+		assert.Equal(t, int64(2), found.Revision)
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		stale.Name = "imported"
+
+		saved, err := repo.SaveAll(ctx, []*user.User{&stale})
+		require.NoError(t, err)
+		require.Len(t, saved, 1)
+		assert.Equal(t, int64(3), saved[0].Revision)
+
+		found, err := repo.FindByID(ctx, entity.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "imported", found.Name)
+		assert.Equal(t, int64(3), found.Revision)
+	})
 }
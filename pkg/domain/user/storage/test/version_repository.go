@@ -0,0 +1,209 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+)
+
+func createVersions(t *testing.T, repo user.UserVersionRepository, userID string, count int) []*user.Version {
+	t.Helper()
+
+	var result []*user.Version
+
+	ctx := context.Background()
+
+	for i := 0; i < count; i++ {
+		entity := &user.Version{
+			UserID:  userID,
+			Action:  user.VersionActionUpdate,
+			ActorID: "actor",
+			Changes: []user.Change{{Field: "given_name", To: fmt.Sprintf("Name %d", i)}},
+			User: &user.User{
+				ID:                 userID,
+				Email:              "user@test.com",
+				PasswordHash:       "password",
+				Username:           "username",
+				NormalizedUsername: "username",
+				GivenName:          fmt.Sprintf("Name %d", i),
+			},
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		result = append(result, savedEntity)
+	}
+
+	return result
+}
+
+func RunVersion(t *testing.T, f func() func(t *testing.T) (user.UserVersionRepository, func())) {
+	t.Helper()
+
+	t.Run("init", func(t *testing.T) {
+		_, cleanup := f()(t)
+		defer cleanup()
+	})
+	t.Run("Save", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserVersionRepositorySave(t, repo)
+	})
+	t.Run("FindByVersion", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserVersionRepositoryFindByVersion(t, repo)
+	})
+	t.Run("FindAllForUser", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserVersionRepositoryFindAllForUser(t, repo)
+	})
+	t.Run("DeleteAllForUser", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testUserVersionRepositoryDeleteAllForUser(t, repo)
+	})
+}
+
+func testUserVersionRepositorySave(t *testing.T, repo user.UserVersionRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := repo.Save(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := repo.Save(ctx, &user.Version{
+			UserID: "user",
+			Action: "invalid",
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("numbered", func(t *testing.T) {
+		versions := createVersions(t, repo, "user1", 2)
+		others := createVersions(t, repo, "user2", 1)
+
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, 2, versions[1].Version)
+		assert.Equal(t, 1, others[0].Version)
+		assert.False(t, versions[0].CreatedAt.IsZero())
+	})
+}
+
+func testUserVersionRepositoryFindByVersion(t *testing.T, repo user.UserVersionRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	createVersions(t, repo, "user1", 2)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByVersion(ctx, "user1", 3)
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		_, err = repo.FindByVersion(ctx, "user2", 1)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		found, err := repo.FindByVersion(ctx, "user1", 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, "user1", found.UserID)
+		assert.Equal(t, 2, found.Version)
+		assert.Equal(t, user.VersionActionUpdate, found.Action)
+		assert.Equal(t, "actor", found.ActorID)
+		require.Len(t, found.Changes, 1)
+		assert.Equal(t, "given_name", found.Changes[0].Field)
+		assert.Equal(t, "Name 1", found.Changes[0].To)
+		require.NotNil(t, found.User)
+		assert.Equal(t, "Name 1", found.User.GivenName)
+		assert.Equal(t, "password", found.User.PasswordHash)
+	})
+}
+
+func testUserVersionRepositoryFindAllForUser(t *testing.T, repo user.UserVersionRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	createVersions(t, repo, "user1", 12)
+	createVersions(t, repo, "user2", 1)
+
+	t.Run("empty", func(t *testing.T) {
+		found, nextCursor, err := repo.FindAllForUser(ctx, "user3", "", 0)
+		require.NoError(t, err)
+
+		assert.Empty(t, found)
+		assert.Equal(t, "", nextCursor)
+	})
+
+	t.Run("newest first", func(t *testing.T) {
+		var (
+			versions []int
+			cursor   string
+		)
+
+		for {
+			found, nextCursor, err := repo.FindAllForUser(ctx, "user1", cursor, 5)
+			require.NoError(t, err)
+
+			for _, v := range found {
+				assert.Equal(t, "user1", v.UserID)
+				versions = append(versions, v.Version)
+			}
+
+			if nextCursor == "" {
+				break
+			}
+			cursor = nextCursor
+		}
+
+		assert.Equal(t, []int{12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}, versions)
+	})
+}
+
+func testUserVersionRepositoryDeleteAllForUser(t *testing.T, repo user.UserVersionRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	createVersions(t, repo, "user1", 2)
+	createVersions(t, repo, "user2", 1)
+
+	err := repo.DeleteAllForUser(ctx, "user1")
+	require.NoError(t, err)
+
+	found, _, err := repo.FindAllForUser(ctx, "user1", "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	found, _, err = repo.FindAllForUser(ctx, "user2", "", 0)
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	t.Run("numbering restarts", func(t *testing.T) {
+		versions := createVersions(t, repo, "user1", 1)
+
+		assert.Equal(t, 1, versions[0].Version)
+	})
+}
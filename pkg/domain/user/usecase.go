@@ -12,8 +12,17 @@ type UserUsecase interface {
 	// UpdateUser updates existing user.
 	UpdateUser(context.Context, *User) (*User, error)
 
-	// UpdatePassword updates existing user password.
-	UpdatePassword(ctx context.Context, id string, password []byte) (*User, error)
+	// UpdatePassword updates existing user password. The user is saved at
+	// the revision it was read, see User.Revision.
+	UpdatePassword(ctx context.Context, user *User, password []byte) (*User, error)
+
+	// HashPassword hashes the password, to be stored by UpdatePasswordHash.
+	// Hashing is slow, so it is done before a transaction is started.
+	HashPassword(ctx context.Context, password []byte) ([]byte, error)
+
+	// UpdatePasswordHash updates existing user password with the hash
+	// returned by HashPassword.
+	UpdatePasswordHash(ctx context.Context, id string, passwordHash []byte) (*User, error)
 
 	// ConfirmUser confirms existing user.
 	ConfirmUser(ctx context.Context, id string) (*User, error)
@@ -24,6 +33,42 @@ type UserUsecase interface {
 	// ConfirmEmailChange confirms the change of email for a user.
 	ConfirmEmailChange(context.Context, *User) (*User, error)
 
+	// CancelDeletion restores the user whose deletion was scheduled.
+	CancelDeletion(context.Context, *User) (*User, error)
+
+	// DeleteUser soft deletes existing user, which can be restored from its
+	// history.
+	DeleteUser(ctx context.Context, id string) error
+
+	// PurgeUser irreversibly deletes the user together with its history.
+	PurgeUser(ctx context.Context, id string) error
+
+	// FindAllUserVersions retrieves the history of the user in pages, newest
+	// first, starting after the cursor.
+	FindAllUserVersions(ctx context.Context, id string, afterCursor string, limit int) ([]*Version, string, error)
+
+	// FindUserVersion retrieves the version of the user.
+	FindUserVersion(ctx context.Context, id string, version int) (*Version, error)
+
+	// RestoreUserVersion restores the user, including a deleted one, to the
+	// state of the version.
+	RestoreUserVersion(ctx context.Context, id string, version int) (*User, error)
+
+	// ImportUsers saves the users at once, as they are, including their
+	// password hashes. Users which already exist with the same ID, email or
+	// username are resolved by the conflict policy, and the skipped ones are
+	// nil in the result. Imported users start without history.
+	ImportUsers(ctx context.Context, users []*User, opts ImportOptions) ([]*User, error)
+
+	// BlockUser prevents the user from signing in.
+	BlockUser(ctx context.Context, id string) (*User, error)
+
+	// UnblockUser allows a blocked user to sign in again.
+	UnblockUser(ctx context.Context, id string) (*User, error)
+
+	// FindAllUsers retrieves users in pages, starting after the cursor.
+	FindAllUsers(ctx context.Context, afterCursor string, limit int) ([]*User, string, error)
+
 	// FindUserByID retrieves an user by ID.
 	FindUserByID(context.Context, string) (*User, error)
 
@@ -36,6 +81,13 @@ type UserUsecase interface {
 	// FindUserByRecoveryToken finds a user with the matching recovery token.
 	FindUserByRecoveryToken(context.Context, string) (*User, error)
 
+	// FindUserByDeletionToken finds a user with the matching deletion token.
+	FindUserByDeletionToken(context.Context, string) (*User, error)
+
+	// FindAllUsersScheduledForDeletion retrieves the users whose deletion
+	// is scheduled in pages, starting after the cursor.
+	FindAllUsersScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*User, string, error)
+
 	// Authenticate a user using password.
 	Authenticate(ctx context.Context, identifier string, password []byte) (*User, error)
 
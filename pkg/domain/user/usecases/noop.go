@@ -21,10 +21,18 @@ func (*noopUserUsecase) UpdateUser(ctx context.Context, user *user.User) (*user.
 	panic("UpdateUser not implemented")
 }
 
-func (*noopUserUsecase) UpdatePassword(ctx context.Context, id string, password []byte) (*user.User, error) {
+func (*noopUserUsecase) UpdatePassword(ctx context.Context, user *user.User, password []byte) (*user.User, error) {
 	panic("UpdatePassword not implemented")
 }
 
+func (*noopUserUsecase) HashPassword(ctx context.Context, password []byte) ([]byte, error) {
+	panic("HashPassword not implemented")
+}
+
+func (*noopUserUsecase) UpdatePasswordHash(ctx context.Context, id string, passwordHash []byte) (*user.User, error) {
+	panic("UpdatePasswordHash not implemented")
+}
+
 func (*noopUserUsecase) ConfirmUser(ctx context.Context, id string) (*user.User, error) {
 	panic("ConfirmUser not implemented")
 }
@@ -37,6 +45,46 @@ func (*noopUserUsecase) ConfirmEmailChange(ctx context.Context, user *user.User)
 	panic("ConfirmEmailChange not implemented")
 }
 
+func (*noopUserUsecase) CancelDeletion(ctx context.Context, user *user.User) (*user.User, error) {
+	panic("CancelDeletion not implemented")
+}
+
+func (*noopUserUsecase) DeleteUser(ctx context.Context, id string) error {
+	panic("DeleteUser not implemented")
+}
+
+func (*noopUserUsecase) ImportUsers(ctx context.Context, users []*user.User, opts user.ImportOptions) ([]*user.User, error) {
+	panic("ImportUsers not implemented")
+}
+
+func (*noopUserUsecase) PurgeUser(ctx context.Context, id string) error {
+	panic("PurgeUser not implemented")
+}
+
+func (*noopUserUsecase) FindAllUserVersions(ctx context.Context, id string, afterCursor string, limit int) ([]*user.Version, string, error) {
+	panic("FindAllUserVersions not implemented")
+}
+
+func (*noopUserUsecase) FindUserVersion(ctx context.Context, id string, version int) (*user.Version, error) {
+	panic("FindUserVersion not implemented")
+}
+
+func (*noopUserUsecase) RestoreUserVersion(ctx context.Context, id string, version int) (*user.User, error) {
+	panic("RestoreUserVersion not implemented")
+}
+
+func (*noopUserUsecase) BlockUser(ctx context.Context, id string) (*user.User, error) {
+	panic("BlockUser not implemented")
+}
+
+func (*noopUserUsecase) UnblockUser(ctx context.Context, id string) (*user.User, error) {
+	panic("UnblockUser not implemented")
+}
+
+func (*noopUserUsecase) FindAllUsers(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	panic("FindAllUsers not implemented")
+}
+
 func (*noopUserUsecase) FindUserByID(ctx context.Context, id string) (*user.User, error) {
 	panic("FindUserByID not implemented")
 }
@@ -53,6 +101,14 @@ func (*noopUserUsecase) FindUserByRecoveryToken(ctx context.Context, token strin
 	panic("FindUserByRecoveryToken not implemented")
 }
 
+func (*noopUserUsecase) FindUserByDeletionToken(ctx context.Context, token string) (*user.User, error) {
+	panic("FindUserByDeletionToken not implemented")
+}
+
+func (*noopUserUsecase) FindAllUsersScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	panic("FindAllUsersScheduledForDeletion not implemented")
+}
+
 func (*noopUserUsecase) Authenticate(ctx context.Context, identifier string, password []byte) (*user.User, error) {
 	panic("Authenticate not implemented")
 }
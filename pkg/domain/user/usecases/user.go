@@ -11,27 +11,85 @@ import (
 	"github.com/zbiljic/authzy/pkg/database"
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	"github.com/zbiljic/authzy/pkg/hash"
+	"github.com/zbiljic/authzy/pkg/logger"
 	"github.com/zbiljic/authzy/pkg/ulid"
 )
 
 type userUsecase struct {
 	noopUserUsecase
 
-	hasher     hash.Hasher
-	repository user.UserRepository
+	log               logger.Logger
+	hasher            hash.Hasher
+	repository        user.UserRepository
+	versionRepository user.UserVersionRepository
 }
 
 func NewUserUsecase(
+	log logger.Logger,
 	hasher hash.Hasher,
 	repository user.UserRepository,
+	versionRepository user.UserVersionRepository,
 ) user.UserUsecase {
 	uc := &userUsecase{
-		hasher:     hasher,
-		repository: repository,
+		log:               log,
+		hasher:            hasher,
+		repository:        repository,
+		versionRepository: versionRepository,
 	}
 	return uc
 }
 
+// save saves the user, and records the changes in its history. Updates which
+// do not change any tracked fields are not recorded.
+func (uc *userUsecase) save(ctx context.Context, entity *user.User, action user.VersionAction) (*user.User, error) {
+	var prev *user.User
+
+	if action != user.VersionActionCreate {
+		found, err := uc.repository.FindByID(ctx, entity.ID)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			return nil, err
+		}
+
+		prev = found
+	}
+
+	saved, err := uc.repository.Save(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.recordVersion(ctx, action, prev, saved); err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+// update saves the changes to the user, see save.
+func (uc *userUsecase) update(ctx context.Context, entity *user.User) (*user.User, error) {
+	return uc.save(ctx, entity, user.VersionActionUpdate)
+}
+
+func (uc *userUsecase) recordVersion(ctx context.Context, action user.VersionAction, prev, next *user.User) error {
+	changes := user.Diff(prev, next)
+	if len(changes) == 0 && action == user.VersionActionUpdate {
+		return nil
+	}
+
+	_, err := uc.versionRepository.Save(ctx, &user.Version{
+		UserID:  next.ID,
+		Action:  action,
+		ActorID: user.ActorFromContext(ctx),
+		Changes: changes,
+		User:    next.WithoutCredentials(),
+	})
+	if err != nil {
+		return fmt.Errorf("record user version: %w", err)
+	}
+
+	return nil
+}
+
 func (uc *userUsecase) CreateUser(ctx context.Context, entity *user.User) (*user.User, error) {
 	// normalize
 	entity.NormalizedUsername = strings.ToLower(entity.Username)
@@ -78,7 +136,7 @@ func (uc *userUsecase) CreateUser(ctx context.Context, entity *user.User) (*user
 		entity.ValidSince = nil
 	}
 
-	return uc.repository.Save(ctx, entity)
+	return uc.save(ctx, entity, user.VersionActionCreate)
 }
 
 func (uc *userUsecase) UpdateUser(ctx context.Context, entity *user.User) (*user.User, error) {
@@ -91,29 +149,53 @@ func (uc *userUsecase) UpdateUser(ctx context.Context, entity *user.User) (*user
 		return nil, database.ErrNotFound
 	}
 
-	return uc.repository.Save(ctx, entity)
+	return uc.update(ctx, entity)
+}
+
+func (uc *userUsecase) UpdatePassword(ctx context.Context, user *user.User, password []byte) (*user.User, error) {
+	var hashedPassword []byte
+
+	if len(password) > 0 {
+		var err error
+
+		hashedPassword, err = uc.HashPassword(ctx, password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return uc.updatePasswordHash(ctx, user, hashedPassword)
+}
+
+func (uc *userUsecase) HashPassword(ctx context.Context, password []byte) ([]byte, error) {
+	hashedPassword, err := uc.hasher.Generate(ctx, password)
+	if err != nil {
+		return nil, fmt.Errorf("password hash generate: %w", err)
+	}
+
+	return hashedPassword, nil
 }
 
-func (uc *userUsecase) UpdatePassword(ctx context.Context, id string, password []byte) (*user.User, error) {
+func (uc *userUsecase) UpdatePasswordHash(ctx context.Context, id string, passwordHash []byte) (*user.User, error) {
 	user, err := uc.repository.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(password) > 0 {
-		// hash password
-		hashedPassword, err := uc.hasher.Generate(ctx, password)
-		if err != nil {
-			return nil, fmt.Errorf("password hash generate: %w", err)
-		}
+	return uc.updatePasswordHash(ctx, user, passwordHash)
+}
 
-		user.PasswordHash = string(hashedPassword)
+// updatePasswordHash saves the user with the password hash, failing with
+// database.ErrConflict if the user was changed since it was read.
+func (uc *userUsecase) updatePasswordHash(ctx context.Context, user *user.User, passwordHash []byte) (*user.User, error) {
+	if len(passwordHash) > 0 {
+		user.PasswordHash = string(passwordHash)
 
 		now := time.Now()
 		user.PasswordUpdatedAt = &now
 	}
 
-	return uc.repository.Save(ctx, user)
+	return uc.update(ctx, user)
 }
 
 func (uc *userUsecase) ConfirmUser(ctx context.Context, id string) (*user.User, error) {
@@ -131,14 +213,14 @@ func (uc *userUsecase) ConfirmUser(ctx context.Context, id string) (*user.User,
 	user.ConfirmationToken = ""
 	user.ConfirmationSentAt = nil
 
-	return uc.repository.Save(ctx, user)
+	return uc.update(ctx, user)
 }
 
 func (uc *userUsecase) ConfirmRecovery(ctx context.Context, user *user.User) (*user.User, error) {
 	user.RecoveryToken = ""
 	user.RecoverySentAt = nil
 
-	return uc.repository.Save(ctx, user)
+	return uc.update(ctx, user)
 }
 
 func (uc *userUsecase) ConfirmEmailChange(ctx context.Context, user *user.User) (*user.User, error) {
@@ -147,7 +229,223 @@ func (uc *userUsecase) ConfirmEmailChange(ctx context.Context, user *user.User)
 	user.EmailChangeToken = ""
 	user.EmailChangeSentAt = nil
 
-	return uc.repository.Save(ctx, user)
+	return uc.update(ctx, user)
+}
+
+func (uc *userUsecase) CancelDeletion(ctx context.Context, user *user.User) (*user.User, error) {
+	user.DeletionToken = ""
+	user.DeletionRequestedAt = nil
+
+	return uc.update(ctx, user)
+}
+
+func (uc *userUsecase) DeleteUser(ctx context.Context, id string) error {
+	entity, err := uc.repository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repository.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+
+	deleted := *entity
+	now := time.Now()
+	deleted.DeletedAt = &now
+
+	return uc.recordVersion(ctx, user.VersionActionDelete, entity, &deleted)
+}
+
+func (uc *userUsecase) PurgeUser(ctx context.Context, id string) error {
+	if err := uc.repository.PurgeByID(ctx, id); err != nil {
+		return err
+	}
+
+	return uc.versionRepository.DeleteAllForUser(ctx, id)
+}
+
+func (uc *userUsecase) ImportUsers(ctx context.Context, users []*user.User, opts user.ImportOptions) ([]*user.User, error) {
+	if err := opts.OnConflict.IsValid(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*user.User, len(users))
+
+	var (
+		entities  []*user.User
+		positions []int
+	)
+
+	for i, entity := range users {
+		// normalize
+		entity.NormalizedUsername = strings.ToLower(entity.Username)
+		entity.Email = strings.ToLower(entity.Email)
+
+		if entity.ID == "" {
+			entity.ID = ulid.ULID().String()
+		}
+
+		existing, err := uc.findExisting(ctx, entity)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(existing) > 0 {
+			switch opts.OnConflict {
+			case user.ConflictSkip:
+				continue
+			case user.ConflictOverwrite:
+				if len(existing) > 1 {
+					return nil, fmt.Errorf("user %s matches several existing users: %w", entity.ID, database.ErrAlreadyExists)
+				}
+
+				// the existing user is replaced, but keeps its ID
+				entity.ID = existing[0].ID
+			default:
+				return nil, fmt.Errorf("user %s: %w", entity.ID, database.ErrAlreadyExists)
+			}
+		}
+
+		entities = append(entities, entity)
+		positions = append(positions, i)
+	}
+
+	if !opts.DryRun && len(entities) > 0 {
+		saved, err := uc.repository.SaveAll(ctx, entities)
+		if err != nil {
+			return nil, err
+		}
+
+		entities = saved
+	}
+
+	for i, entity := range entities {
+		result[positions[i]] = entity
+	}
+
+	return result, nil
+}
+
+// findExisting returns the distinct users which have the same ID, email or
+// username as the entity.
+func (uc *userUsecase) findExisting(ctx context.Context, entity *user.User) ([]*user.User, error) {
+	var result []*user.User
+
+	found, err := uc.repository.FindByID(ctx, entity.ID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return nil, err
+	}
+	if found != nil {
+		result = append(result, found)
+	}
+
+	for _, identifier := range []string{entity.Email, entity.NormalizedUsername} {
+		if identifier == "" {
+			continue
+		}
+
+		found, err := uc.repository.FindByIdentifier(ctx, identifier)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		duplicate := false
+		for _, u := range result {
+			if u.ID == found.ID {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			result = append(result, found)
+		}
+	}
+
+	return result, nil
+}
+
+func (uc *userUsecase) FindAllUserVersions(ctx context.Context, id string, afterCursor string, limit int) ([]*user.Version, string, error) {
+	return uc.versionRepository.FindAllForUser(ctx, id, afterCursor, limit)
+}
+
+func (uc *userUsecase) FindUserVersion(ctx context.Context, id string, version int) (*user.Version, error) {
+	return uc.versionRepository.FindByVersion(ctx, id, version)
+}
+
+func (uc *userUsecase) RestoreUserVersion(ctx context.Context, id string, version int) (*user.User, error) {
+	v, err := uc.versionRepository.FindByVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// deleted users are restored from their tombstone, which keeps the
+	// credentials
+	current, err := uc.repository.FindByID(ctx, id)
+	if errors.Is(err, database.ErrNotFound) {
+		current, err = uc.repository.FindDeletedByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// identifiers might be taken by other users in the meantime
+	for _, identifier := range []string{v.User.NormalizedUsername, v.User.Email} {
+		other, err := uc.repository.FindByIdentifier(ctx, identifier)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			return nil, err
+		}
+
+		if other != nil && other.ID != id {
+			return nil, database.ErrAlreadyExists
+		}
+	}
+
+	prev := *current
+
+	restored := *current
+	restored.Restore(v.User)
+
+	saved, err := uc.repository.Save(ctx, &restored)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.recordVersion(ctx, user.VersionActionRestore, &prev, saved); err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+func (uc *userUsecase) BlockUser(ctx context.Context, id string) (*user.User, error) {
+	user, err := uc.repository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Blocked = true
+
+	return uc.update(ctx, user)
+}
+
+func (uc *userUsecase) UnblockUser(ctx context.Context, id string) (*user.User, error) {
+	user, err := uc.repository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Blocked = false
+
+	return uc.update(ctx, user)
+}
+
+func (uc *userUsecase) FindAllUsers(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return uc.repository.FindAll(ctx, afterCursor, limit)
 }
 
 func (uc *userUsecase) FindUserByID(ctx context.Context, id string) (*user.User, error) {
@@ -166,6 +464,14 @@ func (uc *userUsecase) FindUserByRecoveryToken(ctx context.Context, token string
 	return uc.repository.FindByRecoveryToken(ctx, token)
 }
 
+func (uc *userUsecase) FindUserByDeletionToken(ctx context.Context, token string) (*user.User, error) {
+	return uc.repository.FindByDeletionToken(ctx, token)
+}
+
+func (uc *userUsecase) FindAllUsersScheduledForDeletion(ctx context.Context, afterCursor string, limit int) ([]*user.User, string, error) {
+	return uc.repository.FindAllScheduledForDeletion(ctx, afterCursor, limit)
+}
+
 func (uc *userUsecase) Authenticate(ctx context.Context, identifier string, password []byte) (*user.User, error) {
 	normalizedIdentifier := strings.ToLower(identifier)
 	entity, err := uc.repository.FindByIdentifier(ctx, normalizedIdentifier)
@@ -182,25 +488,66 @@ func (uc *userUsecase) Authenticate(ctx context.Context, identifier string, pass
 		return nil, fmt.Errorf("password compare: %w", err)
 	}
 
+	// upgrade hashes generated with other algorithms or outdated parameters
+	if rh, ok := uc.hasher.(hash.Rehasher); ok && rh.NeedsRehash(ctx, []byte(entity.PasswordHash)) {
+		if saved, err := uc.rehash(ctx, entity, password); err != nil {
+			// the user is authenticated, the password is rehashed on the
+			// next sign in
+			uc.log.WithContext(ctx).
+				WithFields(logger.Fields{"user_id": entity.ID}).
+				Warnf("password rehash: %v", err)
+		} else {
+			entity = saved
+		}
+	}
+
 	return entity, nil
 }
 
-func (uc *userUsecase) UserSignedIn(ctx context.Context, entity *user.User, ipAddress net.IP) (*user.User, error) {
-	user, err := uc.repository.FindByID(ctx, entity.ID)
+// rehash saves the user with the password hashed again.
+func (uc *userUsecase) rehash(ctx context.Context, entity *user.User, password []byte) (*user.User, error) {
+	hashedPassword, err := uc.hasher.Generate(ctx, password)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("password hash generate: %w", err)
 	}
 
-	if ipAddress != nil {
-		user.LastIP = ipAddress.String()
-	}
+	rehashed := *entity
+	rehashed.PasswordHash = string(hashedPassword)
 
-	now := time.Now()
-	user.LastLoginAt = &now
+	// rehashing does not change the password, so it is not tracked by the
+	// history, and fails with database.ErrConflict if the user was changed
+	// in the meantime
+	return uc.repository.Save(ctx, &rehashed)
+}
 
-	user.LoginsCount++
+// signedInAttempts is the number of times the sign in statistics are saved,
+// while the user is changed concurrently.
+const signedInAttempts = 3
 
-	return uc.repository.Save(ctx, user)
+func (uc *userUsecase) UserSignedIn(ctx context.Context, entity *user.User, ipAddress net.IP) (*user.User, error) {
+	for attempt := 1; ; attempt++ {
+		user, err := uc.repository.FindByID(ctx, entity.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if ipAddress != nil {
+			user.LastIP = ipAddress.String()
+		}
+
+		now := time.Now()
+		user.LastLoginAt = &now
+
+		user.LoginsCount++
+
+		// sign in statistics are not tracked by the history
+		saved, err := uc.repository.Save(ctx, user)
+		if errors.Is(err, database.ErrConflict) && attempt < signedInAttempts {
+			continue
+		}
+
+		return saved, err
+	}
 }
 
 func (uc *userUsecase) UpdateUserMetaData(ctx context.Context, user *user.User, updates map[string]interface{}) (*user.User, error) {
@@ -216,7 +563,7 @@ func (uc *userUsecase) UpdateUserMetaData(ctx context.Context, user *user.User,
 		}
 	}
 
-	return uc.repository.Save(ctx, user)
+	return uc.update(ctx, user)
 }
 
 func (uc *userUsecase) UpdateAppMetaData(ctx context.Context, user *user.User, updates map[string]interface{}) (*user.User, error) {
@@ -232,5 +579,5 @@ func (uc *userUsecase) UpdateAppMetaData(ctx context.Context, user *user.User, u
 		}
 	}
 
-	return uc.repository.Save(ctx, user)
+	return uc.update(ctx, user)
 }
@@ -0,0 +1,181 @@
+package user
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/jsonmap"
+)
+
+// VersionAction is the action which created the version of the user.
+type VersionAction string
+
+const (
+	VersionActionCreate  VersionAction = "create"
+	VersionActionUpdate  VersionAction = "update"
+	VersionActionDelete  VersionAction = "delete"
+	VersionActionRestore VersionAction = "restore"
+)
+
+// Version is the state of the user after a change, together with who made
+// the change, and what changed. The state is kept without the credentials.
+type Version struct {
+	UserID  string
+	Version int
+	Action  VersionAction
+	// ActorID is the ID of who made the change, if known.
+	ActorID string
+	Changes []Change
+	User    *User
+
+	CreatedAt time.Time
+}
+
+// Change is the change of a single field of the user. Values of secret fields
+// are left out.
+type Change struct {
+	Field string
+	From  interface{}
+	To    interface{}
+}
+
+// secretFields are the fields whose changes are recorded without values.
+var secretFields = map[string]bool{
+	"password": true,
+}
+
+// versionedFields returns the fields of the user tracked by the history.
+// Bookkeeping fields, such as sign in statistics and one-time tokens, are
+// not tracked.
+func versionedFields(u *User) map[string]interface{} {
+	if u == nil {
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"email":                 u.Email,
+		"email_verified":        u.EmailVerified,
+		"password":              u.PasswordHash,
+		"username":              u.Username,
+		"given_name":            u.GivenName,
+		"family_name":           u.FamilyName,
+		"name":                  u.Name,
+		"nickname":              u.Nickname,
+		"picture":               u.Picture,
+		"new_email":             u.EmailChange,
+		"deletion_requested_at": u.DeletionRequestedAt,
+		"app_metadata":          u.AppMetaData,
+		"user_metadata":         u.UserMetaData,
+		"blocked":               u.Blocked,
+		"deleted_at":            u.DeletedAt,
+	}
+}
+
+// versionedFieldNames is the order in which the changes are reported.
+var versionedFieldNames = []string{
+	"email",
+	"email_verified",
+	"password",
+	"username",
+	"given_name",
+	"family_name",
+	"name",
+	"nickname",
+	"picture",
+	"new_email",
+	"deletion_requested_at",
+	"app_metadata",
+	"user_metadata",
+	"blocked",
+	"deleted_at",
+}
+
+// Diff returns the changes of the tracked fields between two states of the
+// user. The previous state is nil for new users.
+func Diff(prev, next *User) []Change {
+	prevFields := versionedFields(prev)
+	nextFields := versionedFields(next)
+
+	var changes []Change
+
+	for _, field := range versionedFieldNames {
+		from, to := normalizeValue(prevFields[field]), normalizeValue(nextFields[field])
+
+		fromJSON, _ := json.Marshal(from)
+		toJSON, _ := json.Marshal(to)
+
+		if string(fromJSON) == string(toJSON) {
+			continue
+		}
+
+		if secretFields[field] {
+			from, to = nil, nil
+		}
+
+		changes = append(changes, Change{
+			Field: field,
+			From:  from,
+			To:    to,
+		})
+	}
+
+	return changes
+}
+
+// normalizeValue returns the empty values as nil, so they compare equal
+// regardless of how they were stored.
+func normalizeValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+	case bool:
+		if !value {
+			return nil
+		}
+	case *time.Time:
+		if value == nil || value.IsZero() {
+			return nil
+		}
+		return value.UTC().Format(time.RFC3339Nano)
+	case jsonmap.JSONMap:
+		if len(value) == 0 {
+			return nil
+		}
+		return map[string]interface{}(value)
+	}
+
+	return v
+}
+
+// WithoutCredentials returns a copy of the user without the password, as it
+// is kept by its versions.
+func (u *User) WithoutCredentials() *User {
+	c := *u
+	c.Password = ""
+	c.PasswordHash = ""
+	c.PasswordUpdatedAt = nil
+
+	return &c
+}
+
+// Restore sets the tracked fields of the user to the values of the previous
+// state, and undeletes the user. The credentials, and fields describing
+// pending workflows, such as the email change or the scheduled deletion, are
+// left unchanged, so a restore never brings back an old password.
+func (u *User) Restore(prev *User) {
+	u.Email = prev.Email
+	u.EmailVerified = prev.EmailVerified
+	u.Username = prev.Username
+	u.NormalizedUsername = prev.NormalizedUsername
+	u.GivenName = prev.GivenName
+	u.FamilyName = prev.FamilyName
+	u.Name = prev.Name
+	u.Nickname = prev.Nickname
+	u.Picture = prev.Picture
+	u.AppMetaData = prev.AppMetaData.Clone()
+	u.UserMetaData = prev.UserMetaData.Clone()
+	u.Blocked = prev.Blocked
+	u.DeletedAt = nil
+}
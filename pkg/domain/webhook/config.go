@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Config holds the webhook configuration.
+type Config struct {
+	Endpoints      Endpoints     `json:"endpoints" validate:"dive"`
+	Timeout        time.Duration `json:"timeout" default:"10s" validate:"gt=0"`
+	MaxAttempts    int           `json:"max_attempts" split_words:"true" default:"8" validate:"gte=1"`
+	InitialBackoff time.Duration `json:"initial_backoff" split_words:"true" default:"30s" validate:"gt=0"`
+	MaxBackoff     time.Duration `json:"max_backoff" split_words:"true" default:"6h" validate:"gtefield=InitialBackoff"`
+	PollInterval   time.Duration `json:"poll_interval" split_words:"true" default:"5s" validate:"gt=0"`
+}
+
+// Endpoints holds the endpoints events are delivered to. It is decoded from
+// a JSON array.
+type Endpoints []*Endpoint
+
+// Decode implements envconfig.Decoder.
+func (e *Endpoints) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(value), e)
+}
+
+// Endpoint receives the events it is subscribed to, or all of them if none
+// are listed. Requests are signed with the secret.
+type Endpoint struct {
+	URL    string      `json:"url" validate:"required,url"`
+	Secret string      `json:"secret" validate:"required,gte=16"`
+	Events []EventType `json:"events" validate:"dive,oneof=user.created user.verified user.email_changed user.blocked user.deleted"`
+}
+
+// IsSubscribed checks if the endpoint receives the event.
+func (e *Endpoint) IsSubscribed(event EventType) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindEndpoint returns the endpoint with the URL, or nil.
+func (c *Config) FindEndpoint(url string) *Endpoint {
+	for _, e := range c.Endpoints {
+		if e.URL == url {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// Backoff returns how long to wait before the next attempt, after the given
+// number of failed attempts. The wait doubles with every attempt.
+func (c *Config) Backoff(attempts int) time.Duration {
+	backoff := c.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+
+	if backoff > c.MaxBackoff {
+		return c.MaxBackoff
+	}
+
+	return backoff
+}
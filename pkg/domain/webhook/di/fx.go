@@ -0,0 +1,11 @@
+package di
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Options(
+	repositoresfx,
+	usecasesfx,
+	workerfx,
+)
@@ -0,0 +1,93 @@
+package di
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.uber.org/fx"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	database_jsonmutexdb "github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	webhook_bbolt "github.com/zbiljic/authzy/pkg/domain/webhook/storage/bbolt"
+	webhook_jsonmutexdb "github.com/zbiljic/authzy/pkg/domain/webhook/storage/jsonmutexdb"
+	webhook_leveldb "github.com/zbiljic/authzy/pkg/domain/webhook/storage/leveldb"
+	webhook_sqlite "github.com/zbiljic/authzy/pkg/domain/webhook/storage/sqlite"
+)
+
+var repositoresfx = fx.Provide(
+	NewDeliveryRepository,
+)
+
+type RepositoryParams struct {
+	fx.In
+
+	Type string `name:"db_type"`
+
+	JSONMutexDBConfig *database_jsonmutexdb.Config
+	LevelDBConfig     *database_leveldb.Config
+	SQLiteConfig      *database_sqlite.Config
+	BBoltConfig       *database_bbolt.Config
+
+	JSONLoadSaver *database_jsonmutexdb.LoadSaver `optional:"true"`
+	LevelDB       *leveldb.DB                     `optional:"true"`
+	SQLite        *database_sqlite.DB             `optional:"true"`
+	BBolt         *database_bbolt.DB              `optional:"true"`
+}
+
+func NewDeliveryRepository(p RepositoryParams) (webhook.DeliveryRepository, error) {
+	switch p.Type {
+	case database_jsonmutexdb.Type:
+		return NewJSONMutexDBDeliveryRepository(p.JSONMutexDBConfig, p.JSONLoadSaver)
+	case database_leveldb.Type:
+		return NewLevelDBDeliveryRepository(p.LevelDBConfig, p.LevelDB)
+	case database_sqlite.Type:
+		return NewSQLiteDeliveryRepository(p.SQLiteConfig, p.SQLite)
+	case database_bbolt.Type:
+		return NewBBoltDeliveryRepository(p.BBoltConfig, p.BBolt)
+	default:
+		return nil, fmt.Errorf("invalid database type: %s", p.Type)
+	}
+}
+
+func NewJSONMutexDBDeliveryRepository(
+	config *database_jsonmutexdb.Config,
+	ls *database_jsonmutexdb.LoadSaver,
+) (webhook.DeliveryRepository, error) {
+	return webhook_jsonmutexdb.NewDeliveryRepository(
+		*ls,
+		config.FilenamePrefix,
+	)
+}
+
+func NewLevelDBDeliveryRepository(
+	config *database_leveldb.Config,
+	db *leveldb.DB,
+) (webhook.DeliveryRepository, error) {
+	return webhook_leveldb.NewDeliveryRepository(
+		db,
+		config.KeyPrefix,
+	)
+}
+
+func NewSQLiteDeliveryRepository(
+	config *database_sqlite.Config,
+	db *database_sqlite.DB,
+) (webhook.DeliveryRepository, error) {
+	return webhook_sqlite.NewDeliveryRepository(
+		db,
+		config.TablePrefix,
+	)
+}
+
+func NewBBoltDeliveryRepository(
+	config *database_bbolt.Config,
+	db *database_bbolt.DB,
+) (webhook.DeliveryRepository, error) {
+	return webhook_bbolt.NewDeliveryRepository(
+		db,
+		config.BucketPrefix,
+	)
+}
@@ -0,0 +1,23 @@
+package di
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/usecases"
+)
+
+var usecasesfx = fx.Provide(
+	NewWebhookUsecase,
+)
+
+func NewWebhookUsecase(
+	config *webhook.Config,
+	repository webhook.DeliveryRepository,
+) webhook.WebhookUsecase {
+	uc := usecases.NewWebhookUsecase(
+		*config,
+		repository,
+	)
+	return uc
+}
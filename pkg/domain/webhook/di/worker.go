@@ -0,0 +1,68 @@
+package di
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+var workerfx = fx.Invoke(RegisterDeliveryWorker)
+
+// RegisterDeliveryWorker starts delivering queued events in the background,
+// if any endpoints are configured.
+func RegisterDeliveryWorker(
+	lc fx.Lifecycle,
+	log logger.Logger,
+	config *webhook.Config,
+	uc webhook.WebhookUsecase,
+) {
+	if len(config.Endpoints) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			log.Debug("starting webhook delivery worker")
+
+			go func() {
+				defer close(done)
+
+				ticker := time.NewTicker(config.PollInterval)
+				defer ticker.Stop()
+
+				for {
+					if _, err := uc.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+						log.Errorf("deliver webhooks: %v", err)
+					}
+
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			log.Debug("stopping webhook delivery worker")
+
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
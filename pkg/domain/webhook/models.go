@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrDeliveryPending is returned when redelivering the delivery which is
+// still pending.
+var ErrDeliveryPending = errors.New("delivery pending")
+
+// EventType is the type of the event delivered to the endpoints.
+type EventType string
+
+const (
+	EventUserCreated      EventType = "user.created"
+	EventUserVerified     EventType = "user.verified"
+	EventUserEmailChanged EventType = "user.email_changed"
+	EventUserBlocked      EventType = "user.blocked"
+	EventUserDeleted      EventType = "user.deleted"
+)
+
+// Status is the state of the delivery.
+type Status string
+
+const (
+	// StatusPending deliveries are waiting for the next attempt.
+	StatusPending Status = "pending"
+	// StatusDelivered deliveries were accepted by the endpoint.
+	StatusDelivered Status = "delivered"
+	// StatusDead deliveries ran out of attempts, and are kept until they
+	// are redelivered.
+	StatusDead Status = "dead"
+)
+
+// Payload is the body of the webhook request. The ID is shared by the
+// deliveries of the event to all endpoints, and can be used by the receivers
+// to discard duplicates.
+type Payload struct {
+	ID        string      `json:"id"`
+	Type      EventType   `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Delivery is the event queued for delivery to a single endpoint. The
+// payload is kept as sent, so that redeliveries are identical.
+type Delivery struct {
+	ID       string
+	EventID  string
+	Event    EventType
+	URL      string
+	Payload  json.RawMessage
+	Status   Status
+	Attempts int
+
+	NextAttemptAt  time.Time
+	LastAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsDue checks if the delivery should be attempted at the given time.
+func (d *Delivery) IsDue(now time.Time) bool {
+	return d.Status == StatusPending && !d.NextAttemptAt.After(now)
+}
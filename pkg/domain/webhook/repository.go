@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"context"
+	"time"
+)
+
+type DeliveryRepository interface {
+	// Save saves a given entity.
+	Save(ctx context.Context, entity *Delivery) (*Delivery, error)
+
+	// FindByID retrieves an entity by its id.
+	FindByID(ctx context.Context, id string) (*Delivery, error)
+
+	// FindAll returns deliveries having the status, or all of them if the
+	// status is empty, oldest first.
+	FindAll(ctx context.Context, status Status, afterCursor string, limit int) ([]*Delivery, string, error)
+
+	// FindAllDue returns pending deliveries which should be attempted at the
+	// given time, oldest first.
+	FindAllDue(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+
+	// Count returns the number of entities available.
+	Count(ctx context.Context) (int, error)
+
+	// DeleteByID deletes the entity with the given id.
+	DeleteByID(ctx context.Context, id string) error
+
+	// DeleteAll deletes all entities managed by the repository.
+	DeleteAll(ctx context.Context) error
+}
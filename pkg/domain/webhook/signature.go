@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP headers of the webhook request.
+const (
+	SignatureHeader = "X-Authzy-Signature"
+	EventHeader     = "X-Authzy-Event"
+	DeliveryHeader  = "X-Authzy-Delivery"
+)
+
+const signatureVersion = "v1"
+
+var (
+	// ErrInvalidSignature is returned when the signature header is malformed,
+	// or none of the signatures match the payload.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrSignatureExpired is returned when the signature timestamp is outside
+	// of the tolerance, which protects from replaying old requests.
+	ErrSignatureExpired = errors.New("signature expired")
+)
+
+// Sign returns the value of the signature header for the payload sent at the
+// given time. The HMAC-SHA256 is computed over the unix timestamp and the
+// payload, joined by a dot.
+func Sign(secret string, t time.Time, payload []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	return fmt.Sprintf("t=%s,%s=%s", timestamp, signatureVersion, computeSignature(secret, timestamp, payload))
+}
+
+// VerifySignature checks the signature header of the received payload.
+// Receivers should use a tolerance of a few minutes.
+func VerifySignature(secret, header string, payload []byte, tolerance time.Duration, now time.Time) error {
+	var (
+		timestamp  string
+		signatures []string
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return ErrInvalidSignature
+		}
+
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case signatureVersion:
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	diff := now.Sub(time.Unix(unix, 0))
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := computeSignature(secret, timestamp, payload)
+	for _, s := range signatures {
+		if hmac.Equal([]byte(s), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func computeSignature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp)) //nolint:errcheck
+	mac.Write([]byte("."))       //nolint:errcheck
+	mac.Write(payload)           //nolint:errcheck
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,62 @@
+package webhook_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+)
+
+func TestSignature(t *testing.T) {
+	secret := "0123456789abcdef"
+	payload := []byte(`{"id":"event","type":"user.created"}`)
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	header := webhook.Sign(secret, now, payload)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		payload []byte
+		now     time.Time
+		err     error
+	}{
+		{"valid", secret, header, payload, now, nil},
+		{"within tolerance", secret, header, payload, now.Add(4 * time.Minute), nil},
+		{"additional signature", secret, "v1=invalid," + header, payload, now, nil},
+		{"expired", secret, header, payload, now.Add(10 * time.Minute), webhook.ErrSignatureExpired},
+		{"wrong secret", "fedcba9876543210", header, payload, now, webhook.ErrInvalidSignature},
+		{"tampered payload", secret, header, []byte(`{"id":"event","type":"user.deleted"}`), now, webhook.ErrInvalidSignature},
+		{"missing timestamp", secret, "v1=abc", payload, now, webhook.ErrInvalidSignature},
+		{"malformed", secret, "signature", payload, now, webhook.ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhook.VerifySignature(tt.secret, tt.header, tt.payload, 5*time.Minute, tt.now)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.err), "expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	config := &webhook.Config{
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+	}
+
+	assert.Equal(t, 30*time.Second, config.Backoff(1))
+	assert.Equal(t, time.Minute, config.Backoff(2))
+	assert.Equal(t, 2*time.Minute, config.Backoff(3))
+	assert.Equal(t, 4*time.Minute, config.Backoff(4))
+	assert.Equal(t, 5*time.Minute, config.Backoff(5))
+	assert.Equal(t, 5*time.Minute, config.Backoff(50))
+}
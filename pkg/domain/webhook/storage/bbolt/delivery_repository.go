@@ -0,0 +1,237 @@
+package bbolt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/noop"
+)
+
+const (
+	webhookDeliveriesBucket = "webhook_deliveries"
+)
+
+// bboltDeliveryRepository is a repository that uses bbolt database.
+type bboltDeliveryRepository struct {
+	noop.UnimplementedDeliveryRepository
+
+	db *database_bbolt.DB
+
+	webhookDeliveriesBucket []byte
+
+	validate *validator.Validate
+}
+
+// NewDeliveryRepository returns a new bbolt repository.
+func NewDeliveryRepository(
+	db *database_bbolt.DB,
+	bucketPrefix string,
+) (webhook.DeliveryRepository, error) {
+	r := &bboltDeliveryRepository{
+		db:                      db,
+		webhookDeliveriesBucket: []byte(bucketPrefix + webhookDeliveriesBucket),
+		validate:                validator.New(),
+	}
+
+	err := db.CreateBuckets(string(r.webhookDeliveriesBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const (
+	ns           = "webhook/storage/bbolt."
+	opSave       = ns + "Save"
+	opFindByID   = ns + "FindByID"
+	opFindAll    = ns + "FindAll"
+	opFindAllDue = ns + "FindAllDue"
+	opCount      = ns + "Count"
+	opDeleteByID = ns + "DeleteByID"
+	opDeleteAll  = ns + "DeleteAll"
+)
+
+func (r *bboltDeliveryRepository) Save(ctx context.Context, entity *webhook.Delivery) (*webhook.Delivery, error) {
+	inS := schema.DeliveryToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalDelivery(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	err = r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(r.webhookDeliveriesBucket).Put([]byte(inS.ID), value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.DeliveryFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *bboltDeliveryRepository) FindByID(ctx context.Context, id string) (*webhook.Delivery, error) {
+	var ds *schema.Delivery
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) (err error) {
+		value := tx.Bucket(r.webhookDeliveriesBucket).Get([]byte(id))
+		if value == nil {
+			return database.ErrNotFound
+		}
+
+		ds, err = transformer.UnmarshalDelivery(value)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.DeliveryFromSchema(ds)
+
+	return entity, nil
+}
+
+// findAll returns the deliveries after the cursor, in order of IDs, which
+// match the function, up to the limit.
+func (r *bboltDeliveryRepository) findAll(ctx context.Context, afterCursor string, limit int, match func(*webhook.Delivery) bool) ([]*webhook.Delivery, error) {
+	var result []*webhook.Delivery
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.webhookDeliveriesBucket).Cursor()
+
+		k, v := c.First()
+		if afterCursor != "" {
+			k, v = c.Seek([]byte(afterCursor))
+			if k != nil && string(k) == afterCursor {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(result) < limit; k, v = c.Next() {
+			ds, err := transformer.UnmarshalDelivery(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", string(k), err)
+			}
+
+			d := schema.DeliveryFromSchema(ds)
+
+			if !match(d) {
+				continue
+			}
+
+			result = append(result, d)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *bboltDeliveryRepository) FindAll(ctx context.Context, status webhook.Status, afterCursor string, limit int) ([]*webhook.Delivery, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var nextCursor string
+
+	result, err := r.findAll(ctx, afterCursor, limit, func(d *webhook.Delivery) bool {
+		return status == "" || d.Status == status
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *bboltDeliveryRepository) FindAllDue(ctx context.Context, now time.Time, limit int) ([]*webhook.Delivery, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	result, err := r.findAll(ctx, "", limit, func(d *webhook.Delivery) bool {
+		return d.Status == webhook.StatusPending && !d.NextAttemptAt.After(now)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opFindAllDue, err)
+	}
+
+	return result, nil
+}
+
+func (r *bboltDeliveryRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.View(ctx, func(tx *bolt.Tx) error {
+		c := tx.Bucket(r.webhookDeliveriesBucket).Cursor()
+
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *bboltDeliveryRepository) DeleteByID(ctx context.Context, id string) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(r.webhookDeliveriesBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *bboltDeliveryRepository) DeleteAll(ctx context.Context) error {
+	err := r.db.Update(ctx, func(tx *bolt.Tx) error {
+		return database_bbolt.ClearBucket(tx, r.webhookDeliveriesBucket)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
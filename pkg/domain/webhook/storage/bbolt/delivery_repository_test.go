@@ -0,0 +1,25 @@
+package bbolt_test
+
+import (
+	"testing"
+
+	database_bbolt "github.com/zbiljic/authzy/pkg/database/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/bbolt"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/test"
+)
+
+func TestBBoltDeliveryRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (webhook.DeliveryRepository, func()) {
+		return func(t *testing.T) (webhook.DeliveryRepository, func()) {
+			db, cleanup := database_bbolt.Fixture()
+
+			repo, err := bbolt.NewDeliveryRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
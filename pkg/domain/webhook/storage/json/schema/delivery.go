@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+)
+
+type Delivery struct {
+	ID       string          `json:"delivery_id" validate:"required,alphanum"`
+	EventID  string          `json:"event_id" validate:"required,alphanum"`
+	Event    string          `json:"event" validate:"required,max=64"`
+	URL      string          `json:"url" validate:"required,url"`
+	Payload  json.RawMessage `json:"payload" validate:"required"`
+	Status   string          `json:"status" validate:"required,oneof=pending delivered dead"`
+	Attempts int             `json:"attempts" validate:"gte=0"`
+
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastAttemptAt  time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (d *Delivery) BeforeSave() error {
+	return nil
+}
+
+func DeliveryToSchema(in *webhook.Delivery) *Delivery {
+	out := &Delivery{}
+	if in != nil {
+		out.ID = in.ID
+		out.EventID = in.EventID
+		out.Event = string(in.Event)
+		out.URL = in.URL
+		out.Payload = in.Payload
+		out.Status = string(in.Status)
+		out.Attempts = in.Attempts
+		out.NextAttemptAt = in.NextAttemptAt
+		out.LastAttemptAt = in.LastAttemptAt
+		out.LastStatusCode = in.LastStatusCode
+		out.LastError = in.LastError
+		out.CreatedAt = in.CreatedAt
+		out.UpdatedAt = in.UpdatedAt
+	}
+
+	return out
+}
+
+func DeliveryFromSchema(in *Delivery) *webhook.Delivery {
+	out := &webhook.Delivery{}
+	out.ID = in.ID
+	out.EventID = in.EventID
+	out.Event = webhook.EventType(in.Event)
+	out.URL = in.URL
+	out.Payload = in.Payload
+	out.Status = webhook.Status(in.Status)
+	out.Attempts = in.Attempts
+	out.NextAttemptAt = in.NextAttemptAt
+	out.LastAttemptAt = in.LastAttemptAt
+	out.LastStatusCode = in.LastStatusCode
+	out.LastError = in.LastError
+	out.CreatedAt = in.CreatedAt
+	out.UpdatedAt = in.UpdatedAt
+
+	return out
+}
@@ -0,0 +1,46 @@
+package transformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/schema"
+)
+
+const keySeparator = "/"
+
+const (
+	ns                  = "webhook/storage/json/transformer."
+	opMarshalDelivery   = ns + "MarshalDelivery"
+	opUnmarshalDelivery = ns + "UnmarshalDelivery"
+)
+
+func MarshalKey(prefix, id string) string {
+	return strings.Join([]string{prefix, id}, keySeparator)
+}
+
+// MarshalStatusDeliveryKey returns the key of the delivery in the index of
+// deliveries having the status.
+func MarshalStatusDeliveryKey(prefix, status, id string) string {
+	return strings.Join([]string{prefix, status, id}, keySeparator)
+}
+
+func MarshalDelivery(in *schema.Delivery) ([]byte, error) {
+	out, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opMarshalDelivery, err)
+	}
+
+	return out, nil
+}
+
+func UnmarshalDelivery(in []byte) (*schema.Delivery, error) {
+	out := &schema.Delivery{}
+	err := json.Unmarshal(in, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opUnmarshalDelivery, err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,268 @@
+package jsonmutexdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/database/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/noop"
+)
+
+const (
+	webhookDeliveriesPrefix = "webhook_deliveries"
+)
+
+type jsonMutexDBDeliveryRepository struct {
+	noop.UnimplementedDeliveryRepository
+
+	db map[string]schema.Delivery
+	mu sync.RWMutex
+
+	loadSaver jsonmutexdb.LoadSaver
+	filename  string
+
+	validate *validator.Validate
+}
+
+// NewDeliveryRepository returns a new JSONMutexDB repository.
+func NewDeliveryRepository(
+	loadSaver jsonmutexdb.LoadSaver,
+	filenamePrefix string,
+) (webhook.DeliveryRepository, error) {
+	r := &jsonMutexDBDeliveryRepository{
+		db:        make(map[string]schema.Delivery),
+		loadSaver: loadSaver,
+		filename:  fmt.Sprintf("%s%s.json", filenamePrefix, webhookDeliveriesPrefix),
+		validate:  validator.New(),
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jsonMutexDBDeliveryRepository) load() error {
+	if r.loadSaver != nil {
+		data, err := r.loadSaver.Load(r.filename)
+		if err != nil {
+			return err
+		}
+
+		if len(data) > 0 {
+			err = json.Unmarshal(data, &r.db)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reload replaces the entities with the saved ones.
+func (r *jsonMutexDBDeliveryRepository) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Delivery)
+
+	return r.load()
+}
+
+func (r *jsonMutexDBDeliveryRepository) commit(ctx context.Context) error {
+	if r.loadSaver != nil {
+		out, err := json.Marshal(r.db)
+		if err != nil {
+			return err
+		}
+
+		return jsonmutexdb.Save(ctx, r.loadSaver, r.filename, out, r.reload)
+	}
+	return nil
+}
+
+const (
+	ns           = "webhook/storage/jsonmutexdb."
+	opSave       = ns + "Save"
+	opFindByID   = ns + "FindByID"
+	opDeleteByID = ns + "DeleteByID"
+	opDeleteAll  = ns + "DeleteAll"
+)
+
+func (r *jsonMutexDBDeliveryRepository) Save(ctx context.Context, entity *webhook.Delivery) (*webhook.Delivery, error) {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.DeliveryToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	r.db[inS.ID] = *inS
+
+	err = r.commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	savedEntity := schema.DeliveryFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *jsonMutexDBDeliveryRepository) FindByID(ctx context.Context, id string) (*webhook.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, ok := r.db[id]
+	if !ok {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+	}
+
+	entity := schema.DeliveryFromSchema(&value)
+
+	return entity, nil
+}
+
+// sortedKeys returns the IDs of all deliveries, oldest first.
+func (r *jsonMutexDBDeliveryRepository) sortedKeys() []string {
+	keys := make([]string, 0, len(r.db))
+	for id := range r.db {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (r *jsonMutexDBDeliveryRepository) FindAll(ctx context.Context, status webhook.Status, afterCursor string, limit int) ([]*webhook.Delivery, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*webhook.Delivery
+		nextCursor string
+	)
+
+	for _, id := range r.sortedKeys() {
+		if afterCursor != "" && id <= afterCursor {
+			continue
+		}
+
+		val := r.db[id]
+
+		if status != "" && val.Status != string(status) {
+			continue
+		}
+
+		result = append(result, schema.DeliveryFromSchema(&val))
+
+		if len(result) == limit {
+			break
+		}
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *jsonMutexDBDeliveryRepository) FindAllDue(ctx context.Context, now time.Time, limit int) ([]*webhook.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var result []*webhook.Delivery
+
+	for _, id := range r.sortedKeys() {
+		val := r.db[id]
+
+		d := schema.DeliveryFromSchema(&val)
+
+		if !d.IsDue(now) {
+			continue
+		}
+
+		result = append(result, d)
+
+		if len(result) == limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func (r *jsonMutexDBDeliveryRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.db), nil
+}
+
+func (r *jsonMutexDBDeliveryRepository) DeleteByID(ctx context.Context, id string) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[id]; !ok {
+		return nil
+	}
+
+	delete(r.db, id)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteByID, err)
+	}
+
+	return nil
+}
+
+func (r *jsonMutexDBDeliveryRepository) DeleteAll(ctx context.Context) error {
+	defer jsonmutexdb.Lock(ctx, r.loadSaver)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db = make(map[string]schema.Delivery)
+
+	err := r.commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
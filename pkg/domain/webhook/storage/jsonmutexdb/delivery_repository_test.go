@@ -0,0 +1,22 @@
+package jsonmutexdb_test
+
+import (
+	"testing"
+
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/jsonmutexdb"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/test"
+)
+
+func TestJSONMutexDBDeliveryRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (webhook.DeliveryRepository, func()) {
+		return func(t *testing.T) (webhook.DeliveryRepository, func()) {
+			repo, err := jsonmutexdb.NewDeliveryRepository(nil, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, func() {}
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package leveldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/noop"
+)
+
+const (
+	webhookDeliveriesPrefix     = "webhook_deliveries"
+	webhookDeliveryStatusPrefix = "webhook_delivery_status"
+)
+
+// levelDBDeliveryRepository is a repository that uses LevelDB database.
+type levelDBDeliveryRepository struct {
+	noop.UnimplementedDeliveryRepository
+
+	db *leveldb.DB
+	mu sync.Mutex
+
+	keyspace       string
+	statusKeyspace string
+
+	validate *validator.Validate
+}
+
+// NewDeliveryRepository returns a new LevelDB repository.
+func NewDeliveryRepository(
+	db *leveldb.DB,
+	keyPrefix string,
+) (webhook.DeliveryRepository, error) {
+	r := &levelDBDeliveryRepository{
+		db:             db,
+		keyspace:       keyPrefix + webhookDeliveriesPrefix,
+		statusKeyspace: keyPrefix + webhookDeliveryStatusPrefix,
+		validate:       validator.New(),
+	}
+
+	return r, nil
+}
+
+const (
+	ns           = "webhook/storage/leveldb."
+	opSave       = ns + "Save"
+	opFindByID   = ns + "FindByID"
+	opFindAll    = ns + "FindAll"
+	opFindAllDue = ns + "FindAllDue"
+	opCount      = ns + "Count"
+	opDeleteByID = ns + "DeleteByID"
+	opDeleteAll  = ns + "DeleteAll"
+)
+
+func (r *levelDBDeliveryRepository) commit(ctx context.Context, batch *leveldb.Batch) error {
+	return database_leveldb.From(ctx, r.db).Write(batch, nil)
+}
+
+func (r *levelDBDeliveryRepository) deliveryKeys(in *schema.Delivery) (string, string) {
+	return transformer.MarshalKey(r.keyspace, in.ID),
+		transformer.MarshalStatusDeliveryKey(r.statusKeyspace, in.Status, in.ID)
+}
+
+func (r *levelDBDeliveryRepository) Save(ctx context.Context, entity *webhook.Delivery) (*webhook.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inS := schema.DeliveryToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	batch := new(leveldb.Batch)
+
+	// remove indexes of the previous version
+	prev, err := r.find(ctx, inS.ID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	if prev != nil {
+		_, statusKey := r.deliveryKeys(prev)
+
+		batch.Delete([]byte(statusKey))
+	}
+
+	key, statusKey := r.deliveryKeys(inS)
+
+	value, err := transformer.MarshalDelivery(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	batch.Put([]byte(key), value)
+	batch.Put([]byte(statusKey), []byte(inS.ID))
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.DeliveryFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *levelDBDeliveryRepository) find(ctx context.Context, id string) (*schema.Delivery, error) {
+	key := transformer.MarshalKey(r.keyspace, id)
+
+	value, err := database_leveldb.From(ctx, r.db).Get([]byte(key), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, database.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return transformer.UnmarshalDelivery(value)
+}
+
+func (r *levelDBDeliveryRepository) FindByID(ctx context.Context, id string) (*webhook.Delivery, error) {
+	ds, err := r.find(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.DeliveryFromSchema(ds)
+
+	return entity, nil
+}
+
+// findAllRange returns the range of keys holding the deliveries having the
+// status, or all deliveries if the status is empty, after the cursor.
+func (r *levelDBDeliveryRepository) findAllRange(status webhook.Status, afterCursor string) (*util.Range, bool) {
+	prefix := r.keyspace + "/"
+	byStatus := status != ""
+	if byStatus {
+		prefix = transformer.MarshalStatusDeliveryKey(r.statusKeyspace, string(status), "")
+	}
+
+	rng := util.BytesPrefix([]byte(prefix))
+
+	if afterCursor != "" {
+		// the first key after the cursor
+		rng.Start = append([]byte(prefix+afterCursor), 0)
+	}
+
+	return rng, byStatus
+}
+
+func (r *levelDBDeliveryRepository) FindAll(ctx context.Context, status webhook.Status, afterCursor string, limit int) ([]*webhook.Delivery, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*webhook.Delivery
+		nextCursor string
+	)
+
+	rng, byStatus := r.findAllRange(status, afterCursor)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(rng, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		var (
+			ds  *schema.Delivery
+			err error
+		)
+
+		if byStatus {
+			ds, err = r.find(ctx, string(iter.Value()))
+		} else {
+			ds, err = transformer.UnmarshalDelivery(iter.Value())
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, string(iter.Key()), err)
+		}
+
+		result = append(result, schema.DeliveryFromSchema(ds))
+
+		if len(result) == limit {
+			break // stops iterator
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opFindAll, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *levelDBDeliveryRepository) FindAllDue(ctx context.Context, now time.Time, limit int) ([]*webhook.Delivery, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var result []*webhook.Delivery
+
+	rng, _ := r.findAllRange(webhook.StatusPending, "")
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(rng, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		ds, err := r.find(ctx, string(iter.Value()))
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", opFindAllDue, string(iter.Key()), err)
+		}
+
+		d := schema.DeliveryFromSchema(ds)
+
+		if !d.IsDue(now) {
+			continue
+		}
+
+		result = append(result, d)
+
+		if len(result) == limit {
+			break // stops iterator
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opFindAllDue, err)
+	}
+
+	return result, nil
+}
+
+func (r *levelDBDeliveryRepository) Count(ctx context.Context) (int, error) {
+	var (
+		count          int
+		ctxCheckOffset int
+	)
+
+	iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(r.keyspace+"/")), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if count == ctxCheckOffset {
+			select {
+			case <-ctx.Done():
+				return count, fmt.Errorf("%s: %w", opCount, ctx.Err())
+			default:
+			}
+
+			ctxCheckOffset += 100
+		}
+
+		count++
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *levelDBDeliveryRepository) DeleteByID(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ds, err := r.find(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	key, statusKey := r.deliveryKeys(ds)
+
+	batch := new(leveldb.Batch)
+	batch.Delete([]byte(key))
+	batch.Delete([]byte(statusKey))
+
+	err = r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *levelDBDeliveryRepository) DeleteAll(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+
+	for _, keyspace := range []string{r.keyspace, r.statusKeyspace} {
+		iter := database_leveldb.From(ctx, r.db).NewIterator(util.BytesPrefix([]byte(keyspace+"/")), nil)
+
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+
+		iter.Release()
+
+		if err := iter.Error(); err != nil {
+			return fmt.Errorf("%s: %w", opDeleteAll, err)
+		}
+	}
+
+	err := r.commit(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
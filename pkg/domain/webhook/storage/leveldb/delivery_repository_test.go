@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	database_leveldb "github.com/zbiljic/authzy/pkg/database/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/leveldb"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/test"
+)
+
+func TestLevelDBDeliveryRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (webhook.DeliveryRepository, func()) {
+		return func(t *testing.T) (webhook.DeliveryRepository, func()) {
+			db, cleanup := database_leveldb.Fixture()
+
+			repo, err := leveldb.NewDeliveryRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package noop
+
+import (
+	"context"
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+)
+
+// Compile-time proof of interface implementation.
+var _ webhook.DeliveryRepository = (*UnimplementedDeliveryRepository)(nil)
+
+// UnimplementedDeliveryRepository can be embedded to have forward compatible implementations.
+type UnimplementedDeliveryRepository struct{}
+
+func (*UnimplementedDeliveryRepository) Save(ctx context.Context, entity *webhook.Delivery) (*webhook.Delivery, error) {
+	panic("Save not implemented")
+}
+
+func (*UnimplementedDeliveryRepository) FindByID(ctx context.Context, id string) (*webhook.Delivery, error) {
+	panic("FindByID not implemented")
+}
+
+func (*UnimplementedDeliveryRepository) FindAll(ctx context.Context, status webhook.Status, afterCursor string, limit int) ([]*webhook.Delivery, string, error) {
+	panic("FindAll not implemented")
+}
+
+func (*UnimplementedDeliveryRepository) FindAllDue(ctx context.Context, now time.Time, limit int) ([]*webhook.Delivery, error) {
+	panic("FindAllDue not implemented")
+}
+
+func (*UnimplementedDeliveryRepository) Count(ctx context.Context) (int, error) {
+	panic("Count not implemented")
+}
+
+func (*UnimplementedDeliveryRepository) DeleteByID(ctx context.Context, id string) error {
+	panic("DeleteByID not implemented")
+}
+
+func (*UnimplementedDeliveryRepository) DeleteAll(ctx context.Context) error {
+	panic("DeleteAll not implemented")
+}
@@ -0,0 +1,239 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/schema"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/transformer"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/noop"
+)
+
+const (
+	webhookDeliveriesTable = "webhook_deliveries"
+)
+
+// sqliteDeliveryRepository is a repository that uses SQLite database.
+type sqliteDeliveryRepository struct {
+	noop.UnimplementedDeliveryRepository
+
+	db *database_sqlite.DB
+
+	webhookDeliveriesTable string
+
+	validate *validator.Validate
+}
+
+// NewDeliveryRepository returns a new SQLite repository.
+func NewDeliveryRepository(
+	db *database_sqlite.DB,
+	tablePrefix string,
+) (webhook.DeliveryRepository, error) {
+	err := db.Migrate(tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sqliteDeliveryRepository{
+		db:                     db,
+		webhookDeliveriesTable: database_sqlite.Table(tablePrefix, webhookDeliveriesTable),
+		validate:               validator.New(),
+	}
+
+	return r, nil
+}
+
+const (
+	ns           = "webhook/storage/sqlite."
+	opSave       = ns + "Save"
+	opFindByID   = ns + "FindByID"
+	opFindAll    = ns + "FindAll"
+	opFindAllDue = ns + "FindAllDue"
+	opCount      = ns + "Count"
+	opDeleteByID = ns + "DeleteByID"
+	opDeleteAll  = ns + "DeleteAll"
+)
+
+func (r *sqliteDeliveryRepository) Save(ctx context.Context, entity *webhook.Delivery) (*webhook.Delivery, error) {
+	inS := schema.DeliveryToSchema(entity)
+
+	err := r.validate.Struct(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	// before save
+	err = inS.BeforeSave()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opSave, err)
+	}
+
+	if inS.CreatedAt.IsZero() {
+		inS.CreatedAt = time.Now()
+	}
+	inS.UpdatedAt = time.Now()
+
+	value, err := transformer.MarshalDelivery(inS)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO `+r.webhookDeliveriesTable+` (
+			id, status, next_attempt_at, data
+		) VALUES (?, ?, ?, ?)`,
+		inS.ID, inS.Status, inS.NextAttemptAt.UnixNano(), string(value),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opSave, inS.ID, err)
+	}
+
+	savedEntity := schema.DeliveryFromSchema(inS)
+
+	return savedEntity, nil
+}
+
+func (r *sqliteDeliveryRepository) FindByID(ctx context.Context, id string) (*webhook.Delivery, error) {
+	var value string
+
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM `+r.webhookDeliveriesTable+` WHERE id = ?`, id).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, database.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	ds, err := transformer.UnmarshalDelivery([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s(%s): %w", opFindByID, id, err)
+	}
+
+	entity := schema.DeliveryFromSchema(ds)
+
+	return entity, nil
+}
+
+// findAll returns the deliveries selected by the query, which selects the
+// data.
+func (r *sqliteDeliveryRepository) findAll(ctx context.Context, query string, args ...interface{}) ([]*webhook.Delivery, error) {
+	var result []*webhook.Delivery
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		ds, err := transformer.UnmarshalDelivery([]byte(value))
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, schema.DeliveryFromSchema(ds))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *sqliteDeliveryRepository) FindAll(ctx context.Context, status webhook.Status, afterCursor string, limit int) ([]*webhook.Delivery, string, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	var (
+		result     []*webhook.Delivery
+		nextCursor string
+		err        error
+	)
+
+	if status != "" {
+		result, err = r.findAll(ctx,
+			`SELECT data FROM `+r.webhookDeliveriesTable+` WHERE status = ? AND id > ? ORDER BY id LIMIT ?`,
+			string(status), afterCursor, limit,
+		)
+	} else {
+		result, err = r.findAll(ctx,
+			`SELECT data FROM `+r.webhookDeliveriesTable+` WHERE id > ? ORDER BY id LIMIT ?`,
+			afterCursor, limit,
+		)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%s(%s): %w", opFindAll, afterCursor, err)
+	}
+
+	if len(result) == limit {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return result, nextCursor, nil
+}
+
+func (r *sqliteDeliveryRepository) FindAllDue(ctx context.Context, now time.Time, limit int) ([]*webhook.Delivery, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	result, err := r.findAll(ctx,
+		`SELECT data FROM `+r.webhookDeliveriesTable+`
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY id LIMIT ?`,
+		string(webhook.StatusPending), now.UnixNano(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opFindAllDue, err)
+	}
+
+	return result, nil
+}
+
+func (r *sqliteDeliveryRepository) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+r.webhookDeliveriesTable).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opCount, err)
+	}
+
+	return count, nil
+}
+
+func (r *sqliteDeliveryRepository) DeleteByID(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.webhookDeliveriesTable+` WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s(%s): %w", opDeleteByID, id, err)
+	}
+
+	return nil
+}
+
+func (r *sqliteDeliveryRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM `+r.webhookDeliveriesTable)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteAll, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	database_sqlite "github.com/zbiljic/authzy/pkg/database/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/sqlite"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/test"
+)
+
+func TestSQLiteDeliveryRepository(t *testing.T) {
+	test.Run(t, func() func(t *testing.T) (webhook.DeliveryRepository, func()) {
+		return func(t *testing.T) (webhook.DeliveryRepository, func()) {
+			db, cleanup := database_sqlite.Fixture()
+
+			repo, err := sqlite.NewDeliveryRepository(db, test.TestPrefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return repo, cleanup
+		}
+	})
+}
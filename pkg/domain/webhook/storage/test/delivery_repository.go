@@ -0,0 +1,369 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/database"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	"github.com/zbiljic/authzy/pkg/domain/webhook/storage/json/schema"
+)
+
+const (
+	TestPrefix = "test-"
+)
+
+var testTime = time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// createDeliveries creates deliveries due one minute apart, alternating
+// between pending and dead ones.
+func createDeliveries(t *testing.T, repo webhook.DeliveryRepository, count int) []*webhook.Delivery {
+	t.Helper()
+
+	var result []*webhook.Delivery
+
+	ctx := context.Background()
+
+	statuses := []webhook.Status{webhook.StatusPending, webhook.StatusDead}
+
+	for i := 0; i < count; i++ {
+		entity := &webhook.Delivery{
+			ID:            fmt.Sprintf("delivery%02d", i),
+			EventID:       fmt.Sprintf("event%02d", i),
+			Event:         webhook.EventUserCreated,
+			URL:           "https://example.com/webhook",
+			Payload:       []byte(fmt.Sprintf(`{"id":"event%02d"}`, i)),
+			Status:        statuses[i%2],
+			Attempts:      i,
+			NextAttemptAt: testTime.Add(time.Duration(i) * time.Minute),
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		result = append(result, savedEntity)
+	}
+
+	return result
+}
+
+func deliveryIDs(deliveries []*webhook.Delivery) []string {
+	ids := make([]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+func Run(t *testing.T, f func() func(t *testing.T) (webhook.DeliveryRepository, func())) {
+	t.Helper()
+
+	t.Run("init", func(t *testing.T) {
+		_, cleanup := f()(t)
+		defer cleanup()
+	})
+	t.Run("Save", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testDeliveryRepositorySave(t, repo)
+	})
+	t.Run("FindByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testDeliveryRepositoryFindByID(t, repo)
+	})
+	t.Run("FindAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testDeliveryRepositoryFindAll(t, repo)
+	})
+	t.Run("FindAllDue", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testDeliveryRepositoryFindAllDue(t, repo)
+	})
+	t.Run("Count", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testDeliveryRepositoryCount(t, repo)
+	})
+	t.Run("DeleteByID", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testDeliveryRepositoryDeleteByID(t, repo)
+	})
+	t.Run("DeleteAll", func(t *testing.T) {
+		repo, cleanup := f()(t)
+		defer cleanup()
+
+		testDeliveryRepositoryDeleteAll(t, repo)
+	})
+}
+
+func testDeliveryRepositorySave(t *testing.T, repo webhook.DeliveryRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+	validate := validator.New()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := repo.Save(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		entity := &webhook.Delivery{}
+
+		_, err := repo.Save(ctx, entity)
+		assert.Error(t, err)
+
+		inS := schema.DeliveryToSchema(entity)
+		validateErr := validate.Struct(inS)
+
+		assert.Contains(t, err.Error(), validateErr.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := repo.Save(ctx, &webhook.Delivery{
+			ID:      "delivery",
+			EventID: "event",
+			Event:   webhook.EventUserCreated,
+			URL:     "https://example.com/webhook",
+			Payload: []byte(`{}`),
+			Status:  "unknown",
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("simple", func(t *testing.T) {
+		entity := &webhook.Delivery{
+			ID:      "delivery",
+			EventID: "event",
+			Event:   webhook.EventUserCreated,
+			URL:     "https://example.com/webhook",
+			Payload: []byte(`{"id":"event"}`),
+			Status:  webhook.StatusPending,
+		}
+
+		savedEntity, err := repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		assert.False(t, savedEntity.CreatedAt.IsZero())
+		assert.False(t, savedEntity.UpdatedAt.IsZero())
+	})
+
+	t.Run("update status", func(t *testing.T) {
+		entity, err := repo.FindByID(ctx, "delivery")
+		require.NoError(t, err)
+
+		entity.Status = webhook.StatusDead
+		entity.Attempts = 3
+		entity.LastStatusCode = 500
+		entity.LastError = "unexpected status code"
+
+		_, err = repo.Save(ctx, entity)
+		require.NoError(t, err)
+
+		pending, _, err := repo.FindAll(ctx, webhook.StatusPending, "", 0)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		dead, _, err := repo.FindAll(ctx, webhook.StatusDead, "", 0)
+		require.NoError(t, err)
+		require.Len(t, dead, 1)
+
+		assert.Equal(t, 3, dead[0].Attempts)
+		assert.Equal(t, 500, dead[0].LastStatusCode)
+	})
+}
+
+func testDeliveryRepositoryFindByID(t *testing.T, repo webhook.DeliveryRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	deliveries := createDeliveries(t, repo, 1)
+
+	t.Run("non existent", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, "non_existent_id")
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		entity, err := repo.FindByID(ctx, deliveries[0].ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, deliveries[0].ID, entity.ID)
+		assert.Equal(t, deliveries[0].EventID, entity.EventID)
+		assert.Equal(t, deliveries[0].Event, entity.Event)
+		assert.Equal(t, deliveries[0].URL, entity.URL)
+		assert.JSONEq(t, string(deliveries[0].Payload), string(entity.Payload))
+		assert.Equal(t, deliveries[0].Status, entity.Status)
+		assert.True(t, deliveries[0].NextAttemptAt.Equal(entity.NextAttemptAt))
+	})
+}
+
+func testDeliveryRepositoryFindAll(t *testing.T, repo webhook.DeliveryRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	deliveries := createDeliveries(t, repo, 10)
+
+	t.Run("all", func(t *testing.T) {
+		result, nextCursor, err := repo.FindAll(ctx, "", "", 100)
+		require.NoError(t, err)
+
+		assert.Equal(t, deliveryIDs(deliveries), deliveryIDs(result))
+		assert.Empty(t, nextCursor)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		var (
+			result []*webhook.Delivery
+			cursor string
+		)
+
+		for i := 0; i < 5; i++ {
+			page, nextCursor, err := repo.FindAll(ctx, "", cursor, 3)
+			require.NoError(t, err)
+
+			result = append(result, page...)
+
+			if nextCursor == "" {
+				break
+			}
+			cursor = nextCursor
+		}
+
+		assert.Equal(t, deliveryIDs(deliveries), deliveryIDs(result))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		result, _, err := repo.FindAll(ctx, webhook.StatusDead, "", 100)
+		require.NoError(t, err)
+
+		require.Len(t, result, 5)
+		for _, d := range result {
+			assert.Equal(t, webhook.StatusDead, d.Status)
+		}
+
+		page, nextCursor, err := repo.FindAll(ctx, webhook.StatusDead, "", 2)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+
+		page, _, err = repo.FindAll(ctx, webhook.StatusDead, nextCursor, 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, deliveryIDs(result[2:4]), deliveryIDs(page))
+	})
+}
+
+func testDeliveryRepositoryFindAllDue(t *testing.T, repo webhook.DeliveryRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	deliveries := createDeliveries(t, repo, 10)
+
+	t.Run("none", func(t *testing.T) {
+		result, err := repo.FindAllDue(ctx, testTime.Add(-time.Minute), 100)
+		require.NoError(t, err)
+
+		assert.Empty(t, result)
+	})
+
+	t.Run("due", func(t *testing.T) {
+		result, err := repo.FindAllDue(ctx, testTime.Add(4*time.Minute), 100)
+		require.NoError(t, err)
+
+		// pending only
+		assert.Equal(t, []string{deliveries[0].ID, deliveries[2].ID, deliveries[4].ID}, deliveryIDs(result))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		result, err := repo.FindAllDue(ctx, testTime.Add(time.Hour), 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{deliveries[0].ID, deliveries[2].ID}, deliveryIDs(result))
+	})
+}
+
+func testDeliveryRepositoryCount(t *testing.T, repo webhook.DeliveryRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	createDeliveries(t, repo, 5)
+
+	count, err = repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func testDeliveryRepositoryDeleteByID(t *testing.T, repo webhook.DeliveryRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	deliveries := createDeliveries(t, repo, 2)
+
+	t.Run("non existent", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, "non_existent_id")
+		require.NoError(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		err := repo.DeleteByID(ctx, deliveries[0].ID)
+		require.NoError(t, err)
+
+		_, err = repo.FindByID(ctx, deliveries[0].ID)
+		assert.True(t, errors.Is(err, database.ErrNotFound))
+
+		due, err := repo.FindAllDue(ctx, testTime.Add(time.Hour), 100)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+
+		count, err := repo.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func testDeliveryRepositoryDeleteAll(t *testing.T, repo webhook.DeliveryRepository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	createDeliveries(t, repo, 5)
+
+	err := repo.DeleteAll(ctx)
+	require.NoError(t, err)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	result, _, err := repo.FindAll(ctx, webhook.StatusDead, "", 100)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
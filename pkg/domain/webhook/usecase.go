@@ -0,0 +1,23 @@
+package webhook
+
+import "context"
+
+type WebhookUsecase interface {
+	// Enqueue queues the event for delivery to all subscribed endpoints.
+	Enqueue(ctx context.Context, event EventType, data interface{}) ([]*Delivery, error)
+
+	// DeliverDue attempts the deliveries which are due, and returns how many
+	// of them were attempted.
+	DeliverDue(ctx context.Context) (int, error)
+
+	// Redeliver queues the delivered or dead delivery again, with all the
+	// attempts available.
+	Redeliver(ctx context.Context, id string) (*Delivery, error)
+
+	// FindDeliveryByID retrieves a delivery by ID.
+	FindDeliveryByID(context.Context, string) (*Delivery, error)
+
+	// FindAllDeliveries retrieves deliveries having the status in pages,
+	// starting after the cursor.
+	FindAllDeliveries(ctx context.Context, status Status, afterCursor string, limit int) ([]*Delivery, string, error)
+}
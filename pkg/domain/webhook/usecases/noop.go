@@ -0,0 +1,33 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+)
+
+// Compile-time proof of interface implementation.
+var _ webhook.WebhookUsecase = (*noopWebhookUsecase)(nil)
+
+// noopWebhookUsecase can be embedded to have forward compatible implementations.
+type noopWebhookUsecase struct{}
+
+func (*noopWebhookUsecase) Enqueue(ctx context.Context, event webhook.EventType, data interface{}) ([]*webhook.Delivery, error) {
+	panic("Enqueue not implemented")
+}
+
+func (*noopWebhookUsecase) DeliverDue(ctx context.Context) (int, error) {
+	panic("DeliverDue not implemented")
+}
+
+func (*noopWebhookUsecase) Redeliver(ctx context.Context, id string) (*webhook.Delivery, error) {
+	panic("Redeliver not implemented")
+}
+
+func (*noopWebhookUsecase) FindDeliveryByID(ctx context.Context, id string) (*webhook.Delivery, error) {
+	panic("FindDeliveryByID not implemented")
+}
+
+func (*noopWebhookUsecase) FindAllDeliveries(ctx context.Context, status webhook.Status, afterCursor string, limit int) ([]*webhook.Delivery, string, error) {
+	panic("FindAllDeliveries not implemented")
+}
@@ -0,0 +1,242 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	oklogulid "github.com/oklog/ulid"
+
+	"github.com/zbiljic/authzy"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+	"github.com/zbiljic/authzy/pkg/ulid"
+)
+
+// deliverDueBatchSize is the maximum number of deliveries attempted at once.
+const deliverDueBatchSize = 100
+
+// maxResponseBodySize is how much of the response body is read, so that the
+// connection can be reused.
+const maxResponseBodySize = 64 << 10
+
+type webhookUsecase struct {
+	noopWebhookUsecase
+
+	config webhook.Config
+	client *http.Client
+	newID  func(time.Time) oklogulid.ULID
+
+	// serializes claiming the due deliveries, so that none are attempted
+	// twice
+	mu sync.Mutex
+
+	repository webhook.DeliveryRepository
+}
+
+func NewWebhookUsecase(
+	config webhook.Config,
+	repository webhook.DeliveryRepository,
+) webhook.WebhookUsecase {
+	uc := &webhookUsecase{
+		config: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+		},
+		newID:      ulid.Monotonic(),
+		repository: repository,
+	}
+	return uc
+}
+
+func (uc *webhookUsecase) Enqueue(ctx context.Context, event webhook.EventType, data interface{}) ([]*webhook.Delivery, error) {
+	var endpoints []*webhook.Endpoint
+	for _, e := range uc.config.Endpoints {
+		if e.IsSubscribed(event) {
+			endpoints = append(endpoints, e)
+		}
+	}
+
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+
+	eventID := uc.newID(now).String()
+
+	payload, err := json.Marshal(&webhook.Payload{
+		ID:        eventID,
+		Type:      event,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	result := make([]*webhook.Delivery, 0, len(endpoints))
+
+	for _, e := range endpoints {
+		delivery, err := uc.repository.Save(ctx, &webhook.Delivery{
+			ID:            uc.newID(now).String(),
+			EventID:       eventID,
+			Event:         event,
+			URL:           e.URL,
+			Payload:       payload,
+			Status:        webhook.StatusPending,
+			NextAttemptAt: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, delivery)
+	}
+
+	return result, nil
+}
+
+func (uc *webhookUsecase) DeliverDue(ctx context.Context) (int, error) {
+	due, err := uc.claimDue(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, d := range due {
+		if err := uc.deliver(ctx, d); err != nil {
+			return i, err
+		}
+	}
+
+	return len(due), nil
+}
+
+// claimDue returns the deliveries which are due, with their next attempt
+// postponed until they are all sent, so that concurrent runs do not attempt
+// them too. A delivery whose outcome is not saved, because the run was
+// interrupted, is attempted again once the claim runs out.
+func (uc *webhookUsecase) claimDue(ctx context.Context) ([]*webhook.Delivery, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	now := time.Now()
+
+	due, err := uc.repository.FindAllDue(ctx, now, deliverDueBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// deliveries are sent one at a time, each within the timeout
+	claimedUntil := now.Add(time.Duration(len(due)+1) * uc.config.Timeout)
+
+	for i, d := range due {
+		d.NextAttemptAt = claimedUntil
+
+		due[i], err = uc.repository.Save(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return due, nil
+}
+
+// deliver attempts the delivery once, and saves the outcome. The delivery is
+// retried with exponential backoff, until it runs out of attempts.
+func (uc *webhookUsecase) deliver(ctx context.Context, d *webhook.Delivery) error {
+	now := time.Now()
+
+	d.Attempts++
+	d.LastAttemptAt = now
+	d.LastStatusCode = 0
+	d.LastError = ""
+
+	endpoint := uc.config.FindEndpoint(d.URL)
+	if endpoint == nil {
+		// nowhere to retry
+		d.Status = webhook.StatusDead
+		d.LastError = "endpoint not configured"
+
+		_, err := uc.repository.Save(ctx, d)
+		return err
+	}
+
+	statusCode, err := uc.send(ctx, endpoint, d, now)
+	d.LastStatusCode = statusCode
+
+	switch {
+	case err == nil:
+		d.Status = webhook.StatusDelivered
+	case d.Attempts >= uc.config.MaxAttempts:
+		d.Status = webhook.StatusDead
+		d.LastError = err.Error()
+	default:
+		d.NextAttemptAt = now.Add(uc.config.Backoff(d.Attempts))
+		d.LastError = err.Error()
+	}
+
+	_, err = uc.repository.Save(ctx, d)
+
+	return err
+}
+
+// send posts the signed payload to the endpoint. Any status code other than
+// 2xx is an error.
+func (uc *webhookUsecase) send(ctx context.Context, endpoint *webhook.Endpoint, d *webhook.Delivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set(xhttp.ContentType, "application/json")
+	req.Header.Set(xhttp.UserAgent, authzy.AppName+"-webhook")
+	req.Header.Set(webhook.EventHeader, string(d.Event))
+	req.Header.Set(webhook.DeliveryHeader, d.ID)
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(endpoint.Secret, now, d.Payload))
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (uc *webhookUsecase) Redeliver(ctx context.Context, id string) (*webhook.Delivery, error) {
+	d, err := uc.repository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// pending deliveries may be attempted at the moment
+	if d.Status == webhook.StatusPending {
+		return nil, webhook.ErrDeliveryPending
+	}
+
+	d.Status = webhook.StatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = time.Now()
+
+	return uc.repository.Save(ctx, d)
+}
+
+func (uc *webhookUsecase) FindDeliveryByID(ctx context.Context, id string) (*webhook.Delivery, error) {
+	return uc.repository.FindByID(ctx, id)
+}
+
+func (uc *webhookUsecase) FindAllDeliveries(ctx context.Context, status webhook.Status, afterCursor string, limit int) ([]*webhook.Delivery, string, error) {
+	return uc.repository.FindAll(ctx, status, afterCursor, limit)
+}
@@ -11,6 +11,29 @@ type Hasher interface {
 	Generate(ctx context.Context, password []byte) ([]byte, error)
 }
 
+// HashProvider provides the hasher used for generating new hashes.
 type HashProvider interface {
 	Hasher() Hasher
 }
+
+// Identifier is implemented by hashers which are able to recognize hashes
+// generated by their algorithm, usually from the hash prefix.
+type Identifier interface {
+	// Understands returns whether the hash was generated by this hasher.
+	Understands(hash []byte) bool
+}
+
+// Rehasher is implemented by hashers which are able to detect hashes that
+// were generated using outdated algorithm or parameters.
+type Rehasher interface {
+	// NeedsRehash returns whether the hash should be generated again.
+	NeedsRehash(ctx context.Context, hash []byte) bool
+}
+
+// MemoryEstimator is implemented by hashers which are able to estimate the
+// memory required for hashing, in KiB.
+type MemoryEstimator interface {
+	// Memory returns the memory needed to compare a password against the
+	// hash, or to generate a new hash if the hash is nil.
+	Memory(hash []byte) uint32
+}
@@ -28,6 +28,8 @@ const (
 	Argon2DefaultKeyLength   uint32 = 32
 )
 
+const argon2Prefix = "$argon2id$"
+
 var Argon2CPUParallelism = uint8(runtime.NumCPU() * 2)
 
 type HasherArgon2Config struct {
@@ -62,8 +64,8 @@ func (h *Argon2) Generate(ctx context.Context, password []byte) ([]byte, error)
 	var b bytes.Buffer
 	if _, err := fmt.Fprintf(
 		&b,
-		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
-		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
 		base64.RawStdEncoding.EncodeToString(salt),
 		base64.RawStdEncoding.EncodeToString(hash),
 	); err != nil {
@@ -94,6 +96,41 @@ func (h *Argon2) Compare(ctx context.Context, password []byte, hash []byte) erro
 	return ErrMismatchedHashAndPassword
 }
 
+func (h *Argon2) Understands(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(argon2Prefix))
+}
+
+// NeedsRehash returns true if the hash was not generated with Argon2id, or if
+// its memory, iterations or parallelism parameters do not match the current
+// configuration.
+func (h *Argon2) NeedsRehash(ctx context.Context, hash []byte) bool {
+	if !h.Understands(hash) {
+		return true
+	}
+
+	p, _, _, err := decodeHash(string(hash))
+	if err != nil {
+		return true
+	}
+
+	return p.Memory != h.c.Memory ||
+		p.Iterations != h.c.Iterations ||
+		p.Parallelism != h.c.Parallelism
+}
+
+func (h *Argon2) Memory(hash []byte) uint32 {
+	if hash == nil {
+		return h.c.Memory
+	}
+
+	p, _, _, err := decodeHash(string(hash))
+	if err != nil {
+		return 0
+	}
+
+	return p.Memory
+}
+
 func decodeHash(encodedHash string) (p *HasherArgon2Config, salt, hash []byte, err error) {
 	parts := strings.Split(encodedHash, "$")
 	if len(parts) != 6 {
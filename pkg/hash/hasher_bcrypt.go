@@ -0,0 +1,65 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	BcryptDefaultCost = bcrypt.DefaultCost
+
+	// bcryptMaxPasswordLength is the maximum password length, as bcrypt
+	// silently ignores any bytes past it.
+	bcryptMaxPasswordLength = 72
+)
+
+var ErrPasswordTooLong = errors.New("password length exceeds 72 bytes")
+
+var bcryptPrefixes = [][]byte{
+	[]byte("$2a$"),
+	[]byte("$2b$"),
+	[]byte("$2x$"),
+	[]byte("$2y$"),
+}
+
+type Bcrypt struct {
+	cost int
+}
+
+func NewHasherBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{cost: cost}
+}
+
+func (h *Bcrypt) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	if len(password) > bcryptMaxPasswordLength {
+		return nil, ErrPasswordTooLong
+	}
+
+	return bcrypt.GenerateFromPassword(password, h.cost)
+}
+
+func (h *Bcrypt) Compare(ctx context.Context, password []byte, hash []byte) error {
+	err := bcrypt.CompareHashAndPassword(hash, password)
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatchedHashAndPassword
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (h *Bcrypt) Understands(hash []byte) bool {
+	for _, prefix := range bcryptPrefixes {
+		if bytes.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
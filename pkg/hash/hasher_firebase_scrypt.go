@@ -0,0 +1,147 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const firebaseScryptPrefix = "$firebase-scrypt$"
+
+const (
+	FirebaseScryptDefaultRounds     uint32 = 8
+	FirebaseScryptDefaultMemCost    uint32 = 14
+	FirebaseScryptDefaultSaltLength uint32 = 16
+)
+
+var ErrFirebaseScryptNotConfigured = errors.New("firebase scrypt signer key is not configured")
+
+// HasherFirebaseScryptConfig holds the project wide parameters of the
+// Firebase modified scrypt algorithm, as found in the Firebase console.
+// SignerKey and SaltSeparator are standard base64 encoded.
+type HasherFirebaseScryptConfig struct {
+	SignerKey     string `json:"signer_key" split_words:"true"`
+	SaltSeparator string `json:"salt_separator" split_words:"true"`
+	Rounds        uint32 `json:"rounds" default:"8"`
+	MemCost       uint32 `json:"mem_cost" split_words:"true" default:"14"`
+}
+
+// FirebaseScrypt compares hashes exported from Firebase Authentication, stored
+// in the "$firebase-scrypt$r=<rounds>,m=<mem cost>$<salt>$<hash>" format, with
+// salt and hash encoded using unpadded standard base64.
+type FirebaseScrypt struct {
+	c HasherFirebaseScryptConfig
+}
+
+func NewHasherFirebaseScrypt(c HasherFirebaseScryptConfig) *FirebaseScrypt {
+	return &FirebaseScrypt{c: c}
+}
+
+func (h *FirebaseScrypt) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	p := h.c
+
+	salt := make([]byte, FirebaseScryptDefaultSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	hash, err := h.key(password, salt, p.Rounds, p.MemCost)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if _, err := fmt.Fprintf(
+		&b,
+		"%sr=%d,m=%d$%s$%s",
+		firebaseScryptPrefix, p.Rounds, p.MemCost,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func (h *FirebaseScrypt) Compare(ctx context.Context, password []byte, hash []byte) error {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 5 {
+		return ErrInvalidHash
+	}
+
+	var rounds, memCost uint32
+	_, err := fmt.Sscanf(parts[2], "r=%d,m=%d", &rounds, &memCost)
+	if err != nil {
+		return err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return err
+	}
+
+	decodedHash, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return err
+	}
+
+	otherHash, err := h.key(password, salt, rounds, memCost)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(decodedHash, otherHash) == 1 {
+		return nil
+	}
+
+	return ErrMismatchedHashAndPassword
+}
+
+func (h *FirebaseScrypt) Understands(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(firebaseScryptPrefix))
+}
+
+// key derives the scrypt key from the password, salt and salt separator, and
+// uses it to encrypt the signer key with AES-256 in CTR mode.
+func (h *FirebaseScrypt) key(password, salt []byte, rounds, memCost uint32) ([]byte, error) {
+	if h.c.SignerKey == "" {
+		return nil, ErrFirebaseScryptNotConfigured
+	}
+
+	signerKey, err := base64.StdEncoding.DecodeString(h.c.SignerKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode signer key: %w", err)
+	}
+
+	saltSeparator, err := base64.StdEncoding.DecodeString(h.c.SaltSeparator)
+	if err != nil {
+		return nil, fmt.Errorf("decode salt separator: %w", err)
+	}
+
+	derivedKey, err := scrypt.Key(password, append(salt, saltSeparator...), 1<<memCost, int(rounds), 1, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	out := make([]byte, len(signerKey))
+	iv := make([]byte, aes.BlockSize)
+
+	cipher.NewCTR(block, iv).XORKeyStream(out, signerKey)
+
+	return out, nil
+}
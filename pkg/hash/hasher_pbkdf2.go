@@ -0,0 +1,136 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	pbkdf2SHA256Prefix = "$pbkdf2-sha256$"
+	pbkdf2SHA512Prefix = "$pbkdf2-sha512$"
+)
+
+const (
+	PBKDF2DefaultIterations uint32 = 310000
+	PBKDF2DefaultSaltLength uint32 = 16
+	PBKDF2DefaultKeyLength  uint32 = 32
+)
+
+type HasherPBKDF2Config struct {
+	Iterations uint32 `json:"iterations"`
+	SaltLength uint32 `json:"salt_length" split_words:"true"`
+	KeyLength  uint32 `json:"key_length" split_words:"true"`
+}
+
+// PBKDF2 generates and compares PBKDF2-SHA256 hashes in the
+// "$pbkdf2-sha256$i=<iterations>$<salt>$<hash>" format, with salt and hash
+// encoded using unpadded standard base64. PBKDF2-SHA512 hashes in the same
+// format, with the "$pbkdf2-sha512$" prefix, are compared as well.
+type PBKDF2 struct {
+	c HasherPBKDF2Config
+}
+
+func NewHasherPBKDF2(c HasherPBKDF2Config) *PBKDF2 {
+	return &PBKDF2{c: c}
+}
+
+func (h *PBKDF2) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	p := h.c
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	hash := pbkdf2.Key(password, salt, int(p.Iterations), int(p.KeyLength), sha256.New)
+
+	var b bytes.Buffer
+	if _, err := fmt.Fprintf(
+		&b,
+		"%si=%d$%s$%s",
+		pbkdf2SHA256Prefix, p.Iterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func (h *PBKDF2) Compare(ctx context.Context, password []byte, hash []byte) error {
+	p, salt, hash, err := decodePBKDF2Hash(string(hash))
+	if err != nil {
+		return err
+	}
+
+	otherHash := pbkdf2.Key(password, salt, int(p.Iterations), int(p.KeyLength), p.hashFunc)
+
+	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
+		return nil
+	}
+
+	return ErrMismatchedHashAndPassword
+}
+
+func (h *PBKDF2) Understands(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(pbkdf2SHA256Prefix)) ||
+		bytes.HasPrefix(hash, []byte(pbkdf2SHA512Prefix))
+}
+
+type pbkdf2Params struct {
+	HasherPBKDF2Config
+
+	hashFunc func() hash.Hash
+}
+
+func decodePBKDF2Hash(encodedHash string) (p *pbkdf2Params, salt, hash []byte, err error) {
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 5 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	p = new(pbkdf2Params)
+
+	switch "$" + parts[1] + "$" {
+	case pbkdf2SHA256Prefix:
+		p.hashFunc = sha256.New
+	case pbkdf2SHA512Prefix:
+		p.hashFunc = sha512.New
+	default:
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	_, err = fmt.Sscanf(parts[2], "i=%d", &p.Iterations)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if p.Iterations == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	p.SaltLength = uint32(len(salt))
+
+	hash, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	p.KeyLength = uint32(len(hash))
+
+	return p, salt, hash, nil
+}
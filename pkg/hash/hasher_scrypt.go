@@ -0,0 +1,140 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const scryptPrefix = "$scrypt$"
+
+const (
+	ScryptDefaultCost        uint8  = 15
+	ScryptDefaultBlockSize   uint32 = 8
+	ScryptDefaultParallelism uint32 = 1
+	ScryptDefaultSaltLength  uint32 = 16
+	ScryptDefaultKeyLength   uint32 = 32
+)
+
+// HasherScryptConfig holds scrypt parameters. Cost is the base 2 logarithm
+// of the CPU/memory cost parameter N.
+type HasherScryptConfig struct {
+	Cost        uint8  `json:"cost"`
+	BlockSize   uint32 `json:"block_size" split_words:"true"`
+	Parallelism uint32 `json:"parallelism"`
+	SaltLength  uint32 `json:"salt_length" split_words:"true"`
+	KeyLength   uint32 `json:"key_length" split_words:"true"`
+}
+
+// Scrypt generates and compares hashes in the
+// "$scrypt$ln=<cost>,r=<block size>,p=<parallelism>$<salt>$<hash>" format,
+// with salt and hash encoded using unpadded standard base64.
+type Scrypt struct {
+	c HasherScryptConfig
+}
+
+func NewHasherScrypt(c HasherScryptConfig) *Scrypt {
+	return &Scrypt{c: c}
+}
+
+func (h *Scrypt) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	p := h.c
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	hash, err := scrypt.Key(password, salt, 1<<p.Cost, int(p.BlockSize), int(p.Parallelism), int(p.KeyLength))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var b bytes.Buffer
+	if _, err := fmt.Fprintf(
+		&b,
+		"%sln=%d,r=%d,p=%d$%s$%s",
+		scryptPrefix, p.Cost, p.BlockSize, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func (h *Scrypt) Compare(ctx context.Context, password []byte, hash []byte) error {
+	p, salt, hash, err := decodeScryptHash(string(hash))
+	if err != nil {
+		return err
+	}
+
+	otherHash, err := scrypt.Key(password, salt, 1<<p.Cost, int(p.BlockSize), int(p.Parallelism), int(p.KeyLength))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
+		return nil
+	}
+
+	return ErrMismatchedHashAndPassword
+}
+
+func (h *Scrypt) Understands(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(scryptPrefix))
+}
+
+func (h *Scrypt) Memory(hash []byte) uint32 {
+	p := &h.c
+	if hash != nil {
+		var err error
+		p, _, _, err = decodeScryptHash(string(hash))
+		if err != nil {
+			return 0
+		}
+	}
+
+	return scryptMemory(p.Cost, p.BlockSize)
+}
+
+// scryptMemory returns the memory used by scrypt in KiB, which is
+// 128 * r * N bytes.
+func scryptMemory(cost uint8, blockSize uint32) uint32 {
+	return uint32((uint64(128) * uint64(blockSize) << cost) / 1024)
+}
+
+func decodeScryptHash(encodedHash string) (p *HasherScryptConfig, salt, hash []byte, err error) {
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 5 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	p = new(HasherScryptConfig)
+	_, err = fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &p.Cost, &p.BlockSize, &p.Parallelism)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	p.SaltLength = uint32(len(salt))
+
+	hash, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	p.KeyLength = uint32(len(hash))
+
+	return p, salt, hash, nil
+}
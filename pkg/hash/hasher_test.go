@@ -3,11 +3,14 @@ package hash_test
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pbkdf2"
 
 	"github.com/zbiljic/authzy/pkg/hash"
 )
@@ -19,6 +22,13 @@ func mkpw(t *testing.T, length int) []byte {
 	return pw
 }
 
+var firebaseScryptConfig = hash.HasherFirebaseScryptConfig{
+	SignerKey:     "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==",
+	SaltSeparator: "Bw==",
+	Rounds:        8,
+	MemCost:       10,
+}
+
 func TestHasher(t *testing.T) {
 	for k, pw := range [][]byte{
 		mkpw(t, 8),
@@ -37,8 +47,28 @@ func TestHasher(t *testing.T) {
 			}
 			for kk, h := range []hash.Hasher{
 				hash.NewHasherArgon2(config),
+				hash.NewHasherBcrypt(4),
+				hash.NewHasherScrypt(hash.HasherScryptConfig{
+					Cost:        10,
+					BlockSize:   8,
+					Parallelism: 1,
+					SaltLength:  16,
+					KeyLength:   32,
+				}),
+				hash.NewHasherPBKDF2(hash.HasherPBKDF2Config{
+					Iterations: 1000,
+					SaltLength: 16,
+					KeyLength:  32,
+				}),
+				hash.NewHasherFirebaseScrypt(firebaseScryptConfig),
 			} {
 				t.Run(fmt.Sprintf("hasher=%T/password=%d", h, kk), func(t *testing.T) {
+					if _, ok := h.(*hash.Bcrypt); ok && len(pw) > 72 {
+						_, err := h.Generate(context.Background(), pw)
+						require.ErrorIs(t, err, hash.ErrPasswordTooLong)
+						return
+					}
+
 					hs, err := h.Generate(context.Background(), pw)
 					require.NoError(t, err)
 					assert.NotEqual(t, pw, hs)
@@ -50,8 +80,30 @@ func TestHasher(t *testing.T) {
 					copy(mod, pw)
 					mod[len(pw)-1] = ^pw[len(pw)-1]
 					require.Error(t, h.Compare(context.Background(), mod, hs))
+
+					if id, ok := h.(hash.Identifier); ok {
+						assert.True(t, id.Understands(hs))
+					}
 				})
 			}
 		})
 	}
 }
+
+func TestPBKDF2SHA512(t *testing.T) {
+	h := hash.NewHasherPBKDF2(hash.HasherPBKDF2Config{})
+
+	pw := mkpw(t, 16)
+	salt := mkpw(t, 16)
+
+	hs := []byte(fmt.Sprintf(
+		"$pbkdf2-sha512$i=%d$%s$%s",
+		1000,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(pbkdf2.Key(pw, salt, 1000, 64, sha512.New)),
+	))
+
+	assert.True(t, h.Understands(hs))
+	require.NoError(t, h.Compare(context.Background(), pw, hs))
+	require.Error(t, h.Compare(context.Background(), mkpw(t, 16), hs))
+}
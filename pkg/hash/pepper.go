@@ -0,0 +1,195 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const pepperPrefix = "$peppered$"
+
+var (
+	ErrUnknownPepper = errors.New("the pepper used for the hash is not configured")
+
+	pepperIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// Compile-time proof of interface implementation.
+var (
+	_ Hasher          = (*Pepper)(nil)
+	_ Identifier      = (*Pepper)(nil)
+	_ Rehasher        = (*Pepper)(nil)
+	_ MemoryEstimator = (*Pepper)(nil)
+)
+
+// HasherPepperConfig holds the server-side secrets mixed into passwords before
+// hashing. Peppers are standard base64 encoded and identified by an ID, which
+// is stored with the hash. Peppers can be set directly, or loaded from a JSON
+// file containing an object of IDs mapped to peppers.
+type HasherPepperConfig struct {
+	Current string            `json:"current"`
+	Peppers map[string]string `json:"-" envconfig:"peppers"`
+	File    string            `json:"file"`
+}
+
+// Enabled returns whether any pepper is configured.
+func (c *HasherPepperConfig) Enabled() bool {
+	return c != nil && c.Current != ""
+}
+
+// Pepper wraps a hasher, applying HMAC-SHA256 with a secret pepper to the
+// password before it is passed to the wrapped hasher. Hashes are stored in the
+// "$peppered$<pepper id><wrapped hash>" format, so that
+// passwords can be verified with previous peppers after rotation.
+type Pepper struct {
+	hasher  Hasher
+	current string
+	peppers map[string][]byte
+}
+
+func NewHasherPepper(hasher Hasher, c HasherPepperConfig) (*Pepper, error) {
+	encoded := make(map[string]string)
+
+	if c.File != "" {
+		data, err := os.ReadFile(c.File)
+		if err != nil {
+			return nil, fmt.Errorf("read pepper file: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &encoded); err != nil {
+			return nil, fmt.Errorf("parse pepper file: %w", err)
+		}
+	}
+
+	for id, pepper := range c.Peppers {
+		encoded[id] = pepper
+	}
+
+	peppers := make(map[string][]byte, len(encoded))
+	for id, pepper := range encoded {
+		if !pepperIDRegexp.MatchString(id) {
+			return nil, fmt.Errorf("invalid pepper id: %q", id)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(pepper)
+		if err != nil {
+			return nil, fmt.Errorf("decode pepper %s: %w", id, err)
+		}
+
+		if len(decoded) == 0 {
+			return nil, fmt.Errorf("empty pepper: %s", id)
+		}
+
+		peppers[id] = decoded
+	}
+
+	if _, ok := peppers[c.Current]; !ok {
+		return nil, fmt.Errorf("current pepper missing: %s", c.Current)
+	}
+
+	return &Pepper{
+		hasher:  hasher,
+		current: c.Current,
+		peppers: peppers,
+	}, nil
+}
+
+func (h *Pepper) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	hash, err := h.hasher.Generate(ctx, h.pepper(h.current, password))
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(pepperPrefix)
+	b.WriteString(h.current)
+	b.Write(hash)
+
+	return b.Bytes(), nil
+}
+
+func (h *Pepper) Compare(ctx context.Context, password []byte, hash []byte) error {
+	id, inner, err := decodePepperHash(hash)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := h.peppers[id]; !ok {
+		return ErrUnknownPepper
+	}
+
+	return h.hasher.Compare(ctx, h.pepper(id, password), inner)
+}
+
+func (h *Pepper) Understands(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(pepperPrefix))
+}
+
+// NeedsRehash returns true if the hash is not peppered with the current
+// pepper, or if the wrapped hasher reports it as outdated.
+func (h *Pepper) NeedsRehash(ctx context.Context, hash []byte) bool {
+	id, inner, err := decodePepperHash(hash)
+	if err != nil || id != h.current {
+		return true
+	}
+
+	if rh, ok := h.hasher.(Rehasher); ok {
+		return rh.NeedsRehash(ctx, inner)
+	}
+
+	return false
+}
+
+func (h *Pepper) Memory(hash []byte) uint32 {
+	me, ok := h.hasher.(MemoryEstimator)
+	if !ok {
+		return 0
+	}
+
+	if hash == nil {
+		return me.Memory(nil)
+	}
+
+	_, inner, err := decodePepperHash(hash)
+	if err != nil {
+		return 0
+	}
+
+	return me.Memory(inner)
+}
+
+func (h *Pepper) pepper(id string, password []byte) []byte {
+	mac := hmac.New(sha256.New, h.peppers[id])
+	mac.Write(password)
+
+	// encoded, as some hashers do not handle arbitrary bytes
+	sum := mac.Sum(nil)
+	out := make([]byte, base64.RawStdEncoding.EncodedLen(len(sum)))
+	base64.RawStdEncoding.Encode(out, sum)
+
+	return out
+}
+
+// decodePepperHash returns the pepper ID and the wrapped hash.
+func decodePepperHash(hash []byte) (string, []byte, error) {
+	rest := strings.TrimPrefix(string(hash), pepperPrefix)
+	if len(rest) == len(hash) {
+		return "", nil, ErrInvalidHash
+	}
+
+	i := strings.IndexByte(rest, '$')
+	if i <= 0 {
+		return "", nil, ErrInvalidHash
+	}
+
+	return rest[:i], []byte(rest[i:]), nil
+}
@@ -0,0 +1,98 @@
+package hash_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/hash"
+)
+
+func TestPepper(t *testing.T) {
+	ctx := context.Background()
+	pw := mkpw(t, 16)
+
+	argon2 := hash.NewHasherArgon2(hash.HasherArgon2Config{
+		Memory:      16384,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   16,
+	})
+
+	v1, err := hash.NewHasherPepper(argon2, hash.HasherPepperConfig{
+		Current: "v1",
+		Peppers: map[string]string{"v1": "c2VjcmV0LXBlcHBlci0x"},
+	})
+	require.NoError(t, err)
+
+	hs, err := v1.Generate(ctx, pw)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(string(hs), "$peppered$v1$argon2id$"))
+	assert.True(t, v1.Understands(hs))
+	require.NoError(t, v1.Compare(ctx, pw, hs))
+	require.ErrorIs(t, v1.Compare(ctx, mkpw(t, 16), hs), hash.ErrMismatchedHashAndPassword)
+	assert.False(t, v1.NeedsRehash(ctx, hs))
+
+	// the pepper is required to verify the wrapped hash
+	inner := strings.TrimPrefix(string(hs), "$peppered$v1")
+	require.ErrorIs(t, argon2.Compare(ctx, pw, []byte(inner)), hash.ErrMismatchedHashAndPassword)
+
+	t.Run("rotation", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "peppers.json")
+		require.NoError(t, os.WriteFile(file, []byte(`{"v1":"c2VjcmV0LXBlcHBlci0x"}`), 0600))
+
+		v2, err := hash.NewHasherPepper(argon2, hash.HasherPepperConfig{
+			Current: "v2",
+			Peppers: map[string]string{"v2": "c2VjcmV0LXBlcHBlci0y"},
+			File:    file,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, v2.Compare(ctx, pw, hs))
+		assert.True(t, v2.NeedsRehash(ctx, hs))
+
+		rehashed, err := v2.Generate(ctx, pw)
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(string(rehashed), "$peppered$v2$"))
+		assert.False(t, v2.NeedsRehash(ctx, rehashed))
+
+		// removed pepper
+		require.ErrorIs(t, v1.Compare(ctx, pw, rehashed), hash.ErrUnknownPepper)
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		registry := hash.NewRegistry(v1, argon2)
+
+		unpeppered, err := argon2.Generate(ctx, pw)
+		require.NoError(t, err)
+
+		require.NoError(t, registry.Compare(ctx, pw, unpeppered))
+		assert.True(t, registry.NeedsRehash(ctx, unpeppered))
+
+		require.NoError(t, registry.Compare(ctx, pw, hs))
+		assert.False(t, registry.NeedsRehash(ctx, hs))
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := hash.NewHasherPepper(argon2, hash.HasherPepperConfig{
+			Current: "v2",
+			Peppers: map[string]string{"v1": "c2VjcmV0LXBlcHBlci0x"},
+		})
+		assert.Error(t, err)
+
+		_, err = hash.NewHasherPepper(argon2, hash.HasherPepperConfig{
+			Current: "v$1",
+			Peppers: map[string]string{"v$1": "c2VjcmV0LXBlcHBlci0x"},
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,101 @@
+package hash
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownHashAlgorithm = errors.New("the hash algorithm is not supported")
+
+// Compile-time proof of interface implementation.
+var (
+	_ Hasher          = (*Registry)(nil)
+	_ HashProvider    = (*Registry)(nil)
+	_ Rehasher        = (*Registry)(nil)
+	_ MemoryEstimator = (*Registry)(nil)
+)
+
+// Registry generates hashes with the current hasher, and compares passwords
+// using the hasher which understands the stored hash. This allows verifying
+// hashes imported from other systems, or generated with previous algorithms.
+type Registry struct {
+	current   Hasher
+	verifiers []Hasher
+}
+
+// NewRegistry returns a registry which generates hashes using the current
+// hasher. Verifiers should implement Identifier to be selected on compare.
+func NewRegistry(current Hasher, verifiers ...Hasher) *Registry {
+	return &Registry{
+		current:   current,
+		verifiers: verifiers,
+	}
+}
+
+// Hasher returns the hasher used for generating new hashes.
+func (r *Registry) Hasher() Hasher {
+	return r.current
+}
+
+func (r *Registry) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	return r.current.Generate(ctx, password)
+}
+
+func (r *Registry) Compare(ctx context.Context, password []byte, hash []byte) error {
+	h, ok := r.verifier(hash)
+	if !ok {
+		return ErrUnknownHashAlgorithm
+	}
+
+	return h.Compare(ctx, password, hash)
+}
+
+// NeedsRehash returns true if the hash was generated by any hasher other than
+// the current one, or if the current hasher reports it as outdated.
+func (r *Registry) NeedsRehash(ctx context.Context, hash []byte) bool {
+	h, ok := r.verifier(hash)
+	if !ok {
+		return false
+	}
+
+	if h != r.current {
+		return true
+	}
+
+	if rh, ok := h.(Rehasher); ok {
+		return rh.NeedsRehash(ctx, hash)
+	}
+
+	return false
+}
+
+func (r *Registry) Memory(hash []byte) uint32 {
+	h := r.current
+	if hash != nil {
+		var ok bool
+		if h, ok = r.verifier(hash); !ok {
+			return 0
+		}
+	}
+
+	if me, ok := h.(MemoryEstimator); ok {
+		return me.Memory(hash)
+	}
+
+	return 0
+}
+
+func (r *Registry) verifier(hash []byte) (Hasher, bool) {
+	if id, ok := r.current.(Identifier); !ok || id.Understands(hash) {
+		return r.current, true
+	}
+
+	for _, h := range r.verifiers {
+		if id, ok := h.(Identifier); ok && id.Understands(hash) {
+			return h, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,73 @@
+package hash_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/hash"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	pw := mkpw(t, 16)
+
+	config := hash.HasherArgon2Config{
+		Memory:      16384,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   16,
+	}
+
+	current := hash.NewHasherArgon2(config)
+	bcrypt := hash.NewHasherBcrypt(4)
+	pbkdf2 := hash.NewHasherPBKDF2(hash.HasherPBKDF2Config{
+		Iterations: 1000,
+		SaltLength: 16,
+		KeyLength:  32,
+	})
+
+	registry := hash.NewRegistry(current, bcrypt, pbkdf2)
+
+	assert.Equal(t, current, registry.Hasher())
+
+	t.Run("current", func(t *testing.T) {
+		hs, err := registry.Generate(ctx, pw)
+		require.NoError(t, err)
+
+		require.NoError(t, registry.Compare(ctx, pw, hs))
+		assert.False(t, registry.NeedsRehash(ctx, hs))
+	})
+
+	t.Run("verifiers", func(t *testing.T) {
+		for _, h := range []hash.Hasher{bcrypt, pbkdf2} {
+			hs, err := h.Generate(ctx, pw)
+			require.NoError(t, err)
+
+			require.NoError(t, registry.Compare(ctx, pw, hs))
+			require.ErrorIs(t, registry.Compare(ctx, mkpw(t, 16), hs), hash.ErrMismatchedHashAndPassword)
+			assert.True(t, registry.NeedsRehash(ctx, hs))
+		}
+	})
+
+	t.Run("outdated parameters", func(t *testing.T) {
+		outdated := config
+		outdated.Iterations = 2
+
+		hs, err := hash.NewHasherArgon2(outdated).Generate(ctx, pw)
+		require.NoError(t, err)
+
+		require.NoError(t, registry.Compare(ctx, pw, hs))
+		assert.True(t, registry.NeedsRehash(ctx, hs))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		hs := []byte("$unknown$hash")
+
+		require.ErrorIs(t, registry.Compare(ctx, pw, hs), hash.ErrUnknownHashAlgorithm)
+		assert.False(t, registry.NeedsRehash(ctx, hs))
+	})
+}
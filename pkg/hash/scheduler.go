@@ -0,0 +1,294 @@
+package hash
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrHasherSaturated = errors.New("password hasher is saturated")
+
+// Compile-time proof of interface implementation.
+var (
+	_ Hasher       = (*Scheduler)(nil)
+	_ HashProvider = (*Scheduler)(nil)
+	_ Rehasher     = (*Scheduler)(nil)
+)
+
+// HasherSchedulerConfig limits the resources used for password hashing. Zero
+// values disable the respective limit.
+type HasherSchedulerConfig struct {
+	// MaxConcurrent is the maximum number of hashes computed at once.
+	MaxConcurrent int `json:"max_concurrent" split_words:"true" default:"4"`
+	// MaxMemory is the maximum memory, in KiB, used by all running hashes.
+	MaxMemory uint64 `json:"max_memory" split_words:"true" default:"8388608"`
+	// MaxQueue is the maximum number of hashes waiting to be computed.
+	MaxQueue int `json:"max_queue" split_words:"true" default:"64"`
+	// QueueTimeout is the maximum time a hash waits to be computed.
+	QueueTimeout time.Duration `json:"queue_timeout" split_words:"true" default:"10s"`
+}
+
+// SchedulerStats contains the runtime statistics of the scheduler.
+type SchedulerStats struct {
+	Running     int    `json:"running"`
+	QueueDepth  int    `json:"queue_depth"`
+	MemoryInUse uint64 `json:"memory_in_use"`
+
+	Completed uint64 `json:"completed"`
+	Rejected  uint64 `json:"rejected"`
+	TimedOut  uint64 `json:"timed_out"`
+
+	AverageWait     time.Duration `json:"average_wait"`
+	MaxWait         time.Duration `json:"max_wait"`
+	AverageDuration time.Duration `json:"average_duration"`
+	MaxDuration     time.Duration `json:"max_duration"`
+}
+
+type schedulerWaiter struct {
+	memory uint64
+	ready  chan struct{}
+}
+
+// Scheduler wraps a hasher, bounding the number of concurrent hashes and the
+// total memory they use. Hashes which can not be computed immediately wait in
+// a FIFO queue, and ErrHasherSaturated is returned if the queue is full or the
+// queue timeout expires.
+type Scheduler struct {
+	hasher Hasher
+	c      HasherSchedulerConfig
+
+	mu      sync.Mutex
+	running int
+	memory  uint64
+	waiters list.List
+
+	completed     uint64
+	rejected      uint64
+	timedOut      uint64
+	totalWait     time.Duration
+	maxWait       time.Duration
+	totalDuration time.Duration
+	maxDuration   time.Duration
+}
+
+func NewScheduler(hasher Hasher, c HasherSchedulerConfig) *Scheduler {
+	return &Scheduler{
+		hasher: hasher,
+		c:      c,
+	}
+}
+
+// Hasher returns the hasher used for generating new hashes.
+func (s *Scheduler) Hasher() Hasher {
+	if hp, ok := s.hasher.(HashProvider); ok {
+		return hp.Hasher()
+	}
+
+	return s.hasher
+}
+
+func (s *Scheduler) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	var out []byte
+
+	err := s.schedule(ctx, nil, func() error {
+		var err error
+		out, err = s.hasher.Generate(ctx, password)
+		return err
+	})
+
+	return out, err
+}
+
+func (s *Scheduler) Compare(ctx context.Context, password []byte, hash []byte) error {
+	return s.schedule(ctx, hash, func() error {
+		return s.hasher.Compare(ctx, password, hash)
+	})
+}
+
+func (s *Scheduler) NeedsRehash(ctx context.Context, hash []byte) bool {
+	if rh, ok := s.hasher.(Rehasher); ok {
+		return rh.NeedsRehash(ctx, hash)
+	}
+
+	return false
+}
+
+// Stats returns the current statistics of the scheduler.
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := SchedulerStats{
+		Running:     s.running,
+		QueueDepth:  s.waiters.Len(),
+		MemoryInUse: s.memory,
+		Completed:   s.completed,
+		Rejected:    s.rejected,
+		TimedOut:    s.timedOut,
+		MaxWait:     s.maxWait,
+		MaxDuration: s.maxDuration,
+	}
+
+	if s.completed > 0 {
+		stats.AverageWait = s.totalWait / time.Duration(s.completed)
+		stats.AverageDuration = s.totalDuration / time.Duration(s.completed)
+	}
+
+	return stats
+}
+
+func (s *Scheduler) schedule(ctx context.Context, hash []byte, fn func() error) error {
+	memory := s.estimateMemory(hash)
+
+	queuedAt := time.Now()
+
+	if err := s.acquire(ctx, memory); err != nil {
+		return err
+	}
+
+	startedAt := time.Now()
+
+	err := fn()
+
+	s.release(memory, startedAt.Sub(queuedAt), time.Since(startedAt))
+
+	return err
+}
+
+func (s *Scheduler) estimateMemory(hash []byte) uint64 {
+	me, ok := s.hasher.(MemoryEstimator)
+	if !ok {
+		return 0
+	}
+
+	memory := uint64(me.Memory(hash))
+
+	// a hash larger than the limit is allowed to run on its own
+	if s.c.MaxMemory > 0 && memory > s.c.MaxMemory {
+		memory = s.c.MaxMemory
+	}
+
+	return memory
+}
+
+func (s *Scheduler) acquire(ctx context.Context, memory uint64) error {
+	s.mu.Lock()
+
+	if s.waiters.Len() == 0 && s.fits(memory) {
+		s.take(memory)
+		s.mu.Unlock()
+		return nil
+	}
+
+	if s.c.MaxQueue > 0 && s.waiters.Len() >= s.c.MaxQueue {
+		s.rejected++
+		s.mu.Unlock()
+		return ErrHasherSaturated
+	}
+
+	w := &schedulerWaiter{
+		memory: memory,
+		ready:  make(chan struct{}),
+	}
+	elem := s.waiters.PushBack(w)
+
+	s.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if s.c.QueueTimeout > 0 {
+		timer := time.NewTimer(s.c.QueueTimeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	var err error
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrHasherSaturated
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		// acquired while giving up, give it back
+		s.running--
+		s.memory -= memory
+	default:
+		s.waiters.Remove(elem)
+	}
+
+	if errors.Is(err, ErrHasherSaturated) {
+		s.timedOut++
+	}
+
+	s.notify()
+
+	return err
+}
+
+func (s *Scheduler) release(memory uint64, wait, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running--
+	s.memory -= memory
+
+	s.completed++
+	s.totalWait += wait
+	s.totalDuration += duration
+	if wait > s.maxWait {
+		s.maxWait = wait
+	}
+	if duration > s.maxDuration {
+		s.maxDuration = duration
+	}
+
+	s.notify()
+}
+
+func (s *Scheduler) fits(memory uint64) bool {
+	if s.c.MaxConcurrent > 0 && s.running >= s.c.MaxConcurrent {
+		return false
+	}
+
+	if s.c.MaxMemory > 0 && s.memory+memory > s.c.MaxMemory {
+		return false
+	}
+
+	return true
+}
+
+func (s *Scheduler) take(memory uint64) {
+	s.running++
+	s.memory += memory
+}
+
+// notify wakes up waiters from the front of the queue while they fit.
+func (s *Scheduler) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+
+		w := front.Value.(*schedulerWaiter)
+		if !s.fits(w.memory) {
+			return
+		}
+
+		s.take(w.memory)
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,188 @@
+package hash_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/hash"
+	mockhasher "github.com/zbiljic/authzy/pkg/hash/mock"
+)
+
+// blockingHasher blocks every hash until released.
+type blockingHasher struct {
+	hash.Hasher
+
+	memory  uint32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingHasher(memory uint32) *blockingHasher {
+	return &blockingHasher{
+		Hasher:  mockhasher.NewMockHasher(),
+		memory:  memory,
+		started: make(chan struct{}, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (h *blockingHasher) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	h.started <- struct{}{}
+	<-h.release
+	return h.Hasher.Generate(ctx, password)
+}
+
+func (h *blockingHasher) Memory(hash []byte) uint32 {
+	return h.memory
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("max concurrent", func(t *testing.T) {
+		h := newBlockingHasher(0)
+		s := hash.NewScheduler(h, hash.HasherSchedulerConfig{
+			MaxConcurrent: 2,
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := s.Generate(ctx, []byte("password"))
+				assert.NoError(t, err)
+			}()
+		}
+
+		<-h.started
+		<-h.started
+
+		require.Eventually(t, func() bool {
+			return s.Stats().QueueDepth == 1
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 2, s.Stats().Running)
+
+		close(h.release)
+		wg.Wait()
+
+		stats := s.Stats()
+		assert.Equal(t, 0, stats.Running)
+		assert.Equal(t, 0, stats.QueueDepth)
+		assert.Equal(t, uint64(3), stats.Completed)
+	})
+
+	t.Run("max memory", func(t *testing.T) {
+		h := newBlockingHasher(1024)
+		s := hash.NewScheduler(h, hash.HasherSchedulerConfig{
+			MaxMemory: 1536,
+		})
+
+		done := make(chan struct{})
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := s.Generate(ctx, []byte("password"))
+				assert.NoError(t, err)
+				done <- struct{}{}
+			}()
+		}
+
+		<-h.started
+
+		require.Eventually(t, func() bool {
+			return s.Stats().QueueDepth == 1
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, uint64(1024), s.Stats().MemoryInUse)
+
+		close(h.release)
+		<-done
+		<-done
+
+		assert.Equal(t, uint64(0), s.Stats().MemoryInUse)
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		h := newBlockingHasher(0)
+		s := hash.NewScheduler(h, hash.HasherSchedulerConfig{
+			MaxConcurrent: 1,
+			MaxQueue:      1,
+		})
+
+		done := make(chan struct{})
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := s.Generate(ctx, []byte("password"))
+				assert.NoError(t, err)
+				done <- struct{}{}
+			}()
+		}
+
+		require.Eventually(t, func() bool {
+			return s.Stats().QueueDepth == 1
+		}, time.Second, time.Millisecond)
+
+		_, err := s.Generate(ctx, []byte("password"))
+		assert.ErrorIs(t, err, hash.ErrHasherSaturated)
+		assert.Equal(t, uint64(1), s.Stats().Rejected)
+
+		close(h.release)
+		<-done
+		<-done
+	})
+
+	t.Run("queue timeout", func(t *testing.T) {
+		h := newBlockingHasher(0)
+		s := hash.NewScheduler(h, hash.HasherSchedulerConfig{
+			MaxConcurrent: 1,
+			QueueTimeout:  10 * time.Millisecond,
+		})
+
+		done := make(chan struct{})
+		go func() {
+			_, err := s.Generate(ctx, []byte("password"))
+			assert.NoError(t, err)
+			close(done)
+		}()
+
+		<-h.started
+
+		_, err := s.Generate(ctx, []byte("password"))
+		assert.ErrorIs(t, err, hash.ErrHasherSaturated)
+		assert.Equal(t, uint64(1), s.Stats().TimedOut)
+		assert.Equal(t, 0, s.Stats().QueueDepth)
+
+		close(h.release)
+		<-done
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		h := newBlockingHasher(0)
+		s := hash.NewScheduler(h, hash.HasherSchedulerConfig{
+			MaxConcurrent: 1,
+		})
+
+		done := make(chan struct{})
+		go func() {
+			_, err := s.Generate(ctx, []byte("password"))
+			assert.NoError(t, err)
+			close(done)
+		}()
+
+		<-h.started
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := s.Generate(cancelCtx, []byte("password"))
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, s.Stats().QueueDepth)
+
+		close(h.release)
+		<-done
+	})
+}
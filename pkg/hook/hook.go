@@ -0,0 +1,181 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/zbiljic/authzy"
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/webhook"
+	xhttp "github.com/zbiljic/authzy/pkg/http"
+	"github.com/zbiljic/authzy/pkg/logger"
+)
+
+// Name is the name of a hook.
+type Name string
+
+const (
+	BeforeSignup     Name = "before_signup"
+	BeforeTokenIssue Name = "before_token_issue"
+	AfterLogin       Name = "after_login"
+)
+
+// HookHeader is the request header holding the name of the called hook.
+const HookHeader = "X-Authzy-Hook"
+
+// maxResponseBodySize is the maximum size of the hook response body.
+const maxResponseBodySize = 1 << 20
+
+// ErrUnavailable is returned when a hook which does not fail open cannot be
+// reached, times out or responds with an error.
+var ErrUnavailable = errors.New("hook unavailable")
+
+// RejectedError is returned when the hook rejects the request.
+type RejectedError struct {
+	Hook    Name
+	Message string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("hook %s rejected the request: %s", e.Hook, e.Message)
+}
+
+// Request is the payload sent to the hook.
+type Request struct {
+	Hook      Name        `json:"hook"`
+	User      interface{} `json:"user"`
+	Claims    interface{} `json:"claims,omitempty"`
+	IP        string      `json:"ip,omitempty"`
+	UserAgent string      `json:"user_agent,omitempty"`
+}
+
+// Response is the response of the hook. Claims are only used by the
+// before_token_issue hook, and are added to the access token.
+type Response struct {
+	Reject       bool                   `json:"reject"`
+	Message      string                 `json:"message"`
+	Claims       map[string]interface{} `json:"claims"`
+	AppMetaData  map[string]interface{} `json:"app_metadata"`
+	UserMetaData map[string]interface{} `json:"user_metadata"`
+}
+
+// Caller calls the configured hooks.
+type Caller interface {
+	// Call calls the hook with the request. A nil response is returned if the
+	// hook is not configured, or if it fails open and could not be called.
+	Call(ctx context.Context, req *Request) (*Response, error)
+}
+
+type caller struct {
+	log    logger.Logger
+	config *config.HooksConfig
+	client *http.Client
+}
+
+// NewCaller returns a new hook caller.
+func NewCaller(log logger.Logger, config *config.HooksConfig) Caller {
+	return &caller{
+		log:    log,
+		config: config,
+		client: &http.Client{},
+	}
+}
+
+func (c *caller) hookConfig(name Name) *config.HookConfig {
+	if c.config == nil {
+		return nil
+	}
+
+	switch name {
+	case BeforeSignup:
+		return c.config.BeforeSignup
+	case BeforeTokenIssue:
+		return c.config.BeforeTokenIssue
+	case AfterLogin:
+		return c.config.AfterLogin
+	}
+
+	return nil
+}
+
+func (c *caller) Call(ctx context.Context, req *Request) (*Response, error) {
+	hc := c.hookConfig(req.Hook)
+	if hc == nil || hc.URL == "" {
+		return nil, nil
+	}
+
+	resp, err := c.call(ctx, hc, req)
+	if err != nil {
+		if hc.FailOpen {
+			c.log.WithContext(ctx).
+				WithFields(logger.Fields{"hook": req.Hook}).
+				Warnf("hook failed open: %v", err)
+
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%w: %s: %v", ErrUnavailable, req.Hook, err)
+	}
+
+	if resp.Reject {
+		return nil, &RejectedError{Hook: req.Hook, Message: resp.Message}
+	}
+
+	return resp, nil
+}
+
+func (c *caller) call(ctx context.Context, hc *config.HookConfig, req *Request) (*Response, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if hc.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hc.Timeout)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, hc.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set(xhttp.ContentType, "application/json")
+	httpReq.Header.Set(xhttp.UserAgent, authzy.AppName+"-hook")
+	httpReq.Header.Set(HookHeader, string(req.Hook))
+	if hc.Secret != "" {
+		httpReq.Header.Set(webhook.SignatureHeader, webhook.Sign(hc.Secret, time.Now(), payload))
+	}
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status code %d", httpResp.StatusCode)
+	}
+
+	resp := &Response{}
+
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, resp); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+	}
+
+	return resp, nil
+}
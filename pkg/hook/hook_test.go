@@ -0,0 +1,83 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/testutil"
+)
+
+func TestCall(t *testing.T) {
+	log := testutil.Logger{}
+	log.SetOutput(io.Discard)
+
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/reject":
+			w.Write([]byte(`{"reject": true, "message": "no"}`)) //nolint:errcheck
+		case "/invalid":
+			w.Write([]byte(`not json`)) //nolint:errcheck
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	call := func(url string, failOpen bool) (*Response, error) {
+		c := NewCaller(log, &config.HooksConfig{
+			AfterLogin: &config.HookConfig{URL: url, FailOpen: failOpen},
+		})
+
+		return c.Call(ctx, &Request{Hook: AfterLogin})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		resp, err := NewCaller(log, nil).Call(ctx, &Request{Hook: BeforeSignup})
+		assert.NoError(t, err)
+		assert.Nil(t, resp)
+
+		resp, err = call("", false)
+		assert.NoError(t, err)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		resp, err := call(srv.URL+"/empty", false)
+		require.NoError(t, err)
+		assert.NotNil(t, resp)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		// rejections do not fail open
+		_, err := call(srv.URL+"/reject", true)
+		require.Error(t, err)
+
+		var rejected *RejectedError
+		require.True(t, errors.As(err, &rejected))
+		assert.Equal(t, AfterLogin, rejected.Hook)
+		assert.Equal(t, "no", rejected.Message)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		for _, path := range []string{"/error", "/invalid"} {
+			_, err := call(srv.URL+path, false)
+			assert.True(t, errors.Is(err, ErrUnavailable), path)
+
+			resp, err := call(srv.URL+path, true)
+			assert.NoError(t, err, path)
+			assert.Nil(t, resp, path)
+		}
+	})
+}
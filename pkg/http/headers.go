@@ -2,14 +2,18 @@ package http
 
 // Standard HTTP response constants
 const (
-	Accept        = "Accept"
-	Authorization = "Authorization"
-	ContentType   = "Content-Type"
-	Cookie        = "Cookie"
-	Location      = "Location"
-	RetryAfter    = "Retry-After"
-	ServerInfo    = "Server"
-	SetCookie     = "Set-Cookie"
+	Accept             = "Accept"
+	Authorization      = "Authorization"
+	ContentDisposition = "Content-Disposition"
+	ContentType        = "Content-Type"
+	Cookie             = "Cookie"
+	ETag               = "ETag"
+	IfMatch            = "If-Match"
+	Location           = "Location"
+	RetryAfter         = "Retry-After"
+	ServerInfo         = "Server"
+	SetCookie          = "Set-Cookie"
+	UserAgent          = "User-Agent"
 )
 
 // Non standard HTTP response constants
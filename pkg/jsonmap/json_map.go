@@ -31,3 +31,34 @@ func (j JSONMap) Scan(src interface{}) error {
 	}
 	return json.Unmarshal(source, &j)
 }
+
+// Clone returns a deep copy of the map, so changes to the copy do not change
+// the original.
+func (j JSONMap) Clone() JSONMap {
+	if j == nil {
+		return nil
+	}
+
+	return cloneValue(map[string]interface{}(j)).(map[string]interface{})
+}
+
+func cloneValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case JSONMap:
+		return JSONMap(cloneValue(map[string]interface{}(value)).(map[string]interface{}))
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			out[k] = cloneValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = cloneValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
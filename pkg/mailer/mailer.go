@@ -2,8 +2,10 @@ package mailer
 
 import (
 	"net/url"
+	"time"
 
 	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
@@ -25,6 +27,13 @@ type Mailer interface {
 
 	// EmailChangeMail sends an email change confirmation mail to a user.
 	EmailChangeMail(user *user.User, referrerURL string) error
+
+	// RestorationMail sends the link restoring the account of a user, whose
+	// deletion is scheduled at the given time.
+	RestorationMail(user *user.User, deletionAt time.Time, referrerURL string) error
+
+	// InvitationMail sends an invitation to join the organization.
+	InvitationMail(invitation *organization.Invitation, org *organization.Organization, referrerURL string) error
 }
 
 // NewMailer returns a new mailer.
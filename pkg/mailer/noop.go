@@ -1,6 +1,11 @@
 package mailer
 
-import "github.com/zbiljic/authzy/pkg/domain/user"
+import (
+	"time"
+
+	"github.com/zbiljic/authzy/pkg/domain/organization"
+	"github.com/zbiljic/authzy/pkg/domain/user"
+)
 
 // Compile-time proof of interface implementation.
 var _ Mailer = (*noopMailer)(nil)
@@ -26,3 +31,11 @@ func (*noopMailer) RecoveryMail(user *user.User, referrerURL string) error {
 func (*noopMailer) EmailChangeMail(user *user.User, referrerURL string) error {
 	return nil
 }
+
+func (*noopMailer) RestorationMail(user *user.User, deletionAt time.Time, referrerURL string) error {
+	return nil
+}
+
+func (*noopMailer) InvitationMail(invitation *organization.Invitation, org *organization.Organization, referrerURL string) error {
+	return nil
+}
@@ -3,10 +3,12 @@ package mailer
 import (
 	_ "embed" // Remove this line to disable files embedding.
 	"net/url"
+	"time"
 
 	"github.com/netlify/mailme"
 
 	"github.com/zbiljic/authzy/pkg/config"
+	"github.com/zbiljic/authzy/pkg/domain/organization"
 	"github.com/zbiljic/authzy/pkg/domain/user"
 	"github.com/zbiljic/authzy/pkg/logger"
 )
@@ -27,6 +29,12 @@ var defaultRecoveryMail string
 //go:embed templates/defaultEmailChangeMail.go.html
 var defaultEmailChangeMail string
 
+//go:embed templates/defaultRestorationMail.go.html
+var defaultRestorationMail string
+
+//go:embed templates/defaultInvitationMail.go.html
+var defaultInvitationMail string
+
 func newTemplateMailer(log logger.Logger, config *config.Config) Mailer {
 	return &templateMailer{
 		validateMailer: validateMailer{config: config.API.Mailer},
@@ -143,3 +151,70 @@ func (m *templateMailer) EmailChangeMail(user *user.User, referrerURL string) er
 		data,
 	)
 }
+
+func (m *templateMailer) RestorationMail(user *user.User, deletionAt time.Time, referrerURL string) error {
+	query := url.Values{}
+	query.Add("type", "restore")
+	query.Add("token", user.DeletionToken)
+	if len(referrerURL) > 0 {
+		query.Add("redirect_to", referrerURL)
+	}
+
+	url, err := getSiteURL(referrerURL, m.Config.API.ExternalURL, m.Config.API.Mailer.URLPaths.Restoration, query.Encode())
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"SiteURL":         m.Config.SiteURL,
+		"ConfirmationURL": url,
+		"Email":           user.Email,
+		"Token":           user.DeletionToken,
+		"DeletionAt":      deletionAt.UTC().Format(time.RFC1123),
+		"Data":            user.UserMetaData,
+	}
+
+	return m.Mailer.Mail(
+		user.Email,
+		withDefault(m.Config.API.Mailer.Subjects.Restoration, "Restore Your Account"),
+		m.Config.API.Mailer.Templates.Restoration,
+		defaultRestorationMail,
+		data,
+	)
+}
+
+func (m *templateMailer) InvitationMail(invitation *organization.Invitation, org *organization.Organization, referrerURL string) error {
+	query := url.Values{}
+	query.Add("type", "invite")
+	query.Add("token", invitation.Token)
+	if len(referrerURL) > 0 {
+		query.Add("redirect_to", referrerURL)
+	}
+
+	url, err := getSiteURL(referrerURL, m.Config.SiteURL, m.Config.API.Mailer.URLPaths.Invitation, query.Encode())
+	if err != nil {
+		return err
+	}
+
+	organizationName := org.DisplayName
+	if organizationName == "" {
+		organizationName = org.Name
+	}
+
+	data := map[string]interface{}{
+		"SiteURL":          m.Config.SiteURL,
+		"ConfirmationURL":  url,
+		"Email":            invitation.Email,
+		"Token":            invitation.Token,
+		"OrganizationName": organizationName,
+		"Data":             org.MetaData,
+	}
+
+	return m.Mailer.Mail(
+		invitation.Email,
+		withDefault(m.Config.API.Mailer.Subjects.Invitation, "You Have Been Invited"),
+		m.Config.API.Mailer.Templates.Invitation,
+		defaultInvitationMail,
+		data,
+	)
+}
@@ -0,0 +1,134 @@
+package tenant
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "tenant context key " + string(c)
+}
+
+const tenantIDKey = contextKey("tenant_id")
+
+// WithID adds the tenant ID to the context.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, id)
+}
+
+// IDFromContext reads the tenant ID from the context. It is empty for
+// requests served by the process-wide configuration.
+func IDFromContext(ctx context.Context) string {
+	if obj := ctx.Value(tenantIDKey); obj != nil {
+		return obj.(string)
+	}
+	return ""
+}
+
+// Tenant is a tenant served by the router.
+type Tenant struct {
+	ID         string
+	Hosts      []string
+	PathPrefix string
+	Handler    http.Handler
+}
+
+type router struct {
+	fallback     http.Handler
+	hosts        map[string]*Tenant
+	pathPrefixes []*Tenant
+}
+
+// NewRouter creates a handler which resolves the tenant of the request,
+// first by the host header and then by the path prefix, which is stripped
+// before the request is handed over. Requests which do not belong to any of
+// the tenants are served by the fallback handler.
+func NewRouter(fallback http.Handler, tenants ...*Tenant) http.Handler {
+	r := &router{
+		fallback: fallback,
+		hosts:    make(map[string]*Tenant),
+	}
+
+	for _, t := range tenants {
+		for _, host := range t.Hosts {
+			r.hosts[strings.ToLower(host)] = t
+		}
+
+		if t.PathPrefix != "" {
+			r.pathPrefixes = append(r.pathPrefixes, t)
+		}
+	}
+
+	// the longest prefix wins
+	sort.SliceStable(r.pathPrefixes, func(i, j int) bool {
+		return len(r.pathPrefixes[i].PathPrefix) > len(r.pathPrefixes[j].PathPrefix)
+	})
+
+	return r
+}
+
+func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if t := r.resolveHost(req.Host); t != nil {
+		r.serve(w, req, t)
+		return
+	}
+
+	for _, t := range r.pathPrefixes {
+		if path, ok := stripPathPrefix(req.URL.Path, t.PathPrefix); ok {
+			req2 := req.Clone(req.Context())
+			req2.URL.Path = path
+			req2.URL.RawPath = ""
+			req2.RequestURI = req2.URL.RequestURI()
+			r.serve(w, req2, t)
+			return
+		}
+	}
+
+	r.fallback.ServeHTTP(w, req)
+}
+
+func (r *router) serve(w http.ResponseWriter, req *http.Request, t *Tenant) {
+	ctx := WithID(req.Context(), t.ID)
+	t.Handler.ServeHTTP(w, req.WithContext(ctx))
+}
+
+// resolveHost finds the tenant by the host, with or without the port.
+func (r *router) resolveHost(host string) *Tenant {
+	host = strings.ToLower(host)
+	if t, ok := r.hosts[host]; ok {
+		return t
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		if t, ok := r.hosts[h]; ok {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// stripPathPrefix removes the prefix from the path, if the path is the
+// prefix itself or continues with a path segment.
+func stripPathPrefix(path, prefix string) (string, bool) {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+
+	rest := path[len(prefix):]
+	switch {
+	case rest == "":
+		return "/", true
+	case strings.HasPrefix(rest, "/"):
+		return rest, true
+	default:
+		return "", false
+	}
+}
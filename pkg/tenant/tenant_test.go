@@ -0,0 +1,65 @@
+package tenant_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/authzy/pkg/tenant"
+)
+
+func handler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s:%s:%s", name, tenant.IDFromContext(r.Context()), r.URL.Path)
+	})
+}
+
+func TestRouter(t *testing.T) {
+	router := tenant.NewRouter(handler("default"),
+		&tenant.Tenant{
+			ID:      "acme",
+			Hosts:   []string{"acme.example.com"},
+			Handler: handler("acme"),
+		},
+		&tenant.Tenant{
+			ID:         "globex",
+			PathPrefix: "/globex",
+			Handler:    handler("globex"),
+		},
+		&tenant.Tenant{
+			ID:         "globexeu",
+			PathPrefix: "/globex/eu/",
+			Handler:    handler("globexeu"),
+		},
+	)
+
+	tests := []struct {
+		host string
+		path string
+		want string
+	}{
+		{"example.com", "/token", "default::/token"},
+		{"acme.example.com", "/token", "acme:acme:/token"},
+		{"ACME.example.com:8080", "/token", "acme:acme:/token"},
+		{"acme.example.com", "/globex/token", "acme:acme:/globex/token"},
+		{"example.com", "/globex/token", "globex:globex:/token"},
+		{"example.com", "/globex", "globex:globex:/"},
+		{"example.com", "/globexeu/token", "default::/globexeu/token"},
+		{"example.com", "/globex/eu/token", "globexeu:globexeu:/token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Host = tt.host
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
@@ -3,6 +3,7 @@ package ulid
 import (
 	cryptorand "crypto/rand"
 	mathrand "math/rand"
+	"sync"
 	"time"
 
 	"github.com/oklog/ulid"
@@ -14,6 +15,22 @@ func ULID() ulid.ULID {
 	return ulid.MustNew(ulid.Timestamp(time.Now()), entropy)
 }
 
+// Monotonic returns a generator of ULIDs which are strictly increasing, even
+// when generated within the same millisecond, as long as the time given to
+// the generator does not go backwards.
+func Monotonic() func(t time.Time) ulid.ULID {
+	var mu sync.Mutex
+
+	entropy := ulid.Monotonic(cryptorand.Reader, 0)
+
+	return func(t time.Time) ulid.ULID {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return ulid.MustNew(ulid.Timestamp(t), entropy)
+	}
+}
+
 //nolint:gosec
 func QuickULID() ulid.ULID {
 	seed := time.Now().UnixNano()